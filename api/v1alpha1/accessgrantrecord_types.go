@@ -0,0 +1,109 @@
+package v1alpha1
+
+import (
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+// AccessGrantAction is the change to a user's access an AccessGrantRecord records
+// +kubebuilder:validation:Enum=Granted;Changed;Removed;CredentialIssued;CredentialsRevoked
+type AccessGrantAction string
+
+const (
+	// AccessGrantGranted is recorded when a binding for the user was created
+	AccessGrantGranted AccessGrantAction = "Granted"
+	// AccessGrantChanged is recorded when a binding for the user was updated, e.g. its subjects
+	AccessGrantChanged AccessGrantAction = "Changed"
+	// AccessGrantRemoved is recorded when a binding for the user was deleted
+	AccessGrantRemoved AccessGrantAction = "Removed"
+	// AccessGrantCredentialIssued is recorded for every certificate issued for the user,
+	// including rotations
+	AccessGrantCredentialIssued AccessGrantAction = "CredentialIssued"
+	// AccessGrantCredentialsRevoked is recorded when the user's credentials were revoked
+	AccessGrantCredentialsRevoked AccessGrantAction = "CredentialsRevoked"
+)
+
+// GrantedBinding is a binding KubeUser created for a user and the role it grants
+type GrantedBinding struct {
+	// Kind of the binding, RoleBinding or ClusterRoleBinding
+	Kind string `json:"kind"`
+
+	// Namespace of a RoleBinding; empty for ClusterRoleBindings
+	// +optional
+	Namespace string `json:"namespace,omitempty"`
+
+	// Name of the binding
+	Name string `json:"name"`
+
+	// RoleKind is the kind of the bound role, Role or ClusterRole
+	RoleKind string `json:"roleKind"`
+
+	// RoleName is the name of the bound role
+	RoleName string `json:"roleName"`
+}
+
+// AccessGrantRecordSpec describes one change to a user's access
+type AccessGrantRecordSpec struct {
+	// User is the name of the User whose access changed
+	User string `json:"user"`
+
+	// Action is what changed
+	Action AccessGrantAction `json:"action"`
+
+	// Time is when the controller made the change
+	Time metav1.Time `json:"time"`
+
+	// ChangedBy is the identity that last changed the User's spec before the change, as
+	// recorded by the admission webhook. For revocations it is who set spec.revoked.
+	// +optional
+	ChangedBy string `json:"changedBy,omitempty"`
+
+	// UserGeneration is the generation of the User the change was made for
+	// +optional
+	UserGeneration int64 `json:"userGeneration,omitempty"`
+
+	// Binding is the binding that was created, changed or removed
+	// +optional
+	Binding *GrantedBinding `json:"binding,omitempty"`
+
+	// SerialNumber is the serial number, in lowercase hex, of the certificate that was issued,
+	// or of the user's certificate when the change was made
+	// +optional
+	SerialNumber string `json:"serialNumber,omitempty"`
+
+	// Reason explains removals and revocations: UserRevoked, UserSuspended or UserDeleted
+	// +optional
+	Reason string `json:"reason,omitempty"`
+}
+
+// +kubebuilder:object:root=true
+// +kubebuilder:resource:scope=Cluster
+// +kubebuilder:printcolumn:name="User",type="string",JSONPath=".spec.user",description="User whose access changed"
+// +kubebuilder:printcolumn:name="Action",type="string",JSONPath=".spec.action",description="What changed"
+// +kubebuilder:printcolumn:name="Role",type="string",JSONPath=".spec.binding.roleName",description="Role the binding grants"
+// +kubebuilder:printcolumn:name="Namespace",type="string",JSONPath=".spec.binding.namespace",description="Namespace of the binding"
+// +kubebuilder:printcolumn:name="By",type="string",JSONPath=".spec.changedBy",description="Who changed the User"
+// +kubebuilder:printcolumn:name="Time",type="date",JSONPath=".spec.time",description="When the access changed"
+
+// AccessGrantRecord is an append-only record of a change to a user's access: a binding that was
+// created, changed or removed, a certificate that was issued or credentials that were revoked.
+// Records outlive their User and are deleted after the controller's retention period.
+type AccessGrantRecord struct {
+	metav1.TypeMeta   `json:",inline"`
+	metav1.ObjectMeta `json:"metadata,omitempty"`
+
+	// +kubebuilder:validation:XValidation:rule="self == oldSelf",message="access grant records are immutable"
+	Spec AccessGrantRecordSpec `json:"spec"`
+}
+
+// +kubebuilder:object:root=true
+
+// AccessGrantRecordList contains a list of AccessGrantRecord
+type AccessGrantRecordList struct {
+	metav1.TypeMeta `json:",inline"`
+	metav1.ListMeta `json:"metadata,omitempty"`
+	Items           []AccessGrantRecord `json:"items"`
+}
+
+func init() {
+	SchemeBuilder.Register(&AccessGrantRecord{}, &AccessGrantRecordList{})
+}
@@ -0,0 +1,106 @@
+package v1alpha1
+
+import (
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+// AccessReportFormat is an encoding the report is written in
+// +kubebuilder:validation:Enum=JSON;CSV
+type AccessReportFormat string
+
+const (
+	// AccessReportJSON writes the report as report.json, one object per user
+	AccessReportJSON AccessReportFormat = "JSON"
+	// AccessReportCSV writes the report as report.csv, one row per user and binding
+	AccessReportCSV AccessReportFormat = "CSV"
+)
+
+// AccessReportSpec declares when an access review report is generated and what it covers
+type AccessReportSpec struct {
+	// Schedule is a cron expression for when the report is generated, e.g. "0 6 1 * *" for
+	// the first of every month. A report is also generated right after the AccessReport is
+	// created.
+	// +kubebuilder:validation:MinLength=1
+	Schedule string `json:"schedule"`
+
+	// TimeZone is the IANA time zone the schedule is evaluated in, e.g. "Europe/Berlin".
+	// Defaults to UTC.
+	// +optional
+	TimeZone string `json:"timeZone,omitempty"`
+
+	// Formats the report is written in. Defaults to JSON.
+	// +optional
+	// +listType=set
+	Formats []AccessReportFormat `json:"formats,omitempty"`
+
+	// Selector limits the report to Users with matching labels. All Users are included when
+	// it is not set.
+	// +optional
+	Selector *metav1.LabelSelector `json:"selector,omitempty"`
+
+	// HistoryLimit is how many generated reports are kept. Defaults to 12.
+	// +optional
+	// +kubebuilder:validation:Minimum=1
+	HistoryLimit *int32 `json:"historyLimit,omitempty"`
+}
+
+// AccessReportStatus records the reports generated
+type AccessReportStatus struct {
+	// ObservedGeneration is the generation last reconciled by the controller
+	// +optional
+	ObservedGeneration int64 `json:"observedGeneration,omitempty"`
+
+	// LastReport is the name of the ConfigMap holding the latest report, in the namespace of
+	// the controller's per-user resources
+	// +optional
+	LastReport string `json:"lastReport,omitempty"`
+
+	// LastReportTime is when the latest report was generated
+	// +optional
+	LastReportTime *metav1.Time `json:"lastReportTime,omitempty"`
+
+	// NextReportTime is when the next report is due
+	// +optional
+	NextReportTime *metav1.Time `json:"nextReportTime,omitempty"`
+
+	// Users is the number of Users in the latest report
+	// +optional
+	Users int32 `json:"users,omitempty"`
+
+	// Conditions follow Kubernetes conventions; Ready is false while the schedule is invalid or
+	// the report cannot be written
+	// +optional
+	Conditions []metav1.Condition `json:"conditions,omitempty"`
+}
+
+// +kubebuilder:object:root=true
+// +kubebuilder:subresource:status
+// +kubebuilder:resource:scope=Cluster
+// +kubebuilder:printcolumn:name="Schedule",type="string",JSONPath=".spec.schedule",description="When the report is generated"
+// +kubebuilder:printcolumn:name="Last Report",type="string",JSONPath=".status.lastReport",description="ConfigMap holding the latest report"
+// +kubebuilder:printcolumn:name="Users",type="integer",JSONPath=".status.users",description="Users in the latest report"
+// +kubebuilder:printcolumn:name="Next",type="date",JSONPath=".status.nextReportTime",description="When the next report is due"
+// +kubebuilder:printcolumn:name="Ready",type="string",JSONPath=".status.conditions[?(@.type==\"Ready\")].status",description="Whether the latest report was written"
+
+// AccessReport periodically writes a consolidated access review report of every user, their
+// effective bindings, expiry and team memberships to a ConfigMap
+type AccessReport struct {
+	metav1.TypeMeta   `json:",inline"`
+	metav1.ObjectMeta `json:"metadata,omitempty"`
+
+	Spec   AccessReportSpec   `json:"spec,omitempty"`
+	Status AccessReportStatus `json:"status,omitempty"`
+}
+
+// +kubebuilder:object:root=true
+
+// AccessReportList contains a list of AccessReport
+type AccessReportList struct {
+	metav1.TypeMeta `json:",inline"`
+	metav1.ListMeta `json:"metadata,omitempty"`
+	Items           []AccessReport `json:"items"`
+}
+
+func init() {
+	SchemeBuilder.Register(&AccessReport{}, &AccessReportList{})
+}
@@ -0,0 +1,164 @@
+package v1alpha1
+
+import (
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+// RoleSelector matches Roles or ClusterRoles by name or by label
+// +kubebuilder:validation:XValidation:rule="has(self.names) || has(self.selector)",message="names or selector must be set"
+type RoleSelector struct {
+	// Names matches roles with one of these names
+	// +optional
+	// +listType=set
+	Names []string `json:"names,omitempty"`
+
+	// Selector matches roles by their labels
+	// +optional
+	Selector *metav1.LabelSelector `json:"selector,omitempty"`
+}
+
+// RoleRules decides which roles may be bound. A role matching a deny entry is refused. When
+// allow entries are given, a role must also match one of them.
+type RoleRules struct {
+	// Allow lists the roles that may be bound; all roles are allowed when empty
+	// +optional
+	Allow []RoleSelector `json:"allow,omitempty"`
+
+	// Deny lists roles that may never be bound. It takes precedence over Allow.
+	// +optional
+	Deny []RoleSelector `json:"deny,omitempty"`
+}
+
+// NamespaceSelector matches namespaces by name or by label
+// +kubebuilder:validation:XValidation:rule="has(self.names) || has(self.selector)",message="names or selector must be set"
+type NamespaceSelector struct {
+	// Names matches namespaces with one of these names
+	// +optional
+	// +listType=set
+	Names []string `json:"names,omitempty"`
+
+	// Selector matches namespaces by their labels
+	// +optional
+	Selector *metav1.LabelSelector `json:"selector,omitempty"`
+}
+
+// NamespaceRules decides which namespaces Roles may be granted in. A namespace matching a deny
+// entry is refused. When allow entries are given, a namespace must also match one of them.
+type NamespaceRules struct {
+	// Allow lists the namespaces Roles may be granted in; all namespaces are allowed when empty
+	// +optional
+	Allow []NamespaceSelector `json:"allow,omitempty"`
+
+	// Deny lists namespaces Roles may never be granted in. It takes precedence over Allow.
+	// +optional
+	Deny []NamespaceSelector `json:"deny,omitempty"`
+}
+
+// ValueRules decides which values may be requested. A value matching a deny pattern is refused.
+// When allow patterns are given, a value must also match one of them. In patterns * matches any
+// characters, e.g. *@example.com or spiffe://example.com/*.
+type ValueRules struct {
+	// Allow lists the patterns of values that may be requested; all values are allowed when empty
+	// +optional
+	// +listType=set
+	Allow []string `json:"allow,omitempty"`
+
+	// Deny lists the patterns of values that may never be requested. It takes precedence over Allow.
+	// +optional
+	// +listType=set
+	Deny []string `json:"deny,omitempty"`
+}
+
+// CertificateRules restricts the subject attributes and SANs Users add to their client
+// certificate in spec.certificate
+type CertificateRules struct {
+	// Organizations restricts spec.certificate.organizations, which the API server takes as groups
+	// +optional
+	Organizations ValueRules `json:"organizations,omitempty"`
+
+	// OrganizationalUnits restricts spec.certificate.organizationalUnits
+	// +optional
+	OrganizationalUnits ValueRules `json:"organizationalUnits,omitempty"`
+
+	// EmailAddresses restricts spec.certificate.emailAddresses
+	// +optional
+	EmailAddresses ValueRules `json:"emailAddresses,omitempty"`
+
+	// DNSNames restricts spec.certificate.dnsNames
+	// +optional
+	DNSNames ValueRules `json:"dnsNames,omitempty"`
+
+	// URIs restricts spec.certificate.uris
+	// +optional
+	URIs ValueRules `json:"uris,omitempty"`
+}
+
+// PolicyScope limits a ClusterPolicy to some grants. Unset fields match everything.
+type PolicyScope struct {
+	// Namespaces limits the roles rules to Role grants in these namespaces
+	// +optional
+	// +listType=set
+	Namespaces []string `json:"namespaces,omitempty"`
+
+	// Users limits the policy to Users created or changed by these identities
+	// +optional
+	// +listType=set
+	Users []string `json:"users,omitempty"`
+
+	// Groups limits the policy to Users created or changed by members of these groups
+	// +optional
+	// +listType=set
+	Groups []string `json:"groups,omitempty"`
+}
+
+// ClusterPolicySpec restricts the Roles and ClusterRoles KubeUser binds to users, and the
+// certificate subjects they request
+type ClusterPolicySpec struct {
+	// Scope limits which grants the policy applies to
+	// +optional
+	Scope PolicyScope `json:"scope,omitempty"`
+
+	// ClusterRoles restricts the ClusterRoles granted in spec.clusterRoles of Users
+	// +optional
+	ClusterRoles RoleRules `json:"clusterRoles,omitempty"`
+
+	// Roles restricts the Roles granted in spec.roles of Users
+	// +optional
+	Roles RoleRules `json:"roles,omitempty"`
+
+	// Namespaces restricts the namespaces of the Roles granted in spec.roles of Users. Scoped to
+	// users or groups, it confines those creators to their tenant's namespaces.
+	// +optional
+	Namespaces NamespaceRules `json:"namespaces,omitempty"`
+
+	// Certificate restricts the subject attributes and SANs Users request in spec.certificate.
+	// The namespaces of the scope do not limit it.
+	// +optional
+	Certificate CertificateRules `json:"certificate,omitempty"`
+}
+
+// +kubebuilder:object:root=true
+// +kubebuilder:resource:scope=Cluster
+// +kubebuilder:printcolumn:name="Age",type="date",JSONPath=".metadata.creationTimestamp",description="Time since the policy was created"
+
+// ClusterPolicy restricts which roles KubeUser may bind. Every policy that applies to a grant
+// must allow it.
+type ClusterPolicy struct {
+	metav1.TypeMeta   `json:",inline"`
+	metav1.ObjectMeta `json:"metadata,omitempty"`
+
+	Spec ClusterPolicySpec `json:"spec,omitempty"`
+}
+
+// +kubebuilder:object:root=true
+
+// ClusterPolicyList contains a list of ClusterPolicy
+type ClusterPolicyList struct {
+	metav1.TypeMeta `json:",inline"`
+	metav1.ListMeta `json:"metadata,omitempty"`
+	Items           []ClusterPolicy `json:"items"`
+}
+
+func init() {
+	SchemeBuilder.Register(&ClusterPolicy{}, &ClusterPolicyList{})
+}
@@ -0,0 +1,81 @@
+package v1alpha1
+
+import (
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+// Reasons a certificate was revoked, named after the CRL reason codes of RFC 5280
+const (
+	// RevocationReasonSuperseded is recorded when the user was issued a newer certificate
+	RevocationReasonSuperseded = "Superseded"
+	// RevocationReasonUserRevoked is recorded when spec.revoked was set on the User
+	RevocationReasonUserRevoked = "UserRevoked"
+	// RevocationReasonUserDeleted is recorded when the User was deleted
+	RevocationReasonUserDeleted = "UserDeleted"
+)
+
+// IssuedCertificateSpec describes a client certificate KubeUser issued
+type IssuedCertificateSpec struct {
+	// User is the name of the User the certificate was issued for
+	User string `json:"user"`
+
+	// SerialNumber is the certificate's serial number in lowercase hex
+	SerialNumber string `json:"serialNumber"`
+
+	// CommonName is the certificate's subject common name, the user name the API server authenticates
+	CommonName string `json:"commonName"`
+
+	// NotBefore is when the certificate becomes valid
+	NotBefore metav1.Time `json:"notBefore"`
+
+	// NotAfter is when the certificate expires
+	NotAfter metav1.Time `json:"notAfter"`
+
+	// CSRName is the CertificateSigningRequest or CertificateRequest the certificate was issued through
+	CSRName string `json:"csrName"`
+}
+
+// IssuedCertificateStatus records whether KubeUser still stands behind the certificate
+type IssuedCertificateStatus struct {
+	// RevokedAt is when the certificate was revoked. Kubernetes keeps accepting it until it
+	// expires, but it no longer grants the user's access.
+	// +optional
+	RevokedAt *metav1.Time `json:"revokedAt,omitempty"`
+
+	// Reason the certificate was revoked
+	// +kubebuilder:validation:Enum=Superseded;UserRevoked;UserDeleted
+	// +optional
+	Reason string `json:"reason,omitempty"`
+}
+
+// +kubebuilder:object:root=true
+// +kubebuilder:subresource:status
+// +kubebuilder:resource:scope=Cluster
+// +kubebuilder:printcolumn:name="User",type="string",JSONPath=".spec.user",description="User the certificate was issued for"
+// +kubebuilder:printcolumn:name="Serial",type="string",JSONPath=".spec.serialNumber",description="Certificate serial number"
+// +kubebuilder:printcolumn:name="Not After",type="date",JSONPath=".spec.notAfter",description="When the certificate expires"
+// +kubebuilder:printcolumn:name="Revoked",type="string",JSONPath=".status.reason",description="Why the certificate was revoked"
+// +kubebuilder:printcolumn:name="Age",type="date",JSONPath=".metadata.creationTimestamp",description="Time since the certificate was issued"
+
+// IssuedCertificate records a client certificate KubeUser issued. It outlives its User, so
+// certificates that are still valid can be found after the User is gone.
+type IssuedCertificate struct {
+	metav1.TypeMeta   `json:",inline"`
+	metav1.ObjectMeta `json:"metadata,omitempty"`
+
+	Spec   IssuedCertificateSpec   `json:"spec,omitempty"`
+	Status IssuedCertificateStatus `json:"status,omitempty"`
+}
+
+// +kubebuilder:object:root=true
+
+// IssuedCertificateList contains a list of IssuedCertificate
+type IssuedCertificateList struct {
+	metav1.TypeMeta `json:",inline"`
+	metav1.ListMeta `json:"metadata,omitempty"`
+	Items           []IssuedCertificate `json:"items"`
+}
+
+func init() {
+	SchemeBuilder.Register(&IssuedCertificate{}, &IssuedCertificateList{})
+}
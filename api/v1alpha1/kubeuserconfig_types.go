@@ -0,0 +1,289 @@
+package v1alpha1
+
+import (
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+// KubeUserConfigName is the name of the KubeUserConfig the controller reads; others are ignored
+const KubeUserConfigName = "default"
+
+// RotateAllAnnotation on the KubeUserConfig starts a bulk rotation: every User gets a new key
+// and certificate, or a new token for machine users, in batches. Its value names the rotation;
+// setting a new value starts another one.
+const RotateAllAnnotation = "auth.openkube.io/rotate-all"
+
+// KeyAlgorithm is the algorithm of private keys generated for users
+// +kubebuilder:validation:Enum=RSA2048;RSA4096;ECDSAP256;ECDSAP384
+type KeyAlgorithm string
+
+const (
+	// KeyAlgorithmRSA2048 is a 2048-bit RSA key
+	KeyAlgorithmRSA2048 KeyAlgorithm = "RSA2048"
+	// KeyAlgorithmRSA4096 is a 4096-bit RSA key
+	KeyAlgorithmRSA4096 KeyAlgorithm = "RSA4096"
+	// KeyAlgorithmECDSAP256 is an ECDSA key on the P-256 curve
+	KeyAlgorithmECDSAP256 KeyAlgorithm = "ECDSAP256"
+	// KeyAlgorithmECDSAP384 is an ECDSA key on the P-384 curve
+	KeyAlgorithmECDSAP384 KeyAlgorithm = "ECDSAP384"
+)
+
+// NotificationSink is a destination for user lifecycle notifications
+type NotificationSink struct {
+	// Name identifies the sink in logs and status
+	// +kubebuilder:validation:MinLength=1
+	// +kubebuilder:validation:MaxLength=63
+	Name string `json:"name"`
+
+	// Channel selects the delivery channel and the notification templates used for it
+	// +kubebuilder:validation:Enum=slack;email;webhook
+	Channel string `json:"channel"`
+
+	// SecretRef names a Secret in the KubeUser namespace holding the sink's endpoint and
+	// credentials, e.g. a Slack webhook URL or SMTP login
+	// +optional
+	SecretRef string `json:"secretRef,omitempty"`
+
+	// Events limits the sink to these event types; all events are sent when empty
+	// +optional
+	// +listType=set
+	Events []string `json:"events,omitempty"`
+
+	// AttachKubeconfig makes an email sink attach the user's kubeconfig to the provisioned
+	// notification, encrypted with a passphrase kept in a per-user Secret
+	// +optional
+	AttachKubeconfig bool `json:"attachKubeconfig,omitempty"`
+}
+
+// CredentialStore is an external secret store that receives a copy of every user's credential
+// Secret, so credentials can be handed out through it instead of the cluster
+// +kubebuilder:validation:XValidation:rule="[has(self.vault), has(self.awsSecretsManager), has(self.azureKeyVault)].filter(x, x).size() == 1",message="exactly one of vault, awsSecretsManager and azureKeyVault must be set"
+type CredentialStore struct {
+	// Name identifies the store in logs and Events
+	// +kubebuilder:validation:MinLength=1
+	// +kubebuilder:validation:MaxLength=63
+	Name string `json:"name"`
+
+	// Path is where each user's credentials are written, a Go template rendered with the user
+	// name as .User
+	// +kubebuilder:validation:MinLength=1
+	Path string `json:"path"`
+
+	// SecretRef names a Secret in the KubeUser namespace holding static credentials for an AWS
+	// or Azure store. Without it the controller's workload identity is used.
+	// +optional
+	SecretRef string `json:"secretRef,omitempty"`
+
+	// Vault writes to a KV version 2 secrets engine, through the operator's --vault-* connection
+	// +optional
+	Vault *VaultKVStore `json:"vault,omitempty"`
+
+	// AWSSecretsManager writes to AWS Secrets Manager
+	// +optional
+	AWSSecretsManager *AWSSecretsManagerStore `json:"awsSecretsManager,omitempty"`
+
+	// AzureKeyVault writes to an Azure Key Vault
+	// +optional
+	AzureKeyVault *AzureKeyVaultStore `json:"azureKeyVault,omitempty"`
+}
+
+// VaultKVStore is a Vault KV version 2 secrets engine
+type VaultKVStore struct {
+	// Mount is the mount path of the secrets engine. Defaults to secret.
+	// +optional
+	Mount string `json:"mount,omitempty"`
+}
+
+// AWSSecretsManagerStore is AWS Secrets Manager in one region
+type AWSSecretsManagerStore struct {
+	// Region of the secrets, e.g. eu-west-1
+	// +kubebuilder:validation:MinLength=1
+	Region string `json:"region"`
+}
+
+// AzureKeyVaultStore is an Azure Key Vault
+type AzureKeyVaultStore struct {
+	// VaultURL is the URL of the key vault, e.g. https://example.vault.azure.net
+	// +kubebuilder:validation:Pattern=`^https://`
+	VaultURL string `json:"vaultURL"`
+}
+
+// KubeUserConfigSpec holds operator-wide defaults. Unset fields keep the value given by the
+// controller's flags.
+type KubeUserConfigSpec struct {
+	// CertificateDuration is the requested lifetime of user certificates. The issuer may cap it.
+	// +optional
+	CertificateDuration *metav1.Duration `json:"certificateDuration,omitempty"`
+
+	// RotationThreshold is how long before expiry a user certificate is renewed
+	// +optional
+	RotationThreshold *metav1.Duration `json:"rotationThreshold,omitempty"`
+
+	// RotationThresholdPercent renews a user certificate once less than this percentage of its
+	// lifetime remains, instead of rotationThreshold before expiry. 75 renews 24h certificates
+	// after 6h.
+	// +optional
+	// +kubebuilder:validation:Minimum=1
+	// +kubebuilder:validation:Maximum=99
+	RotationThresholdPercent *int32 `json:"rotationThresholdPercent,omitempty"`
+
+	// KeyAlgorithm is used for private keys generated from now on. Existing keys are kept.
+	// +optional
+	KeyAlgorithm KeyAlgorithm `json:"keyAlgorithm,omitempty"`
+
+	// Namespace is where per-user Secrets and ServiceAccounts are created
+	// +optional
+	// +kubebuilder:validation:MaxLength=63
+	// +kubebuilder:validation:Pattern=`^[a-z0-9]([-a-z0-9]*[a-z0-9])?$`
+	Namespace string `json:"namespace,omitempty"`
+
+	// APIServer is the API server URL written into generated kubeconfigs
+	// +optional
+	// +kubebuilder:validation:Pattern=`^https://`
+	APIServer string `json:"apiServer,omitempty"`
+
+	// ProxyURL is the proxy written into generated kubeconfigs for reaching the API server,
+	// e.g. a corporate forward proxy (http, https or socks5)
+	// +optional
+	// +kubebuilder:validation:Pattern=`^(https?|socks5)://`
+	ProxyURL string `json:"proxyURL,omitempty"`
+
+	// TLSServerName is written into generated kubeconfigs as the name the API server
+	// certificate is verified against, when it differs from the apiServer host
+	// +optional
+	// +kubebuilder:validation:MaxLength=253
+	TLSServerName string `json:"tlsServerName,omitempty"`
+
+	// InsecureSkipTLSVerify makes generated kubeconfigs skip verifying the API server
+	// certificate. Only meant for test clusters.
+	// +optional
+	InsecureSkipTLSVerify *bool `json:"insecureSkipTLSVerify,omitempty"`
+
+	// UsernameTemplate builds the name the API server authenticates users as, the common name
+	// of their certificates and the subject of their bindings, from {{.Name}}, e.g.
+	// kubeuser:{{.Name}}, so they cannot collide with ServiceAccounts, nodes or users of
+	// other authenticators. Changing it issues new certificates and rebinds every user.
+	// +optional
+	// +kubebuilder:validation:MaxLength=73
+	UsernameTemplate string `json:"usernameTemplate,omitempty"`
+
+	// BreakGlassDuration is how long break-glass access lasts before the User is deleted
+	// +optional
+	BreakGlassDuration *metav1.Duration `json:"breakGlassDuration,omitempty"`
+
+	// ExpiryWarnings are the windows before certificate expiry in which a user is reported as
+	// ExpiringSoon. A Warning Event is recorded when the certificate enters each of them.
+	// +optional
+	ExpiryWarnings []metav1.Duration `json:"expiryWarnings,omitempty"`
+
+	// DeleteAfterExpiry deletes Users that have been Expired for this long, together with their
+	// bindings, credentials and certificate requests. Expired Users are kept when unset or zero.
+	// +optional
+	DeleteAfterExpiry *metav1.Duration `json:"deleteAfterExpiry,omitempty"`
+
+	// NotificationSinks receive user lifecycle notifications
+	// +optional
+	// +listType=map
+	// +listMapKey=name
+	NotificationSinks []NotificationSink `json:"notificationSinks,omitempty"`
+
+	// BulkRotation paces rotations started with the auth.openkube.io/rotate-all annotation
+	// +optional
+	BulkRotation *BulkRotationSpec `json:"bulkRotation,omitempty"`
+
+	// CredentialStores receive a copy of every user's credentials, updated when they change and
+	// deleted when the user is revoked or deleted
+	// +optional
+	// +listType=map
+	// +listMapKey=name
+	CredentialStores []CredentialStore `json:"credentialStores,omitempty"`
+}
+
+// BulkRotationSpec limits how fast a bulk rotation renews users
+type BulkRotationSpec struct {
+	// BatchSize is how many users are renewing at the same time. Defaults to 10.
+	// +optional
+	// +kubebuilder:validation:Minimum=1
+	// +kubebuilder:validation:Maximum=1000
+	BatchSize int32 `json:"batchSize,omitempty"`
+
+	// Interval is the least time between two batches. Defaults to 1m.
+	// +optional
+	Interval *metav1.Duration `json:"interval,omitempty"`
+}
+
+// BulkRotationStatus reports the progress of a bulk rotation
+type BulkRotationStatus struct {
+	// Name is the value of the auth.openkube.io/rotate-all annotation that started the rotation
+	Name string `json:"name"`
+
+	// StartedAt is when the rotation started
+	StartedAt metav1.Time `json:"startedAt"`
+
+	// CompletedAt is when every user had renewed; unset while the rotation is in progress
+	// +optional
+	CompletedAt *metav1.Time `json:"completedAt,omitempty"`
+
+	// LastBatchAt is when the latest batch of users was asked to renew
+	// +optional
+	LastBatchAt *metav1.Time `json:"lastBatchAt,omitempty"`
+
+	// Total is the number of users the rotation covers. Revoked users are left out.
+	Total int32 `json:"total"`
+
+	// Requested is the number of users asked to renew so far
+	Requested int32 `json:"requested"`
+
+	// Renewed is the number of users that have started renewing, by discarding their old
+	// credentials. Suspended users only renew once resumed.
+	Renewed int32 `json:"renewed"`
+}
+
+// KubeUserConfigStatus reports whether the configuration is in effect
+type KubeUserConfigStatus struct {
+	// ObservedGeneration is the generation last applied by the controller
+	// +optional
+	ObservedGeneration int64 `json:"observedGeneration,omitempty"`
+
+	// Conditions follow Kubernetes conventions; Ready is false while the spec is rejected
+	// +optional
+	Conditions []metav1.Condition `json:"conditions,omitempty"`
+
+	// Namespace is the namespace per-user resources are created in, from spec.namespace or the
+	// controller's flags
+	// +optional
+	Namespace string `json:"namespace,omitempty"`
+
+	// BulkRotation is the progress of the latest bulk rotation
+	// +optional
+	BulkRotation *BulkRotationStatus `json:"bulkRotation,omitempty"`
+}
+
+// +kubebuilder:object:root=true
+// +kubebuilder:subresource:status
+// +kubebuilder:resource:scope=Cluster
+// +kubebuilder:validation:XValidation:rule="self.metadata.name == 'default'",message="the KubeUserConfig must be named default"
+// +kubebuilder:printcolumn:name="Ready",type="string",JSONPath=".status.conditions[?(@.type==\"Ready\")].status",description="Whether the configuration is in effect"
+// +kubebuilder:printcolumn:name="Namespace",type="string",JSONPath=".status.namespace",description="Namespace per-user resources are created in"
+// +kubebuilder:printcolumn:name="Age",type="date",JSONPath=".metadata.creationTimestamp",description="Time since the configuration was created"
+
+// KubeUserConfig holds operator-wide defaults that are applied without restarting the controller
+type KubeUserConfig struct {
+	metav1.TypeMeta   `json:",inline"`
+	metav1.ObjectMeta `json:"metadata,omitempty"`
+
+	Spec   KubeUserConfigSpec   `json:"spec,omitempty"`
+	Status KubeUserConfigStatus `json:"status,omitempty"`
+}
+
+// +kubebuilder:object:root=true
+
+// KubeUserConfigList contains a list of KubeUserConfig
+type KubeUserConfigList struct {
+	metav1.TypeMeta `json:",inline"`
+	metav1.ListMeta `json:"metadata,omitempty"`
+	Items           []KubeUserConfig `json:"items"`
+}
+
+func init() {
+	SchemeBuilder.Register(&KubeUserConfig{}, &KubeUserConfigList{})
+}
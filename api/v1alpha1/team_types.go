@@ -0,0 +1,183 @@
+package v1alpha1
+
+import (
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+// TeamMember is a User belonging to a team
+type TeamMember struct {
+	// Name of the member's User. The controller creates the User when it does not exist.
+	// +kubebuilder:validation:MinLength=1
+	Name string `json:"name"`
+
+	// ExpiresAt ends the membership, e.g. for a temporary rotation into the team. The member
+	// then loses the team's roles as if the entry were removed; the entry itself is kept.
+	// +optional
+	ExpiresAt *metav1.Time `json:"expiresAt,omitempty"`
+}
+
+// ExpiredMember is a member of spec.members whose membership has ended
+type ExpiredMember struct {
+	// Name of the member's User
+	Name string `json:"name"`
+
+	// ExpiresAt is the spec.members entry's expiresAt that has passed
+	ExpiresAt metav1.Time `json:"expiresAt"`
+}
+
+// DirectoryProvider is an external directory Teams can take members from
+// +kubebuilder:validation:Enum=entra;google
+type DirectoryProvider string
+
+const (
+	// DirectoryProviderEntra reads groups of Microsoft Entra ID through Microsoft Graph
+	DirectoryProviderEntra DirectoryProvider = "entra"
+	// DirectoryProviderGoogle reads Google Workspace groups through the Admin SDK Directory API
+	DirectoryProviderGoogle DirectoryProvider = "google"
+)
+
+// DirectorySync names the directory group whose members join a Team
+type DirectorySync struct {
+	// Provider is the directory holding the group. The controller must be configured for it.
+	Provider DirectoryProvider `json:"provider"`
+
+	// Group identifies the group in the directory, e.g. the object ID of an Entra ID group or
+	// the email address of a Google group. Members of nested groups are included.
+	// +kubebuilder:validation:MinLength=1
+	Group string `json:"group"`
+}
+
+// DirectoryMember is a member of a Team's directory group
+type DirectoryMember struct {
+	// Name of the member's User, derived from the directory account
+	Name string `json:"name"`
+
+	// Disabled is set when the account is disabled in the directory. The member does not get
+	// the team's roles, and the User the team created for it is suspended.
+	// +optional
+	Disabled bool `json:"disabled,omitempty"`
+}
+
+// TeamSpec declares a team's namespaces, the roles its members share and the members
+type TeamSpec struct {
+	// Namespaces are created for the team if they do not exist. They are kept when the team
+	// is deleted.
+	// +optional
+	// +listType=set
+	Namespaces []string `json:"namespaces,omitempty"`
+
+	// Roles are bound for every member, in addition to the member's own. Roles in the team's
+	// namespaces are bound once the controller created them.
+	// +optional
+	Roles []RoleSpec `json:"roles,omitempty"`
+
+	// ClusterRoles are bound for every member, in addition to the member's own
+	// +optional
+	ClusterRoles []ClusterRoleSpec `json:"clusterRoles,omitempty"`
+
+	// EKSAccessPolicies are associated with the EKS access entries of members that have one,
+	// in addition to the members' own
+	// +optional
+	EKSAccessPolicies []EKSAccessPolicy `json:"eksAccessPolicies,omitempty"`
+
+	// GCPRoles are IAM roles granted on the GKE cluster's project to the Google accounts of
+	// members that have one, in addition to the members' own
+	// +optional
+	// +listType=set
+	GCPRoles []string `json:"gcpRoles,omitempty"`
+
+	// IncludeTeams name Teams whose roles the members of this team receive as well, e.g. an
+	// org-wide baseline. Their own included Teams are followed too; Teams including each other
+	// are rejected.
+	// +optional
+	// +listType=set
+	IncludeTeams []string `json:"includeTeams,omitempty"`
+
+	// Members of the team. Users the team created are deleted when they are removed from
+	// the list; other Users only lose the team's roles.
+	// +optional
+	// +listType=map
+	// +listMapKey=name
+	Members []TeamMember `json:"members,omitempty"`
+
+	// MemberSelector adds the Users whose labels match it to the team, e.g. every User labelled
+	// team=payments. The team does not create or delete Users for them; a User joins and leaves
+	// with its labels. An empty selector selects no Users.
+	// +optional
+	MemberSelector *metav1.LabelSelector `json:"memberSelector,omitempty"`
+
+	// DirectorySync adds the members of a directory group to the team. The controller reads
+	// the group periodically; members listed above stay members regardless.
+	// +optional
+	DirectorySync *DirectorySync `json:"directorySync,omitempty"`
+}
+
+// TeamStatus reports the team's namespaces and members
+type TeamStatus struct {
+	// ObservedGeneration is the generation last reconciled by the controller
+	// +optional
+	ObservedGeneration int64 `json:"observedGeneration,omitempty"`
+
+	// Members is the number of members with a User
+	// +optional
+	Members int32 `json:"members,omitempty"`
+
+	// DirectoryMembers are the members of spec.directorySync's group as of the last successful
+	// sync. They are kept while the directory cannot be read.
+	// +optional
+	// +listType=map
+	// +listMapKey=name
+	DirectoryMembers []DirectoryMember `json:"directoryMembers,omitempty"`
+
+	// SelectedMembers are the Users matching spec.memberSelector as of the last reconcile
+	// +optional
+	// +listType=set
+	SelectedMembers []string `json:"selectedMembers,omitempty"`
+
+	// ExpiredMembers are the members of spec.members whose expiresAt has passed. They do not get
+	// the team's roles until their expiresAt changes.
+	// +optional
+	// +listType=map
+	// +listMapKey=name
+	ExpiredMembers []ExpiredMember `json:"expiredMembers,omitempty"`
+
+	// LastDirectorySync is when the directory group was last read
+	// +optional
+	LastDirectorySync *metav1.Time `json:"lastDirectorySync,omitempty"`
+
+	// Conditions follow Kubernetes conventions; Ready is false while namespaces or member
+	// Users could not be created, DirectorySynced is false while the directory cannot be read,
+	// IncludesResolved is false while an included Team is missing or the includes form a cycle
+	// +optional
+	Conditions []metav1.Condition `json:"conditions,omitempty"`
+}
+
+// +kubebuilder:object:root=true
+// +kubebuilder:subresource:status
+// +kubebuilder:resource:scope=Cluster
+// +kubebuilder:printcolumn:name="Members",type="integer",JSONPath=".status.members",description="Members with a User"
+// +kubebuilder:printcolumn:name="Ready",type="string",JSONPath=".status.conditions[?(@.type==\"Ready\")].status",description="Whether the team's namespaces and Users exist"
+// +kubebuilder:printcolumn:name="Age",type="date",JSONPath=".metadata.creationTimestamp",description="Time since the team was created"
+
+// Team provisions namespaces and member Users together, so a squad is one object instead of a
+// User per member and separate namespace manifests
+type Team struct {
+	metav1.TypeMeta   `json:",inline"`
+	metav1.ObjectMeta `json:"metadata,omitempty"`
+
+	Spec   TeamSpec   `json:"spec,omitempty"`
+	Status TeamStatus `json:"status,omitempty"`
+}
+
+// +kubebuilder:object:root=true
+
+// TeamList contains a list of Team
+type TeamList struct {
+	metav1.TypeMeta `json:",inline"`
+	metav1.ListMeta `json:"metadata,omitempty"`
+	Items           []Team `json:"items"`
+}
+
+func init() {
+	SchemeBuilder.Register(&Team{}, &TeamList{})
+}
@@ -1,6 +1,7 @@
 package v1alpha1
 
 import (
+	corev1 "k8s.io/api/core/v1"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 )
 
@@ -9,6 +10,7 @@ import (
 //
 
 // RoleSpec defines namespace-scoped access by binding to an existing Role
+// +kubebuilder:validation:XValidation:rule="!(has(self.expiresAt) && has(self.duration))",message="expiresAt and duration are mutually exclusive"
 type RoleSpec struct {
 	// Namespace where the RoleBinding will be created
 	// +kubebuilder:validation:MinLength=1
@@ -17,17 +19,373 @@ type RoleSpec struct {
 	// ExistingRole is the name of the Role inside that namespace
 	// +kubebuilder:validation:MinLength=1
 	ExistingRole string `json:"existingRole"`
+
+	// CreateNamespace makes the controller create the namespace if it does not exist, instead of
+	// failing until it does. The Role itself must still be created by someone else.
+	// +optional
+	CreateNamespace bool `json:"createNamespace,omitempty"`
+
+	// ExpiresAt is when this grant ends. Its binding is removed then while the user keeps its
+	// other grants.
+	// +optional
+	ExpiresAt *metav1.Time `json:"expiresAt,omitempty"`
+
+	// Duration ends this grant the given time after it was first bound, as recorded in
+	// status.timedGrants
+	// +optional
+	Duration *metav1.Duration `json:"duration,omitempty"`
+}
+
+// Elevation marks a grant as temporary. The controller removes the binding once Until has
+// passed, independent of the user's own expiry.
+type Elevation struct {
+	// Until is when the elevated access ends
+	Until metav1.Time `json:"until"`
+
+	// Reason documents why the elevation was granted, e.g. a ticket reference
+	// +optional
+	Reason string `json:"reason,omitempty"`
 }
 
 // ClusterRoleSpec defines cluster-wide access by binding to an existing ClusterRole
+// +kubebuilder:validation:XValidation:rule="!(has(self.expiresAt) && has(self.duration))",message="expiresAt and duration are mutually exclusive"
 type ClusterRoleSpec struct {
 	// ExistingClusterRole is the name of the ClusterRole to bind
 	// +kubebuilder:validation:MinLength=1
 	ExistingClusterRole string `json:"existingClusterRole"`
+
+	// Elevation makes this a temporary grant that is removed after its end time
+	// +optional
+	Elevation *Elevation `json:"elevation,omitempty"`
+
+	// ExpiresAt is when this grant ends. Its binding is removed then while the user keeps its
+	// other grants.
+	// +optional
+	ExpiresAt *metav1.Time `json:"expiresAt,omitempty"`
+
+	// Duration ends this grant the given time after it was first bound, as recorded in
+	// status.timedGrants
+	// +optional
+	Duration *metav1.Duration `json:"duration,omitempty"`
+}
+
+// UserTemplateReference names the UserTemplate a User takes its defaults from
+type UserTemplateReference struct {
+	// Name of the UserTemplate
+	// +kubebuilder:validation:MinLength=1
+	Name string `json:"name"`
+}
+
+// AccessWindow is a recurring period in which the user's roles are bound
+type AccessWindow struct {
+	// Start is a cron expression with five fields (minute hour day-of-month month day-of-week)
+	// for when the window opens, e.g. "0 8 * * 1-5" for weekdays at 08:00
+	// +kubebuilder:validation:MinLength=1
+	Start string `json:"start"`
+
+	// Duration is how long the window stays open, e.g. "10h"
+	Duration metav1.Duration `json:"duration"`
+}
+
+// AccessSchedule restricts the user's bindings to recurring windows
+type AccessSchedule struct {
+	// TimeZone is the IANA time zone the windows are evaluated in, e.g. "Europe/Berlin".
+	// Defaults to UTC.
+	// +optional
+	TimeZone string `json:"timeZone,omitempty"`
+
+	// Windows in which the roles are bound; access is allowed while any of them is open
+	// +kubebuilder:validation:MinItems=1
+	Windows []AccessWindow `json:"windows"`
+}
+
+// CredentialFormat is how a credential is encoded in the credential Secret
+// +kubebuilder:validation:Enum=kubeconfig;kubeconfig-json;ca-cert;client-cert;client-key;env
+type CredentialFormat string
+
+const (
+	// CredentialFormatKubeconfig is a YAML kubeconfig with embedded certificate and key
+	CredentialFormatKubeconfig CredentialFormat = "kubeconfig"
+	// CredentialFormatKubeconfigJSON is the same kubeconfig encoded as JSON
+	CredentialFormatKubeconfigJSON CredentialFormat = "kubeconfig-json"
+	// CredentialFormatCACert is the PEM cluster CA
+	CredentialFormatCACert CredentialFormat = "ca-cert"
+	// CredentialFormatClientCert is the PEM client certificate
+	CredentialFormatClientCert CredentialFormat = "client-cert"
+	// CredentialFormatClientKey is the PEM client private key
+	CredentialFormatClientKey CredentialFormat = "client-key"
+	// CredentialFormatEnv is an env file with KUBE_SERVER, KUBE_USER and base64 encoded KUBE_*_DATA entries
+	CredentialFormatEnv CredentialFormat = "env"
+)
+
+// KubeconfigFormat is how the generated kubeconfig authenticates the user
+// +kubebuilder:validation:Enum=embedded;execCredential
+type KubeconfigFormat string
+
+const (
+	// KubeconfigFormatEmbedded embeds the client certificate and key in the kubeconfig
+	KubeconfigFormatEmbedded KubeconfigFormat = "embedded"
+	// KubeconfigFormatExecCredential runs the kubectl-kubeuser credential helper, which fetches
+	// the certificate and key from the credential Secret when kubectl needs them
+	KubeconfigFormatExecCredential KubeconfigFormat = "execCredential"
+)
+
+// CredentialKey is a single entry of the credential Secret
+type CredentialKey struct {
+	// Key in the Secret data
+	// +kubebuilder:validation:MinLength=1
+	// +kubebuilder:validation:MaxLength=253
+	// +kubebuilder:validation:Pattern=`^[-._a-zA-Z0-9]+$`
+	Key string `json:"key"`
+
+	// Format of the value stored under Key
+	Format CredentialFormat `json:"format"`
+}
+
+// OutputSecretRef locates the credential Secret
+type OutputSecretRef struct {
+	// Name of the Secret
+	// +kubebuilder:validation:MinLength=1
+	// +kubebuilder:validation:MaxLength=253
+	Name string `json:"name"`
+
+	// Namespace of the Secret. Defaults to the KubeUser namespace.
+	// +optional
+	// +kubebuilder:validation:MaxLength=63
+	Namespace string `json:"namespace,omitempty"`
+
+	// Key the kubeconfig is stored under; shorthand for a single kubeconfig entry in keys
+	// +optional
+	// +kubebuilder:validation:MaxLength=253
+	// +kubebuilder:validation:Pattern=`^[-._a-zA-Z0-9]+$`
+	Key string `json:"key,omitempty"`
+}
+
+// OutputSpec configures the credential Secret
+type OutputSpec struct {
+	// Keys written into the credential Secret. Defaults to the operator's
+	// --credential-layout, which is a single kubeconfig under "config".
+	// +optional
+	// +listType=map
+	// +listMapKey=key
+	Keys []CredentialKey `json:"keys,omitempty"`
+
+	// SecretRef is where the credential Secret is written instead of <user>-kubeconfig in the
+	// KubeUser namespace. When it changes the Secret is moved to the new location.
+	// +optional
+	SecretRef *OutputSecretRef `json:"secretRef,omitempty"`
+
+	// Server is the API server URL written into the kubeconfig, e.g. an external load balancer.
+	// Defaults to the operator's --api-server.
+	// +optional
+	// +kubebuilder:validation:Pattern=`^https://`
+	Server string `json:"server,omitempty"`
+
+	// CABundle is the PEM CA bundle written into the kubeconfig to verify Server. Defaults to
+	// the CA found through the operator's --ca-sources.
+	// +optional
+	CABundle string `json:"caBundle,omitempty"`
+
+	// ProxyURL is the proxy kubectl connects to Server through, e.g. a corporate forward proxy
+	// (http, https or socks5). Defaults to the operator's kubeconfig proxyURL.
+	// +optional
+	// +kubebuilder:validation:Pattern=`^(https?|socks5)://`
+	ProxyURL string `json:"proxyURL,omitempty"`
+
+	// TLSServerName is the name the API server certificate is verified against instead of
+	// Server's host, e.g. behind a TCP load balancer. Defaults to the operator's tlsServerName.
+	// +optional
+	// +kubebuilder:validation:MaxLength=253
+	TLSServerName string `json:"tlsServerName,omitempty"`
+
+	// InsecureSkipTLSVerify writes a kubeconfig that does not verify the API server
+	// certificate, and therefore holds no CA. Only meant for test clusters. Defaults to the
+	// operator's insecureSkipTLSVerify.
+	// +optional
+	InsecureSkipTLSVerify *bool `json:"insecureSkipTLSVerify,omitempty"`
+
+	// Format is embedded (default) to write the certificate and key into the kubeconfig, or
+	// execCredential for a kubeconfig without them that runs "kubectl kubeuser credential".
+	// The certificate and key are then stored in the credential Secret next to it, as tls.crt
+	// and tls.key unless spec.output.keys already holds them.
+	// +optional
+	Format KubeconfigFormat `json:"format,omitempty"`
+
+	// Encryption encrypts the credentials in the Secret to the end user's OpenPGP keys, so
+	// only they can read them
+	// +optional
+	Encryption *OutputEncryption `json:"encryption,omitempty"`
+}
+
+// OutputEncryption encrypts every key of the credential Secret holding a private key or token,
+// i.e. all but ca-cert and client-cert, as an ASCII-armored OpenPGP message that
+// "gpg --decrypt" reads. The client certificate is kept in plaintext, as tls.crt unless
+// spec.output.keys already holds it, so the controller can still rotate it.
+type OutputEncryption struct {
+	// Recipients are ASCII-armored OpenPGP public keys ("gpg --armor --export"). The newest
+	// RSA or ECDH key of each that can encrypt is used.
+	// +kubebuilder:validation:MinItems=1
+	// +kubebuilder:validation:MaxItems=10
+	Recipients []string `json:"recipients"`
+}
+
+// UserType is the kind of identity a User stands for
+// +kubebuilder:validation:Enum=human;machine
+type UserType string
+
+const (
+	// UserTypeHuman is a person authenticating with a client certificate
+	UserTypeHuman UserType = "human"
+	// UserTypeMachine is a CI system or bot authenticating with short-lived ServiceAccount tokens
+	UserTypeMachine UserType = "machine"
+)
+
+// SSHSpec requests an SSH user certificate for node access, signed by the operator's SSH CA.
+// The certificate expires with the user's client certificate and is re-issued when it rotates.
+type SSHSpec struct {
+	// Principals are the login names the certificate is valid for. Defaults to the user name.
+	// +optional
+	Principals []string `json:"principals,omitempty"`
+
+	// PublicKey is an OpenSSH public key ("ssh-ed25519 AAAA...") to certify. When empty the
+	// controller generates an ed25519 key pair and stores the private key in the <user>-ssh Secret.
+	// +optional
+	PublicKey string `json:"publicKey,omitempty"`
+}
+
+// ClusterKubeconfigReference references the kubeconfig of a member cluster
+type ClusterKubeconfigReference struct {
+	// Name of the Secret
+	Name string `json:"name"`
+
+	// Namespace of the Secret. Defaults to the KubeUser namespace; the creator of the User must
+	// be allowed to get a Secret in any other namespace.
+	// +optional
+	Namespace string `json:"namespace,omitempty"`
+
+	// Key of the kubeconfig in the Secret
+	// +optional
+	// +kubebuilder:default=kubeconfig
+	Key string `json:"key,omitempty"`
+}
+
+// ClusterTarget is a member cluster the user is provisioned on besides the cluster the
+// controller runs in. The controller binds the user there to the same roles it is bound to here,
+// and issues a certificate through the member cluster's CSR API.
+type ClusterTarget struct {
+	// Name identifies the member cluster in status and in the name of its credential Secret
+	// +kubebuilder:validation:MaxLength=63
+	// +kubebuilder:validation:Pattern=`^[a-z0-9]([-a-z0-9]*[a-z0-9])?$`
+	Name string `json:"name"`
+
+	// KubeconfigSecretRef references the kubeconfig the controller manages the member cluster
+	// with. It needs to create bindings, namespaces and CSRs there and approve the CSRs.
+	KubeconfigSecretRef ClusterKubeconfigReference `json:"kubeconfigSecretRef"`
+}
+
+// EKSAccessPolicy is an EKS access policy associated with the user's access entry
+type EKSAccessPolicy struct {
+	// PolicyARN is the access policy, e.g.
+	// arn:aws:eks::aws:cluster-access-policy/AmazonEKSViewPolicy
+	// +kubebuilder:validation:Pattern=`^arn:aws[-a-z]*:eks::aws:cluster-access-policy/.+$`
+	PolicyARN string `json:"policyARN"`
+
+	// Namespaces limits the policy to these namespaces. It applies to the whole cluster when
+	// empty.
+	// +optional
+	// +listType=set
+	Namespaces []string `json:"namespaces,omitempty"`
+}
+
+// EKSAccess maps the user to the IAM principal it signs in to AWS as, through an EKS access
+// entry on the cluster the controller is configured for
+type EKSAccess struct {
+	// PrincipalARN is the IAM role or user of the person. Its access entry authenticates it to
+	// the cluster as this user, so the user's bindings apply to it.
+	// +kubebuilder:validation:Pattern=`^arn:aws[-a-z]*:iam::[0-9]{12}:(role|user)/.+$`
+	PrincipalARN string `json:"principalARN"`
+
+	// AccessPolicies are associated with the access entry and grant access in addition to the
+	// user's bindings
+	// +optional
+	// +listType=map
+	// +listMapKey=policyARN
+	AccessPolicies []EKSAccessPolicy `json:"accessPolicies,omitempty"`
+
+	// IAMOnly replaces the client certificate with the access entry: no certificate is issued
+	// and no kubeconfig is written, the user gets one with "aws eks update-kubeconfig".
+	// +optional
+	IAMOnly bool `json:"iamOnly,omitempty"`
+}
+
+// GCPAccess maps the user to the Google account it signs in to GKE with, and grants the account
+// IAM roles on the project the controller is configured for
+type GCPAccess struct {
+	// Account is the email address of the person's Google account, or of a Google service
+	// account. GKE authenticates it under this name, so it is bound to the user's roles too.
+	// +kubebuilder:validation:MaxLength=254
+	// +kubebuilder:validation:Pattern=`^[^@\s:]+@[^@\s:]+$`
+	Account string `json:"account"`
+
+	// Roles are IAM roles granted to the account on the project, such as roles/container.viewer
+	// or a custom role. Defaults to roles/container.clusterViewer, which only lets it get the
+	// cluster's credentials; the bindings of the user decide what it can do in the cluster.
+	// +optional
+	// +listType=set
+	Roles []string `json:"roles,omitempty"`
+}
+
+// CertificateSubject adds attributes to the subject and subject alternative names of the user's
+// client certificate, for systems behind the API server that identify clients by them. The
+// common name stays the user name.
+type CertificateSubject struct {
+	// Organizations are added as O attributes. The API server takes them as the user's groups,
+	// so the requester must be allowed to impersonate each of them.
+	// +optional
+	// +listType=set
+	Organizations []string `json:"organizations,omitempty"`
+
+	// OrganizationalUnits are added as OU attributes
+	// +optional
+	// +listType=set
+	OrganizationalUnits []string `json:"organizationalUnits,omitempty"`
+
+	// EmailAddresses are added as email SANs, where RFC 5280 puts them rather than in the subject
+	// +optional
+	// +listType=set
+	EmailAddresses []string `json:"emailAddresses,omitempty"`
+
+	// DNSNames are added as DNS SANs
+	// +optional
+	// +listType=set
+	DNSNames []string `json:"dnsNames,omitempty"`
+
+	// URIs are added as URI SANs
+	// +optional
+	// +listType=set
+	URIs []string `json:"uris,omitempty"`
 }
 
 // UserSpec defines the desired state of User
 type UserSpec struct {
+	// Type is human for people, who get a client certificate, or machine for CI systems and
+	// bots. Machine users are bound through their ServiceAccount anchor only and get a kubeconfig
+	// with a bound ServiceAccount token that the controller refreshes before it expires.
+	// +optional
+	// +kubebuilder:default=human
+	// +kubebuilder:validation:XValidation:rule="self == oldSelf",message="type is immutable"
+	Type UserType `json:"type,omitempty"`
+
+	// TokenDuration is how long the tokens of a machine user are valid. They are refreshed
+	// after 80% of it has passed. Defaults to 1h; at least 10m.
+	// +optional
+	TokenDuration *metav1.Duration `json:"tokenDuration,omitempty"`
+
+	// TemplateRef takes defaults, such as roles every developer gets, from a UserTemplate. The
+	// User's own roles are bound in addition; entries for the same role take precedence.
+	// +optional
+	TemplateRef *UserTemplateReference `json:"templateRef,omitempty"`
+
 	// Roles is a list of namespace-scoped Role bindings
 	// +optional
 	Roles []RoleSpec `json:"roles,omitempty"`
@@ -35,14 +393,380 @@ type UserSpec struct {
 	// ClusterRoles is a list of cluster-wide ClusterRole bindings
 	// +optional
 	ClusterRoles []ClusterRoleSpec `json:"clusterRoles,omitempty"`
+
+	// Output configures the credential Secret
+	// +optional
+	Output *OutputSpec `json:"output,omitempty"`
+
+	// ServiceAccountAnchor controls whether a ServiceAccount named after the user is created
+	// and bound alongside the certificate identity, for short-lived tokens. Defaults to the
+	// operator's --service-account-anchor setting.
+	// +optional
+	ServiceAccountAnchor *bool `json:"serviceAccountAnchor,omitempty"`
+
+	// SSH requests an SSH certificate alongside the kubeconfig
+	// +optional
+	SSH *SSHSpec `json:"ssh,omitempty"`
+
+	// DefaultNamespace is the namespace of the kubeconfig's current context. Defaults to the
+	// first namespace in roles, or "default" when the user has no namespaced roles.
+	// +optional
+	// +kubebuilder:validation:MaxLength=63
+	// +kubebuilder:validation:Pattern=`^[a-z0-9]([-a-z0-9]*[a-z0-9])?$`
+	DefaultNamespace string `json:"defaultNamespace,omitempty"`
+
+	// Email is the user's address. Email notification sinks send the user's notifications, such
+	// as expiry notices and the kubeconfig on first issuance, to it.
+	// +optional
+	// +kubebuilder:validation:MaxLength=254
+	// +kubebuilder:validation:Pattern=`^[^@\s]+@[^@\s]+$`
+	Email string `json:"email,omitempty"`
+
+	// CSR is a PEM encoded certificate signing request with the user name as common name and
+	// no organizations. When set the controller never generates or stores a private key: it
+	// signs this CSR and publishes only the certificate and a kubeconfig without the key.
+	// +optional
+	CSR string `json:"csr,omitempty"`
+
+	// Certificate adds subject attributes and SANs to the client certificate. Changing it
+	// issues a new certificate. ClusterPolicies may restrict the values. Cannot be combined
+	// with csr, whose subject is the user's own.
+	// +optional
+	Certificate *CertificateSubject `json:"certificate,omitempty"`
+
+	// BreakGlass requests emergency access. ClusterPolicies do not apply, but access ends after
+	// the operator's break-glass duration: certificates expire with it and the User, with its
+	// bindings and credentials, is deleted then. It can only be set on creation, by requesters
+	// holding the breakglass verb on users.
+	// +optional
+	BreakGlass bool `json:"breakGlass,omitempty"`
+
+	// AccessSchedule limits the user's RoleBindings and ClusterRoleBindings to recurring
+	// windows, e.g. business hours. Outside of them the bindings are removed; the user keeps its
+	// certificate.
+	// +optional
+	AccessSchedule *AccessSchedule `json:"accessSchedule,omitempty"`
+
+	// Suspended removes all of the user's bindings while keeping the User, its key and its
+	// credentials. Setting it back to false restores the access.
+	// +optional
+	Suspended bool `json:"suspended,omitempty"`
+
+	// Revoked withdraws the user's access for good: the controller removes every binding,
+	// deletes the key and credential Secrets and any pending certificate request, and issues no
+	// new certificate until it is cleared. Certificates already issued stay valid until they
+	// expire but no longer grant anything.
+	// +optional
+	Revoked bool `json:"revoked,omitempty"`
+
+	// Clusters are member clusters the user is provisioned on as well, each with its own
+	// credential Secret in the KubeUser namespace, reported in status.clusters. Not supported
+	// for machine users.
+	// +optional
+	// +listType=map
+	// +listMapKey=name
+	Clusters []ClusterTarget `json:"clusters,omitempty"`
+
+	// EKS gives the user an access entry on the EKS cluster, so it can sign in with its IAM
+	// principal. Requires the controller's --eks-cluster-name.
+	// +optional
+	EKS *EKSAccess `json:"eks,omitempty"`
+
+	// GCP binds the user's Google account, which GKE authenticates it with, to the user's roles
+	// and grants it IAM roles on the GKE cluster's project when the controller's --gcp-project
+	// is set. Not supported for machine users.
+	// +optional
+	GCP *GCPAccess `json:"gcp,omitempty"`
 }
 
 //
 // Status types
 //
 
+// TimedGrant records when a grant with an expiresAt or duration was first bound and when it ends
+type TimedGrant struct {
+	// Kind of the granted role, Role or ClusterRole
+	Kind string `json:"kind"`
+
+	// Namespace of a Role grant; empty for ClusterRoles
+	// +optional
+	Namespace string `json:"namespace,omitempty"`
+
+	// Name of the Role or ClusterRole
+	Name string `json:"name"`
+
+	// Since is when the grant was first bound; durations count from here
+	Since metav1.Time `json:"since"`
+
+	// ExpiresAt is when the grant ends and its binding is removed
+	ExpiresAt metav1.Time `json:"expiresAt"`
+}
+
+// BindingAction is what the controller would do to a binding in report-only mode
+// +kubebuilder:validation:Enum=Create;Update;Delete
+type BindingAction string
+
+const (
+	BindingActionCreate BindingAction = "Create"
+	BindingActionUpdate BindingAction = "Update"
+	BindingActionDelete BindingAction = "Delete"
+)
+
+// PlannedBinding is a binding change that was computed but not applied
+type PlannedBinding struct {
+	// Action the controller would take
+	Action BindingAction `json:"action"`
+
+	// Kind of the binding (RoleBinding or ClusterRoleBinding)
+	Kind string `json:"kind"`
+
+	// Namespace of the binding; empty for ClusterRoleBindings
+	// +optional
+	Namespace string `json:"namespace,omitempty"`
+
+	// Name of the binding
+	Name string `json:"name"`
+
+	// RoleRef names the bound role as Kind/Name
+	RoleRef string `json:"roleRef"`
+}
+
+// BindingState is the state of a binding the user is to have
+// +kubebuilder:validation:Enum=Bound;Pending;Failed
+type BindingState string
+
+const (
+	// BindingStateBound means the binding exists as desired
+	BindingStateBound BindingState = "Bound"
+	// BindingStatePending means the binding waits for its role to be created, or is not applied
+	// in report-only binding mode
+	BindingStatePending BindingState = "Pending"
+	// BindingStateFailed means the binding could not be created or updated
+	BindingStateFailed BindingState = "Failed"
+)
+
+// BindingStatus reports a RoleBinding or ClusterRoleBinding the user is to have
+type BindingStatus struct {
+	// Kind of the binding (RoleBinding or ClusterRoleBinding)
+	Kind string `json:"kind"`
+
+	// Namespace of the binding; empty for ClusterRoleBindings
+	// +optional
+	Namespace string `json:"namespace,omitempty"`
+
+	// Name of the binding
+	Name string `json:"name"`
+
+	// RoleRef names the bound role as Kind/Name
+	RoleRef string `json:"roleRef"`
+
+	// State of the binding
+	State BindingState `json:"state"`
+
+	// LastError explains why a Pending or Failed binding is not bound
+	// +optional
+	LastError string `json:"lastError,omitempty"`
+}
+
+// ClusterState is the state of the user on a member cluster
+// +kubebuilder:validation:Enum=Ready;Pending;Failed
+type ClusterState string
+
+const (
+	// ClusterStateReady means the user's bindings and credentials on the member cluster are current
+	ClusterStateReady ClusterState = "Ready"
+	// ClusterStatePending means the certificate for the member cluster is being issued
+	ClusterStatePending ClusterState = "Pending"
+	// ClusterStateFailed means the member cluster could not be reached or reconciled
+	ClusterStateFailed ClusterState = "Failed"
+)
+
+// ClusterStatus reports the user on a member cluster
+type ClusterStatus struct {
+	// Name of the member cluster in spec.clusters
+	Name string `json:"name"`
+
+	// State of the user on the member cluster
+	State ClusterState `json:"state"`
+
+	// Message explains a Pending or Failed state
+	// +optional
+	Message string `json:"message,omitempty"`
+
+	// KubeconfigSecretRef is the kubeconfig the member cluster was last reconciled with. It is
+	// used to remove the user from the member cluster once it is dropped from spec.clusters.
+	KubeconfigSecretRef ClusterKubeconfigReference `json:"kubeconfigSecretRef"`
+
+	// Bindings is the number of bindings the user has on the member cluster
+	// +optional
+	Bindings int32 `json:"bindings,omitempty"`
+
+	// CredentialSecret is where the kubeconfig with a context for the member cluster is written.
+	// It is the same Secret for all member clusters.
+	// +optional
+	CredentialSecret *corev1.SecretReference `json:"credentialSecret,omitempty"`
+
+	// ExpiryTime is when the certificate for the member cluster expires (RFC3339 format)
+	// +optional
+	ExpiryTime string `json:"expiryTime,omitempty"`
+}
+
+// AccessCheck is a SubjectAccessReview spot check of a permission a planned binding grants
+type AccessCheck struct {
+	// Namespace checked; empty for cluster-wide checks
+	// +optional
+	Namespace string `json:"namespace,omitempty"`
+
+	// Verb checked
+	Verb string `json:"verb"`
+
+	// Group of the resource checked
+	// +optional
+	Group string `json:"group,omitempty"`
+
+	// Resource checked, including the subresource if any
+	Resource string `json:"resource"`
+
+	// AllowedNow is whether the user already has this permission without the planned bindings
+	AllowedNow bool `json:"allowedNow"`
+}
+
+// AccessPlan records what access report-only mode would grant
+type AccessPlan struct {
+	// Bindings lists the binding changes that would be made
+	// +optional
+	Bindings []PlannedBinding `json:"bindings,omitempty"`
+
+	// Checks are spot checks of permissions the planned bindings grant
+	// +optional
+	Checks []AccessCheck `json:"checks,omitempty"`
+}
+
+// RecommendationAction is what a RoleRecommendation suggests doing with a bound role
+// +kubebuilder:validation:Enum=Remove;Replace
+type RecommendationAction string
+
+const (
+	// RecommendationRemove suggests dropping a role that was not used
+	RecommendationRemove RecommendationAction = "Remove"
+	// RecommendationReplace suggests binding a narrower ClusterRole instead
+	RecommendationReplace RecommendationAction = "Replace"
+)
+
+// RoleRecommendation is a least-privilege suggestion derived from observed API usage
+type RoleRecommendation struct {
+	// Kind of the bound role (Role or ClusterRole)
+	Kind string `json:"kind"`
+
+	// Namespace of the binding; empty for cluster-wide bindings
+	// +optional
+	Namespace string `json:"namespace,omitempty"`
+
+	// Name of the bound role
+	Name string `json:"name"`
+
+	// Action suggested for the role
+	Action RecommendationAction `json:"action"`
+
+	// Replacement is the ClusterRole to bind instead when Action is Replace
+	// +optional
+	Replacement string `json:"replacement,omitempty"`
+
+	// Reason explains the recommendation
+	Reason string `json:"reason"`
+}
+
+// AccessRule lists resources and the verbs allowed on every one of them
+type AccessRule struct {
+	// Verbs allowed; "*" allows every verb
+	Verbs []string `json:"verbs"`
+
+	// Resources as "resource[.group]", including subresources such as "pods/log". Rules limited
+	// to some objects name them in brackets, e.g. "secrets[db-password]".
+	// +optional
+	Resources []string `json:"resources,omitempty"`
+
+	// NonResourceURLs such as "/healthz"; only granted cluster-wide
+	// +optional
+	NonResourceURLs []string `json:"nonResourceURLs,omitempty"`
+}
+
+// NamespaceAccess summarizes what the user may do in a namespace
+type NamespaceAccess struct {
+	// Namespace the rules apply in; empty for the rules of ClusterRoleBindings, which apply
+	// cluster-wide and in every namespace
+	// +optional
+	Namespace string `json:"namespace,omitempty"`
+
+	// Rules grouping the resources with the same allowed verbs
+	Rules []AccessRule `json:"rules"`
+
+	// Incomplete is set when the API server could not evaluate every rule that applies to the
+	// user in the namespace, so the user may be allowed more
+	// +optional
+	Incomplete bool `json:"incomplete,omitempty"`
+}
+
+// UnusedPermission lists the permissions of a bound role that were not exercised
+type UnusedPermission struct {
+	// Kind of the bound role (Role or ClusterRole)
+	Kind string `json:"kind"`
+
+	// Namespace of the binding; empty for cluster-wide bindings
+	// +optional
+	Namespace string `json:"namespace,omitempty"`
+
+	// Name of the bound role
+	Name string `json:"name"`
+
+	// Permissions never exercised, as "verb resource[.group]"
+	Permissions []string `json:"permissions"`
+}
+
+// Annotations the admission webhook sets on Users when spec.revoked is set, so the controller
+// can record who revoked the user. Values supplied by clients are overwritten.
+const (
+	RevokedByAnnotation = "auth.openkube.io/revoked-by"
+	RevokedAtAnnotation = "auth.openkube.io/revoked-at"
+)
+
+// ChangedByAnnotation is set by the admission webhook to the identity that last created or
+// changed the User's spec, so access grant records can name who asked for a change. Values
+// supplied by clients are overwritten.
+const ChangedByAnnotation = "auth.openkube.io/changed-by"
+
+// RenewAnnotation set to "true" on a User makes the controller issue a new certificate, or a new
+// token for machine users, right away instead of waiting for the rotation threshold. The
+// controller removes it once the renewal has started.
+const RenewAnnotation = "auth.openkube.io/renew"
+
+// FleetLabel on a Cluster API Cluster names the fleet it belongs to. Users with the same label,
+// or in a Team with it, are provisioned on the Cluster as a member cluster once its kubeconfig
+// Secret exists.
+const FleetLabel = "auth.openkube.io/fleet"
+
+// RenewNewKey as the value of RenewAnnotation also replaces the private key the controller holds
+// for the user
+const RenewNewKey = "new-key"
+
+// BulkRotationAnnotation records on a User the bulk rotation that last asked it to renew
+const BulkRotationAnnotation = "auth.openkube.io/bulk-rotation"
+
+// Revocation records who revoked a user and when
+type Revocation struct {
+	// By is the identity that set spec.revoked, as recorded by the admission webhook
+	By string `json:"by"`
+
+	// At is when spec.revoked was set
+	At metav1.Time `json:"at"`
+}
+
 // UserStatus defines the observed state of User
 type UserStatus struct {
+	// ObservedGeneration is the generation of the User the status was last written for
+	// +optional
+	ObservedGeneration int64 `json:"observedGeneration,omitempty"`
+
 	// ExpiryTime is the actual expiry timestamp (RFC3339 format)
 	// This comes from the actual certificate NotAfter time when available
 	// +optional
@@ -53,7 +777,13 @@ type UserStatus struct {
 	// +optional
 	CertificateExpiry string `json:"certificateExpiry,omitempty"`
 
-	// Phase is a simple high-level status (Pending, Active, Expired, Error)
+	// Username is the name the API server authenticates the user's current certificate as, the
+	// common name built by the operator's username template when it was issued. While it differs
+	// from the current template's, both names are bound, until the new certificate is issued.
+	// +optional
+	Username string `json:"username,omitempty"`
+
+	// Phase is a simple high-level status (Pending, Active, ExpiringSoon, Suspended, Revoked, Expired, Error)
 	// +optional
 	Phase string `json:"phase,omitempty"`
 
@@ -61,9 +791,81 @@ type UserStatus struct {
 	// +optional
 	Message string `json:"message,omitempty"`
 
-	// Conditions follow Kubernetes conventions for detailed status
+	// Conditions follow Kubernetes conventions for detailed status: Provisioned, BindingsReady,
+	// CertificateReady and Expired are always set, others while they apply
 	// +optional
 	Conditions []metav1.Condition `json:"conditions,omitempty"`
+
+	// Recommendations lists roles that could be removed or narrowed based on observed usage.
+	// Only populated when the UsageTracking feature gate is enabled.
+	// +optional
+	Recommendations []RoleRecommendation `json:"recommendations,omitempty"`
+
+	// UnusedPermissions lists granted permissions that were not used during the usage window.
+	// Only populated when the UsageTracking feature gate is enabled.
+	// +optional
+	UnusedPermissions []UnusedPermission `json:"unusedPermissions,omitempty"`
+
+	// Bindings lists the RoleBindings and ClusterRoleBindings the user is to have and whether
+	// each one is bound
+	// +optional
+	Bindings []BindingStatus `json:"bindings,omitempty"`
+
+	// EffectiveAccess summarizes what the roles bound for the user allow, per namespace
+	// +optional
+	EffectiveAccess []NamespaceAccess `json:"effectiveAccess,omitempty"`
+
+	// LastActivity is when the user last made a request to the API server, as seen in audit
+	// events. Only maintained when the UsageTracking feature gate is enabled.
+	// +optional
+	LastActivity *metav1.Time `json:"lastActivity,omitempty"`
+
+	// PlannedAccess records the binding changes computed but not applied while the user is
+	// reconciled in report-only binding mode
+	// +optional
+	PlannedAccess *AccessPlan `json:"plannedAccess,omitempty"`
+
+	// CredentialSecret is where the credential Secret is currently written
+	// +optional
+	CredentialSecret *corev1.SecretReference `json:"credentialSecret,omitempty"`
+
+	// BreakGlassUntil is when break-glass access ends and the User is deleted. It is fixed on the
+	// first reconcile from the creation time and never extended.
+	// +optional
+	BreakGlassUntil *metav1.Time `json:"breakGlassUntil,omitempty"`
+
+	// Revocation records who revoked the user and when, while spec.revoked is set
+	// +optional
+	Revocation *Revocation `json:"revocation,omitempty"`
+
+	// TimedGrants tracks the grants with an expiresAt or duration
+	// +optional
+	TimedGrants []TimedGrant `json:"timedGrants,omitempty"`
+
+	// TokenExpiry is when the ServiceAccount token in a machine user's credential Secret
+	// expires. A new one is written before then.
+	// +optional
+	TokenExpiry *metav1.Time `json:"tokenExpiry,omitempty"`
+
+	// Clusters reports the user on each member cluster it is provisioned on
+	// +optional
+	// +listType=map
+	// +listMapKey=name
+	Clusters []ClusterStatus `json:"clusters,omitempty"`
+
+	// EKSPrincipalARN is the IAM principal of the access entry the controller created on the
+	// EKS cluster. It is used to delete the entry once spec.eks changes.
+	// +optional
+	EKSPrincipalARN string `json:"eksPrincipalARN,omitempty"`
+
+	// GCPAccount is the Google account the controller granted GCPRoles to
+	// +optional
+	GCPAccount string `json:"gcpAccount,omitempty"`
+
+	// GCPRoles are the IAM roles the controller granted to GCPAccount on the project. Roles the
+	// account held before are not listed and never revoked.
+	// +optional
+	GCPRoles []string `json:"gcpRoles,omitempty"`
 }
 
 //
@@ -75,7 +877,9 @@ type UserStatus struct {
 // +kubebuilder:resource:scope=Cluster
 // +kubebuilder:printcolumn:name="Phase",type="string",JSONPath=".status.phase",description="Current phase of the user"
 // +kubebuilder:printcolumn:name="Expiry",type="string",JSONPath=".status.expiryTime",description="Certificate expiry time"
+// +kubebuilder:printcolumn:name="Expiring",type="string",JSONPath=".status.conditions[?(@.type==\"ExpiringSoon\")].status",description="Whether the certificate is within an expiry warning window"
 // +kubebuilder:printcolumn:name="Age",type="date",JSONPath=".metadata.creationTimestamp",description="Time since the user was created"
+// +kubebuilder:printcolumn:name="Last Activity",type="date",JSONPath=".status.lastActivity",description="Last API request by the user",priority=1
 // +kubebuilder:printcolumn:name="Message",type="string",JSONPath=".status.message",description="Status message",priority=1
 
 // User is the Schema for the users API
@@ -0,0 +1,70 @@
+package v1alpha1
+
+import (
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+// ClaimedRole is a Role a UserClaim requests for its User
+type ClaimedRole struct {
+	// Namespace of the Role. Defaults to the claim's namespace; other namespaces must belong to
+	// the same tenant.
+	// +optional
+	Namespace string `json:"namespace,omitempty"`
+
+	// ExistingRole is the name of the Role inside that namespace
+	// +kubebuilder:validation:MinLength=1
+	ExistingRole string `json:"existingRole"`
+}
+
+// UserClaimSpec requests a User with access to the tenant's namespaces
+type UserClaimSpec struct {
+	// Roles are bound for the User in the tenant's namespaces
+	// +optional
+	Roles []ClaimedRole `json:"roles,omitempty"`
+}
+
+// UserClaimStatus reports the User materialized for the claim
+type UserClaimStatus struct {
+	// UserName is the cluster-scoped User created for the claim
+	// +optional
+	UserName string `json:"userName,omitempty"`
+
+	// ObservedGeneration is the generation last reconciled by the controller
+	// +optional
+	ObservedGeneration int64 `json:"observedGeneration,omitempty"`
+
+	// Conditions follow Kubernetes conventions; Ready is false while the User could not be
+	// created, e.g. because another User has the name, or roles are outside the tenant
+	// +optional
+	Conditions []metav1.Condition `json:"conditions,omitempty"`
+}
+
+// +kubebuilder:object:root=true
+// +kubebuilder:subresource:status
+// +kubebuilder:printcolumn:name="User",type="string",JSONPath=".status.userName",description="User created for the claim"
+// +kubebuilder:printcolumn:name="Ready",type="string",JSONPath=".status.conditions[?(@.type==\"Ready\")].status",description="Whether the User is in place"
+// +kubebuilder:printcolumn:name="Age",type="date",JSONPath=".metadata.creationTimestamp",description="Time since the claim was created"
+
+// UserClaim lets tenant admins request a User from within their namespace. The controller
+// creates a cluster-scoped User of the claim's name with the claimed Roles, limited to the
+// tenant's namespaces, and deletes it with the claim.
+type UserClaim struct {
+	metav1.TypeMeta   `json:",inline"`
+	metav1.ObjectMeta `json:"metadata,omitempty"`
+
+	Spec   UserClaimSpec   `json:"spec,omitempty"`
+	Status UserClaimStatus `json:"status,omitempty"`
+}
+
+// +kubebuilder:object:root=true
+
+// UserClaimList contains a list of UserClaim
+type UserClaimList struct {
+	metav1.TypeMeta `json:",inline"`
+	metav1.ListMeta `json:"metadata,omitempty"`
+	Items           []UserClaim `json:"items"`
+}
+
+func init() {
+	SchemeBuilder.Register(&UserClaim{}, &UserClaimList{})
+}
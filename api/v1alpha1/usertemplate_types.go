@@ -0,0 +1,63 @@
+package v1alpha1
+
+import (
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+// UserTemplateSpec holds the defaults shared by the Users referencing the template
+type UserTemplateSpec struct {
+	// Roles are bound for every User of the template, in addition to the User's own. A User's
+	// entry for the same Role and namespace takes precedence.
+	// +optional
+	Roles []RoleSpec `json:"roles,omitempty"`
+
+	// ClusterRoles are bound for every User of the template, in addition to the User's own. A
+	// User's entry for the same ClusterRole takes precedence.
+	// +optional
+	ClusterRoles []ClusterRoleSpec `json:"clusterRoles,omitempty"`
+
+	// CertificateDuration is the requested lifetime of the Users' certificates. Defaults to the
+	// operator's certificateDuration.
+	// +optional
+	CertificateDuration *metav1.Duration `json:"certificateDuration,omitempty"`
+
+	// KeyAlgorithm is used for private keys generated for the Users. Defaults to the operator's
+	// keyAlgorithm.
+	// +optional
+	KeyAlgorithm KeyAlgorithm `json:"keyAlgorithm,omitempty"`
+
+	// Labels are added to Users of the template that do not set them
+	// +optional
+	Labels map[string]string `json:"labels,omitempty"`
+
+	// SlackChannel receives the Slack notifications of Users without the
+	// auth.openkube.io/slack-channel annotation
+	// +optional
+	SlackChannel string `json:"slackChannel,omitempty"`
+}
+
+// +kubebuilder:object:root=true
+// +kubebuilder:resource:scope=Cluster
+// +kubebuilder:printcolumn:name="Age",type="date",JSONPath=".metadata.creationTimestamp",description="Time since the template was created"
+
+// UserTemplate holds defaults that Users reference with spec.templateRef, so similar Users do
+// not repeat the same spec. Changes apply to every User referencing the template.
+type UserTemplate struct {
+	metav1.TypeMeta   `json:",inline"`
+	metav1.ObjectMeta `json:"metadata,omitempty"`
+
+	Spec UserTemplateSpec `json:"spec,omitempty"`
+}
+
+// +kubebuilder:object:root=true
+
+// UserTemplateList contains a list of UserTemplate
+type UserTemplateList struct {
+	metav1.TypeMeta `json:",inline"`
+	metav1.ListMeta `json:"metadata,omitempty"`
+	Items           []UserTemplate `json:"items"`
+}
+
+func init() {
+	SchemeBuilder.Register(&UserTemplate{}, &UserTemplateList{})
+}
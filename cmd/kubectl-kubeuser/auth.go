@@ -0,0 +1,348 @@
+/*
+Copyright 2025.
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+*/
+
+package main
+
+import (
+	"context"
+	"crypto/sha256"
+	"crypto/tls"
+	"crypto/x509"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/spf13/cobra"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	clientauthenticationv1 "k8s.io/client-go/pkg/apis/clientauthentication/v1"
+)
+
+const (
+	// deviceCodeGrant is the grant type devices poll the token endpoint with, see RFC 8628
+	deviceCodeGrant = "urn:ietf:params:oauth:grant-type:device_code"
+	// tokenRenewBefore is how long before it expires a cached token is replaced, so kubectl does
+	// not send a token that expires on its way
+	tokenRenewBefore = time.Minute
+)
+
+type authOptions struct {
+	issuerURL string
+	clientID  string
+	caFile    string
+	cacheDir  string
+}
+
+func newAuthCommand() *cobra.Command {
+	o := &authOptions{}
+	cmd := &cobra.Command{
+		Use:   "auth",
+		Short: "Log in to the KubeUser OIDC issuer",
+	}
+	cmd.PersistentFlags().StringVar(&o.issuerURL, "issuer-url", "", "URL of the KubeUser OIDC issuer, --oidc-issuer-url of the manager")
+	cmd.PersistentFlags().StringVar(&o.clientID, "client-id", "kubeuser", "Client ID of the issuer, --oidc-client-id of the manager")
+	cmd.PersistentFlags().StringVar(&o.caFile, "certificate-authority", "", "CA file to verify the issuer with instead of the system roots")
+	cmd.PersistentFlags().StringVar(&o.cacheDir, "cache-dir", defaultAuthCacheDir(), "Directory tokens are cached in")
+	_ = cmd.MarkPersistentFlagRequired("issuer-url")
+
+	cmd.AddCommand(&cobra.Command{
+		Use:   "login",
+		Short: "Print an ID token as ExecCredential for kubectl",
+		Long: `Credential helper for kubeconfigs authenticating through the KubeUser OIDC issuer.
+kubectl runs it when it needs a token. It prints the cached ID token while it is
+valid, refreshes it with the cached refresh token, and otherwise starts a device
+login: open the printed URL, sign in with your KubeUser client certificate or a
+Kubernetes token and approve the code shown. The kubeconfig holds no secret.`,
+		Example: `  kubectl config set-credentials jane --exec-api-version=client.authentication.k8s.io/v1 \
+    --exec-command=kubectl --exec-interactive-mode=IfAvailable \
+    --exec-arg=kubeuser --exec-arg=auth --exec-arg=login \
+    --exec-arg=--issuer-url=https://kubeuser-oidc.example.com:8446`,
+		Args: cobra.NoArgs,
+		RunE: func(cmd *cobra.Command, _ []string) error {
+			ctx := cmd.Context()
+			if ctx == nil {
+				ctx = context.Background()
+			}
+			return o.login(ctx)
+		},
+	})
+	cmd.AddCommand(&cobra.Command{
+		Use:   "logout",
+		Short: "Revoke the cached refresh token and remove the cached tokens",
+		Args:  cobra.NoArgs,
+		RunE: func(cmd *cobra.Command, _ []string) error {
+			ctx := cmd.Context()
+			if ctx == nil {
+				ctx = context.Background()
+			}
+			return o.logout(ctx)
+		},
+	})
+	return cmd
+}
+
+// defaultAuthCacheDir returns the directory next to kubectl's own caches
+func defaultAuthCacheDir() string {
+	home, err := os.UserHomeDir()
+	if err != nil {
+		return ""
+	}
+	return filepath.Join(home, ".kube", "cache", "kubeuser")
+}
+
+// cachedTokens are the tokens of a login, kept between runs of the helper
+type cachedTokens struct {
+	IDToken      string    `json:"id_token"`
+	RefreshToken string    `json:"refresh_token,omitempty"`
+	Expiry       time.Time `json:"expiry"`
+}
+
+// tokenResponse is the response of the token and device authorization endpoints
+type tokenResponse struct {
+	IDToken          string `json:"id_token"`
+	RefreshToken     string `json:"refresh_token"`
+	ExpiresIn        int64  `json:"expires_in"`
+	DeviceCode       string `json:"device_code"`
+	UserCode         string `json:"user_code"`
+	VerificationURI  string `json:"verification_uri"`
+	VerificationFull string `json:"verification_uri_complete"`
+	Interval         int64  `json:"interval"`
+	Error            string `json:"error"`
+	Description      string `json:"error_description"`
+}
+
+// oauthError is an error response of the issuer
+type oauthError struct {
+	code        string
+	description string
+}
+
+func (e *oauthError) Error() string {
+	if e.description == "" {
+		return e.code
+	}
+	return e.code + ": " + e.description
+}
+
+func (o *authOptions) login(ctx context.Context) error {
+	client, err := o.httpClient()
+	if err != nil {
+		return err
+	}
+	tokens, err := o.readCache()
+	if err != nil {
+		return err
+	}
+	if tokens == nil || time.Until(tokens.Expiry) <= tokenRenewBefore {
+		tokens, err = o.obtain(ctx, client, tokens)
+		if err != nil {
+			return err
+		}
+		if err := o.writeCache(tokens); err != nil {
+			return err
+		}
+	}
+
+	// kubectl runs the helper again once the token has expired
+	return json.NewEncoder(os.Stdout).Encode(&clientauthenticationv1.ExecCredential{
+		TypeMeta: metav1.TypeMeta{APIVersion: clientauthenticationv1.SchemeGroupVersion.String(), Kind: "ExecCredential"},
+		Status: &clientauthenticationv1.ExecCredentialStatus{
+			ExpirationTimestamp: &metav1.Time{Time: tokens.Expiry},
+			Token:               tokens.IDToken,
+		},
+	})
+}
+
+// obtain returns new tokens, refreshing cached ones when possible and logging in on the device
+// otherwise
+func (o *authOptions) obtain(ctx context.Context, client *http.Client, cached *cachedTokens) (*cachedTokens, error) {
+	if cached != nil && cached.RefreshToken != "" {
+		resp, err := o.post(ctx, client, "/token", url.Values{"grant_type": {"refresh_token"}, "refresh_token": {cached.RefreshToken}})
+		var oerr *oauthError
+		switch {
+		case err == nil:
+			return newCachedTokens(resp), nil
+		case errors.As(err, &oerr) && oerr.code == "invalid_grant":
+			fmt.Fprintln(os.Stderr, "Your KubeUser login ended, log in again.")
+		default:
+			return nil, err
+		}
+	}
+	return o.deviceLogin(ctx, client)
+}
+
+// deviceLogin asks the user to approve this device and polls the issuer until they did
+func (o *authOptions) deviceLogin(ctx context.Context, client *http.Client) (*cachedTokens, error) {
+	auth, err := o.post(ctx, client, "/device_authorization", url.Values{"scope": {"openid offline_access"}})
+	if err != nil {
+		return nil, err
+	}
+	fmt.Fprintf(os.Stderr, "To log in, open %s and enter the code %s\n", auth.VerificationURI, auth.UserCode)
+	if auth.VerificationFull != "" {
+		fmt.Fprintf(os.Stderr, "or open %s\n", auth.VerificationFull)
+	}
+
+	interval := time.Duration(auth.Interval) * time.Second
+	if interval <= 0 {
+		interval = 5 * time.Second
+	}
+	for {
+		select {
+		case <-ctx.Done():
+			return nil, ctx.Err()
+		case <-time.After(interval):
+		}
+		resp, err := o.post(ctx, client, "/token", url.Values{"grant_type": {deviceCodeGrant}, "device_code": {auth.DeviceCode}})
+		var oerr *oauthError
+		switch {
+		case err == nil:
+			return newCachedTokens(resp), nil
+		case !errors.As(err, &oerr):
+			return nil, err
+		case oerr.code == "authorization_pending":
+		case oerr.code == "slow_down":
+			interval += 5 * time.Second
+		case oerr.code == "expired_token":
+			return nil, errors.New("the code expired before it was approved, run the command again")
+		case oerr.code == "access_denied":
+			return nil, errors.New("the login was denied")
+		default:
+			return nil, err
+		}
+	}
+}
+
+func newCachedTokens(resp *tokenResponse) *cachedTokens {
+	return &cachedTokens{
+		IDToken:      resp.IDToken,
+		RefreshToken: resp.RefreshToken,
+		Expiry:       time.Now().Add(time.Duration(resp.ExpiresIn) * time.Second),
+	}
+}
+
+func (o *authOptions) logout(ctx context.Context) error {
+	tokens, err := o.readCache()
+	if err != nil || tokens == nil {
+		return err
+	}
+	if tokens.RefreshToken != "" {
+		client, err := o.httpClient()
+		if err != nil {
+			return err
+		}
+		if _, err := o.post(ctx, client, "/revoke", url.Values{"token": {tokens.RefreshToken}, "token_type_hint": {"refresh_token"}}); err != nil {
+			return fmt.Errorf("revoking the refresh token: %w", err)
+		}
+	}
+	if err := os.Remove(o.cacheFile()); err != nil && !errors.Is(err, os.ErrNotExist) {
+		return err
+	}
+	return nil
+}
+
+// post posts form to the issuer endpoint path and decodes the response
+func (o *authOptions) post(ctx context.Context, client *http.Client, path string, form url.Values) (*tokenResponse, error) {
+	form.Set("client_id", o.clientID)
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, strings.TrimSuffix(o.issuerURL, "/")+path, strings.NewReader(form.Encode()))
+	if err != nil {
+		return nil, err
+	}
+	req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+	resp, err := client.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	defer func() { _ = resp.Body.Close() }()
+	body, err := io.ReadAll(io.LimitReader(resp.Body, 1<<20))
+	if err != nil {
+		return nil, err
+	}
+	var decoded tokenResponse
+	if len(body) > 0 {
+		if err := json.Unmarshal(body, &decoded); err != nil {
+			return nil, fmt.Errorf("%s: unexpected response %s", path, resp.Status)
+		}
+	}
+	if decoded.Error != "" {
+		return nil, &oauthError{code: decoded.Error, description: decoded.Description}
+	}
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("%s: unexpected response %s", path, resp.Status)
+	}
+	return &decoded, nil
+}
+
+func (o *authOptions) httpClient() (*http.Client, error) {
+	if o.caFile == "" {
+		return &http.Client{Timeout: 30 * time.Second}, nil
+	}
+	data, err := os.ReadFile(o.caFile)
+	if err != nil {
+		return nil, err
+	}
+	pool := x509.NewCertPool()
+	if !pool.AppendCertsFromPEM(data) {
+		return nil, fmt.Errorf("%s holds no PEM certificates", o.caFile)
+	}
+	transport := http.DefaultTransport.(*http.Transport).Clone()
+	transport.TLSClientConfig = &tls.Config{RootCAs: pool, MinVersion: tls.VersionTLS12}
+	return &http.Client{Timeout: 30 * time.Second, Transport: transport}, nil
+}
+
+// cacheFile returns the file the tokens of the issuer and client are cached in
+func (o *authOptions) cacheFile() string {
+	sum := sha256.Sum256([]byte(o.issuerURL + "\x00" + o.clientID))
+	return filepath.Join(o.cacheDir, hex.EncodeToString(sum[:16])+".json")
+}
+
+// readCache returns the cached tokens, or nil when there are none
+func (o *authOptions) readCache() (*cachedTokens, error) {
+	data, err := os.ReadFile(o.cacheFile())
+	if errors.Is(err, os.ErrNotExist) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	var tokens cachedTokens
+	if err := json.Unmarshal(data, &tokens); err != nil {
+		// A corrupt cache only costs a new login
+		return nil, nil
+	}
+	return &tokens, nil
+}
+
+// writeCache stores tokens readable by the current user only, since the refresh token logs in
+// as them
+func (o *authOptions) writeCache(tokens *cachedTokens) error {
+	if err := os.MkdirAll(o.cacheDir, 0o700); err != nil {
+		return err
+	}
+	data, err := json.Marshal(tokens)
+	if err != nil {
+		return err
+	}
+	tmp, err := os.CreateTemp(o.cacheDir, ".tokens-*")
+	if err != nil {
+		return err
+	}
+	defer func() { _ = os.Remove(tmp.Name()) }()
+	if _, err := tmp.Write(data); err != nil {
+		_ = tmp.Close()
+		return err
+	}
+	if err := tmp.Close(); err != nil {
+		return err
+	}
+	return os.Rename(tmp.Name(), o.cacheFile())
+}
@@ -0,0 +1,114 @@
+/*
+Copyright 2025.
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+*/
+
+package main
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"strings"
+	"time"
+
+	"github.com/spf13/cobra"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime"
+
+	authv1alpha1 "github.com/openkube-hub/KubeUser/api/v1alpha1"
+)
+
+type createOptions struct {
+	*options
+	roles        []string
+	clusterRoles []string
+	csrFile      string
+	wait         bool
+	timeout      time.Duration
+	output       string
+}
+
+func newCreateCommand(opts *options) *cobra.Command {
+	o := &createOptions{options: opts}
+	cmd := &cobra.Command{
+		Use:   "create USER",
+		Short: "Create a user bound to existing roles",
+		Long: `Creates a User bound to existing Roles and ClusterRoles. With --wait the command
+returns once the user is Active, and with --output it also writes the generated
+kubeconfig, so a new user can be onboarded in one step.`,
+		Example: `  # Read access in dev, kubeconfig written once the certificate is issued
+  kubectl kubeuser create jane --role dev/developer --cluster-role view -o jane.kubeconfig
+
+  # Keep the private key local; the kubeconfig then lacks the key
+  kubectl kubeuser create jane --role dev/developer --csr jane.csr -o jane.kubeconfig`,
+		Args: cobra.ExactArgs(1),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			return o.run(cmd.Context(), args[0])
+		},
+	}
+	cmd.Flags().StringArrayVar(&o.roles, "role", nil, "Role to bind as NAMESPACE/ROLE; may be repeated")
+	cmd.Flags().StringArrayVar(&o.clusterRoles, "cluster-role", nil, "ClusterRole to bind; may be repeated")
+	cmd.Flags().StringVar(&o.csrFile, "csr", "",
+		"PEM CSR with the user name as common name, signed instead of a generated key")
+	cmd.Flags().BoolVar(&o.wait, "wait", false, "Wait until the user is Active")
+	cmd.Flags().DurationVar(&o.timeout, "timeout", defaultWaitTimeout, "How long to wait with --wait or --output")
+	cmd.Flags().StringVarP(&o.output, "output", "o", "",
+		"File to write the kubeconfig to once the user is Active; implies --wait")
+	return cmd
+}
+
+func (o *createOptions) run(ctx context.Context, username string) error {
+	if ctx == nil {
+		ctx = context.Background()
+	}
+	user := &authv1alpha1.User{
+		TypeMeta:   metav1.TypeMeta{APIVersion: authv1alpha1.GroupVersion.String(), Kind: "User"},
+		ObjectMeta: metav1.ObjectMeta{Name: username},
+	}
+	for _, role := range o.roles {
+		namespace, name, ok := strings.Cut(role, "/")
+		if !ok || namespace == "" || name == "" {
+			return fmt.Errorf("invalid --role %q, must be NAMESPACE/ROLE", role)
+		}
+		user.Spec.Roles = append(user.Spec.Roles, authv1alpha1.RoleSpec{Namespace: namespace, ExistingRole: name})
+	}
+	for _, clusterRole := range o.clusterRoles {
+		user.Spec.ClusterRoles = append(user.Spec.ClusterRoles,
+			authv1alpha1.ClusterRoleSpec{ExistingClusterRole: clusterRole})
+	}
+	if o.csrFile != "" {
+		csr, err := os.ReadFile(o.csrFile)
+		if err != nil {
+			return fmt.Errorf("reading --csr: %w", err)
+		}
+		user.Spec.CSR = string(csr)
+	}
+
+	obj, err := runtime.DefaultUnstructuredConverter.ToUnstructured(user)
+	if err != nil {
+		return err
+	}
+	dyn, clientset, err := o.clients()
+	if err != nil {
+		return err
+	}
+	_, err = dyn.Resource(userResource).Create(ctx, &unstructured.Unstructured{Object: obj}, metav1.CreateOptions{})
+	if err != nil {
+		return fmt.Errorf("creating user %s: %w", username, err)
+	}
+	fmt.Fprintf(os.Stderr, "Created user %s\n", username)
+
+	if !o.wait && o.output == "" {
+		return nil
+	}
+	if _, err := waitForActive(ctx, dyn, username, o.timeout); err != nil {
+		return err
+	}
+	if o.output == "" {
+		return nil
+	}
+	return (&fetchOptions{options: o.options, output: o.output}).fetch(ctx, dyn, clientset, username)
+}
@@ -0,0 +1,154 @@
+/*
+Copyright 2025.
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+*/
+
+package main
+
+import (
+	"context"
+	"crypto/x509"
+	"encoding/json"
+	"encoding/pem"
+	"errors"
+	"fmt"
+	"os"
+	"slices"
+
+	"github.com/spf13/cobra"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	clientauthenticationv1 "k8s.io/client-go/pkg/apis/clientauthentication/v1"
+	clientcmdapi "k8s.io/client-go/tools/clientcmd/api"
+
+	"github.com/openkube-hub/KubeUser/internal/credentials"
+)
+
+// credentialLayoutAnnotation records the layout the controller wrote the credential Secret with
+const credentialLayoutAnnotation = "auth.openkube.io/credential-layout"
+
+// Environment variables selecting the cluster access the credential helper reads Secrets with,
+// since $KUBECONFIG usually points at the kubeconfig that runs the helper
+const (
+	envHelperKubeconfig = "KUBEUSER_KUBECONFIG"
+	envHelperContext    = "KUBEUSER_CONTEXT"
+)
+
+type credentialOptions struct {
+	*options
+}
+
+func newCredentialCommand(opts *options) *cobra.Command {
+	o := &credentialOptions{options: opts}
+	cmd := &cobra.Command{
+		Use:   "credential USER",
+		Short: "Print a user's client certificate as ExecCredential for kubectl",
+		Long: `Credential helper for kubeconfigs generated with spec.output.format: execCredential.
+kubectl runs it when it needs the user's client certificate. It reads the
+certificate and key from the user's credential Secret, so neither is stored in
+the kubeconfig itself.
+
+The Secret is read with --kubeconfig and --context, or $KUBEUSER_KUBECONFIG and
+$KUBEUSER_CONTEXT, e.g. a kubeconfig signing in through your identity provider.`,
+		Example: `  export KUBEUSER_KUBECONFIG=~/.kube/sso
+  kubectl kubeuser fetch jane -o jane.kubeconfig
+  kubectl --kubeconfig jane.kubeconfig get pods -n dev`,
+		Args: cobra.ExactArgs(1),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			ctx := cmd.Context()
+			if ctx == nil {
+				ctx = context.Background()
+			}
+			return o.run(ctx, args[0])
+		},
+	}
+	return cmd
+}
+
+func (o *credentialOptions) run(ctx context.Context, username string) error {
+	if o.kubeconfig == "" {
+		o.kubeconfig = os.Getenv(envHelperKubeconfig)
+	}
+	if o.context == "" {
+		o.context = os.Getenv(envHelperContext)
+	}
+	if err := o.checkNotSelf(); err != nil {
+		return err
+	}
+
+	dyn, clientset, err := o.clients()
+	if err != nil {
+		return err
+	}
+	user, err := getUser(ctx, dyn, username)
+	if err != nil {
+		return err
+	}
+	namespace, name := o.credentialSecret(user)
+	secret, err := clientset.CoreV1().Secrets(namespace).Get(ctx, name, metav1.GetOptions{})
+	if err != nil {
+		return fmt.Errorf("credentials of user %s (phase %q): %w", username, user.Status.Phase, err)
+	}
+
+	layout, err := credentials.ParseLayout(secret.Annotations[credentialLayoutAnnotation])
+	if err != nil {
+		return fmt.Errorf("secret %s/%s: %w", namespace, name, err)
+	}
+	cert, err := layout.ClientCertificate(secret.Data)
+	if err != nil {
+		return err
+	}
+	key, err := layout.ClientKey(secret.Data)
+	if err != nil {
+		return err
+	}
+	if cert == nil || key == nil {
+		return fmt.Errorf("secret %s/%s holds no client certificate and key for user %s", namespace, name, username)
+	}
+	block, _ := pem.Decode(cert)
+	if block == nil {
+		return errors.New("client certificate is not PEM encoded")
+	}
+	parsed, err := x509.ParseCertificate(block.Bytes)
+	if err != nil {
+		return fmt.Errorf("parsing client certificate: %w", err)
+	}
+
+	// kubectl runs the helper again once the certificate has expired
+	return json.NewEncoder(os.Stdout).Encode(&clientauthenticationv1.ExecCredential{
+		TypeMeta: metav1.TypeMeta{APIVersion: clientauthenticationv1.SchemeGroupVersion.String(), Kind: "ExecCredential"},
+		Status: &clientauthenticationv1.ExecCredentialStatus{
+			ExpirationTimestamp:   &metav1.Time{Time: parsed.NotAfter},
+			ClientCertificateData: string(cert),
+			ClientKeyData:         string(key),
+		},
+	})
+}
+
+// checkNotSelf fails when the selected context authenticates through this helper, which would
+// otherwise call itself until kubectl gives up
+func (o *credentialOptions) checkNotSelf() error {
+	config, err := o.clientConfig().RawConfig()
+	if err != nil {
+		return err
+	}
+	contextName := o.context
+	if contextName == "" {
+		contextName = config.CurrentContext
+	}
+	kubeContext, ok := config.Contexts[contextName]
+	if !ok {
+		return nil
+	}
+	if auth, ok := config.AuthInfos[kubeContext.AuthInfo]; ok && runsCredentialHelper(auth) {
+		return fmt.Errorf("context %q itself uses the kubeuser credential helper; set $%s or --kubeconfig to "+
+			"a kubeconfig with your own access to the cluster", contextName, envHelperKubeconfig)
+	}
+	return nil
+}
+
+// runsCredentialHelper returns whether auth runs "kubectl kubeuser credential"
+func runsCredentialHelper(auth *clientcmdapi.AuthInfo) bool {
+	return auth.Exec != nil && slices.Contains(auth.Exec.Args, "credential") &&
+		(slices.Contains(auth.Exec.Args, "kubeuser") || auth.Exec.Command == "kubectl-kubeuser")
+}
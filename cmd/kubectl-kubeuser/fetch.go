@@ -0,0 +1,83 @@
+/*
+Copyright 2025.
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+*/
+
+package main
+
+import (
+	"context"
+	"fmt"
+	"os"
+
+	"github.com/spf13/cobra"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/dynamic"
+	"k8s.io/client-go/kubernetes"
+
+	"github.com/openkube-hub/KubeUser/internal/credentials"
+	"github.com/openkube-hub/KubeUser/internal/pgp"
+)
+
+type fetchOptions struct {
+	*options
+	output string
+}
+
+func newFetchCommand(opts *options) *cobra.Command {
+	o := &fetchOptions{options: opts}
+	cmd := &cobra.Command{
+		Use:   "fetch USER",
+		Short: "Print the kubeconfig generated for a user",
+		Long: `Reads the kubeconfig from the user's <user>-kubeconfig Secret, honouring custom
+credential layouts set with spec.output.keys or --credential-layout.`,
+		Example: `  kubectl kubeuser fetch jane -o jane.kubeconfig
+  kubectl --kubeconfig jane.kubeconfig auth can-i get pods -n dev`,
+		Args: cobra.ExactArgs(1),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			ctx := cmd.Context()
+			if ctx == nil {
+				ctx = context.Background()
+			}
+			dyn, clientset, err := o.clients()
+			if err != nil {
+				return err
+			}
+			return o.fetch(ctx, dyn, clientset, args[0])
+		},
+	}
+	cmd.Flags().StringVarP(&o.output, "output", "o", "", "File to write the kubeconfig to; defaults to stdout")
+	return cmd
+}
+
+func (o *fetchOptions) fetch(ctx context.Context, dyn dynamic.Interface, clientset kubernetes.Interface,
+	username string) error {
+	user, err := getUser(ctx, dyn, username)
+	if err != nil {
+		return err
+	}
+	namespace, name := o.credentialSecret(user)
+	secret, err := clientset.CoreV1().Secrets(namespace).Get(ctx, name, metav1.GetOptions{})
+	if err != nil {
+		return fmt.Errorf("kubeconfig of user %s (phase %q): %w", username, user.Status.Phase, err)
+	}
+	data, err := credentials.FindKubeconfig(user.Spec.Output, secret.Data)
+	if err != nil {
+		return fmt.Errorf("secret %s/%s: %w", namespace, name, err)
+	}
+	if pgp.IsMessage(data) {
+		fmt.Fprintf(os.Stderr, "The kubeconfig of %s is encrypted to their OpenPGP key, decrypt it with gpg --decrypt\n",
+			username)
+	}
+
+	if o.output == "" {
+		_, err = os.Stdout.Write(data)
+		return err
+	}
+	if err := os.WriteFile(o.output, data, 0o600); err != nil {
+		return err
+	}
+	fmt.Fprintf(os.Stderr, "Wrote kubeconfig for %s to %s, valid until %s\n", username, o.output, user.Status.ExpiryTime)
+	return nil
+}
@@ -0,0 +1,160 @@
+/*
+Copyright 2025.
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+*/
+
+package main
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"strings"
+	"text/tabwriter"
+	"time"
+
+	"github.com/spf13/cobra"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+
+	authv1alpha1 "github.com/openkube-hub/KubeUser/api/v1alpha1"
+	"github.com/openkube-hub/KubeUser/internal/history"
+)
+
+var accessGrantRecordResource = schema.GroupVersionResource{
+	Group: "auth.openkube.io", Version: "v1alpha1", Resource: "accessgrantrecords",
+}
+
+type historyOptions struct {
+	*options
+	role      string
+	namespace string
+	since     string
+	until     string
+}
+
+func newHistoryCommand(opts *options) *cobra.Command {
+	o := &historyOptions{options: opts}
+	cmd := &cobra.Command{
+		Use:   "history [USER]",
+		Short: "Show who held which roles, and when",
+		Long: `Replays the AccessGrantRecords into the periods users held their bindings. Filter by
+user, role, namespace and time to answer questions like "who had edit in prod during
+May". With --namespace, ClusterRoles bound cluster-wide are listed too, as they apply
+in every namespace.`,
+		Example: `  kubectl kubeuser history --role edit --namespace prod --since 2025-05-01 --until 2025-06-01
+  kubectl kubeuser history jane`,
+		Args: cobra.MaximumNArgs(1),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			ctx := cmd.Context()
+			if ctx == nil {
+				ctx = context.Background()
+			}
+			username := ""
+			if len(args) == 1 {
+				username = args[0]
+			}
+			return o.run(ctx, username)
+		},
+	}
+	cmd.Flags().StringVar(&o.role, "role", "", "Only grants of this Role or ClusterRole")
+	cmd.Flags().StringVar(&o.namespace, "namespace", "", "Only grants applying in this namespace")
+	cmd.Flags().StringVar(&o.since, "since", "", "Only grants held at or after this date (YYYY-MM-DD or RFC 3339)")
+	cmd.Flags().StringVar(&o.until, "until", "", "Only grants held before this date (YYYY-MM-DD or RFC 3339)")
+	return cmd
+}
+
+// parseDate reads a date or time flag; empty is the zero time
+func parseDate(flag, value string) (time.Time, error) {
+	if value == "" {
+		return time.Time{}, nil
+	}
+	if t, err := time.Parse(time.DateOnly, value); err == nil {
+		return t, nil
+	}
+	t, err := time.Parse(time.RFC3339, value)
+	if err != nil {
+		return time.Time{}, fmt.Errorf("--%s must be YYYY-MM-DD or RFC 3339, got %q", flag, value)
+	}
+	return t, nil
+}
+
+func (o *historyOptions) run(ctx context.Context, username string) error {
+	since, err := parseDate("since", o.since)
+	if err != nil {
+		return err
+	}
+	until, err := parseDate("until", o.until)
+	if err != nil {
+		return err
+	}
+	dyn, _, err := o.clients()
+	if err != nil {
+		return err
+	}
+	listOptions := metav1.ListOptions{}
+	if username != "" {
+		listOptions.LabelSelector = history.UserLabel + "=" + username
+	}
+	list, err := dyn.Resource(accessGrantRecordResource).List(ctx, listOptions)
+	if err != nil {
+		return fmt.Errorf("listing access grant records: %w", err)
+	}
+	records := make([]authv1alpha1.AccessGrantRecord, 0, len(list.Items))
+	for _, item := range list.Items {
+		var record authv1alpha1.AccessGrantRecord
+		if err := runtime.DefaultUnstructuredConverter.FromUnstructured(item.Object, &record); err != nil {
+			return fmt.Errorf("access grant record %s: %w", item.GetName(), err)
+		}
+		records = append(records, record)
+	}
+
+	w := tabwriter.NewWriter(os.Stdout, 0, 4, 2, ' ', 0)
+	fmt.Fprintln(w, "USER\tROLE\tNAMESPACE\tFROM\tUNTIL\tGRANTED BY\tREMOVED BY\tCERTIFICATES")
+	shown := 0
+	for _, grant := range history.Grants(records) {
+		binding := grant.Binding
+		if o.role != "" && binding.RoleName != o.role {
+			continue
+		}
+		if o.namespace != "" && binding.Namespace != o.namespace && binding.Kind != "ClusterRoleBinding" {
+			continue
+		}
+		if !grant.Overlaps(since, until) {
+			continue
+		}
+		shown++
+		namespace := binding.Namespace
+		if namespace == "" {
+			namespace = "*"
+		}
+		removedBy := grant.RemovedBy
+		if grant.RemovalReason != "" {
+			removedBy = strings.TrimSpace(removedBy + " (" + grant.RemovalReason + ")")
+		}
+		fmt.Fprintf(w, "%s\t%s/%s\t%s\t%s\t%s\t%s\t%s\t%s\n", grant.User, binding.RoleKind, binding.RoleName, namespace,
+			formatGrantTime(grant.From, "before history"), formatGrantTime(grant.Until, "now"),
+			orDash(grant.GrantedBy), orDash(removedBy), orDash(strings.Join(grant.SerialNumbers, ",")))
+	}
+	if shown == 0 {
+		fmt.Fprintln(os.Stderr, "No grants found")
+		return nil
+	}
+	return w.Flush()
+}
+
+func formatGrantTime(t time.Time, zero string) string {
+	if t.IsZero() {
+		return zero
+	}
+	return t.UTC().Format(time.RFC3339)
+}
+
+func orDash(s string) string {
+	if s == "" {
+		return "-"
+	}
+	return s
+}
@@ -0,0 +1,369 @@
+/*
+Copyright 2025.
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+*/
+
+package main
+
+import (
+	"context"
+	"encoding/csv"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+	"text/tabwriter"
+
+	"github.com/spf13/cobra"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/client-go/dynamic"
+	"sigs.k8s.io/yaml"
+
+	authv1alpha1 "github.com/openkube-hub/KubeUser/api/v1alpha1"
+)
+
+// Results of an imported row
+const (
+	importCreated   = "Created"
+	importUpdated   = "Updated"
+	importSkipped   = "Skipped"
+	importFailed    = "Failed"
+	importValidated = "Validated"
+)
+
+// importRow is one user of an import file. CSV files name the fields in their header and
+// separate the values of list fields with ";".
+type importRow struct {
+	Name  string `json:"name"`
+	Email string `json:"email,omitempty"`
+	// Groups are put into the certificate as organizations
+	Groups []string `json:"groups,omitempty"`
+	// Roles are NAMESPACE/ROLE
+	Roles        []string `json:"roles,omitempty"`
+	ClusterRoles []string `json:"clusterRoles,omitempty"`
+	// Template names a UserTemplate
+	Template string `json:"template,omitempty"`
+
+	// number counts the rows of the file from 1, for reporting
+	number int
+}
+
+type importOptions struct {
+	*options
+	file   string
+	format string
+	update bool
+	dryRun bool
+}
+
+func newImportCommand(opts *options) *cobra.Command {
+	o := &importOptions{options: opts}
+	cmd := &cobra.Command{
+		Use:   "import -f FILE",
+		Short: "Create users in bulk from a CSV or YAML file",
+		Long: `Creates a User for every row of a CSV or YAML file and reports the result of each row.
+Rows with errors do not stop the import; the command fails at the end if any row did.
+
+CSV files start with a header naming their columns: name, email, groups, roles,
+clusterRoles and template. Only name is required. List columns separate their values
+with ";". YAML files hold a list of objects with the same fields, lists as lists.
+
+Groups become the organizations of the user's certificate, roles are NAMESPACE/ROLE
+and template names a UserTemplate. Existing users are skipped, unless --update sets
+the fields the row has values for. Run with --dry-run first to have the API server and
+the KubeUser webhook check every row without creating anything.`,
+		Example: `  # users.csv:
+  #   name,email,groups,roles,clusterRoles
+  #   jane,jane@example.com,developers,dev/developer;staging/developer,view
+  #   john,john@example.com,developers;oncall,prod/operator,
+  kubectl kubeuser import -f users.csv --dry-run
+  kubectl kubeuser import -f users.csv
+
+  # Re-run after editing the file, changing existing users too
+  kubectl kubeuser import -f users.yaml --update`,
+		Args: cobra.NoArgs,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			ctx := cmd.Context()
+			if ctx == nil {
+				ctx = context.Background()
+			}
+			return o.run(ctx)
+		},
+	}
+	cmd.Flags().StringVarP(&o.file, "filename", "f", "", "CSV or YAML file to import; - reads standard input")
+	cmd.Flags().StringVar(&o.format, "format", "",
+		"csv or yaml; defaults to the file extension, csv for standard input")
+	cmd.Flags().BoolVar(&o.update, "update", false, "Update existing users instead of skipping them")
+	cmd.Flags().BoolVar(&o.dryRun, "dry-run", false, "Check every row with the API server without creating users")
+	_ = cmd.MarkFlagRequired("filename")
+	return cmd
+}
+
+func (o *importOptions) run(ctx context.Context) error {
+	rows, err := o.readRows()
+	if err != nil {
+		return err
+	}
+	if len(rows) == 0 {
+		return fmt.Errorf("%s holds no users", o.file)
+	}
+	dyn, _, err := o.clients()
+	if err != nil {
+		return err
+	}
+
+	w := tabwriter.NewWriter(os.Stdout, 0, 4, 2, ' ', 0)
+	fmt.Fprintln(w, "ROW\tUSER\tRESULT\tMESSAGE")
+	counts := map[string]int{}
+	seen := map[string]int{}
+	for _, row := range rows {
+		result, message := o.importRow(ctx, dyn, row, seen)
+		counts[result]++
+		fmt.Fprintf(w, "%d\t%s\t%s\t%s\n", row.number, orDash(row.Name), result, orDash(message))
+	}
+	if err := w.Flush(); err != nil {
+		return err
+	}
+
+	var summary []string
+	for _, result := range []string{importCreated, importUpdated, importValidated, importSkipped, importFailed} {
+		if counts[result] > 0 {
+			summary = append(summary, fmt.Sprintf("%d %s", counts[result], strings.ToLower(result)))
+		}
+	}
+	fmt.Fprintf(os.Stderr, "%d users: %s\n", len(rows), strings.Join(summary, ", "))
+	if counts[importFailed] > 0 {
+		return fmt.Errorf("%d of %d users failed to import", counts[importFailed], len(rows))
+	}
+	return nil
+}
+
+// importRow creates or updates the user of row and returns the result and why
+func (o *importOptions) importRow(ctx context.Context, dyn dynamic.Interface, row importRow,
+	seen map[string]int) (string, string) {
+	if row.Name == "" {
+		return importFailed, "no name"
+	}
+	if number, ok := seen[row.Name]; ok {
+		return importFailed, fmt.Sprintf("duplicate of row %d", number)
+	}
+	seen[row.Name] = row.number
+	user, err := row.user()
+	if err != nil {
+		return importFailed, err.Error()
+	}
+	var dryRun []string
+	if o.dryRun {
+		dryRun = []string{metav1.DryRunAll}
+	}
+
+	obj, err := runtime.DefaultUnstructuredConverter.ToUnstructured(user)
+	if err != nil {
+		return importFailed, err.Error()
+	}
+	_, err = dyn.Resource(userResource).Create(ctx, &unstructured.Unstructured{Object: obj},
+		metav1.CreateOptions{DryRun: dryRun})
+	switch {
+	case err == nil && o.dryRun:
+		return importValidated, "would be created"
+	case err == nil:
+		return importCreated, ""
+	case !apierrors.IsAlreadyExists(err):
+		return importFailed, apiMessage(err)
+	case !o.update:
+		return importSkipped, "already exists"
+	}
+
+	patch, err := json.Marshal(map[string]any{"spec": row.patch(user)})
+	if err != nil {
+		return importFailed, err.Error()
+	}
+	if _, err := dyn.Resource(userResource).Patch(ctx, row.Name, types.MergePatchType, patch,
+		metav1.PatchOptions{DryRun: dryRun}); err != nil {
+		return importFailed, apiMessage(err)
+	}
+	if o.dryRun {
+		return importValidated, "would be updated"
+	}
+	return importUpdated, ""
+}
+
+// user returns the User the row describes
+func (row importRow) user() (*authv1alpha1.User, error) {
+	user := &authv1alpha1.User{
+		TypeMeta:   metav1.TypeMeta{APIVersion: authv1alpha1.GroupVersion.String(), Kind: "User"},
+		ObjectMeta: metav1.ObjectMeta{Name: row.Name},
+	}
+	user.Spec.Email = row.Email
+	for _, role := range row.Roles {
+		namespace, name, ok := strings.Cut(role, "/")
+		if !ok || namespace == "" || name == "" {
+			return nil, fmt.Errorf("invalid role %q, must be NAMESPACE/ROLE", role)
+		}
+		user.Spec.Roles = append(user.Spec.Roles, authv1alpha1.RoleSpec{Namespace: namespace, ExistingRole: name})
+	}
+	for _, clusterRole := range row.ClusterRoles {
+		user.Spec.ClusterRoles = append(user.Spec.ClusterRoles,
+			authv1alpha1.ClusterRoleSpec{ExistingClusterRole: clusterRole})
+	}
+	if len(row.Groups) > 0 {
+		user.Spec.Certificate = &authv1alpha1.CertificateSubject{Organizations: row.Groups}
+	}
+	if row.Template != "" {
+		user.Spec.TemplateRef = &authv1alpha1.UserTemplateReference{Name: row.Template}
+	}
+	return user, nil
+}
+
+// patch returns the merge patch of the spec of an existing user, setting only the fields the
+// row has values for. Lists replace those of the user; other certificate fields are kept.
+func (row importRow) patch(user *authv1alpha1.User) map[string]any {
+	spec := map[string]any{}
+	if row.Email != "" {
+		spec["email"] = user.Spec.Email
+	}
+	if len(user.Spec.Roles) > 0 {
+		spec["roles"] = user.Spec.Roles
+	}
+	if len(user.Spec.ClusterRoles) > 0 {
+		spec["clusterRoles"] = user.Spec.ClusterRoles
+	}
+	if user.Spec.Certificate != nil {
+		spec["certificate"] = map[string]any{"organizations": user.Spec.Certificate.Organizations}
+	}
+	if user.Spec.TemplateRef != nil {
+		spec["templateRef"] = user.Spec.TemplateRef
+	}
+	return spec
+}
+
+// apiMessage returns the message of an API error without the status details
+func apiMessage(err error) string {
+	var status apierrors.APIStatus
+	if errors.As(err, &status) && status.Status().Message != "" {
+		return status.Status().Message
+	}
+	return err.Error()
+}
+
+// readRows reads the import file in its format
+func (o *importOptions) readRows() ([]importRow, error) {
+	var in io.Reader = os.Stdin
+	if o.file != "-" {
+		f, err := os.Open(o.file)
+		if err != nil {
+			return nil, err
+		}
+		defer func() { _ = f.Close() }()
+		in = f
+	}
+	format := o.format
+	if format == "" {
+		switch strings.ToLower(filepath.Ext(o.file)) {
+		case ".yaml", ".yml", ".json":
+			format = "yaml"
+		default:
+			format = "csv"
+		}
+	}
+	switch format {
+	case "csv":
+		return readCSVRows(in)
+	case "yaml":
+		return readYAMLRows(in)
+	default:
+		return nil, fmt.Errorf("invalid --format %q, must be csv or yaml", o.format)
+	}
+}
+
+// readCSVRows reads rows from CSV with a header naming the columns
+func readCSVRows(in io.Reader) ([]importRow, error) {
+	r := csv.NewReader(in)
+	r.TrimLeadingSpace = true
+	r.FieldsPerRecord = -1
+	header, err := r.Read()
+	if err == io.EOF {
+		return nil, nil
+	} else if err != nil {
+		return nil, fmt.Errorf("reading CSV header: %w", err)
+	}
+	columns := map[string]int{}
+	for i, column := range header {
+		column = strings.ToLower(strings.TrimSpace(column))
+		switch column {
+		case "name", "email", "groups", "roles", "clusterroles", "template":
+		default:
+			return nil, fmt.Errorf("unknown CSV column %q", column)
+		}
+		if _, ok := columns[column]; ok {
+			return nil, fmt.Errorf("duplicate CSV column %q", column)
+		}
+		columns[column] = i
+	}
+	if _, ok := columns["name"]; !ok {
+		return nil, errors.New("the CSV header has no name column")
+	}
+
+	var rows []importRow
+	for number := 1; ; number++ {
+		record, err := r.Read()
+		if err == io.EOF {
+			return rows, nil
+		} else if err != nil {
+			return nil, fmt.Errorf("reading CSV: %w", err)
+		}
+		field := func(column string) string {
+			if i, ok := columns[column]; ok && i < len(record) {
+				return strings.TrimSpace(record[i])
+			}
+			return ""
+		}
+		if strings.TrimSpace(strings.Join(record, "")) == "" {
+			continue
+		}
+		rows = append(rows, importRow{
+			Name:         field("name"),
+			Email:        field("email"),
+			Groups:       splitList(field("groups")),
+			Roles:        splitList(field("roles")),
+			ClusterRoles: splitList(field("clusterroles")),
+			Template:     field("template"),
+			number:       number,
+		})
+	}
+}
+
+// splitList splits a CSV list field at ";"
+func splitList(field string) []string {
+	var values []string
+	for _, value := range strings.Split(field, ";") {
+		if value = strings.TrimSpace(value); value != "" {
+			values = append(values, value)
+		}
+	}
+	return values
+}
+
+// readYAMLRows reads rows from a YAML or JSON list
+func readYAMLRows(in io.Reader) ([]importRow, error) {
+	data, err := io.ReadAll(in)
+	if err != nil {
+		return nil, err
+	}
+	var rows []importRow
+	if err := yaml.UnmarshalStrict(data, &rows); err != nil {
+		return nil, fmt.Errorf("reading YAML: %w", err)
+	}
+	for i := range rows {
+		rows[i].number = i + 1
+	}
+	return rows, nil
+}
@@ -0,0 +1,87 @@
+/*
+Copyright 2025.
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+*/
+
+package main
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"strings"
+	"time"
+
+	"github.com/spf13/cobra"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+
+	"github.com/openkube-hub/KubeUser/internal/portal"
+)
+
+// defaultLinkTTL is how long download links stay valid unless --ttl is given
+const defaultLinkTTL = 24 * time.Hour
+
+type linkOptions struct {
+	*options
+	portalURL string
+	ttl       time.Duration
+}
+
+func newLinkCommand(opts *options) *cobra.Command {
+	o := &linkOptions{options: opts}
+	cmd := &cobra.Command{
+		Use:   "link USER",
+		Short: "Create a one-time link to download a user's kubeconfig",
+		Long: `Creates a download link for the user's kubeconfig, served by the KubeUser
+portal (SelfServiceAPI feature gate). The link works once and expires after --ttl.
+Send it to the user instead of the kubeconfig itself. The link is only printed
+here; the cluster keeps just a digest of it.`,
+		Example: `  kubectl kubeuser link jane --portal-url https://kubeuser.example.com:8445 --ttl 2h`,
+		Args:    cobra.ExactArgs(1),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			return o.run(cmd.Context(), args[0])
+		},
+	}
+	cmd.Flags().StringVar(&o.portalURL, "portal-url", os.Getenv("KUBEUSER_PORTAL_URL"),
+		"External URL of the KubeUser portal; defaults to $KUBEUSER_PORTAL_URL")
+	cmd.Flags().DurationVar(&o.ttl, "ttl", defaultLinkTTL, "How long the link stays valid, at most 168h")
+	return cmd
+}
+
+func (o *linkOptions) run(ctx context.Context, username string) error {
+	if ctx == nil {
+		ctx = context.Background()
+	}
+	if o.portalURL == "" {
+		return errors.New("--portal-url or KUBEUSER_PORTAL_URL is required")
+	}
+	dyn, clientset, err := o.clients()
+	if err != nil {
+		return err
+	}
+	user, err := getUser(ctx, dyn, username)
+	if err != nil {
+		return err
+	}
+	if user.Spec.Revoked || user.Spec.Suspended {
+		return fmt.Errorf("user %s is revoked or suspended", username)
+	}
+
+	token, err := portal.NewToken()
+	if err != nil {
+		return err
+	}
+	link, err := portal.NewLink(token, username, o.namespace, o.ttl, time.Now())
+	if err != nil {
+		return err
+	}
+	if _, err := clientset.CoreV1().Secrets(o.namespace).Create(ctx, link, metav1.CreateOptions{}); err != nil {
+		return fmt.Errorf("creating download link for user %s: %w", username, err)
+	}
+	fmt.Fprintf(os.Stderr, "Created a download link for %s, valid once until %s\n", username,
+		link.Annotations[portal.ExpiresAtAnnotation])
+	fmt.Printf("%s/download/%s\n", strings.TrimSuffix(o.portalURL, "/"), token)
+	return nil
+}
@@ -0,0 +1,66 @@
+/*
+Copyright 2025.
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+*/
+
+// kubectl-kubeuser is a kubectl plugin for working with KubeUser users.
+// Install it on the PATH and run it as "kubectl kubeuser <command>".
+package main
+
+import (
+	"fmt"
+	"os"
+
+	"github.com/spf13/cobra"
+	"k8s.io/client-go/tools/clientcmd"
+)
+
+// defaultNamespace is the namespace KubeUser keeps per-user resources in
+const defaultNamespace = "kubeuser"
+
+// options are shared by all commands
+type options struct {
+	kubeconfig string
+	context    string
+	namespace  string
+}
+
+func (o *options) clientConfig() clientcmd.ClientConfig {
+	rules := clientcmd.NewDefaultClientConfigLoadingRules()
+	rules.ExplicitPath = o.kubeconfig
+	return clientcmd.NewNonInteractiveDeferredLoadingClientConfig(rules, &clientcmd.ConfigOverrides{CurrentContext: o.context})
+}
+
+func main() {
+	opts := &options{}
+	root := &cobra.Command{
+		Use:           "kubectl-kubeuser",
+		Short:         "Manage KubeUser users",
+		SilenceUsage:  true,
+		SilenceErrors: true,
+	}
+	root.PersistentFlags().StringVar(&opts.kubeconfig, "kubeconfig", "", "Path to the kubeconfig file to use")
+	root.PersistentFlags().StringVar(&opts.context, "context", "", "The kubeconfig context to use")
+	root.PersistentFlags().StringVar(&opts.namespace, "kubeuser-namespace", defaultNamespace,
+		"Namespace KubeUser keeps per-user resources in")
+
+	root.AddCommand(
+		newCreateCommand(opts),
+		newImportCommand(opts),
+		newWaitCommand(opts),
+		newFetchCommand(opts),
+		newRenewCommand(opts),
+		newRevokeCommand(opts),
+		newTokenCommand(opts),
+		newCredentialCommand(opts),
+		newLinkCommand(opts),
+		newHistoryCommand(opts),
+		newAuthCommand(),
+	)
+
+	if err := root.Execute(); err != nil {
+		fmt.Fprintln(os.Stderr, "Error:", err)
+		os.Exit(1)
+	}
+}
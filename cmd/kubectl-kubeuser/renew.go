@@ -0,0 +1,108 @@
+/*
+Copyright 2025.
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+*/
+
+package main
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"time"
+
+	"github.com/spf13/cobra"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/apimachinery/pkg/util/wait"
+
+	authv1alpha1 "github.com/openkube-hub/KubeUser/api/v1alpha1"
+)
+
+type renewOptions struct {
+	*options
+	newKey  bool
+	wait    bool
+	timeout time.Duration
+}
+
+func newRenewCommand(opts *options) *cobra.Command {
+	o := &renewOptions{options: opts}
+	cmd := &cobra.Command{
+		Use:   "renew USER",
+		Short: "Issue a new certificate for a user",
+		Long: `Sets the auth.openkube.io/renew annotation on the user, so the controller
+replaces its kubeconfig with a new certificate, or a new token for machine users,
+right away. The private key is kept unless --new-key is given, e.g. when it may
+have leaked. The previous certificate stays valid until it expires.`,
+		Example: `  kubectl kubeuser renew jane --wait`,
+		Args:    cobra.ExactArgs(1),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			return o.run(cmd.Context(), args[0])
+		},
+	}
+	cmd.Flags().BoolVar(&o.newKey, "new-key", false, "Also generate a new private key")
+	cmd.Flags().BoolVar(&o.wait, "wait", false, "Wait until the user is Active again")
+	cmd.Flags().DurationVar(&o.timeout, "timeout", defaultWaitTimeout, "How long to wait with --wait")
+	return cmd
+}
+
+func (o *renewOptions) run(ctx context.Context, username string) error {
+	if ctx == nil {
+		ctx = context.Background()
+	}
+	dyn, clientset, err := o.clients()
+	if err != nil {
+		return err
+	}
+	user, err := getUser(ctx, dyn, username)
+	if err != nil {
+		return err
+	}
+
+	value := "true"
+	if o.newKey {
+		value = authv1alpha1.RenewNewKey
+	}
+	patch := fmt.Sprintf(`{"metadata":{"annotations":{%q:%q}}}`, authv1alpha1.RenewAnnotation, value)
+	if _, err := dyn.Resource(userResource).Patch(ctx, username, types.MergePatchType, []byte(patch),
+		metav1.PatchOptions{}); err != nil {
+		return fmt.Errorf("requesting renewal of user %s: %w", username, err)
+	}
+	fmt.Fprintf(os.Stderr, "Requested a new certificate for %s\n", username)
+
+	if !o.wait {
+		return nil
+	}
+	// The controller removes the annotation once it has dropped the old credentials; the phase
+	// may still read Active from before, so then wait for the new kubeconfig
+	cfgNamespace, cfgName := o.credentialSecret(user)
+	started := func(ctx context.Context) (bool, error) {
+		current, err := getUser(ctx, dyn, username)
+		if err != nil {
+			return false, err
+		}
+		_, pending := current.Annotations[authv1alpha1.RenewAnnotation]
+		return !pending, nil
+	}
+	issued := func(ctx context.Context) (bool, error) {
+		_, err := clientset.CoreV1().Secrets(cfgNamespace).Get(ctx, cfgName, metav1.GetOptions{})
+		if apierrors.IsNotFound(err) {
+			return false, nil
+		}
+		return err == nil, err
+	}
+	for _, condition := range []wait.ConditionWithContextFunc{started, issued} {
+		if err := wait.PollUntilContextTimeout(ctx, waitPollInterval, o.timeout, true, condition); err != nil {
+			return fmt.Errorf("waiting for the new kubeconfig of user %s: %w", username, err)
+		}
+	}
+	user, err = waitForActive(ctx, dyn, username, o.timeout)
+	if err != nil {
+		return err
+	}
+	fmt.Fprintf(os.Stderr, "User %s is Active, credentials expire %s\n", username, user.Status.ExpiryTime)
+	return nil
+}
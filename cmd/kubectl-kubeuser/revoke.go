@@ -0,0 +1,56 @@
+/*
+Copyright 2025.
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+*/
+
+package main
+
+import (
+	"context"
+	"fmt"
+	"os"
+
+	"github.com/spf13/cobra"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+func newRevokeCommand(opts *options) *cobra.Command {
+	var yes bool
+	cmd := &cobra.Command{
+		Use:   "revoke USER",
+		Short: "Revoke a user's access by deleting the user",
+		Long: `Deletes the User. The controller then removes its RoleBindings, ClusterRoleBindings,
+ServiceAccount anchor and credential Secrets, which also invalidates tokens issued
+with "kubectl kubeuser token". Kubernetes cannot revoke client certificates, so the
+certificate itself stays valid until it expires but no longer grants any access.`,
+		Example: `  kubectl kubeuser revoke jane --yes`,
+		Args:    cobra.ExactArgs(1),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			ctx := cmd.Context()
+			if ctx == nil {
+				ctx = context.Background()
+			}
+			username := args[0]
+			if !yes {
+				return fmt.Errorf("revoking deletes user %s and all its access, pass --yes to confirm", username)
+			}
+			dyn, _, err := opts.clients()
+			if err != nil {
+				return err
+			}
+			user, err := getUser(ctx, dyn, username)
+			if err != nil {
+				return err
+			}
+			if err := dyn.Resource(userResource).Delete(ctx, username, metav1.DeleteOptions{}); err != nil {
+				return fmt.Errorf("deleting user %s: %w", username, err)
+			}
+			fmt.Fprintf(os.Stderr, "Revoked user %s; its certificate grants no access but stays valid until %s\n",
+				username, user.Status.ExpiryTime)
+			return nil
+		},
+	}
+	cmd.Flags().BoolVar(&yes, "yes", false, "Confirm the revocation")
+	return cmd
+}
@@ -0,0 +1,150 @@
+/*
+Copyright 2025.
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+*/
+
+package main
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"time"
+
+	"github.com/spf13/cobra"
+	authenticationv1 "k8s.io/api/authentication/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"k8s.io/client-go/dynamic"
+	"k8s.io/client-go/kubernetes"
+	"k8s.io/client-go/rest"
+	"k8s.io/client-go/tools/clientcmd"
+	clientcmdapi "k8s.io/client-go/tools/clientcmd/api"
+)
+
+// minTokenDuration is the shortest expiration the TokenRequest API accepts
+const minTokenDuration = 10 * time.Minute
+
+var userResource = schema.GroupVersionResource{Group: "auth.openkube.io", Version: "v1alpha1", Resource: "users"}
+
+type tokenOptions struct {
+	*options
+	duration  time.Duration
+	audiences []string
+	server    string
+	output    string
+}
+
+func newTokenCommand(opts *options) *cobra.Command {
+	o := &tokenOptions{options: opts}
+	cmd := &cobra.Command{
+		Use:   "token USER",
+		Short: "Issue a short-lived token for a user and print a kubeconfig using it",
+		Long: `Requests a short-lived token for the user's ServiceAccount anchor through the
+TokenRequest API and writes a ready-to-use kubeconfig. The token grants the same
+roles as the user's certificate and cannot be revoked before it expires, so keep
+the duration short.`,
+		Example: `  # 15 minutes of access for a support engineer
+  kubectl kubeuser token jane --duration 15m -o /tmp/jane.kubeconfig
+  kubectl --kubeconfig /tmp/jane.kubeconfig get pods -n dev`,
+		Args: cobra.ExactArgs(1),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			return o.run(cmd.Context(), args[0])
+		},
+	}
+	cmd.Flags().DurationVar(&o.duration, "duration", 15*time.Minute, "How long the token is valid (at least 10m)")
+	cmd.Flags().StringSliceVar(&o.audiences, "audience", nil,
+		"Audiences of the token; defaults to the API server's own audience")
+	cmd.Flags().StringVar(&o.server, "server", "", "API server URL written to the kubeconfig; defaults to the current context's")
+	cmd.Flags().StringVarP(&o.output, "output", "o", "", "File to write the kubeconfig to; defaults to stdout")
+	return cmd
+}
+
+func (o *tokenOptions) run(ctx context.Context, username string) error {
+	if o.duration < minTokenDuration {
+		return fmt.Errorf("--duration must be at least %s", minTokenDuration)
+	}
+	if ctx == nil {
+		ctx = context.Background()
+	}
+	config, err := o.clientConfig().ClientConfig()
+	if err != nil {
+		return err
+	}
+
+	// Make sure the name refers to a managed user rather than any ServiceAccount
+	dyn, err := dynamic.NewForConfig(config)
+	if err != nil {
+		return err
+	}
+	if _, err := dyn.Resource(userResource).Get(ctx, username, metav1.GetOptions{}); err != nil {
+		return fmt.Errorf("user %s: %w", username, err)
+	}
+
+	clientset, err := kubernetes.NewForConfig(config)
+	if err != nil {
+		return err
+	}
+	expiration := int64(o.duration.Seconds())
+	token, err := clientset.CoreV1().ServiceAccounts(o.namespace).CreateToken(ctx, username, &authenticationv1.TokenRequest{
+		Spec: authenticationv1.TokenRequestSpec{
+			Audiences:         o.audiences,
+			ExpirationSeconds: &expiration,
+		},
+	}, metav1.CreateOptions{})
+	if apierrors.IsNotFound(err) {
+		return fmt.Errorf("user %s has no ServiceAccount anchor in %s; enable spec.serviceAccountAnchor to request tokens",
+			username, o.namespace)
+	}
+	if err != nil {
+		return fmt.Errorf("requesting token for ServiceAccount %s/%s: %w", o.namespace, username, err)
+	}
+
+	kubeconfig, err := o.buildKubeconfig(config, username, token.Status.Token)
+	if err != nil {
+		return err
+	}
+	data, err := clientcmd.Write(*kubeconfig)
+	if err != nil {
+		return err
+	}
+	if o.output == "" {
+		_, err = os.Stdout.Write(data)
+		return err
+	}
+	if err := os.WriteFile(o.output, data, 0o600); err != nil {
+		return err
+	}
+	fmt.Fprintf(os.Stderr, "Wrote kubeconfig for %s to %s, valid until %s\n",
+		username, o.output, token.Status.ExpirationTimestamp.Format(time.RFC3339))
+	return nil
+}
+
+// buildKubeconfig builds a single-context kubeconfig for the token, reusing the current cluster's CA
+func (o *tokenOptions) buildKubeconfig(config *rest.Config, username, token string) (*clientcmdapi.Config, error) {
+	server := o.server
+	if server == "" {
+		server = config.Host
+	}
+	caData := config.CAData
+	if len(caData) == 0 && config.CAFile != "" {
+		var err error
+		if caData, err = os.ReadFile(config.CAFile); err != nil {
+			return nil, fmt.Errorf("reading CA file: %w", err)
+		}
+	}
+
+	name := "kubeuser-" + username
+	kubeconfig := clientcmdapi.NewConfig()
+	kubeconfig.Clusters[name] = &clientcmdapi.Cluster{
+		Server:                   server,
+		CertificateAuthorityData: caData,
+		InsecureSkipTLSVerify:    config.Insecure,
+	}
+	kubeconfig.AuthInfos[name] = &clientcmdapi.AuthInfo{Token: token}
+	kubeconfig.Contexts[name] = &clientcmdapi.Context{Cluster: name, AuthInfo: name}
+	kubeconfig.CurrentContext = name
+	return kubeconfig, nil
+}
@@ -0,0 +1,80 @@
+/*
+Copyright 2025.
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+*/
+
+package main
+
+import (
+	"context"
+	"fmt"
+
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/client-go/dynamic"
+	"k8s.io/client-go/kubernetes"
+
+	authv1alpha1 "github.com/openkube-hub/KubeUser/api/v1alpha1"
+	"github.com/openkube-hub/KubeUser/internal/naming"
+)
+
+// Phases reported in status.phase
+const (
+	phaseActive       = "Active"
+	phaseExpiringSoon = "ExpiringSoon"
+	phaseError        = "Error"
+	phaseExpired      = "Expired"
+	phaseSuspended    = "Suspended"
+	phaseRevoked      = "Revoked"
+)
+
+// clients returns the dynamic client for Users and the typed client for everything else
+func (o *options) clients() (dynamic.Interface, kubernetes.Interface, error) {
+	config, err := o.clientConfig().ClientConfig()
+	if err != nil {
+		return nil, nil, err
+	}
+	dyn, err := dynamic.NewForConfig(config)
+	if err != nil {
+		return nil, nil, err
+	}
+	clientset, err := kubernetes.NewForConfig(config)
+	if err != nil {
+		return nil, nil, err
+	}
+	return dyn, clientset, nil
+}
+
+func getUser(ctx context.Context, dyn dynamic.Interface, username string) (*authv1alpha1.User, error) {
+	obj, err := dyn.Resource(userResource).Get(ctx, username, metav1.GetOptions{})
+	if err != nil {
+		return nil, fmt.Errorf("user %s: %w", username, err)
+	}
+	var user authv1alpha1.User
+	if err := runtime.DefaultUnstructuredConverter.FromUnstructured(obj.Object, &user); err != nil {
+		return nil, fmt.Errorf("user %s: %w", username, err)
+	}
+	return &user, nil
+}
+
+// credentialSecret returns the namespace and name of the user's credential Secret: where the
+// controller reports it, else where the spec puts it
+func (o *options) credentialSecret(user *authv1alpha1.User) (string, string) {
+	if ref := user.Status.CredentialSecret; ref != nil && ref.Name != "" {
+		return ref.Namespace, ref.Name
+	}
+	if user.Spec.Output != nil && user.Spec.Output.SecretRef != nil {
+		namespace := user.Spec.Output.SecretRef.Namespace
+		if namespace == "" {
+			namespace = o.namespace
+		}
+		return namespace, user.Spec.Output.SecretRef.Name
+	}
+	return o.namespace, kubeconfigSecretName(user.Name)
+}
+
+// kubeconfigSecretName is the credential Secret name generated by the controller
+func kubeconfigSecretName(username string) string {
+	return naming.Suffixed(naming.MaxNameLength, username, "kubeconfig")
+}
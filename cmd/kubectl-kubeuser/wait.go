@@ -0,0 +1,84 @@
+/*
+Copyright 2025.
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+*/
+
+package main
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"time"
+
+	"github.com/spf13/cobra"
+	"k8s.io/apimachinery/pkg/util/wait"
+	"k8s.io/client-go/dynamic"
+
+	authv1alpha1 "github.com/openkube-hub/KubeUser/api/v1alpha1"
+)
+
+const (
+	defaultWaitTimeout = 5 * time.Minute
+	waitPollInterval   = 2 * time.Second
+)
+
+func newWaitCommand(opts *options) *cobra.Command {
+	var timeout time.Duration
+	cmd := &cobra.Command{
+		Use:   "wait USER",
+		Short: "Wait until a user is Active",
+		Long: `Waits until the user's certificate is issued and the user is Active. Fails right
+away when the user reports the Error, Expired or Suspended phase, printing its status message.`,
+		Example: `  kubectl kubeuser wait jane --timeout 2m`,
+		Args:    cobra.ExactArgs(1),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			ctx := cmd.Context()
+			if ctx == nil {
+				ctx = context.Background()
+			}
+			dyn, _, err := opts.clients()
+			if err != nil {
+				return err
+			}
+			user, err := waitForActive(ctx, dyn, args[0], timeout)
+			if err != nil {
+				return err
+			}
+			fmt.Fprintf(os.Stderr, "User %s is Active, credentials expire %s\n", user.Name, user.Status.ExpiryTime)
+			return nil
+		},
+	}
+	cmd.Flags().DurationVar(&timeout, "timeout", defaultWaitTimeout, "How long to wait")
+	return cmd
+}
+
+// waitForActive polls the user until it is Active or ExpiringSoon, failing early on Error, Expired, Suspended or Revoked
+func waitForActive(ctx context.Context, dyn dynamic.Interface, username string,
+	timeout time.Duration) (*authv1alpha1.User, error) {
+	var user *authv1alpha1.User
+	err := wait.PollUntilContextTimeout(ctx, waitPollInterval, timeout, true, func(ctx context.Context) (bool, error) {
+		var err error
+		if user, err = getUser(ctx, dyn, username); err != nil {
+			return false, err
+		}
+		switch user.Status.Phase {
+		case phaseActive, phaseExpiringSoon:
+			return true, nil
+		case phaseError, phaseExpired, phaseSuspended, phaseRevoked:
+			return false, fmt.Errorf("user %s is %s: %s", username, user.Status.Phase, user.Status.Message)
+		}
+		return false, nil
+	})
+	if wait.Interrupted(err) && !errors.Is(ctx.Err(), context.Canceled) {
+		phase, message := "unknown", ""
+		if user != nil {
+			phase, message = user.Status.Phase, user.Status.Message
+		}
+		return nil, fmt.Errorf("timed out after %s waiting for user %s to become Active (phase %s: %s)",
+			timeout, username, phase, message)
+	}
+	return user, err
+}
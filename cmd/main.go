@@ -17,18 +17,32 @@ limitations under the License.
 package main
 
 import (
+	"context"
 	"crypto/tls"
+	"crypto/x509"
 	"flag"
+	"net/http"
+	"net/http/pprof"
 	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+	"time"
 
 	// Import all Kubernetes client auth plugins (e.g. Azure, GCP, OIDC, etc.)
 	// to ensure that exec-entrypoint and run can make use of them.
 	_ "k8s.io/client-go/plugin/pkg/client/auth"
 
+	apiextensionsv1 "k8s.io/apiextensions-apiserver/pkg/apis/apiextensions/v1"
 	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/types"
 	utilruntime "k8s.io/apimachinery/pkg/util/runtime"
+	"k8s.io/client-go/discovery"
 	clientgoscheme "k8s.io/client-go/kubernetes/scheme"
+	"k8s.io/client-go/rest"
 	ctrl "sigs.k8s.io/controller-runtime"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/event"
 	"sigs.k8s.io/controller-runtime/pkg/healthz"
 	"sigs.k8s.io/controller-runtime/pkg/log/zap"
 	"sigs.k8s.io/controller-runtime/pkg/metrics/filters"
@@ -36,11 +50,37 @@ import (
 	"sigs.k8s.io/controller-runtime/pkg/webhook"
 
 	authv1alpha1 "github.com/openkube-hub/KubeUser/api/v1alpha1"
+	"github.com/openkube-hub/KubeUser/internal/adminapi"
+	"github.com/openkube-hub/KubeUser/internal/aws"
+	"github.com/openkube-hub/KubeUser/internal/ca"
+	"github.com/openkube-hub/KubeUser/internal/certs"
 	"github.com/openkube-hub/KubeUser/internal/controller"
+	"github.com/openkube-hub/KubeUser/internal/credentials"
+	"github.com/openkube-hub/KubeUser/internal/dashboard"
+	"github.com/openkube-hub/KubeUser/internal/directory"
+	"github.com/openkube-hub/KubeUser/internal/features"
+	"github.com/openkube-hub/KubeUser/internal/gcp"
+	"github.com/openkube-hub/KubeUser/internal/history"
+	"github.com/openkube-hub/KubeUser/internal/inventory"
+	"github.com/openkube-hub/KubeUser/internal/issuer"
+	"github.com/openkube-hub/KubeUser/internal/notify"
+	"github.com/openkube-hub/KubeUser/internal/oidc"
+	"github.com/openkube-hub/KubeUser/internal/operatorconfig"
+	"github.com/openkube-hub/KubeUser/internal/operatorstatus"
+	"github.com/openkube-hub/KubeUser/internal/portal"
+	"github.com/openkube-hub/KubeUser/internal/preflight"
+	"github.com/openkube-hub/KubeUser/internal/proxy"
+	"github.com/openkube-hub/KubeUser/internal/secretstore"
+	"github.com/openkube-hub/KubeUser/internal/spire"
+	"github.com/openkube-hub/KubeUser/internal/tracing"
+	"github.com/openkube-hub/KubeUser/internal/usage"
 	webhookpkg "github.com/openkube-hub/KubeUser/internal/webhook"
 	// +kubebuilder:scaffold:imports
 )
 
+// envFeatureGates configures feature gates when --feature-gates is not given
+const envFeatureGates = "KUBEUSER_FEATURE_GATES"
+
 var (
 	scheme   = runtime.NewScheme()
 	setupLog = ctrl.Log.WithName("setup")
@@ -48,6 +88,7 @@ var (
 
 func init() {
 	utilruntime.Must(clientgoscheme.AddToScheme(scheme))
+	utilruntime.Must(apiextensionsv1.AddToScheme(scheme))
 
 	utilruntime.Must(authv1alpha1.AddToScheme(scheme))
 	// +kubebuilder:scaffold:scheme
@@ -57,11 +98,49 @@ func init() {
 func main() {
 	var metricsAddr string
 	var metricsCertPath, metricsCertName, metricsCertKey string
-	var webhookCertPath, webhookCertName, webhookCertKey string
+	var webhookCertConfig certs.Config
+	var injectCABundle bool
 	var enableLeaderElection bool
 	var probeAddr string
 	var secureMetrics bool
 	var enableHTTP2 bool
+	var caSources string
+	var apiServer string
+	var kubeuserNamespace string
+	var usageWindow time.Duration
+	var auditClientCA, auditTokenFile string
+	var accessHistoryRetention time.Duration
+	var effectiveAccessReview bool
+	var bindingMode string
+	var namespaceCleanup string
+	var serviceAccountAnchor bool
+	var credentialReaderRBAC bool
+	var clusterAPI bool
+	var eksClusterName, eksRegion string
+	var gcpProject string
+	var tracingConfig tracing.Config
+	var enablePprof bool
+	var sshCASecret string
+	var notificationTemplatesDir string
+	var reconcileTimeout, circuitBreakerCooldown time.Duration
+	var statusMessageInterval time.Duration
+	var circuitBreakerFailures int
+	var maxConcurrentReconciles, queueBurst, kubeAPIBurst int
+	var queueQPS, kubeAPIQPS float64
+	var queueBaseDelay, queueMaxDelay, retryBaseDelay, retryMaxDelay time.Duration
+	var credentialLayout string
+	var issuerConfig controller.IssuerConfig
+	var keyProtectionConfig controller.KeyProtectionConfig
+	var certificateDuration, rotationThreshold, renewalCheckInterval time.Duration
+	var rotationThresholdPercent int
+	var usernameTemplate string
+	var entraConfig directory.EntraConfig
+	var googleConfig directory.GoogleConfig
+	var directorySyncInterval time.Duration
+	var proxyAddr, proxyURL, proxyCertPath, proxyCertName, proxyCertKey, proxyCA, proxyClientCA string
+	var portalAddr, portalCertPath, portalCertName, portalCertKey string
+	var oidcIssuerURL, oidcAddr, oidcCertPath, oidcCertName, oidcCertKey, oidcClientCA, oidcClientID string
+	var oidcTokenTTL, oidcRefreshTokenTTL time.Duration
 	var tlsOpts []func(*tls.Config)
 	flag.StringVar(&metricsAddr, "metrics-bind-address", "0", "The address the metrics endpoint binds to. "+
 		"Use :8443 for HTTPS or :8080 for HTTP, or leave as 0 to disable the metrics service.")
@@ -71,15 +150,240 @@ func main() {
 			"Enabling this will ensure there is only one active controller manager.")
 	flag.BoolVar(&secureMetrics, "metrics-secure", true,
 		"If set, the metrics endpoint is served securely via HTTPS. Use --metrics-secure=false to use HTTP instead.")
-	flag.StringVar(&webhookCertPath, "webhook-cert-path", "", "The directory that contains the webhook certificate.")
-	flag.StringVar(&webhookCertName, "webhook-cert-name", "tls.crt", "The name of the webhook certificate file.")
-	flag.StringVar(&webhookCertKey, "webhook-cert-key", "tls.key", "The name of the webhook key file.")
+	flag.StringVar(&webhookCertConfig.CertDir, "webhook-cert-path", "",
+		"The directory that contains the webhook certificate. Falls back to $"+certs.EnvCertDir+
+			", then "+certs.DefaultCertDir+".")
+	flag.StringVar(&webhookCertConfig.CertName, "webhook-cert-name", "tls.crt", "The name of the webhook certificate file.")
+	flag.StringVar(&webhookCertConfig.KeyName, "webhook-cert-key", "tls.key", "The name of the webhook key file.")
+	flag.StringVar(&webhookCertConfig.ServiceName, "webhook-service-name", "",
+		"The name of the Service fronting the webhook server. Falls back to $"+certs.EnvServiceName+".")
+	flag.StringVar(&webhookCertConfig.Namespace, "webhook-service-namespace", "",
+		"The namespace of the webhook Service. Falls back to $"+certs.EnvNamespace+".")
+	flag.StringVar(&webhookCertConfig.SecretName, "webhook-cert-secret", "",
+		"Generate a self-signed CA and webhook serving certificate into this Secret in the KubeUser namespace, "+
+			"renew it before it expires, and sync it into the webhook certificate directory of every replica. "+
+			"A Secret created by others is only synced.")
+	flag.DurationVar(&webhookCertConfig.Validity, "webhook-cert-validity", certs.DefaultServingValidity,
+		"Lifetime of webhook serving certificates generated with --webhook-cert-secret.")
+	flag.DurationVar(&webhookCertConfig.RenewBefore, "webhook-cert-renew-before", certs.DefaultServingRenewBefore,
+		"How long before expiry webhook serving certificates generated with --webhook-cert-secret are renewed.")
+	flag.BoolVar(&injectCABundle, "inject-ca-bundle", false,
+		"Keep the caBundle of webhook configurations and CustomResourceDefinitions annotated with "+
+			certs.InjectAnnotation+"=<namespace>/<secret> equal to the CA of that Secret in the KubeUser namespace.")
 	flag.StringVar(&metricsCertPath, "metrics-cert-path", "",
 		"The directory that contains the metrics server certificate.")
 	flag.StringVar(&metricsCertName, "metrics-cert-name", "tls.crt", "The name of the metrics server certificate file.")
 	flag.StringVar(&metricsCertKey, "metrics-cert-key", "tls.key", "The name of the metrics server key file.")
 	flag.BoolVar(&enableHTTP2, "enable-http2", false,
 		"If set, HTTP/2 will be enabled for the metrics and webhook servers")
+	flag.StringVar(&caSources, "ca-sources", os.Getenv("KUBEUSER_CA_SOURCES"),
+		"Comma separated, ordered list of cluster CA sources embedded in generated kubeconfigs: "+
+			"file:<path>, secret:<ns>/<name>[/<key>], configmap:<ns>/<name>[/<key>], inline:<base64 PEM>, cluster-info. "+
+			"Defaults to the ServiceAccount CA mount, default/kube-root-ca.crt, then kube-public/cluster-info.")
+	flag.StringVar(&apiServer, "api-server", os.Getenv("KUBERNETES_API_SERVER"),
+		"External API server URL written into generated kubeconfigs unless a User sets spec.output.server. "+
+			"Defaults to the server in kube-public/cluster-info, then https://kubernetes.default.svc. "+
+			"Overridden by spec.apiServer of the KubeUserConfig.")
+	flag.StringVar(&kubeuserNamespace, "kubeuser-namespace", os.Getenv("KUBEUSER_NAMESPACE"),
+		"Namespace the controller creates per-user Secrets and ServiceAccounts in, creating it when missing. "+
+			"Defaults to "+operatorconfig.DefaultNamespace+". Overridden by spec.namespace of the KubeUserConfig.")
+	flag.StringVar(&credentialLayout, "credential-layout", os.Getenv("KUBEUSER_CREDENTIAL_LAYOUT"),
+		"Comma separated key=format pairs written into credential Secrets unless a User sets spec.output.keys. "+
+			"Formats: kubeconfig, kubeconfig-json, ca-cert, client-cert, client-key, env. Defaults to config=kubeconfig.")
+	flag.StringVar(&bindingMode, "binding-mode", controller.BindingModeEnforce,
+		"Default binding mode: 'enforce' applies RoleBindings and ClusterRoleBindings, 'report-only' only records "+
+			"the changes in User status. The "+controller.BindingModeAnnotation+" annotation overrides it per user.")
+	flag.StringVar(&sshCASecret, "ssh-ca-secret", os.Getenv("KUBEUSER_SSH_CA_SECRET"),
+		"Secret holding the SSH CA private key that signs certificates requested in spec.ssh, as "+
+			"<namespace>/<name>[/<key>] (key defaults to '"+controller.DefaultSSHCAKey+"'). "+
+			"SSH certificates are disabled when empty.")
+	flag.StringVar(&notificationTemplatesDir, "notification-templates", os.Getenv("KUBEUSER_NOTIFICATION_TEMPLATES"),
+		"Directory with notification template overrides named <channel>.<event>.<part> and organization "+
+			"variables in "+notify.OrgFile+", typically a mounted ConfigMap. Built-in wording is used when empty.")
+	flag.BoolVar(&serviceAccountAnchor, "service-account-anchor", true,
+		"Create a ServiceAccount per user, bound to the same roles, so short-lived tokens can be requested "+
+			"with kubectl kubeuser token. Users override it with spec.serviceAccountAnchor.")
+	flag.BoolVar(&credentialReaderRBAC, "credential-reader-rbac", true,
+		"Create a Role and RoleBinding per user that let them get their own credential Secret, so they "+
+			"can fetch their kubeconfig without a cluster admin.")
+	flag.BoolVar(&clusterAPI, "cluster-api", false,
+		"Provision Users and Team members labelled with auth.openkube.io/fleet on the Cluster API Clusters "+
+			"with the same label once their kubeconfig Secret exists. Requires the MultiCluster feature gate "+
+			"and the Cluster API CRDs.")
+	flag.StringVar(&eksClusterName, "eks-cluster-name", "",
+		"Name of the EKS cluster the controller runs in. When set, Users with spec.eks get an access entry "+
+			"that lets their IAM principal sign in as the user. Uses the controller's AWS credentials, "+
+			"e.g. an IAM role for its ServiceAccount.")
+	flag.StringVar(&eksRegion, "eks-region", os.Getenv("AWS_REGION"),
+		"AWS region of --eks-cluster-name. Defaults to $AWS_REGION.")
+	flag.StringVar(&gcpProject, "gcp-project", "",
+		"Google Cloud project of the GKE clusters. When set, the Google accounts of Users with spec.gcp "+
+			"are granted their IAM roles on the project. Uses the Google service account of the controller's "+
+			"ServiceAccount through Workload Identity, which needs resourcemanager.projects.setIamPolicy.")
+	flag.DurationVar(&reconcileTimeout, "reconcile-timeout", controller.DefaultReconcileTimeout,
+		"Maximum duration of a single User reconcile. 0 disables the timeout.")
+	flag.IntVar(&circuitBreakerFailures, "circuit-breaker-failures", controller.DefaultCircuitBreakerFailures,
+		"Consecutive reconcile failures after which a User is skipped for --circuit-breaker-cooldown. 0 disables it.")
+	flag.DurationVar(&circuitBreakerCooldown, "circuit-breaker-cooldown", controller.DefaultCircuitBreakerCooldown,
+		"How long reconciles of a User are skipped once its circuit breaker opened.")
+	flag.IntVar(&maxConcurrentReconciles, "max-concurrent-reconciles", 1,
+		"How many Users are reconciled in parallel.")
+	flag.DurationVar(&queueBaseDelay, "queue-base-delay", controller.DefaultQueueBaseDelay,
+		"Delay before a User whose reconcile failed is retried the first time; it doubles with every failure.")
+	flag.DurationVar(&queueMaxDelay, "queue-max-delay", controller.DefaultQueueMaxDelay,
+		"Maximum delay before a User whose reconcile failed is retried.")
+	flag.Float64Var(&queueQPS, "queue-qps", controller.DefaultQueueQPS,
+		"How many User reconciles per second the workqueue starts at most, across all Users.")
+	flag.IntVar(&queueBurst, "queue-burst", controller.DefaultQueueBurst,
+		"How many User reconciles the workqueue starts at once before --queue-qps applies.")
+	flag.DurationVar(&retryBaseDelay, "retry-base-delay", controller.DefaultRetryBaseDelay,
+		"Delay before a User whose certificate or token could not be issued yet is reconciled again; "+
+			"it doubles with every attempt.")
+	flag.DurationVar(&retryMaxDelay, "retry-max-delay", controller.DefaultRetryMaxDelay,
+		"Maximum delay before a User whose certificate or token could not be issued yet is reconciled again.")
+	flag.Float64Var(&kubeAPIQPS, "kube-api-qps", 20,
+		"Requests per second the manager sends to the API server at most.")
+	flag.IntVar(&kubeAPIBurst, "kube-api-burst", 30,
+		"Requests the manager sends to the API server at once before --kube-api-qps applies.")
+	flag.DurationVar(&statusMessageInterval, "status-message-interval", controller.DefaultStatusMessageInterval,
+		"Minimum time between status writes of a User when only status or condition messages changed. "+
+			"Unchanged status is never written.")
+	flag.StringVar(&namespaceCleanup, "namespace-cleanup", controller.NamespaceCleanupNone,
+		"What to remove from the kubeuser namespace when the last User is deleted: 'none', 'leftovers' "+
+			"(controller-created Secrets and ServiceAccounts) or 'namespace' (the namespace itself, "+
+			"only if the controller created it). The namespace is recreated for the next User.")
+	flag.DurationVar(&usageWindow, "usage-window", controller.DefaultUsageWindow,
+		"How long permissions must go unused before they are reported, and how much observed API usage "+
+			"role recommendations are based on (requires the UsageTracking feature gate).")
+	flag.StringVar(&auditClientCA, "audit-client-ca", "",
+		"PEM bundle of the CA that signs the client certificate of the API server's audit webhook. "+
+			"Events sent to /audit without such a certificate or the --audit-token-file token are rejected.")
+	flag.StringVar(&auditTokenFile, "audit-token-file", "",
+		"File holding the bearer token the API server's audit webhook sends to /audit. The UsageTracking "+
+			"feature gate requires this or --audit-client-ca.")
+	flag.DurationVar(&accessHistoryRetention, "access-history-retention", history.DefaultRetention,
+		"How long AccessGrantRecords are kept before they are deleted. 0 keeps them forever.")
+	flag.BoolVar(&effectiveAccessReview, "effective-access-rules-review", false,
+		"Resolve status.effectiveAccess of Users with SelfSubjectRulesReviews made as the user, which also "+
+			"covers bindings KubeUser does not manage, instead of from the bound roles alone.")
+	flag.StringVar(&issuerConfig.Backend, "issuer", controller.IssuerKubernetes,
+		"Backend signing user certificates: 'kubernetes' (the CSR API), 'cert-manager', 'vault' or 'spire'.")
+	flag.StringVar(&issuerConfig.CertManagerIssuer, "cert-manager-issuer", os.Getenv("KUBEUSER_CERT_MANAGER_ISSUER"),
+		"cert-manager issuer for --issuer=cert-manager as [Issuer/|ClusterIssuer/]<name>; a bare name is a "+
+			"ClusterIssuer and an Issuer must live in the kubeuser namespace.")
+	flag.StringVar(&issuerConfig.Vault.Address, "vault-address", os.Getenv("VAULT_ADDR"),
+		"Address of the Vault server for --issuer=vault and --key-protection=vault-transit.")
+	flag.StringVar(&issuerConfig.Vault.CACert, "vault-ca-cert", os.Getenv("VAULT_CACERT"),
+		"PEM file with the CA of the Vault server certificate. Defaults to the system roots.")
+	flag.StringVar(&issuerConfig.Vault.PKIMount, "vault-pki-mount", "pki", "Mount path of the Vault PKI secrets engine.")
+	flag.StringVar(&issuerConfig.Vault.Role, "vault-pki-role", "",
+		"Vault PKI role used to sign user certificates; it must allow the usernames as common names.")
+	flag.StringVar(&issuerConfig.Vault.AuthMount, "vault-auth-mount", "kubernetes",
+		"Mount path of the Vault Kubernetes auth method.")
+	flag.StringVar(&issuerConfig.Vault.AuthRole, "vault-auth-role", "",
+		"Vault Kubernetes auth role the controller ServiceAccount logs in as.")
+	flag.StringVar(&issuerConfig.Vault.TokenPath, "vault-token-path", issuer.DefaultServiceAccountTokenPath,
+		"ServiceAccount token presented to the Vault Kubernetes auth method.")
+	flag.StringVar(&issuerConfig.SPIRE.SocketPath, "spire-server-socket", spire.DefaultSocketPath,
+		"SPIRE server API socket for --issuer=spire, shared with the controller.")
+	flag.StringVar(&issuerConfig.SPIRE.TrustDomain, "spire-trust-domain", "",
+		"Trust domain of the SPIRE server for --issuer=spire.")
+	flag.StringVar(&issuerConfig.SPIRE.IDPath, "spire-id-path", issuer.DefaultSPIFFEIDPath,
+		"Path of user SPIFFE IDs for --issuer=spire; users are spiffe://<trust domain><path>/<user>.")
+	flag.StringVar(&keyProtectionConfig.Backend, "key-protection", controller.KeyProtectionNone,
+		"How private keys the controller generates for users are stored: 'none' (PEM in the <user>-key Secret) or "+
+			"'vault-transit' (encrypted with --vault-transit-key, decrypted only in memory). Existing keys are "+
+			"encrypted the next time they are used.")
+	flag.StringVar(&keyProtectionConfig.TransitMount, "vault-transit-mount", "transit",
+		"Mount path of the Vault transit secrets engine for --key-protection=vault-transit.")
+	flag.StringVar(&keyProtectionConfig.TransitKey, "vault-transit-key", "",
+		"Vault transit key that encrypts user private keys; it must be of an AEAD type such as aes256-gcm96.")
+	flag.DurationVar(&certificateDuration, "certificate-duration", 0,
+		"Requested lifetime of user certificates. Zero leaves it to the signer; the CSR API signer caps it at "+
+			"--cluster-signing-duration. Overridden by spec.certificateDuration of the KubeUserConfig.")
+	flag.DurationVar(&rotationThreshold, "rotation-threshold", operatorconfig.DefaultRotationThreshold,
+		"How long before expiry user certificates are renewed. Overridden by spec.rotationThreshold of the KubeUserConfig.")
+	flag.IntVar(&rotationThresholdPercent, "rotation-threshold-percent", 0,
+		"Renew user certificates once less than this percentage (1-99) of their lifetime remains, instead of "+
+			"--rotation-threshold before expiry. Overridden by spec.rotationThresholdPercent of the KubeUserConfig.")
+	flag.StringVar(&usernameTemplate, "username-template", "",
+		"Username users authenticate as, with "+operatorconfig.UsernamePlaceholder+" standing for the User's name, "+
+			"e.g. 'kubeuser:"+operatorconfig.UsernamePlaceholder+"'. Empty uses the name itself. Changing it reissues "+
+			"every certificate. Overridden by spec.usernameTemplate of the KubeUserConfig.")
+	flag.DurationVar(&renewalCheckInterval, "renewal-check-interval", controller.DefaultRenewalCheckInterval,
+		"How often a User is reconciled when nothing else is due, and so how late after the rotation threshold "+
+			"its certificate may be renewed; between "+controller.MinRenewalCheckInterval.String()+" and "+
+			controller.MaxRenewalCheckInterval.String()+".")
+	flag.StringVar(&entraConfig.TenantID, "entra-tenant-id", os.Getenv("AZURE_TENANT_ID"),
+		"Microsoft Entra ID tenant whose groups Teams can sync members from with provider 'entra'. "+
+			"The client secret is read from $AZURE_CLIENT_SECRET. Entra ID sync is disabled when empty.")
+	flag.StringVar(&entraConfig.ClientID, "entra-client-id", os.Getenv("AZURE_CLIENT_ID"),
+		"Application (client) ID the controller signs in to Microsoft Graph as.")
+	flag.StringVar(&entraConfig.UsernameAttribute, "entra-username-attribute", directory.DefaultEntraUsernameAttribute,
+		"Entra ID user property mapped to User names: 'userPrincipalName', 'mail' or 'mailNickname'. "+
+			"The part before '@' is used, lowercased, with dots and underscores replaced by dashes.")
+	flag.StringVar(&googleConfig.CredentialsFile, "google-credentials-file", os.Getenv("GOOGLE_APPLICATION_CREDENTIALS"),
+		"JSON key of a service account with domain-wide delegation, whose Google Workspace groups Teams can sync "+
+			"members from with provider 'google'. Google Workspace sync is disabled when empty.")
+	flag.StringVar(&googleConfig.Subject, "google-admin-email", os.Getenv("KUBEUSER_GOOGLE_ADMIN_EMAIL"),
+		"Google Workspace administrator the service account acts as when reading groups.")
+	flag.StringVar(&googleConfig.UsernameAttribute, "google-username-attribute", directory.DefaultGoogleUsernameAttribute,
+		"Google Workspace user attribute mapped to User names: 'primaryEmail' or a custom schema field as "+
+			"<schema>.<field>. It is mapped like --entra-username-attribute.")
+	flag.DurationVar(&directorySyncInterval, "directory-sync-interval", controller.DefaultDirectorySyncInterval,
+		"How often the directory group of a Team with spec.directorySync is read.")
+	flag.StringVar(&proxyAddr, "impersonation-proxy-bind-address", ":8444",
+		"The address the impersonation proxy binds to (requires the ImpersonationProxy feature gate).")
+	flag.StringVar(&proxyURL, "impersonation-proxy-url", os.Getenv("KUBEUSER_IMPERSONATION_PROXY_URL"),
+		"External URL of the impersonation proxy, written into generated kubeconfigs instead of the API server "+
+			"unless a User sets spec.output.server.")
+	flag.StringVar(&proxyCertPath, "impersonation-proxy-cert-path", "",
+		"The directory that contains the impersonation proxy serving certificate.")
+	flag.StringVar(&proxyCertName, "impersonation-proxy-cert-name", "tls.crt",
+		"The name of the impersonation proxy certificate file.")
+	flag.StringVar(&proxyCertKey, "impersonation-proxy-cert-key", "tls.key",
+		"The name of the impersonation proxy key file.")
+	flag.StringVar(&proxyCA, "impersonation-proxy-ca", "",
+		"PEM file with the CA of the proxy serving certificate, embedded in generated kubeconfigs. "+
+			"Defaults to ca.crt in --impersonation-proxy-cert-path.")
+	flag.StringVar(&proxyClientCA, "impersonation-proxy-client-ca", "",
+		"PEM file with the CA that signs user certificates, which the proxy verifies clients against. "+
+			"Defaults to the cluster CA from --ca-sources.")
+	flag.StringVar(&portalAddr, "portal-bind-address", ":8445",
+		"The address the kubeconfig download portal binds to (requires the SelfServiceAPI feature gate).")
+	flag.StringVar(&portalCertPath, "portal-cert-path", "",
+		"The directory that contains the portal serving certificate.")
+	flag.StringVar(&portalCertName, "portal-cert-name", "tls.crt", "The name of the portal certificate file.")
+	flag.StringVar(&portalCertKey, "portal-cert-key", "tls.key", "The name of the portal key file.")
+	flag.StringVar(&oidcIssuerURL, "oidc-issuer-url", os.Getenv("KUBEUSER_OIDC_ISSUER_URL"),
+		"External https URL of the OIDC issuer, the iss claim of its tokens, which the API server's "+
+			"--oidc-issuer-url must match (requires the OIDC feature gate).")
+	flag.StringVar(&oidcAddr, "oidc-bind-address", ":8446", "The address the OIDC issuer binds to.")
+	flag.StringVar(&oidcCertPath, "oidc-cert-path", "",
+		"The directory that contains the OIDC issuer serving certificate.")
+	flag.StringVar(&oidcCertName, "oidc-cert-name", "tls.crt", "The name of the OIDC issuer certificate file.")
+	flag.StringVar(&oidcCertKey, "oidc-cert-key", "tls.key", "The name of the OIDC issuer key file.")
+	flag.StringVar(&oidcClientCA, "oidc-client-ca", "",
+		"PEM file with the CA that signs user certificates, which the OIDC issuer verifies clients against. "+
+			"Defaults to the cluster CA from --ca-sources.")
+	flag.StringVar(&oidcClientID, "oidc-client-id", oidc.DefaultClientID,
+		"Audience of issued tokens, which the API server's --oidc-client-id must match.")
+	flag.DurationVar(&oidcTokenTTL, "oidc-token-ttl", oidc.DefaultTokenTTL,
+		"How long issued tokens are valid, at most "+oidc.MaxTokenTTL.String()+".")
+	flag.DurationVar(&oidcRefreshTokenTTL, "oidc-refresh-token-ttl", oidc.DefaultRefreshTokenTTL,
+		"How long a login can be kept going with refresh tokens, at most "+oidc.MaxRefreshTokenTTL.String()+
+			"; refreshing does not extend it. 0 disables refresh tokens.")
+	flag.StringVar(&tracingConfig.Endpoint, "otlp-endpoint", "",
+		"host:port of an OTLP gRPC collector receiving OpenTelemetry spans of reconciles, certificate "+
+			"issuance, admission reviews and Secret writes. Tracing is off when empty.")
+	flag.BoolVar(&tracingConfig.Insecure, "otlp-insecure", false,
+		"Send spans to --otlp-endpoint without TLS, e.g. to a collector in the same pod.")
+	flag.BoolVar(&enablePprof, "enable-pprof", false,
+		"Serve the Go profiler under /debug/pprof/ on the metrics endpoint, with the same authentication "+
+			"and authorization as /metrics.")
+	flag.Var(features.DefaultGate, "feature-gates",
+		"Comma separated Name=true|false pairs enabling experimental features. Falls back to $"+envFeatureGates+
+			". Options are:\n"+strings.Join(features.DefaultGate.KnownFeatures(), "\n"))
 	opts := zap.Options{
 		Development: true,
 	}
@@ -88,6 +392,20 @@ func main() {
 
 	ctrl.SetLogger(zap.New(zap.UseFlagOptions(&opts)))
 
+	featureGatesSet := false
+	flag.Visit(func(f *flag.Flag) {
+		if f.Name == "feature-gates" {
+			featureGatesSet = true
+		}
+	})
+	if env := os.Getenv(envFeatureGates); !featureGatesSet && env != "" {
+		if err := features.DefaultGate.Set(env); err != nil {
+			setupLog.Error(err, "invalid "+envFeatureGates)
+			os.Exit(1)
+		}
+	}
+	setupLog.Info("Feature gates", "enabled", features.DefaultGate.EnabledFeatures())
+
 	// if the enable-http2 flag is false (the default), http/2 should be disabled
 	// due to its vulnerabilities. More specifically, disabling http/2 will
 	// prevent from being vulnerable to the HTTP/2 Stream Cancellation and
@@ -103,21 +421,140 @@ func main() {
 		tlsOpts = append(tlsOpts, disableHTTP2)
 	}
 
-	// Certificate management is now handled by cert-manager
-	// The webhook server will use certificates from the mounted secret
+	if err := controller.ValidateBindingMode(bindingMode); err != nil {
+		setupLog.Error(err, "invalid --binding-mode")
+		os.Exit(1)
+	}
+
+	if err := controller.ValidateNamespaceCleanup(namespaceCleanup); err != nil {
+		setupLog.Error(err, "invalid --namespace-cleanup")
+		os.Exit(1)
+	}
+
+	if enablePprof && metricsAddr == "0" {
+		setupLog.Error(nil, "--enable-pprof requires the metrics endpoint, set --metrics-bind-address")
+		os.Exit(1)
+	}
+	if clusterAPI && !features.Enabled(features.MultiCluster) {
+		setupLog.Error(nil, "--cluster-api requires the MultiCluster feature gate")
+		os.Exit(1)
+	}
+
+	var eksClient *aws.EKS
+	if eksClusterName != "" {
+		if eksRegion == "" {
+			setupLog.Error(nil, "--eks-cluster-name requires --eks-region")
+			os.Exit(1)
+		}
+		httpClient := &http.Client{Timeout: 30 * time.Second}
+		awsCredentials, err := aws.EnvironmentCredentials(eksRegion, httpClient)
+		if err != nil {
+			setupLog.Error(err, "--eks-cluster-name needs AWS credentials")
+			os.Exit(1)
+		}
+		eksClient = aws.NewEKS(eksRegion, eksClusterName, awsCredentials, httpClient)
+	}
+	shutdownTracing, err := tracing.Setup(context.Background(), tracingConfig)
+	if err != nil {
+		setupLog.Error(err, "unable to set up tracing")
+		os.Exit(1)
+	}
+	if tracingConfig.Endpoint != "" {
+		setupLog.Info("Exporting traces", "endpoint", tracingConfig.Endpoint)
+	}
+
+	var gcpClient *gcp.Projects
+	if gcpProject != "" {
+		gcpClient = gcp.NewProjects(&http.Client{Timeout: 30 * time.Second})
+	}
 
-	// Set default cert path if not provided
-	if webhookCertPath == "" {
-		webhookCertPath = "/tmp/k8s-webhook-server/serving-certs"
+	sshCASecretName, sshCAKey, err := controller.ParseSSHCASecret(sshCASecret)
+	if err != nil {
+		setupLog.Error(err, "invalid --ssh-ca-secret")
+		os.Exit(1)
 	}
 
-	// Initial webhook TLS options
+	parsedCredentialLayout, err := credentials.ParseLayout(credentialLayout)
+	if err != nil {
+		setupLog.Error(err, "invalid --credential-layout")
+		os.Exit(1)
+	}
+
+	notificationTemplates, err := notify.New(nil, nil)
+	if notificationTemplatesDir != "" {
+		notificationTemplates, err = notify.LoadDir(notificationTemplatesDir)
+	}
+	if err != nil {
+		setupLog.Error(err, "invalid --notification-templates", "dir", notificationTemplatesDir)
+		os.Exit(1)
+	}
+
+	parsedCASources, err := ca.ParseSources(caSources)
+	if err != nil {
+		setupLog.Error(err, "invalid --ca-sources")
+		os.Exit(1)
+	}
+	setupLog.Info("Configured CA sources", "sources", ca.SourceNames(parsedCASources))
+
+	// Flags are the defaults; the KubeUserConfig named "default" overrides them at runtime
+	configDefaults := operatorconfig.Defaults()
+	configDefaults.CertificateDuration = certificateDuration
+	configDefaults.RotationThreshold = rotationThreshold
+	configDefaults.RotationThresholdPercent = int32(rotationThresholdPercent)
+	configDefaults.UsernameTemplate = usernameTemplate
+	configDefaults.APIServer = apiServer
+	configDefaults.Namespace = kubeuserNamespace
+	if err := operatorconfig.DefaultStore.SetDefaults(configDefaults); err != nil {
+		setupLog.Error(err, "invalid operator defaults")
+		os.Exit(1)
+	}
+	setupLog.Info("KubeUser namespace", "namespace", operatorconfig.Namespace())
+	if renewalCheckInterval < controller.MinRenewalCheckInterval || renewalCheckInterval > controller.MaxRenewalCheckInterval {
+		setupLog.Error(nil, "--renewal-check-interval is out of range", "interval", renewalCheckInterval,
+			"min", controller.MinRenewalCheckInterval, "max", controller.MaxRenewalCheckInterval)
+		os.Exit(1)
+	}
+
+	// Certificate management is handled by cert-manager, or by the controller with
+	// --webhook-cert-secret; the webhook server uses the certificate from the mounted
+	// or synced secret. Validate it up front so a wrong path or a certificate issued
+	// for another Service fails at startup instead of as TLS errors in the API server.
+	webhookCertConfig.ApplyEnv()
+	if webhookCertConfig.CertDir == "" {
+		webhookCertConfig.CertDir = certs.DefaultCertDir
+	}
+	if webhookCertConfig.SecretName != "" {
+		if err := webhookCertConfig.ValidateSettings(); err != nil {
+			setupLog.Error(err, "invalid webhook certificate configuration")
+			os.Exit(1)
+		}
+		if err := syncWebhookCertSecret(webhookCertConfig); err != nil {
+			setupLog.Error(err, "unable to sync webhook certificate Secret", "secret", webhookCertConfig.SecretName)
+			os.Exit(1)
+		}
+	}
+	if err := webhookCertConfig.Validate(); err != nil {
+		setupLog.Error(err, "invalid webhook certificate configuration")
+		os.Exit(1)
+	}
+	setupLog.Info("Using webhook certificate", "certDir", webhookCertConfig.CertDir,
+		"service", webhookCertConfig.ServiceHost())
+
 	webhookTLSOpts := tlsOpts
+	if features.Enabled(features.UsageTracking) && auditClientCA != "" {
+		// The /audit handler verifies the audit webhook's client certificate itself, since
+		// admission requests come without one
+		webhookTLSOpts = append(slices.Clone(tlsOpts), func(c *tls.Config) {
+			if c.ClientAuth == tls.NoClientCert {
+				c.ClientAuth = tls.RequestClientCert
+			}
+		})
+	}
 	webhookServerOptions := webhook.Options{
 		TLSOpts:  webhookTLSOpts,
-		CertDir:  webhookCertPath,
-		CertName: webhookCertName,
-		KeyName:  webhookCertKey,
+		CertDir:  webhookCertConfig.CertDir,
+		CertName: webhookCertConfig.CertName,
+		KeyName:  webhookCertConfig.KeyName,
 	}
 
 	webhookServer := webhook.NewServer(webhookServerOptions)
@@ -157,43 +594,409 @@ func main() {
 		metricsServerOptions.KeyName = metricsCertKey
 	}
 
-	mgr, err := ctrl.NewManager(ctrl.GetConfigOrDie(), ctrl.Options{
+	restConfig := ctrl.GetConfigOrDie()
+	restConfig.QPS = float32(kubeAPIQPS)
+	restConfig.Burst = kubeAPIBurst
+	mgr, err := ctrl.NewManager(restConfig, ctrl.Options{
 		Scheme:                 scheme,
 		Metrics:                metricsServerOptions,
 		WebhookServer:          webhookServer,
 		HealthProbeBindAddress: probeAddr,
 		LeaderElection:         enableLeaderElection,
 		LeaderElectionID:       "01049f18.openkube.io",
-		// LeaderElectionReleaseOnCancel defines if the leader should step down voluntarily
-		// when the Manager ends. This requires the binary to immediately end when the
-		// Manager is stopped, otherwise, this setting is unsafe. Setting this significantly
-		// speeds up voluntary leader transitions as the new leader don't have to wait
-		// LeaseDuration time first.
-		//
-		// In the default scaffold provided, the program ends immediately after
-		// the manager stops, so would be fine to enable this option. However,
-		// if you are doing or is intended to do any operation such as perform cleanups
-		// after the manager stops then its usage might be unsafe.
-		// LeaderElectionReleaseOnCancel: true,
+		// The new leader takes over as soon as the old one stops, instead of waiting for the
+		// lease to expire. Safe because the process exits right after the manager stops;
+		// flushing traces writes nothing to the cluster.
+		LeaderElectionReleaseOnCancel: true,
 	})
 	if err != nil {
 		setupLog.Error(err, "unable to start manager")
 		os.Exit(1)
 	}
 
-	if err := (&controller.UserReconciler{
+	if err := controller.SetupIndexes(context.Background(), mgr.GetFieldIndexer()); err != nil {
+		setupLog.Error(err, "unable to set up field indexes")
+		os.Exit(1)
+	}
+
+	caResolver := ca.NewResolver(mgr.GetClient(), parsedCASources)
+
+	certIssuer, err := controller.NewIssuer(mgr.GetClient(), issuerConfig)
+	if err != nil {
+		setupLog.Error(err, "invalid --issuer")
+		os.Exit(1)
+	}
+	setupLog.Info("Certificate issuer", "issuer", issuerConfig.Backend)
+
+	keyProtectionConfig.Vault = issuerConfig.Vault.Client()
+	keyProtector, err := controller.NewKeyProtector(keyProtectionConfig)
+	if err != nil {
+		setupLog.Error(err, "invalid --key-protection")
+		os.Exit(1)
+	}
+	if keyProtector != nil {
+		setupLog.Info("User private keys are encrypted", "protector", keyProtector.Name())
+	}
+
+	// Usage tracking: the API server sends audit events to /audit on the webhook server,
+	// authenticated with a client certificate or a bearer token; the users' last activity is
+	// written to their status and the resulting recommendations are published on the
+	// (authenticated) metrics server.
+	var usageStore *usage.Store
+	if features.Enabled(features.UsageTracking) {
+		usageStore = usage.NewStore()
+		ingester := &usage.Ingester{
+			Store:   usageStore,
+			Resolve: usage.UserResolver(mgr.GetClient(), operatorconfig.Namespace, operatorconfig.UserName),
+		}
+		if auditClientCA == "" && auditTokenFile == "" {
+			setupLog.Error(nil, "--audit-client-ca or --audit-token-file is required with the UsageTracking feature gate")
+			os.Exit(1)
+		}
+		if auditClientCA != "" {
+			data, err := os.ReadFile(auditClientCA)
+			if err != nil {
+				setupLog.Error(err, "unable to read --audit-client-ca")
+				os.Exit(1)
+			}
+			ingester.ClientCAs = x509.NewCertPool()
+			if !ingester.ClientCAs.AppendCertsFromPEM(data) {
+				setupLog.Error(nil, "no certificates in --audit-client-ca", "path", auditClientCA)
+				os.Exit(1)
+			}
+		}
+		if auditTokenFile != "" {
+			data, err := os.ReadFile(auditTokenFile)
+			if err != nil {
+				setupLog.Error(err, "unable to read --audit-token-file")
+				os.Exit(1)
+			}
+			if ingester.Token = strings.TrimSpace(string(data)); ingester.Token == "" {
+				setupLog.Error(nil, "--audit-token-file is empty", "path", auditTokenFile)
+				os.Exit(1)
+			}
+		}
+		webhookServer.Register("/audit", ingester)
+		if err := mgr.Add(&usage.ActivityWriter{Client: mgr.GetClient(), Store: usageStore}); err != nil {
+			setupLog.Error(err, "unable to add activity writer")
+			os.Exit(1)
+		}
+		reportHandler := &usage.ReportHandler{Reader: mgr.GetClient()}
+		if err := mgr.AddMetricsServerExtraHandler("/usage/report", reportHandler); err != nil {
+			setupLog.Error(err, "unable to register usage report handler")
+			os.Exit(1)
+		}
+		setupLog.Info("Usage tracking enabled", "window", usageWindow)
+	}
+
+	// Impersonation proxy: users reach the API server through the manager, which checks every
+	// request against the current User and certificate inventory and impersonates the user
+	// with the groups of its User
+	var proxyServerCA []byte
+	if features.Enabled(features.ImpersonationProxy) {
+		if proxyURL == "" || proxyCertPath == "" {
+			setupLog.Error(nil, "--impersonation-proxy-url and --impersonation-proxy-cert-path are required "+
+				"with the ImpersonationProxy feature gate")
+			os.Exit(1)
+		}
+		if proxyCA == "" {
+			proxyCA = filepath.Join(proxyCertPath, "ca.crt")
+		}
+		if proxyServerCA, err = os.ReadFile(proxyCA); err != nil {
+			setupLog.Error(err, "unable to read --impersonation-proxy-ca")
+			os.Exit(1)
+		}
+		clientCA := func(ctx context.Context) ([]byte, error) {
+			if proxyClientCA != "" {
+				return os.ReadFile(proxyClientCA)
+			}
+			data, _, err := caResolver.Resolve(ctx)
+			return data, err
+		}
+		apiServerURL, _, err := rest.DefaultServerUrlFor(mgr.GetConfig())
+		if err != nil {
+			setupLog.Error(err, "unable to determine the API server URL for the impersonation proxy")
+			os.Exit(1)
+		}
+		apiServerTransport, err := rest.TransportFor(mgr.GetConfig())
+		if err != nil {
+			setupLog.Error(err, "unable to create the API server transport for the impersonation proxy")
+			os.Exit(1)
+		}
+		if err := mgr.Add(&proxy.Server{
+			Addr:     proxyAddr,
+			CertDir:  proxyCertPath,
+			CertName: proxyCertName,
+			KeyName:  proxyCertKey,
+			ClientCA: clientCA,
+			TLSOpts:  tlsOpts,
+			Handler:  proxy.New(mgr.GetClient(), proxy.Reviewing(mgr.GetClient()), apiServerURL, apiServerTransport),
+		}); err != nil {
+			setupLog.Error(err, "unable to set up the impersonation proxy")
+			os.Exit(1)
+		}
+		setupLog.Info("Impersonation proxy enabled", "addr", proxyAddr, "url", proxyURL)
+	} else {
+		proxyURL = ""
+	}
+
+	// Download portal, admin API and dashboard: users redeem one-time links to their kubeconfig,
+	// and automation and people manage users over HTTPS
+	if features.Enabled(features.SelfServiceAPI) {
+		if portalCertPath == "" {
+			setupLog.Error(nil, "--portal-cert-path is required with the SelfServiceAPI feature gate")
+			os.Exit(1)
+		}
+		downloads := portal.New(mgr.GetAPIReader(), mgr.GetClient(), operatorconfig.Namespace)
+		mux := http.NewServeMux()
+		mux.Handle("/download/", downloads)
+		mux.Handle("/api/", adminapi.New(mgr.GetClient(),
+			adminapi.Impersonating(mgr.GetConfig(), client.Options{Scheme: mgr.GetScheme(), Mapper: mgr.GetRESTMapper()}),
+			operatorconfig.Namespace))
+		mux.Handle(dashboard.Path, dashboard.Handler())
+		if err := mgr.Add(&portal.Server{
+			Addr:     portalAddr,
+			CertDir:  portalCertPath,
+			CertName: portalCertName,
+			KeyName:  portalCertKey,
+			TLSOpts:  tlsOpts,
+			Portal:   downloads,
+			Handler:  mux,
+		}); err != nil {
+			setupLog.Error(err, "unable to set up the portal")
+			os.Exit(1)
+		}
+		setupLog.Info("Download portal, admin API and dashboard enabled", "addr", portalAddr)
+	}
+
+	// OIDC issuer: users exchange their client certificate for short-lived tokens the API server
+	// trusts through its --oidc-* flags
+	if features.Enabled(features.OIDC) {
+		if oidcIssuerURL == "" || oidcCertPath == "" {
+			setupLog.Error(nil, "--oidc-issuer-url and --oidc-cert-path are required with the OIDC feature gate")
+			os.Exit(1)
+		}
+		oidcIssuer, err := oidc.New(oidcIssuerURL, oidcClientID, oidcTokenTTL, mgr.GetClient())
+		if err != nil {
+			setupLog.Error(err, "invalid OIDC issuer configuration")
+			os.Exit(1)
+		}
+		if oidcRefreshTokenTTL < 0 || oidcRefreshTokenTTL > oidc.MaxRefreshTokenTTL {
+			setupLog.Error(nil, "--oidc-refresh-token-ttl must be between 0 and "+oidc.MaxRefreshTokenTTL.String())
+			os.Exit(1)
+		}
+		oidcIssuer.RefreshTTL = oidcRefreshTokenTTL
+		oidcIssuer.Secrets, oidcIssuer.Writer, oidcIssuer.Namespace = mgr.GetAPIReader(), mgr.GetClient(), operatorconfig.Namespace
+		oidcIssuer.Reviewer = proxy.Reviewing(mgr.GetClient())
+		if err := mgr.Add(&oidc.KeySyncer{
+			Reader: mgr.GetAPIReader(),
+			Writer: mgr.GetClient(),
+			Secret: types.NamespacedName{Namespace: operatorconfig.Namespace(), Name: oidc.SigningKeySecret},
+			Issuer: oidcIssuer,
+		}); err != nil {
+			setupLog.Error(err, "unable to set up the OIDC signing key")
+			os.Exit(1)
+		}
+		if err := mgr.Add(&oidc.Server{
+			Addr:     oidcAddr,
+			CertDir:  oidcCertPath,
+			CertName: oidcCertName,
+			KeyName:  oidcCertKey,
+			ClientCA: func(ctx context.Context) ([]byte, error) {
+				if oidcClientCA != "" {
+					return os.ReadFile(oidcClientCA)
+				}
+				data, _, err := caResolver.Resolve(ctx)
+				return data, err
+			},
+			TLSOpts: tlsOpts,
+			Issuer:  oidcIssuer,
+		}); err != nil {
+			setupLog.Error(err, "unable to set up the OIDC issuer")
+			os.Exit(1)
+		}
+		setupLog.Info("OIDC issuer enabled", "addr", oidcAddr, "issuer", oidcIssuerURL)
+	}
+
+	// Certificates KubeUser revoked but Kubernetes still accepts until they expire
+	revocationList := &inventory.RevocationListHandler{Reader: mgr.GetClient()}
+	if err := mgr.AddMetricsServerExtraHandler("/certificates/revoked", revocationList); err != nil {
+		setupLog.Error(err, "unable to register certificate revocation list handler")
+		os.Exit(1)
+	}
+
+	// Access grant records past their retention are deleted by the leader
+	if err := mgr.Add(&history.Pruner{
+		Reader:    mgr.GetAPIReader(),
+		Writer:    mgr.GetClient(),
+		Retention: accessHistoryRetention,
+	}); err != nil {
+		setupLog.Error(err, "unable to set up access history pruning")
+		os.Exit(1)
+	}
+
+	if webhookCertConfig.SecretName != "" {
+		if err := mgr.Add(&certs.SecretSyncer{Client: mgr.GetClient(), Config: webhookCertConfig}); err != nil {
+			setupLog.Error(err, "unable to set up webhook certificate sync")
+			os.Exit(1)
+		}
+	}
+	if injectCABundle {
+		if err := (&certs.CABundleInjector{
+			Client:    mgr.GetClient(),
+			Namespace: webhookCertConfig.Namespace,
+		}).SetupWithManager(mgr); err != nil {
+			setupLog.Error(err, "unable to create controller", "controller", "CABundleInjector")
+			os.Exit(1)
+		}
+	}
+
+	// Every replica serves webhooks, the portal and the proxy, so every replica needs the
+	// settings; only the leader reports on the KubeUserConfig and re-enqueues Users
+	if err := (&controller.KubeUserConfigSettingsReconciler{
 		Client: mgr.GetClient(),
-		Scheme: mgr.GetScheme(),
 	}).SetupWithManager(mgr); err != nil {
+		setupLog.Error(err, "unable to create controller", "controller", "KubeUserConfigSettings")
+		os.Exit(1)
+	}
+	configEvents := make(chan event.GenericEvent, controller.ConfigEventBuffer)
+	if err := (&controller.KubeUserConfigReconciler{
+		Client:     mgr.GetClient(),
+		UserEvents: configEvents,
+	}).SetupWithManager(mgr); err != nil {
+		setupLog.Error(err, "unable to create controller", "controller", "KubeUserConfig")
+		os.Exit(1)
+	}
+
+	var rulesReviewer controller.RulesReviewer
+	if effectiveAccessReview {
+		rulesReviewer = controller.ImpersonatingRulesReviewer(mgr.GetConfig())
+	}
+
+	userReconciler := &controller.UserReconciler{
+		Client:                  mgr.GetClient(),
+		Scheme:                  mgr.GetScheme(),
+		APIReader:               mgr.GetAPIReader(),
+		BindingMode:             bindingMode,
+		CredentialLayout:        parsedCredentialLayout,
+		CAResolver:              caResolver,
+		NotificationTemplates:   notificationTemplates,
+		SSHCASecret:             sshCASecretName,
+		SSHCAKey:                sshCAKey,
+		ServiceAccountAnchor:    serviceAccountAnchor,
+		CredentialReaderRBAC:    credentialReaderRBAC,
+		MemberClusters:          features.Enabled(features.MultiCluster),
+		ClusterAPI:              clusterAPI,
+		EKS:                     eksClient,
+		GCP:                     gcpClient,
+		GCPProject:              gcpProject,
+		ReconcileTimeout:        reconcileTimeout,
+		CircuitBreakerFailures:  circuitBreakerFailures,
+		MaxConcurrentReconciles: maxConcurrentReconciles,
+		RateLimiter:             controller.NewRateLimiter(queueBaseDelay, queueMaxDelay, queueQPS, queueBurst),
+		RetryBaseDelay:          retryBaseDelay,
+		RetryMaxDelay:           retryMaxDelay,
+		RenewalCheckInterval:    renewalCheckInterval,
+		CircuitBreakerCooldown:  circuitBreakerCooldown,
+		StatusMessageInterval:   statusMessageInterval,
+		Recorder:                mgr.GetEventRecorderFor("kubeuser-controller"),
+		Issuer:                  certIssuer,
+		KeyProtector:            keyProtector,
+		CredentialStores:        &secretstore.Opener{Reader: mgr.GetClient(), Vault: issuerConfig.Vault.Client()},
+		ConfigEvents:            configEvents,
+		NamespaceCleanup:        namespaceCleanup,
+		UsageStore:              usageStore,
+		UsageWindow:             usageWindow,
+		RulesReviewer:           rulesReviewer,
+		ProxyServer:             proxyURL,
+		ProxyCA:                 proxyServerCA,
+	}
+	if err := userReconciler.SetupWithManager(mgr); err != nil {
 		setupLog.Error(err, "unable to create controller", "controller", "User")
 		os.Exit(1)
 	}
 
+	// Runtime diagnostics for reconcilers that loop or keep failing
+	diagnostics := map[string]http.Handler{"/debug/reconciles": userReconciler.DiagnosticsHandler()}
+	if enablePprof {
+		diagnostics["/debug/pprof/"] = http.HandlerFunc(pprof.Index)
+		diagnostics["/debug/pprof/cmdline"] = http.HandlerFunc(pprof.Cmdline)
+		diagnostics["/debug/pprof/profile"] = http.HandlerFunc(pprof.Profile)
+		diagnostics["/debug/pprof/symbol"] = http.HandlerFunc(pprof.Symbol)
+		diagnostics["/debug/pprof/trace"] = http.HandlerFunc(pprof.Trace)
+	}
+	for path, handler := range diagnostics {
+		if err := mgr.AddMetricsServerExtraHandler(path, handler); err != nil {
+			setupLog.Error(err, "unable to register diagnostics handler", "path", path)
+			os.Exit(1)
+		}
+	}
+
+	directories := map[authv1alpha1.DirectoryProvider]directory.Directory{}
+	if entraConfig.TenantID != "" {
+		entraConfig.ClientSecret = os.Getenv("AZURE_CLIENT_SECRET")
+		entra, err := directory.NewEntra(entraConfig)
+		if err != nil {
+			setupLog.Error(err, "invalid Entra ID configuration")
+			os.Exit(1)
+		}
+		directories[authv1alpha1.DirectoryProviderEntra] = entra
+		setupLog.Info("Entra ID group sync enabled", "tenant", entraConfig.TenantID)
+	}
+	if googleConfig.CredentialsFile != "" {
+		google, err := directory.NewGoogle(googleConfig)
+		if err != nil {
+			setupLog.Error(err, "invalid Google Workspace configuration")
+			os.Exit(1)
+		}
+		directories[authv1alpha1.DirectoryProviderGoogle] = google
+		setupLog.Info("Google Workspace group sync enabled", "subject", googleConfig.Subject)
+	}
+
+	if err := (&controller.AccessReportReconciler{
+		Client: mgr.GetClient(),
+		Scheme: mgr.GetScheme(),
+	}).SetupWithManager(mgr); err != nil {
+		setupLog.Error(err, "unable to create controller", "controller", "AccessReport")
+		os.Exit(1)
+	}
+
+	if err := (&controller.TeamReconciler{
+		Client:                mgr.GetClient(),
+		Scheme:                mgr.GetScheme(),
+		Directories:           directories,
+		DirectorySyncInterval: directorySyncInterval,
+	}).SetupWithManager(mgr); err != nil {
+		setupLog.Error(err, "unable to create controller", "controller", "Team")
+		os.Exit(1)
+	}
+
+	if err := (&controller.UserClaimReconciler{
+		Client: mgr.GetClient(),
+	}).SetupWithManager(mgr); err != nil {
+		setupLog.Error(err, "unable to create controller", "controller", "UserClaim")
+		os.Exit(1)
+	}
+
 	// Setup webhook for User validation
-	if err := (&webhookpkg.UserWebhook{}).SetupWithManager(mgr); err != nil {
+	if err := (&webhookpkg.UserWebhook{
+		MemberClusters: features.Enabled(features.MultiCluster),
+	}).SetupWithManager(mgr); err != nil {
 		setupLog.Error(err, "unable to create webhook", "webhook", "User")
 		os.Exit(1)
 	}
+	if err := (&webhookpkg.TeamWebhook{}).SetupWithManager(mgr); err != nil {
+		setupLog.Error(err, "unable to create webhook", "webhook", "Team")
+		os.Exit(1)
+	}
+	if err := (&webhookpkg.UserClaimWebhook{}).SetupWithManager(mgr); err != nil {
+		setupLog.Error(err, "unable to create webhook", "webhook", "UserClaim")
+		os.Exit(1)
+	}
+	if err := (&webhookpkg.UserTemplateWebhook{}).SetupWithManager(mgr); err != nil {
+		setupLog.Error(err, "unable to create webhook", "webhook", "UserTemplate")
+		os.Exit(1)
+	}
 
 	// Certificate management is handled by cert-manager - no manual setup needed
 	// +kubebuilder:scaffold:builder
@@ -207,9 +1010,72 @@ func main() {
 		os.Exit(1)
 	}
 
+	// Preflight checks catch misinstalls (missing RBAC, disabled CSR API, unresolvable CA,
+	// broken webhook Service) at startup. Failures keep the pod unready and are reported
+	// in the operator status ConfigMap.
+	discoveryClient, err := discovery.NewDiscoveryClientForConfig(mgr.GetConfig())
+	if err != nil {
+		setupLog.Error(err, "unable to create discovery client")
+		os.Exit(1)
+	}
+	preflightChecker := &preflight.Checker{
+		Checks: []preflight.Check{
+			preflight.CSRSignerCheck(discoveryClient, mgr.GetClient()),
+			preflight.RBACCheck(mgr.GetClient(), preflight.RequiredPermissions(operatorconfig.Namespace())),
+			preflight.CACheck(caResolver),
+			preflight.WebhookCheck(mgr.GetAPIReader(), webhookCertConfig.Namespace, webhookCertConfig.ServiceName,
+				webhook.DefaultPort, webhookServer.StartedChecker()),
+		},
+		Reporter: &operatorstatus.Reporter{Client: mgr.GetClient(), Namespace: webhookCertConfig.Namespace},
+	}
+	if err := mgr.Add(preflightChecker); err != nil {
+		setupLog.Error(err, "unable to set up preflight checks")
+		os.Exit(1)
+	}
+	if err := mgr.AddReadyzCheck("preflight", preflightChecker.ReadyzCheck); err != nil {
+		setupLog.Error(err, "unable to set up preflight ready check")
+		os.Exit(1)
+	}
+
+	// Without leader election every replica would reconcile, approve CSRs and write status,
+	// so a scaled-out deployment must elect a leader
+	if podName := os.Getenv("POD_NAME"); !enableLeaderElection && podName != "" {
+		replicas, err := preflight.Replicas(context.Background(), mgr.GetAPIReader(), webhookCertConfig.Namespace, podName)
+		if err != nil {
+			setupLog.Error(err, "unable to count controller replicas")
+		} else if replicas > 1 {
+			setupLog.Error(nil, "multiple replicas require --leader-elect", "replicas", replicas)
+			os.Exit(1)
+		}
+	}
+
 	setupLog.Info("starting manager")
-	if err := mgr.Start(ctrl.SetupSignalHandler()); err != nil {
-		setupLog.Error(err, "problem running manager")
+	managerErr := mgr.Start(ctrl.SetupSignalHandler())
+	// Export the spans of the last reconciles before exiting
+	flushCtx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	if err := shutdownTracing(flushCtx); err != nil {
+		setupLog.Error(err, "failed to flush traces")
+	}
+	cancel()
+	if managerErr != nil {
+		setupLog.Error(managerErr, "problem running manager")
 		os.Exit(1)
 	}
 }
+
+// syncWebhookCertSecret writes the webhook certificate of the Secret, generated first if
+// needed, to the certificate directory before the webhook server loads it
+func syncWebhookCertSecret(config certs.Config) error {
+	c, err := client.New(ctrl.GetConfigOrDie(), client.Options{Scheme: scheme})
+	if err != nil {
+		return err
+	}
+	ctx, cancel := context.WithTimeout(context.Background(), 30*time.Second)
+	defer cancel()
+	secret, err := certs.EnsureSecret(ctx, c, config, time.Now())
+	if err != nil {
+		return err
+	}
+	_, err = certs.WriteFiles(config, secret)
+	return err
+}
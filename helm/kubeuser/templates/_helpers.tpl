@@ -75,6 +75,13 @@ Create the namespace to use (with suffix if specified)
 {{- end }}
 {{- end }}
 
+{{/*
+The namespace of per-user resources
+*/}}
+{{- define "kubeuser.userNamespace" -}}
+{{- default (include "kubeuser.namespace" .) .Values.global.userNamespace }}
+{{- end }}
+
 {{/*
 Create manager labels for controller-manager
 */}}
@@ -102,3 +109,15 @@ Create image name
 {{- printf "%s:%s" .Values.image.repository (.Values.image.tag | default .Chart.AppVersion) }}
 {{- end }}
 
+
+{{/*
+Annotation that has the CA of the webhook serving certificate injected into a webhook
+configuration, by cert-manager's cainjector or by the controller
+*/}}
+{{- define "kubeuser.webhookCAInjection" -}}
+{{- if .Values.webhook.certManager.enabled }}
+cert-manager.io/inject-ca-from: {{ include "kubeuser.namespace" . }}/{{ include "kubeuser.fullname" . }}-webhook-cert
+{{- else }}
+auth.openkube.io/inject-ca-from-secret: {{ include "kubeuser.namespace" . }}/{{ include "kubeuser.fullname" . }}-webhook-certs
+{{- end }}
+{{- end }}
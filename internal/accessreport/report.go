@@ -0,0 +1,235 @@
+/*
+Copyright 2025.
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+*/
+
+// Package accessreport builds access review reports: every user with its effective bindings,
+// credential expiry, last activity and team memberships, as JSON or CSV.
+package accessreport
+
+import (
+	"bytes"
+	"context"
+	"encoding/csv"
+	"encoding/json"
+	"fmt"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+
+	rbacv1 "k8s.io/api/rbac/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/labels"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+
+	authv1alpha1 "github.com/openkube-hub/KubeUser/api/v1alpha1"
+	"github.com/openkube-hub/KubeUser/internal/team"
+)
+
+// ReportLabel marks the ConfigMaps holding the reports of an AccessReport; the value is its name
+const ReportLabel = "auth.openkube.io/access-report"
+
+// managedLabel marks the bindings KubeUser created for a user; the value is the user's name
+const managedLabel = "auth.openkube.io/user"
+
+// Review is an access review of every user at one point in time
+type Review struct {
+	// Name of the AccessReport it was generated for
+	Name        string    `json:"name"`
+	GeneratedAt time.Time `json:"generatedAt"`
+	Users       []User    `json:"users"`
+}
+
+// User is a user's access as of the report
+type User struct {
+	Name  string `json:"name"`
+	Email string `json:"email,omitempty"`
+	Phase string `json:"phase,omitempty"`
+	// CredentialExpiry is when the user's certificate, or a machine user's token, expires
+	CredentialExpiry *time.Time `json:"credentialExpiry,omitempty"`
+	// LastActivity is when the user last made an API request, if usage tracking is enabled
+	LastActivity *time.Time `json:"lastActivity,omitempty"`
+	Suspended    bool       `json:"suspended,omitempty"`
+	Revoked      bool       `json:"revoked,omitempty"`
+	// Teams listing the user as a member
+	Teams []string `json:"teams"`
+	// Bindings naming the user as a subject, whether KubeUser created them or not
+	Bindings []Binding `json:"bindings"`
+}
+
+// Binding is a RoleBinding or ClusterRoleBinding naming a user as a subject
+type Binding struct {
+	Kind string `json:"kind"`
+	// Namespace of a RoleBinding; empty for ClusterRoleBindings, which apply in every namespace
+	Namespace string `json:"namespace,omitempty"`
+	Name      string `json:"name"`
+	RoleKind  string `json:"roleKind"`
+	Role      string `json:"role"`
+	// Managed is true for bindings KubeUser created for the user
+	Managed bool `json:"managed"`
+}
+
+// Build reports the Users matching selector. Bindings are matched to users by their User
+// subject, or by the ServiceAccount subject of the user's anchor in namespace.
+func Build(ctx context.Context, reader client.Reader, name string, selector labels.Selector, namespace string,
+	now time.Time) (*Review, error) {
+	var users authv1alpha1.UserList
+	if err := reader.List(ctx, &users, client.MatchingLabelsSelector{Selector: selector}); err != nil {
+		return nil, fmt.Errorf("failed to list users: %w", err)
+	}
+	var teams authv1alpha1.TeamList
+	if err := reader.List(ctx, &teams); err != nil {
+		return nil, fmt.Errorf("failed to list teams: %w", err)
+	}
+	var roleBindings rbacv1.RoleBindingList
+	if err := reader.List(ctx, &roleBindings); err != nil {
+		return nil, fmt.Errorf("failed to list role bindings: %w", err)
+	}
+	var clusterRoleBindings rbacv1.ClusterRoleBindingList
+	if err := reader.List(ctx, &clusterRoleBindings); err != nil {
+		return nil, fmt.Errorf("failed to list cluster role bindings: %w", err)
+	}
+
+	bindings := map[string][]Binding{}
+	add := func(kind string, meta metav1.ObjectMeta, subjects []rbacv1.Subject, roleRef rbacv1.RoleRef) {
+		for _, user := range subjectUsers(subjects, namespace) {
+			bindings[user] = append(bindings[user], Binding{
+				Kind: kind, Namespace: meta.Namespace, Name: meta.Name,
+				RoleKind: roleRef.Kind, Role: roleRef.Name,
+				Managed: meta.Labels[managedLabel] == user,
+			})
+		}
+	}
+	for i := range roleBindings.Items {
+		rb := &roleBindings.Items[i]
+		add("RoleBinding", rb.ObjectMeta, rb.Subjects, rb.RoleRef)
+	}
+	for i := range clusterRoleBindings.Items {
+		crb := &clusterRoleBindings.Items[i]
+		add("ClusterRoleBinding", crb.ObjectMeta, crb.Subjects, crb.RoleRef)
+	}
+
+	report := &Review{Name: name, GeneratedAt: now.UTC(), Users: make([]User, 0, len(users.Items))}
+	for i := range users.Items {
+		user := &users.Items[i]
+		entry := User{
+			Name:      user.Name,
+			Email:     user.Spec.Email,
+			Phase:     user.Status.Phase,
+			Suspended: user.Spec.Suspended,
+			Revoked:   user.Spec.Revoked,
+			Teams:     []string{},
+			Bindings:  bindings[user.Name],
+		}
+		if expiry := credentialExpiry(user); !expiry.IsZero() {
+			entry.CredentialExpiry = &expiry
+		}
+		if user.Status.LastActivity != nil {
+			last := user.Status.LastActivity.UTC()
+			entry.LastActivity = &last
+		}
+		for j := range teams.Items {
+			if team.IsMember(&teams.Items[j], user.Name) {
+				entry.Teams = append(entry.Teams, teams.Items[j].Name)
+			}
+		}
+		if entry.Bindings == nil {
+			entry.Bindings = []Binding{}
+		}
+		slices.Sort(entry.Teams)
+		slices.SortFunc(entry.Bindings, func(a, b Binding) int {
+			return strings.Compare(a.Kind+"/"+a.Namespace+"/"+a.Name, b.Kind+"/"+b.Namespace+"/"+b.Name)
+		})
+		report.Users = append(report.Users, entry)
+	}
+	slices.SortFunc(report.Users, func(a, b User) int { return strings.Compare(a.Name, b.Name) })
+	return report, nil
+}
+
+// subjectUsers returns the users the subjects name: User subjects, and ServiceAccount anchors
+// in namespace, which are named after their user
+func subjectUsers(subjects []rbacv1.Subject, namespace string) []string {
+	var users []string
+	for _, subject := range subjects {
+		switch {
+		case subject.Kind == rbacv1.UserKind:
+			users = append(users, subject.Name)
+		case subject.Kind == rbacv1.ServiceAccountKind && subject.Namespace == namespace:
+			users = append(users, subject.Name)
+		}
+	}
+	slices.Sort(users)
+	return slices.Compact(users)
+}
+
+// credentialExpiry returns when the user's certificate or token expires; zero if unknown
+func credentialExpiry(user *authv1alpha1.User) time.Time {
+	if expiry, err := time.Parse(time.RFC3339, user.Status.ExpiryTime); err == nil {
+		return expiry.UTC()
+	}
+	if user.Status.TokenExpiry != nil {
+		return user.Status.TokenExpiry.UTC()
+	}
+	return time.Time{}
+}
+
+// FileName returns the ConfigMap key a report in format is stored under
+func FileName(format authv1alpha1.AccessReportFormat) string {
+	return "report." + strings.ToLower(string(format))
+}
+
+// Encode writes the report in format
+func Encode(report *Review, format authv1alpha1.AccessReportFormat) ([]byte, error) {
+	switch format {
+	case authv1alpha1.AccessReportJSON:
+		return json.MarshalIndent(report, "", "  ")
+	case authv1alpha1.AccessReportCSV:
+		return encodeCSV(report)
+	default:
+		return nil, fmt.Errorf("unknown report format %q", format)
+	}
+}
+
+// csvHeader names the columns of CSV reports; there is one row per user and binding, and
+// one row without binding columns for users without bindings
+var csvHeader = []string{
+	"user", "email", "phase", "credential_expiry", "last_activity", "suspended", "revoked", "teams",
+	"binding_kind", "namespace", "binding", "role_kind", "role", "managed",
+}
+
+func encodeCSV(report *Review) ([]byte, error) {
+	var buf bytes.Buffer
+	w := csv.NewWriter(&buf)
+	if err := w.Write(csvHeader); err != nil {
+		return nil, err
+	}
+	for _, user := range report.Users {
+		head := []string{
+			user.Name, user.Email, user.Phase, formatTime(user.CredentialExpiry), formatTime(user.LastActivity),
+			strconv.FormatBool(user.Suspended), strconv.FormatBool(user.Revoked), strings.Join(user.Teams, ";"),
+		}
+		if len(user.Bindings) == 0 {
+			if err := w.Write(append(head, "", "", "", "", "", "")); err != nil {
+				return nil, err
+			}
+			continue
+		}
+		for _, b := range user.Bindings {
+			row := append(slices.Clone(head), b.Kind, b.Namespace, b.Name, b.RoleKind, b.Role, strconv.FormatBool(b.Managed))
+			if err := w.Write(row); err != nil {
+				return nil, err
+			}
+		}
+	}
+	w.Flush()
+	return buf.Bytes(), w.Error()
+}
+
+func formatTime(t *time.Time) string {
+	if t == nil {
+		return ""
+	}
+	return t.UTC().Format(time.RFC3339)
+}
@@ -0,0 +1,156 @@
+/*
+Copyright 2025.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package accessreport
+
+import (
+	"context"
+	"encoding/json"
+	"strings"
+	"time"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+	rbacv1 "k8s.io/api/rbac/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/labels"
+	"k8s.io/apimachinery/pkg/runtime"
+	clientgoscheme "k8s.io/client-go/kubernetes/scheme"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+
+	authv1alpha1 "github.com/openkube-hub/KubeUser/api/v1alpha1"
+)
+
+var _ = Describe("Access report", func() {
+	var (
+		ctx    context.Context
+		reader client.Reader
+		now    = time.Date(2025, 6, 1, 6, 0, 0, 0, time.UTC)
+	)
+
+	BeforeEach(func() {
+		ctx = context.Background()
+		scheme := runtime.NewScheme()
+		Expect(clientgoscheme.AddToScheme(scheme)).To(Succeed())
+		Expect(authv1alpha1.AddToScheme(scheme)).To(Succeed())
+		lastActivity := metav1.NewTime(time.Date(2025, 5, 30, 12, 0, 0, 0, time.UTC))
+		reader = fake.NewClientBuilder().WithScheme(scheme).WithObjects(
+			&authv1alpha1.User{
+				ObjectMeta: metav1.ObjectMeta{Name: "jane", Labels: map[string]string{"department": "payments"}},
+				Spec:       authv1alpha1.UserSpec{Email: "jane@example.com"},
+				Status: authv1alpha1.UserStatus{
+					Phase: "Active", ExpiryTime: "2025-08-01T00:00:00Z", LastActivity: &lastActivity,
+				},
+			},
+			&authv1alpha1.User{
+				ObjectMeta: metav1.ObjectMeta{Name: "ci"},
+				Status:     authv1alpha1.UserStatus{Phase: "Active", TokenExpiry: &metav1.Time{Time: now.Add(time.Hour)}},
+			},
+			&authv1alpha1.User{
+				ObjectMeta: metav1.ObjectMeta{Name: "bob", Labels: map[string]string{"department": "payments"}},
+				Spec:       authv1alpha1.UserSpec{Suspended: true},
+				Status:     authv1alpha1.UserStatus{Phase: "Suspended"},
+			},
+			&authv1alpha1.Team{
+				ObjectMeta: metav1.ObjectMeta{Name: "payments"},
+				Spec:       authv1alpha1.TeamSpec{Members: []authv1alpha1.TeamMember{{Name: "jane"}, {Name: "bob"}}},
+			},
+			&rbacv1.RoleBinding{
+				ObjectMeta: metav1.ObjectMeta{Name: "jane-edit", Namespace: "prod", Labels: map[string]string{"auth.openkube.io/user": "jane"}},
+				Subjects:   []rbacv1.Subject{{Kind: rbacv1.UserKind, Name: "jane"}, {Kind: rbacv1.ServiceAccountKind, Name: "jane", Namespace: "kubeuser"}},
+				RoleRef:    rbacv1.RoleRef{Kind: "ClusterRole", Name: "edit"},
+			},
+			&rbacv1.RoleBinding{
+				ObjectMeta: metav1.ObjectMeta{Name: "legacy", Namespace: "dev"},
+				Subjects:   []rbacv1.Subject{{Kind: rbacv1.UserKind, Name: "jane"}, {Kind: rbacv1.GroupKind, Name: "jane"}},
+				RoleRef:    rbacv1.RoleRef{Kind: "Role", Name: "debugger"},
+			},
+			&rbacv1.ClusterRoleBinding{
+				ObjectMeta: metav1.ObjectMeta{Name: "ci-view", Labels: map[string]string{"auth.openkube.io/user": "ci"}},
+				Subjects: []rbacv1.Subject{
+					{Kind: rbacv1.ServiceAccountKind, Name: "ci", Namespace: "kubeuser"},
+					{Kind: rbacv1.ServiceAccountKind, Name: "jane", Namespace: "other"},
+				},
+				RoleRef: rbacv1.RoleRef{Kind: "ClusterRole", Name: "view"},
+			},
+		).Build()
+	})
+
+	It("lists every user with its bindings, expiry, activity and teams", func() {
+		report, err := Build(ctx, reader, "quarterly", labels.Everything(), "kubeuser", now)
+		Expect(err).NotTo(HaveOccurred())
+		Expect(report.Name).To(Equal("quarterly"))
+		Expect(report.GeneratedAt).To(Equal(now))
+		Expect(report.Users).To(HaveLen(3))
+
+		bob, ci, jane := report.Users[0], report.Users[1], report.Users[2]
+		Expect(bob.Name).To(Equal("bob"))
+		Expect(bob.Suspended).To(BeTrue())
+		Expect(bob.Teams).To(Equal([]string{"payments"}))
+		Expect(bob.Bindings).To(BeEmpty())
+		Expect(bob.CredentialExpiry).To(BeNil())
+
+		Expect(ci.Bindings).To(Equal([]Binding{
+			{Kind: "ClusterRoleBinding", Name: "ci-view", RoleKind: "ClusterRole", Role: "view", Managed: true},
+		}))
+		Expect(*ci.CredentialExpiry).To(BeTemporally("==", now.Add(time.Hour)))
+
+		Expect(jane.Email).To(Equal("jane@example.com"))
+		Expect(*jane.CredentialExpiry).To(BeTemporally("==", time.Date(2025, 8, 1, 0, 0, 0, 0, time.UTC)))
+		Expect(*jane.LastActivity).To(BeTemporally("==", time.Date(2025, 5, 30, 12, 0, 0, 0, time.UTC)))
+		Expect(jane.Bindings).To(Equal([]Binding{
+			{Kind: "RoleBinding", Namespace: "dev", Name: "legacy", RoleKind: "Role", Role: "debugger"},
+			{Kind: "RoleBinding", Namespace: "prod", Name: "jane-edit", RoleKind: "ClusterRole", Role: "edit", Managed: true},
+		}))
+	})
+
+	It("only includes users matching the selector", func() {
+		selector := labels.SelectorFromSet(labels.Set{"department": "payments"})
+		report, err := Build(ctx, reader, "payments", selector, "kubeuser", now)
+		Expect(err).NotTo(HaveOccurred())
+		Expect(report.Users).To(HaveLen(2))
+		Expect(report.Users[0].Name).To(Equal("bob"))
+		Expect(report.Users[1].Name).To(Equal("jane"))
+	})
+
+	It("encodes reports as JSON and CSV", func() {
+		report, err := Build(ctx, reader, "quarterly", labels.Everything(), "kubeuser", now)
+		Expect(err).NotTo(HaveOccurred())
+
+		data, err := Encode(report, authv1alpha1.AccessReportJSON)
+		Expect(err).NotTo(HaveOccurred())
+		var decoded Review
+		Expect(json.Unmarshal(data, &decoded)).To(Succeed())
+		Expect(decoded.Users).To(HaveLen(3))
+		Expect(decoded.Users[0].Bindings).NotTo(BeNil())
+
+		data, err = Encode(report, authv1alpha1.AccessReportCSV)
+		Expect(err).NotTo(HaveOccurred())
+		rows := strings.Split(strings.TrimSpace(string(data)), "\n")
+		Expect(rows).To(Equal([]string{
+			"user,email,phase,credential_expiry,last_activity,suspended,revoked,teams,binding_kind,namespace,binding,role_kind,role,managed",
+			"bob,,Suspended,,,true,false,payments,,,,,,",
+			"ci,,Active,2025-06-01T07:00:00Z,,false,false,,ClusterRoleBinding,,ci-view,ClusterRole,view,true",
+			"jane,jane@example.com,Active,2025-08-01T00:00:00Z,2025-05-30T12:00:00Z,false,false,payments,RoleBinding,dev,legacy,Role,debugger,false",
+			"jane,jane@example.com,Active,2025-08-01T00:00:00Z,2025-05-30T12:00:00Z,false,false,payments,RoleBinding,prod,jane-edit,ClusterRole,edit,true",
+		}))
+
+		Expect(FileName(authv1alpha1.AccessReportCSV)).To(Equal("report.csv"))
+		_, err = Encode(report, "XML")
+		Expect(err).To(HaveOccurred())
+	})
+})
@@ -0,0 +1,357 @@
+/*
+Copyright 2025.
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+*/
+
+// Package adminapi serves the admin API: creating, listing, renewing and revoking users and
+// reading their kubeconfigs over HTTPS. Callers authenticate with a Kubernetes token, checked
+// with a TokenReview, and every call is authorized with a SubjectAccessReview. Writes are made
+// impersonating the caller, so the admission webhooks judge the caller's grants as they would
+// for kubectl.
+package adminapi
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"strings"
+
+	authenticationv1 "k8s.io/api/authentication/v1"
+	authorizationv1 "k8s.io/api/authorization/v1"
+	corev1 "k8s.io/api/core/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/client-go/rest"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	logf "sigs.k8s.io/controller-runtime/pkg/log"
+
+	authv1alpha1 "github.com/openkube-hub/KubeUser/api/v1alpha1"
+	"github.com/openkube-hub/KubeUser/internal/credentials"
+	"github.com/openkube-hub/KubeUser/internal/naming"
+	"github.com/openkube-hub/KubeUser/internal/pgp"
+	"github.com/openkube-hub/KubeUser/internal/team"
+	"github.com/openkube-hub/KubeUser/pkg/adminclient"
+)
+
+// KubeconfigSubresource is the subresource of users a caller needs get on to read kubeconfigs.
+// It exists only for authorization; RBAC rules can name it like any other subresource.
+const KubeconfigSubresource = "kubeconfig"
+
+// API serves the admin API under /api/v1/
+type API struct {
+	// Client creates TokenReviews and SubjectAccessReviews and reads Users and credential Secrets
+	Client client.Client
+	// Impersonate returns a client acting as the caller, for writes
+	Impersonate func(user authenticationv1.UserInfo) (client.Client, error)
+	// Namespace returns the KubeUser namespace
+	Namespace func() string
+
+	mux *http.ServeMux
+}
+
+// New returns the admin API
+func New(c client.Client, impersonate func(authenticationv1.UserInfo) (client.Client, error),
+	namespace func() string) *API {
+	a := &API{Client: c, Impersonate: impersonate, Namespace: namespace}
+	a.mux = http.NewServeMux()
+	a.mux.HandleFunc("GET /api/v1/openapi.yaml", a.openAPI)
+	a.mux.HandleFunc("GET /api/v1/users", a.authorized("list", "", a.listUsers))
+	a.mux.HandleFunc("POST /api/v1/users", a.authorized("create", "", a.createUser))
+	a.mux.HandleFunc("GET /api/v1/users/{name}", a.authorized("get", "", a.getUser))
+	a.mux.HandleFunc("GET /api/v1/users/{name}/kubeconfig", a.authorized("get", KubeconfigSubresource, a.kubeconfig))
+	a.mux.HandleFunc("POST /api/v1/users/{name}/renew", a.authorized("patch", "", a.renew))
+	a.mux.HandleFunc("POST /api/v1/users/{name}/revoke", a.authorized("patch", "", a.revoke))
+	a.mux.HandleFunc("POST /api/v1/users/{name}/suspend", a.authorized("patch", "", a.suspend))
+	a.mux.HandleFunc("POST /api/v1/users/{name}/resume", a.authorized("patch", "", a.resume))
+	return a
+}
+
+// ServeHTTP implements http.Handler
+func (a *API) ServeHTTP(w http.ResponseWriter, req *http.Request) {
+	w.Header().Set("Cache-Control", "no-store")
+	w.Header().Set("X-Content-Type-Options", "nosniff")
+	a.mux.ServeHTTP(w, req)
+}
+
+// callerKey is the context key of the authenticated caller
+type callerKey struct{}
+
+func callerFrom(ctx context.Context) authenticationv1.UserInfo {
+	caller, _ := ctx.Value(callerKey{}).(authenticationv1.UserInfo)
+	return caller
+}
+
+// authorized authenticates the caller and checks it may verb the users resource, or its
+// subresource, before calling next
+func (a *API) authorized(verb, subresource string, next http.HandlerFunc) http.HandlerFunc {
+	return func(w http.ResponseWriter, req *http.Request) {
+		ctx := req.Context()
+		caller, err := a.authenticate(ctx, req)
+		if err != nil {
+			writeError(ctx, w, err)
+			return
+		}
+		attributes := &authorizationv1.ResourceAttributes{
+			Verb:        verb,
+			Group:       authv1alpha1.GroupVersion.Group,
+			Version:     authv1alpha1.GroupVersion.Version,
+			Resource:    "users",
+			Subresource: subresource,
+			Name:        req.PathValue("name"),
+		}
+		if err := a.authorize(ctx, caller, attributes); err != nil {
+			writeError(ctx, w, err)
+			return
+		}
+		logf.FromContext(ctx).Info("Admin API call", "caller", caller.Username, "method", req.Method, "path", req.URL.Path)
+		next(w, req.WithContext(context.WithValue(ctx, callerKey{}, caller)))
+	}
+}
+
+// authenticate reviews the bearer token of req
+func (a *API) authenticate(ctx context.Context, req *http.Request) (authenticationv1.UserInfo, error) {
+	token, ok := strings.CutPrefix(req.Header.Get("Authorization"), "Bearer ")
+	if !ok || token == "" {
+		return authenticationv1.UserInfo{}, apierrors.NewUnauthorized("a bearer token is required")
+	}
+	review := &authenticationv1.TokenReview{Spec: authenticationv1.TokenReviewSpec{Token: token}}
+	if err := a.Client.Create(ctx, review); err != nil {
+		return authenticationv1.UserInfo{}, fmt.Errorf("failed to review token: %w", err)
+	}
+	if !review.Status.Authenticated {
+		return authenticationv1.UserInfo{}, apierrors.NewUnauthorized("the token is not valid")
+	}
+	return review.Status.User, nil
+}
+
+// authorize checks caller may perform attributes
+func (a *API) authorize(ctx context.Context, caller authenticationv1.UserInfo,
+	attributes *authorizationv1.ResourceAttributes) error {
+	extra := make(map[string]authorizationv1.ExtraValue, len(caller.Extra))
+	for key, values := range caller.Extra {
+		extra[key] = authorizationv1.ExtraValue(values)
+	}
+	review := &authorizationv1.SubjectAccessReview{Spec: authorizationv1.SubjectAccessReviewSpec{
+		User:               caller.Username,
+		UID:                caller.UID,
+		Groups:             caller.Groups,
+		Extra:              extra,
+		ResourceAttributes: attributes,
+	}}
+	if err := a.Client.Create(ctx, review); err != nil {
+		return fmt.Errorf("failed to review access: %w", err)
+	}
+	if !review.Status.Allowed {
+		resource := attributes.Resource
+		if attributes.Subresource != "" {
+			resource += "/" + attributes.Subresource
+		}
+		return apierrors.NewForbidden(authv1alpha1.GroupVersion.WithResource(resource).GroupResource(), attributes.Name,
+			fmt.Errorf("user %q may not %s it", caller.Username, attributes.Verb))
+	}
+	return nil
+}
+
+func (a *API) openAPI(w http.ResponseWriter, _ *http.Request) {
+	w.Header().Set("Content-Type", "application/yaml")
+	_, _ = w.Write(adminclient.OpenAPI)
+}
+
+func (a *API) listUsers(w http.ResponseWriter, req *http.Request) {
+	var users authv1alpha1.UserList
+	if err := a.Client.List(req.Context(), &users); err != nil {
+		writeError(req.Context(), w, err)
+		return
+	}
+	var teams authv1alpha1.TeamList
+	if err := a.Client.List(req.Context(), &teams); err != nil {
+		writeError(req.Context(), w, err)
+		return
+	}
+	list := adminclient.UserList{Items: make([]adminclient.User, 0, len(users.Items))}
+	for i := range users.Items {
+		list.Items = append(list.Items, toAPIUser(&users.Items[i], teams.Items))
+	}
+	writeJSON(w, http.StatusOK, list)
+}
+
+func (a *API) getUser(w http.ResponseWriter, req *http.Request) {
+	var user authv1alpha1.User
+	if err := a.Client.Get(req.Context(), types.NamespacedName{Name: req.PathValue("name")}, &user); err != nil {
+		writeError(req.Context(), w, err)
+		return
+	}
+	var teams authv1alpha1.TeamList
+	if err := a.Client.List(req.Context(), &teams); err != nil {
+		writeError(req.Context(), w, err)
+		return
+	}
+	writeJSON(w, http.StatusOK, toAPIUser(&user, teams.Items))
+}
+
+func (a *API) createUser(w http.ResponseWriter, req *http.Request) {
+	ctx := req.Context()
+	var body adminclient.User
+	decoder := json.NewDecoder(req.Body)
+	decoder.DisallowUnknownFields()
+	if err := decoder.Decode(&body); err != nil {
+		writeError(ctx, w, apierrors.NewBadRequest(fmt.Sprintf("invalid user: %v", err)))
+		return
+	}
+	if body.Name == "" {
+		writeError(ctx, w, apierrors.NewBadRequest("name is required"))
+		return
+	}
+	c, err := a.Impersonate(callerFrom(ctx))
+	if err != nil {
+		writeError(ctx, w, err)
+		return
+	}
+	user := &authv1alpha1.User{
+		ObjectMeta: metav1.ObjectMeta{Name: body.Name, Labels: body.Labels},
+		Spec:       body.Spec,
+	}
+	if err := c.Create(ctx, user); err != nil {
+		writeError(ctx, w, err)
+		return
+	}
+	writeJSON(w, http.StatusCreated, toAPIUser(user, nil))
+}
+
+func (a *API) kubeconfig(w http.ResponseWriter, req *http.Request) {
+	ctx := req.Context()
+	var user authv1alpha1.User
+	if err := a.Client.Get(ctx, types.NamespacedName{Name: req.PathValue("name")}, &user); err != nil {
+		writeError(ctx, w, err)
+		return
+	}
+	key := types.NamespacedName{Name: naming.Suffixed(naming.MaxNameLength, user.Name, "kubeconfig"), Namespace: a.Namespace()}
+	if ref := user.Status.CredentialSecret; ref != nil && ref.Name != "" {
+		key = types.NamespacedName{Name: ref.Name, Namespace: ref.Namespace}
+	}
+	notIssued := apierrors.NewConflict(authv1alpha1.GroupVersion.WithResource("users").GroupResource(), user.Name,
+		errors.New("the kubeconfig is not issued yet"))
+	var secret corev1.Secret
+	if err := a.Client.Get(ctx, key, &secret); err != nil {
+		if apierrors.IsNotFound(err) {
+			err = notIssued
+		}
+		writeError(ctx, w, err)
+		return
+	}
+	if secret.Labels["auth.openkube.io/user"] != user.Name {
+		writeError(ctx, w, fmt.Errorf("credential secret %s is not managed by KubeUser for user %s", key, user.Name))
+		return
+	}
+	data, err := credentials.FindKubeconfig(user.Spec.Output, secret.Data)
+	if err != nil {
+		writeError(ctx, w, notIssued)
+		return
+	}
+	contentType := "application/yaml"
+	if pgp.IsMessage(data) {
+		contentType = "application/pgp-encrypted"
+	}
+	w.Header().Set("Content-Type", contentType)
+	_, _ = w.Write(data)
+}
+
+func (a *API) renew(w http.ResponseWriter, req *http.Request) {
+	ctx := req.Context()
+	var body adminclient.RenewRequest
+	if req.ContentLength != 0 {
+		if err := json.NewDecoder(req.Body).Decode(&body); err != nil {
+			writeError(ctx, w, apierrors.NewBadRequest(fmt.Sprintf("invalid renew request: %v", err)))
+			return
+		}
+	}
+	value := "true"
+	if body.NewKey {
+		value = authv1alpha1.RenewNewKey
+	}
+	a.patch(w, req, fmt.Sprintf(`{"metadata":{"annotations":{%q:%q}}}`, authv1alpha1.RenewAnnotation, value))
+}
+
+func (a *API) revoke(w http.ResponseWriter, req *http.Request) {
+	a.patch(w, req, `{"spec":{"revoked":true}}`)
+}
+
+func (a *API) suspend(w http.ResponseWriter, req *http.Request) {
+	a.patch(w, req, `{"spec":{"suspended":true}}`)
+}
+
+func (a *API) resume(w http.ResponseWriter, req *http.Request) {
+	a.patch(w, req, `{"spec":{"suspended":false}}`)
+}
+
+// patch merge-patches the User named in req as the caller
+func (a *API) patch(w http.ResponseWriter, req *http.Request, patch string) {
+	ctx := req.Context()
+	c, err := a.Impersonate(callerFrom(ctx))
+	if err != nil {
+		writeError(ctx, w, err)
+		return
+	}
+	user := &authv1alpha1.User{ObjectMeta: metav1.ObjectMeta{Name: req.PathValue("name")}}
+	if err := c.Patch(ctx, user, client.RawPatch(types.MergePatchType, []byte(patch))); err != nil {
+		writeError(ctx, w, err)
+		return
+	}
+	w.WriteHeader(http.StatusAccepted)
+}
+
+// Impersonating returns a function that builds clients acting as a caller from config, the
+// manager's. The caller's groups are impersonated too, so RBAC sees the caller's full
+// permissions; extra fields are not.
+func Impersonating(config *rest.Config, options client.Options) func(authenticationv1.UserInfo) (client.Client, error) {
+	return func(user authenticationv1.UserInfo) (client.Client, error) {
+		impersonated := rest.CopyConfig(config)
+		impersonated.Impersonate = rest.ImpersonationConfig{UserName: user.Username, Groups: user.Groups}
+		return client.New(impersonated, options)
+	}
+}
+
+// toAPIUser converts user, naming the teams it is a member of
+func toAPIUser(user *authv1alpha1.User, teams []authv1alpha1.Team) adminclient.User {
+	apiUser := adminclient.User{
+		Name:   user.Name,
+		Labels: user.Labels,
+		Spec:   user.Spec,
+		Status: adminclient.UserStatus{
+			Phase:             user.Status.Phase,
+			Message:           user.Status.Message,
+			ExpiryTime:        user.Status.ExpiryTime,
+			CertificateExpiry: user.Status.CertificateExpiry,
+			Suspended:         user.Spec.Suspended,
+			Revoked:           user.Spec.Revoked,
+			TimedGrants:       user.Status.TimedGrants,
+		},
+	}
+	for i := range teams {
+		if team.IsMember(&teams[i], user.Name) {
+			apiUser.Status.Teams = append(apiUser.Status.Teams, teams[i].Name)
+		}
+	}
+	return apiUser
+}
+
+func writeJSON(w http.ResponseWriter, status int, body any) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	_ = json.NewEncoder(w).Encode(body)
+}
+
+// writeError answers with the status of Kubernetes API errors, and 500 for others
+func writeError(ctx context.Context, w http.ResponseWriter, err error) {
+	var status apierrors.APIStatus
+	if errors.As(err, &status) && status.Status().Code != 0 {
+		writeJSON(w, int(status.Status().Code), adminclient.Error{Code: int(status.Status().Code), Message: status.Status().Message})
+		return
+	}
+	logf.FromContext(ctx).Error(err, "Admin API call failed")
+	writeJSON(w, http.StatusInternalServerError, adminclient.Error{Code: http.StatusInternalServerError, Message: err.Error()})
+}
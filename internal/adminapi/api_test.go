@@ -0,0 +1,207 @@
+/*
+Copyright 2025.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package adminapi
+
+import (
+	"context"
+	"net/http/httptest"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+	authenticationv1 "k8s.io/api/authentication/v1"
+	authorizationv1 "k8s.io/api/authorization/v1"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	clientgoscheme "k8s.io/client-go/kubernetes/scheme"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+	"sigs.k8s.io/controller-runtime/pkg/client/interceptor"
+
+	authv1alpha1 "github.com/openkube-hub/KubeUser/api/v1alpha1"
+	"github.com/openkube-hub/KubeUser/pkg/adminclient"
+)
+
+const kubeconfig = `apiVersion: v1
+kind: Config
+clusters:
+- name: kubernetes
+  cluster:
+    server: https://api.example.com:6443
+contexts:
+- name: jane@kubernetes
+  context:
+    cluster: kubernetes
+    user: jane
+current-context: jane@kubernetes
+users:
+- name: jane
+  user:
+    token: secret
+`
+
+var _ = Describe("Admin API", func() {
+	var (
+		c client.Client
+		// allowed are the verbs, with subresource, the caller holds on users
+		allowed       map[string]bool
+		impersonated  []authenticationv1.UserInfo
+		portal        *adminclient.Client
+		reviewedToken string
+	)
+
+	BeforeEach(func() {
+		scheme := runtime.NewScheme()
+		Expect(clientgoscheme.AddToScheme(scheme)).To(Succeed())
+		Expect(authv1alpha1.AddToScheme(scheme)).To(Succeed())
+		allowed = map[string]bool{}
+		impersonated = nil
+
+		c = fake.NewClientBuilder().WithScheme(scheme).WithObjects(
+			&authv1alpha1.User{
+				ObjectMeta: metav1.ObjectMeta{Name: "jane"},
+				Spec:       authv1alpha1.UserSpec{ClusterRoles: []authv1alpha1.ClusterRoleSpec{{ExistingClusterRole: "view"}}},
+				Status:     authv1alpha1.UserStatus{Phase: "Active", ExpiryTime: "2026-01-01T00:00:00Z"},
+			},
+			&authv1alpha1.Team{
+				ObjectMeta: metav1.ObjectMeta{Name: "payments"},
+				Spec:       authv1alpha1.TeamSpec{Members: []authv1alpha1.TeamMember{{Name: "jane"}}},
+			},
+			&corev1.Secret{
+				ObjectMeta: metav1.ObjectMeta{
+					Name:      "jane-kubeconfig",
+					Namespace: "kubeuser",
+					Labels:    map[string]string{"auth.openkube.io/user": "jane"},
+				},
+				Data: map[string][]byte{"config": []byte(kubeconfig)},
+			},
+		).WithInterceptorFuncs(interceptor.Funcs{
+			Create: func(ctx context.Context, c client.WithWatch, obj client.Object, opts ...client.CreateOption) error {
+				switch review := obj.(type) {
+				case *authenticationv1.TokenReview:
+					reviewedToken = review.Spec.Token
+					if review.Spec.Token == "portal-token" {
+						review.Status = authenticationv1.TokenReviewStatus{Authenticated: true, User: authenticationv1.UserInfo{
+							Username: "system:serviceaccount:portal:backend",
+							Groups:   []string{"system:serviceaccounts"},
+						}}
+					}
+					return nil
+				case *authorizationv1.SubjectAccessReview:
+					attributes := review.Spec.ResourceAttributes
+					Expect(review.Spec.User).To(Equal("system:serviceaccount:portal:backend"))
+					Expect(attributes.Group).To(Equal("auth.openkube.io"))
+					Expect(attributes.Resource).To(Equal("users"))
+					review.Status.Allowed = allowed[attributes.Verb+"/"+attributes.Subresource]
+					return nil
+				}
+				return c.Create(ctx, obj, opts...)
+			},
+		}).Build()
+
+		impersonate := func(user authenticationv1.UserInfo) (client.Client, error) {
+			impersonated = append(impersonated, user)
+			return c, nil
+		}
+		server := httptest.NewServer(New(c, impersonate, func() string { return "kubeuser" }))
+		DeferCleanup(server.Close)
+		portal = adminclient.New(server.URL, "portal-token")
+	})
+
+	It("rejects invalid tokens", func() {
+		portal.Token = "stolen"
+		_, err := portal.ListUsers(context.Background())
+		Expect(err).To(MatchError(ContainSubstring("the token is not valid")))
+		Expect(reviewedToken).To(Equal("stolen"))
+	})
+
+	It("authorizes every call", func() {
+		_, err := portal.GetUser(context.Background(), "jane")
+		Expect(adminclient.IsForbidden(err)).To(BeTrue())
+		Expect(err).To(MatchError(ContainSubstring(`user "system:serviceaccount:portal:backend" may not get it`)))
+
+		allowed["get/"] = true
+		_, err = portal.Kubeconfig(context.Background(), "jane")
+		Expect(adminclient.IsForbidden(err)).To(BeTrue(), "kubeconfigs need their own permission")
+	})
+
+	It("lists and gets users", func() {
+		allowed["list/"] = true
+		allowed["get/"] = true
+		users, err := portal.ListUsers(context.Background())
+		Expect(err).NotTo(HaveOccurred())
+		Expect(users).To(HaveLen(1))
+		Expect(users[0].Status.Phase).To(Equal("Active"))
+		Expect(users[0].Status.Teams).To(ConsistOf("payments"))
+
+		user, err := portal.GetUser(context.Background(), "jane")
+		Expect(err).NotTo(HaveOccurred())
+		Expect(user.Spec.ClusterRoles[0].ExistingClusterRole).To(Equal("view"))
+
+		_, err = portal.GetUser(context.Background(), "john")
+		Expect(adminclient.IsNotFound(err)).To(BeTrue())
+	})
+
+	It("returns kubeconfigs", func() {
+		allowed["get/kubeconfig"] = true
+		data, err := portal.Kubeconfig(context.Background(), "jane")
+		Expect(err).NotTo(HaveOccurred())
+		Expect(string(data)).To(Equal(kubeconfig))
+	})
+
+	It("creates users as the caller", func() {
+		allowed["create/"] = true
+		created, err := portal.CreateUser(context.Background(), &adminclient.User{
+			Name: "john",
+			Spec: authv1alpha1.UserSpec{Roles: []authv1alpha1.RoleSpec{{Namespace: "dev", ExistingRole: "developer"}}},
+		})
+		Expect(err).NotTo(HaveOccurred())
+		Expect(created.Name).To(Equal("john"))
+		Expect(impersonated).To(HaveLen(1))
+		Expect(impersonated[0].Username).To(Equal("system:serviceaccount:portal:backend"))
+		Expect(impersonated[0].Groups).To(ConsistOf("system:serviceaccounts"))
+
+		var user authv1alpha1.User
+		Expect(c.Get(context.Background(), client.ObjectKey{Name: "john"}, &user)).To(Succeed())
+		Expect(user.Spec.Roles[0].ExistingRole).To(Equal("developer"))
+	})
+
+	It("suspends and resumes users as the caller", func() {
+		allowed["patch/"] = true
+		Expect(portal.Suspend(context.Background(), "jane")).To(Succeed())
+		var user authv1alpha1.User
+		Expect(c.Get(context.Background(), client.ObjectKey{Name: "jane"}, &user)).To(Succeed())
+		Expect(user.Spec.Suspended).To(BeTrue())
+
+		Expect(portal.Resume(context.Background(), "jane")).To(Succeed())
+		Expect(c.Get(context.Background(), client.ObjectKey{Name: "jane"}, &user)).To(Succeed())
+		Expect(user.Spec.Suspended).To(BeFalse())
+		Expect(impersonated).To(HaveLen(2))
+	})
+
+	It("renews and revokes users as the caller", func() {
+		allowed["patch/"] = true
+		Expect(portal.Renew(context.Background(), "jane", adminclient.RenewRequest{NewKey: true})).To(Succeed())
+		Expect(portal.Revoke(context.Background(), "jane")).To(Succeed())
+		Expect(impersonated).To(HaveLen(2))
+
+		var user authv1alpha1.User
+		Expect(c.Get(context.Background(), client.ObjectKey{Name: "jane"}, &user)).To(Succeed())
+		Expect(user.Annotations).To(HaveKeyWithValue(authv1alpha1.RenewAnnotation, authv1alpha1.RenewNewKey))
+		Expect(user.Spec.Revoked).To(BeTrue())
+	})
+})
@@ -0,0 +1,243 @@
+/*
+Copyright 2025.
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+*/
+
+// Package aws signs requests to AWS APIs and finds the controller's AWS credentials. It is shared
+// by the Secrets Manager credential store and the EKS access entry bridge, so KubeUser does not
+// depend on the AWS SDK.
+package aws
+
+import (
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/xml"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"os"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+)
+
+// ErrNoCredentials is returned when the controller's environment holds no AWS credentials
+var ErrNoCredentials = errors.New("no AWS credentials")
+
+// Credentials sign requests to AWS
+type Credentials struct {
+	AccessKeyID     string
+	SecretAccessKey string
+	SessionToken    string
+	// Expires is when temporary credentials have to be renewed; zero for static ones
+	Expires time.Time
+}
+
+// CredentialSource returns credentials, renewing temporary ones before they expire
+type CredentialSource func(ctx context.Context) (Credentials, error)
+
+// StaticCredentials returns a source that always returns credentials
+func StaticCredentials(credentials Credentials) CredentialSource {
+	return func(context.Context) (Credentials, error) { return credentials, nil }
+}
+
+// EnvironmentCredentials returns the credentials of the controller's environment: static keys
+// or an IAM role for service accounts (a web identity token), which is exchanged through STS in
+// region
+func EnvironmentCredentials(region string, httpClient *http.Client) (CredentialSource, error) {
+	static := Credentials{
+		AccessKeyID:     os.Getenv("AWS_ACCESS_KEY_ID"),
+		SecretAccessKey: os.Getenv("AWS_SECRET_ACCESS_KEY"),
+		SessionToken:    os.Getenv("AWS_SESSION_TOKEN"),
+	}
+	roleARN, tokenFile := os.Getenv("AWS_ROLE_ARN"), os.Getenv("AWS_WEB_IDENTITY_TOKEN_FILE")
+	if static.AccessKeyID == "" && roleARN != "" && tokenFile != "" {
+		identity := &webIdentity{
+			roleARN:   roleARN,
+			tokenFile: tokenFile,
+			endpoint:  fmt.Sprintf("https://sts.%s.amazonaws.com/", region),
+			http:      httpClient,
+		}
+		return identity.credentials, nil
+	}
+	if static.AccessKeyID == "" || static.SecretAccessKey == "" {
+		return nil, ErrNoCredentials
+	}
+	return StaticCredentials(static), nil
+}
+
+// webIdentity exchanges the projected ServiceAccount token for temporary credentials of an
+// IAM role through STS AssumeRoleWithWebIdentity, which needs no signature
+type webIdentity struct {
+	roleARN   string
+	tokenFile string
+	endpoint  string
+	http      *http.Client
+
+	mu     sync.Mutex
+	cached Credentials
+}
+
+func (w *webIdentity) credentials(ctx context.Context) (Credentials, error) {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	if w.cached.AccessKeyID != "" && time.Now().Add(5*time.Minute).Before(w.cached.Expires) {
+		return w.cached, nil
+	}
+	token, err := os.ReadFile(w.tokenFile)
+	if err != nil {
+		return Credentials{}, fmt.Errorf("failed to read web identity token: %w", err)
+	}
+	query := url.Values{
+		"Action":           {"AssumeRoleWithWebIdentity"},
+		"Version":          {"2011-06-15"},
+		"RoleArn":          {w.roleARN},
+		"RoleSessionName":  {"kubeuser"},
+		"WebIdentityToken": {strings.TrimSpace(string(token))},
+	}
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, w.endpoint,
+		strings.NewReader(query.Encode()))
+	if err != nil {
+		return Credentials{}, err
+	}
+	req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+	resp, err := w.http.Do(req)
+	if err != nil {
+		return Credentials{}, fmt.Errorf("assuming role %s: %w", w.roleARN, err)
+	}
+	defer func() { _ = resp.Body.Close() }()
+	body, err := io.ReadAll(io.LimitReader(resp.Body, 1<<20))
+	if err != nil {
+		return Credentials{}, err
+	}
+	if resp.StatusCode != http.StatusOK {
+		return Credentials{}, fmt.Errorf("assuming role %s: STS returned %d: %s", w.roleARN, resp.StatusCode,
+			strings.TrimSpace(string(body)))
+	}
+	var result struct {
+		Credentials struct {
+			AccessKeyID     string    `xml:"AccessKeyId"`
+			SecretAccessKey string    `xml:"SecretAccessKey"`
+			SessionToken    string    `xml:"SessionToken"`
+			Expiration      time.Time `xml:"Expiration"`
+		} `xml:"AssumeRoleWithWebIdentityResult>Credentials"`
+	}
+	if err := xml.Unmarshal(body, &result); err != nil {
+		return Credentials{}, fmt.Errorf("assuming role %s: %w", w.roleARN, err)
+	}
+	w.cached = Credentials{
+		AccessKeyID:     result.Credentials.AccessKeyID,
+		SecretAccessKey: result.Credentials.SecretAccessKey,
+		SessionToken:    result.Credentials.SessionToken,
+		Expires:         result.Credentials.Expiration,
+	}
+	return w.cached, nil
+}
+
+// Error is an error response of an AWS API
+type Error struct {
+	Status  int
+	Type    string
+	Message string
+}
+
+func (e *Error) Error() string {
+	return fmt.Sprintf("AWS returned %d %s: %s", e.Status, e.Type, e.Message)
+}
+
+// IsError reports whether err is an AWS error response of errorType, e.g. ResourceNotFoundException
+func IsError(err error, errorType string) bool {
+	var awsErr *Error
+	return errors.As(err, &awsErr) && awsErr.Type == errorType
+}
+
+// SignV4 signs req with AWS Signature Version 4, covering the host and every header set on it
+func SignV4(req *http.Request, body []byte, credentials Credentials, region, service string, now time.Time) {
+	amzDate := now.UTC().Format("20060102T150405Z")
+	date := amzDate[:8]
+	req.Header.Set("X-Amz-Date", amzDate)
+	if credentials.SessionToken != "" {
+		req.Header.Set("X-Amz-Security-Token", credentials.SessionToken)
+	}
+
+	headers := map[string]string{"host": req.URL.Host}
+	for name, values := range req.Header {
+		headers[strings.ToLower(name)] = strings.TrimSpace(strings.Join(values, ","))
+	}
+	names := make([]string, 0, len(headers))
+	for name := range headers {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	var canonicalHeaders strings.Builder
+	for _, name := range names {
+		canonicalHeaders.WriteString(name + ":" + headers[name] + "\n")
+	}
+	signedHeaders := strings.Join(names, ";")
+
+	canonicalRequest := strings.Join([]string{
+		req.Method,
+		canonicalPath(req.URL),
+		canonicalQuery(req.URL.Query()),
+		canonicalHeaders.String(),
+		signedHeaders,
+		sha256Hex(body),
+	}, "\n")
+
+	scope := strings.Join([]string{date, region, service, "aws4_request"}, "/")
+	stringToSign := strings.Join([]string{"AWS4-HMAC-SHA256", amzDate, scope, sha256Hex([]byte(canonicalRequest))}, "\n")
+	key := hmacSHA256([]byte("AWS4"+credentials.SecretAccessKey), date)
+	for _, part := range []string{region, service, "aws4_request"} {
+		key = hmacSHA256(key, part)
+	}
+	signature := hex.EncodeToString(hmacSHA256(key, stringToSign))
+	req.Header.Set("Authorization", fmt.Sprintf("AWS4-HMAC-SHA256 Credential=%s/%s, SignedHeaders=%s, Signature=%s",
+		credentials.AccessKeyID, scope, signedHeaders, signature))
+}
+
+// canonicalPath encodes the escaped path once more, as SigV4 expects for all services but S3
+func canonicalPath(u *url.URL) string {
+	path := u.EscapedPath()
+	if path == "" {
+		return "/"
+	}
+	segments := strings.Split(path, "/")
+	for i, segment := range segments {
+		segments[i] = Escape(segment)
+	}
+	return strings.Join(segments, "/")
+}
+
+// canonicalQuery sorts and encodes query parameters the way SigV4 expects
+func canonicalQuery(query url.Values) string {
+	pairs := make([]string, 0, len(query))
+	for key, values := range query {
+		for _, value := range values {
+			pairs = append(pairs, Escape(key)+"="+Escape(value))
+		}
+	}
+	sort.Strings(pairs)
+	return strings.Join(pairs, "&")
+}
+
+// Escape percent-encodes s, a path segment or query parameter, the way AWS APIs expect
+func Escape(s string) string {
+	return strings.ReplaceAll(url.QueryEscape(s), "+", "%20")
+}
+
+func sha256Hex(data []byte) string {
+	sum := sha256.Sum256(data)
+	return hex.EncodeToString(sum[:])
+}
+
+func hmacSHA256(key []byte, data string) []byte {
+	mac := hmac.New(sha256.New, key)
+	mac.Write([]byte(data))
+	return mac.Sum(nil)
+}
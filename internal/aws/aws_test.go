@@ -0,0 +1,142 @@
+/*
+Copyright 2025.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package aws
+
+import (
+	"context"
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"time"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("SignV4", func() {
+	credentials := Credentials{AccessKeyID: "AKIDEXAMPLE", SecretAccessKey: "wJalrXUtnFEMI/K7MDENG+bPxRfiCYEXAMPLEKEY"}
+	now := time.Date(2015, 8, 30, 12, 36, 0, 0, time.UTC)
+
+	It("signs requests", func() {
+		req, err := http.NewRequest(http.MethodGet, "https://example.amazonaws.com/", nil)
+		Expect(err).NotTo(HaveOccurred())
+		SignV4(req, nil, credentials, "us-east-1", "service", now)
+		Expect(req.Header.Get("Authorization")).To(Equal("AWS4-HMAC-SHA256 " +
+			"Credential=AKIDEXAMPLE/20150830/us-east-1/service/aws4_request, SignedHeaders=host;x-amz-date, " +
+			"Signature=5fa00fa31553b73ebf1942676e86291e8372ff2a2260956d9b8aae1d763fbf31"))
+	})
+
+	It("encodes escaped paths once more", func() {
+		req, err := http.NewRequest(http.MethodGet, "https://example.amazonaws.com/arn%3Aaws%2Frole", nil)
+		Expect(err).NotTo(HaveOccurred())
+		Expect(canonicalPath(req.URL)).To(Equal("/arn%253Aaws%252Frole"))
+	})
+})
+
+var _ = Describe("EKS", func() {
+	type request struct {
+		Method string
+		Path   string
+		Query  string
+		Body   string
+		Header http.Header
+	}
+	var (
+		requests []request
+		respond  func(w http.ResponseWriter, r request)
+		eks      *EKS
+	)
+
+	BeforeEach(func() {
+		requests, respond = nil, nil
+		server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			body, _ := io.ReadAll(r.Body)
+			req := request{Method: r.Method, Path: r.URL.EscapedPath(), Query: r.URL.RawQuery, Body: string(body), Header: r.Header}
+			requests = append(requests, req)
+			if respond != nil {
+				respond(w, req)
+				return
+			}
+			_, _ = io.WriteString(w, `{}`)
+		}))
+		DeferCleanup(server.Close)
+		eks = NewEKS("eu-west-1", "prod", StaticCredentials(Credentials{AccessKeyID: "AKIDEXAMPLE", SecretAccessKey: "secret"}),
+			server.Client())
+		eks.endpoint = server.URL
+	})
+
+	It("creates signed STANDARD access entries", func() {
+		Expect(eks.CreateAccessEntry(context.Background(), AccessEntry{
+			PrincipalARN: "arn:aws:iam::111122223333:role/jane",
+			Username:     "jane",
+			Tags:         map[string]string{"app.kubernetes.io/managed-by": "kubeuser"},
+		})).To(Succeed())
+		Expect(requests).To(HaveLen(1))
+		Expect(requests[0].Method).To(Equal(http.MethodPost))
+		Expect(requests[0].Path).To(Equal("/clusters/prod/access-entries"))
+		Expect(requests[0].Header.Get("Authorization")).To(ContainSubstring("/eu-west-1/eks/aws4_request"))
+		Expect(requests[0].Body).To(MatchJSON(`{"principalArn":"arn:aws:iam::111122223333:role/jane","username":"jane",
+			"type":"STANDARD","tags":{"app.kubernetes.io/managed-by":"kubeuser"}}`))
+	})
+
+	It("escapes principal ARNs in paths", func() {
+		_, err := eks.DescribeAccessEntry(context.Background(), "arn:aws:iam::111122223333:role/jane")
+		Expect(err).NotTo(HaveOccurred())
+		Expect(requests[0].Path).To(Equal("/clusters/prod/access-entries/arn%3Aaws%3Aiam%3A%3A111122223333%3Arole%2Fjane"))
+	})
+
+	It("reports the error type of REST responses", func() {
+		respond = func(w http.ResponseWriter, _ request) {
+			w.Header().Set("X-Amzn-Errortype", "ResourceNotFoundException:http://internal.amazon.com/coral/com.amazonaws.eks/")
+			w.WriteHeader(http.StatusNotFound)
+			_, _ = io.WriteString(w, `{"message":"The specified access entry resource is not found."}`)
+		}
+		_, err := eks.DescribeAccessEntry(context.Background(), "arn:aws:iam::111122223333:role/jane")
+		Expect(IsError(err, "ResourceNotFoundException")).To(BeTrue())
+		Expect(err).To(MatchError(ContainSubstring("access entry resource is not found")))
+	})
+
+	It("sends empty groups to clear them", func() {
+		Expect(eks.UpdateAccessEntry(context.Background(), AccessEntry{
+			PrincipalARN: "arn:aws:iam::111122223333:role/jane",
+			Username:     "jane",
+		})).To(Succeed())
+		Expect(requests[0].Body).To(MatchJSON(`{"username":"jane","kubernetesGroups":[]}`))
+	})
+
+	It("follows pages of associated access policies", func() {
+		respond = func(w http.ResponseWriter, r request) {
+			page := map[string]any{"associatedAccessPolicies": []AccessPolicy{{
+				PolicyARN:   "arn:aws:eks::aws:cluster-access-policy/AmazonEKSViewPolicy",
+				AccessScope: AccessScope{Type: AccessScopeNamespace, Namespaces: []string{"team-a"}},
+			}}, "nextToken": "next"}
+			if r.Query != "" {
+				page = map[string]any{"associatedAccessPolicies": []AccessPolicy{{
+					PolicyARN:   "arn:aws:eks::aws:cluster-access-policy/AmazonEKSEditPolicy",
+					AccessScope: AccessScope{Type: AccessScopeCluster},
+				}}}
+			}
+			Expect(json.NewEncoder(w).Encode(page)).To(Succeed())
+		}
+		policies, err := eks.ListAssociatedAccessPolicies(context.Background(), "arn:aws:iam::111122223333:role/jane")
+		Expect(err).NotTo(HaveOccurred())
+		Expect(policies).To(HaveLen(2))
+		Expect(requests[1].Query).To(Equal("nextToken=next"))
+		Expect(policies[1].AccessScope.Type).To(Equal(AccessScopeCluster))
+	})
+})
@@ -0,0 +1,206 @@
+/*
+Copyright 2025.
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+*/
+
+package aws
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"strings"
+	"time"
+)
+
+// Access scope types of EKS access policy associations
+const (
+	AccessScopeCluster   = "cluster"
+	AccessScopeNamespace = "namespace"
+)
+
+// AccessEntry is an EKS access entry, which lets an IAM principal authenticate to the cluster as
+// a Kubernetes user name and groups
+type AccessEntry struct {
+	PrincipalARN     string            `json:"principalArn"`
+	Username         string            `json:"username,omitempty"`
+	KubernetesGroups []string          `json:"kubernetesGroups,omitempty"`
+	Type             string            `json:"type,omitempty"`
+	Tags             map[string]string `json:"tags,omitempty"`
+}
+
+// AccessScope is where an access policy applies: the cluster or the listed namespaces
+type AccessScope struct {
+	Type       string   `json:"type"`
+	Namespaces []string `json:"namespaces,omitempty"`
+}
+
+// AccessPolicy is an EKS access policy associated with an access entry
+type AccessPolicy struct {
+	PolicyARN   string      `json:"policyArn"`
+	AccessScope AccessScope `json:"accessScope"`
+}
+
+// EKS manages the access entries of one EKS cluster through the EKS REST API
+type EKS struct {
+	region      string
+	cluster     string
+	endpoint    string
+	credentials CredentialSource
+	http        *http.Client
+	// now is replaced in tests
+	now func() time.Time
+}
+
+// NewEKS returns a client for the access entries of cluster in region
+func NewEKS(region, cluster string, credentials CredentialSource, httpClient *http.Client) *EKS {
+	return &EKS{
+		region:      region,
+		cluster:     cluster,
+		endpoint:    fmt.Sprintf("https://eks.%s.amazonaws.com", region),
+		credentials: credentials,
+		http:        httpClient,
+		now:         time.Now,
+	}
+}
+
+// Cluster is the name of the EKS cluster
+func (c *EKS) Cluster() string {
+	return c.cluster
+}
+
+// DescribeAccessEntry returns the access entry of principalARN. A missing entry is an Error of
+// type ResourceNotFoundException.
+func (c *EKS) DescribeAccessEntry(ctx context.Context, principalARN string) (*AccessEntry, error) {
+	var output struct {
+		AccessEntry AccessEntry `json:"accessEntry"`
+	}
+	if err := c.call(ctx, http.MethodGet, c.entryPath(principalARN), nil, nil, &output); err != nil {
+		return nil, err
+	}
+	return &output.AccessEntry, nil
+}
+
+// CreateAccessEntry creates a STANDARD access entry
+func (c *EKS) CreateAccessEntry(ctx context.Context, entry AccessEntry) error {
+	entry.Type = "STANDARD"
+	return c.call(ctx, http.MethodPost, c.clusterPath()+"/access-entries", nil, entry, nil)
+}
+
+// UpdateAccessEntry sets the user name and groups of the access entry of entry.PrincipalARN
+func (c *EKS) UpdateAccessEntry(ctx context.Context, entry AccessEntry) error {
+	groups := entry.KubernetesGroups
+	if groups == nil {
+		groups = []string{}
+	}
+	input := map[string]any{"username": entry.Username, "kubernetesGroups": groups}
+	return c.call(ctx, http.MethodPost, c.entryPath(entry.PrincipalARN), nil, input, nil)
+}
+
+// DeleteAccessEntry deletes the access entry of principalARN and its policy associations
+func (c *EKS) DeleteAccessEntry(ctx context.Context, principalARN string) error {
+	return c.call(ctx, http.MethodDelete, c.entryPath(principalARN), nil, nil, nil)
+}
+
+// ListAssociatedAccessPolicies returns the access policies associated with the access entry of
+// principalARN
+func (c *EKS) ListAssociatedAccessPolicies(ctx context.Context, principalARN string) ([]AccessPolicy, error) {
+	var policies []AccessPolicy
+	query := url.Values{}
+	for {
+		var output struct {
+			AssociatedAccessPolicies []AccessPolicy `json:"associatedAccessPolicies"`
+			NextToken                string         `json:"nextToken"`
+		}
+		if err := c.call(ctx, http.MethodGet, c.entryPath(principalARN)+"/access-policies", query, nil, &output); err != nil {
+			return nil, err
+		}
+		policies = append(policies, output.AssociatedAccessPolicies...)
+		if output.NextToken == "" {
+			return policies, nil
+		}
+		query.Set("nextToken", output.NextToken)
+	}
+}
+
+// AssociateAccessPolicy associates policy with the access entry of principalARN, replacing the
+// scope of an existing association
+func (c *EKS) AssociateAccessPolicy(ctx context.Context, principalARN string, policy AccessPolicy) error {
+	return c.call(ctx, http.MethodPost, c.entryPath(principalARN)+"/access-policies", nil, policy, nil)
+}
+
+// DisassociateAccessPolicy removes the access policy policyARN from the access entry of principalARN
+func (c *EKS) DisassociateAccessPolicy(ctx context.Context, principalARN, policyARN string) error {
+	return c.call(ctx, http.MethodDelete, c.entryPath(principalARN)+"/access-policies/"+Escape(policyARN), nil, nil, nil)
+}
+
+func (c *EKS) clusterPath() string {
+	return "/clusters/" + Escape(c.cluster)
+}
+
+func (c *EKS) entryPath(principalARN string) string {
+	return c.clusterPath() + "/access-entries/" + Escape(principalARN)
+}
+
+// call sends a SigV4 signed request to the EKS REST API at path, the escaped path, and decodes
+// the response into output unless it is nil
+func (c *EKS) call(ctx context.Context, method, path string, query url.Values, input, output any) error {
+	var body []byte
+	if input != nil {
+		var err error
+		if body, err = json.Marshal(input); err != nil {
+			return err
+		}
+	}
+	credentials, err := c.credentials(ctx)
+	if err != nil {
+		return err
+	}
+	u, err := url.Parse(c.endpoint + path)
+	if err != nil {
+		return err
+	}
+	u.RawQuery = query.Encode()
+	req, err := http.NewRequestWithContext(ctx, method, u.String(), bytes.NewReader(body))
+	if err != nil {
+		return err
+	}
+	if input != nil {
+		req.Header.Set("Content-Type", "application/json")
+	}
+	SignV4(req, body, credentials, c.region, "eks", c.now())
+
+	resp, err := c.http.Do(req)
+	if err != nil {
+		return err
+	}
+	defer func() { _ = resp.Body.Close() }()
+	respBody, err := io.ReadAll(io.LimitReader(resp.Body, 1<<20))
+	if err != nil {
+		return err
+	}
+	if resp.StatusCode >= http.StatusBadRequest {
+		awsErr := &Error{Status: resp.StatusCode}
+		var errorBody struct {
+			Message string `json:"message"`
+		}
+		if json.Unmarshal(respBody, &errorBody) == nil {
+			awsErr.Message = errorBody.Message
+		}
+		// REST APIs name the type in a header, e.g. "ResourceNotFoundException:http://..."
+		awsErr.Type, _, _ = strings.Cut(resp.Header.Get("X-Amzn-Errortype"), ":")
+		return awsErr
+	}
+	if output == nil {
+		return nil
+	}
+	if err := json.Unmarshal(respBody, output); err != nil {
+		return fmt.Errorf("invalid EKS response: %w", err)
+	}
+	return nil
+}
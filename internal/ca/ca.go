@@ -0,0 +1,295 @@
+/*
+Copyright 2025.
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+*/
+
+// Package ca resolves the cluster CA bundle that is embedded in generated kubeconfigs.
+package ca
+
+import (
+	"context"
+	"crypto/x509"
+	"encoding/base64"
+	"encoding/pem"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"sync"
+
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/client-go/tools/clientcmd"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+)
+
+// SourceKind identifies where a CA bundle is read from
+type SourceKind string
+
+const (
+	// SourceFile reads the CA from a file on the operator's filesystem
+	SourceFile SourceKind = "file"
+	// SourceSecret reads the CA from a key of a Secret
+	SourceSecret SourceKind = "secret"
+	// SourceConfigMap reads the CA from a key of a ConfigMap
+	SourceConfigMap SourceKind = "configmap"
+	// SourceInline uses a base64-encoded PEM bundle given directly in the configuration
+	SourceInline SourceKind = "inline"
+	// SourceClusterInfo reads the CA from the cluster-info ConfigMap in kube-public
+	SourceClusterInfo SourceKind = "cluster-info"
+
+	// DefaultKey is the data key used for Secret and ConfigMap sources when none is given
+	DefaultKey = "ca.crt"
+
+	// InClusterCAPath is the CA mounted into every pod with a ServiceAccount token
+	InClusterCAPath = "/var/run/secrets/kubernetes.io/serviceaccount/ca.crt"
+
+	// ClusterInfoNamespace and ClusterInfoName locate the public cluster-info ConfigMap
+	// published by kubeadm and most distributions for bootstrapping clients
+	ClusterInfoNamespace = "kube-public"
+	ClusterInfoName      = "cluster-info"
+)
+
+// Source is a single location a CA bundle can be loaded from
+type Source struct {
+	Kind SourceKind
+	// Path is used by file sources
+	Path string
+	// Namespace, Name and Key are used by Secret and ConfigMap sources
+	Namespace string
+	Name      string
+	Key       string
+	// PEM is used by inline sources
+	PEM []byte
+}
+
+// String renders the source in the same form accepted by ParseSources.
+// Inline sources are not echoed back to keep logs and status short.
+func (s Source) String() string {
+	switch s.Kind {
+	case SourceFile:
+		return fmt.Sprintf("file:%s", s.Path)
+	case SourceSecret, SourceConfigMap:
+		return fmt.Sprintf("%s:%s/%s/%s", s.Kind, s.Namespace, s.Name, s.Key)
+	case SourceInline:
+		return "inline"
+	}
+	return string(s.Kind)
+}
+
+// SourceNames renders a list of sources for logging
+func SourceNames(sources []Source) []string {
+	names := make([]string, 0, len(sources))
+	for _, src := range sources {
+		names = append(names, src.String())
+	}
+	return names
+}
+
+// DefaultSources returns the historical lookup order: the ServiceAccount mount, then kube-root-ca.crt,
+// then the cluster-info ConfigMap
+func DefaultSources() []Source {
+	return []Source{
+		{Kind: SourceFile, Path: InClusterCAPath},
+		{Kind: SourceConfigMap, Namespace: "default", Name: "kube-root-ca.crt", Key: DefaultKey},
+		{Kind: SourceClusterInfo},
+	}
+}
+
+// ParseSources parses a comma separated list of CA sources. Supported entries are:
+//
+//	file:<path>
+//	secret:<namespace>/<name>[/<key>]
+//	configmap:<namespace>/<name>[/<key>]
+//	inline:<base64-encoded PEM>
+//	cluster-info
+//
+// An empty spec yields DefaultSources.
+func ParseSources(spec string) ([]Source, error) {
+	if strings.TrimSpace(spec) == "" {
+		return DefaultSources(), nil
+	}
+
+	var sources []Source
+	for _, entry := range strings.Split(spec, ",") {
+		entry = strings.TrimSpace(entry)
+		if entry == "" {
+			continue
+		}
+		if entry == string(SourceClusterInfo) {
+			sources = append(sources, Source{Kind: SourceClusterInfo})
+			continue
+		}
+		kind, value, found := strings.Cut(entry, ":")
+		if !found || value == "" {
+			return nil, fmt.Errorf("invalid CA source %q: expected <kind>:<value>", entry)
+		}
+
+		switch SourceKind(kind) {
+		case SourceFile:
+			sources = append(sources, Source{Kind: SourceFile, Path: value})
+		case SourceSecret, SourceConfigMap:
+			parts := strings.Split(value, "/")
+			if len(parts) < 2 || len(parts) > 3 || parts[0] == "" || parts[1] == "" {
+				return nil, fmt.Errorf("invalid CA source %q: expected %s:<namespace>/<name>[/<key>]", entry, kind)
+			}
+			src := Source{Kind: SourceKind(kind), Namespace: parts[0], Name: parts[1], Key: DefaultKey}
+			if len(parts) == 3 && parts[2] != "" {
+				src.Key = parts[2]
+			}
+			sources = append(sources, src)
+		case SourceInline:
+			data, err := base64.StdEncoding.DecodeString(value)
+			if err != nil {
+				return nil, fmt.Errorf("invalid CA source %q: inline PEM must be base64 encoded: %w", entry, err)
+			}
+			sources = append(sources, Source{Kind: SourceInline, PEM: data})
+		default:
+			return nil, fmt.Errorf("invalid CA source %q: unknown kind %q", entry, kind)
+		}
+	}
+
+	if len(sources) == 0 {
+		return nil, errors.New("no CA sources configured")
+	}
+	return sources, nil
+}
+
+// Resolver walks an ordered list of sources and returns the first usable CA bundle
+type Resolver struct {
+	Reader  client.Reader
+	Sources []Source
+
+	mu     sync.RWMutex
+	active string
+}
+
+// NewResolver returns a Resolver reading in-cluster sources through the given reader
+func NewResolver(reader client.Reader, sources []Source) *Resolver {
+	if len(sources) == 0 {
+		sources = DefaultSources()
+	}
+	return &Resolver{Reader: reader, Sources: sources}
+}
+
+// Resolve returns the PEM bundle of the first source that yields a valid certificate,
+// together with the source that produced it.
+func (r *Resolver) Resolve(ctx context.Context) ([]byte, Source, error) {
+	var errs []error
+	for _, src := range r.Sources {
+		data, err := r.load(ctx, src)
+		if err == nil {
+			err = validatePEM(data)
+		}
+		if err != nil {
+			errs = append(errs, fmt.Errorf("%s: %w", src, err))
+			continue
+		}
+
+		r.mu.Lock()
+		r.active = src.String()
+		r.mu.Unlock()
+		return data, src, nil
+	}
+	return nil, Source{}, fmt.Errorf("CA not found: %w", errors.Join(errs...))
+}
+
+// Active returns the source used by the last successful Resolve, or "" if none succeeded yet
+func (r *Resolver) Active() string {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	return r.active
+}
+
+func (r *Resolver) load(ctx context.Context, src Source) ([]byte, error) {
+	key := types.NamespacedName{Namespace: src.Namespace, Name: src.Name}
+	switch src.Kind {
+	case SourceFile:
+		return os.ReadFile(filepath.Clean(src.Path))
+	case SourceSecret:
+		var secret corev1.Secret
+		if err := r.Reader.Get(ctx, key, &secret); err != nil {
+			return nil, err
+		}
+		if data, ok := secret.Data[src.Key]; ok {
+			return data, nil
+		}
+	case SourceConfigMap:
+		var cm corev1.ConfigMap
+		if err := r.Reader.Get(ctx, key, &cm); err != nil {
+			return nil, err
+		}
+		if data, ok := cm.Data[src.Key]; ok {
+			return []byte(data), nil
+		}
+	case SourceInline:
+		return src.PEM, nil
+	case SourceClusterInfo:
+		info, err := LoadClusterInfo(ctx, r.Reader)
+		if err != nil {
+			return nil, err
+		}
+		return info.CA, nil
+	default:
+		return nil, fmt.Errorf("unknown source kind %q", src.Kind)
+	}
+	return nil, fmt.Errorf("key %q not found", src.Key)
+}
+
+// validatePEM makes sure the bundle contains at least one parseable certificate
+func validatePEM(data []byte) error {
+	if len(data) == 0 {
+		return errors.New("empty CA bundle")
+	}
+	for rest := data; ; {
+		var block *pem.Block
+		block, rest = pem.Decode(rest)
+		if block == nil {
+			return errors.New("no PEM certificate found")
+		}
+		if block.Type != "CERTIFICATE" {
+			continue
+		}
+		if _, err := x509.ParseCertificate(block.Bytes); err != nil {
+			return fmt.Errorf("invalid certificate: %w", err)
+		}
+		return nil
+	}
+}
+
+// ClusterInfo is the API server endpoint and CA published in the cluster-info ConfigMap
+type ClusterInfo struct {
+	Server string
+	CA     []byte
+}
+
+// LoadClusterInfo reads the first cluster of the kubeconfig in kube-public/cluster-info. Its
+// server is usually the externally reachable endpoint, unlike kubernetes.default.svc.
+func LoadClusterInfo(ctx context.Context, reader client.Reader) (*ClusterInfo, error) {
+	var cm corev1.ConfigMap
+	key := types.NamespacedName{Namespace: ClusterInfoNamespace, Name: ClusterInfoName}
+	if err := reader.Get(ctx, key, &cm); err != nil {
+		return nil, err
+	}
+	data, ok := cm.Data["kubeconfig"]
+	if !ok {
+		return nil, fmt.Errorf("%s has no kubeconfig", key)
+	}
+	config, err := clientcmd.Load([]byte(data))
+	if err != nil {
+		return nil, fmt.Errorf("parsing kubeconfig in %s: %w", key, err)
+	}
+	names := make([]string, 0, len(config.Clusters))
+	for name := range config.Clusters {
+		names = append(names, name)
+	}
+	if len(names) == 0 {
+		return nil, fmt.Errorf("kubeconfig in %s has no clusters", key)
+	}
+	sort.Strings(names)
+	cluster := config.Clusters[names[0]]
+	return &ClusterInfo{Server: cluster.Server, CA: cluster.CertificateAuthorityData}, nil
+}
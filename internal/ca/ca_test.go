@@ -0,0 +1,147 @@
+/*
+Copyright 2025.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package ca
+
+import (
+	"context"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/base64"
+	"encoding/pem"
+	"fmt"
+	"math/big"
+	"time"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+)
+
+func selfSignedPEM() []byte {
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	Expect(err).NotTo(HaveOccurred())
+	tmpl := &x509.Certificate{
+		SerialNumber:          big.NewInt(1),
+		Subject:               pkix.Name{CommonName: "test-ca"},
+		NotBefore:             time.Now(),
+		NotAfter:              time.Now().Add(time.Hour),
+		IsCA:                  true,
+		BasicConstraintsValid: true,
+	}
+	der, err := x509.CreateCertificate(rand.Reader, tmpl, tmpl, &key.PublicKey, key)
+	Expect(err).NotTo(HaveOccurred())
+	return pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: der})
+}
+
+var _ = Describe("ParseSources", func() {
+	It("returns the default sources for an empty spec", func() {
+		sources, err := ParseSources("")
+		Expect(err).NotTo(HaveOccurred())
+		Expect(sources).To(Equal(DefaultSources()))
+	})
+
+	It("parses every supported kind in order", func() {
+		inline := base64.StdEncoding.EncodeToString([]byte("pem"))
+		sources, err := ParseSources("file:/etc/ca.crt, secret:kube-system/ca, configmap:kube-public/cluster-info/ca,inline:" +
+			inline + ",cluster-info")
+		Expect(err).NotTo(HaveOccurred())
+		Expect(SourceNames(sources)).To(Equal([]string{
+			"file:/etc/ca.crt",
+			"secret:kube-system/ca/ca.crt",
+			"configmap:kube-public/cluster-info/ca",
+			"inline",
+			"cluster-info",
+		}))
+		Expect(sources[3].PEM).To(Equal([]byte("pem")))
+	})
+
+	It("rejects malformed entries", func() {
+		for _, spec := range []string{"file", "secret:only-namespace", "vault:foo", "inline:not base64!"} {
+			_, err := ParseSources(spec)
+			Expect(err).To(HaveOccurred(), spec)
+		}
+	})
+})
+
+var _ = Describe("Resolver", func() {
+	ctx := context.Background()
+
+	It("falls back to the next source and records the active one", func() {
+		caPEM := selfSignedPEM()
+		reader := fake.NewClientBuilder().WithObjects(&corev1.ConfigMap{
+			ObjectMeta: metav1.ObjectMeta{Name: "kube-root-ca.crt", Namespace: "default"},
+			Data:       map[string]string{"ca.crt": string(caPEM)},
+		}).Build()
+
+		resolver := NewResolver(reader, []Source{
+			{Kind: SourceFile, Path: "/does/not/exist"},
+			{Kind: SourceSecret, Namespace: "default", Name: "missing", Key: DefaultKey},
+			{Kind: SourceConfigMap, Namespace: "default", Name: "kube-root-ca.crt", Key: DefaultKey},
+		})
+		data, src, err := resolver.Resolve(ctx)
+		Expect(err).NotTo(HaveOccurred())
+		Expect(data).To(Equal(caPEM))
+		Expect(src.Kind).To(Equal(SourceConfigMap))
+		Expect(resolver.Active()).To(Equal("configmap:default/kube-root-ca.crt/ca.crt"))
+	})
+
+	It("skips sources that do not contain a certificate", func() {
+		caPEM := selfSignedPEM()
+		resolver := NewResolver(fake.NewClientBuilder().Build(), []Source{
+			{Kind: SourceInline, PEM: []byte("garbage")},
+			{Kind: SourceInline, PEM: caPEM},
+		})
+		data, _, err := resolver.Resolve(ctx)
+		Expect(err).NotTo(HaveOccurred())
+		Expect(data).To(Equal(caPEM))
+	})
+
+	It("reads the CA from cluster-info", func() {
+		caPEM := selfSignedPEM()
+		kubeconfig := fmt.Sprintf(`apiVersion: v1
+kind: Config
+clusters:
+- cluster:
+    certificate-authority-data: %s
+    server: https://k8s.example.com:6443
+  name: ""
+`, base64.StdEncoding.EncodeToString(caPEM))
+		reader := fake.NewClientBuilder().WithObjects(&corev1.ConfigMap{
+			ObjectMeta: metav1.ObjectMeta{Name: ClusterInfoName, Namespace: ClusterInfoNamespace},
+			Data:       map[string]string{"kubeconfig": kubeconfig},
+		}).Build()
+
+		info, err := LoadClusterInfo(ctx, reader)
+		Expect(err).NotTo(HaveOccurred())
+		Expect(info.Server).To(Equal("https://k8s.example.com:6443"))
+		data, _, err := NewResolver(reader, []Source{{Kind: SourceClusterInfo}}).Resolve(ctx)
+		Expect(err).NotTo(HaveOccurred())
+		Expect(data).To(Equal(caPEM))
+	})
+
+	It("fails when no source is usable", func() {
+		resolver := NewResolver(fake.NewClientBuilder().Build(), []Source{{Kind: SourceFile, Path: "/does/not/exist"}})
+		_, _, err := resolver.Resolve(ctx)
+		Expect(err).To(MatchError(ContainSubstring("CA not found")))
+		Expect(resolver.Active()).To(BeEmpty())
+	})
+})
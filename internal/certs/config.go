@@ -0,0 +1,170 @@
+/*
+Copyright 2025.
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+*/
+
+// Package certs holds the configuration of the webhook serving certificate, generates a
+// self-signed one into a Secret on request, and injects CAs into webhook configurations.
+package certs
+
+import (
+	"crypto/tls"
+	"crypto/x509"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"k8s.io/apimachinery/pkg/util/validation"
+)
+
+const (
+	// DefaultCertDir is where the serving certificate Secret is mounted by the manifests and chart
+	DefaultCertDir = "/tmp/k8s-webhook-server/serving-certs"
+
+	// EnvCertDir overrides the certificate directory when the flag is not set
+	EnvCertDir = "WEBHOOK_CERT_DIR"
+	// EnvServiceName is the name of the Service fronting the webhook server
+	EnvServiceName = "WEBHOOK_SERVICE_NAME"
+	// EnvNamespace is the namespace of the webhook Service, the manager's own from the downward
+	// API. KUBEUSER_NAMESPACE is the namespace of per-user resources, which may differ.
+	EnvNamespace = "POD_NAMESPACE"
+)
+
+// Config describes where the webhook serving certificate lives and which Service it must be valid for
+type Config struct {
+	CertDir     string
+	CertName    string
+	KeyName     string
+	ServiceName string
+	Namespace   string
+	// SecretName, if set, is the Secret in Namespace the keypair is generated into and
+	// synced from, see EnsureSecret
+	SecretName string
+	// Validity and RenewBefore are the lifetime of generated serving certificates and how long
+	// before expiry they are renewed; zero means DefaultServingValidity and
+	// DefaultServingRenewBefore
+	Validity    time.Duration
+	RenewBefore time.Duration
+}
+
+func (c Config) validity() time.Duration {
+	if c.Validity <= 0 {
+		return DefaultServingValidity
+	}
+	return c.Validity
+}
+
+func (c Config) renewBefore() time.Duration {
+	if c.RenewBefore <= 0 {
+		return DefaultServingRenewBefore
+	}
+	return c.RenewBefore
+}
+
+// ApplyEnv fills empty fields from the environment. Flags always take precedence.
+func (c *Config) ApplyEnv() {
+	if c.CertDir == "" {
+		c.CertDir = os.Getenv(EnvCertDir)
+	}
+	if c.ServiceName == "" {
+		c.ServiceName = os.Getenv(EnvServiceName)
+	}
+	if c.Namespace == "" {
+		c.Namespace = os.Getenv(EnvNamespace)
+	}
+}
+
+// ServiceHost returns the in-cluster DNS name the API server uses to reach the webhook
+func (c Config) ServiceHost() string {
+	return fmt.Sprintf("%s.%s.svc", c.ServiceName, c.Namespace)
+}
+
+// DNSNames returns every name the serving certificate is expected to cover
+func (c Config) DNSNames() []string {
+	return []string{
+		c.ServiceName,
+		fmt.Sprintf("%s.%s", c.ServiceName, c.Namespace),
+		c.ServiceHost(),
+		c.ServiceHost() + ".cluster.local",
+	}
+}
+
+// Validate checks the configuration and the certificate on disk, returning an error that
+// names the offending flag or environment variable.
+func (c Config) Validate() error {
+	if err := c.ValidateSettings(); err != nil {
+		return err
+	}
+	return c.validateKeyPair()
+}
+
+// ValidateSettings checks the configuration without the certificate, which may yet have to be
+// written from the Secret.
+func (c Config) ValidateSettings() error {
+	if c.CertDir == "" {
+		return fmt.Errorf("webhook certificate directory is not set: use --webhook-cert-path or %s", EnvCertDir)
+	}
+	if !filepath.IsAbs(c.CertDir) {
+		return fmt.Errorf("webhook certificate directory %q must be an absolute path", c.CertDir)
+	}
+	if info, err := os.Stat(c.CertDir); err != nil {
+		return fmt.Errorf("webhook certificate directory %q is not accessible: %w", c.CertDir, err)
+	} else if !info.IsDir() {
+		return fmt.Errorf("webhook certificate directory %q is not a directory", c.CertDir)
+	}
+	if c.CertName == "" || c.KeyName == "" {
+		return errors.New("webhook certificate and key file names must not be empty")
+	}
+
+	if c.ServiceName == "" {
+		return fmt.Errorf("webhook service name is not set: use --webhook-service-name or %s", EnvServiceName)
+	}
+	if errs := validation.IsDNS1035Label(c.ServiceName); len(errs) > 0 {
+		return fmt.Errorf("invalid webhook service name %q: %s", c.ServiceName, strings.Join(errs, "; "))
+	}
+	if c.Namespace == "" {
+		return fmt.Errorf("webhook service namespace is not set: use --webhook-service-namespace or %s", EnvNamespace)
+	}
+	if errs := validation.IsDNS1123Label(c.Namespace); len(errs) > 0 {
+		return fmt.Errorf("invalid webhook service namespace %q: %s", c.Namespace, strings.Join(errs, "; "))
+	}
+	if c.SecretName != "" {
+		if errs := validation.IsDNS1123Subdomain(c.SecretName); len(errs) > 0 {
+			return fmt.Errorf("invalid webhook certificate Secret name %q: %s", c.SecretName, strings.Join(errs, "; "))
+		}
+	}
+	// The serving certificate must expire well before the CA that signs it is replaced
+	if c.Validity < 0 || c.Validity > 0 && (c.Validity < MinServingValidity || c.Validity > CARenewBefore) {
+		return fmt.Errorf("webhook certificate validity %s must be between %s and %s", c.Validity,
+			MinServingValidity, CARenewBefore)
+	}
+	if c.RenewBefore < 0 || c.renewBefore() >= c.validity() {
+		return fmt.Errorf("webhook certificate renewal %s before expiry must be shorter than its validity %s",
+			c.renewBefore(), c.validity())
+	}
+	return nil
+}
+
+// validateKeyPair loads the serving keypair and makes sure it covers the webhook Service
+func (c Config) validateKeyPair() error {
+	certFile := filepath.Join(c.CertDir, c.CertName)
+	keyFile := filepath.Join(c.CertDir, c.KeyName)
+
+	pair, err := tls.LoadX509KeyPair(certFile, keyFile)
+	if err != nil {
+		return fmt.Errorf("failed to load webhook keypair from %s and %s: %w", certFile, keyFile, err)
+	}
+	leaf, err := x509.ParseCertificate(pair.Certificate[0])
+	if err != nil {
+		return fmt.Errorf("failed to parse webhook certificate %s: %w", certFile, err)
+	}
+	if err := leaf.VerifyHostname(c.ServiceHost()); err != nil {
+		return fmt.Errorf("webhook certificate %s is not valid for service %s (DNS names %v): %w",
+			certFile, c.ServiceHost(), leaf.DNSNames, err)
+	}
+	return nil
+}
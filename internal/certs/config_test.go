@@ -0,0 +1,43 @@
+/*
+Copyright 2025.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package certs
+
+import (
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("Config", func() {
+	It("takes the Service namespace from POD_NAMESPACE, not the namespace of per-user resources", func() {
+		GinkgoT().Setenv("KUBEUSER_NAMESPACE", "identity")
+		GinkgoT().Setenv(EnvNamespace, "kubeuser-system")
+		GinkgoT().Setenv(EnvServiceName, "kubeuser-webhook-service")
+
+		config := &Config{}
+		config.ApplyEnv()
+		Expect(config.Namespace).To(Equal("kubeuser-system"))
+		Expect(config.ServiceHost()).To(Equal("kubeuser-webhook-service.kubeuser-system.svc"))
+	})
+
+	It("prefers the flags over the environment", func() {
+		GinkgoT().Setenv(EnvNamespace, "kubeuser-system")
+
+		config := &Config{Namespace: "kubeuser"}
+		config.ApplyEnv()
+		Expect(config.Namespace).To(Equal("kubeuser"))
+	})
+})
@@ -0,0 +1,178 @@
+/*
+Copyright 2025.
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+*/
+
+package certs
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"strings"
+
+	admissionregistrationv1 "k8s.io/api/admissionregistration/v1"
+	corev1 "k8s.io/api/core/v1"
+	apiextensionsv1 "k8s.io/apiextensions-apiserver/pkg/apis/apiextensions/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/types"
+	ctrl "sigs.k8s.io/controller-runtime"
+	"sigs.k8s.io/controller-runtime/pkg/builder"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/handler"
+	logf "sigs.k8s.io/controller-runtime/pkg/log"
+	"sigs.k8s.io/controller-runtime/pkg/predicate"
+	"sigs.k8s.io/controller-runtime/pkg/reconcile"
+)
+
+const (
+	// InjectAnnotation names the Secret, as <namespace>/<name>, whose CA the CABundleInjector
+	// puts into the caBundle of an annotated webhook configuration or CustomResourceDefinition
+	InjectAnnotation = "auth.openkube.io/inject-ca-from-secret"
+
+	// CAKey holds the CA of a serving certificate Secret, as written by cert-manager. Secrets
+	// without it are taken to hold a self-signed certificate under corev1.TLSCertKey.
+	CAKey = "ca.crt"
+)
+
+// +kubebuilder:rbac:groups=admissionregistration.k8s.io,resources=validatingwebhookconfigurations;mutatingwebhookconfigurations,verbs=get;list;watch;patch
+// +kubebuilder:rbac:groups=apiextensions.k8s.io,resources=customresourcedefinitions,verbs=get;list;watch;patch
+
+// CABundleInjector keeps the caBundle of the webhook configurations and the conversion webhooks
+// of the CustomResourceDefinitions carrying InjectAnnotation equal to the CA in the Secret
+// they name, so fail-closed webhooks keep working when the serving certificate is renewed
+// with a new CA. Only Secrets in Namespace are injected.
+type CABundleInjector struct {
+	client.Client
+
+	// Namespace is the namespace of the Secrets that may be injected, the KubeUser namespace
+	Namespace string
+}
+
+// Reconcile injects the CA of the Secret in req into every object naming it. Objects are left
+// as they are while the Secret does not exist or holds no certificate.
+func (r *CABundleInjector) Reconcile(ctx context.Context, req ctrl.Request) (ctrl.Result, error) {
+	var secret corev1.Secret
+	if err := r.Get(ctx, req.NamespacedName, &secret); apierrors.IsNotFound(err) {
+		return ctrl.Result{}, nil
+	} else if err != nil {
+		return ctrl.Result{}, err
+	}
+	caBundle := CABundle(&secret)
+	if len(caBundle) == 0 {
+		return ctrl.Result{}, nil
+	}
+	source := req.String()
+
+	var validating admissionregistrationv1.ValidatingWebhookConfigurationList
+	if err := r.List(ctx, &validating); err != nil {
+		return ctrl.Result{}, err
+	}
+	for i := range validating.Items {
+		config := &validating.Items[i]
+		if config.Annotations[InjectAnnotation] != source {
+			continue
+		}
+		original := config.DeepCopy()
+		changed := false
+		for j := range config.Webhooks {
+			changed = setCABundle(&config.Webhooks[j].ClientConfig, caBundle) || changed
+		}
+		if err := r.patch(ctx, config, original, changed); err != nil {
+			return ctrl.Result{}, err
+		}
+	}
+
+	var mutating admissionregistrationv1.MutatingWebhookConfigurationList
+	if err := r.List(ctx, &mutating); err != nil {
+		return ctrl.Result{}, err
+	}
+	for i := range mutating.Items {
+		config := &mutating.Items[i]
+		if config.Annotations[InjectAnnotation] != source {
+			continue
+		}
+		original := config.DeepCopy()
+		changed := false
+		for j := range config.Webhooks {
+			changed = setCABundle(&config.Webhooks[j].ClientConfig, caBundle) || changed
+		}
+		if err := r.patch(ctx, config, original, changed); err != nil {
+			return ctrl.Result{}, err
+		}
+	}
+
+	var crds apiextensionsv1.CustomResourceDefinitionList
+	if err := r.List(ctx, &crds); err != nil {
+		return ctrl.Result{}, err
+	}
+	for i := range crds.Items {
+		crd := &crds.Items[i]
+		if crd.Annotations[InjectAnnotation] != source || crd.Spec.Conversion == nil ||
+			crd.Spec.Conversion.Webhook == nil || crd.Spec.Conversion.Webhook.ClientConfig == nil {
+			continue
+		}
+		original := crd.DeepCopy()
+		changed := false
+		if clientConfig := crd.Spec.Conversion.Webhook.ClientConfig; !bytes.Equal(clientConfig.CABundle, caBundle) {
+			clientConfig.CABundle, changed = caBundle, true
+		}
+		if err := r.patch(ctx, crd, original, changed); err != nil {
+			return ctrl.Result{}, err
+		}
+	}
+	return ctrl.Result{}, nil
+}
+
+// patch writes the injected caBundle of obj, if it changed
+func (r *CABundleInjector) patch(ctx context.Context, obj, original client.Object, changed bool) error {
+	if !changed {
+		return nil
+	}
+	if err := r.Patch(ctx, obj, client.MergeFrom(original)); err != nil {
+		return fmt.Errorf("failed to inject caBundle into %T %s: %w", obj, obj.GetName(), err)
+	}
+	logf.FromContext(ctx).Info("Injected caBundle", "kind", fmt.Sprintf("%T", obj), "name", obj.GetName())
+	return nil
+}
+
+// setCABundle reports whether clientConfig did not trust caBundle yet
+func setCABundle(clientConfig *admissionregistrationv1.WebhookClientConfig, caBundle []byte) bool {
+	if bytes.Equal(clientConfig.CABundle, caBundle) {
+		return false
+	}
+	clientConfig.CABundle = caBundle
+	return true
+}
+
+// CABundle returns the CA of a serving certificate Secret
+func CABundle(secret *corev1.Secret) []byte {
+	if ca := secret.Data[CAKey]; len(ca) > 0 {
+		return ca
+	}
+	return secret.Data[corev1.TLSCertKey]
+}
+
+// injectSource maps an annotated object to the Secret it names
+func (r *CABundleInjector) injectSource(_ context.Context, obj client.Object) []reconcile.Request {
+	namespace, name, ok := strings.Cut(obj.GetAnnotations()[InjectAnnotation], "/")
+	if !ok || namespace != r.Namespace || name == "" {
+		return nil
+	}
+	return []reconcile.Request{{NamespacedName: types.NamespacedName{Namespace: namespace, Name: name}}}
+}
+
+// SetupWithManager wires the injector. It runs on the leader, like the other controllers.
+func (r *CABundleInjector) SetupWithManager(mgr ctrl.Manager) error {
+	annotated := handler.EnqueueRequestsFromMapFunc(r.injectSource)
+	return ctrl.NewControllerManagedBy(mgr).
+		For(&corev1.Secret{}, builder.WithPredicates(predicate.NewPredicateFuncs(func(o client.Object) bool {
+			return o.GetNamespace() == r.Namespace
+		}))).
+		Watches(&admissionregistrationv1.ValidatingWebhookConfiguration{}, annotated).
+		Watches(&admissionregistrationv1.MutatingWebhookConfiguration{}, annotated).
+		Watches(&apiextensionsv1.CustomResourceDefinition{}, annotated).
+		Named("cabundle-injector").
+		Complete(r)
+}
@@ -0,0 +1,131 @@
+/*
+Copyright 2025.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package certs
+
+import (
+	"context"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+	admissionregistrationv1 "k8s.io/api/admissionregistration/v1"
+	corev1 "k8s.io/api/core/v1"
+	apiextensionsv1 "k8s.io/apiextensions-apiserver/pkg/apis/apiextensions/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/types"
+	clientgoscheme "k8s.io/client-go/kubernetes/scheme"
+	ctrl "sigs.k8s.io/controller-runtime"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+)
+
+var _ = Describe("CABundleInjector", func() {
+	var (
+		ctx      context.Context
+		c        client.Client
+		injector *CABundleInjector
+		secret   *corev1.Secret
+		source   = types.NamespacedName{Namespace: "kubeuser", Name: "webhook-certs"}
+	)
+
+	annotated := func(value string) metav1.ObjectMeta {
+		return metav1.ObjectMeta{Name: "kubeuser", Annotations: map[string]string{InjectAnnotation: value}}
+	}
+
+	BeforeEach(func() {
+		ctx = context.Background()
+		scheme := runtime.NewScheme()
+		Expect(clientgoscheme.AddToScheme(scheme)).To(Succeed())
+		Expect(apiextensionsv1.AddToScheme(scheme)).To(Succeed())
+		secret = &corev1.Secret{
+			ObjectMeta: metav1.ObjectMeta{Namespace: source.Namespace, Name: source.Name},
+			Data:       map[string][]byte{CAKey: []byte("new CA"), corev1.TLSCertKey: []byte("serving cert")},
+		}
+		c = fake.NewClientBuilder().WithScheme(scheme).WithObjects(secret).Build()
+		injector = &CABundleInjector{Client: c, Namespace: "kubeuser"}
+	})
+
+	It("injects the CA into every webhook of annotated configurations", func() {
+		validating := &admissionregistrationv1.ValidatingWebhookConfiguration{
+			ObjectMeta: annotated(source.String()),
+			Webhooks: []admissionregistrationv1.ValidatingWebhook{
+				{Name: "user.auth.openkube.io", ClientConfig: admissionregistrationv1.WebhookClientConfig{CABundle: []byte("old CA")}},
+				{Name: "team.auth.openkube.io"},
+			},
+		}
+		mutating := &admissionregistrationv1.MutatingWebhookConfiguration{
+			ObjectMeta: annotated(source.String()),
+			Webhooks:   []admissionregistrationv1.MutatingWebhook{{Name: "muser.auth.openkube.io"}},
+		}
+		other := &admissionregistrationv1.ValidatingWebhookConfiguration{
+			ObjectMeta: metav1.ObjectMeta{Name: "other"},
+			Webhooks:   []admissionregistrationv1.ValidatingWebhook{{Name: "other.example.com"}},
+		}
+		Expect(c.Create(ctx, validating)).To(Succeed())
+		Expect(c.Create(ctx, mutating)).To(Succeed())
+		Expect(c.Create(ctx, other)).To(Succeed())
+
+		_, err := injector.Reconcile(ctx, ctrl.Request{NamespacedName: source})
+		Expect(err).NotTo(HaveOccurred())
+
+		Expect(c.Get(ctx, client.ObjectKeyFromObject(validating), validating)).To(Succeed())
+		Expect(validating.Webhooks[0].ClientConfig.CABundle).To(Equal([]byte("new CA")))
+		Expect(validating.Webhooks[1].ClientConfig.CABundle).To(Equal([]byte("new CA")))
+		Expect(c.Get(ctx, client.ObjectKeyFromObject(mutating), mutating)).To(Succeed())
+		Expect(mutating.Webhooks[0].ClientConfig.CABundle).To(Equal([]byte("new CA")))
+		Expect(c.Get(ctx, client.ObjectKeyFromObject(other), other)).To(Succeed())
+		Expect(other.Webhooks[0].ClientConfig.CABundle).To(BeEmpty())
+	})
+
+	It("injects the CA into conversion webhooks", func() {
+		crd := &apiextensionsv1.CustomResourceDefinition{
+			ObjectMeta: annotated(source.String()),
+			Spec: apiextensionsv1.CustomResourceDefinitionSpec{
+				Conversion: &apiextensionsv1.CustomResourceConversion{
+					Strategy: apiextensionsv1.WebhookConverter,
+					Webhook: &apiextensionsv1.WebhookConversion{
+						ClientConfig: &apiextensionsv1.WebhookClientConfig{},
+					},
+				},
+			},
+		}
+		Expect(c.Create(ctx, crd)).To(Succeed())
+
+		_, err := injector.Reconcile(ctx, ctrl.Request{NamespacedName: source})
+		Expect(err).NotTo(HaveOccurred())
+
+		Expect(c.Get(ctx, client.ObjectKeyFromObject(crd), crd)).To(Succeed())
+		Expect(crd.Spec.Conversion.Webhook.ClientConfig.CABundle).To(Equal([]byte("new CA")))
+	})
+
+	It("uses the serving certificate of Secrets without a CA", func() {
+		delete(secret.Data, CAKey)
+		Expect(CABundle(secret)).To(Equal([]byte("serving cert")))
+	})
+
+	It("only maps annotations naming Secrets in its namespace", func() {
+		Expect(injector.injectSource(ctx, &admissionregistrationv1.ValidatingWebhookConfiguration{
+			ObjectMeta: annotated(source.String()),
+		})).To(ConsistOf(ctrl.Request{NamespacedName: source}))
+		Expect(injector.injectSource(ctx, &admissionregistrationv1.ValidatingWebhookConfiguration{
+			ObjectMeta: annotated("kube-system/webhook-certs"),
+		})).To(BeEmpty())
+		Expect(injector.injectSource(ctx, &admissionregistrationv1.ValidatingWebhookConfiguration{
+			ObjectMeta: annotated("webhook-certs"),
+		})).To(BeEmpty())
+	})
+})
@@ -0,0 +1,300 @@
+/*
+Copyright 2025.
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+*/
+
+package certs
+
+import (
+	"bytes"
+	"context"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/pem"
+	"errors"
+	"fmt"
+	"math/big"
+	"os"
+	"path/filepath"
+	"time"
+
+	corev1 "k8s.io/api/core/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/types"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	logf "sigs.k8s.io/controller-runtime/pkg/log"
+)
+
+const (
+	// CAPrivateKey holds the key of the generated CA, so the serving certificate can be renewed
+	// without changing the caBundle
+	CAPrivateKey = "ca.key"
+	// ManagedByLabel marks the Secrets the controller generated; only those are renewed
+	ManagedByLabel = "app.kubernetes.io/managed-by"
+
+	// DefaultServingValidity and DefaultServingRenewBefore match the defaults of the chart's
+	// cert-manager Certificate
+	DefaultServingValidity    = 365 * 24 * time.Hour
+	DefaultServingRenewBefore = 90 * 24 * time.Hour
+	// MinServingValidity keeps renewals, which every replica picks up within a sync interval,
+	// well apart
+	MinServingValidity = 24 * time.Hour
+	// CAValidity is long, since a new CA has to reach every webhook configuration before the
+	// serving certificate it signs is used
+	CAValidity    = 10 * 365 * 24 * time.Hour
+	CARenewBefore = 365 * 24 * time.Hour
+
+	// DefaultSyncInterval is how often every replica checks the Secret
+	DefaultSyncInterval = time.Minute
+)
+
+// EnsureSecret returns the Secret config.SecretName in config.Namespace, generating a self-signed
+// CA and a serving certificate for the webhook Service into it when it does not exist, and
+// renewing a Secret it generated before its certificate expires. Secrets created by others are
+// returned as they are. Replicas racing to write the Secret all end up with the one written
+// first.
+func EnsureSecret(ctx context.Context, c client.Client, config Config, now time.Time) (*corev1.Secret, error) {
+	key := types.NamespacedName{Namespace: config.Namespace, Name: config.SecretName}
+	for attempt := 0; ; attempt++ {
+		var secret corev1.Secret
+		err := c.Get(ctx, key, &secret)
+		if apierrors.IsNotFound(err) {
+			secret = corev1.Secret{
+				ObjectMeta: metav1.ObjectMeta{
+					Name:      key.Name,
+					Namespace: key.Namespace,
+					Labels:    map[string]string{ManagedByLabel: "kubeuser"},
+				},
+				Type: corev1.SecretTypeTLS,
+			}
+			if secret.Data, err = generate(config, nil, now); err != nil {
+				return nil, err
+			}
+			err = c.Create(ctx, &secret)
+			if apierrors.IsAlreadyExists(err) && attempt == 0 {
+				continue
+			} else if err != nil {
+				return nil, fmt.Errorf("failed to create webhook certificate Secret %s: %w", key, err)
+			}
+			logf.FromContext(ctx).Info("Generated webhook certificate", "secret", key)
+			return &secret, nil
+		} else if err != nil {
+			return nil, fmt.Errorf("failed to read webhook certificate Secret %s: %w", key, err)
+		}
+
+		if secret.Labels[ManagedByLabel] != "kubeuser" || !needsRenewal(config, &secret, now) {
+			return &secret, nil
+		}
+		if secret.Data, err = generate(config, secret.Data, now); err != nil {
+			return nil, err
+		}
+		err = c.Update(ctx, &secret)
+		if apierrors.IsConflict(err) && attempt == 0 {
+			continue
+		} else if err != nil {
+			return nil, fmt.Errorf("failed to renew webhook certificate Secret %s: %w", key, err)
+		}
+		logf.FromContext(ctx).Info("Renewed webhook certificate", "secret", key)
+		return &secret, nil
+	}
+}
+
+// needsRenewal reports whether the serving certificate in secret is unusable for the webhook
+// Service or expires soon
+func needsRenewal(config Config, secret *corev1.Secret, now time.Time) bool {
+	serving, err := parseCertificate(secret.Data[corev1.TLSCertKey])
+	if err != nil || serving.VerifyHostname(config.ServiceHost()) != nil {
+		return true
+	}
+	return now.Add(config.renewBefore()).After(serving.NotAfter)
+}
+
+// generate returns the data of a Secret holding a serving certificate for the webhook Service.
+// The CA in previous signs it while it is valid for long enough; else a new CA is generated.
+func generate(config Config, previous map[string][]byte, now time.Time) (map[string][]byte, error) {
+	ca, caKey, err := loadCA(previous)
+	if err != nil || now.Add(CARenewBefore).After(ca.NotAfter) {
+		if ca, caKey, err = generateCA(config, now); err != nil {
+			return nil, err
+		}
+	}
+
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		return nil, fmt.Errorf("failed to generate webhook serving key: %w", err)
+	}
+	serial, err := serialNumber()
+	if err != nil {
+		return nil, err
+	}
+	template := &x509.Certificate{
+		SerialNumber: serial,
+		Subject:      pkix.Name{CommonName: config.ServiceHost()},
+		DNSNames:     config.DNSNames(),
+		NotBefore:    now.Add(-5 * time.Minute),
+		NotAfter:     now.Add(config.validity()),
+		KeyUsage:     x509.KeyUsageDigitalSignature,
+		ExtKeyUsage:  []x509.ExtKeyUsage{x509.ExtKeyUsageServerAuth},
+	}
+	der, err := x509.CreateCertificate(rand.Reader, template, ca, &key.PublicKey, caKey)
+	if err != nil {
+		return nil, fmt.Errorf("failed to sign webhook serving certificate: %w", err)
+	}
+	keyDER, err := x509.MarshalECPrivateKey(key)
+	if err != nil {
+		return nil, err
+	}
+	caKeyDER, err := x509.MarshalECPrivateKey(caKey)
+	if err != nil {
+		return nil, err
+	}
+	return map[string][]byte{
+		corev1.TLSCertKey:       pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: der}),
+		corev1.TLSPrivateKeyKey: pem.EncodeToMemory(&pem.Block{Type: "EC PRIVATE KEY", Bytes: keyDER}),
+		CAKey:                   pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: ca.Raw}),
+		CAPrivateKey:            pem.EncodeToMemory(&pem.Block{Type: "EC PRIVATE KEY", Bytes: caKeyDER}),
+	}, nil
+}
+
+func generateCA(config Config, now time.Time) (*x509.Certificate, *ecdsa.PrivateKey, error) {
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		return nil, nil, fmt.Errorf("failed to generate webhook CA key: %w", err)
+	}
+	serial, err := serialNumber()
+	if err != nil {
+		return nil, nil, err
+	}
+	template := &x509.Certificate{
+		SerialNumber:          serial,
+		Subject:               pkix.Name{CommonName: config.ServiceName + "-ca"},
+		NotBefore:             now.Add(-5 * time.Minute),
+		NotAfter:              now.Add(CAValidity),
+		KeyUsage:              x509.KeyUsageCertSign | x509.KeyUsageDigitalSignature,
+		BasicConstraintsValid: true,
+		IsCA:                  true,
+	}
+	der, err := x509.CreateCertificate(rand.Reader, template, template, &key.PublicKey, key)
+	if err != nil {
+		return nil, nil, fmt.Errorf("failed to self-sign webhook CA: %w", err)
+	}
+	ca, err := x509.ParseCertificate(der)
+	if err != nil {
+		return nil, nil, err
+	}
+	return ca, key, nil
+}
+
+// loadCA returns the CA and its key from the data of a generated Secret
+func loadCA(data map[string][]byte) (*x509.Certificate, *ecdsa.PrivateKey, error) {
+	ca, err := parseCertificate(data[CAKey])
+	if err != nil {
+		return nil, nil, err
+	}
+	block, _ := pem.Decode(data[CAPrivateKey])
+	if block == nil {
+		return nil, nil, errors.New("no CA key")
+	}
+	key, err := x509.ParseECPrivateKey(block.Bytes)
+	if err != nil {
+		return nil, nil, err
+	}
+	return ca, key, nil
+}
+
+func parseCertificate(data []byte) (*x509.Certificate, error) {
+	block, _ := pem.Decode(data)
+	if block == nil || block.Type != "CERTIFICATE" {
+		return nil, errors.New("no PEM certificate")
+	}
+	return x509.ParseCertificate(block.Bytes)
+}
+
+func serialNumber() (*big.Int, error) {
+	serial, err := rand.Int(rand.Reader, new(big.Int).Lsh(big.NewInt(1), 128))
+	if err != nil {
+		return nil, fmt.Errorf("failed to generate serial number: %w", err)
+	}
+	return serial, nil
+}
+
+// WriteFiles writes the serving keypair and CA of secret to config.CertDir, where the webhook
+// server reloads them from, and reports whether any file changed. Files are replaced by
+// renaming, so the server never reads a partly written keypair.
+func WriteFiles(config Config, secret *corev1.Secret) (bool, error) {
+	if len(secret.Data[corev1.TLSCertKey]) == 0 || len(secret.Data[corev1.TLSPrivateKeyKey]) == 0 {
+		return false, fmt.Errorf("secret %s/%s holds no serving keypair", secret.Namespace, secret.Name)
+	}
+	changed := false
+	// The key goes first, so a certificate is never paired with an older key for long
+	for _, file := range []struct {
+		name string
+		data []byte
+	}{
+		{config.KeyName, secret.Data[corev1.TLSPrivateKeyKey]},
+		{config.CertName, secret.Data[corev1.TLSCertKey]},
+		{CAKey, CABundle(secret)},
+	} {
+		path := filepath.Join(config.CertDir, file.name)
+		if current, err := os.ReadFile(path); err == nil && bytes.Equal(current, file.data) {
+			continue
+		}
+		tmp := path + ".tmp"
+		if err := os.WriteFile(tmp, file.data, 0o600); err != nil {
+			return changed, fmt.Errorf("failed to write %s: %w", path, err)
+		}
+		if err := os.Rename(tmp, path); err != nil {
+			return changed, fmt.Errorf("failed to write %s: %w", path, err)
+		}
+		changed = true
+	}
+	return changed, nil
+}
+
+// SecretSyncer keeps the certificate directory of its replica equal to the Secret, renewing it
+// before it expires. It runs on every replica, since each serves webhooks from its own
+// directory; the replica whose write of a renewal reaches the API server first wins.
+type SecretSyncer struct {
+	Client client.Client
+	Config Config
+	// Interval defaults to DefaultSyncInterval
+	Interval time.Duration
+}
+
+// NeedLeaderElection implements manager.LeaderElectionRunnable
+func (s *SecretSyncer) NeedLeaderElection() bool {
+	return false
+}
+
+// Start implements manager.Runnable; it syncs until ctx is done
+func (s *SecretSyncer) Start(ctx context.Context) error {
+	logger := logf.FromContext(ctx).WithName("webhook-certs")
+	interval := s.Interval
+	if interval <= 0 {
+		interval = DefaultSyncInterval
+	}
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+	for {
+		secret, err := EnsureSecret(ctx, s.Client, s.Config, time.Now())
+		if err == nil {
+			var changed bool
+			if changed, err = WriteFiles(s.Config, secret); changed {
+				logger.Info("Updated webhook certificate files", "certDir", s.Config.CertDir)
+			}
+		}
+		if err != nil {
+			logger.Error(err, "Failed to sync webhook certificate", "secret", s.Config.SecretName)
+		}
+		select {
+		case <-ctx.Done():
+			return nil
+		case <-ticker.C:
+		}
+	}
+}
@@ -0,0 +1,130 @@
+/*
+Copyright 2025.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package certs
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"time"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	clientgoscheme "k8s.io/client-go/kubernetes/scheme"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+)
+
+var _ = Describe("Webhook certificate Secret", func() {
+	var (
+		ctx    context.Context
+		c      client.Client
+		config Config
+		now    time.Time
+	)
+
+	BeforeEach(func() {
+		ctx = context.Background()
+		now = time.Date(2025, 5, 10, 12, 0, 0, 0, time.UTC)
+		scheme := runtime.NewScheme()
+		Expect(clientgoscheme.AddToScheme(scheme)).To(Succeed())
+		c = fake.NewClientBuilder().WithScheme(scheme).Build()
+		config = Config{
+			CertDir:     GinkgoT().TempDir(),
+			CertName:    "tls.crt",
+			KeyName:     "tls.key",
+			ServiceName: "kubeuser-webhook-service",
+			Namespace:   "kubeuser",
+			SecretName:  "kubeuser-webhook-certs",
+		}
+	})
+
+	It("generates a CA and serving certificate the webhook server accepts", func() {
+		secret, err := EnsureSecret(ctx, c, config, now)
+		Expect(err).NotTo(HaveOccurred())
+		Expect(secret.Labels).To(HaveKeyWithValue(ManagedByLabel, "kubeuser"))
+
+		changed, err := WriteFiles(config, secret)
+		Expect(err).NotTo(HaveOccurred())
+		Expect(changed).To(BeTrue())
+		Expect(config.validateKeyPair()).To(Succeed())
+		caFile, err := os.ReadFile(filepath.Join(config.CertDir, CAKey))
+		Expect(err).NotTo(HaveOccurred())
+		Expect(caFile).To(Equal(secret.Data[CAKey]))
+
+		changed, err = WriteFiles(config, secret)
+		Expect(err).NotTo(HaveOccurred())
+		Expect(changed).To(BeFalse())
+	})
+
+	It("keeps a valid certificate, so every replica serves the same one", func() {
+		first, err := EnsureSecret(ctx, c, config, now)
+		Expect(err).NotTo(HaveOccurred())
+		second, err := EnsureSecret(ctx, c, config, now.Add(24*time.Hour))
+		Expect(err).NotTo(HaveOccurred())
+		Expect(second.Data).To(Equal(first.Data))
+	})
+
+	It("renews the serving certificate before it expires with the same CA", func() {
+		first, err := EnsureSecret(ctx, c, config, now)
+		Expect(err).NotTo(HaveOccurred())
+		renewed, err := EnsureSecret(ctx, c, config, now.Add(DefaultServingValidity-DefaultServingRenewBefore+time.Hour))
+		Expect(err).NotTo(HaveOccurred())
+		Expect(renewed.Data[corev1.TLSCertKey]).NotTo(Equal(first.Data[corev1.TLSCertKey]))
+		Expect(renewed.Data[CAKey]).To(Equal(first.Data[CAKey]))
+	})
+
+	It("does not renew Secrets it did not generate", func() {
+		foreign := &corev1.Secret{
+			ObjectMeta: metav1.ObjectMeta{Namespace: config.Namespace, Name: config.SecretName},
+			Data:       map[string][]byte{corev1.TLSCertKey: []byte("cert"), corev1.TLSPrivateKeyKey: []byte("key")},
+		}
+		Expect(c.Create(ctx, foreign)).To(Succeed())
+		secret, err := EnsureSecret(ctx, c, config, now)
+		Expect(err).NotTo(HaveOccurred())
+		Expect(secret.Data).To(Equal(foreign.Data))
+	})
+
+	It("issues certificates of the configured validity", func() {
+		config.Validity, config.RenewBefore = 24*time.Hour, 6*time.Hour
+		Expect(config.ValidateSettings()).To(Succeed())
+		first, err := EnsureSecret(ctx, c, config, now)
+		Expect(err).NotTo(HaveOccurred())
+		serving, err := parseCertificate(first.Data[corev1.TLSCertKey])
+		Expect(err).NotTo(HaveOccurred())
+		Expect(serving.NotAfter).To(Equal(now.Add(24 * time.Hour)))
+
+		renewed, err := EnsureSecret(ctx, c, config, now.Add(19*time.Hour))
+		Expect(err).NotTo(HaveOccurred())
+		Expect(renewed.Data[corev1.TLSCertKey]).NotTo(Equal(first.Data[corev1.TLSCertKey]))
+	})
+
+	It("rejects validities outside the sane range", func() {
+		config.Validity = time.Hour
+		Expect(config.ValidateSettings()).To(MatchError(ContainSubstring("must be between 24h0m0s")))
+		config.Validity, config.RenewBefore = 48*time.Hour, 48*time.Hour
+		Expect(config.ValidateSettings()).To(MatchError(ContainSubstring("must be shorter than its validity")))
+	})
+
+	It("rejects invalid Secret names", func() {
+		config.SecretName = "Webhook_Certs"
+		Expect(config.ValidateSettings()).To(MatchError(ContainSubstring("invalid webhook certificate Secret name")))
+	})
+})
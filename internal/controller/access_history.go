@@ -0,0 +1,105 @@
+/*
+Copyright 2025.
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+*/
+
+package controller
+
+import (
+	"context"
+	"time"
+
+	rbacv1 "k8s.io/api/rbac/v1"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	logf "sigs.k8s.io/controller-runtime/pkg/log"
+
+	authv1alpha1 "github.com/openkube-hub/KubeUser/api/v1alpha1"
+	"github.com/openkube-hub/KubeUser/internal/history"
+	"github.com/openkube-hub/KubeUser/internal/inventory"
+)
+
+// grantActions maps binding changes to the actions of the access grant history
+var grantActions = map[authv1alpha1.BindingAction]authv1alpha1.AccessGrantAction{
+	authv1alpha1.BindingActionCreate: authv1alpha1.AccessGrantGranted,
+	authv1alpha1.BindingActionUpdate: authv1alpha1.AccessGrantChanged,
+	authv1alpha1.BindingActionDelete: authv1alpha1.AccessGrantRemoved,
+}
+
+// recordBindingChange adds a binding the controller created, updated or deleted for user to the
+// access grant history, with the user's current certificate
+func (r *UserReconciler) recordBindingChange(ctx context.Context, user *authv1alpha1.User,
+	action authv1alpha1.BindingAction, obj client.Object, roleRef rbacv1.RoleRef) error {
+	serial, err := inventory.CurrentSerial(ctx, r.Client, user.Name)
+	if err != nil {
+		return err
+	}
+	record := authv1alpha1.AccessGrantRecordSpec{
+		Action: grantActions[action],
+		Binding: &authv1alpha1.GrantedBinding{
+			Kind:      bindingKind(obj),
+			Namespace: obj.GetNamespace(),
+			Name:      obj.GetName(),
+			RoleKind:  roleRef.Kind,
+			RoleName:  roleRef.Name,
+		},
+		SerialNumber: serial,
+	}
+	if action == authv1alpha1.BindingActionDelete {
+		record.Reason = removalReason(user)
+	}
+	return history.Record(ctx, r.Client, user, record, time.Now())
+}
+
+// recordRemovedOnDeletion adds a binding deleted with the user to the access grant history.
+// Cleanup goes on when that fails.
+func (r *UserReconciler) recordRemovedOnDeletion(ctx context.Context, user *authv1alpha1.User, obj client.Object,
+	roleRef rbacv1.RoleRef) {
+	if err := r.recordBindingChange(ctx, user, authv1alpha1.BindingActionDelete, obj, roleRef); err != nil {
+		logf.FromContext(ctx).Error(err, "Failed to record removed binding", "binding", obj.GetName())
+	}
+}
+
+// recordCredentialIssued adds the certificate issued for user to the access grant history
+func (r *UserReconciler) recordCredentialIssued(ctx context.Context, user *authv1alpha1.User, certPEM []byte) error {
+	serial, err := inventory.SerialNumber(certPEM)
+	if err != nil {
+		return err
+	}
+	return history.Record(ctx, r.Client, user, authv1alpha1.AccessGrantRecordSpec{
+		Action:       authv1alpha1.AccessGrantCredentialIssued,
+		SerialNumber: serial,
+	}, time.Now())
+}
+
+// recordCredentialsRevoked adds the revocation of user's credentials to the access grant
+// history; by is who revoked them, if known
+func (r *UserReconciler) recordCredentialsRevoked(ctx context.Context, user *authv1alpha1.User, by string) error {
+	return history.Record(ctx, r.Client, user, authv1alpha1.AccessGrantRecordSpec{
+		Action:    authv1alpha1.AccessGrantCredentialsRevoked,
+		ChangedBy: by,
+		Reason:    removalReason(user),
+	}, time.Now())
+}
+
+// removalReason explains why the user's bindings are removed or credentials revoked, or is
+// empty when the user's roles changed
+func removalReason(user *authv1alpha1.User) string {
+	switch {
+	case !user.DeletionTimestamp.IsZero():
+		return history.ReasonUserDeleted
+	case user.Spec.Revoked:
+		return history.ReasonUserRevoked
+	case user.Spec.Suspended:
+		return history.ReasonUserSuspended
+	}
+	return ""
+}
+
+// bindingKind returns the kind of a binding the controller manages
+func bindingKind(obj client.Object) string {
+	if _, ok := obj.(*rbacv1.RoleBinding); ok {
+		return "RoleBinding"
+	}
+	return "ClusterRoleBinding"
+}
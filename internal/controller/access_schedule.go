@@ -0,0 +1,86 @@
+/*
+Copyright 2025.
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+*/
+
+package controller
+
+import (
+	"fmt"
+	"time"
+
+	"k8s.io/apimachinery/pkg/api/meta"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+
+	authv1alpha1 "github.com/openkube-hub/KubeUser/api/v1alpha1"
+	"github.com/openkube-hub/KubeUser/internal/schedule"
+)
+
+// ConditionAccessWindow is True while a window of the user's access schedule is open and
+// its roles are bound. Users without a schedule do not have it.
+const ConditionAccessWindow = "AccessWindow"
+
+// accessWindow reports whether the user's access schedule allows bindings at now and when
+// that changes. Users without a schedule are always allowed; an invalid one allows nothing.
+func accessWindow(user *authv1alpha1.User, now time.Time) (open bool, change time.Time, err error) {
+	if user.Spec.AccessSchedule == nil {
+		return true, time.Time{}, nil
+	}
+	s, err := schedule.New(user.Spec.AccessSchedule)
+	if err != nil {
+		return false, time.Time{}, err
+	}
+	open, change = s.At(now)
+	return open, change, nil
+}
+
+// inAccessWindow reports whether the user's roles may be bound at now
+func inAccessWindow(user *authv1alpha1.User, now time.Time) bool {
+	open, _, _ := accessWindow(user, now)
+	return open
+}
+
+// untilAccessWindowChange caps a requeue delay so the controller wakes up when the user's
+// access window opens or closes
+func untilAccessWindowChange(user *authv1alpha1.User, now time.Time, delay time.Duration) time.Duration {
+	_, change, err := accessWindow(user, now)
+	if err != nil || change.IsZero() {
+		return delay
+	}
+	if remaining := change.Sub(now); remaining > 0 && remaining < delay {
+		delay = remaining
+	}
+	return delay
+}
+
+// setAccessWindowCondition reports whether the user's access window is open and until when
+func setAccessWindowCondition(user *authv1alpha1.User, now time.Time) {
+	if user.Spec.AccessSchedule == nil {
+		meta.RemoveStatusCondition(&user.Status.Conditions, ConditionAccessWindow)
+		return
+	}
+	condition := metav1.Condition{Type: ConditionAccessWindow, ObservedGeneration: user.Generation}
+	open, change, err := accessWindow(user, now)
+	switch {
+	case err != nil:
+		condition.Status = metav1.ConditionFalse
+		condition.Reason = "InvalidSchedule"
+		condition.Message = fmt.Sprintf("Access schedule is invalid, no roles are bound: %v", err)
+	case open:
+		condition.Status = metav1.ConditionTrue
+		condition.Reason = "WindowOpen"
+		condition.Message = "Access window is open"
+		if !change.IsZero() {
+			condition.Message += " until " + change.UTC().Format(time.RFC3339)
+		}
+	default:
+		condition.Status = metav1.ConditionFalse
+		condition.Reason = "WindowClosed"
+		condition.Message = "Outside of the access schedule, roles are not bound"
+		if !change.IsZero() {
+			condition.Message += " until " + change.UTC().Format(time.RFC3339)
+		}
+	}
+	meta.SetStatusCondition(&user.Status.Conditions, condition)
+}
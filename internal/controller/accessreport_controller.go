@@ -0,0 +1,225 @@
+/*
+Copyright 2025.
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+*/
+
+package controller
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"slices"
+	"strings"
+	"time"
+
+	corev1 "k8s.io/api/core/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/api/meta"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/labels"
+	"k8s.io/apimachinery/pkg/runtime"
+	ctrl "sigs.k8s.io/controller-runtime"
+	"sigs.k8s.io/controller-runtime/pkg/builder"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/controller/controllerutil"
+	logf "sigs.k8s.io/controller-runtime/pkg/log"
+	"sigs.k8s.io/controller-runtime/pkg/predicate"
+
+	authv1alpha1 "github.com/openkube-hub/KubeUser/api/v1alpha1"
+	"github.com/openkube-hub/KubeUser/internal/accessreport"
+	"github.com/openkube-hub/KubeUser/internal/schedule"
+)
+
+const (
+	// DefaultAccessReportHistoryLimit is how many reports are kept unless spec.historyLimit is set
+	DefaultAccessReportHistoryLimit = 12
+
+	// maxAccessReportBytes keeps reports below the size limit of ConfigMaps, with room for metadata
+	maxAccessReportBytes = 1000 * 1024
+)
+
+// AccessReportReconciler writes the reports of AccessReports to ConfigMaps on their schedule
+// and deletes those past the history limit
+type AccessReportReconciler struct {
+	client.Client
+	Scheme *runtime.Scheme
+}
+
+// +kubebuilder:rbac:groups=auth.openkube.io,resources=accessreports,verbs=get;list;watch
+// +kubebuilder:rbac:groups=auth.openkube.io,resources=accessreports/status,verbs=get;update;patch
+
+// Reconcile generates the report when it is due, or right away when the AccessReport is new or
+// its spec changed, and requeues for the next one
+func (r *AccessReportReconciler) Reconcile(ctx context.Context, req ctrl.Request) (ctrl.Result, error) {
+	logger := logf.FromContext(ctx)
+	var report authv1alpha1.AccessReport
+	if err := r.Get(ctx, req.NamespacedName, &report); err != nil {
+		return ctrl.Result{}, client.IgnoreNotFound(err)
+	}
+	if !report.DeletionTimestamp.IsZero() {
+		// The garbage collector deletes the report ConfigMaps
+		return ctrl.Result{}, nil
+	}
+
+	now := time.Now()
+	cron, location, selector, err := parseAccessReport(&report.Spec)
+	if err != nil {
+		report.Status.NextReportTime = nil
+		setAccessReportCondition(&report, metav1.ConditionFalse, "InvalidSpec", err.Error())
+		// Nothing to retry until the spec changes
+		return ctrl.Result{}, r.Status().Update(ctx, &report)
+	}
+
+	due := report.Status.LastReportTime == nil || report.Status.ObservedGeneration != report.Generation
+	if !due {
+		next := cron.Next(report.Status.LastReportTime.In(location))
+		due = !next.IsZero() && !now.Before(next)
+	}
+	var genErr error
+	if due {
+		name, users, err := r.generate(ctx, &report, selector, now)
+		if err != nil {
+			genErr = err
+			setAccessReportCondition(&report, metav1.ConditionFalse, "ReportFailed", err.Error())
+		} else {
+			logger.Info("Generated access report", "report", report.Name, "configMap", name, "users", users)
+			report.Status.LastReport = name
+			report.Status.LastReportTime = &metav1.Time{Time: now}
+			report.Status.Users = int32(users)
+			setAccessReportCondition(&report, metav1.ConditionTrue, "ReportWritten",
+				fmt.Sprintf("Report of %d users written to ConfigMap %s/%s", users, getKubeUserNamespace(), name))
+			if err := r.pruneReports(ctx, &report); err != nil {
+				logger.Error(err, "Failed to delete old access reports", "report", report.Name)
+			}
+		}
+	}
+
+	report.Status.ObservedGeneration = report.Generation
+	report.Status.NextReportTime = nil
+	var requeueAfter time.Duration
+	if next := cron.Next(now.In(location)); !next.IsZero() {
+		report.Status.NextReportTime = &metav1.Time{Time: next}
+		requeueAfter = next.Sub(now)
+	}
+	if err := r.Status().Update(ctx, &report); err != nil {
+		return ctrl.Result{}, errors.Join(genErr, err)
+	}
+	if genErr != nil {
+		return ctrl.Result{}, genErr
+	}
+	return ctrl.Result{RequeueAfter: requeueAfter}, nil
+}
+
+// parseAccessReport checks the schedule, time zone and selector of spec
+func parseAccessReport(spec *authv1alpha1.AccessReportSpec) (*schedule.Cron, *time.Location, labels.Selector, error) {
+	cron, err := schedule.ParseCron(spec.Schedule)
+	if err != nil {
+		return nil, nil, nil, err
+	}
+	location := time.UTC
+	if spec.TimeZone != "" {
+		if location, err = time.LoadLocation(spec.TimeZone); err != nil {
+			return nil, nil, nil, fmt.Errorf("invalid time zone %q: %w", spec.TimeZone, err)
+		}
+	}
+	selector := labels.Everything()
+	if spec.Selector != nil {
+		if selector, err = metav1.LabelSelectorAsSelector(spec.Selector); err != nil {
+			return nil, nil, nil, fmt.Errorf("invalid selector: %w", err)
+		}
+	}
+	return cron, location, selector, nil
+}
+
+// generate writes a new report ConfigMap and returns its name and how many users it covers
+func (r *AccessReportReconciler) generate(ctx context.Context, report *authv1alpha1.AccessReport,
+	selector labels.Selector, now time.Time) (string, int, error) {
+	namespace := getKubeUserNamespace()
+	built, err := accessreport.Build(ctx, r.Client, report.Name, selector, namespace, now)
+	if err != nil {
+		return "", 0, err
+	}
+	formats := report.Spec.Formats
+	if len(formats) == 0 {
+		formats = []authv1alpha1.AccessReportFormat{authv1alpha1.AccessReportJSON}
+	}
+	cm := &corev1.ConfigMap{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      report.Name + "-" + now.UTC().Format("20060102-150405"),
+			Namespace: namespace,
+			Labels: map[string]string{
+				accessreport.ReportLabel:       report.Name,
+				"app.kubernetes.io/managed-by": "kubeuser",
+			},
+		},
+		Data: map[string]string{},
+	}
+	size := 0
+	for _, format := range formats {
+		data, err := accessreport.Encode(built, format)
+		if err != nil {
+			return "", 0, err
+		}
+		size += len(data)
+		cm.Data[accessreport.FileName(format)] = string(data)
+	}
+	if size > maxAccessReportBytes {
+		return "", 0, fmt.Errorf("report of %d users is %d bytes, more than a ConfigMap holds; "+
+			"narrow spec.selector or write fewer formats", len(built.Users), size)
+	}
+	if err := controllerutil.SetControllerReference(report, cm, r.Scheme); err != nil {
+		return "", 0, err
+	}
+	if err := ensureNamespace(ctx, r.Client, namespace); err != nil {
+		return "", 0, err
+	}
+	if err := r.Create(ctx, cm); err != nil && !apierrors.IsAlreadyExists(err) {
+		return "", 0, fmt.Errorf("failed to write report ConfigMap: %w", err)
+	}
+	return cm.Name, len(built.Users), nil
+}
+
+// pruneReports deletes the oldest report ConfigMaps beyond the history limit
+func (r *AccessReportReconciler) pruneReports(ctx context.Context, report *authv1alpha1.AccessReport) error {
+	limit := DefaultAccessReportHistoryLimit
+	if report.Spec.HistoryLimit != nil {
+		limit = int(*report.Spec.HistoryLimit)
+	}
+	var cms corev1.ConfigMapList
+	if err := r.List(ctx, &cms, client.InNamespace(getKubeUserNamespace()),
+		client.MatchingLabels{accessreport.ReportLabel: report.Name}); err != nil {
+		return err
+	}
+	if len(cms.Items) <= limit {
+		return nil
+	}
+	// Names end in the generation time, so they sort oldest first
+	slices.SortFunc(cms.Items, func(a, b corev1.ConfigMap) int { return strings.Compare(a.Name, b.Name) })
+	var errs []error
+	for i := range cms.Items[:len(cms.Items)-limit] {
+		if err := r.Delete(ctx, &cms.Items[i]); err != nil && !apierrors.IsNotFound(err) {
+			errs = append(errs, err)
+		}
+	}
+	return errors.Join(errs...)
+}
+
+func setAccessReportCondition(report *authv1alpha1.AccessReport, status metav1.ConditionStatus, reason, message string) {
+	meta.SetStatusCondition(&report.Status.Conditions, metav1.Condition{
+		Type:               "Ready",
+		Status:             status,
+		Reason:             reason,
+		Message:            message,
+		ObservedGeneration: report.Generation,
+	})
+}
+
+// SetupWithManager wires the controller
+func (r *AccessReportReconciler) SetupWithManager(mgr ctrl.Manager) error {
+	return ctrl.NewControllerManagedBy(mgr).
+		For(&authv1alpha1.AccessReport{}, builder.WithPredicates(predicate.GenerationChangedPredicate{})).
+		Named("accessreport").
+		Complete(r)
+}
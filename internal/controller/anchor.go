@@ -0,0 +1,123 @@
+/*
+Copyright 2025.
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+*/
+
+package controller
+
+import (
+	"context"
+
+	corev1 "k8s.io/api/core/v1"
+	rbacv1 "k8s.io/api/rbac/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/types"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	logf "sigs.k8s.io/controller-runtime/pkg/log"
+
+	authv1alpha1 "github.com/openkube-hub/KubeUser/api/v1alpha1"
+	"github.com/openkube-hub/KubeUser/internal/operatorconfig"
+)
+
+// The ServiceAccount anchor is a per-user ServiceAccount named after the user in the KubeUser
+// namespace. It is bound alongside the certificate identity so that short-lived tokens can be
+// requested for it through the TokenRequest API (kubectl kubeuser token).
+//
+// The anchor is optional. Without it the bindings name only the certificate identity, and they
+// are owned by and garbage-collected with the User through their owner references and the
+// finalizer cleanup, as are the credential Secrets. Disabling the anchor on a running user deletes
+// its ServiceAccount and drops it from the bindings.
+
+// serviceAccountAnchorEnabled returns whether the user gets an anchor: machine users always do,
+// otherwise spec wins over the operator default
+func (r *UserReconciler) serviceAccountAnchorEnabled(user *authv1alpha1.User) bool {
+	if isMachine(user) {
+		return true
+	}
+	if user.Spec.ServiceAccountAnchor != nil {
+		return *user.Spec.ServiceAccountAnchor
+	}
+	return r.ServiceAccountAnchor
+}
+
+// userSubjects returns the binding subjects for a user: the certificate identity, named by the
+// username template, and the one of its current certificate while that differs, the Google
+// account GKE authenticates the user as and, when enabled, its ServiceAccount anchor. Machine
+// users have no certificate identity.
+func (r *UserReconciler) userSubjects(user *authv1alpha1.User) []rbacv1.Subject {
+	anchor := rbacv1.Subject{Kind: "ServiceAccount", Name: user.Name, Namespace: getKubeUserNamespace()}
+	if isMachine(user) {
+		return []rbacv1.Subject{anchor}
+	}
+	username := operatorconfig.Current().Username(user.Name)
+	subjects := []rbacv1.Subject{{Kind: "User", Name: username}}
+	if previous := user.Status.Username; previous != "" && previous != username {
+		// The certificate issued under an earlier username template keeps its access until
+		// the certificate for the new username replaces it
+		subjects = append(subjects, rbacv1.Subject{Kind: "User", Name: previous})
+	}
+	if user.Spec.GCP != nil {
+		subjects = append(subjects, rbacv1.Subject{Kind: "User", Name: user.Spec.GCP.Account})
+	}
+	if r.serviceAccountAnchorEnabled(user) {
+		subjects = append(subjects, anchor)
+	}
+	return subjects
+}
+
+// subjectsMatch compares subjects in order, which is stable because the controller generates them
+func subjectsMatch(existing, desired []rbacv1.Subject) bool {
+	if len(existing) != len(desired) {
+		return false
+	}
+	for i := range desired {
+		if existing[i].Kind != desired[i].Kind || existing[i].Name != desired[i].Name ||
+			existing[i].Namespace != desired[i].Namespace {
+			return false
+		}
+	}
+	return true
+}
+
+// ensureServiceAccountAnchor creates the user's ServiceAccount anchor if it does not exist, or
+// deletes it when the anchor is disabled
+func (r *UserReconciler) ensureServiceAccountAnchor(ctx context.Context, user *authv1alpha1.User) error {
+	var sa corev1.ServiceAccount
+	key := types.NamespacedName{Name: user.Name, Namespace: getKubeUserNamespace()}
+	err := r.Get(ctx, key, &sa)
+	if !r.serviceAccountAnchorEnabled(user) {
+		if err != nil {
+			return client.IgnoreNotFound(err)
+		}
+		// Only remove a ServiceAccount the controller created
+		if sa.Labels["auth.openkube.io/user"] != user.Name {
+			return nil
+		}
+		logf.FromContext(ctx).Info("Deleting disabled ServiceAccount anchor", "name", key.Name, "namespace", key.Namespace)
+		return client.IgnoreNotFound(r.Delete(ctx, &sa))
+	}
+	if err == nil || !apierrors.IsNotFound(err) {
+		return err
+	}
+
+	logf.FromContext(ctx).Info("Creating ServiceAccount anchor", "name", key.Name, "namespace", key.Namespace)
+	sa = corev1.ServiceAccount{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      key.Name,
+			Namespace: key.Namespace,
+			Labels:    map[string]string{"auth.openkube.io/user": user.Name},
+			OwnerReferences: []metav1.OwnerReference{{
+				APIVersion: "auth.openkube.io/v1alpha1",
+				Kind:       "User",
+				Name:       user.Name,
+				UID:        user.UID,
+				Controller: &[]bool{true}[0],
+			}},
+		},
+		// Tokens are only ever requested explicitly
+		AutomountServiceAccountToken: &[]bool{false}[0],
+	}
+	return r.Create(ctx, &sa)
+}
@@ -0,0 +1,71 @@
+/*
+Copyright 2025.
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+*/
+
+package controller
+
+import (
+	"fmt"
+	"sort"
+
+	rbacv1 "k8s.io/api/rbac/v1"
+
+	authv1alpha1 "github.com/openkube-hub/KubeUser/api/v1alpha1"
+)
+
+// recordBinding adds a binding the user is to have to status.bindings
+func recordBinding(user *authv1alpha1.User, kind, namespace, name string, roleRef rbacv1.RoleRef,
+	state authv1alpha1.BindingState, err error) {
+	entry := authv1alpha1.BindingStatus{
+		Kind:      kind,
+		Namespace: namespace,
+		Name:      name,
+		RoleRef:   roleRef.Kind + "/" + roleRef.Name,
+		State:     state,
+	}
+	if err != nil {
+		entry.LastError = err.Error()
+	}
+	user.Status.Bindings = append(user.Status.Bindings, entry)
+}
+
+// sortBindings orders status.bindings like the planned bindings of report-only mode
+func sortBindings(user *authv1alpha1.User) {
+	bindings := user.Status.Bindings
+	sort.Slice(bindings, func(i, j int) bool {
+		a, b := bindings[i], bindings[j]
+		if a.Kind != b.Kind {
+			return a.Kind < b.Kind
+		}
+		if a.Namespace != b.Namespace {
+			return a.Namespace < b.Namespace
+		}
+		return a.Name < b.Name
+	})
+}
+
+// bindingFailureMessage is the status message of a user whose bindings could not all be
+// reconciled
+func bindingFailureMessage(user *authv1alpha1.User, err error) string {
+	failed := 0
+	for _, binding := range user.Status.Bindings {
+		if binding.LastError != "" {
+			failed++
+		}
+	}
+	if failed == 0 {
+		return fmt.Sprintf("Failed to reconcile bindings: %v", err)
+	}
+	return fmt.Sprintf("%d of %d bindings are not bound; see status.bindings", failed, len(user.Status.Bindings))
+}
+
+// bindingStateApplied is the state of a binding that was just created or updated, or would
+// have been in report-only mode
+func bindingStateApplied(plan *bindingPlan) authv1alpha1.BindingState {
+	if plan != nil {
+		return authv1alpha1.BindingStatePending
+	}
+	return authv1alpha1.BindingStateBound
+}
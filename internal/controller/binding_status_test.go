@@ -0,0 +1,97 @@
+/*
+Copyright 2025.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package controller
+
+import (
+	"time"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+	rbacv1 "k8s.io/api/rbac/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/types"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+
+	authv1alpha1 "github.com/openkube-hub/KubeUser/api/v1alpha1"
+)
+
+var _ = Describe("Binding status", func() {
+	const name = "binding-status-user"
+	var (
+		r              *UserReconciler
+		reader, writer *rbacv1.ClusterRole
+	)
+
+	// user returns the user as stored
+	user := func(g Gomega) *authv1alpha1.User {
+		var user authv1alpha1.User
+		g.Expect(k8sClient.Get(ctx, types.NamespacedName{Name: name}, &user)).To(Succeed())
+		return &user
+	}
+
+	BeforeEach(func() {
+		r = newCachedReconciler(&pendingIssuer{})
+		rules := []rbacv1.PolicyRule{{APIGroups: []string{""}, Resources: []string{"pods"}, Verbs: []string{"get"}}}
+		reader = &rbacv1.ClusterRole{ObjectMeta: metav1.ObjectMeta{Name: "binding-status-reader"}, Rules: rules}
+		// The writer ClusterRole is created by the spec
+		writer = &rbacv1.ClusterRole{ObjectMeta: metav1.ObjectMeta{Name: "binding-status-writer"}, Rules: rules}
+		Expect(k8sClient.Create(ctx, reader)).To(Succeed())
+		Expect(k8sClient.Create(ctx, &authv1alpha1.User{
+			ObjectMeta: metav1.ObjectMeta{Name: name},
+			Spec: authv1alpha1.UserSpec{ClusterRoles: []authv1alpha1.ClusterRoleSpec{
+				{ExistingClusterRole: reader.Name},
+				{ExistingClusterRole: writer.Name},
+			}},
+		})).To(Succeed())
+	})
+
+	AfterEach(func() {
+		deleteUser(r, name)
+		Expect(k8sClient.Delete(ctx, reader)).To(Succeed())
+		Expect(client.IgnoreNotFound(k8sClient.Delete(ctx, writer))).To(Succeed())
+	})
+
+	It("reports each binding, binding the others while one cannot be bound", func() {
+		Eventually(func(g Gomega) {
+			g.Expect(reconcileCached(g, r, name)).To(MatchError(ContainSubstring("clusterrole binding-status-writer not found")))
+			stored := user(g)
+			g.Expect(stored.Status.Phase).To(Equal(PhaseError))
+			g.Expect(stored.Status.Message).To(Equal("1 of 2 bindings are not bound; see status.bindings"))
+			g.Expect(stored.Status.Bindings).To(ConsistOf(
+				authv1alpha1.BindingStatus{Kind: "ClusterRoleBinding", Name: clusterRoleBindingName(name, reader.Name),
+					RoleRef: "ClusterRole/" + reader.Name, State: authv1alpha1.BindingStateBound},
+				authv1alpha1.BindingStatus{Kind: "ClusterRoleBinding", Name: clusterRoleBindingName(name, writer.Name),
+					RoleRef: "ClusterRole/" + writer.Name, State: authv1alpha1.BindingStatePending,
+					LastError: "clusterrole binding-status-writer not found"},
+			))
+		}, 10*time.Second).Should(Succeed())
+		expectExists(Default, &rbacv1.ClusterRoleBinding{ObjectMeta: metav1.ObjectMeta{
+			Name: clusterRoleBindingName(name, reader.Name)}}, true)
+
+		By("creating the missing ClusterRole")
+		Expect(k8sClient.Create(ctx, writer)).To(Succeed())
+		reconcileUntil(r, name, func(g Gomega) {
+			stored := user(g)
+			g.Expect(stored.Status.Phase).NotTo(Equal(PhaseError))
+			g.Expect(stored.Status.Bindings).To(HaveLen(2))
+			g.Expect(stored.Status.Bindings).To(HaveEach(And(
+				HaveField("State", authv1alpha1.BindingStateBound),
+				HaveField("LastError", BeEmpty()),
+			)))
+		})
+	})
+})
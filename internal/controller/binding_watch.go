@@ -0,0 +1,48 @@
+/*
+Copyright 2025.
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+*/
+
+package controller
+
+import (
+	"context"
+
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/types"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/reconcile"
+
+	authv1alpha1 "github.com/openkube-hub/KubeUser/api/v1alpha1"
+)
+
+// usersForBinding maps a RoleBinding or ClusterRoleBinding event to a reconcile of the User it
+// was created for, so that a deleted or altered binding is restored right away. The user label
+// and the controller reference are both followed, since tampering may remove either one;
+// updates are mapped for the old and the new object, so removing both is still seen.
+func usersForBinding(_ context.Context, obj client.Object) []reconcile.Request {
+	names := map[string]bool{}
+	if name := obj.GetLabels()["auth.openkube.io/user"]; name != "" {
+		names[name] = true
+	}
+	if owner := metav1.GetControllerOf(obj); owner != nil && owner.Kind == "User" &&
+		owner.APIVersion == authv1alpha1.GroupVersion.String() {
+		names[owner.Name] = true
+	}
+	requests := make([]reconcile.Request, 0, len(names))
+	for name := range names {
+		requests = append(requests, reconcile.Request{NamespacedName: types.NamespacedName{Name: name}})
+	}
+	return requests
+}
+
+// bindingMetadataMatches reports whether existing still carries the user label and controller
+// reference of desired; bindings that lost either are re-applied
+func bindingMetadataMatches(existing, desired metav1.Object) bool {
+	if existing.GetLabels()["auth.openkube.io/user"] != desired.GetLabels()["auth.openkube.io/user"] {
+		return false
+	}
+	owner, wanted := metav1.GetControllerOf(existing), metav1.GetControllerOf(desired)
+	return owner != nil && wanted != nil && owner.UID == wanted.UID
+}
@@ -0,0 +1,118 @@
+/*
+Copyright 2025.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package controller
+
+import (
+	"context"
+	"time"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+	rbacv1 "k8s.io/api/rbac/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/kubernetes/scheme"
+	"k8s.io/client-go/util/workqueue"
+	ctrl "sigs.k8s.io/controller-runtime"
+	"sigs.k8s.io/controller-runtime/pkg/config"
+	metricsserver "sigs.k8s.io/controller-runtime/pkg/metrics/server"
+	"sigs.k8s.io/controller-runtime/pkg/reconcile"
+
+	authv1alpha1 "github.com/openkube-hub/KubeUser/api/v1alpha1"
+)
+
+// startUserController runs r in a manager of its own until the spec ends. Requeues and retries
+// are put off for an hour, so whatever it reconciles during the spec was enqueued by a watch.
+func startUserController(r *UserReconciler) {
+	GinkgoHelper()
+	// Each spec registers the user controller again
+	skipNameValidation := true
+	mgr, err := ctrl.NewManager(cfg, ctrl.Options{
+		Scheme:     scheme.Scheme,
+		Metrics:    metricsserver.Options{BindAddress: "0"},
+		Controller: config.Controller{SkipNameValidation: &skipNameValidation},
+	})
+	Expect(err).NotTo(HaveOccurred())
+	Expect(SetupIndexes(ctx, mgr.GetFieldIndexer())).To(Succeed())
+	r.Client, r.APIReader, r.Scheme = mgr.GetClient(), mgr.GetAPIReader(), mgr.GetScheme()
+	r.RetryBaseDelay, r.RetryMaxDelay, r.RenewalCheckInterval = time.Hour, time.Hour, time.Hour
+	r.RateLimiter = workqueue.NewTypedItemExponentialFailureRateLimiter[reconcile.Request](time.Hour, time.Hour)
+	Expect(r.SetupWithManager(mgr)).To(Succeed())
+
+	mgrCtx, stop := context.WithCancel(ctx)
+	stopped := make(chan struct{})
+	go func() {
+		defer GinkgoRecover()
+		defer close(stopped)
+		Expect(mgr.Start(mgrCtx)).To(Succeed())
+	}()
+	DeferCleanup(func() {
+		stop()
+		<-stopped
+	})
+}
+
+var _ = Describe("Binding watches", func() {
+	const name = "watched-user"
+	var (
+		r           *UserReconciler
+		clusterRole *rbacv1.ClusterRole
+		binding     *rbacv1.ClusterRoleBinding
+	)
+
+	BeforeEach(func() {
+		r = &UserReconciler{Issuer: &pendingIssuer{}, ProxyServer: "https://kubeuser-proxy.example.org", ProxyCA: []byte("ca")}
+		clusterRole = &rbacv1.ClusterRole{
+			ObjectMeta: metav1.ObjectMeta{Name: "watched-user-reader"},
+			Rules:      []rbacv1.PolicyRule{{APIGroups: []string{""}, Resources: []string{"pods"}, Verbs: []string{"get"}}},
+		}
+		Expect(k8sClient.Create(ctx, clusterRole)).To(Succeed())
+		Expect(k8sClient.Create(ctx, &authv1alpha1.User{
+			ObjectMeta: metav1.ObjectMeta{Name: name},
+			Spec: authv1alpha1.UserSpec{
+				ClusterRoles: []authv1alpha1.ClusterRoleSpec{{ExistingClusterRole: clusterRole.Name}},
+			},
+		})).To(Succeed())
+		binding = &rbacv1.ClusterRoleBinding{ObjectMeta: metav1.ObjectMeta{
+			Name: clusterRoleBindingName(name, clusterRole.Name)}}
+		// Cleanups run last to first, so this one runs once the controller has stopped
+		DeferCleanup(func() {
+			deleteUser(newCachedReconciler(&pendingIssuer{}), name)
+			Expect(k8sClient.Delete(ctx, clusterRole)).To(Succeed())
+		})
+
+		startUserController(r)
+		Eventually(func(g Gomega) { expectExists(g, binding, true) }, 10*time.Second).Should(Succeed())
+	})
+
+	It("restores a deleted binding", func() {
+		uid := binding.UID
+		Expect(k8sClient.Delete(ctx, binding)).To(Succeed())
+		Eventually(func(g Gomega) {
+			expectExists(g, binding, true)
+			g.Expect(binding.UID).NotTo(Equal(uid))
+		}, 10*time.Second).Should(Succeed())
+	})
+
+	It("restores a binding that lost its user label", func() {
+		delete(binding.Labels, "auth.openkube.io/user")
+		Expect(k8sClient.Update(ctx, binding)).To(Succeed())
+		Eventually(func(g Gomega) {
+			expectExists(g, binding, true)
+			g.Expect(binding.Labels).To(HaveKeyWithValue("auth.openkube.io/user", name))
+		}, 10*time.Second).Should(Succeed())
+	})
+})
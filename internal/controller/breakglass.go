@@ -0,0 +1,111 @@
+/*
+Copyright 2025.
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+*/
+
+package controller
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"time"
+
+	corev1 "k8s.io/api/core/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/api/meta"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	logf "sigs.k8s.io/controller-runtime/pkg/log"
+
+	authv1alpha1 "github.com/openkube-hub/KubeUser/api/v1alpha1"
+	"github.com/openkube-hub/KubeUser/internal/operatorconfig"
+)
+
+// ConditionBreakGlass is True while a User has break-glass access
+const ConditionBreakGlass = "BreakGlass"
+
+// reconcileBreakGlass fixes the end of a break-glass User's access on its first reconcile and
+// deletes the User once it has passed; the finalizer then removes its bindings and credentials.
+// It reports whether the User was deleted.
+func (r *UserReconciler) reconcileBreakGlass(ctx context.Context, user *authv1alpha1.User) (bool, error) {
+	if !user.Spec.BreakGlass {
+		return false, nil
+	}
+	logger := logf.FromContext(ctx)
+
+	if user.Status.BreakGlassUntil == nil {
+		until := metav1.NewTime(user.CreationTimestamp.Add(operatorconfig.Current().BreakGlassDuration))
+		user.Status.BreakGlassUntil = &until
+		setBreakGlassCondition(user)
+		logger.Info("Break-glass access granted", "until", until.UTC(), "grants", describeGrants(user))
+		r.event(user, corev1.EventTypeWarning, EventBreakGlassActivated,
+			"Break-glass access granted until %s: %s", until.UTC().Format(time.RFC3339), describeGrants(user))
+	}
+
+	until := user.Status.BreakGlassUntil.Time
+	if time.Now().Before(until) {
+		setBreakGlassCondition(user)
+		return false, nil
+	}
+
+	logger.Info("Break-glass access ended, deleting user", "until", until.UTC())
+	r.event(user, corev1.EventTypeWarning, EventBreakGlassExpired,
+		"Break-glass access ended at %s, deleting the User with its bindings and credentials", until.UTC().Format(time.RFC3339))
+	if err := r.Delete(ctx, user); err != nil && !apierrors.IsNotFound(err) {
+		return false, fmt.Errorf("failed to delete expired break-glass user: %w", err)
+	}
+	return true, nil
+}
+
+func setBreakGlassCondition(user *authv1alpha1.User) {
+	meta.SetStatusCondition(&user.Status.Conditions, metav1.Condition{
+		Type:   ConditionBreakGlass,
+		Status: metav1.ConditionTrue,
+		Reason: "Active",
+		Message: fmt.Sprintf("Break-glass access until %s, then the User is deleted",
+			user.Status.BreakGlassUntil.UTC().Format(time.RFC3339)),
+		ObservedGeneration: user.Generation,
+	})
+}
+
+// untilBreakGlassEnd caps a requeue delay so the controller wakes up when break-glass access
+// ends. A zero delay, meaning no requeue, becomes the time until then.
+func untilBreakGlassEnd(user *authv1alpha1.User, now time.Time, delay time.Duration) time.Duration {
+	if !user.Spec.BreakGlass || user.Status.BreakGlassUntil == nil {
+		return delay
+	}
+	remaining := max(user.Status.BreakGlassUntil.Sub(now), time.Second)
+	if delay == 0 || remaining < delay {
+		return remaining
+	}
+	return delay
+}
+
+// breakGlassCertificateDuration limits a break-glass User's certificate to the remaining
+// access, but not below the shortest lifetime the CSR API issues
+func breakGlassCertificateDuration(user *authv1alpha1.User, duration time.Duration, now time.Time) time.Duration {
+	if !user.Spec.BreakGlass || user.Status.BreakGlassUntil == nil {
+		return duration
+	}
+	remaining := max(user.Status.BreakGlassUntil.Sub(now), operatorconfig.MinBreakGlassDuration)
+	if duration == 0 || remaining < duration {
+		return remaining
+	}
+	return duration
+}
+
+// describeGrants lists the roles granted to the user for Events
+func describeGrants(user *authv1alpha1.User) string {
+	grants := make([]string, 0, len(user.Spec.Roles)+len(user.Spec.ClusterRoles))
+	for _, role := range user.Spec.Roles {
+		grants = append(grants, fmt.Sprintf("Role %s in namespace %s", role.ExistingRole, role.Namespace))
+	}
+	for _, clusterRole := range user.Spec.ClusterRoles {
+		grants = append(grants, "ClusterRole "+clusterRole.ExistingClusterRole)
+	}
+	if len(grants) == 0 {
+		return "no roles"
+	}
+	return strings.Join(grants, ", ")
+}
@@ -0,0 +1,138 @@
+/*
+Copyright 2025.
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+*/
+
+package controller
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"time"
+
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	logf "sigs.k8s.io/controller-runtime/pkg/log"
+
+	authv1alpha1 "github.com/openkube-hub/KubeUser/api/v1alpha1"
+)
+
+// A bulk rotation renews every User after a suspected key leak or signer change. It asks users
+// to renew through the renew annotation, a batch at a time: the next batch starts once the
+// interval has passed and there is room among the users still renewing.
+
+const (
+	// DefaultBulkRotationBatchSize is how many users renew at the same time unless spec.bulkRotation.batchSize is set
+	DefaultBulkRotationBatchSize = 10
+	// DefaultBulkRotationInterval is the least time between two batches unless spec.bulkRotation.interval is set
+	DefaultBulkRotationInterval = time.Minute
+)
+
+// bulkRotationPace returns the batch size and interval of bulk rotations
+func bulkRotationPace(spec *authv1alpha1.BulkRotationSpec) (int, time.Duration) {
+	batchSize, interval := DefaultBulkRotationBatchSize, DefaultBulkRotationInterval
+	if spec != nil {
+		if spec.BatchSize > 0 {
+			batchSize = int(spec.BatchSize)
+		}
+		if spec.Interval != nil && spec.Interval.Duration > 0 {
+			interval = spec.Interval.Duration
+		}
+	}
+	return batchSize, interval
+}
+
+// reconcileBulkRotation advances the bulk rotation named by the rotate-all annotation and
+// records its progress in config's status. It returns when to look again, zero once the
+// rotation has completed or none was requested.
+func (r *KubeUserConfigReconciler) reconcileBulkRotation(ctx context.Context, config *authv1alpha1.KubeUserConfig,
+	now time.Time) (time.Duration, error) {
+	logger := logf.FromContext(ctx)
+	name := config.Annotations[authv1alpha1.RotateAllAnnotation]
+	if name == "" {
+		return 0, nil
+	}
+	status := config.Status.BulkRotation
+	if status == nil || status.Name != name {
+		logger.Info("Starting bulk rotation", "rotation", name)
+		status = &authv1alpha1.BulkRotationStatus{Name: name, StartedAt: metav1.NewTime(now)}
+		config.Status.BulkRotation = status
+	}
+	if status.CompletedAt != nil {
+		return 0, nil
+	}
+	batchSize, interval := bulkRotationPace(config.Spec.BulkRotation)
+
+	var users authv1alpha1.UserList
+	if err := r.List(ctx, &users); err != nil {
+		return 0, fmt.Errorf("failed to list users: %w", err)
+	}
+	sort.Slice(users.Items, func(i, j int) bool { return users.Items[i].Name < users.Items[j].Name })
+
+	// Revoked users have no credentials left to rotate
+	var pending []*authv1alpha1.User
+	var total, requested, renewed, renewing int
+	for i := range users.Items {
+		user := &users.Items[i]
+		if user.Spec.Revoked || !user.DeletionTimestamp.IsZero() {
+			continue
+		}
+		total++
+		switch {
+		case user.Annotations[authv1alpha1.BulkRotationAnnotation] != name:
+			pending = append(pending, user)
+		case renewRequested(user):
+			requested++
+			// Suspended users renew once resumed; they do not hold up the rotation
+			if !user.Spec.Suspended {
+				renewing++
+			}
+		default:
+			requested++
+			renewed++
+		}
+	}
+
+	if batch := min(batchSize-renewing, len(pending)); batch > 0 &&
+		(status.LastBatchAt == nil || now.Sub(status.LastBatchAt.Time) >= interval) {
+		for _, user := range pending[:batch] {
+			if err := r.requestRenewal(ctx, user, name); err != nil {
+				return 0, err
+			}
+		}
+		logger.Info("Asked users to renew", "rotation", name, "users", batch)
+		requested += batch
+		renewing += batch
+		pending = pending[batch:]
+		status.LastBatchAt = &metav1.Time{Time: now}
+	}
+	status.Total, status.Requested, status.Renewed = int32(total), int32(requested), int32(renewed)
+
+	if len(pending) == 0 && renewing == 0 {
+		logger.Info("Bulk rotation completed", "rotation", name, "users", total)
+		status.CompletedAt = &metav1.Time{Time: now}
+		return 0, nil
+	}
+	if status.LastBatchAt == nil {
+		return interval, nil
+	}
+	// Progress is counted again at the next batch, or sooner if that is far off
+	return max(min(status.LastBatchAt.Add(interval).Sub(now), time.Minute), time.Second), nil
+}
+
+// requestRenewal sets the renew annotation on user, with a new key, and records the rotation
+// that asked for it
+func (r *KubeUserConfigReconciler) requestRenewal(ctx context.Context, user *authv1alpha1.User, rotation string) error {
+	base := user.DeepCopy()
+	if user.Annotations == nil {
+		user.Annotations = map[string]string{}
+	}
+	user.Annotations[authv1alpha1.RenewAnnotation] = authv1alpha1.RenewNewKey
+	user.Annotations[authv1alpha1.BulkRotationAnnotation] = rotation
+	if err := r.Patch(ctx, user, client.MergeFrom(base)); err != nil {
+		return fmt.Errorf("failed to request renewal of user %s: %w", user.Name, err)
+	}
+	return nil
+}
@@ -0,0 +1,123 @@
+/*
+Copyright 2025.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package controller
+
+import (
+	"time"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/types"
+	ctrl "sigs.k8s.io/controller-runtime"
+
+	authv1alpha1 "github.com/openkube-hub/KubeUser/api/v1alpha1"
+	"github.com/openkube-hub/KubeUser/internal/operatorconfig"
+)
+
+var _ = Describe("Bulk rotation", func() {
+	users := []string{"rotation-a", "rotation-b", "rotation-c"}
+	const revoked = "rotation-revoked"
+	var r *KubeUserConfigReconciler
+
+	// rotation reconciles the KubeUserConfig and returns the progress of the bulk rotation
+	rotation := func() *authv1alpha1.BulkRotationStatus {
+		GinkgoHelper()
+		_, err := r.Reconcile(ctx, ctrl.Request{NamespacedName: types.NamespacedName{Name: authv1alpha1.KubeUserConfigName}})
+		Expect(err).NotTo(HaveOccurred())
+		var config authv1alpha1.KubeUserConfig
+		Expect(k8sClient.Get(ctx, types.NamespacedName{Name: authv1alpha1.KubeUserConfigName}, &config)).To(Succeed())
+		return config.Status.BulkRotation
+	}
+	// asked returns the users asked to renew that have not yet
+	asked := func() []string {
+		GinkgoHelper()
+		var renewing []string
+		for _, name := range append(users, revoked) {
+			var user authv1alpha1.User
+			Expect(k8sClient.Get(ctx, types.NamespacedName{Name: name}, &user)).To(Succeed())
+			if renewRequested(&user) {
+				Expect(user.Annotations).To(HaveKeyWithValue(authv1alpha1.BulkRotationAnnotation, "leak-1"))
+				renewing = append(renewing, name)
+			}
+		}
+		return renewing
+	}
+	// renew removes the renew annotation from users, as the user controller does once it has
+	// started the renewal
+	renew := func(names ...string) {
+		GinkgoHelper()
+		for _, name := range names {
+			updateUser(name, func(user *authv1alpha1.User) {
+				delete(user.Annotations, authv1alpha1.RenewAnnotation)
+			})
+		}
+	}
+
+	BeforeEach(func() {
+		r = &KubeUserConfigReconciler{Client: k8sClient, Store: operatorconfig.NewStore(operatorconfig.Defaults())}
+		for _, name := range users {
+			Expect(k8sClient.Create(ctx, &authv1alpha1.User{ObjectMeta: metav1.ObjectMeta{Name: name}})).To(Succeed())
+		}
+		Expect(k8sClient.Create(ctx, &authv1alpha1.User{
+			ObjectMeta: metav1.ObjectMeta{Name: revoked},
+			Spec:       authv1alpha1.UserSpec{Revoked: true},
+		})).To(Succeed())
+		Expect(k8sClient.Create(ctx, &authv1alpha1.KubeUserConfig{
+			ObjectMeta: metav1.ObjectMeta{
+				Name:        authv1alpha1.KubeUserConfigName,
+				Annotations: map[string]string{authv1alpha1.RotateAllAnnotation: "leak-1"},
+			},
+			Spec: authv1alpha1.KubeUserConfigSpec{BulkRotation: &authv1alpha1.BulkRotationSpec{
+				BatchSize: 2,
+				Interval:  &metav1.Duration{Duration: time.Millisecond},
+			}},
+		})).To(Succeed())
+	})
+
+	AfterEach(func() {
+		for _, name := range append(users, revoked) {
+			Expect(k8sClient.Delete(ctx, &authv1alpha1.User{ObjectMeta: metav1.ObjectMeta{Name: name}})).To(Succeed())
+		}
+		Expect(k8sClient.Delete(ctx, &authv1alpha1.KubeUserConfig{
+			ObjectMeta: metav1.ObjectMeta{Name: authv1alpha1.KubeUserConfigName}})).To(Succeed())
+	})
+
+	It("asks users to renew a batch at a time", func() {
+		progress := rotation()
+		Expect(progress).To(HaveField("Name", "leak-1"))
+		Expect(progress.CompletedAt).To(BeNil())
+		Expect(asked()).To(Equal([]string{"rotation-a", "rotation-b"}))
+
+		By("waiting while the batch is renewing")
+		Expect(rotation().CompletedAt).To(BeNil())
+		Expect(asked()).To(Equal([]string{"rotation-a", "rotation-b"}))
+
+		By("asking the next batch once there is room")
+		renew("rotation-a", "rotation-b")
+		Expect(rotation().CompletedAt).To(BeNil())
+		Expect(asked()).To(Equal([]string{"rotation-c"}))
+
+		By("completing once every user has renewed")
+		renew("rotation-c")
+		progress = rotation()
+		Expect(progress.CompletedAt).NotTo(BeNil())
+		Expect(progress.Renewed).To(Equal(progress.Total))
+		// Revoked users have nothing to renew
+		Expect(asked()).To(BeEmpty())
+	})
+})
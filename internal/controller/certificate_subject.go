@@ -0,0 +1,110 @@
+/*
+Copyright 2025.
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+*/
+
+package controller
+
+import (
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"fmt"
+	"net/url"
+	"slices"
+	"strings"
+
+	authv1alpha1 "github.com/openkube-hub/KubeUser/api/v1alpha1"
+	"github.com/openkube-hub/KubeUser/internal/issuer"
+)
+
+// certificateSubjectAnnotation records the spec.certificate a credential Secret's certificate
+// was requested with, so a new one is issued when it changes. The certificate itself cannot
+// tell: issuers such as Vault may replace the subject of the CSR with their own.
+const certificateSubjectAnnotation = "auth.openkube.io/certificate-subject"
+
+// subjectString describes subject for certificateSubjectAnnotation; empty without one
+func subjectString(subject *authv1alpha1.CertificateSubject) string {
+	if subject == nil {
+		return ""
+	}
+	var parts []string
+	for _, field := range []struct {
+		name   string
+		values []string
+	}{
+		{"o", subject.Organizations},
+		{"ou", subject.OrganizationalUnits},
+		{"email", subject.EmailAddresses},
+		{"dns", subject.DNSNames},
+		{"uri", subject.URIs},
+	} {
+		if len(field.values) > 0 {
+			parts = append(parts, field.name+"="+strings.Join(sortedCopy(field.values), ","))
+		}
+	}
+	return strings.Join(parts, ";")
+}
+
+// csrTemplate returns the CSR for username, with the attributes and SANs of subject and the
+// URI SANs the issuer needs
+func csrTemplate(username string, subject *authv1alpha1.CertificateSubject, issuerURIs []*url.URL) (*x509.CertificateRequest, error) {
+	template := &x509.CertificateRequest{Subject: pkix.Name{CommonName: username}, URIs: issuerURIs}
+	if subject == nil {
+		return template, nil
+	}
+	template.Subject.Organization = subject.Organizations
+	template.Subject.OrganizationalUnit = subject.OrganizationalUnits
+	template.EmailAddresses = subject.EmailAddresses
+	template.DNSNames = subject.DNSNames
+	for _, uri := range subject.URIs {
+		parsed, err := url.Parse(uri)
+		if err != nil {
+			return nil, fmt.Errorf("invalid spec.certificate.uris entry %q: %w", uri, err)
+		}
+		template.URIs = append(template.URIs, parsed)
+	}
+	return template, nil
+}
+
+// certificateHasSubject reports whether the PEM certificate carries exactly the attributes and
+// SANs of subject. Only signers that copy them from the CSR, like the Kubernetes CSR API's,
+// issue such certificates.
+func certificateHasSubject(certPEM []byte, subject *authv1alpha1.CertificateSubject) bool {
+	cert, err := issuer.ParseCertificate(certPEM)
+	if err != nil {
+		return false
+	}
+	if subject == nil {
+		subject = &authv1alpha1.CertificateSubject{}
+	}
+	uris := make([]string, 0, len(cert.URIs))
+	for _, uri := range cert.URIs {
+		uris = append(uris, uri.String())
+	}
+	return sameValues(cert.Subject.Organization, subject.Organizations) &&
+		sameValues(cert.Subject.OrganizationalUnit, subject.OrganizationalUnits) &&
+		sameValues(cert.EmailAddresses, subject.EmailAddresses) &&
+		sameValues(cert.DNSNames, subject.DNSNames) &&
+		sameValues(uris, subject.URIs)
+}
+
+func sameValues(a, b []string) bool {
+	return slices.Equal(sortedCopy(a), sortedCopy(b))
+}
+
+func sortedCopy(values []string) []string {
+	sorted := slices.Clone(values)
+	slices.Sort(sorted)
+	return sorted
+}
+
+// certificateCommonName returns the common name of the PEM certificate, the name the API server
+// authenticates it as; empty if it cannot be parsed
+func certificateCommonName(certPEM []byte) string {
+	cert, err := issuer.ParseCertificate(certPEM)
+	if err != nil {
+		return ""
+	}
+	return cert.Subject.CommonName
+}
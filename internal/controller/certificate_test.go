@@ -0,0 +1,92 @@
+/*
+Copyright 2025.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package controller
+
+import (
+	"context"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+	corev1 "k8s.io/api/core/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+
+	authv1alpha1 "github.com/openkube-hub/KubeUser/api/v1alpha1"
+	"github.com/openkube-hub/KubeUser/internal/credentials"
+	"github.com/openkube-hub/KubeUser/internal/issuer"
+)
+
+// pendingIssuer records the certificates requested from it, which stay pending
+type pendingIssuer struct {
+	signed []string
+	reset  []string
+}
+
+func (i *pendingIssuer) Sign(_ context.Context, req issuer.Request) (*issuer.Certificate, error) {
+	i.signed = append(i.signed, req.Name)
+	return nil, issuer.ErrPending
+}
+
+func (i *pendingIssuer) Reset(_ context.Context, name string) error {
+	i.reset = append(i.reset, name)
+	return nil
+}
+
+var _ = Describe("Certificate kubeconfigs", func() {
+	// cert is rendered into the kubeconfig of the credential Secret; kubeconfig replaces the
+	// rendered kubeconfig when set
+	DescribeTable("reissues certificates when the credential Secret holds none that is usable",
+		func(cert, kubeconfig []byte) {
+			ctx := context.Background()
+			user := &authv1alpha1.User{ObjectMeta: metav1.ObjectMeta{Name: "jane"}}
+			signer := &pendingIssuer{}
+			patches := 0
+			c := statusClient(&patches, 0, user)
+			r := &UserReconciler{Client: c, Scheme: c.Scheme(), Issuer: signer,
+				ProxyServer: "https://kubeuser-proxy.example.org", ProxyCA: []byte("ca")}
+
+			// A credential Secret whose annotations match the user, so only the certificate is wrong
+			cluster, err := r.kubeconfigCluster(ctx, user)
+			Expect(err).NotTo(HaveOccurred())
+			recipients, err := userCredentialRecipients(user)
+			Expect(err).NotTo(HaveOccurred())
+			contexts := userKubeconfigContexts(user)
+			if kubeconfig == nil {
+				kubeconfig, err = buildCertKubeconfig(cluster, cert, nil, user.Name, contexts)
+				Expect(err).NotTo(HaveOccurred())
+			}
+			secret, err := credentialSecret(credentialSecretKey(user), user, r.credentialLayout(user), contexts, cluster,
+				recipients, credentials.Material{Server: cluster.Server, Username: user.Name, CA: cluster.CA,
+					Cert: cert, Kubeconfig: kubeconfig})
+			Expect(err).NotTo(HaveOccurred())
+			Expect(c.Create(ctx, secret)).To(Succeed())
+
+			pending, err := r.ensureCertKubeconfig(ctx, user)
+			Expect(err).NotTo(HaveOccurred())
+			Expect(pending).To(BeTrue())
+			Expect(signer.signed).To(Equal([]string{userCSRName(user.Name)}))
+			Expect(signer.reset).To(Equal([]string{userCSRName(user.Name)}))
+			err = c.Get(ctx, credentialSecretKey(user), &corev1.Secret{})
+			Expect(apierrors.IsNotFound(err)).To(BeTrue())
+		},
+		Entry("no certificate", nil, nil),
+		Entry("a certificate that does not parse",
+			[]byte("-----BEGIN CERTIFICATE-----\nZ2FyYmFnZQ==\n-----END CERTIFICATE-----\n"), nil),
+		Entry("data that is not PEM", []byte("garbage"), nil),
+		Entry("a kubeconfig that does not parse", nil, []byte("{")),
+	)
+})
@@ -0,0 +1,184 @@
+/*
+Copyright 2025.
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+*/
+
+package controller
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sync"
+	"time"
+
+	"go.opentelemetry.io/otel/attribute"
+	"k8s.io/apimachinery/pkg/api/meta"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/types"
+	ctrl "sigs.k8s.io/controller-runtime"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	logf "sigs.k8s.io/controller-runtime/pkg/log"
+
+	authv1alpha1 "github.com/openkube-hub/KubeUser/api/v1alpha1"
+	"github.com/openkube-hub/KubeUser/internal/tracing"
+)
+
+const (
+	// ConditionReconcileSuspended is True while a user's circuit breaker is open after repeated failures
+	ConditionReconcileSuspended = "ReconcileSuspended"
+
+	// DefaultReconcileTimeout bounds a single reconcile of one user
+	DefaultReconcileTimeout = 2 * time.Minute
+	// DefaultCircuitBreakerFailures is how many consecutive failures open a user's circuit breaker
+	DefaultCircuitBreakerFailures = 5
+	// DefaultCircuitBreakerCooldown is how long reconciles are skipped once the breaker is open
+	DefaultCircuitBreakerCooldown = 10 * time.Minute
+
+	// conditionUpdateTimeout bounds the status update made after a reconcile timed out
+	conditionUpdateTimeout = 10 * time.Second
+)
+
+// circuitBreaker counts consecutive reconcile failures per user. Once a user reaches the
+// threshold its reconciles are skipped for the cooldown; the first attempt after the cooldown
+// closes the breaker on success or opens it again on failure.
+type circuitBreaker struct {
+	threshold int
+	cooldown  time.Duration
+
+	mu    sync.Mutex
+	users map[string]*circuitState
+}
+
+type circuitState struct {
+	failures  int
+	openUntil time.Time
+}
+
+func newCircuitBreaker(threshold int, cooldown time.Duration) *circuitBreaker {
+	return &circuitBreaker{threshold: threshold, cooldown: cooldown, users: map[string]*circuitState{}}
+}
+
+// open returns how long the breaker for the user stays open, or zero when reconciles may run
+func (b *circuitBreaker) open(name string, now time.Time) time.Duration {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	if state, ok := b.users[name]; ok && now.Before(state.openUntil) {
+		return state.openUntil.Sub(now)
+	}
+	return 0
+}
+
+// failure records a failed reconcile and reports whether it opened the breaker
+func (b *circuitBreaker) failure(name string, now time.Time) bool {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	state, ok := b.users[name]
+	if !ok {
+		state = &circuitState{}
+		b.users[name] = state
+	}
+	state.failures++
+	if b.threshold <= 0 || state.failures < b.threshold {
+		return false
+	}
+	state.openUntil = now.Add(b.cooldown)
+	return true
+}
+
+// success forgets the user's failures and reports whether the breaker had been opened before
+func (b *circuitBreaker) success(name string) bool {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	state, ok := b.users[name]
+	if !ok {
+		return false
+	}
+	delete(b.users, name)
+	return !state.openUntil.IsZero()
+}
+
+func (r *UserReconciler) breaker() *circuitBreaker {
+	r.circuitBreakerOnce.Do(func() {
+		r.circuitBreaker = newCircuitBreaker(r.CircuitBreakerFailures, r.CircuitBreakerCooldown)
+	})
+	return r.circuitBreaker
+}
+
+// Reconcile bounds each user's reconcile with ReconcileTimeout and skips users whose circuit
+// breaker is open, so a single failing user cannot occupy the workers for everyone else.
+func (r *UserReconciler) Reconcile(ctx context.Context, req ctrl.Request) (ctrl.Result, error) {
+	logger := logf.FromContext(ctx)
+	breaker := r.breaker()
+
+	if wait := breaker.open(req.Name, time.Now()); wait > 0 {
+		logger.Info("Circuit breaker open, skipping reconcile", "user", req.Name, "retryIn", wait)
+		return ctrl.Result{RequeueAfter: wait}, nil
+	}
+
+	reconcileCtx := ctx
+	if r.ReconcileTimeout > 0 {
+		var cancel context.CancelFunc
+		reconcileCtx, cancel = context.WithTimeout(ctx, r.ReconcileTimeout)
+		defer cancel()
+	}
+	reconcileCtx, span := tracing.Start(reconcileCtx, "User.Reconcile", attribute.String("user", req.Name))
+	start := time.Now()
+	result, err := r.reconcileUser(reconcileCtx, req)
+	if err == nil && errors.Is(reconcileCtx.Err(), context.DeadlineExceeded) {
+		err = fmt.Errorf("reconcile exceeded timeout of %s", r.ReconcileTimeout)
+	}
+	r.diagnostics.record(req.Name, start, time.Since(start), result, err)
+	span.SetAttributes(attribute.String("requeueAfter", result.RequeueAfter.String()))
+	tracing.End(span, err)
+
+	if err == nil {
+		if breaker.success(req.Name) {
+			r.setSuspendedCondition(ctx, req.Name, nil)
+		}
+		return result, nil
+	}
+	reconcileErrors.WithLabelValues(req.Name).Inc()
+	if breaker.failure(req.Name, time.Now()) {
+		logger.Error(err, "Repeated reconcile failures, opening circuit breaker", "user", req.Name,
+			"failures", r.CircuitBreakerFailures, "cooldown", r.CircuitBreakerCooldown)
+		r.setSuspendedCondition(ctx, req.Name, err)
+		return ctrl.Result{RequeueAfter: r.CircuitBreakerCooldown}, nil
+	}
+	return result, err
+}
+
+// setSuspendedCondition records whether the user's reconciles are suspended by the circuit breaker.
+// It uses its own deadline because the reconcile context may already have timed out.
+func (r *UserReconciler) setSuspendedCondition(ctx context.Context, name string, cause error) {
+	ctx, cancel := context.WithTimeout(context.WithoutCancel(ctx), conditionUpdateTimeout)
+	defer cancel()
+
+	var user authv1alpha1.User
+	if err := r.Get(ctx, types.NamespacedName{Name: name}, &user); err != nil {
+		if client.IgnoreNotFound(err) != nil {
+			logf.FromContext(ctx).Error(err, "Failed to get user for circuit breaker condition")
+		}
+		return
+	}
+	condition := metav1.Condition{
+		Type:    ConditionReconcileSuspended,
+		Status:  metav1.ConditionFalse,
+		Reason:  "Reconciling",
+		Message: "Reconciles succeed again",
+	}
+	if cause != nil {
+		condition.Status = metav1.ConditionTrue
+		condition.Reason = "RepeatedFailures"
+		condition.Message = fmt.Sprintf("Reconciles suspended for %s after %d consecutive failures, last error: %v",
+			r.CircuitBreakerCooldown, r.CircuitBreakerFailures, cause)
+	}
+	original := user.DeepCopy()
+	if !meta.SetStatusCondition(&user.Status.Conditions, condition) {
+		return
+	}
+	if err := r.updateStatus(ctx, original, &user); err != nil {
+		logf.FromContext(ctx).Error(err, "Failed to update circuit breaker condition")
+	}
+}
@@ -0,0 +1,152 @@
+/*
+Copyright 2025.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package controller
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+	"k8s.io/apimachinery/pkg/api/meta"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/types"
+	ctrl "sigs.k8s.io/controller-runtime"
+
+	authv1alpha1 "github.com/openkube-hub/KubeUser/api/v1alpha1"
+)
+
+var _ = Describe("Circuit breaker", func() {
+	It("opens at the threshold", func() {
+		now := time.Date(2026, 1, 1, 0, 0, 0, 0, time.UTC)
+		b := newCircuitBreaker(3, 10*time.Minute)
+
+		Expect(b.failure("jane", now)).To(BeFalse())
+		Expect(b.failure("jane", now)).To(BeFalse())
+		Expect(b.open("jane", now)).To(BeZero())
+		Expect(b.failure("jane", now)).To(BeTrue())
+		Expect(b.open("jane", now)).To(Equal(10 * time.Minute))
+		Expect(b.open("jane", now.Add(4*time.Minute))).To(Equal(6 * time.Minute))
+
+		// Other users keep their own count
+		Expect(b.open("john", now)).To(BeZero())
+		Expect(b.failure("john", now)).To(BeFalse())
+	})
+
+	It("closes for one attempt after the cooldown", func() {
+		now := time.Date(2026, 1, 1, 0, 0, 0, 0, time.UTC)
+		b := newCircuitBreaker(2, time.Minute)
+		b.failure("jane", now)
+		Expect(b.failure("jane", now)).To(BeTrue())
+
+		// Closed again at the end of the cooldown, for one attempt
+		Expect(b.open("jane", now.Add(time.Minute-time.Nanosecond))).To(Equal(time.Nanosecond))
+		Expect(b.open("jane", now.Add(time.Minute))).To(BeZero())
+
+		// Which opens it again right away when it fails
+		later := now.Add(time.Minute)
+		Expect(b.failure("jane", later)).To(BeTrue())
+		Expect(b.open("jane", later)).To(Equal(time.Minute))
+
+		// Or closes it for good when it succeeds
+		Expect(b.success("jane")).To(BeTrue())
+		Expect(b.open("jane", later)).To(BeZero())
+		Expect(b.failure("jane", later)).To(BeFalse())
+	})
+
+	DescribeTable("forgets failures on success",
+		func(failures int, reopened bool) {
+			now := time.Now()
+			b := newCircuitBreaker(3, time.Minute)
+			for range failures {
+				b.failure("jane", now)
+			}
+			Expect(b.success("jane")).To(Equal(reopened))
+			// The failures are forgotten
+			Expect(b.failure("jane", now)).To(BeFalse())
+		},
+		Entry("no failures", 0, false),
+		Entry("failures below the threshold", 2, false),
+		Entry("an open breaker", 3, true),
+	)
+
+	It("never opens when disabled", func() {
+		now := time.Now()
+		b := newCircuitBreaker(0, time.Minute)
+		for range 100 {
+			Expect(b.failure("jane", now)).To(BeFalse())
+		}
+		Expect(b.open("jane", now)).To(BeZero())
+	})
+})
+
+var _ = Describe("User reconcile suspension", func() {
+	It("suspends users that fail repeatedly", func() {
+		ctx := context.Background()
+		// The missing UserTemplate fails every reconcile
+		user := &authv1alpha1.User{
+			ObjectMeta: metav1.ObjectMeta{Name: "jane"},
+			Spec:       authv1alpha1.UserSpec{TemplateRef: &authv1alpha1.UserTemplateReference{Name: "missing"}},
+		}
+		patches := 0
+		c := statusClient(&patches, 0, user)
+		r := &UserReconciler{Client: c, Scheme: c.Scheme(), CircuitBreakerFailures: 2, CircuitBreakerCooldown: time.Hour}
+		req := ctrl.Request{NamespacedName: types.NamespacedName{Name: "jane"}}
+		suspended := func() *metav1.Condition {
+			var stored authv1alpha1.User
+			Expect(c.Get(ctx, req.NamespacedName, &stored)).To(Succeed())
+			return meta.FindStatusCondition(stored.Status.Conditions, ConditionReconcileSuspended)
+		}
+
+		_, err := r.Reconcile(ctx, req)
+		Expect(err).To(HaveOccurred())
+		Expect(suspended()).To(BeNil())
+
+		// The second failure opens the breaker instead of returning the error
+		result, err := r.Reconcile(ctx, req)
+		Expect(err).NotTo(HaveOccurred())
+		Expect(result.RequeueAfter).To(Equal(time.Hour))
+		Expect(suspended()).To(And(
+			HaveField("Status", metav1.ConditionTrue),
+			HaveField("Reason", "RepeatedFailures"),
+			HaveField("Message", ContainSubstring("after 2 consecutive failures")),
+			HaveField("Message", ContainSubstring("last error: usertemplate missing not found")),
+		))
+
+		// While it is open reconciles are skipped
+		written := patches
+		result, err = r.Reconcile(ctx, req)
+		Expect(err).NotTo(HaveOccurred())
+		Expect(result.RequeueAfter).To(And(BeNumerically(">", 59*time.Minute), BeNumerically("<=", time.Hour)))
+		Expect(patches).To(Equal(written))
+
+		// A success after the cooldown clears the condition
+		r.setSuspendedCondition(ctx, "jane", nil)
+		Expect(suspended()).To(And(
+			HaveField("Status", metav1.ConditionFalse),
+			HaveField("Reason", "Reconciling"),
+		))
+	})
+
+	It("ignores deleted users", func() {
+		patches := 0
+		r := &UserReconciler{Client: statusClient(&patches, 0)}
+		r.setSuspendedCondition(context.Background(), "jane", errors.New("failed"))
+		Expect(patches).To(BeZero())
+	})
+})
@@ -0,0 +1,172 @@
+/*
+Copyright 2025.
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+*/
+
+package controller
+
+import (
+	"context"
+	"sort"
+
+	corev1 "k8s.io/api/core/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"k8s.io/apimachinery/pkg/types"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	logf "sigs.k8s.io/controller-runtime/pkg/log"
+	"sigs.k8s.io/controller-runtime/pkg/reconcile"
+
+	authv1alpha1 "github.com/openkube-hub/KubeUser/api/v1alpha1"
+	"github.com/openkube-hub/KubeUser/internal/naming"
+	"github.com/openkube-hub/KubeUser/internal/team"
+)
+
+// Cluster API writes the kubeconfig of a workload cluster to the Secret <cluster>-kubeconfig, in
+// the Cluster's namespace and labelled with the Cluster's name
+const (
+	clusterAPIKubeconfigSuffix = "-kubeconfig"
+	clusterAPIKubeconfigKey    = "value"
+	clusterAPIClusterNameLabel = "cluster.x-k8s.io/cluster-name"
+)
+
+// ClusterAPIClusterGVK is the kind of Cluster API Clusters. They are read as unstructured
+// objects, so KubeUser does not depend on Cluster API.
+var ClusterAPIClusterGVK = schema.GroupVersionKind{Group: "cluster.x-k8s.io", Version: "v1beta1", Kind: "Cluster"}
+
+// newClusterAPICluster returns an empty Cluster API Cluster
+func newClusterAPICluster() *unstructured.Unstructured {
+	obj := &unstructured.Unstructured{}
+	obj.SetGroupVersionKind(ClusterAPIClusterGVK)
+	return obj
+}
+
+// fleetClusterName returns the member cluster name of a Cluster API Cluster
+func fleetClusterName(cluster types.NamespacedName) string {
+	return naming.Join(naming.MaxLabelLength, "", cluster.Namespace, cluster.Name)
+}
+
+// userFleets returns the fleets of the user, from its own label and those of its Teams
+func (r *UserReconciler) userFleets(ctx context.Context, user *authv1alpha1.User) ([]string, error) {
+	fleets := map[string]bool{}
+	if fleet := user.Labels[authv1alpha1.FleetLabel]; fleet != "" {
+		fleets[fleet] = true
+	}
+	teams, err := team.ForUser(ctx, r.Client, user.Name, client.MatchingFields{TeamMemberIndex: user.Name})
+	if err != nil {
+		return nil, err
+	}
+	for _, t := range teams {
+		if fleet := t.Labels[authv1alpha1.FleetLabel]; fleet != "" {
+			fleets[fleet] = true
+		}
+	}
+	names := make([]string, 0, len(fleets))
+	for fleet := range fleets {
+		names = append(names, fleet)
+	}
+	sort.Strings(names)
+	return names, nil
+}
+
+// applyFleets adds the Cluster API Clusters of the user's fleets to spec.clusters, in memory like
+// a UserTemplate. A Cluster is added once its kubeconfig Secret exists and until it is being
+// deleted; the user is then removed from it like from a member cluster dropped from the spec.
+// Member clusters listed in the spec take precedence over a Cluster of the same name.
+func (r *UserReconciler) applyFleets(ctx context.Context, user *authv1alpha1.User) error {
+	if !r.ClusterAPI {
+		return nil
+	}
+	fleets, err := r.userFleets(ctx, user)
+	if err != nil {
+		return err
+	}
+	listed := map[string]bool{}
+	for _, target := range user.Spec.Clusters {
+		listed[target.Name] = true
+	}
+	for _, fleet := range fleets {
+		clusters := &unstructured.UnstructuredList{}
+		clusters.SetGroupVersionKind(ClusterAPIClusterGVK.GroupVersion().WithKind(ClusterAPIClusterGVK.Kind + "List"))
+		if err := r.List(ctx, clusters, client.MatchingLabels{authv1alpha1.FleetLabel: fleet}); err != nil {
+			return err
+		}
+		for _, cluster := range clusters.Items {
+			if !cluster.GetDeletionTimestamp().IsZero() {
+				continue
+			}
+			name := fleetClusterName(client.ObjectKeyFromObject(&cluster))
+			if listed[name] {
+				continue
+			}
+			ref := authv1alpha1.ClusterKubeconfigReference{
+				Name:      cluster.GetName() + clusterAPIKubeconfigSuffix,
+				Namespace: cluster.GetNamespace(),
+				Key:       clusterAPIKubeconfigKey,
+			}
+			var secret corev1.Secret
+			if err := r.Get(ctx, clusterSecretKey(ref), &secret); apierrors.IsNotFound(err) {
+				continue
+			} else if err != nil {
+				return err
+			}
+			listed[name] = true
+			user.Spec.Clusters = append(user.Spec.Clusters, authv1alpha1.ClusterTarget{Name: name, KubeconfigSecretRef: ref})
+		}
+	}
+	return nil
+}
+
+// usersForClusterAPICluster maps a Cluster API Cluster change to reconcile requests for the
+// Users of its fleet, directly or through a Team. A Cluster moved to another fleet is enqueued
+// for the previous one by its update event for the previous version.
+func (r *UserReconciler) usersForClusterAPICluster(ctx context.Context, obj client.Object) []reconcile.Request {
+	fleet := obj.GetLabels()[authv1alpha1.FleetLabel]
+	if fleet == "" {
+		return nil
+	}
+	logger := logf.FromContext(ctx)
+	names := map[string]bool{}
+	var users authv1alpha1.UserList
+	if err := r.List(ctx, &users, client.MatchingLabels{authv1alpha1.FleetLabel: fleet}); err != nil {
+		logger.Error(err, "Failed to list users for cluster change")
+		return nil
+	}
+	for _, user := range users.Items {
+		names[user.Name] = true
+	}
+	var teams authv1alpha1.TeamList
+	if err := r.List(ctx, &teams, client.MatchingLabels{authv1alpha1.FleetLabel: fleet}); err != nil {
+		logger.Error(err, "Failed to list teams for cluster change")
+		return nil
+	}
+	for i := range teams.Items {
+		for _, name := range team.MemberNames(&teams.Items[i]) {
+			names[name] = true
+		}
+	}
+	requests := make([]reconcile.Request, 0, len(names))
+	for name := range names {
+		requests = append(requests, reconcile.Request{NamespacedName: types.NamespacedName{Name: name}})
+	}
+	return requests
+}
+
+// usersForClusterAPISecret maps a change of a Cluster API kubeconfig Secret, such as its creation
+// once the workload cluster is up, to reconcile requests for the Users of the Cluster's fleet
+func (r *UserReconciler) usersForClusterAPISecret(ctx context.Context, obj client.Object) []reconcile.Request {
+	name := obj.GetLabels()[clusterAPIClusterNameLabel]
+	if name == "" || obj.GetName() != name+clusterAPIKubeconfigSuffix {
+		return nil
+	}
+	cluster := newClusterAPICluster()
+	if err := r.Get(ctx, types.NamespacedName{Name: name, Namespace: obj.GetNamespace()}, cluster); err != nil {
+		if !apierrors.IsNotFound(err) {
+			logf.FromContext(ctx).Error(err, "Failed to get cluster for kubeconfig secret change")
+		}
+		return nil
+	}
+	return r.usersForClusterAPICluster(ctx, cluster)
+}
@@ -0,0 +1,197 @@
+/*
+Copyright 2025.
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+*/
+
+package controller
+
+import (
+	"context"
+	"crypto/sha256"
+	"fmt"
+	"sort"
+	"strings"
+	"time"
+
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/client-go/tools/clientcmd"
+	clientcmdapi "k8s.io/client-go/tools/clientcmd/api"
+
+	authv1alpha1 "github.com/openkube-hub/KubeUser/api/v1alpha1"
+	"github.com/openkube-hub/KubeUser/internal/credentials"
+	"github.com/openkube-hub/KubeUser/internal/naming"
+)
+
+// hubClusterName is the name of this cluster in kubeconfigs, which member clusters cannot take
+const hubClusterName = "cluster"
+
+// memberCertificateSuffix follows the member cluster's name in the key that holds the
+// certificate it issued, which is kept readable next to a possibly encrypted kubeconfig
+const memberCertificateSuffix = ".crt"
+
+// kubeconfigDigestAnnotation records a digest of the kubeconfig for all clusters, so it is
+// only written again, and encrypted again, when it changed
+const kubeconfigDigestAnnotation = "auth.openkube.io/kubeconfig-digest"
+
+// memberCredential is a cluster and the certificate it issued for the user
+type memberCredential struct {
+	cluster kubeconfigCluster
+	cert    []byte
+}
+
+// clustersCredentialSecretKey returns the Secret holding the user's kubeconfig for this cluster
+// and all of its member clusters
+func clustersCredentialSecretKey(username string) types.NamespacedName {
+	return types.NamespacedName{
+		Name:      naming.Suffixed(naming.MaxNameLength, username, "kubeconfig-clusters"),
+		Namespace: getKubeUserNamespace(),
+	}
+}
+
+// memberCertificates returns the certificates in the user's kubeconfig for all clusters by
+// member cluster
+func (r *UserReconciler) memberCertificates(ctx context.Context, key types.NamespacedName,
+	username string) (map[string][]byte, error) {
+	secret, err := r.getCredentialSecret(ctx, key, username)
+	if apierrors.IsNotFound(err) {
+		return nil, nil
+	} else if err != nil {
+		return nil, err
+	}
+	certs := map[string][]byte{}
+	for dataKey, value := range secret.Data {
+		if name, ok := strings.CutSuffix(dataKey, memberCertificateSuffix); ok {
+			certs[name] = value
+		}
+	}
+	return certs, nil
+}
+
+// writeClustersCredentialSecret writes the user's kubeconfig for this cluster and members to
+// the Secret at key, or deletes it when there are no members. This cluster is included once its
+// credential Secret holds a certificate.
+func (r *UserReconciler) writeClustersCredentialSecret(ctx context.Context, user *authv1alpha1.User,
+	key types.NamespacedName, members map[string]memberCredential) error {
+	if len(members) == 0 {
+		return r.deleteCredentialSecret(ctx, key, user.Name)
+	}
+	var keyPEM []byte
+	if user.Spec.CSR == "" {
+		_, _, keyAlgorithm := r.certificateSettings(ctx, user)
+		_, pemData, err := r.ensureUserKey(ctx, userKeySecretName(user.Name), user, keyAlgorithm)
+		if err != nil {
+			return err
+		}
+		keyPEM = pemData
+	}
+	hub, err := r.hubCredential(ctx, user)
+	if err != nil {
+		return err
+	}
+	contexts := userKubeconfigContexts(user)
+	contexts.Exec = false
+	kubeconfig, err := buildClustersKubeconfig(user.Name, contexts, keyPEM, hub, members)
+	if err != nil {
+		return fmt.Errorf("failed to render kubeconfig: %w", err)
+	}
+	recipients, err := userCredentialRecipients(user)
+	if err != nil {
+		return err
+	}
+	sum := sha256.Sum256(kubeconfig)
+	digest := fmt.Sprintf("%x", sum[:16])
+	existing, err := r.getCredentialSecret(ctx, key, user.Name)
+	if err != nil && !apierrors.IsNotFound(err) {
+		return err
+	} else if err == nil && existing.Annotations[kubeconfigDigestAnnotation] == digest &&
+		existing.Annotations[credentialRecipientsAnnotation] == recipients.String() {
+		return nil
+	}
+
+	layout := credentials.Layout{credentials.DefaultKubeconfigKey: authv1alpha1.CredentialFormatKubeconfig}
+	data := map[string][]byte{credentials.DefaultKubeconfigKey: kubeconfig}
+	for name, member := range members {
+		layout[name+memberCertificateSuffix] = authv1alpha1.CredentialFormatClientCert
+		data[name+memberCertificateSuffix] = member.cert
+	}
+	if len(recipients) > 0 {
+		if err := layout.Encrypt(data, recipients); err != nil {
+			return err
+		}
+	}
+	secret := userSecret(key, user, CredentialSecretType, data)
+	secret.Annotations[credentialLayoutAnnotation] = layout.String()
+	secret.Annotations[kubeconfigDigestAnnotation] = digest
+	if len(recipients) > 0 {
+		secret.Annotations[credentialRecipientsAnnotation] = recipients.String()
+	}
+	setRotatedAt(secret, time.Now())
+	return r.applyCredentialSecret(ctx, secret, user.Name)
+}
+
+// hubCredential returns this cluster and the certificate in the user's credential Secret, or
+// nil while it holds none
+func (r *UserReconciler) hubCredential(ctx context.Context, user *authv1alpha1.User) (*memberCredential, error) {
+	secret, err := r.getCredentialSecret(ctx, credentialSecretKey(user), user.Name)
+	if apierrors.IsNotFound(err) {
+		return nil, nil
+	} else if err != nil {
+		return nil, err
+	}
+	cert, err := secretLayout(secret).ClientCertificate(secret.Data)
+	if err != nil || cert == nil {
+		return nil, err
+	}
+	cluster, err := r.kubeconfigCluster(ctx, user)
+	if err != nil {
+		return nil, err
+	}
+	return &memberCredential{cluster: cluster, cert: cert}, nil
+}
+
+// buildClustersKubeconfig renders the kubeconfig for this cluster, if hub is set, and the member
+// clusters. This cluster has the contexts of buildCertKubeconfig and stays the current context;
+// each member cluster has one context named after it, in the default namespace, and becomes
+// the current context in name order when this cluster is missing.
+func buildClustersKubeconfig(username string, contexts kubeconfigContexts, keyPEM []byte,
+	hub *memberCredential, members map[string]memberCredential) ([]byte, error) {
+	config := clientcmdapi.NewConfig()
+	if hub != nil {
+		config.Clusters[hubClusterName] = kubeconfigClusterEntry(hub.cluster)
+		config.AuthInfos[username] = &clientcmdapi.AuthInfo{ClientCertificateData: hub.cert, ClientKeyData: keyPEM}
+		config.Contexts[username+"@"+hubClusterName] = &clientcmdapi.Context{
+			Cluster:   hubClusterName,
+			AuthInfo:  username,
+			Namespace: contexts.DefaultNamespace,
+		}
+		for _, namespace := range contexts.Namespaces {
+			config.Contexts[username+"@"+namespace] = &clientcmdapi.Context{
+				Cluster:   hubClusterName,
+				AuthInfo:  username,
+				Namespace: namespace,
+			}
+		}
+		config.CurrentContext = username + "@" + hubClusterName
+	}
+	names := make([]string, 0, len(members))
+	for name := range members {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	for _, name := range names {
+		member := members[name]
+		config.Clusters[name] = kubeconfigClusterEntry(member.cluster)
+		config.AuthInfos[username+"@"+name] = &clientcmdapi.AuthInfo{ClientCertificateData: member.cert, ClientKeyData: keyPEM}
+		config.Contexts[name] = &clientcmdapi.Context{
+			Cluster:   name,
+			AuthInfo:  username + "@" + name,
+			Namespace: contexts.DefaultNamespace,
+		}
+		if config.CurrentContext == "" {
+			config.CurrentContext = name
+		}
+	}
+	return clientcmd.Write(*config)
+}
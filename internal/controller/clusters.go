@@ -0,0 +1,534 @@
+/*
+Copyright 2025.
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+*/
+
+package controller
+
+import (
+	"context"
+	"crypto"
+	"errors"
+	"fmt"
+	"sort"
+	"strings"
+	"time"
+
+	corev1 "k8s.io/api/core/v1"
+	rbacv1 "k8s.io/api/rbac/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/api/meta"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/types"
+	clientgoscheme "k8s.io/client-go/kubernetes/scheme"
+	"k8s.io/client-go/rest"
+	"k8s.io/client-go/tools/clientcmd"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	logf "sigs.k8s.io/controller-runtime/pkg/log"
+	"sigs.k8s.io/controller-runtime/pkg/reconcile"
+
+	authv1alpha1 "github.com/openkube-hub/KubeUser/api/v1alpha1"
+	"github.com/openkube-hub/KubeUser/internal/issuer"
+	"github.com/openkube-hub/KubeUser/internal/naming"
+	"github.com/openkube-hub/KubeUser/internal/operatorconfig"
+)
+
+// Member clusters are the clusters in spec.clusters, reached with kubeconfigs in Secrets of the
+// KubeUser namespace or another one, and the Cluster API Clusters of the user's fleets. The user
+// is bound there through the bindings it holds on this cluster, so report-only mode,
+// ClusterPolicies, schedules and timed grants apply to member clusters too, and gets a
+// certificate from each member cluster's CSR API for the same key. One kubeconfig Secret holds a
+// context for each member cluster besides those for this cluster.
+
+// ConditionClustersReady is True while the user is provisioned on every member cluster. It is
+// only set on users with member clusters.
+const ConditionClustersReady = "ClustersReady"
+
+// defaultClusterKubeconfigKey is the key of the kubeconfig in a member cluster's Secret
+const defaultClusterKubeconfigKey = "kubeconfig"
+
+// Delays before a member cluster that is not ready is reconciled again
+const (
+	memberPendingRetry = 10 * time.Second
+	memberFailedRetry  = time.Minute
+)
+
+// MemberClientFactory returns a client for the member cluster reached with config
+type MemberClientFactory func(config *rest.Config) (client.Client, error)
+
+// NewMemberClient is the default MemberClientFactory, an uncached client with the built-in types
+func NewMemberClient(config *rest.Config) (client.Client, error) {
+	return client.New(config, client.Options{Scheme: clientgoscheme.Scheme})
+}
+
+// memberClient is the client of a member cluster, kept while its kubeconfig Secret is unchanged
+type memberClient struct {
+	resourceVersion string
+	client          client.Client
+	cluster         kubeconfigCluster
+}
+
+// memberCSRName returns the name of the user's CSRs on member clusters, which differs from
+// userCSRName in case KubeUser manages the same user there too
+func memberCSRName(username string) string {
+	return naming.Suffixed(naming.MaxNameLength, username, "member-csr")
+}
+
+// clusterSecretKey returns the kubeconfig Secret ref points to
+func clusterSecretKey(ref authv1alpha1.ClusterKubeconfigReference) types.NamespacedName {
+	key := types.NamespacedName{Name: ref.Name, Namespace: ref.Namespace}
+	if key.Namespace == "" {
+		key.Namespace = getKubeUserNamespace()
+	}
+	return key
+}
+
+// memberCluster connects to the member cluster with the kubeconfig ref points to. It also returns
+// the member cluster's endpoint and CA, which go into the user's kubeconfig for it.
+func (r *UserReconciler) memberCluster(ctx context.Context, ref authv1alpha1.ClusterKubeconfigReference) (
+	client.Client, kubeconfigCluster, error) {
+	key := clusterSecretKey(ref)
+	dataKey := ref.Key
+	if dataKey == "" {
+		dataKey = defaultClusterKubeconfigKey
+	}
+	var secret corev1.Secret
+	if err := r.Get(ctx, key, &secret); err != nil {
+		return nil, kubeconfigCluster{}, fmt.Errorf("failed to get kubeconfig secret %s: %w", key, err)
+	}
+
+	r.memberClientsMu.Lock()
+	defer r.memberClientsMu.Unlock()
+	cacheKey := key.String() + "/" + dataKey
+	if cached, ok := r.memberClients[cacheKey]; ok && cached.resourceVersion == secret.ResourceVersion {
+		return cached.client, cached.cluster, nil
+	}
+	data, ok := secret.Data[dataKey]
+	if !ok {
+		return nil, kubeconfigCluster{}, fmt.Errorf("kubeconfig secret %s has no key %s", key, dataKey)
+	}
+	config, err := clientcmd.RESTConfigFromKubeConfig(data)
+	if err != nil {
+		return nil, kubeconfigCluster{}, fmt.Errorf("invalid kubeconfig in secret %s: %w", key, err)
+	}
+	newClient := r.MemberClients
+	if newClient == nil {
+		newClient = NewMemberClient
+	}
+	c, err := newClient(config)
+	if err != nil {
+		return nil, kubeconfigCluster{}, fmt.Errorf("failed to connect to member cluster with secret %s: %w", key, err)
+	}
+	cluster := kubeconfigCluster{
+		Server:                config.Host,
+		CA:                    config.CAData,
+		TLSServerName:         config.ServerName,
+		InsecureSkipTLSVerify: config.Insecure,
+	}
+	if r.memberClients == nil {
+		r.memberClients = map[string]memberClient{}
+	}
+	r.memberClients[cacheKey] = memberClient{resourceVersion: secret.ResourceVersion, client: c, cluster: cluster}
+	return c, cluster, nil
+}
+
+// reconcileClusters provisions the user on its member clusters and removes it from those dropped
+// from spec.clusters, while the MultiCluster feature gate is enabled, and writes the kubeconfig
+// for all of them. A member cluster that fails does not hold up the others, nor the user on this
+// cluster; it is reported in status.clusters and retried. It returns when the member clusters
+// need to be reconciled again, 0 if not before the regular reconcile.
+func (r *UserReconciler) reconcileClusters(ctx context.Context, user *authv1alpha1.User) time.Duration {
+	logger := logf.FromContext(ctx)
+	if !r.MemberClusters {
+		// Member clusters provisioned before are left as they are
+		if len(user.Spec.Clusters) > 0 {
+			meta.SetStatusCondition(&user.Status.Conditions, metav1.Condition{
+				Type:               ConditionClustersReady,
+				Status:             metav1.ConditionFalse,
+				Reason:             "MultiClusterDisabled",
+				Message:            "Member clusters are not reconciled while the MultiCluster feature gate is disabled",
+				ObservedGeneration: user.Generation,
+			})
+		}
+		return 0
+	}
+	previous := map[string]authv1alpha1.ClusterStatus{}
+	for _, status := range user.Status.Clusters {
+		previous[status.Name] = status
+	}
+
+	key := clustersCredentialSecretKey(user.Name)
+	issued, err := r.memberCertificates(ctx, key, user.Name)
+	if err != nil {
+		logger.Error(err, "Failed to read member cluster credentials", "secret", key)
+	}
+
+	var statuses []authv1alpha1.ClusterStatus
+	var retry time.Duration
+	members := map[string]memberCredential{}
+	for _, target := range user.Spec.Clusters {
+		status, credential, delay := r.reconcileMemberCluster(ctx, user, target, issued[target.Name])
+		if credential != nil {
+			members[target.Name] = *credential
+			status.CredentialSecret = &corev1.SecretReference{Name: key.Name, Namespace: key.Namespace}
+		}
+		if status.State == authv1alpha1.ClusterStateFailed && previous[target.Name].State != authv1alpha1.ClusterStateFailed {
+			logger.Error(errors.New(status.Message), "Failed to provision user on member cluster", "cluster", target.Name)
+			r.event(user, corev1.EventTypeWarning, EventClusterProvisioningFailed,
+				"Failed to provision user on member cluster %s: %s", target.Name, status.Message)
+		}
+		statuses = append(statuses, status)
+		retry = untilRetry(retry, delay)
+		delete(previous, target.Name)
+	}
+
+	// Member clusters dropped from spec.clusters, which are only forgotten once the user is gone
+	for name, status := range previous {
+		if err := r.removeFromMemberCluster(ctx, user, name, status.KubeconfigSecretRef); err != nil {
+			logger.Error(err, "Failed to remove user from member cluster", "cluster", name)
+			status.State = authv1alpha1.ClusterStateFailed
+			status.Message = fmt.Sprintf("Failed to remove the user: %v", err)
+			statuses = append(statuses, status)
+			retry = untilRetry(retry, memberFailedRetry)
+			continue
+		}
+		logger.Info("Removed user from member cluster", "cluster", name)
+	}
+
+	if err := r.writeClustersCredentialSecret(ctx, user, key, members); err != nil {
+		logger.Error(err, "Failed to write member cluster credentials", "secret", key)
+		for i := range statuses {
+			if statuses[i].CredentialSecret != nil {
+				statuses[i].State = authv1alpha1.ClusterStateFailed
+				statuses[i].Message = fmt.Sprintf("Failed to write credentials: %v", err)
+			}
+		}
+		retry = untilRetry(retry, memberFailedRetry)
+	}
+
+	sort.Slice(statuses, func(i, j int) bool { return statuses[i].Name < statuses[j].Name })
+	user.Status.Clusters = statuses
+	setClustersCondition(user)
+	return retry
+}
+
+// untilRetry returns the earlier of two retry delays, where 0 means none
+func untilRetry(retry, delay time.Duration) time.Duration {
+	if retry == 0 || (delay > 0 && delay < retry) {
+		return delay
+	}
+	return retry
+}
+
+// reconcileMemberCluster provisions the user on one member cluster, given the certificate issued
+// there before, if any. It returns its status, the credential for the user's kubeconfig, if
+// there is one, and when to reconcile it again.
+func (r *UserReconciler) reconcileMemberCluster(ctx context.Context, user *authv1alpha1.User,
+	target authv1alpha1.ClusterTarget, issued []byte) (authv1alpha1.ClusterStatus, *memberCredential, time.Duration) {
+	status := authv1alpha1.ClusterStatus{Name: target.Name, KubeconfigSecretRef: target.KubeconfigSecretRef}
+	fail := func(err error) (authv1alpha1.ClusterStatus, *memberCredential, time.Duration) {
+		status.State = authv1alpha1.ClusterStateFailed
+		status.Message = err.Error()
+		return status, nil, memberFailedRetry
+	}
+	if isMachine(user) {
+		return fail(errors.New("machine users cannot be provisioned on member clusters"))
+	}
+	member, cluster, err := r.memberCluster(ctx, target.KubeconfigSecretRef)
+	if err != nil {
+		return fail(err)
+	}
+
+	bindings, namespaces := memberBindings(user, issued)
+	for _, namespace := range namespaces {
+		if err := ensureNamespace(ctx, member, namespace); err != nil {
+			return fail(fmt.Errorf("failed to create namespace %s: %w", namespace, err))
+		}
+	}
+	if err := syncMemberBindings(ctx, member, user.Name, bindings); err != nil {
+		return fail(err)
+	}
+	status.Bindings = int32(len(bindings))
+
+	switch {
+	case user.Spec.Revoked:
+		if err := issuer.NewCSRIssuer(member).Reset(ctx, memberCSRName(user.Name)); err != nil {
+			return fail(err)
+		}
+		status.State = authv1alpha1.ClusterStateReady
+		status.Message = "User is revoked, its bindings and credentials are removed"
+		return status, nil, 0
+	case user.Spec.Suspended:
+		status.State = authv1alpha1.ClusterStateReady
+		status.Message = "User is suspended, its bindings are removed"
+		if issued == nil {
+			return status, nil, 0
+		}
+		return status, &memberCredential{cluster: cluster, cert: issued}, 0
+	}
+
+	cert, expiry, renewAt, err := r.ensureMemberCredentials(ctx, member, user, target.Name, issued)
+	if errors.Is(err, issuer.ErrPending) {
+		status.State = authv1alpha1.ClusterStatePending
+		status.Message = "Certificate is being issued"
+		return status, nil, memberPendingRetry
+	} else if err != nil {
+		return fail(err)
+	}
+	status.State = authv1alpha1.ClusterStateReady
+	status.ExpiryTime = expiry.Format(time.RFC3339)
+	return status, &memberCredential{cluster: cluster, cert: cert}, max(time.Until(renewAt), time.Minute)
+}
+
+// memberBindings returns the bindings the user is to have on its member clusters, those bound
+// here, and the namespaces to create for them. Suspended and revoked users have none. They
+// are bound to the username, and to the common name of the certificate issued there before
+// while it differs, so it keeps working until its replacement for a new username template is issued.
+func memberBindings(user *authv1alpha1.User, issued []byte) ([]client.Object, []string) {
+	if user.Spec.Suspended || user.Spec.Revoked {
+		return nil, nil
+	}
+	username := operatorconfig.Current().Username(user.Name)
+	subjects := []rbacv1.Subject{{Kind: "User", Name: username}}
+	if previous := certificateCommonName(issued); previous != "" && previous != username {
+		subjects = append(subjects, rbacv1.Subject{Kind: "User", Name: previous})
+	}
+	createNamespace := map[string]bool{}
+	for _, role := range user.Spec.Roles {
+		if role.CreateNamespace {
+			createNamespace[role.Namespace] = true
+		}
+	}
+	var bindings []client.Object
+	var namespaces []string
+	for _, binding := range user.Status.Bindings {
+		if binding.State != authv1alpha1.BindingStateBound {
+			continue
+		}
+		kind, name, _ := strings.Cut(binding.RoleRef, "/")
+		objectMeta := metav1.ObjectMeta{
+			Name:      binding.Name,
+			Namespace: binding.Namespace,
+			Labels: map[string]string{
+				"auth.openkube.io/user":        user.Name,
+				"app.kubernetes.io/managed-by": "kubeuser",
+			},
+		}
+		roleRef := rbacv1.RoleRef{APIGroup: rbacv1.GroupName, Kind: kind, Name: name}
+		switch binding.Kind {
+		case "RoleBinding":
+			bindings = append(bindings, &rbacv1.RoleBinding{ObjectMeta: objectMeta, Subjects: subjects, RoleRef: roleRef})
+			if createNamespace[binding.Namespace] && !containsString(namespaces, binding.Namespace) {
+				namespaces = append(namespaces, binding.Namespace)
+			}
+		case "ClusterRoleBinding":
+			bindings = append(bindings, &rbacv1.ClusterRoleBinding{ObjectMeta: objectMeta, Subjects: subjects, RoleRef: roleRef})
+		}
+	}
+	return bindings, namespaces
+}
+
+// syncMemberBindings applies bindings on the member cluster and deletes the other bindings made
+// there for the user
+func syncMemberBindings(ctx context.Context, member client.Client, username string, bindings []client.Object) error {
+	desired := map[string]bool{}
+	for _, binding := range bindings {
+		if err := applyTo(ctx, member, binding); err != nil {
+			return fmt.Errorf("failed to apply binding %s: %w", client.ObjectKeyFromObject(binding), err)
+		}
+		desired[fmt.Sprintf("%T/%s", binding, client.ObjectKeyFromObject(binding))] = true
+	}
+
+	selector := client.MatchingLabels{"auth.openkube.io/user": username}
+	var rbs rbacv1.RoleBindingList
+	if err := member.List(ctx, &rbs, selector); err != nil {
+		return fmt.Errorf("failed to list RoleBindings: %w", err)
+	}
+	var crbs rbacv1.ClusterRoleBindingList
+	if err := member.List(ctx, &crbs, selector); err != nil {
+		return fmt.Errorf("failed to list ClusterRoleBindings: %w", err)
+	}
+	var existing []client.Object
+	for i := range rbs.Items {
+		existing = append(existing, &rbs.Items[i])
+	}
+	for i := range crbs.Items {
+		existing = append(existing, &crbs.Items[i])
+	}
+	for _, binding := range existing {
+		if desired[fmt.Sprintf("%T/%s", binding, client.ObjectKeyFromObject(binding))] {
+			continue
+		}
+		if err := member.Delete(ctx, binding); client.IgnoreNotFound(err) != nil {
+			return fmt.Errorf("failed to delete binding %s: %w", client.ObjectKeyFromObject(binding), err)
+		}
+	}
+	return nil
+}
+
+// ensureMemberCredentials returns a certificate the member cluster issued for the user's key:
+// issued while it is current, or a new one. It also returns when the certificate expires and
+// when it is to be renewed, or issuer.ErrPending while it is being issued.
+func (r *UserReconciler) ensureMemberCredentials(ctx context.Context, member client.Client, user *authv1alpha1.User,
+	clusterName string, issued []byte) ([]byte, time.Time, time.Time, error) {
+	duration, rotation, keyAlgorithm := r.certificateSettings(ctx, user)
+	if user.Spec.BreakGlass {
+		rotation = rotationPolicy{}
+	}
+	username := operatorconfig.Current().Username(user.Name)
+
+	var csrPEM []byte
+	var publicKey crypto.PublicKey
+	if user.Spec.CSR != "" {
+		csr, err := issuer.ParseCSR([]byte(user.Spec.CSR), username)
+		if err != nil {
+			return nil, time.Time{}, time.Time{}, fmt.Errorf("invalid spec.csr: %w", err)
+		}
+		publicKey, csrPEM = csr.PublicKey, []byte(user.Spec.CSR)
+	} else {
+		signer, pemData, err := r.ensureUserKey(ctx, userKeySecretName(user.Name), user, keyAlgorithm)
+		if err != nil {
+			return nil, time.Time{}, time.Time{}, err
+		}
+		if csrPEM, err = csrFromKey(username, pemData, user.Spec.Certificate); err != nil {
+			return nil, time.Time{}, time.Time{}, err
+		}
+		publicKey = signer.Public()
+	}
+	signer := issuer.NewCSRIssuer(member)
+	csrName := memberCSRName(user.Name)
+
+	switch {
+	case issued == nil:
+		// Nothing to keep or renew; issue one below
+	case certificateMatchesKey(issued, publicKey) && certificateHasSubject(issued, user.Spec.Certificate) &&
+		certificateCommonName(issued) == username:
+		cert, err := issuer.ParseCertificate(issued)
+		if err != nil {
+			return nil, time.Time{}, time.Time{}, err
+		}
+		if renewAt := rotation.renewAt(cert.NotBefore, cert.NotAfter); time.Now().Before(renewAt) {
+			return issued, cert.NotAfter, renewAt, nil
+		}
+		fallthrough
+	default:
+		// Expiring, or issued for another key, subject or username; the member cluster issues a new certificate,
+		// which is left out of the kubeconfig until then
+		logf.FromContext(ctx).Info("Renewing certificate for member cluster", "cluster", clusterName)
+		if err := signer.Reset(ctx, csrName); err != nil {
+			return nil, time.Time{}, time.Time{}, err
+		}
+	}
+
+	cert, err := signer.Sign(ctx, issuer.Request{
+		Name:     csrName,
+		Username: username,
+		CSR:      csrPEM,
+		Duration: breakGlassCertificateDuration(user, duration, time.Now()),
+		Labels:   map[string]string{"auth.openkube.io/user": user.Name},
+	})
+	if err != nil {
+		return nil, time.Time{}, time.Time{}, err
+	}
+	// A CSR left over from an earlier key or certificate is replaced
+	parsed, err := issuer.ParseCertificate(cert.PEM)
+	if err != nil {
+		return nil, time.Time{}, time.Time{}, err
+	}
+	renewAt := rotation.renewAt(parsed.NotBefore, parsed.NotAfter)
+	if !certificateMatchesKey(cert.PEM, publicKey) || !time.Now().Before(renewAt) {
+		if err := signer.Reset(ctx, csrName); err != nil {
+			return nil, time.Time{}, time.Time{}, err
+		}
+		return nil, time.Time{}, time.Time{}, issuer.ErrPending
+	}
+	r.event(user, corev1.EventTypeNormal, EventCertificateIssued,
+		"Issued client certificate for member cluster %s valid until %s", clusterName, cert.NotAfter.Format(time.RFC3339))
+	return cert.PEM, cert.NotAfter, renewAt, nil
+}
+
+// removeFromMemberCluster removes the user's bindings and certificate request from a member
+// cluster, whose context is left out of the user's kubeconfig from now on. Without the
+// kubeconfig Secret the member cluster cannot be reached anymore, and nothing is removed there.
+func (r *UserReconciler) removeFromMemberCluster(ctx context.Context, user *authv1alpha1.User, name string,
+	ref authv1alpha1.ClusterKubeconfigReference) error {
+	member, _, err := r.memberCluster(ctx, ref)
+	if apierrors.IsNotFound(err) {
+		logf.FromContext(ctx).Info("Kubeconfig secret of member cluster is gone, leaving its bindings",
+			"cluster", name, "secret", ref.Name)
+		return nil
+	} else if err != nil {
+		return err
+	}
+	if err := syncMemberBindings(ctx, member, user.Name, nil); err != nil {
+		return err
+	}
+	return issuer.NewCSRIssuer(member).Reset(ctx, memberCSRName(user.Name))
+}
+
+// removeFromMemberClusters removes a deleted user from all of its member clusters
+func (r *UserReconciler) removeFromMemberClusters(ctx context.Context, user *authv1alpha1.User) {
+	if err := r.deleteCredentialSecret(ctx, clustersCredentialSecretKey(user.Name), user.Name); err != nil {
+		logf.FromContext(ctx).Error(err, "Failed to delete member cluster credentials of deleted user")
+	}
+	refs := map[string]authv1alpha1.ClusterKubeconfigReference{}
+	for _, status := range user.Status.Clusters {
+		refs[status.Name] = status.KubeconfigSecretRef
+	}
+	for _, target := range user.Spec.Clusters {
+		refs[target.Name] = target.KubeconfigSecretRef
+	}
+	for name, ref := range refs {
+		if err := r.removeFromMemberCluster(ctx, user, name, ref); err != nil {
+			logf.FromContext(ctx).Error(err, "Failed to remove deleted user from member cluster", "cluster", name)
+		}
+	}
+}
+
+// setClustersCondition sets the ClustersReady condition from status.clusters
+func setClustersCondition(user *authv1alpha1.User) {
+	if len(user.Status.Clusters) == 0 {
+		meta.RemoveStatusCondition(&user.Status.Conditions, ConditionClustersReady)
+		return
+	}
+	var notReady []string
+	for _, cluster := range user.Status.Clusters {
+		if cluster.State != authv1alpha1.ClusterStateReady {
+			notReady = append(notReady, cluster.Name+": "+cluster.Message)
+		}
+	}
+	condition := metav1.Condition{
+		Type:               ConditionClustersReady,
+		Status:             metav1.ConditionTrue,
+		Reason:             "ClustersReady",
+		Message:            fmt.Sprintf("The user is provisioned on all %d member clusters", len(user.Status.Clusters)),
+		ObservedGeneration: user.Generation,
+	}
+	if len(notReady) > 0 {
+		condition.Status = metav1.ConditionFalse
+		condition.Reason = "ClustersNotReady"
+		condition.Message = fmt.Sprintf("%d of %d member clusters are not ready: %s",
+			len(notReady), len(user.Status.Clusters), strings.Join(notReady, "; "))
+	}
+	meta.SetStatusCondition(&user.Status.Conditions, condition)
+}
+
+// usersForClusterSecret maps a change of a member cluster's kubeconfig Secret to reconcile
+// requests for the Users provisioned on it
+func (r *UserReconciler) usersForClusterSecret(ctx context.Context, obj client.Object) []reconcile.Request {
+	var users authv1alpha1.UserList
+	if err := r.List(ctx, &users, client.MatchingFields{
+		UserClusterSecretIndex: client.ObjectKeyFromObject(obj).String()}); err != nil {
+		logf.FromContext(ctx).Error(err, "Failed to list users for member cluster secret change")
+		return nil
+	}
+	requests := make([]reconcile.Request, 0, len(users.Items))
+	for _, user := range users.Items {
+		requests = append(requests, reconcile.Request{NamespacedName: types.NamespacedName{Name: user.Name}})
+	}
+	if r.ClusterAPI {
+		requests = append(requests, r.usersForClusterAPISecret(ctx, obj)...)
+	}
+	return requests
+}
@@ -0,0 +1,182 @@
+/*
+Copyright 2025.
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+*/
+
+package controller
+
+import (
+	"fmt"
+	"strings"
+	"time"
+
+	"k8s.io/apimachinery/pkg/api/meta"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+
+	authv1alpha1 "github.com/openkube-hub/KubeUser/api/v1alpha1"
+)
+
+// Conditions set on every User, so that tooling can rely on them being present
+const (
+	// ConditionProvisioned is True once the user's resources were reconciled without error
+	ConditionProvisioned = "Provisioned"
+	// ConditionBindingsReady is True while every binding in status.bindings is bound
+	ConditionBindingsReady = "BindingsReady"
+	// ConditionCertificateReady is True while the user holds a valid certificate, or a machine
+	// user a valid token
+	ConditionCertificateReady = "CertificateReady"
+	// ConditionExpired is True while the user's certificate has expired
+	ConditionExpired = "Expired"
+)
+
+// setStandardConditions derives the standard conditions from the rest of the status and marks
+// the status as written for the user's current generation. The single Ready condition of
+// earlier versions, which changed meaning with the phase, is removed.
+func setStandardConditions(user *authv1alpha1.User, now time.Time) {
+	user.Status.ObservedGeneration = user.Generation
+	meta.RemoveStatusCondition(&user.Status.Conditions, PhaseReady)
+	for _, condition := range []metav1.Condition{
+		provisionedCondition(user),
+		bindingsReadyCondition(user),
+		certificateReadyCondition(user, now),
+		expiredCondition(user),
+	} {
+		condition.ObservedGeneration = user.Generation
+		// The transition time only moves when the condition status changes
+		meta.SetStatusCondition(&user.Status.Conditions, condition)
+	}
+}
+
+func provisionedCondition(user *authv1alpha1.User) metav1.Condition {
+	condition := metav1.Condition{
+		Type:    ConditionProvisioned,
+		Status:  metav1.ConditionTrue,
+		Reason:  "UserProvisioned",
+		Message: user.Status.Message,
+	}
+	switch user.Status.Phase {
+	case "", "Pending":
+		condition.Status, condition.Reason = metav1.ConditionFalse, "Provisioning"
+	case PhaseError:
+		condition.Status, condition.Reason = metav1.ConditionFalse, "ProvisioningFailed"
+	case PhaseSuspended:
+		condition.Status, condition.Reason = metav1.ConditionFalse, "Suspended"
+	case PhaseRevoked:
+		condition.Status, condition.Reason = metav1.ConditionFalse, "Revoked"
+	}
+	return condition
+}
+
+func bindingsReadyCondition(user *authv1alpha1.User) metav1.Condition {
+	var failed, planned []string
+	for _, binding := range user.Status.Bindings {
+		if binding.State == authv1alpha1.BindingStateBound {
+			continue
+		}
+		name := binding.Kind + " " + binding.Name
+		if binding.Namespace != "" {
+			name = binding.Kind + " " + binding.Namespace + "/" + binding.Name
+		}
+		if binding.LastError != "" {
+			failed = append(failed, name+": "+binding.LastError)
+		} else {
+			planned = append(planned, name)
+		}
+	}
+	total := len(user.Status.Bindings)
+	switch {
+	case len(failed) > 0:
+		return metav1.Condition{
+			Type:   ConditionBindingsReady,
+			Status: metav1.ConditionFalse,
+			Reason: "BindingsFailed",
+			Message: fmt.Sprintf("%d of %d bindings are not bound: %s",
+				len(failed), total, strings.Join(failed, "; ")),
+		}
+	case len(planned) > 0:
+		return metav1.Condition{
+			Type:    ConditionBindingsReady,
+			Status:  metav1.ConditionFalse,
+			Reason:  "ReportOnly",
+			Message: fmt.Sprintf("%d of %d bindings are not applied in report-only binding mode", len(planned), total),
+		}
+	case total == 0 && (user.Status.Phase == "" || user.Status.Phase == "Pending"):
+		return metav1.Condition{
+			Type:    ConditionBindingsReady,
+			Status:  metav1.ConditionUnknown,
+			Reason:  "Provisioning",
+			Message: "Bindings are not reconciled yet",
+		}
+	case total == 0:
+		return metav1.Condition{
+			Type:    ConditionBindingsReady,
+			Status:  metav1.ConditionTrue,
+			Reason:  "NoBindings",
+			Message: "The user is to have no bindings",
+		}
+	default:
+		return metav1.Condition{
+			Type:    ConditionBindingsReady,
+			Status:  metav1.ConditionTrue,
+			Reason:  "BindingsBound",
+			Message: fmt.Sprintf("All %d bindings are bound", total),
+		}
+	}
+}
+
+func certificateReadyCondition(user *authv1alpha1.User, now time.Time) metav1.Condition {
+	if user.Spec.EKS != nil && user.Spec.EKS.IAMOnly {
+		return metav1.Condition{
+			Type:    ConditionCertificateReady,
+			Status:  metav1.ConditionFalse,
+			Reason:  "NotRequested",
+			Message: "The user signs in through its IAM principal, no certificate is issued",
+		}
+	}
+	credential := "certificate"
+	if isMachine(user) {
+		credential = "token"
+	}
+	validUntil, valid := credentialsValidUntil(user, now)
+	switch {
+	case valid:
+		return metav1.Condition{
+			Type:    ConditionCertificateReady,
+			Status:  metav1.ConditionTrue,
+			Reason:  "CredentialsValid",
+			Message: fmt.Sprintf("The %s is valid until %s", credential, validUntil.UTC().Format(time.RFC3339)),
+		}
+	case validUntil.IsZero():
+		return metav1.Condition{
+			Type:    ConditionCertificateReady,
+			Status:  metav1.ConditionFalse,
+			Reason:  "NotIssued",
+			Message: fmt.Sprintf("No %s has been issued yet", credential),
+		}
+	default:
+		return metav1.Condition{
+			Type:    ConditionCertificateReady,
+			Status:  metav1.ConditionFalse,
+			Reason:  "CredentialsExpired",
+			Message: fmt.Sprintf("The %s expired at %s", credential, validUntil.UTC().Format(time.RFC3339)),
+		}
+	}
+}
+
+func expiredCondition(user *authv1alpha1.User) metav1.Condition {
+	if user.Status.Phase == PhaseExpired {
+		return metav1.Condition{
+			Type:    ConditionExpired,
+			Status:  metav1.ConditionTrue,
+			Reason:  "CertificateExpired",
+			Message: user.Status.Message,
+		}
+	}
+	return metav1.Condition{
+		Type:    ConditionExpired,
+		Status:  metav1.ConditionFalse,
+		Reason:  "NotExpired",
+		Message: "The user has not expired",
+	}
+}
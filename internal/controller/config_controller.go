@@ -0,0 +1,209 @@
+/*
+Copyright 2025.
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+*/
+
+package controller
+
+import (
+	"context"
+	"fmt"
+	"reflect"
+	"time"
+
+	"k8s.io/apimachinery/pkg/api/equality"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/api/meta"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	ctrl "sigs.k8s.io/controller-runtime"
+	"sigs.k8s.io/controller-runtime/pkg/builder"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	crcontroller "sigs.k8s.io/controller-runtime/pkg/controller"
+	"sigs.k8s.io/controller-runtime/pkg/event"
+	logf "sigs.k8s.io/controller-runtime/pkg/log"
+	"sigs.k8s.io/controller-runtime/pkg/predicate"
+
+	authv1alpha1 "github.com/openkube-hub/KubeUser/api/v1alpha1"
+	"github.com/openkube-hub/KubeUser/internal/operatorconfig"
+)
+
+// ConfigEventBuffer is the size of the channel the KubeUserConfig reconciler re-enqueues Users through
+const ConfigEventBuffer = 1024
+
+// KubeUserConfigReconciler puts the KubeUserConfig named "default" into effect and re-enqueues
+// every User when that changed the settings, so new defaults apply without a restart. It also
+// runs bulk rotations requested on it. It runs on the leader; KubeUserConfigSettingsReconciler
+// puts the settings into effect on the other replicas.
+type KubeUserConfigReconciler struct {
+	client.Client
+
+	// Store receives the settings; defaults to operatorconfig.DefaultStore
+	Store *operatorconfig.Store
+	// UserEvents receives every User after the settings changed
+	UserEvents chan<- event.GenericEvent
+
+	// pending is set while changed settings have not reached all Users yet
+	pending bool
+	// enqueued are the settings Users were last enqueued for
+	enqueued *operatorconfig.Settings
+}
+
+// +kubebuilder:rbac:groups=auth.openkube.io,resources=kubeuserconfigs,verbs=get;list;watch
+// +kubebuilder:rbac:groups=auth.openkube.io,resources=kubeuserconfigs/status,verbs=get;update;patch
+// +kubebuilder:rbac:groups=auth.openkube.io,resources=users,verbs=get;list;watch;patch
+
+// Reconcile applies the KubeUserConfig, or the defaults when it was deleted
+func (r *KubeUserConfigReconciler) Reconcile(ctx context.Context, req ctrl.Request) (ctrl.Result, error) {
+	logger := logf.FromContext(ctx)
+
+	var config authv1alpha1.KubeUserConfig
+	err := r.Get(ctx, req.NamespacedName, &config)
+	if apierrors.IsNotFound(err) {
+		// Restoring the defaults cannot fail; they were validated at startup
+		_, _ = r.Store.Apply(nil)
+		changed := r.settingsChanged()
+		if changed {
+			logger.Info("KubeUserConfig removed, restored defaults")
+		}
+		r.pending = r.pending || changed
+		return ctrl.Result{}, r.enqueueUsers(ctx)
+	} else if err != nil {
+		return ctrl.Result{}, err
+	}
+
+	_, applyErr := r.Store.Apply(&config.Spec)
+	changed := r.settingsChanged()
+	condition := metav1.Condition{
+		Type:               PhaseReady,
+		Status:             metav1.ConditionTrue,
+		Reason:             "Applied",
+		Message:            "Configuration is in effect",
+		ObservedGeneration: config.Generation,
+	}
+	if applyErr != nil {
+		logger.Error(applyErr, "Rejected KubeUserConfig, keeping previous settings")
+		condition.Status = metav1.ConditionFalse
+		condition.Reason = "Invalid"
+		condition.Message = fmt.Sprintf("Configuration rejected, previous settings stay in effect: %v", applyErr)
+	} else if changed {
+		logger.Info("Applied KubeUserConfig", "generation", config.Generation)
+	}
+	r.pending = r.pending || changed
+	if err := r.enqueueUsers(ctx); err != nil {
+		return ctrl.Result{}, err
+	}
+
+	rotation := config.Status.BulkRotation.DeepCopy()
+	requeueAfter, err := r.reconcileBulkRotation(ctx, &config, time.Now())
+	if err != nil {
+		return ctrl.Result{}, err
+	}
+
+	statusChanged := meta.SetStatusCondition(&config.Status.Conditions, condition)
+	if config.Status.ObservedGeneration != config.Generation {
+		config.Status.ObservedGeneration = config.Generation
+		statusChanged = true
+	}
+	if namespace := r.Store.Get().EffectiveNamespace(); config.Status.Namespace != namespace {
+		config.Status.Namespace = namespace
+		statusChanged = true
+	}
+	if !equality.Semantic.DeepEqual(rotation, config.Status.BulkRotation) {
+		statusChanged = true
+	}
+	if statusChanged {
+		if err := r.Status().Update(ctx, &config); err != nil {
+			return ctrl.Result{}, err
+		}
+	}
+	return ctrl.Result{RequeueAfter: requeueAfter}, nil
+}
+
+// settingsChanged reports whether the settings in effect differ from those Users were last
+// enqueued for. Store.Apply cannot tell, since the settings reconciler on the same replica may
+// have applied them first.
+func (r *KubeUserConfigReconciler) settingsChanged() bool {
+	current := r.Store.Get()
+	changed := r.enqueued == nil || !reflect.DeepEqual(*r.enqueued, current)
+	r.enqueued = &current
+	return changed
+}
+
+// enqueueUsers re-enqueues every User while changed settings are pending
+func (r *KubeUserConfigReconciler) enqueueUsers(ctx context.Context) error {
+	if !r.pending || r.UserEvents == nil {
+		r.pending = false
+		return nil
+	}
+	var users authv1alpha1.UserList
+	if err := r.List(ctx, &users); err != nil {
+		return fmt.Errorf("failed to list users: %w", err)
+	}
+	for i := range users.Items {
+		select {
+		case r.UserEvents <- event.GenericEvent{Object: &users.Items[i]}:
+		case <-ctx.Done():
+			return ctx.Err()
+		}
+	}
+	logf.FromContext(ctx).Info("Re-enqueued users for new settings", "users", len(users.Items))
+	r.pending = false
+	return nil
+}
+
+// SetupWithManager wires the controller. Only the KubeUserConfig named "default" is read.
+func (r *KubeUserConfigReconciler) SetupWithManager(mgr ctrl.Manager) error {
+	if r.Store == nil {
+		r.Store = operatorconfig.DefaultStore
+	}
+	return ctrl.NewControllerManagedBy(mgr).
+		For(&authv1alpha1.KubeUserConfig{}, builder.WithPredicates(defaultConfigOnly)).
+		Named("kubeuserconfig").
+		Complete(r)
+}
+
+// defaultConfigOnly selects the KubeUserConfig named "default"
+var defaultConfigOnly = predicate.NewPredicateFuncs(func(o client.Object) bool {
+	return o.GetName() == authv1alpha1.KubeUserConfigName
+})
+
+// KubeUserConfigSettingsReconciler puts the KubeUserConfig named "default" into effect on every
+// replica, leader or not, so the webhooks, the portal and the impersonation proxy they serve
+// use the same settings as the controller. It writes nothing to the cluster.
+type KubeUserConfigSettingsReconciler struct {
+	client.Client
+
+	// Store receives the settings; defaults to operatorconfig.DefaultStore
+	Store *operatorconfig.Store
+}
+
+// Reconcile applies the KubeUserConfig, or the defaults when it was deleted. A rejected
+// configuration is reported in its status by the leader.
+func (r *KubeUserConfigSettingsReconciler) Reconcile(ctx context.Context, req ctrl.Request) (ctrl.Result, error) {
+	var config authv1alpha1.KubeUserConfig
+	err := r.Get(ctx, req.NamespacedName, &config)
+	var spec *authv1alpha1.KubeUserConfigSpec
+	if err == nil {
+		spec = &config.Spec
+	} else if !apierrors.IsNotFound(err) {
+		return ctrl.Result{}, err
+	}
+	if changed, err := r.Store.Apply(spec); err == nil && changed {
+		logf.FromContext(ctx).Info("Put KubeUserConfig settings into effect", "deleted", spec == nil)
+	}
+	return ctrl.Result{}, nil
+}
+
+// SetupWithManager wires the controller without leader election
+func (r *KubeUserConfigSettingsReconciler) SetupWithManager(mgr ctrl.Manager) error {
+	if r.Store == nil {
+		r.Store = operatorconfig.DefaultStore
+	}
+	needLeaderElection := false
+	return ctrl.NewControllerManagedBy(mgr).
+		For(&authv1alpha1.KubeUserConfig{}, builder.WithPredicates(defaultConfigOnly)).
+		WithOptions(crcontroller.Options{NeedLeaderElection: &needLeaderElection}).
+		Named("kubeuserconfig-settings").
+		Complete(r)
+}
@@ -0,0 +1,174 @@
+/*
+Copyright 2025.
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+*/
+
+package controller
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	corev1 "k8s.io/api/core/v1"
+	rbacv1 "k8s.io/api/rbac/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/api/meta"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/types"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	logf "sigs.k8s.io/controller-runtime/pkg/log"
+
+	authv1alpha1 "github.com/openkube-hub/KubeUser/api/v1alpha1"
+	"github.com/openkube-hub/KubeUser/internal/naming"
+)
+
+// credentialReaderLabel marks the Role and RoleBinding that let a user read their own credential
+// Secret. They carry it instead of auth.openkube.io/user so they are not taken for bindings of
+// the user's spec, which are pruned and reported as access.
+const credentialReaderLabel = "auth.openkube.io/credential-reader"
+
+// ConditionCredentialAccessReady is False while the user's credential reader Role and
+// RoleBinding cannot be reconciled. It is removed once they are.
+const ConditionCredentialAccessReady = "CredentialAccessReady"
+
+// credentialAccessFailedRetry is how soon a user whose credential reader access could not be
+// reconciled is retried
+const credentialAccessFailedRetry = time.Minute
+
+// credentialReaderName returns the name of the user's credential reader Role and RoleBinding
+func credentialReaderName(username string) string {
+	return naming.Suffixed(naming.MaxNameLength, username, "credential-reader")
+}
+
+// syncCredentialAccess reconciles the user's credential reader access. Failures do not stop the
+// reconcile: they are reported in the CredentialAccessReady condition, and in an Event when the
+// access was not failing before. It returns when to retry, zero if nothing failed.
+func (r *UserReconciler) syncCredentialAccess(ctx context.Context, user *authv1alpha1.User) time.Duration {
+	err := r.reconcileCredentialAccess(ctx, user)
+	if err == nil {
+		meta.RemoveStatusCondition(&user.Status.Conditions, ConditionCredentialAccessReady)
+		return 0
+	}
+	logf.FromContext(ctx).Error(err, "Failed to reconcile credential reader access")
+	reason := "ReconcileFailed"
+	if apierrors.IsForbidden(err) {
+		// The operator is only granted Roles in the namespace it was installed for; a namespace
+		// set later in the KubeUserConfig needs the same grant
+		reason = "Forbidden"
+		err = fmt.Errorf("the operator may not manage Roles in namespace %s, grant it the manager Role there: %w",
+			getKubeUserNamespace(), err)
+	}
+	if condition := meta.FindStatusCondition(user.Status.Conditions, ConditionCredentialAccessReady); condition == nil ||
+		condition.Reason != reason {
+		r.event(user, corev1.EventTypeWarning, EventCredentialAccessFailed, "Failed to reconcile credential reader access: %v", err)
+	}
+	meta.SetStatusCondition(&user.Status.Conditions, metav1.Condition{
+		Type:               ConditionCredentialAccessReady,
+		Status:             metav1.ConditionFalse,
+		Reason:             reason,
+		Message:            err.Error(),
+		ObservedGeneration: user.Generation,
+	})
+	return credentialAccessFailedRetry
+}
+
+// reconcileCredentialAccess lets the user, through the same subjects as their bindings, get their
+// credential Secret and nothing else, so they can fetch their kubeconfig without an admin. The
+// Role and RoleBinding follow the Secret when spec.output.secretRef moves it within the kubeuser
+// namespace, and are removed while the user is revoked or suspended, when credential reader RBAC
+// is disabled or when the Secret is moved to another namespace, where the operator may not
+// create Roles.
+func (r *UserReconciler) reconcileCredentialAccess(ctx context.Context, user *authv1alpha1.User) error {
+	var target types.NamespacedName
+	enabled := r.CredentialReaderRBAC && !user.Spec.Revoked && !user.Spec.Suspended &&
+		credentialSecretKey(user).Namespace == getKubeUserNamespace()
+	if enabled {
+		target = types.NamespacedName{Name: credentialReaderName(user.Name), Namespace: credentialSecretKey(user).Namespace}
+	}
+	if err := r.deleteStaleCredentialAccess(ctx, user.Name, target); err != nil {
+		return err
+	}
+	if !enabled {
+		return nil
+	}
+
+	meta := metav1.ObjectMeta{
+		Name:      target.Name,
+		Namespace: target.Namespace,
+		Labels: map[string]string{
+			credentialReaderLabel:          user.Name,
+			"app.kubernetes.io/managed-by": "kubeuser",
+		},
+		OwnerReferences: []metav1.OwnerReference{{
+			APIVersion: "auth.openkube.io/v1alpha1",
+			Kind:       "User",
+			Name:       user.Name,
+			UID:        user.UID,
+			Controller: &[]bool{true}[0],
+		}},
+	}
+	// The kubeconfig for member clusters can be read too when it is next to the credential Secret
+	secrets := []string{credentialSecretKey(user).Name}
+	if clusters := clustersCredentialSecretKey(user.Name); r.MemberClusters && len(user.Spec.Clusters) > 0 &&
+		clusters.Namespace == target.Namespace {
+		secrets = append(secrets, clusters.Name)
+	}
+	role := &rbacv1.Role{
+		ObjectMeta: *meta.DeepCopy(),
+		Rules: []rbacv1.PolicyRule{{
+			APIGroups:     []string{""},
+			Resources:     []string{"secrets"},
+			ResourceNames: secrets,
+			Verbs:         []string{"get"},
+		}},
+	}
+	if err := r.apply(ctx, role); err != nil {
+		return err
+	}
+	binding := &rbacv1.RoleBinding{
+		ObjectMeta: *meta.DeepCopy(),
+		Subjects:   r.userSubjects(user),
+		RoleRef: rbacv1.RoleRef{
+			APIGroup: rbacv1.GroupName,
+			Kind:     "Role",
+			Name:     target.Name,
+		},
+	}
+	return r.apply(ctx, binding)
+}
+
+// deleteStaleCredentialAccess deletes the user's credential reader Roles and RoleBindings other
+// than the one at target, which is empty to delete them all. Roles are only created and deleted
+// in the kubeuser namespace; those elsewhere are left to garbage collection of the User.
+func (r *UserReconciler) deleteStaleCredentialAccess(ctx context.Context, username string, target types.NamespacedName) error {
+	selector := client.MatchingLabels{credentialReaderLabel: username}
+	var roles rbacv1.RoleList
+	if err := r.List(ctx, &roles, selector, client.InNamespace(getKubeUserNamespace())); err != nil {
+		return err
+	}
+	var bindings rbacv1.RoleBindingList
+	if err := r.List(ctx, &bindings, selector); err != nil {
+		return err
+	}
+	stale := []client.Object{}
+	for i := range bindings.Items {
+		if client.ObjectKeyFromObject(&bindings.Items[i]) != target {
+			stale = append(stale, &bindings.Items[i])
+		}
+	}
+	for i := range roles.Items {
+		if client.ObjectKeyFromObject(&roles.Items[i]) != target {
+			stale = append(stale, &roles.Items[i])
+		}
+	}
+	for _, obj := range stale {
+		logf.FromContext(ctx).Info("Deleting stale credential reader access", "name", obj.GetName(),
+			"namespace", obj.GetNamespace())
+		if err := r.Delete(ctx, obj); client.IgnoreNotFound(err) != nil {
+			return err
+		}
+	}
+	return nil
+}
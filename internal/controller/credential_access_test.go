@@ -0,0 +1,84 @@
+/*
+Copyright 2025.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package controller
+
+import (
+	"context"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+	rbacv1 "k8s.io/api/rbac/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/api/meta"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	clientgoscheme "k8s.io/client-go/kubernetes/scheme"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+	"sigs.k8s.io/controller-runtime/pkg/client/interceptor"
+
+	authv1alpha1 "github.com/openkube-hub/KubeUser/api/v1alpha1"
+)
+
+var _ = Describe("Credential reader access", func() {
+	var (
+		r         *UserReconciler
+		user      *authv1alpha1.User
+		forbidden bool
+		applied   []string
+	)
+
+	BeforeEach(func() {
+		forbidden, applied = false, nil
+		scheme := runtime.NewScheme()
+		Expect(clientgoscheme.AddToScheme(scheme)).To(Succeed())
+		Expect(authv1alpha1.AddToScheme(scheme)).To(Succeed())
+		// Roles are applied, which the fake client does not support, so applies are only recorded
+		c := fake.NewClientBuilder().WithScheme(scheme).WithInterceptorFuncs(interceptor.Funcs{
+			Patch: func(ctx context.Context, c client.WithWatch, obj client.Object, patch client.Patch,
+				opts ...client.PatchOption) error {
+				if _, ok := obj.(*rbacv1.Role); ok && forbidden {
+					return apierrors.NewForbidden(rbacv1.Resource("roles"), obj.GetName(), nil)
+				}
+				applied = append(applied, obj.GetObjectKind().GroupVersionKind().Kind+"/"+obj.GetName())
+				return nil
+			},
+		}).Build()
+		r = &UserReconciler{Client: c, Scheme: scheme, CredentialReaderRBAC: true}
+		user = &authv1alpha1.User{ObjectMeta: metav1.ObjectMeta{Name: "jane", UID: "uid-jane"}}
+	})
+
+	It("grants the user get on their credential Secret", func() {
+		Expect(r.syncCredentialAccess(context.Background(), user)).To(BeZero())
+		Expect(applied).To(ConsistOf("Role/jane-credential-reader", "RoleBinding/jane-credential-reader"))
+		Expect(meta.FindStatusCondition(user.Status.Conditions, ConditionCredentialAccessReady)).To(BeNil())
+	})
+
+	It("reports Roles it may not create in a condition instead of failing", func() {
+		forbidden = true
+		Expect(r.syncCredentialAccess(context.Background(), user)).To(Equal(credentialAccessFailedRetry))
+		condition := meta.FindStatusCondition(user.Status.Conditions, ConditionCredentialAccessReady)
+		Expect(condition).NotTo(BeNil())
+		Expect(condition.Status).To(Equal(metav1.ConditionFalse))
+		Expect(condition.Reason).To(Equal("Forbidden"))
+		Expect(condition.Message).To(ContainSubstring("may not manage Roles in namespace " + getKubeUserNamespace()))
+
+		forbidden = false
+		Expect(r.syncCredentialAccess(context.Background(), user)).To(BeZero())
+		Expect(meta.FindStatusCondition(user.Status.Conditions, ConditionCredentialAccessReady)).To(BeNil())
+	})
+})
@@ -0,0 +1,151 @@
+/*
+Copyright 2025.
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+*/
+
+package controller
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"slices"
+	"strings"
+
+	"go.opentelemetry.io/otel/attribute"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	logf "sigs.k8s.io/controller-runtime/pkg/log"
+
+	authv1alpha1 "github.com/openkube-hub/KubeUser/api/v1alpha1"
+	"github.com/openkube-hub/KubeUser/internal/operatorconfig"
+	"github.com/openkube-hub/KubeUser/internal/secretstore"
+	"github.com/openkube-hub/KubeUser/internal/tracing"
+)
+
+// credentialStoresAnnotation records which content of the credential Secret was written to which
+// credential store, as comma-separated name=digest pairs, so stores are only written when the
+// credentials or the path changed
+const credentialStoresAnnotation = "auth.openkube.io/credential-stores"
+
+// syncCredentialStores writes the user's credential Secret to the credential stores configured in
+// the KubeUserConfig. A rotation rewrites the Secret and so changes its digest, which writes the
+// new credentials to every store.
+func (r *UserReconciler) syncCredentialStores(ctx context.Context, user *authv1alpha1.User) error {
+	stores := operatorconfig.Current().CredentialStores
+	if len(stores) == 0 || r.CredentialStores == nil {
+		return nil
+	}
+	secret, err := r.getCredentialSecret(ctx, credentialSecretKey(user), user.Name)
+	if apierrors.IsNotFound(err) {
+		return nil
+	} else if err != nil {
+		return err
+	}
+	data := make(map[string]string, len(secret.Data))
+	for key, value := range secret.Data {
+		data[key] = string(value)
+	}
+
+	written := parseCredentialStores(secret.Annotations[credentialStoresAnnotation])
+	var errs []error
+	changed := false
+	for _, spec := range stores {
+		path, err := secretstore.RenderPath(spec.Path, user.Name)
+		if err != nil {
+			errs = append(errs, fmt.Errorf("credential store %s: %w", spec.Name, err))
+			continue
+		}
+		digest := credentialStoreDigest(path, data)
+		if written[spec.Name] == digest {
+			continue
+		}
+		store, err := r.CredentialStores.Open(ctx, spec, getKubeUserNamespace())
+		if err == nil {
+			putCtx, span := tracing.Start(ctx, "CredentialStore.Put",
+				attribute.String("store", spec.Name), attribute.String("path", path))
+			err = store.Put(putCtx, path, data)
+			tracing.End(span, err)
+		}
+		if err != nil {
+			errs = append(errs, fmt.Errorf("credential store %s: %w", spec.Name, err))
+			continue
+		}
+		logf.FromContext(ctx).Info("Wrote credentials to credential store", "store", spec.Name, "path", path)
+		written[spec.Name] = digest
+		changed = true
+	}
+	if changed {
+		base := secret.DeepCopy()
+		if secret.Annotations == nil {
+			secret.Annotations = map[string]string{}
+		}
+		secret.Annotations[credentialStoresAnnotation] = formatCredentialStores(written)
+		if err := r.Patch(ctx, secret, client.MergeFrom(base)); err != nil {
+			errs = append(errs, fmt.Errorf("failed to record credential stores: %w", err))
+		}
+	}
+	return errors.Join(errs...)
+}
+
+// deleteFromCredentialStores deletes the user's credentials from every configured credential
+// store. Paths of stores removed from the KubeUserConfig, or rendered from an earlier path
+// template, are not known anymore and stay behind.
+func (r *UserReconciler) deleteFromCredentialStores(ctx context.Context, user *authv1alpha1.User) error {
+	if r.CredentialStores == nil {
+		return nil
+	}
+	var errs []error
+	for _, spec := range operatorconfig.Current().CredentialStores {
+		path, err := secretstore.RenderPath(spec.Path, user.Name)
+		if err != nil {
+			errs = append(errs, fmt.Errorf("credential store %s: %w", spec.Name, err))
+			continue
+		}
+		store, err := r.CredentialStores.Open(ctx, spec, getKubeUserNamespace())
+		if err == nil {
+			err = store.Delete(ctx, path)
+		}
+		if err != nil {
+			errs = append(errs, fmt.Errorf("credential store %s: %w", spec.Name, err))
+		}
+	}
+	return errors.Join(errs...)
+}
+
+// credentialStoreDigest identifies what was written where
+func credentialStoreDigest(path string, data map[string]string) string {
+	h := sha256.New()
+	h.Write([]byte(path))
+	keys := make([]string, 0, len(data))
+	for key := range data {
+		keys = append(keys, key)
+	}
+	slices.Sort(keys)
+	for _, key := range keys {
+		fmt.Fprintf(h, "\x00%s\x00%s", key, data[key])
+	}
+	return hex.EncodeToString(h.Sum(nil))[:16]
+}
+
+func parseCredentialStores(annotation string) map[string]string {
+	written := map[string]string{}
+	for _, pair := range strings.Split(annotation, ",") {
+		if name, digest, ok := strings.Cut(pair, "="); ok {
+			written[name] = digest
+		}
+	}
+	return written
+}
+
+func formatCredentialStores(written map[string]string) string {
+	pairs := make([]string, 0, len(written))
+	for name, digest := range written {
+		pairs = append(pairs, name+"="+digest)
+	}
+	slices.Sort(pairs)
+	return strings.Join(pairs, ",")
+}
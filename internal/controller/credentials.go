@@ -0,0 +1,363 @@
+/*
+Copyright 2025.
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+*/
+
+package controller
+
+import (
+	"context"
+	"crypto/sha256"
+	"fmt"
+	"strings"
+	"time"
+
+	corev1 "k8s.io/api/core/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/types"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	logf "sigs.k8s.io/controller-runtime/pkg/log"
+
+	authv1alpha1 "github.com/openkube-hub/KubeUser/api/v1alpha1"
+	"github.com/openkube-hub/KubeUser/internal/ca"
+	"github.com/openkube-hub/KubeUser/internal/credentials"
+	"github.com/openkube-hub/KubeUser/internal/operatorconfig"
+	"github.com/openkube-hub/KubeUser/internal/pgp"
+)
+
+// credentialLayoutAnnotation records the layout a credential Secret was rendered with, so the
+// certificate can be found again and layout changes are detected
+const credentialLayoutAnnotation = "auth.openkube.io/credential-layout"
+
+// kubeconfigContextsAnnotation records the kubeconfig contexts a credential Secret was rendered
+// with, so the kubeconfig is re-rendered when roles or the default namespace change
+const kubeconfigContextsAnnotation = "auth.openkube.io/kubeconfig-contexts"
+
+// kubeconfigClusterAnnotation records the API server and CA a credential Secret was rendered
+// with, so the kubeconfig is re-rendered when either changes
+const kubeconfigClusterAnnotation = "auth.openkube.io/kubeconfig-cluster"
+
+// credentialRecipientsAnnotation records the fingerprints of the OpenPGP keys a credential
+// Secret was encrypted to, so it is encrypted again when spec.output.encryption changes
+const credentialRecipientsAnnotation = "auth.openkube.io/encrypted-to"
+
+// defaultAPIServer is the in-cluster API server, used when no external endpoint is known
+const defaultAPIServer = "https://kubernetes.default.svc"
+
+// kubeconfigCluster is the API server endpoint, CA bundle and connection settings written into
+// a user's kubeconfig
+type kubeconfigCluster struct {
+	Server                string
+	CA                    []byte
+	ProxyURL              string
+	TLSServerName         string
+	InsecureSkipTLSVerify bool
+}
+
+// String identifies the cluster for kubeconfigClusterAnnotation without repeating the CA.
+// Connection settings are only listed when set.
+func (c kubeconfigCluster) String() string {
+	sum := sha256.Sum256(c.CA)
+	s := fmt.Sprintf("server=%s;ca=%x", c.Server, sum[:8])
+	if c.ProxyURL != "" {
+		s += ";proxy=" + c.ProxyURL
+	}
+	if c.TLSServerName != "" {
+		s += ";tls-server-name=" + c.TLSServerName
+	}
+	if c.InsecureSkipTLSVerify {
+		s += ";insecure"
+	}
+	return s
+}
+
+// kubeconfigCluster resolves the cluster for the user's kubeconfig. The server is taken from
+// spec.output.server, the impersonation proxy, the operator config, kube-public/cluster-info,
+// then kubernetes.default.svc; the CA from spec.output.caBundle, the proxy's, then the CA resolver.
+// The proxy URL, TLS server name and insecure flag come from spec.output, then the operator config.
+func (r *UserReconciler) kubeconfigCluster(ctx context.Context, user *authv1alpha1.User) (kubeconfigCluster, error) {
+	settings := operatorconfig.Current()
+	cluster := kubeconfigCluster{
+		ProxyURL:              settings.ProxyURL,
+		TLSServerName:         settings.TLSServerName,
+		InsecureSkipTLSVerify: settings.InsecureSkipTLSVerify,
+	}
+	if output := user.Spec.Output; output != nil {
+		cluster.Server = output.Server
+		cluster.CA = []byte(output.CABundle)
+		if output.ProxyURL != "" {
+			cluster.ProxyURL = output.ProxyURL
+		}
+		if output.TLSServerName != "" {
+			cluster.TLSServerName = output.TLSServerName
+		}
+		if output.InsecureSkipTLSVerify != nil {
+			cluster.InsecureSkipTLSVerify = *output.InsecureSkipTLSVerify
+		}
+	}
+	if cluster.Server == "" && r.ProxyServer != "" {
+		cluster.Server = r.ProxyServer
+		if len(cluster.CA) == 0 {
+			cluster.CA = r.ProxyCA
+		}
+	}
+	if cluster.Server == "" {
+		cluster.Server = settings.APIServer
+	}
+	if cluster.Server == "" {
+		info, err := ca.LoadClusterInfo(ctx, r.Client)
+		if err == nil && info.Server != "" {
+			cluster.Server = info.Server
+		} else {
+			logf.FromContext(ctx).V(1).Info("No API server in cluster-info, using the in-cluster address",
+				"server", defaultAPIServer, "reason", err)
+			cluster.Server = defaultAPIServer
+		}
+	}
+	if len(cluster.CA) == 0 {
+		data, err := r.getClusterCA(ctx)
+		if err != nil {
+			return kubeconfigCluster{}, err
+		}
+		cluster.CA = data
+	}
+	return cluster, nil
+}
+
+// credentialRecipients are the OpenPGP keys a credential Secret is encrypted to; none stores
+// it in plaintext
+type credentialRecipients []*pgp.Recipient
+
+// String lists the fingerprints for credentialRecipientsAnnotation
+func (c credentialRecipients) String() string {
+	fingerprints := make([]string, 0, len(c))
+	for _, recipient := range c {
+		fingerprints = append(fingerprints, recipient.Fingerprint())
+	}
+	return strings.Join(fingerprints, ",")
+}
+
+// userCredentialRecipients parses the keys in spec.output.encryption
+func userCredentialRecipients(user *authv1alpha1.User) (credentialRecipients, error) {
+	if user.Spec.Output == nil || user.Spec.Output.Encryption == nil {
+		return nil, nil
+	}
+	recipients, err := pgp.ParseRecipients(user.Spec.Output.Encryption.Recipients)
+	if err != nil {
+		return nil, fmt.Errorf("invalid spec.output.encryption: %w", err)
+	}
+	return recipients, nil
+}
+
+// credentialLayout returns the layout for the user's credential Secret. ExecCredential
+// kubeconfigs hold no certificate or key, so the helper and rotation find them under tls.crt and
+// tls.key unless the layout already stores them. Encrypted Secrets keep the certificate readable
+// for rotation under tls.crt unless the layout already stores it on its own.
+func (r *UserReconciler) credentialLayout(user *authv1alpha1.User) credentials.Layout {
+	fallback := r.CredentialLayout
+	if len(fallback) == 0 {
+		fallback = credentials.DefaultLayout()
+	}
+	layout := credentials.FromSpec(user.Spec.Output, fallback)
+	output := user.Spec.Output
+	exec := output != nil && output.Format == authv1alpha1.KubeconfigFormatExecCredential
+	encrypted := output != nil && output.Encryption != nil && !isMachine(user)
+	if !exec && !encrypted {
+		return layout
+	}
+	extended := credentials.Layout{}
+	hasCert, hasKey := false, false
+	for key, format := range layout {
+		extended[key] = format
+		// Encrypted env files cannot be read for the certificate
+		hasCert = hasCert || format == authv1alpha1.CredentialFormatClientCert ||
+			format == authv1alpha1.CredentialFormatEnv && !encrypted
+		hasKey = hasKey || format == authv1alpha1.CredentialFormatClientKey || format == authv1alpha1.CredentialFormatEnv
+	}
+	if !hasCert {
+		extended[corev1.TLSCertKey] = authv1alpha1.CredentialFormatClientCert
+	}
+	if exec && !hasKey {
+		extended[corev1.TLSPrivateKeyKey] = authv1alpha1.CredentialFormatClientKey
+	}
+	return extended
+}
+
+// secretLayout returns the layout an existing credential Secret was written with. Secrets
+// written before layouts were configurable hold a single kubeconfig under "config".
+func secretLayout(secret *corev1.Secret) credentials.Layout {
+	if layout, err := credentials.ParseLayout(secret.Annotations[credentialLayoutAnnotation]); err == nil {
+		return layout
+	}
+	return credentials.DefaultLayout()
+}
+
+// credentialSecretKey returns where the user's credential Secret belongs: spec.output.secretRef,
+// or <user>-kubeconfig in the KubeUser namespace
+func credentialSecretKey(user *authv1alpha1.User) types.NamespacedName {
+	key := types.NamespacedName{Name: userKubeconfigSecretName(user.Name), Namespace: getKubeUserNamespace()}
+	if user.Spec.Output != nil && user.Spec.Output.SecretRef != nil {
+		key.Name = user.Spec.Output.SecretRef.Name
+		if user.Spec.Output.SecretRef.Namespace != "" {
+			key.Namespace = user.Spec.Output.SecretRef.Namespace
+		}
+	}
+	return key
+}
+
+// getCredentialSecret loads the credential Secret at key. Secrets the controller did not create
+// for the user are never read, overwritten or deleted, since secretRef can point anywhere.
+func (r *UserReconciler) getCredentialSecret(ctx context.Context, key types.NamespacedName,
+	username string) (*corev1.Secret, error) {
+	var secret corev1.Secret
+	if err := r.Get(ctx, key, &secret); err != nil {
+		return nil, err
+	}
+	if secret.Labels["auth.openkube.io/user"] != username {
+		return nil, fmt.Errorf("secret %s already exists and is not managed by KubeUser for user %s", key, username)
+	}
+	return &secret, nil
+}
+
+// applyCredentialSecret writes secret unless an unrelated Secret already exists there
+func (r *UserReconciler) applyCredentialSecret(ctx context.Context, secret *corev1.Secret, username string) error {
+	_, err := r.getCredentialSecret(ctx, client.ObjectKeyFromObject(secret), username)
+	if err != nil && !apierrors.IsNotFound(err) {
+		return err
+	}
+	return r.writeSecret(ctx, secret)
+}
+
+// moveCredentialSecret moves the credential Secret to target when spec.output.secretRef changed
+// and records its location in status. Secrets written before the location was recorded are in
+// the default location.
+func (r *UserReconciler) moveCredentialSecret(ctx context.Context, user *authv1alpha1.User,
+	target types.NamespacedName) error {
+	previous := types.NamespacedName{Name: userKubeconfigSecretName(user.Name), Namespace: getKubeUserNamespace()}
+	if ref := user.Status.CredentialSecret; ref != nil {
+		previous = types.NamespacedName{Name: ref.Name, Namespace: ref.Namespace}
+	}
+	if previous == target {
+		return nil
+	}
+
+	old, err := r.getCredentialSecret(ctx, previous, user.Name)
+	switch {
+	case apierrors.IsNotFound(err):
+		// Nothing written yet
+	case err != nil:
+		// Not ours; leave it alone
+		logf.FromContext(ctx).Info("Not moving unmanaged credential secret", "secret", previous, "reason", err.Error())
+	default:
+		logf.FromContext(ctx).Info("Moving credential secret", "from", previous, "to", target)
+		moved := userSecret(target, user, CredentialSecretType, old.Data)
+		moved.Annotations[credentialLayoutAnnotation] = old.Annotations[credentialLayoutAnnotation]
+		moved.Annotations[kubeconfigContextsAnnotation] = old.Annotations[kubeconfigContextsAnnotation]
+		moved.Annotations[kubeconfigClusterAnnotation] = old.Annotations[kubeconfigClusterAnnotation]
+		setRotatedAt(moved, rotatedAt(old))
+		for _, annotation := range []string{tokenExpiryAnnotation, credentialRecipientsAnnotation, certificateSubjectAnnotation} {
+			if value, ok := old.Annotations[annotation]; ok {
+				moved.Annotations[annotation] = value
+			}
+		}
+		if err := r.applyCredentialSecret(ctx, moved, user.Name); err != nil {
+			return fmt.Errorf("failed to move credential secret to %s: %w", target, err)
+		}
+		if err := r.Delete(ctx, old); err != nil && !apierrors.IsNotFound(err) {
+			return fmt.Errorf("failed to delete credential secret %s: %w", previous, err)
+		}
+	}
+
+	user.Status.CredentialSecret = &corev1.SecretReference{Name: target.Name, Namespace: target.Namespace}
+	return nil
+}
+
+// deleteCredentialSecret deletes the credential Secret at key if the controller created it
+func (r *UserReconciler) deleteCredentialSecret(ctx context.Context, key types.NamespacedName, username string) error {
+	secret, err := r.getCredentialSecret(ctx, key, username)
+	if apierrors.IsNotFound(err) {
+		return nil
+	} else if err != nil {
+		return err
+	}
+	if err := r.Delete(ctx, secret); err != nil && !apierrors.IsNotFound(err) {
+		return err
+	}
+	return nil
+}
+
+// writeCredentialSecret renders the signed certificate and key, issued at issuedAt, into the
+// credential Secret at key
+func (r *UserReconciler) writeCredentialSecret(ctx context.Context, key types.NamespacedName, user *authv1alpha1.User,
+	layout credentials.Layout, contexts kubeconfigContexts, cluster kubeconfigCluster, recipients credentialRecipients,
+	signedCert, keyPEM []byte, issuedAt time.Time) error {
+	username := user.Name
+	kubeconfig, err := buildCertKubeconfig(cluster, signedCert, keyPEM, username, contexts)
+	if err != nil {
+		return fmt.Errorf("failed to render kubeconfig: %w", err)
+	}
+	secret, err := credentialSecret(key, user, layout, contexts, cluster, recipients, credentials.Material{
+		Server:     cluster.Server,
+		Username:   username,
+		CA:         cluster.CA,
+		Cert:       signedCert,
+		Key:        keyPEM,
+		Kubeconfig: kubeconfig,
+	})
+	if err != nil {
+		return err
+	}
+	if subject := subjectString(user.Spec.Certificate); subject != "" {
+		secret.Annotations[certificateSubjectAnnotation] = subject
+	}
+	setRotatedAt(secret, issuedAt)
+	return r.applyCredentialSecret(ctx, secret, username)
+}
+
+// writeTokenSecret renders the ServiceAccount token of a machine user into the credential
+// Secret at key, recording when it expires
+func (r *UserReconciler) writeTokenSecret(ctx context.Context, key types.NamespacedName, user *authv1alpha1.User,
+	layout credentials.Layout, contexts kubeconfigContexts, cluster kubeconfigCluster, recipients credentialRecipients,
+	token string, expiry time.Time) error {
+	username := user.Name
+	kubeconfig, err := buildTokenKubeconfig(cluster, token, username, contexts)
+	if err != nil {
+		return fmt.Errorf("failed to render kubeconfig: %w", err)
+	}
+	secret, err := credentialSecret(key, user, layout, contexts, cluster, recipients, credentials.Material{
+		Server:     cluster.Server,
+		Username:   username,
+		CA:         cluster.CA,
+		Token:      token,
+		Kubeconfig: kubeconfig,
+	})
+	if err != nil {
+		return err
+	}
+	secret.Annotations[tokenExpiryAnnotation] = expiry.UTC().Format(time.RFC3339)
+	setRotatedAt(secret, time.Now())
+	return r.applyCredentialSecret(ctx, secret, username)
+}
+
+// credentialSecret renders m with layout into the credential Secret at key, encrypted to
+// recipients if there are any, annotated with what it was rendered from
+func credentialSecret(key types.NamespacedName, user *authv1alpha1.User, layout credentials.Layout,
+	contexts kubeconfigContexts, cluster kubeconfigCluster, recipients credentialRecipients,
+	m credentials.Material) (*corev1.Secret, error) {
+	data, err := layout.Render(m)
+	if err != nil {
+		return nil, err
+	}
+	secret := userSecret(key, user, CredentialSecretType, data)
+	secret.Annotations[credentialLayoutAnnotation] = layout.String()
+	secret.Annotations[kubeconfigContextsAnnotation] = contexts.String()
+	secret.Annotations[kubeconfigClusterAnnotation] = cluster.String()
+	if len(recipients) > 0 {
+		if err := layout.Encrypt(data, recipients); err != nil {
+			return nil, err
+		}
+		secret.Annotations[credentialRecipientsAnnotation] = recipients.String()
+	}
+	return secret, nil
+}
@@ -0,0 +1,181 @@
+/*
+Copyright 2025.
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+*/
+
+package controller
+
+import (
+	"encoding/json"
+	"net/http"
+	"slices"
+	"strings"
+	"sync"
+	"time"
+
+	ctrl "sigs.k8s.io/controller-runtime"
+	"sigs.k8s.io/controller-runtime/pkg/metrics"
+
+	authv1alpha1 "github.com/openkube-hub/KubeUser/api/v1alpha1"
+)
+
+// Diagnostics is the document served by DiagnosticsHandler: the state of the User workqueue
+// and the reconciles of every user since the controller started
+type Diagnostics struct {
+	GeneratedAt time.Time `json:"generatedAt"`
+	// Queue holds the workqueue metrics of the User controller without their workqueue_
+	// prefix, e.g. depth, adds_total and retries_total
+	Queue map[string]float64 `json:"queue"`
+	Users []UserDiagnostics  `json:"users"`
+}
+
+// UserDiagnostics describes the reconciles of one user. Reconciles skipped by an open circuit
+// breaker are not counted.
+type UserDiagnostics struct {
+	Name           string    `json:"name"`
+	Reconciles     int       `json:"reconciles"`
+	Failures       int       `json:"failures"`
+	FirstReconcile time.Time `json:"firstReconcile"`
+	LastReconcile  time.Time `json:"lastReconcile"`
+	// LastDuration is how long the last reconcile took
+	LastDuration string `json:"lastDuration"`
+	// RequeueAfter is when the last reconcile asked to run again
+	RequeueAfter  string     `json:"requeueAfter,omitempty"`
+	LastError     string     `json:"lastError,omitempty"`
+	LastErrorTime *time.Time `json:"lastErrorTime,omitempty"`
+	// ConsecutiveFailures counts towards --circuit-breaker-failures
+	ConsecutiveFailures int        `json:"consecutiveFailures,omitempty"`
+	CircuitOpenUntil    *time.Time `json:"circuitOpenUntil,omitempty"`
+}
+
+// reconcileDiagnostics remembers the reconciles of each user for DiagnosticsHandler
+type reconcileDiagnostics struct {
+	mu    sync.Mutex
+	users map[string]*UserDiagnostics
+}
+
+func (d *reconcileDiagnostics) record(name string, start time.Time, duration time.Duration, result ctrl.Result, err error) {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	if d.users == nil {
+		d.users = map[string]*UserDiagnostics{}
+	}
+	user, ok := d.users[name]
+	if !ok {
+		user = &UserDiagnostics{Name: name, FirstReconcile: start.UTC()}
+		d.users[name] = user
+	}
+	user.Reconciles++
+	user.LastReconcile = start.UTC()
+	user.LastDuration = duration.String()
+	user.RequeueAfter = ""
+	if result.RequeueAfter > 0 {
+		user.RequeueAfter = result.RequeueAfter.String()
+	}
+	if err != nil {
+		at := start.UTC()
+		user.Failures++
+		user.LastError, user.LastErrorTime = err.Error(), &at
+	}
+}
+
+// snapshot copies the records of the users in keep, and forgets the others, which were deleted
+func (d *reconcileDiagnostics) snapshot(keep map[string]bool) []UserDiagnostics {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	users := make([]UserDiagnostics, 0, len(d.users))
+	for name, user := range d.users {
+		if keep != nil && !keep[name] {
+			delete(d.users, name)
+			continue
+		}
+		users = append(users, *user)
+	}
+	slices.SortFunc(users, func(a, b UserDiagnostics) int { return strings.Compare(a.Name, b.Name) })
+	return users
+}
+
+// state returns the user's consecutive failures and until when its breaker is open, if it is
+func (b *circuitBreaker) state(name string) (int, time.Time) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	if state, ok := b.users[name]; ok {
+		return state.failures, state.openUntil
+	}
+	return 0, time.Time{}
+}
+
+// DiagnosticsHandler serves Diagnostics as JSON, for finding users that reconcile in a loop or
+// keep failing without rebuilding the controller with more logging. ?user=<name> limits the
+// users to one and ?failing=true to those whose last reconcile failed. The records are kept
+// in memory by the controller that is reconciling.
+func (r *UserReconciler) DiagnosticsHandler() http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+		if req.Method != http.MethodGet {
+			http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
+			return
+		}
+		// Records of deleted users are dropped; without the list they are all kept
+		var keep map[string]bool
+		var list authv1alpha1.UserList
+		if err := r.List(req.Context(), &list); err == nil {
+			keep = make(map[string]bool, len(list.Items))
+			for _, user := range list.Items {
+				keep[user.Name] = true
+			}
+		}
+
+		name, failing := req.URL.Query().Get("user"), req.URL.Query().Get("failing") == "true"
+		diagnostics := Diagnostics{GeneratedAt: time.Now().UTC(), Queue: workqueueMetrics("user"), Users: []UserDiagnostics{}}
+		for _, user := range r.diagnostics.snapshot(keep) {
+			if name != "" && user.Name != name {
+				continue
+			}
+			if failing && (user.LastErrorTime == nil || !user.LastErrorTime.Equal(user.LastReconcile)) {
+				continue
+			}
+			failures, openUntil := r.breaker().state(user.Name)
+			user.ConsecutiveFailures = failures
+			if time.Now().Before(openUntil) {
+				openUntil = openUntil.UTC()
+				user.CircuitOpenUntil = &openUntil
+			}
+			diagnostics.Users = append(diagnostics.Users, user)
+		}
+		w.Header().Set("Content-Type", "application/json")
+		_ = json.NewEncoder(w).Encode(diagnostics)
+	})
+}
+
+// workqueueMetrics returns the gauges and counters of the named controller's workqueue from the
+// controller-runtime registry, summed over priorities
+func workqueueMetrics(controllerName string) map[string]float64 {
+	queue := map[string]float64{}
+	families, err := metrics.Registry.Gather()
+	if err != nil {
+		return queue
+	}
+	for _, family := range families {
+		name, ok := strings.CutPrefix(family.GetName(), "workqueue_")
+		if !ok {
+			continue
+		}
+		for _, metric := range family.GetMetric() {
+			matches := false
+			for _, label := range metric.GetLabel() {
+				matches = matches || label.GetName() == "name" && label.GetValue() == controllerName
+			}
+			if !matches {
+				continue
+			}
+			switch {
+			case metric.GetGauge() != nil:
+				queue[name] += metric.GetGauge().GetValue()
+			case metric.GetCounter() != nil:
+				queue[name] += metric.GetCounter().GetValue()
+			}
+		}
+	}
+	return queue
+}
@@ -0,0 +1,88 @@
+/*
+Copyright 2025.
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+*/
+
+package controller
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"k8s.io/apimachinery/pkg/api/meta"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	logf "sigs.k8s.io/controller-runtime/pkg/log"
+
+	authv1alpha1 "github.com/openkube-hub/KubeUser/api/v1alpha1"
+)
+
+const (
+	// DefaultDirectorySyncInterval is how often the directory group of a Team is read again
+	DefaultDirectorySyncInterval = 10 * time.Minute
+
+	// ConditionDirectorySynced is True while the directory group of a Team could be read
+	ConditionDirectorySynced = "DirectorySynced"
+)
+
+// syncDirectory reads the members of t's directory group into its status when a sync is due,
+// and returns when the next one is. Failures are reported in the DirectorySynced condition and
+// keep the members of the last successful sync, so a directory outage does not remove anyone.
+func (r *TeamReconciler) syncDirectory(ctx context.Context, t *authv1alpha1.Team, now time.Time) time.Duration {
+	sync := t.Spec.DirectorySync
+	if sync == nil {
+		t.Status.DirectoryMembers = nil
+		t.Status.LastDirectorySync = nil
+		meta.RemoveStatusCondition(&t.Status.Conditions, ConditionDirectorySynced)
+		return 0
+	}
+
+	interval := r.DirectorySyncInterval
+	if interval <= 0 {
+		interval = DefaultDirectorySyncInterval
+	}
+	previous := meta.FindStatusCondition(t.Status.Conditions, ConditionDirectorySynced)
+	if last := t.Status.LastDirectorySync; last != nil && previous != nil &&
+		previous.ObservedGeneration == t.Generation && now.Before(last.Add(interval)) {
+		return last.Add(interval).Sub(now)
+	}
+
+	condition := metav1.Condition{
+		Type:               ConditionDirectorySynced,
+		Status:             metav1.ConditionTrue,
+		Reason:             "Synced",
+		ObservedGeneration: t.Generation,
+	}
+	members, err := r.readDirectory(ctx, sync)
+	if err != nil {
+		logf.FromContext(ctx).Error(err, "Failed to read directory group", "team", t.Name,
+			"provider", sync.Provider, "group", sync.Group)
+		condition.Status = metav1.ConditionFalse
+		condition.Reason = "SyncFailed"
+		condition.Message = err.Error()
+	} else {
+		t.Status.DirectoryMembers = members
+		condition.Message = fmt.Sprintf("%d members in %s group %s", len(members), sync.Provider, sync.Group)
+	}
+	t.Status.LastDirectorySync = &metav1.Time{Time: now}
+	meta.SetStatusCondition(&t.Status.Conditions, condition)
+	return interval
+}
+
+// readDirectory returns the members of sync's group
+func (r *TeamReconciler) readDirectory(ctx context.Context, sync *authv1alpha1.DirectorySync) ([]authv1alpha1.DirectoryMember, error) {
+	dir, ok := r.Directories[sync.Provider]
+	if !ok {
+		return nil, fmt.Errorf("directory provider %s is not configured", sync.Provider)
+	}
+	members, err := dir.Members(ctx, sync.Group)
+	if err != nil {
+		return nil, err
+	}
+	result := make([]authv1alpha1.DirectoryMember, 0, len(members))
+	for _, m := range members {
+		result = append(result, authv1alpha1.DirectoryMember{Name: m.Name, Disabled: m.Disabled})
+	}
+	return result, nil
+}
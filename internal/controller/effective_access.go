@@ -0,0 +1,117 @@
+/*
+Copyright 2025.
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+*/
+
+package controller
+
+import (
+	"context"
+	"fmt"
+
+	authorizationv1 "k8s.io/api/authorization/v1"
+	rbacv1 "k8s.io/api/rbac/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/client-go/kubernetes"
+	"k8s.io/client-go/rest"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+
+	authv1alpha1 "github.com/openkube-hub/KubeUser/api/v1alpha1"
+	"github.com/openkube-hub/KubeUser/internal/rbacsummary"
+)
+
+// RulesReviewer returns the rules the API server applies to a user in a namespace, as a
+// SelfSubjectRulesReview made by that user would
+type RulesReviewer func(ctx context.Context, username string, groups []string, namespace string) (
+	authorizationv1.SubjectRulesReviewStatus, error)
+
+// ImpersonatingRulesReviewer reviews rules with SelfSubjectRulesReviews made as the user, by
+// impersonating it with config, the manager's
+func ImpersonatingRulesReviewer(config *rest.Config) RulesReviewer {
+	return func(ctx context.Context, username string, groups []string, namespace string) (
+		authorizationv1.SubjectRulesReviewStatus, error) {
+		impersonated := rest.CopyConfig(config)
+		impersonated.Impersonate = rest.ImpersonationConfig{UserName: username, Groups: groups}
+		clientset, err := kubernetes.NewForConfig(impersonated)
+		if err != nil {
+			return authorizationv1.SubjectRulesReviewStatus{}, err
+		}
+		review, err := clientset.AuthorizationV1().SelfSubjectRulesReviews().Create(ctx,
+			&authorizationv1.SelfSubjectRulesReview{Spec: authorizationv1.SelfSubjectRulesReviewSpec{Namespace: namespace}},
+			metav1.CreateOptions{})
+		if err != nil {
+			return authorizationv1.SubjectRulesReviewStatus{}, err
+		}
+		return review.Status, nil
+	}
+}
+
+// summarizeEffectiveAccess fills status.effectiveAccess from the roles the user's managed
+// bindings refer to. With a RulesReviewer, the summary of every namespace holding a managed
+// RoleBinding is what the API server reports instead, which also covers bindings KubeUser does
+// not manage and the groups of machine users.
+func (r *UserReconciler) summarizeEffectiveAccess(ctx context.Context, user *authv1alpha1.User) error {
+	selector := client.MatchingFields{BindingUserIndex: user.Name}
+	var rbs rbacv1.RoleBindingList
+	if err := r.List(ctx, &rbs, selector); err != nil {
+		return err
+	}
+	var crbs rbacv1.ClusterRoleBindingList
+	if err := r.List(ctx, &crbs, selector); err != nil {
+		return err
+	}
+
+	rules := map[string][]rbacv1.PolicyRule{}
+	for _, crb := range crbs.Items {
+		roleRules, err := r.roleRefRules(ctx, "", crb.RoleRef)
+		if err != nil {
+			return err
+		}
+		rules[""] = append(rules[""], roleRules...)
+	}
+	for _, rb := range rbs.Items {
+		roleRules, err := r.roleRefRules(ctx, rb.Namespace, rb.RoleRef)
+		if err != nil {
+			return err
+		}
+		rules[rb.Namespace] = append(rules[rb.Namespace], roleRules...)
+	}
+	summary := rbacsummary.Summarize(rules)
+
+	if r.RulesReviewer != nil {
+		username, groups := reviewIdentity(user)
+		for i := range summary {
+			if summary[i].Namespace == "" {
+				// Cluster-wide rules are only reported together with those of a namespace
+				continue
+			}
+			status, err := r.RulesReviewer(ctx, username, groups, summary[i].Namespace)
+			if err != nil {
+				return fmt.Errorf("failed to review rules in namespace %s: %w", summary[i].Namespace, err)
+			}
+			summary[i].Rules = rbacsummary.ReviewRules(status)
+			summary[i].Incomplete = status.Incomplete
+		}
+	}
+	user.Status.EffectiveAccess = summary
+	return nil
+}
+
+// roleRefRules returns the rules of the role a binding in namespace refers to; a role that does
+// not exist grants nothing
+func (r *UserReconciler) roleRefRules(ctx context.Context, namespace string, ref rbacv1.RoleRef) ([]rbacv1.PolicyRule, error) {
+	if ref.Kind == "Role" {
+		var role rbacv1.Role
+		if err := r.Get(ctx, types.NamespacedName{Namespace: namespace, Name: ref.Name}, &role); err != nil {
+			return nil, client.IgnoreNotFound(err)
+		}
+		return role.Rules, nil
+	}
+	var clusterRole rbacv1.ClusterRole
+	if err := r.Get(ctx, types.NamespacedName{Name: ref.Name}, &clusterRole); err != nil {
+		return nil, client.IgnoreNotFound(err)
+	}
+	return clusterRole.Rules, nil
+}
@@ -0,0 +1,228 @@
+/*
+Copyright 2025.
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+*/
+
+package controller
+
+import (
+	"context"
+	"fmt"
+	"slices"
+	"time"
+
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/meta"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	logf "sigs.k8s.io/controller-runtime/pkg/log"
+
+	authv1alpha1 "github.com/openkube-hub/KubeUser/api/v1alpha1"
+	"github.com/openkube-hub/KubeUser/internal/aws"
+	"github.com/openkube-hub/KubeUser/internal/operatorconfig"
+)
+
+// ConditionEKSAccessReady is True while the user's EKS access entry and access policies match
+// spec.eks. It is only set on users with spec.eks.
+const ConditionEKSAccessReady = "EKSAccessReady"
+
+// eksFailedRetry is how soon a user whose access entry could not be reconciled is retried
+const eksFailedRetry = time.Minute
+
+// Tags marking the access entries the controller created, and for which user. Entries without
+// them are never changed or deleted.
+const (
+	eksManagedByTag = "app.kubernetes.io/managed-by"
+	eksUserTag      = "auth.openkube.io/user"
+)
+
+// reconcileEKSAccess gives the user an access entry on the EKS cluster for spec.eks, and
+// corrects changes made to it outside of KubeUser. The entry is deleted while the user is
+// suspended, revoked or expired, and once spec.eks is removed or names another principal.
+// It returns when to retry, zero if nothing failed.
+func (r *UserReconciler) reconcileEKSAccess(ctx context.Context, user *authv1alpha1.User) time.Duration {
+	if r.EKS == nil {
+		// Access entries created before are left as they are
+		if user.Spec.EKS != nil {
+			setEKSAccessCondition(user, metav1.ConditionFalse, "EKSDisabled",
+				"Access entries are not reconciled without the controller's --eks-cluster-name")
+		}
+		return 0
+	}
+	logger := logf.FromContext(ctx)
+	var principal string
+	if user.Spec.EKS != nil && cloudAccessAllowed(user) {
+		principal = user.Spec.EKS.PrincipalARN
+	}
+
+	if previous := user.Status.EKSPrincipalARN; previous != "" && previous != principal {
+		if err := r.deleteEKSAccessEntry(ctx, user.Name, previous); err != nil {
+			logger.Error(err, "Failed to delete EKS access entry", "principal", previous)
+			r.eksAccessFailed(user, fmt.Errorf("failed to delete the access entry of %s: %w", previous, err))
+			return eksFailedRetry
+		}
+		logger.Info("Deleted EKS access entry", "principal", previous, "cluster", r.EKS.Cluster())
+		user.Status.EKSPrincipalARN = ""
+	}
+	if user.Spec.EKS == nil {
+		meta.RemoveStatusCondition(&user.Status.Conditions, ConditionEKSAccessReady)
+		return 0
+	}
+	if principal == "" {
+		setEKSAccessCondition(user, metav1.ConditionFalse, "AccessWithdrawn",
+			fmt.Sprintf("The access entry is removed while the user is %s", user.Status.Phase))
+		return 0
+	}
+
+	if err := r.ensureEKSAccessEntry(ctx, user); err != nil {
+		logger.Error(err, "Failed to reconcile EKS access entry", "principal", principal)
+		r.eksAccessFailed(user, err)
+		return eksFailedRetry
+	}
+	setEKSAccessCondition(user, metav1.ConditionTrue, "AccessEntryReady",
+		fmt.Sprintf("%s signs in to EKS cluster %s as the user", principal, r.EKS.Cluster()))
+	return 0
+}
+
+// cloudAccessAllowed reports whether the user may have cloud-side access, an access entry or
+// IAM roles. Its bindings are already removed otherwise, but access policies and IAM roles such
+// as roles/container.developer would still grant access.
+func cloudAccessAllowed(user *authv1alpha1.User) bool {
+	return !user.Spec.Revoked && !user.Spec.Suspended && user.Status.Phase != PhaseExpired
+}
+
+// ensureEKSAccessEntry creates the user's access entry, or updates it to the username and no
+// groups, and associates exactly the access policies in spec.eks
+func (r *UserReconciler) ensureEKSAccessEntry(ctx context.Context, user *authv1alpha1.User) error {
+	principal := user.Spec.EKS.PrincipalARN
+	desired := aws.AccessEntry{
+		PrincipalARN: principal,
+		Username:     operatorconfig.Current().Username(user.Name),
+		Tags:         map[string]string{eksManagedByTag: "kubeuser", eksUserTag: user.Name},
+	}
+	entry, err := r.EKS.DescribeAccessEntry(ctx, principal)
+	switch {
+	case aws.IsError(err, "ResourceNotFoundException"):
+		if err := r.EKS.CreateAccessEntry(ctx, desired); err != nil {
+			return fmt.Errorf("failed to create the access entry: %w", err)
+		}
+		logf.FromContext(ctx).Info("Created EKS access entry", "principal", principal, "cluster", r.EKS.Cluster())
+	case err != nil:
+		return fmt.Errorf("failed to read the access entry: %w", err)
+	case !ownsEKSAccessEntry(entry, user.Name):
+		return fmt.Errorf("the access entry of %s was not created by KubeUser for this user", principal)
+	case entry.Username != desired.Username || len(entry.KubernetesGroups) > 0:
+		if err := r.EKS.UpdateAccessEntry(ctx, desired); err != nil {
+			return fmt.Errorf("failed to update the access entry: %w", err)
+		}
+		logf.FromContext(ctx).Info("Reverted changes to EKS access entry", "principal", principal,
+			"username", entry.Username, "groups", entry.KubernetesGroups)
+	}
+	user.Status.EKSPrincipalARN = principal
+
+	associated, err := r.EKS.ListAssociatedAccessPolicies(ctx, principal)
+	if err != nil {
+		return fmt.Errorf("failed to list the access policies: %w", err)
+	}
+	wanted := map[string]bool{}
+	for _, policy := range user.Spec.EKS.AccessPolicies {
+		wanted[policy.PolicyARN] = true
+		scope := eksAccessScope(policy)
+		i := slices.IndexFunc(associated, func(a aws.AccessPolicy) bool { return a.PolicyARN == policy.PolicyARN })
+		if i >= 0 && eksAccessScopesEqual(associated[i].AccessScope, scope) {
+			continue
+		}
+		association := aws.AccessPolicy{PolicyARN: policy.PolicyARN, AccessScope: scope}
+		if err := r.EKS.AssociateAccessPolicy(ctx, principal, association); err != nil {
+			return fmt.Errorf("failed to associate access policy %s: %w", policy.PolicyARN, err)
+		}
+	}
+	for _, policy := range associated {
+		if wanted[policy.PolicyARN] {
+			continue
+		}
+		if err := r.EKS.DisassociateAccessPolicy(ctx, principal, policy.PolicyARN); err != nil &&
+			!aws.IsError(err, "ResourceNotFoundException") {
+			return fmt.Errorf("failed to disassociate access policy %s: %w", policy.PolicyARN, err)
+		}
+	}
+	return nil
+}
+
+// deleteEKSAccessEntry deletes the access entry of principal if the controller created it for
+// the user; a missing entry is already deleted
+func (r *UserReconciler) deleteEKSAccessEntry(ctx context.Context, username, principal string) error {
+	entry, err := r.EKS.DescribeAccessEntry(ctx, principal)
+	if aws.IsError(err, "ResourceNotFoundException") {
+		return nil
+	} else if err != nil {
+		return err
+	}
+	if !ownsEKSAccessEntry(entry, username) {
+		return nil
+	}
+	err = r.EKS.DeleteAccessEntry(ctx, principal)
+	if aws.IsError(err, "ResourceNotFoundException") {
+		return nil
+	}
+	return err
+}
+
+// removeEKSAccess deletes the access entries of a deleted user
+func (r *UserReconciler) removeEKSAccess(ctx context.Context, user *authv1alpha1.User) {
+	if r.EKS == nil {
+		return
+	}
+	principals := []string{user.Status.EKSPrincipalARN}
+	if user.Spec.EKS != nil {
+		principals = append(principals, user.Spec.EKS.PrincipalARN)
+	}
+	for _, principal := range slices.Compact(principals) {
+		if principal == "" {
+			continue
+		}
+		if err := r.deleteEKSAccessEntry(ctx, user.Name, principal); err != nil {
+			logf.FromContext(ctx).Error(err, "Failed to delete EKS access entry of deleted user", "principal", principal)
+		}
+	}
+}
+
+func ownsEKSAccessEntry(entry *aws.AccessEntry, username string) bool {
+	return entry.Tags[eksManagedByTag] == "kubeuser" && entry.Tags[eksUserTag] == username
+}
+
+// eksAccessScope is the scope of policy: its namespaces, in order, or the whole cluster
+func eksAccessScope(policy authv1alpha1.EKSAccessPolicy) aws.AccessScope {
+	if len(policy.Namespaces) == 0 {
+		return aws.AccessScope{Type: aws.AccessScopeCluster}
+	}
+	namespaces := slices.Clone(policy.Namespaces)
+	slices.Sort(namespaces)
+	return aws.AccessScope{Type: aws.AccessScopeNamespace, Namespaces: slices.Compact(namespaces)}
+}
+
+func eksAccessScopesEqual(a, b aws.AccessScope) bool {
+	namespaces := slices.Clone(a.Namespaces)
+	slices.Sort(namespaces)
+	return a.Type == b.Type && slices.Equal(namespaces, b.Namespaces)
+}
+
+// eksAccessFailed reports err in the EKSAccessReady condition, and in an Event when the access
+// entry was not failing before
+func (r *UserReconciler) eksAccessFailed(user *authv1alpha1.User, err error) {
+	if condition := meta.FindStatusCondition(user.Status.Conditions, ConditionEKSAccessReady); condition == nil ||
+		condition.Reason != "AccessEntryFailed" {
+		r.event(user, corev1.EventTypeWarning, EventEKSAccessFailed, "Failed to reconcile EKS access entry: %v", err)
+	}
+	setEKSAccessCondition(user, metav1.ConditionFalse, "AccessEntryFailed", err.Error())
+}
+
+func setEKSAccessCondition(user *authv1alpha1.User, status metav1.ConditionStatus, reason, message string) {
+	meta.SetStatusCondition(&user.Status.Conditions, metav1.Condition{
+		Type:               ConditionEKSAccessReady,
+		Status:             status,
+		Reason:             reason,
+		Message:            message,
+		ObservedGeneration: user.Generation,
+	})
+}
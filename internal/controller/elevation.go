@@ -0,0 +1,103 @@
+/*
+Copyright 2025.
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+*/
+
+package controller
+
+import (
+	"fmt"
+	"strings"
+	"time"
+
+	"k8s.io/apimachinery/pkg/api/meta"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+
+	authv1alpha1 "github.com/openkube-hub/KubeUser/api/v1alpha1"
+)
+
+// ConditionElevationExpired is True while the spec still lists elevated grants whose end time
+// has passed. Their bindings are already removed; the condition tells the user why access ended.
+const ConditionElevationExpired = "ElevationExpired"
+
+// elevationEnded reports whether the grant is an elevation whose end time has passed
+func elevationEnded(spec authv1alpha1.ClusterRoleSpec, now time.Time) bool {
+	return spec.Elevation != nil && !now.Before(spec.Elevation.Until.Time)
+}
+
+// activeClusterRoles returns the cluster role grants that should currently be bound: those
+// whose elevation, expiresAt or duration has not ended
+func activeClusterRoles(user *authv1alpha1.User, now time.Time) []authv1alpha1.ClusterRoleSpec {
+	active := make([]authv1alpha1.ClusterRoleSpec, 0, len(user.Spec.ClusterRoles))
+	for _, spec := range user.Spec.ClusterRoles {
+		if !elevationEnded(spec, now) && !grantEnded(user, "ClusterRole", "", spec.ExistingClusterRole, now) {
+			active = append(active, spec)
+		}
+	}
+	return active
+}
+
+// boundClusterRoles returns the cluster role grants to bind: the active ones, or none while
+// the user is suspended, revoked or outside of its access schedule
+func boundClusterRoles(user *authv1alpha1.User, now time.Time) []authv1alpha1.ClusterRoleSpec {
+	if user.Spec.Suspended || user.Spec.Revoked || !inAccessWindow(user, now) {
+		return nil
+	}
+	return activeClusterRoles(user, now)
+}
+
+// boundRoles returns the namespaced role grants to bind: the active ones, or none while the
+// user is suspended, revoked or outside of its access schedule
+func boundRoles(user *authv1alpha1.User, now time.Time) []authv1alpha1.RoleSpec {
+	if user.Spec.Suspended || user.Spec.Revoked || !inAccessWindow(user, now) {
+		return nil
+	}
+	return activeRoles(user, now)
+}
+
+// untilNextElevationEnd caps a requeue delay so the controller wakes up when the next elevation ends
+func untilNextElevationEnd(user *authv1alpha1.User, now time.Time, delay time.Duration) time.Duration {
+	for _, spec := range user.Spec.ClusterRoles {
+		if spec.Elevation == nil || elevationEnded(spec, now) {
+			continue
+		}
+		if remaining := spec.Elevation.Until.Sub(now); remaining < delay {
+			delay = remaining
+		}
+	}
+	return delay
+}
+
+// endedElevation reports whether clusterRole was granted to user by an elevation that has ended
+func endedElevation(user *authv1alpha1.User, clusterRole string, now time.Time) bool {
+	for _, spec := range user.Spec.ClusterRoles {
+		if spec.ExistingClusterRole == clusterRole && elevationEnded(spec, now) {
+			return true
+		}
+	}
+	return false
+}
+
+// setElevationCondition reports elevations that have ended, or drops the condition once
+// they have been removed from the spec
+func setElevationCondition(user *authv1alpha1.User, now time.Time) {
+	var ended []string
+	for _, spec := range user.Spec.ClusterRoles {
+		if elevationEnded(spec, now) {
+			ended = append(ended, fmt.Sprintf("ClusterRole %s at %s", spec.ExistingClusterRole,
+				spec.Elevation.Until.UTC().Format(time.RFC3339)))
+		}
+	}
+	if len(ended) == 0 {
+		meta.RemoveStatusCondition(&user.Status.Conditions, ConditionElevationExpired)
+		return
+	}
+	meta.SetStatusCondition(&user.Status.Conditions, metav1.Condition{
+		Type:               ConditionElevationExpired,
+		Status:             metav1.ConditionTrue,
+		Reason:             "ElevationEnded",
+		Message:            "Elevated access ended: " + strings.Join(ended, ", "),
+		ObservedGeneration: user.Generation,
+	})
+}
@@ -0,0 +1,77 @@
+/*
+Copyright 2025.
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+*/
+
+package controller
+
+import (
+	"context"
+
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+
+	authv1alpha1 "github.com/openkube-hub/KubeUser/api/v1alpha1"
+	"github.com/openkube-hub/KubeUser/internal/notify"
+)
+
+// Reasons of the Events recorded on Users, shown by kubectl describe user
+const (
+	EventUserActive                = "UserActive"
+	EventUserExpired               = "UserExpired"
+	EventExpiredUserDeleted        = "ExpiredUserDeleted"
+	EventUserSuspended             = "UserSuspended"
+	EventUserRevoked               = "UserRevoked"
+	EventProvisioningFailed        = "ProvisioningFailed"
+	EventCertificateIssued         = "CertificateIssued"
+	EventCertificateRotated        = "CertificateRotated"
+	EventCertificateExpiringSoon   = "CertificateExpiringSoon"
+	EventTokenIssued               = "TokenIssued"
+	EventCredentialStoreFailed     = "CredentialStoreFailed"
+	EventRoleBindingCreated        = "RoleBindingCreated"
+	EventRoleBindingDeleted        = "RoleBindingDeleted"
+	EventClusterRoleBindingCreated = "ClusterRoleBindingCreated"
+	EventClusterRoleBindingDeleted = "ClusterRoleBindingDeleted"
+	EventPolicyViolation           = "PolicyViolation"
+	EventBreakGlassActivated       = "BreakGlassActivated"
+	EventBreakGlassExpired         = "BreakGlassExpired"
+	EventClusterProvisioningFailed = "ClusterProvisioningFailed"
+	EventEKSAccessFailed           = "EKSAccessFailed"
+	EventGCPAccessFailed           = "GCPAccessFailed"
+	EventCredentialAccessFailed    = "CredentialAccessFailed"
+)
+
+// event records an Event on obj; it is a no-op when the reconciler has no recorder, e.g. in tests
+func (r *UserReconciler) event(obj runtime.Object, eventType, reason, messageFmt string, args ...any) {
+	if r.Recorder == nil {
+		return
+	}
+	r.Recorder.Eventf(obj, eventType, reason, messageFmt, args...)
+}
+
+// phaseEvent records the transition of a user into a new phase and notifies about it
+func (r *UserReconciler) phaseEvent(ctx context.Context, user *authv1alpha1.User, previous string) {
+	if previous == user.Status.Phase {
+		return
+	}
+	switch user.Status.Phase {
+	case "Active":
+		r.event(user, corev1.EventTypeNormal, EventUserActive, "%s", user.Status.Message)
+		// A renewed certificate ends ExpiringSoon; that is announced as rotated
+		if previous != PhaseExpiringSoon {
+			r.notify(ctx, user, notify.EventActive, user.Status.Message, nil)
+		}
+	case PhaseExpired:
+		r.event(user, corev1.EventTypeWarning, EventUserExpired, "%s", user.Status.Message)
+		r.notify(ctx, user, notify.EventExpired, user.Status.Message, nil)
+	case PhaseSuspended:
+		r.event(user, corev1.EventTypeWarning, EventUserSuspended, "%s", user.Status.Message)
+	case PhaseRevoked:
+		r.event(user, corev1.EventTypeWarning, EventUserRevoked, "%s", user.Status.Message)
+		r.notify(ctx, user, notify.EventRevoked, user.Status.Message, nil)
+	case PhaseError:
+		r.event(user, corev1.EventTypeWarning, EventProvisioningFailed, "%s", user.Status.Message)
+		r.notify(ctx, user, notify.EventReconcileFailed, user.Status.Message, nil)
+	}
+}
@@ -0,0 +1,136 @@
+/*
+Copyright 2025.
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+*/
+
+package controller
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	corev1 "k8s.io/api/core/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/api/meta"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	logf "sigs.k8s.io/controller-runtime/pkg/log"
+
+	authv1alpha1 "github.com/openkube-hub/KubeUser/api/v1alpha1"
+	"github.com/openkube-hub/KubeUser/internal/notify"
+	"github.com/openkube-hub/KubeUser/internal/operatorconfig"
+)
+
+// ConditionExpiringSoon is True while the user's certificate is within one of the expiry
+// warning windows. The reason names the shortest window it is in, e.g. Within7d.
+const ConditionExpiringSoon = "ExpiringSoon"
+
+// expiryWindow returns the shortest of the windows, ordered longest first, that remaining
+// falls into, or 0 when it is in none
+func expiryWindow(windows []time.Duration, remaining time.Duration) time.Duration {
+	var window time.Duration
+	for _, w := range windows {
+		if remaining <= w {
+			window = w
+		}
+	}
+	return window
+}
+
+// formatWindow renders whole days as e.g. 7d and other windows as durations
+func formatWindow(window time.Duration) string {
+	if window%(24*time.Hour) == 0 {
+		return fmt.Sprintf("%dd", window/(24*time.Hour))
+	}
+	return window.String()
+}
+
+// setExpiryStatus moves an active user to ExpiringSoon while its certificate, valid until
+// expiry, is within a warning window. Entering a window records a Warning Event and sends
+// an expiring notification.
+func (r *UserReconciler) setExpiryStatus(ctx context.Context, user *authv1alpha1.User, expiry, now time.Time) {
+	window := expiryWindow(operatorconfig.Current().ExpiryWarnings, expiry.Sub(now))
+	if window == 0 {
+		meta.SetStatusCondition(&user.Status.Conditions, metav1.Condition{
+			Type:               ConditionExpiringSoon,
+			Status:             metav1.ConditionFalse,
+			Reason:             "NotWithinWarning",
+			Message:            fmt.Sprintf("Certificate expires at %s", expiry.UTC().Format(time.RFC3339)),
+			ObservedGeneration: user.Generation,
+		})
+		return
+	}
+
+	reason := "Within" + formatWindow(window)
+	message := fmt.Sprintf("Certificate expires at %s, within the %s warning window",
+		expiry.UTC().Format(time.RFC3339), formatWindow(window))
+	user.Status.Phase = PhaseExpiringSoon
+	user.Status.Message = message
+	previous := meta.FindStatusCondition(user.Status.Conditions, ConditionExpiringSoon)
+	meta.SetStatusCondition(&user.Status.Conditions, metav1.Condition{
+		Type:               ConditionExpiringSoon,
+		Status:             metav1.ConditionTrue,
+		Reason:             reason,
+		Message:            message,
+		ObservedGeneration: user.Generation,
+	})
+	if previous == nil || previous.Reason != reason {
+		r.event(user, corev1.EventTypeWarning, EventCertificateExpiringSoon, "%s", message)
+		r.notify(ctx, user, notify.EventExpiring, message, map[string]string{"window": formatWindow(window)})
+	}
+}
+
+// expiredDeletionTime returns when an Expired user is deleted after the grace period, or zero
+// when expired users are kept or the user is not Expired
+func expiredDeletionTime(user *authv1alpha1.User, grace time.Duration) time.Time {
+	if grace <= 0 || user.Status.Phase != PhaseExpired {
+		return time.Time{}
+	}
+	expiry, err := time.Parse(time.RFC3339, user.Status.ExpiryTime)
+	if err != nil {
+		return time.Time{}
+	}
+	return expiry.Add(grace)
+}
+
+// expiredMessage is the status message of an Expired user, naming when it will be deleted
+func expiredMessage(user *authv1alpha1.User) string {
+	deleteAt := expiredDeletionTime(user, operatorconfig.Current().DeleteAfterExpiry)
+	if deleteAt.IsZero() {
+		return "User certificate has expired"
+	}
+	return fmt.Sprintf("User certificate has expired, the User is deleted at %s", deleteAt.UTC().Format(time.RFC3339))
+}
+
+// reconcileExpiredDeletion deletes a User that has been Expired for longer than the configured
+// deleteAfterExpiry; the finalizer then removes its bindings, credentials and certificate
+// request. It reports whether the User was deleted.
+func (r *UserReconciler) reconcileExpiredDeletion(ctx context.Context, user *authv1alpha1.User) (bool, error) {
+	deleteAt := expiredDeletionTime(user, operatorconfig.Current().DeleteAfterExpiry)
+	if deleteAt.IsZero() || time.Now().Before(deleteAt) {
+		return false, nil
+	}
+
+	logf.FromContext(ctx).Info("User expired past its grace period, deleting user", "expiry", user.Status.ExpiryTime)
+	r.event(user, corev1.EventTypeWarning, EventExpiredUserDeleted,
+		"Certificate expired at %s, deleting the User with its bindings and credentials", user.Status.ExpiryTime)
+	if err := r.Delete(ctx, user); err != nil && !apierrors.IsNotFound(err) {
+		return false, fmt.Errorf("failed to delete expired user: %w", err)
+	}
+	return true, nil
+}
+
+// untilExpiredDeletion caps a requeue delay so the controller wakes up when an Expired user is
+// due for deletion. A zero delay, meaning no requeue, becomes the time until then.
+func untilExpiredDeletion(user *authv1alpha1.User, now time.Time, delay time.Duration) time.Duration {
+	deleteAt := expiredDeletionTime(user, operatorconfig.Current().DeleteAfterExpiry)
+	if deleteAt.IsZero() {
+		return delay
+	}
+	remaining := max(deleteAt.Sub(now), time.Second)
+	if delay == 0 || remaining < delay {
+		return remaining
+	}
+	return delay
+}
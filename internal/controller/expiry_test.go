@@ -0,0 +1,104 @@
+/*
+Copyright 2025.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package controller
+
+import (
+	"time"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+	rbacv1 "k8s.io/api/rbac/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/types"
+
+	authv1alpha1 "github.com/openkube-hub/KubeUser/api/v1alpha1"
+	"github.com/openkube-hub/KubeUser/internal/operatorconfig"
+)
+
+var _ = Describe("Expired user deletion", func() {
+	const name = "expired-user"
+	var (
+		r           *UserReconciler
+		clusterRole *rbacv1.ClusterRole
+		binding     *rbacv1.ClusterRoleBinding
+	)
+
+	// expire marks the user Expired since expiredFor, as a reconcile past its certificate's
+	// expiry does
+	expire := func(expiredFor time.Duration) {
+		GinkgoHelper()
+		Eventually(func() error {
+			var user authv1alpha1.User
+			if err := k8sClient.Get(ctx, types.NamespacedName{Name: name}, &user); err != nil {
+				return err
+			}
+			user.Status.Phase = PhaseExpired
+			user.Status.ExpiryTime = time.Now().Add(-expiredFor).UTC().Format(time.RFC3339)
+			return k8sClient.Status().Update(ctx, &user)
+		}, 10*time.Second).Should(Succeed())
+	}
+
+	BeforeEach(func() {
+		_, err := operatorconfig.DefaultStore.Apply(&authv1alpha1.KubeUserConfigSpec{
+			DeleteAfterExpiry: &metav1.Duration{Duration: time.Hour},
+		})
+		Expect(err).NotTo(HaveOccurred())
+		DeferCleanup(func() { _, _ = operatorconfig.DefaultStore.Apply(nil) })
+
+		r = newCachedReconciler(&pendingIssuer{})
+		clusterRole = &rbacv1.ClusterRole{
+			ObjectMeta: metav1.ObjectMeta{Name: "expired-user-reader"},
+			Rules:      []rbacv1.PolicyRule{{APIGroups: []string{""}, Resources: []string{"pods"}, Verbs: []string{"get"}}},
+		}
+		Expect(k8sClient.Create(ctx, clusterRole)).To(Succeed())
+		Expect(k8sClient.Create(ctx, &authv1alpha1.User{
+			ObjectMeta: metav1.ObjectMeta{Name: name},
+			Spec: authv1alpha1.UserSpec{
+				ClusterRoles: []authv1alpha1.ClusterRoleSpec{{ExistingClusterRole: clusterRole.Name}},
+			},
+		})).To(Succeed())
+		binding = &rbacv1.ClusterRoleBinding{ObjectMeta: metav1.ObjectMeta{
+			Name: clusterRoleBindingName(name, clusterRole.Name)}}
+		reconcileUntil(r, name, func(g Gomega) { expectExists(g, binding, true) })
+	})
+
+	AfterEach(func() {
+		deleteUser(r, name)
+		Expect(k8sClient.Delete(ctx, clusterRole)).To(Succeed())
+	})
+
+	It("deletes the user with its bindings once the grace period has passed", func() {
+		expire(2 * time.Hour)
+		reconcileUntil(r, name, func(g Gomega) {
+			err := k8sClient.Get(ctx, types.NamespacedName{Name: name}, &authv1alpha1.User{})
+			g.Expect(apierrors.IsNotFound(err)).To(BeTrue())
+			expectExists(g, binding, false)
+		})
+	})
+
+	It("keeps the user during the grace period", func() {
+		expire(30 * time.Minute)
+		reconcileUntil(r, name, func(g Gomega) {
+			var user authv1alpha1.User
+			g.Expect(k8sClient.Get(ctx, types.NamespacedName{Name: name}, &user)).To(Succeed())
+			g.Expect(user.DeletionTimestamp).To(BeNil())
+			g.Expect(user.Status.Phase).To(Equal(PhaseExpired))
+			g.Expect(user.Status.Message).To(ContainSubstring("the User is deleted at"))
+		})
+	})
+})
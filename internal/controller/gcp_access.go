@@ -0,0 +1,173 @@
+/*
+Copyright 2025.
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+*/
+
+package controller
+
+import (
+	"context"
+	"fmt"
+	"slices"
+	"strings"
+	"time"
+
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/meta"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	logf "sigs.k8s.io/controller-runtime/pkg/log"
+
+	authv1alpha1 "github.com/openkube-hub/KubeUser/api/v1alpha1"
+	"github.com/openkube-hub/KubeUser/internal/gcp"
+)
+
+// ConditionGCPAccessReady is True while the Google account in spec.gcp holds its IAM roles on
+// the project. It is only set on users with spec.gcp.
+const ConditionGCPAccessReady = "GCPAccessReady"
+
+// DefaultGCPRole is granted when spec.gcp lists no roles. It only lets the account get the
+// credentials of the project's GKE clusters; RBAC decides what it can do in them.
+const DefaultGCPRole = "roles/container.clusterViewer"
+
+const (
+	// gcpFailedRetry is how soon a user whose IAM roles could not be reconciled is retried
+	gcpFailedRetry = time.Minute
+	// gcpPolicyAttempts bounds the writes of a policy that others change concurrently
+	gcpPolicyAttempts = 3
+)
+
+// reconcileGCPAccess grants the Google account in spec.gcp its IAM roles on the project, and
+// revokes the roles the controller granted before once they are removed from spec.gcp, the
+// account changes, or the user is suspended, revoked or expired. It returns when to retry, zero
+// if nothing failed.
+func (r *UserReconciler) reconcileGCPAccess(ctx context.Context, user *authv1alpha1.User) time.Duration {
+	if r.GCP == nil {
+		// Roles granted before are left as they are
+		if user.Spec.GCP != nil {
+			setGCPAccessCondition(user, metav1.ConditionFalse, "GCPDisabled",
+				"IAM roles are not granted without the controller's --gcp-project")
+		}
+		return 0
+	}
+	var account string
+	var roles []string
+	if user.Spec.GCP != nil && cloudAccessAllowed(user) {
+		account, roles = user.Spec.GCP.Account, gcpRoles(user.Spec.GCP)
+	}
+
+	if err := r.updateGCPPolicy(ctx, user, account, roles); err != nil {
+		logf.FromContext(ctx).Error(err, "Failed to reconcile GCP IAM roles", "project", r.GCPProject)
+		r.gcpAccessFailed(user, err)
+		return gcpFailedRetry
+	}
+	switch {
+	case user.Spec.GCP == nil:
+		meta.RemoveStatusCondition(&user.Status.Conditions, ConditionGCPAccessReady)
+	case account == "":
+		setGCPAccessCondition(user, metav1.ConditionFalse, "AccessWithdrawn",
+			fmt.Sprintf("IAM roles are revoked while the user is %s", user.Status.Phase))
+	default:
+		setGCPAccessCondition(user, metav1.ConditionTrue, "RolesGranted",
+			fmt.Sprintf("%s holds %s on project %s", account, strings.Join(roles, ", "), r.GCPProject))
+	}
+	return 0
+}
+
+// updateGCPPolicy changes the project's IAM policy so that account holds roles, and the roles
+// in status that are no longer wanted are revoked. Roles the account held before the controller
+// granted them are not recorded in status, so they are never revoked. The policy is read again
+// when someone else changed it meanwhile.
+func (r *UserReconciler) updateGCPPolicy(ctx context.Context, user *authv1alpha1.User, account string, roles []string) error {
+	previous, previousRoles := user.Status.GCPAccount, user.Status.GCPRoles
+	if previous == "" && account == "" {
+		return nil
+	}
+	for attempt := 1; ; attempt++ {
+		policy, err := r.GCP.GetPolicy(ctx, r.GCPProject)
+		if err != nil {
+			return fmt.Errorf("failed to read the IAM policy of project %s: %w", r.GCPProject, err)
+		}
+		changed := false
+		for _, role := range previousRoles {
+			if previous == account && slices.Contains(roles, role) {
+				continue
+			}
+			if member := gcp.Member(previous); policy.HasMember(role, member) {
+				policy.RemoveMember(role, member)
+				changed = true
+			}
+		}
+		var granted []string
+		for _, role := range roles {
+			member := gcp.Member(account)
+			owned := previous == account && slices.Contains(previousRoles, role)
+			if policy.HasMember(role, member) {
+				if owned {
+					granted = append(granted, role)
+				}
+				continue
+			}
+			policy.AddMember(role, member)
+			granted = append(granted, role)
+			changed = true
+		}
+
+		if changed {
+			err = r.GCP.SetPolicy(ctx, r.GCPProject, policy)
+			if gcp.IsConflict(err) && attempt < gcpPolicyAttempts {
+				continue
+			} else if err != nil {
+				return fmt.Errorf("failed to update the IAM policy of project %s: %w", r.GCPProject, err)
+			}
+			logf.FromContext(ctx).Info("Updated GCP IAM roles", "project", r.GCPProject, "account", account,
+				"roles", granted, "previousAccount", previous, "previousRoles", previousRoles)
+		}
+		user.Status.GCPAccount, user.Status.GCPRoles = "", granted
+		if len(granted) > 0 {
+			user.Status.GCPAccount = account
+		}
+		return nil
+	}
+}
+
+// removeGCPAccess revokes the IAM roles the controller granted to a deleted user
+func (r *UserReconciler) removeGCPAccess(ctx context.Context, user *authv1alpha1.User) {
+	if r.GCP == nil {
+		return
+	}
+	if err := r.updateGCPPolicy(ctx, user, "", nil); err != nil {
+		logf.FromContext(ctx).Error(err, "Failed to revoke GCP IAM roles of deleted user",
+			"account", user.Status.GCPAccount)
+	}
+}
+
+// gcpRoles returns the roles of access, sorted, or DefaultGCPRole when it lists none
+func gcpRoles(access *authv1alpha1.GCPAccess) []string {
+	if len(access.Roles) == 0 {
+		return []string{DefaultGCPRole}
+	}
+	roles := slices.Clone(access.Roles)
+	slices.Sort(roles)
+	return slices.Compact(roles)
+}
+
+// gcpAccessFailed reports err in the GCPAccessReady condition, and in an Event when the roles
+// were not failing before
+func (r *UserReconciler) gcpAccessFailed(user *authv1alpha1.User, err error) {
+	if condition := meta.FindStatusCondition(user.Status.Conditions, ConditionGCPAccessReady); condition == nil ||
+		condition.Reason != "RolesFailed" {
+		r.event(user, corev1.EventTypeWarning, EventGCPAccessFailed, "Failed to reconcile GCP IAM roles: %v", err)
+	}
+	setGCPAccessCondition(user, metav1.ConditionFalse, "RolesFailed", err.Error())
+}
+
+func setGCPAccessCondition(user *authv1alpha1.User, status metav1.ConditionStatus, reason, message string) {
+	meta.SetStatusCondition(&user.Status.Conditions, metav1.Condition{
+		Type:               ConditionGCPAccessReady,
+		Status:             status,
+		Reason:             reason,
+		Message:            message,
+		ObservedGeneration: user.Generation,
+	})
+}
@@ -0,0 +1,107 @@
+/*
+Copyright 2025.
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+*/
+
+package controller
+
+import (
+	"context"
+
+	rbacv1 "k8s.io/api/rbac/v1"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+
+	authv1alpha1 "github.com/openkube-hub/KubeUser/api/v1alpha1"
+	"github.com/openkube-hub/KubeUser/internal/team"
+)
+
+// Field indexes of the manager's cache. Lookups through them are served from the index instead
+// of listing every cached object and filtering it, which matters with thousands of Users.
+const (
+	// BindingUserIndex indexes RoleBindings and ClusterRoleBindings by the User they were
+	// created for, the value of their auth.openkube.io/user label
+	BindingUserIndex = "metadata.labels.user"
+	// BindingRoleIndex indexes RoleBindings and ClusterRoleBindings by their role as Kind/Name
+	BindingRoleIndex = "roleRef"
+	// UserRoleIndex indexes Users by the Roles in their spec as namespace/name
+	UserRoleIndex = "spec.roles"
+	// UserClusterRoleIndex indexes Users by the ClusterRoles in their spec
+	UserClusterRoleIndex = "spec.clusterRoles"
+	// UserTemplateIndex indexes Users by the name of their UserTemplate
+	UserTemplateIndex = "spec.templateRef.name"
+	// UserClusterSecretIndex indexes Users by the kubeconfig Secrets of their member clusters as
+	// namespace/name
+	UserClusterSecretIndex = "spec.clusters.kubeconfigSecretRef.name"
+	// TeamMemberIndex indexes Teams by the names of their members, listed or from the directory
+	TeamMemberIndex = "members"
+	// TeamIncludeIndex indexes Teams by the names of the Teams they include
+	TeamIncludeIndex = "spec.includeTeams"
+)
+
+// SetupIndexes registers the field indexes with the manager's cache; it must be called before
+// the manager is started
+func SetupIndexes(ctx context.Context, indexer client.FieldIndexer) error {
+	indexes := []struct {
+		obj     client.Object
+		field   string
+		extract client.IndexerFunc
+	}{
+		{&rbacv1.RoleBinding{}, BindingUserIndex, bindingUser},
+		{&rbacv1.ClusterRoleBinding{}, BindingUserIndex, bindingUser},
+		{&rbacv1.RoleBinding{}, BindingRoleIndex, func(obj client.Object) []string {
+			ref := obj.(*rbacv1.RoleBinding).RoleRef
+			return []string{ref.Kind + "/" + ref.Name}
+		}},
+		{&rbacv1.ClusterRoleBinding{}, BindingRoleIndex, func(obj client.Object) []string {
+			ref := obj.(*rbacv1.ClusterRoleBinding).RoleRef
+			return []string{ref.Kind + "/" + ref.Name}
+		}},
+		{&authv1alpha1.User{}, UserRoleIndex, func(obj client.Object) []string {
+			var roles []string
+			for _, role := range obj.(*authv1alpha1.User).Spec.Roles {
+				roles = append(roles, role.Namespace+"/"+role.ExistingRole)
+			}
+			return roles
+		}},
+		{&authv1alpha1.User{}, UserClusterRoleIndex, func(obj client.Object) []string {
+			var clusterRoles []string
+			for _, clusterRole := range obj.(*authv1alpha1.User).Spec.ClusterRoles {
+				clusterRoles = append(clusterRoles, clusterRole.ExistingClusterRole)
+			}
+			return clusterRoles
+		}},
+		{&authv1alpha1.User{}, UserTemplateIndex, func(obj client.Object) []string {
+			if ref := obj.(*authv1alpha1.User).Spec.TemplateRef; ref != nil {
+				return []string{ref.Name}
+			}
+			return nil
+		}},
+		{&authv1alpha1.User{}, UserClusterSecretIndex, func(obj client.Object) []string {
+			var secrets []string
+			for _, cluster := range obj.(*authv1alpha1.User).Spec.Clusters {
+				secrets = append(secrets, clusterSecretKey(cluster.KubeconfigSecretRef).String())
+			}
+			return secrets
+		}},
+		{&authv1alpha1.Team{}, TeamMemberIndex, func(obj client.Object) []string {
+			return team.MemberNames(obj.(*authv1alpha1.Team))
+		}},
+		{&authv1alpha1.Team{}, TeamIncludeIndex, func(obj client.Object) []string {
+			return obj.(*authv1alpha1.Team).Spec.IncludeTeams
+		}},
+	}
+	for _, index := range indexes {
+		if err := indexer.IndexField(ctx, index.obj, index.field, index.extract); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func bindingUser(obj client.Object) []string {
+	if user := obj.GetLabels()["auth.openkube.io/user"]; user != "" {
+		return []string{user}
+	}
+	return nil
+}
@@ -0,0 +1,121 @@
+/*
+Copyright 2025.
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+*/
+
+package controller
+
+import (
+	"fmt"
+	"net/url"
+	"time"
+
+	authv1alpha1 "github.com/openkube-hub/KubeUser/api/v1alpha1"
+	"github.com/openkube-hub/KubeUser/internal/issuer"
+	"k8s.io/apimachinery/pkg/api/meta"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/util/workqueue"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+)
+
+// Issuer backends selectable with --issuer
+const (
+	// IssuerKubernetes signs through the Kubernetes CSR API and the kube-apiserver-client signer
+	IssuerKubernetes = "kubernetes"
+	// IssuerCertManager signs through cert-manager CertificateRequests against a configured issuer
+	IssuerCertManager = "cert-manager"
+	// IssuerVault signs through the sign endpoint of Vault's PKI secrets engine
+	IssuerVault = "vault"
+	// IssuerSPIRE mints X509-SVIDs with users' SPIFFE IDs through the SPIRE server API
+	IssuerSPIRE = "spire"
+)
+
+// IssuerConfig selects and configures the certificate issuer
+type IssuerConfig struct {
+	// Backend is one of IssuerKubernetes (default), IssuerCertManager, IssuerVault or IssuerSPIRE
+	Backend string
+	// CertManagerIssuer is [Issuer/|ClusterIssuer/]<name>, used by the cert-manager backend
+	CertManagerIssuer string
+	// Vault configures the vault backend
+	Vault issuer.VaultConfig
+	// SPIRE configures the spire backend
+	SPIRE issuer.SPIREConfig
+}
+
+// NewIssuer returns the issuer for the configured backend
+func NewIssuer(c client.Client, config IssuerConfig) (issuer.Issuer, error) {
+	switch config.Backend {
+	case "", IssuerKubernetes:
+		return issuer.NewCSRIssuer(c), nil
+	case IssuerCertManager:
+		if config.CertManagerIssuer == "" {
+			return nil, fmt.Errorf("the %s issuer requires a cert-manager issuer", IssuerCertManager)
+		}
+		ref, err := issuer.ParseIssuerRef(config.CertManagerIssuer)
+		if err != nil {
+			return nil, err
+		}
+		return issuer.NewCertManagerIssuer(c, getKubeUserNamespace(), ref), nil
+	case IssuerVault:
+		return issuer.NewVaultIssuer(config.Vault)
+	case IssuerSPIRE:
+		return issuer.NewSPIREIssuer(config.SPIRE)
+	default:
+		return nil, fmt.Errorf("unknown issuer %q, must be one of %s, %s, %s, %s",
+			config.Backend, IssuerKubernetes, IssuerCertManager, IssuerVault, IssuerSPIRE)
+	}
+}
+
+const (
+	// ConditionCertificateIssuanceUnavailable is set while certificates cannot be issued,
+	// e.g. because certificates.k8s.io is disabled or the signer does not sign approved CSRs
+	ConditionCertificateIssuanceUnavailable = "CertificateIssuanceUnavailable"
+
+	// issuanceBackoffBase and issuanceBackoffMax bound the retry delay for unavailable issuance
+	issuanceBackoffBase = 5 * time.Second
+	issuanceBackoffMax  = 5 * time.Minute
+)
+
+// certIssuer returns the configured issuer, or the CSR API issuer when none was set
+func (r *UserReconciler) certIssuer() issuer.Issuer {
+	if r.Issuer == nil {
+		return issuer.NewCSRIssuer(r.Client)
+	}
+	return r.Issuer
+}
+
+// csrURIs returns the URI SANs the configured issuer needs in the user's CSR
+func (r *UserReconciler) csrURIs(username string) []*url.URL {
+	if uriIssuer, ok := r.certIssuer().(issuer.URIIssuer); ok {
+		return uriIssuer.URIs(username)
+	}
+	return nil
+}
+
+func (r *UserReconciler) issuanceBackoff() workqueue.TypedRateLimiter[string] {
+	r.issuanceBackoffOnce.Do(func() {
+		r.issuanceBackoffLimiter = workqueue.NewTypedItemExponentialFailureRateLimiter[string](issuanceBackoffBase, issuanceBackoffMax)
+	})
+	return r.issuanceBackoffLimiter
+}
+
+// setIssuanceCondition records whether certificate issuance is currently unavailable for the user
+func (r *UserReconciler) setIssuanceCondition(user *authv1alpha1.User, issueErr error) {
+	condition := metav1.Condition{
+		Type:    ConditionCertificateIssuanceUnavailable,
+		Status:  metav1.ConditionFalse,
+		Reason:  "IssuanceAvailable",
+		Message: "Certificates can be issued",
+	}
+	if issueErr != nil {
+		condition.Status = metav1.ConditionTrue
+		condition.Reason = "IssuanceUnavailable"
+		condition.Message = issueErr.Error()
+	} else if meta.FindStatusCondition(user.Status.Conditions, ConditionCertificateIssuanceUnavailable) == nil {
+		// Nothing to clear
+		return
+	}
+
+	meta.SetStatusCondition(&user.Status.Conditions, condition)
+}
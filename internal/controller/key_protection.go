@@ -0,0 +1,128 @@
+/*
+Copyright 2025.
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+*/
+
+package controller
+
+import (
+	"context"
+	"errors"
+	"fmt"
+
+	corev1 "k8s.io/api/core/v1"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	logf "sigs.k8s.io/controller-runtime/pkg/log"
+
+	authv1alpha1 "github.com/openkube-hub/KubeUser/api/v1alpha1"
+	"github.com/openkube-hub/KubeUser/internal/issuer"
+	"github.com/openkube-hub/KubeUser/internal/keyprotect"
+	"github.com/openkube-hub/KubeUser/internal/vault"
+)
+
+// Key protection backends selectable with --key-protection
+const (
+	// KeyProtectionNone stores user private keys as PEM in their Secrets
+	KeyProtectionNone = "none"
+	// KeyProtectionVaultTransit encrypts user private keys with a key of Vault's transit secrets engine
+	KeyProtectionVaultTransit = "vault-transit"
+)
+
+// KeyProtectionConfig selects and configures how user private keys are protected at rest
+type KeyProtectionConfig struct {
+	// Backend is KeyProtectionNone (default) or KeyProtectionVaultTransit
+	Backend string
+	// TransitMount and TransitKey name the transit key used by the vault-transit backend
+	TransitMount string
+	TransitKey   string
+	// Vault is the connection used by the vault-transit backend
+	Vault vault.Config
+}
+
+// NewKeyProtector returns the protector for the configured backend, nil when keys are stored as PEM
+func NewKeyProtector(config KeyProtectionConfig) (keyprotect.Protector, error) {
+	switch config.Backend {
+	case "", KeyProtectionNone:
+		return nil, nil
+	case KeyProtectionVaultTransit:
+		client, err := vault.NewClient(config.Vault)
+		if err != nil {
+			return nil, err
+		}
+		return keyprotect.NewVaultTransit(client, config.TransitMount, config.TransitKey)
+	default:
+		return nil, fmt.Errorf("unknown key protection %q, must be one of %s, %s",
+			config.Backend, KeyProtectionNone, KeyProtectionVaultTransit)
+	}
+}
+
+const (
+	// userKeyData holds the PEM private key in key Secrets written without a KeyProtector
+	userKeyData = "key.pem"
+	// encryptedUserKeyData holds the ciphertext of the private key in key Secrets written with one
+	encryptedUserKeyData = "key.enc"
+	// keyProtectionAnnotation records the protector that encrypted the key in a key Secret
+	keyProtectionAnnotation = "auth.openkube.io/key-protection"
+)
+
+// protectUserKey stores keyPEM in secret, encrypted when the reconciler has a KeyProtector
+func (r *UserReconciler) protectUserKey(ctx context.Context, secret *corev1.Secret, username string,
+	keyPEM []byte) error {
+	if r.KeyProtector == nil {
+		secret.Data = map[string][]byte{userKeyData: keyPEM}
+		return nil
+	}
+	ciphertext, err := r.KeyProtector.Encrypt(ctx, username, keyPEM)
+	if err != nil {
+		return keyProtectionError(err)
+	}
+	secret.Data = map[string][]byte{encryptedUserKeyData: ciphertext}
+	if secret.Annotations == nil {
+		secret.Annotations = map[string]string{}
+	}
+	secret.Annotations[keyProtectionAnnotation] = r.KeyProtector.Name()
+	return nil
+}
+
+// readUserKey returns the PEM private key stored in secret. A plaintext key is encrypted once a
+// KeyProtector is configured, so keys written before are protected too; the Secret is immutable
+// and replaced for that.
+func (r *UserReconciler) readUserKey(ctx context.Context, secret *corev1.Secret, user *authv1alpha1.User) ([]byte, error) {
+	username := user.Name
+	if ciphertext, ok := secret.Data[encryptedUserKeyData]; ok {
+		if r.KeyProtector == nil {
+			return nil, fmt.Errorf("private key of user %s is encrypted with %s, but no key protection is configured",
+				username, secret.Annotations[keyProtectionAnnotation])
+		}
+		keyPEM, err := r.KeyProtector.Decrypt(ctx, username, ciphertext)
+		if err != nil {
+			return nil, keyProtectionError(err)
+		}
+		return keyPEM, nil
+	}
+
+	keyPEM := secret.Data[userKeyData]
+	if r.KeyProtector == nil || keyPEM == nil {
+		return keyPEM, nil
+	}
+	protected := userSecret(client.ObjectKeyFromObject(secret), user, PrivateKeySecretType, nil)
+	setRotatedAt(protected, rotatedAt(secret))
+	if err := r.protectUserKey(ctx, protected, username, keyPEM); err != nil {
+		return nil, err
+	}
+	if err := r.replaceSecret(ctx, secret, protected); err != nil {
+		return nil, fmt.Errorf("failed to encrypt private key secret: %w", err)
+	}
+	logf.FromContext(ctx).Info("Encrypted private key", "protector", r.KeyProtector.Name())
+	return keyPEM, nil
+}
+
+// keyProtectionError reports an unreachable key management service like an unavailable issuer,
+// since no certificate can be issued without the key either
+func keyProtectionError(err error) error {
+	if errors.Is(err, vault.ErrUnavailable) {
+		return &issuer.UnavailableError{Reason: "key protection is unavailable", Err: err}
+	}
+	return err
+}
@@ -0,0 +1,138 @@
+/*
+Copyright 2025.
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+*/
+
+package controller
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	authenticationv1 "k8s.io/api/authentication/v1"
+	corev1 "k8s.io/api/core/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	logf "sigs.k8s.io/controller-runtime/pkg/log"
+
+	authv1alpha1 "github.com/openkube-hub/KubeUser/api/v1alpha1"
+	"github.com/openkube-hub/KubeUser/internal/operatorconfig"
+)
+
+// Machine users (spec.type: machine) are CI systems and bots. Instead of a client certificate
+// they authenticate as their ServiceAccount anchor with bound tokens from the TokenRequest API,
+// which the controller writes into the credential Secret and replaces after 80% of their
+// lifetime. A leaked token is useless within spec.tokenDuration.
+
+// DefaultTokenDuration is how long machine user tokens are valid unless spec.tokenDuration is set
+const DefaultTokenDuration = time.Hour
+
+// tokenExpiryAnnotation records when the token in a machine user's credential Secret expires
+const tokenExpiryAnnotation = "auth.openkube.io/token-expiry"
+
+// isMachine returns whether the user authenticates with ServiceAccount tokens
+func isMachine(user *authv1alpha1.User) bool {
+	return user.Spec.Type == authv1alpha1.UserTypeMachine
+}
+
+// tokenDuration returns the requested lifetime of the user's tokens
+func tokenDuration(user *authv1alpha1.User) time.Duration {
+	if user.Spec.TokenDuration != nil && user.Spec.TokenDuration.Duration > 0 {
+		return user.Spec.TokenDuration.Duration
+	}
+	return DefaultTokenDuration
+}
+
+// tokenRefreshAt returns when a token expiring at expiry is replaced: once 80% of the
+// requested lifetime has passed
+func tokenRefreshAt(expiry time.Time, duration time.Duration) time.Time {
+	return expiry.Add(-duration / 5)
+}
+
+// reviewIdentity returns the user name and groups the API server authenticates the user as
+func reviewIdentity(user *authv1alpha1.User) (string, []string) {
+	if !isMachine(user) {
+		return operatorconfig.Current().Username(user.Name), nil
+	}
+	namespace := getKubeUserNamespace()
+	return fmt.Sprintf("system:serviceaccount:%s:%s", namespace, user.Name),
+		[]string{"system:serviceaccounts", "system:serviceaccounts:" + namespace}
+}
+
+// ensureTokenKubeconfig keeps a valid token for the machine user's ServiceAccount anchor in its
+// credential Secret. It returns how long until the token has to be refreshed.
+func (r *UserReconciler) ensureTokenKubeconfig(ctx context.Context, user *authv1alpha1.User,
+	now time.Time) (time.Duration, error) {
+	logger := logf.FromContext(ctx)
+	username := user.Name
+	cfgSecret := credentialSecretKey(user)
+
+	// Follow spec.output.secretRef to its current location
+	if err := r.moveCredentialSecret(ctx, user, cfgSecret); err != nil {
+		return 0, err
+	}
+
+	// Keep the current token while it is fresh and was rendered the way it would be now, unless
+	// a renewal was requested
+	duration := tokenDuration(user)
+	layout := r.credentialLayout(user)
+	contexts := userKubeconfigContexts(user)
+	cluster, err := r.kubeconfigCluster(ctx, user)
+	if err != nil {
+		return 0, err
+	}
+	recipients, err := userCredentialRecipients(user)
+	if err != nil {
+		return 0, err
+	}
+	existing, err := r.getCredentialSecret(ctx, cfgSecret, username)
+	if err != nil && !apierrors.IsNotFound(err) {
+		return 0, err
+	}
+	if err == nil && !renewRequested(user) && existing.Annotations[credentialLayoutAnnotation] == layout.String() &&
+		existing.Annotations[kubeconfigContextsAnnotation] == contexts.String() &&
+		existing.Annotations[kubeconfigClusterAnnotation] == cluster.String() &&
+		existing.Annotations[credentialRecipientsAnnotation] == recipients.String() {
+		if expiry, err := time.Parse(time.RFC3339, existing.Annotations[tokenExpiryAnnotation]); err == nil {
+			if refresh := tokenRefreshAt(expiry, duration); now.Before(refresh) {
+				return refresh.Sub(now), nil
+			}
+		}
+	}
+
+	seconds := int64(duration.Seconds())
+	request := &authenticationv1.TokenRequest{
+		Spec: authenticationv1.TokenRequestSpec{ExpirationSeconds: &seconds},
+	}
+	sa := &corev1.ServiceAccount{ObjectMeta: metav1.ObjectMeta{Name: username, Namespace: getKubeUserNamespace()}}
+	if err := r.SubResource("token").Create(ctx, sa, request); err != nil {
+		return 0, fmt.Errorf("failed to request token for ServiceAccount %s/%s: %w", sa.Namespace, sa.Name, err)
+	}
+	expiry := request.Status.ExpirationTimestamp.Time
+	logger.Info("Token issued", "expiry", expiry)
+
+	if err := r.writeTokenSecret(ctx, cfgSecret, user, layout, contexts, cluster, recipients,
+		request.Status.Token, expiry); err != nil {
+		return 0, err
+	}
+	if err := r.clearRenewRequest(ctx, user); err != nil {
+		return 0, err
+	}
+	firstIssue := user.Status.TokenExpiry == nil
+	user.Status.TokenExpiry = &metav1.Time{Time: expiry}
+	user.Status.CredentialSecret = &corev1.SecretReference{Name: cfgSecret.Name, Namespace: cfgSecret.Namespace}
+	if firstIssue {
+		r.event(user, corev1.EventTypeNormal, EventTokenIssued,
+			"Issued ServiceAccount token valid until %s, credentials in Secret %s",
+			expiry.UTC().Format(time.RFC3339), cfgSecret)
+	}
+
+	refresh := tokenRefreshAt(expiry, duration).Sub(now)
+	if refresh < time.Minute {
+		// The API server shortened the token below what was asked for
+		refresh = time.Minute
+	}
+	return refresh, nil
+}
@@ -0,0 +1,88 @@
+/*
+Copyright 2025.
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+*/
+
+package controller
+
+import (
+	"sync"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"sigs.k8s.io/controller-runtime/pkg/metrics"
+
+	authv1alpha1 "github.com/openkube-hub/KubeUser/api/v1alpha1"
+)
+
+// Metrics are served on the controller-runtime metrics endpoint next to its reconcile metrics
+var (
+	usersByPhase = prometheus.NewGaugeVec(prometheus.GaugeOpts{
+		Name: "kubeuser_users",
+		Help: "Number of Users by status phase",
+	}, []string{"phase"})
+
+	certificateExpiry = prometheus.NewGaugeVec(prometheus.GaugeOpts{
+		Name: "kubeuser_user_certificate_expiry_timestamp_seconds",
+		Help: "Expiry of each User's client certificate as a Unix timestamp",
+	}, []string{"user"})
+
+	csrIssuanceDuration = prometheus.NewHistogram(prometheus.HistogramOpts{
+		Name:    "kubeuser_csr_issuance_duration_seconds",
+		Help:    "Time from creating a User's CertificateSigningRequest until its certificate was picked up",
+		Buckets: []float64{1, 2.5, 5, 10, 30, 60, 120, 300, 600},
+	})
+
+	reconcileErrors = prometheus.NewCounterVec(prometheus.CounterOpts{
+		Name: "kubeuser_reconcile_errors_total",
+		Help: "Failed User reconciles, including timeouts",
+	}, []string{"user"})
+
+	certificateRotations = prometheus.NewCounter(prometheus.CounterOpts{
+		Name: "kubeuser_certificate_rotations_total",
+		Help: "Client certificates rotated because they were close to expiry",
+	})
+)
+
+func init() {
+	metrics.Registry.MustRegister(usersByPhase, certificateExpiry, csrIssuanceDuration, reconcileErrors, certificateRotations)
+}
+
+// userPhases tracks the last known phase of every User so usersByPhase can be kept as counts
+var userPhases = struct {
+	sync.Mutex
+	phases map[string]string
+}{phases: map[string]string{}}
+
+// recordUserMetrics updates the per-user metrics from the status about to be written
+func recordUserMetrics(user *authv1alpha1.User) {
+	if expiry, err := time.Parse(time.RFC3339, user.Status.ExpiryTime); err == nil {
+		certificateExpiry.WithLabelValues(user.Name).Set(float64(expiry.Unix()))
+	}
+
+	userPhases.Lock()
+	defer userPhases.Unlock()
+	previous, known := userPhases.phases[user.Name]
+	if known && previous == user.Status.Phase {
+		return
+	}
+	if known {
+		usersByPhase.WithLabelValues(previous).Dec()
+	}
+	userPhases.phases[user.Name] = user.Status.Phase
+	usersByPhase.WithLabelValues(user.Status.Phase).Inc()
+}
+
+// forgetUserMetrics drops the series of a deleted User
+func forgetUserMetrics(name string) {
+	certificateExpiry.DeleteLabelValues(name)
+	reconcileErrors.DeleteLabelValues(name)
+
+	userPhases.Lock()
+	defer userPhases.Unlock()
+	if phase, known := userPhases.phases[name]; known {
+		usersByPhase.WithLabelValues(phase).Dec()
+		delete(userPhases.phases, name)
+	}
+}
@@ -0,0 +1,118 @@
+/*
+Copyright 2025.
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+*/
+
+package controller
+
+import (
+	"context"
+	"fmt"
+	"strings"
+
+	corev1 "k8s.io/api/core/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/types"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	logf "sigs.k8s.io/controller-runtime/pkg/log"
+
+	authv1alpha1 "github.com/openkube-hub/KubeUser/api/v1alpha1"
+)
+
+const (
+	// NamespaceCleanupNone leaves the kubeuser namespace untouched when the last User is deleted (the default)
+	NamespaceCleanupNone = "none"
+	// NamespaceCleanupLeftovers deletes controller-created Secrets and ServiceAccounts once no User remains
+	NamespaceCleanupLeftovers = "leftovers"
+	// NamespaceCleanupNamespace deletes the namespace itself once no User remains, if the controller created it
+	NamespaceCleanupNamespace = "namespace"
+
+	// ManagedNamespaceLabel marks a namespace created by the controller; only such namespaces are ever deleted
+	ManagedNamespaceLabel = "auth.openkube.io/managed-namespace"
+)
+
+// ValidateNamespaceCleanup checks a --namespace-cleanup value
+func ValidateNamespaceCleanup(mode string) error {
+	switch mode {
+	case NamespaceCleanupNone, NamespaceCleanupLeftovers, NamespaceCleanupNamespace:
+		return nil
+	}
+	return fmt.Errorf("invalid namespace cleanup mode %q, must be %q, %q or %q",
+		mode, NamespaceCleanupNone, NamespaceCleanupLeftovers, NamespaceCleanupNamespace)
+}
+
+// cleanupEmptyNamespace removes what the controller left in the kubeuser namespace once the
+// deleted user was the last one. Errors are logged, the deleted user is already gone.
+func (r *UserReconciler) cleanupEmptyNamespace(ctx context.Context, deleted string) {
+	logger := logf.FromContext(ctx)
+	if r.NamespaceCleanup == "" || r.NamespaceCleanup == NamespaceCleanupNone {
+		return
+	}
+
+	var users authv1alpha1.UserList
+	if err := r.List(ctx, &users); err != nil {
+		logger.Error(err, "Failed to list users for namespace cleanup")
+		return
+	}
+	for _, u := range users.Items {
+		if u.Name != deleted {
+			return
+		}
+	}
+
+	userNamespace := getKubeUserNamespace()
+	if r.NamespaceCleanup == NamespaceCleanupNamespace {
+		var ns corev1.Namespace
+		if err := r.Get(ctx, types.NamespacedName{Name: userNamespace}, &ns); err != nil {
+			if !apierrors.IsNotFound(err) {
+				logger.Error(err, "Failed to get namespace for cleanup", "namespace", userNamespace)
+			}
+			return
+		}
+		if ns.Labels[ManagedNamespaceLabel] == "true" {
+			logger.Info("Last user deleted, deleting managed namespace", "namespace", userNamespace)
+			if err := r.Delete(ctx, &ns); err != nil && !apierrors.IsNotFound(err) {
+				logger.Error(err, "Failed to delete managed namespace", "namespace", userNamespace)
+			}
+			return
+		}
+		// The namespace was installed with the operator (and may be running it), keep it
+		logger.Info("Namespace was not created by the controller, only deleting leftovers", "namespace", userNamespace)
+	}
+
+	logger.Info("Last user deleted, deleting leftovers", "namespace", userNamespace)
+	var secrets corev1.SecretList
+	if err := r.List(ctx, &secrets, client.InNamespace(userNamespace)); err != nil {
+		logger.Error(err, "Failed to list secrets for cleanup", "namespace", userNamespace)
+	}
+	for i := range secrets.Items {
+		if isLeftoverSecret(&secrets.Items[i]) {
+			if err := r.Delete(ctx, &secrets.Items[i]); err != nil && !apierrors.IsNotFound(err) {
+				logger.Error(err, "Failed to delete leftover secret", "secret", secrets.Items[i].Name)
+			}
+		}
+	}
+	var serviceAccounts corev1.ServiceAccountList
+	if err := r.List(ctx, &serviceAccounts, client.InNamespace(userNamespace), client.HasLabels{"auth.openkube.io/user"}); err != nil {
+		logger.Error(err, "Failed to list service accounts for cleanup", "namespace", userNamespace)
+	}
+	for i := range serviceAccounts.Items {
+		if err := r.Delete(ctx, &serviceAccounts.Items[i]); err != nil && !apierrors.IsNotFound(err) {
+			logger.Error(err, "Failed to delete leftover service account", "serviceAccount", serviceAccounts.Items[i].Name)
+		}
+	}
+}
+
+// isLeftoverSecret reports whether a Secret was written by the controller for some user. Secrets
+// from older releases carry no user label and are recognised by their name and contents.
+func isLeftoverSecret(secret *corev1.Secret) bool {
+	if _, ok := secret.Labels["auth.openkube.io/user"]; ok {
+		return true
+	}
+	if _, ok := secret.Annotations[credentialLayoutAnnotation]; ok {
+		return true
+	}
+	_, hasKey := secret.Data[userKeyData]
+	return strings.HasSuffix(secret.Name, "-key") && hasKey && len(secret.Data) == 1
+}
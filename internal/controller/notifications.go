@@ -0,0 +1,115 @@
+/*
+Copyright 2025.
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+*/
+
+package controller
+
+import (
+	"context"
+	"time"
+
+	corev1 "k8s.io/api/core/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/types"
+	logf "sigs.k8s.io/controller-runtime/pkg/log"
+
+	authv1alpha1 "github.com/openkube-hub/KubeUser/api/v1alpha1"
+	"github.com/openkube-hub/KubeUser/internal/naming"
+	"github.com/openkube-hub/KubeUser/internal/notify"
+	"github.com/openkube-hub/KubeUser/internal/operatorconfig"
+)
+
+// kubeconfigPassphraseKey holds the passphrase of emailed kubeconfigs in the user's passphrase Secret
+const kubeconfigPassphraseKey = "passphrase"
+
+func userPassphraseSecretName(username string) string {
+	return naming.Suffixed(naming.MaxNameLength, username, "passphrase")
+}
+
+// notify sends a lifecycle notification about user to the configured sinks. Delivery
+// failures are logged; they never fail the reconcile.
+func (r *UserReconciler) notify(ctx context.Context, user *authv1alpha1.User, eventType notify.EventType,
+	message string, details map[string]string, attachments ...notify.Attachment) {
+	sinks := operatorconfig.Current().NotificationSinks
+	if r.Notifier == nil || len(sinks) == 0 {
+		return
+	}
+	event := notify.Event{
+		Type:        eventType,
+		User:        user.Name,
+		Time:        time.Now(),
+		Message:     message,
+		Details:     details,
+		Attachments: attachments,
+	}
+	if expiry, err := time.Parse(time.RFC3339, user.Status.ExpiryTime); err == nil {
+		event.Expiry = expiry
+	}
+	if err := r.Notifier.Notify(ctx, sinks, operatorconfig.Namespace(), user, event); err != nil {
+		logf.FromContext(ctx).Error(err, "Failed to send notification", "event", eventType)
+	}
+}
+
+// notifyProvisioned announces the user's first certificate. Email sinks with attachKubeconfig
+// also get the kubeconfig, encrypted with the passphrase in the user's passphrase Secret, unless
+// the user's credentials are encrypted to their own keys: the passphrase is readable in the cluster.
+func (r *UserReconciler) notifyProvisioned(ctx context.Context, user *authv1alpha1.User,
+	credentialSecret types.NamespacedName, kubeconfig []byte) {
+	details := map[string]string{"secret": credentialSecret.String()}
+	ownKeys := user.Spec.Output != nil && user.Spec.Output.Encryption != nil
+	if r.Notifier == nil || ownKeys || !attachesKubeconfig(operatorconfig.Current().NotificationSinks) {
+		r.notify(ctx, user, notify.EventProvisioned, "", details)
+		return
+	}
+	passphrase, err := r.ensurePassphrase(ctx, user)
+	if err != nil {
+		logf.FromContext(ctx).Error(err, "Failed to prepare kubeconfig passphrase, sending no attachment")
+		r.notify(ctx, user, notify.EventProvisioned, "", details)
+		return
+	}
+	encrypted, err := notify.Encrypt(kubeconfig, passphrase)
+	if err != nil {
+		logf.FromContext(ctx).Error(err, "Failed to encrypt kubeconfig, sending no attachment")
+		r.notify(ctx, user, notify.EventProvisioned, "", details)
+		return
+	}
+	r.notify(ctx, user, notify.EventProvisioned, "", details, notify.Attachment{
+		Name:        user.Name + ".kubeconfig.enc",
+		ContentType: notify.EncryptedContentType,
+		Data:        encrypted,
+	})
+}
+
+// attachesKubeconfig reports whether any sink emails kubeconfigs with provisioned notifications
+func attachesKubeconfig(sinks []authv1alpha1.NotificationSink) bool {
+	for _, sink := range sinks {
+		if sink.AttachKubeconfig && notify.Subscribed(sink, notify.EventProvisioned) {
+			return true
+		}
+	}
+	return false
+}
+
+// ensurePassphrase loads the passphrase of the user's emailed kubeconfigs, generating it on first use
+func (r *UserReconciler) ensurePassphrase(ctx context.Context, user *authv1alpha1.User) (string, error) {
+	var secret corev1.Secret
+	key := types.NamespacedName{Name: userPassphraseSecretName(user.Name), Namespace: getKubeUserNamespace()}
+	err := r.Get(ctx, key, &secret)
+	if err == nil {
+		return string(secret.Data[kubeconfigPassphraseKey]), nil
+	} else if !apierrors.IsNotFound(err) {
+		return "", err
+	}
+
+	passphrase, err := notify.NewPassphrase()
+	if err != nil {
+		return "", err
+	}
+	if err := r.Create(ctx, userSecret(key, user, PassphraseSecretType,
+		map[string][]byte{kubeconfigPassphraseKey: []byte(passphrase)})); err != nil {
+		return "", err
+	}
+	return passphrase, nil
+}
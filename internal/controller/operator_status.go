@@ -0,0 +1,19 @@
+/*
+Copyright 2025.
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+*/
+
+package controller
+
+import (
+	"context"
+
+	"github.com/openkube-hub/KubeUser/internal/operatorstatus"
+)
+
+// setOperatorStatus records a single key in the operator status ConfigMap of the KubeUser namespace
+func (r *UserReconciler) setOperatorStatus(ctx context.Context, key, value string) error {
+	reporter := &operatorstatus.Reporter{Client: r.Client, Namespace: getKubeUserNamespace()}
+	return reporter.Set(ctx, key, value)
+}
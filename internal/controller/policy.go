@@ -0,0 +1,93 @@
+/*
+Copyright 2025.
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+*/
+
+package controller
+
+import (
+	"context"
+	"errors"
+	"strings"
+
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/meta"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	logf "sigs.k8s.io/controller-runtime/pkg/log"
+	"sigs.k8s.io/controller-runtime/pkg/reconcile"
+
+	authv1alpha1 "github.com/openkube-hub/KubeUser/api/v1alpha1"
+	"github.com/openkube-hub/KubeUser/internal/policy"
+)
+
+// ConditionPolicyViolation is True while ClusterPolicies keep some of the user's grants from
+// being bound. Bindings of those grants are removed.
+const ConditionPolicyViolation = "PolicyViolation"
+
+// policyGate checks the grants of one reconcile against the ClusterPolicies and collects violations
+type policyGate struct {
+	policies   []authv1alpha1.ClusterPolicy
+	violations []string
+}
+
+// +kubebuilder:rbac:groups=auth.openkube.io,resources=clusterpolicies,verbs=get;list;watch
+
+// policyGate returns the gate for the user's grants. Break-glass Users are exempt from
+// ClusterPolicies; their access is limited in time instead.
+func (r *UserReconciler) policyGate(ctx context.Context, user *authv1alpha1.User) (*policyGate, error) {
+	if user.Spec.BreakGlass {
+		return &policyGate{}, nil
+	}
+	policies, err := policy.List(ctx, r)
+	if err != nil {
+		return nil, err
+	}
+	return &policyGate{policies: policies}, nil
+}
+
+// allows reports whether grant may be bound. The controller does not know who created the
+// User, so policies scoped to users or groups are left to the webhook.
+func (g *policyGate) allows(grant policy.Grant) (bool, error) {
+	err := policy.Check(g.policies, grant, nil)
+	var violation *policy.Violation
+	if errors.As(err, &violation) {
+		g.violations = append(g.violations, violation.Error())
+		return false, nil
+	}
+	return err == nil, err
+}
+
+// setPolicyCondition reports the grants the policies refused, or drops the condition
+func (r *UserReconciler) setPolicyCondition(user *authv1alpha1.User, gate *policyGate) {
+	if len(gate.violations) == 0 {
+		meta.RemoveStatusCondition(&user.Status.Conditions, ConditionPolicyViolation)
+		return
+	}
+	message := "Not bound: " + strings.Join(gate.violations, "; ")
+	changed := meta.SetStatusCondition(&user.Status.Conditions, metav1.Condition{
+		Type:               ConditionPolicyViolation,
+		Status:             metav1.ConditionTrue,
+		Reason:             "GrantsDenied",
+		Message:            message,
+		ObservedGeneration: user.Generation,
+	})
+	if changed {
+		r.event(user, corev1.EventTypeWarning, EventPolicyViolation, "%s", message)
+	}
+}
+
+// usersForPolicy re-enqueues every User when a ClusterPolicy changes
+func (r *UserReconciler) usersForPolicy(ctx context.Context, _ client.Object) []reconcile.Request {
+	var users authv1alpha1.UserList
+	if err := r.List(ctx, &users); err != nil {
+		logf.FromContext(ctx).Error(err, "Failed to list users for ClusterPolicy change")
+		return nil
+	}
+	requests := make([]reconcile.Request, 0, len(users.Items))
+	for _, user := range users.Items {
+		requests = append(requests, reconcile.Request{NamespacedName: client.ObjectKeyFromObject(&user)})
+	}
+	return requests
+}
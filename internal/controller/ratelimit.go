@@ -0,0 +1,70 @@
+/*
+Copyright 2025.
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+*/
+
+package controller
+
+import (
+	"time"
+
+	"golang.org/x/time/rate"
+	"k8s.io/client-go/util/workqueue"
+	"sigs.k8s.io/controller-runtime/pkg/reconcile"
+)
+
+// Defaults of the User controller's workqueue, the same as controller-runtime's
+const (
+	DefaultQueueBaseDelay = 5 * time.Millisecond
+	DefaultQueueMaxDelay  = 1000 * time.Second
+	DefaultQueueQPS       = 10
+	DefaultQueueBurst     = 100
+)
+
+// Defaults of the delay before a User whose credentials are not ready is reconciled again
+const (
+	DefaultRetryBaseDelay = 3 * time.Second
+	DefaultRetryMaxDelay  = 5 * time.Minute
+)
+
+// Bounds of how often a User that needs nothing else is reconciled, which is when its
+// certificate is checked for renewal
+const (
+	DefaultRenewalCheckInterval = 30 * time.Minute
+	MinRenewalCheckInterval     = time.Minute
+	MaxRenewalCheckInterval     = 24 * time.Hour
+)
+
+// NewRateLimiter returns a workqueue rate limiter that delays each failing User exponentially
+// from baseDelay up to maxDelay, and lets at most qps Users with burst through overall
+func NewRateLimiter(baseDelay, maxDelay time.Duration, qps float64, burst int) workqueue.TypedRateLimiter[reconcile.Request] {
+	return workqueue.NewTypedMaxOfRateLimiter(
+		workqueue.NewTypedItemExponentialFailureRateLimiter[reconcile.Request](baseDelay, maxDelay),
+		&workqueue.TypedBucketRateLimiter[reconcile.Request]{Limiter: rate.NewLimiter(rate.Limit(qps), burst)},
+	)
+}
+
+// retryBackoff delays the reconciles of a User whose credentials could not be issued yet,
+// doubling with every attempt until they are
+func (r *UserReconciler) retryBackoff() workqueue.TypedRateLimiter[string] {
+	r.retryBackoffOnce.Do(func() {
+		base, limit := r.RetryBaseDelay, r.RetryMaxDelay
+		if base <= 0 {
+			base = DefaultRetryBaseDelay
+		}
+		if limit <= 0 {
+			limit = DefaultRetryMaxDelay
+		}
+		r.retryBackoffLimiter = workqueue.NewTypedItemExponentialFailureRateLimiter[string](base, limit)
+	})
+	return r.retryBackoffLimiter
+}
+
+// renewalCheckInterval returns RenewalCheckInterval, or its default when unset
+func (r *UserReconciler) renewalCheckInterval() time.Duration {
+	if r.RenewalCheckInterval <= 0 {
+		return DefaultRenewalCheckInterval
+	}
+	return r.RenewalCheckInterval
+}
@@ -0,0 +1,44 @@
+/*
+Copyright 2025.
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+*/
+
+package controller
+
+import (
+	"context"
+	"fmt"
+
+	"sigs.k8s.io/controller-runtime/pkg/client"
+
+	authv1alpha1 "github.com/openkube-hub/KubeUser/api/v1alpha1"
+)
+
+// renewRequested returns whether the user asked for new credentials through the renew annotation
+func renewRequested(user *authv1alpha1.User) bool {
+	value := user.Annotations[authv1alpha1.RenewAnnotation]
+	return value == "true" || value == authv1alpha1.RenewNewKey
+}
+
+// newKeyRequested returns whether the renewal also replaces the user's private key
+func newKeyRequested(user *authv1alpha1.User) bool {
+	return user.Annotations[authv1alpha1.RenewAnnotation] == authv1alpha1.RenewNewKey
+}
+
+// clearRenewRequest removes the renew annotation once the renewal has started. Only the
+// annotation is patched, so the spec merged with the user's UserTemplate and Teams is kept.
+func (r *UserReconciler) clearRenewRequest(ctx context.Context, user *authv1alpha1.User) error {
+	if _, ok := user.Annotations[authv1alpha1.RenewAnnotation]; !ok {
+		return nil
+	}
+	patched := user.DeepCopy()
+	base := patched.DeepCopy()
+	delete(patched.Annotations, authv1alpha1.RenewAnnotation)
+	if err := r.Patch(ctx, patched, client.MergeFrom(base)); err != nil {
+		return fmt.Errorf("failed to remove %s annotation: %w", authv1alpha1.RenewAnnotation, err)
+	}
+	delete(user.Annotations, authv1alpha1.RenewAnnotation)
+	user.ResourceVersion = patched.ResourceVersion
+	return nil
+}
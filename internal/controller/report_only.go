@@ -0,0 +1,216 @@
+/*
+Copyright 2025.
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+*/
+
+package controller
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"strings"
+
+	authorizationv1 "k8s.io/api/authorization/v1"
+	rbacv1 "k8s.io/api/rbac/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/api/meta"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/types"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	logf "sigs.k8s.io/controller-runtime/pkg/log"
+
+	authv1alpha1 "github.com/openkube-hub/KubeUser/api/v1alpha1"
+)
+
+const (
+	// BindingModeAnnotation overrides the operator-wide binding mode for a single user
+	BindingModeAnnotation = "auth.openkube.io/binding-mode"
+
+	// BindingModeEnforce creates, updates and deletes bindings (the default)
+	BindingModeEnforce = "enforce"
+	// BindingModeReportOnly computes binding changes and records them in status without applying them
+	BindingModeReportOnly = "report-only"
+
+	// ConditionBindingsEnforced is False while the user is reconciled in report-only mode
+	ConditionBindingsEnforced = "BindingsEnforced"
+
+	// maxChecksPerBinding bounds the SubjectAccessReviews made for each planned binding
+	maxChecksPerBinding = 3
+)
+
+// ValidateBindingMode checks a --binding-mode value
+func ValidateBindingMode(mode string) error {
+	if mode != BindingModeEnforce && mode != BindingModeReportOnly {
+		return fmt.Errorf("invalid binding mode %q, must be %q or %q", mode, BindingModeEnforce, BindingModeReportOnly)
+	}
+	return nil
+}
+
+// bindingMode returns the mode for the user: a valid annotation wins over the operator default
+func (r *UserReconciler) bindingMode(user *authv1alpha1.User) string {
+	if mode := user.Annotations[BindingModeAnnotation]; ValidateBindingMode(mode) == nil {
+		return mode
+	}
+	if r.BindingMode == "" {
+		return BindingModeEnforce
+	}
+	return r.BindingMode
+}
+
+// bindingPlan collects binding changes instead of applying them
+type bindingPlan struct {
+	bindings []authv1alpha1.PlannedBinding
+}
+
+// applyBinding performs a binding change and adds it to the access grant history, or only
+// records it in the plan when plan is non-nil
+func (r *UserReconciler) applyBinding(ctx context.Context, user *authv1alpha1.User, plan *bindingPlan,
+	action authv1alpha1.BindingAction, obj client.Object, roleRef rbacv1.RoleRef) error {
+	if plan != nil {
+		plan.bindings = append(plan.bindings, authv1alpha1.PlannedBinding{
+			Action:    action,
+			Kind:      bindingKind(obj),
+			Namespace: obj.GetNamespace(),
+			Name:      obj.GetName(),
+			RoleRef:   roleRef.Kind + "/" + roleRef.Name,
+		})
+		return nil
+	}
+
+	var err error
+	if action == authv1alpha1.BindingActionDelete {
+		err = r.Delete(ctx, obj)
+	} else {
+		err = r.apply(ctx, obj)
+	}
+	if err != nil {
+		return err
+	}
+	return r.recordBindingChange(ctx, user, action, obj, roleRef)
+}
+
+// recordAccessPlan stores the plan and spot checks in status, or clears them when bindings are enforced
+func (r *UserReconciler) recordAccessPlan(ctx context.Context, user *authv1alpha1.User, plan *bindingPlan) {
+	if plan == nil {
+		user.Status.PlannedAccess = nil
+		if meta.FindStatusCondition(user.Status.Conditions, ConditionBindingsEnforced) != nil {
+			meta.SetStatusCondition(&user.Status.Conditions, metav1.Condition{
+				Type:               ConditionBindingsEnforced,
+				Status:             metav1.ConditionTrue,
+				Reason:             "Enforced",
+				Message:            "Bindings are applied",
+				ObservedGeneration: user.Generation,
+			})
+		}
+		return
+	}
+
+	sort.Slice(plan.bindings, func(i, j int) bool {
+		a, b := plan.bindings[i], plan.bindings[j]
+		if a.Kind != b.Kind {
+			return a.Kind < b.Kind
+		}
+		if a.Namespace != b.Namespace {
+			return a.Namespace < b.Namespace
+		}
+		return a.Name < b.Name
+	})
+	checks, err := r.spotCheckAccess(ctx, user, plan.bindings)
+	if err != nil {
+		logf.FromContext(ctx).Error(err, "Failed to spot check planned access")
+	}
+	user.Status.PlannedAccess = &authv1alpha1.AccessPlan{Bindings: plan.bindings, Checks: checks}
+	meta.SetStatusCondition(&user.Status.Conditions, metav1.Condition{
+		Type:               ConditionBindingsEnforced,
+		Status:             metav1.ConditionFalse,
+		Reason:             "ReportOnly",
+		Message:            fmt.Sprintf("Report-only mode: %d binding changes were not applied, see status.plannedAccess", len(plan.bindings)),
+		ObservedGeneration: user.Generation,
+	})
+}
+
+// spotCheckAccess asks the API server, through SubjectAccessReviews, whether the user already has
+// a few of the permissions each planned binding would grant
+func (r *UserReconciler) spotCheckAccess(ctx context.Context, user *authv1alpha1.User, planned []authv1alpha1.PlannedBinding) ([]authv1alpha1.AccessCheck, error) {
+	var checks []authv1alpha1.AccessCheck
+	seen := map[authv1alpha1.AccessCheck]bool{}
+	for _, binding := range planned {
+		if binding.Action == authv1alpha1.BindingActionDelete {
+			continue
+		}
+		rules, err := r.plannedRoleRules(ctx, binding)
+		if err != nil {
+			return checks, err
+		}
+		for _, check := range sampleChecks(rules, binding.Namespace) {
+			if seen[check] {
+				continue
+			}
+			seen[check] = true
+
+			resource, subresource, _ := strings.Cut(check.Resource, "/")
+			username, groups := reviewIdentity(user)
+			review := &authorizationv1.SubjectAccessReview{
+				Spec: authorizationv1.SubjectAccessReviewSpec{
+					User:   username,
+					Groups: groups,
+					ResourceAttributes: &authorizationv1.ResourceAttributes{
+						Namespace:   check.Namespace,
+						Verb:        check.Verb,
+						Group:       check.Group,
+						Resource:    resource,
+						Subresource: subresource,
+					},
+				},
+			}
+			if err := r.Create(ctx, review); err != nil {
+				return checks, fmt.Errorf("subject access review for %s %s: %w", check.Verb, check.Resource, err)
+			}
+			check.AllowedNow = review.Status.Allowed
+			checks = append(checks, check)
+		}
+	}
+	return checks, nil
+}
+
+// plannedRoleRules loads the rules of the role a planned binding refers to
+func (r *UserReconciler) plannedRoleRules(ctx context.Context, binding authv1alpha1.PlannedBinding) ([]rbacv1.PolicyRule, error) {
+	kind, name, _ := strings.Cut(binding.RoleRef, "/")
+	if kind == "Role" {
+		var role rbacv1.Role
+		err := r.Get(ctx, types.NamespacedName{Namespace: binding.Namespace, Name: name}, &role)
+		return role.Rules, client.IgnoreNotFound(err)
+	}
+	var clusterRole rbacv1.ClusterRole
+	if err := r.Get(ctx, types.NamespacedName{Name: name}, &clusterRole); err != nil && !apierrors.IsNotFound(err) {
+		return nil, err
+	}
+	return clusterRole.Rules, nil
+}
+
+// sampleChecks picks up to maxChecksPerBinding concrete verb/resource pairs from the rules.
+// Wildcards and rules restricted to resource names are skipped.
+func sampleChecks(rules []rbacv1.PolicyRule, namespace string) []authv1alpha1.AccessCheck {
+	var checks []authv1alpha1.AccessCheck
+	for _, rule := range rules {
+		if len(rule.ResourceNames) > 0 {
+			continue
+		}
+		for _, group := range rule.APIGroups {
+			for _, resource := range rule.Resources {
+				for _, verb := range rule.Verbs {
+					if strings.Contains(group+resource+verb, "*") {
+						continue
+					}
+					checks = append(checks, authv1alpha1.AccessCheck{Namespace: namespace, Verb: verb, Group: group, Resource: resource})
+					if len(checks) == maxChecksPerBinding {
+						return checks
+					}
+				}
+			}
+		}
+	}
+	return checks
+}
@@ -0,0 +1,103 @@
+/*
+Copyright 2025.
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+*/
+
+package controller
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	corev1 "k8s.io/api/core/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/types"
+
+	authv1alpha1 "github.com/openkube-hub/KubeUser/api/v1alpha1"
+	"github.com/openkube-hub/KubeUser/internal/inventory"
+)
+
+// unknownRevoker is recorded when a User was revoked without passing the mutating webhook
+const unknownRevoker = "unknown"
+
+// reconcileRevocation records who revoked the user, deletes its key, credential Secrets and
+// certificate request and revokes its certificates in the inventory. Once spec.revoked is cleared the record is dropped and the next
+// reconcile issues a new key and certificate.
+func (r *UserReconciler) reconcileRevocation(ctx context.Context, user *authv1alpha1.User) error {
+	if !user.Spec.Revoked {
+		user.Status.Revocation = nil
+		return nil
+	}
+	revokedBefore := user.Status.Revocation != nil
+	user.Status.Revocation = revocationRecord(user, time.Now())
+	if !revokedBefore {
+		if err := r.recordCredentialsRevoked(ctx, user, user.Status.Revocation.By); err != nil {
+			return err
+		}
+	}
+	if err := r.deleteCredentials(ctx, user); err != nil {
+		return fmt.Errorf("failed to delete credentials of revoked user: %w", err)
+	}
+	if err := inventory.Revoke(ctx, r.Client, user.Name, authv1alpha1.RevocationReasonUserRevoked, time.Now()); err != nil {
+		return err
+	}
+	user.Status.CredentialSecret = nil
+	user.Status.TokenExpiry = nil
+	return nil
+}
+
+// revocationRecord reads the revocation from the webhook's annotations, keeping the recorded
+// one when they are missing
+func revocationRecord(user *authv1alpha1.User, now time.Time) *authv1alpha1.Revocation {
+	by := user.Annotations[authv1alpha1.RevokedByAnnotation]
+	at, err := time.Parse(time.RFC3339, user.Annotations[authv1alpha1.RevokedAtAnnotation])
+	if by != "" && err == nil {
+		return &authv1alpha1.Revocation{By: by, At: metav1.NewTime(at)}
+	}
+	if user.Status.Revocation != nil {
+		return user.Status.Revocation
+	}
+	return &authv1alpha1.Revocation{By: unknownRevoker, At: metav1.NewTime(now)}
+}
+
+// deleteCredentials deletes the user's key, SSH, passphrase and credential Secrets and resets its
+// certificate request, pending or issued
+func (r *UserReconciler) deleteCredentials(ctx context.Context, user *authv1alpha1.User) error {
+	username := user.Name
+	userNamespace := getKubeUserNamespace()
+
+	// Copies in credential stores go first, while the store configuration is certain to be read
+	errs := []error{r.deleteFromCredentialStores(ctx, user)}
+	for _, name := range []string{userKeySecretName(username), sshSecretName(username), userPassphraseSecretName(username)} {
+		secret := &corev1.Secret{ObjectMeta: metav1.ObjectMeta{Name: name, Namespace: userNamespace}}
+		if err := r.Delete(ctx, secret); err != nil && !apierrors.IsNotFound(err) {
+			errs = append(errs, err)
+		}
+	}
+	credentialSecrets := []types.NamespacedName{
+		{Name: userKubeconfigSecretName(username), Namespace: userNamespace},
+		credentialSecretKey(user),
+	}
+	if ref := user.Status.CredentialSecret; ref != nil {
+		credentialSecrets = append(credentialSecrets, types.NamespacedName{Name: ref.Name, Namespace: ref.Namespace})
+	}
+	for _, key := range credentialSecrets {
+		errs = append(errs, r.deleteCredentialSecret(ctx, key, username))
+	}
+	errs = append(errs, r.certIssuer().Reset(ctx, userCSRName(username)))
+	return errors.Join(errs...)
+}
+
+// revocationMessage describes the revocation for status and Events
+func revocationMessage(user *authv1alpha1.User) string {
+	revocation := user.Status.Revocation
+	if revocation == nil {
+		return "User is revoked, all role bindings and credentials are removed"
+	}
+	return fmt.Sprintf("User was revoked by %s at %s, all role bindings and credentials are removed",
+		revocation.By, revocation.At.UTC().Format(time.RFC3339))
+}
@@ -0,0 +1,96 @@
+/*
+Copyright 2025.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package controller
+
+import (
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+	corev1 "k8s.io/api/core/v1"
+	rbacv1 "k8s.io/api/rbac/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/types"
+
+	authv1alpha1 "github.com/openkube-hub/KubeUser/api/v1alpha1"
+)
+
+var _ = Describe("Revoked users", func() {
+	const name = "revoked-user"
+	var (
+		r           *UserReconciler
+		signer      *pendingIssuer
+		clusterRole *rbacv1.ClusterRole
+	)
+
+	BeforeEach(func() {
+		signer = &pendingIssuer{}
+		r = newCachedReconciler(signer)
+		clusterRole = &rbacv1.ClusterRole{
+			ObjectMeta: metav1.ObjectMeta{Name: "revoked-user-reader"},
+			Rules:      []rbacv1.PolicyRule{{APIGroups: []string{""}, Resources: []string{"pods"}, Verbs: []string{"get"}}},
+		}
+		Expect(k8sClient.Create(ctx, clusterRole)).To(Succeed())
+		Expect(k8sClient.Create(ctx, &authv1alpha1.User{
+			ObjectMeta: metav1.ObjectMeta{Name: name},
+			Spec: authv1alpha1.UserSpec{
+				ClusterRoles: []authv1alpha1.ClusterRoleSpec{{ExistingClusterRole: clusterRole.Name}},
+			},
+		})).To(Succeed())
+	})
+
+	AfterEach(func() {
+		deleteUser(r, name)
+		Expect(k8sClient.Delete(ctx, clusterRole)).To(Succeed())
+	})
+
+	It("removes the bindings and the key and issues nothing until the revocation is cleared", func() {
+		binding := &rbacv1.ClusterRoleBinding{ObjectMeta: metav1.ObjectMeta{
+			Name: clusterRoleBindingName(name, clusterRole.Name)}}
+		key := &corev1.Secret{ObjectMeta: metav1.ObjectMeta{
+			Name: userKeySecretName(name), Namespace: getKubeUserNamespace()}}
+		reconcileUntil(r, name, func(g Gomega) {
+			expectExists(g, binding, true)
+			expectExists(g, key, true)
+		})
+
+		By("revoking the user")
+		updateUser(name, func(user *authv1alpha1.User) { user.Spec.Revoked = true })
+		signed := len(signer.signed)
+		reconcileUntil(r, name, func(g Gomega) {
+			expectExists(g, binding, false)
+			expectExists(g, key, false)
+			var user authv1alpha1.User
+			g.Expect(k8sClient.Get(ctx, types.NamespacedName{Name: name}, &user)).To(Succeed())
+			g.Expect(user.Status.Phase).To(Equal(PhaseRevoked))
+			// Revoked without the mutating webhook, which records who it was
+			g.Expect(user.Status.Revocation).To(HaveField("By", unknownRevoker))
+		})
+		// The certificate request is dropped and no new one made
+		Expect(signer.reset).To(ContainElement(userCSRName(name)))
+		Expect(signer.signed).To(HaveLen(signed))
+
+		By("clearing the revocation")
+		updateUser(name, func(user *authv1alpha1.User) { user.Spec.Revoked = false })
+		reconcileUntil(r, name, func(g Gomega) {
+			expectExists(g, binding, true)
+			expectExists(g, key, true)
+			var user authv1alpha1.User
+			g.Expect(k8sClient.Get(ctx, types.NamespacedName{Name: name}, &user)).To(Succeed())
+			g.Expect(user.Status.Revocation).To(BeNil())
+		})
+		Expect(len(signer.signed)).To(BeNumerically(">", signed))
+	})
+})
@@ -0,0 +1,115 @@
+/*
+Copyright 2025.
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+*/
+
+package controller
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"time"
+
+	rbacv1 "k8s.io/api/rbac/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/api/meta"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/types"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	logf "sigs.k8s.io/controller-runtime/pkg/log"
+	"sigs.k8s.io/controller-runtime/pkg/reconcile"
+
+	authv1alpha1 "github.com/openkube-hub/KubeUser/api/v1alpha1"
+)
+
+// ConditionRolesValid is False while a Role or ClusterRole the user is to be bound to does not
+// exist. Its bindings are not created, or grant nothing while the role is gone.
+const ConditionRolesValid = "RolesValid"
+
+// checkRoleReferences sets the RolesValid condition from the roles the user is currently bound to
+func (r *UserReconciler) checkRoleReferences(ctx context.Context, user *authv1alpha1.User, now time.Time) error {
+	var missing []string
+	for _, role := range boundRoles(user, now) {
+		var obj rbacv1.Role
+		if err := r.Get(ctx, types.NamespacedName{Namespace: role.Namespace, Name: role.ExistingRole}, &obj); err != nil {
+			if !apierrors.IsNotFound(err) {
+				return err
+			}
+			missing = append(missing, fmt.Sprintf("Role %s/%s", role.Namespace, role.ExistingRole))
+		}
+	}
+	for _, clusterRole := range boundClusterRoles(user, now) {
+		var obj rbacv1.ClusterRole
+		if err := r.Get(ctx, types.NamespacedName{Name: clusterRole.ExistingClusterRole}, &obj); err != nil {
+			if !apierrors.IsNotFound(err) {
+				return err
+			}
+			missing = append(missing, "ClusterRole "+clusterRole.ExistingClusterRole)
+		}
+	}
+
+	condition := metav1.Condition{
+		Type:               ConditionRolesValid,
+		Status:             metav1.ConditionTrue,
+		Reason:             "RolesFound",
+		Message:            "All referenced roles exist",
+		ObservedGeneration: user.Generation,
+	}
+	if len(missing) > 0 {
+		condition.Status = metav1.ConditionFalse
+		condition.Reason = "RoleNotFound"
+		condition.Message = "Referenced roles do not exist: " + strings.Join(missing, ", ")
+	}
+	meta.SetStatusCondition(&user.Status.Conditions, condition)
+	return nil
+}
+
+// usersForRole maps a Role or ClusterRole change to reconcile requests for the Users bound to it,
+// through a managed binding or their spec, so that a deleted role is reported and a created one
+// bound right away
+func (r *UserReconciler) usersForRole(ctx context.Context, obj client.Object) []reconcile.Request {
+	logger := logf.FromContext(ctx)
+	names := map[string]bool{}
+	var users authv1alpha1.UserList
+	switch obj.(type) {
+	case *rbacv1.Role:
+		var rbs rbacv1.RoleBindingList
+		if err := r.List(ctx, &rbs, client.InNamespace(obj.GetNamespace()),
+			client.MatchingFields{BindingRoleIndex: "Role/" + obj.GetName()}); err != nil {
+			logger.Error(err, "Failed to list role bindings for role change")
+			return nil
+		}
+		for _, rb := range rbs.Items {
+			names[rb.Labels["auth.openkube.io/user"]] = true
+		}
+		if err := r.List(ctx, &users, client.MatchingFields{UserRoleIndex: obj.GetNamespace() + "/" + obj.GetName()}); err != nil {
+			logger.Error(err, "Failed to list users for role change")
+			return nil
+		}
+	case *rbacv1.ClusterRole:
+		var crbs rbacv1.ClusterRoleBindingList
+		if err := r.List(ctx, &crbs, client.MatchingFields{BindingRoleIndex: "ClusterRole/" + obj.GetName()}); err != nil {
+			logger.Error(err, "Failed to list cluster role bindings for cluster role change")
+			return nil
+		}
+		for _, crb := range crbs.Items {
+			names[crb.Labels["auth.openkube.io/user"]] = true
+		}
+		if err := r.List(ctx, &users, client.MatchingFields{UserClusterRoleIndex: obj.GetName()}); err != nil {
+			logger.Error(err, "Failed to list users for cluster role change")
+			return nil
+		}
+	}
+	for _, user := range users.Items {
+		names[user.Name] = true
+	}
+	// Bindings made outside KubeUser have no user
+	delete(names, "")
+	requests := make([]reconcile.Request, 0, len(names))
+	for name := range names {
+		requests = append(requests, reconcile.Request{NamespacedName: types.NamespacedName{Name: name}})
+	}
+	return requests
+}
@@ -0,0 +1,94 @@
+/*
+Copyright 2025.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package controller
+
+import (
+	"time"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+	rbacv1 "k8s.io/api/rbac/v1"
+	"k8s.io/apimachinery/pkg/api/meta"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/types"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+
+	authv1alpha1 "github.com/openkube-hub/KubeUser/api/v1alpha1"
+)
+
+var _ = Describe("Role watches", func() {
+	const name = "role-watching-user"
+	var (
+		clusterRole *rbacv1.ClusterRole
+		binding     *rbacv1.ClusterRoleBinding
+	)
+
+	// rolesValid returns the RolesValid condition of the user
+	rolesValid := func(g Gomega) *metav1.Condition {
+		var user authv1alpha1.User
+		g.Expect(k8sClient.Get(ctx, types.NamespacedName{Name: name}, &user)).To(Succeed())
+		return meta.FindStatusCondition(user.Status.Conditions, ConditionRolesValid)
+	}
+
+	BeforeEach(func() {
+		// The ClusterRole is created by the spec
+		clusterRole = &rbacv1.ClusterRole{
+			ObjectMeta: metav1.ObjectMeta{Name: "role-watching-user-reader"},
+			Rules:      []rbacv1.PolicyRule{{APIGroups: []string{""}, Resources: []string{"pods"}, Verbs: []string{"get"}}},
+		}
+		Expect(k8sClient.Create(ctx, &authv1alpha1.User{
+			ObjectMeta: metav1.ObjectMeta{Name: name},
+			Spec: authv1alpha1.UserSpec{
+				ClusterRoles: []authv1alpha1.ClusterRoleSpec{{ExistingClusterRole: clusterRole.Name}},
+			},
+		})).To(Succeed())
+		binding = &rbacv1.ClusterRoleBinding{ObjectMeta: metav1.ObjectMeta{
+			Name: clusterRoleBindingName(name, clusterRole.Name)}}
+		// Cleanups run last to first, so this one runs once the controller has stopped
+		DeferCleanup(func() {
+			deleteUser(newCachedReconciler(&pendingIssuer{}), name)
+			Expect(client.IgnoreNotFound(k8sClient.Delete(ctx, clusterRole))).To(Succeed())
+		})
+
+		startUserController(&UserReconciler{Issuer: &pendingIssuer{},
+			ProxyServer: "https://kubeuser-proxy.example.org", ProxyCA: []byte("ca")})
+	})
+
+	It("binds a ClusterRole created after the user and reports it once it is deleted", func() {
+		Eventually(func(g Gomega) {
+			g.Expect(rolesValid(g)).To(And(
+				HaveField("Status", metav1.ConditionFalse),
+				HaveField("Reason", "RoleNotFound"),
+				HaveField("Message", ContainSubstring("ClusterRole "+clusterRole.Name)),
+			))
+		}, 10*time.Second).Should(Succeed())
+		expectExists(Default, binding, false)
+
+		By("creating the ClusterRole")
+		Expect(k8sClient.Create(ctx, clusterRole)).To(Succeed())
+		Eventually(func(g Gomega) {
+			g.Expect(rolesValid(g)).To(HaveField("Status", metav1.ConditionTrue))
+			expectExists(g, binding, true)
+		}, 10*time.Second).Should(Succeed())
+
+		By("deleting the ClusterRole")
+		Expect(k8sClient.Delete(ctx, clusterRole)).To(Succeed())
+		Eventually(func(g Gomega) {
+			g.Expect(rolesValid(g)).To(HaveField("Reason", "RoleNotFound"))
+		}, 10*time.Second).Should(Succeed())
+	})
+})
@@ -0,0 +1,106 @@
+/*
+Copyright 2025.
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+*/
+
+package controller
+
+import (
+	"context"
+	"time"
+
+	"go.opentelemetry.io/otel/attribute"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/equality"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/types"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+
+	authv1alpha1 "github.com/openkube-hub/KubeUser/api/v1alpha1"
+	"github.com/openkube-hub/KubeUser/internal/tracing"
+)
+
+// Types of the Secrets the controller writes for each User, which tell them apart from the
+// Secrets of other tools. Secrets written by earlier releases are Opaque; since the type of a
+// Secret cannot change, they are replaced the next time they are written.
+const (
+	CredentialSecretType corev1.SecretType = "auth.openkube.io/credentials"
+	PrivateKeySecretType corev1.SecretType = "auth.openkube.io/private-key"
+	SSHSecretType        corev1.SecretType = "auth.openkube.io/ssh-certificate"
+	PassphraseSecretType corev1.SecretType = "auth.openkube.io/kubeconfig-passphrase"
+)
+
+// rotatedAtAnnotation records when the credentials or key in a Secret were issued
+const rotatedAtAnnotation = "auth.openkube.io/rotated-at"
+
+// userSecret returns an immutable Secret of secretType holding data for user. It is labelled as
+// managed by KubeUser and owned by the User, so it is garbage collected with it even if the
+// finalizer is removed by hand.
+func userSecret(key types.NamespacedName, user *authv1alpha1.User, secretType corev1.SecretType,
+	data map[string][]byte) *corev1.Secret {
+	return &corev1.Secret{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      key.Name,
+			Namespace: key.Namespace,
+			Labels: map[string]string{
+				"auth.openkube.io/user":        user.Name,
+				"app.kubernetes.io/managed-by": "kubeuser",
+			},
+			Annotations: map[string]string{},
+			OwnerReferences: []metav1.OwnerReference{{
+				APIVersion: "auth.openkube.io/v1alpha1",
+				Kind:       "User",
+				Name:       user.Name,
+				UID:        user.UID,
+				Controller: &[]bool{true}[0],
+			}},
+		},
+		Type:      secretType,
+		Immutable: &[]bool{true}[0],
+		Data:      data,
+	}
+}
+
+// setRotatedAt records on secret when its credentials were issued
+func setRotatedAt(secret *corev1.Secret, at time.Time) {
+	secret.Annotations[rotatedAtAnnotation] = at.UTC().Format(time.RFC3339)
+}
+
+// rotatedAt returns when the credentials in secret were issued; Secrets written before this was
+// recorded were last issued no earlier than they were created
+func rotatedAt(secret *corev1.Secret) time.Time {
+	if at, err := time.Parse(time.RFC3339, secret.Annotations[rotatedAtAnnotation]); err == nil {
+		return at
+	}
+	return secret.CreationTimestamp.Time
+}
+
+// writeSecret applies secret. The data of an immutable Secret and the type of any Secret cannot
+// be changed in place, so an existing Secret that differs in either is deleted and created again.
+func (r *UserReconciler) writeSecret(ctx context.Context, secret *corev1.Secret) (err error) {
+	ctx, span := tracing.Start(ctx, "Secret.Write",
+		attribute.String("secret", client.ObjectKeyFromObject(secret).String()))
+	defer func() { tracing.End(span, err) }()
+
+	var existing corev1.Secret
+	err = r.Get(ctx, client.ObjectKeyFromObject(secret), &existing)
+	if client.IgnoreNotFound(err) != nil {
+		return err
+	}
+	immutable := existing.Immutable != nil && *existing.Immutable
+	if err == nil && (existing.Type != secret.Type || immutable && !equality.Semantic.DeepEqual(existing.Data, secret.Data)) {
+		span.SetAttributes(attribute.Bool("replaced", true))
+		return r.replaceSecret(ctx, &existing, secret)
+	}
+	return r.apply(ctx, secret)
+}
+
+// replaceSecret deletes existing and creates secret in its place. The deletion is conditional
+// on existing being the current Secret, so a Secret written meanwhile is not lost.
+func (r *UserReconciler) replaceSecret(ctx context.Context, existing, secret *corev1.Secret) error {
+	if err := r.Delete(ctx, existing, client.Preconditions{UID: &existing.UID}); client.IgnoreNotFound(err) != nil {
+		return err
+	}
+	return r.apply(ctx, secret)
+}
@@ -0,0 +1,194 @@
+/*
+Copyright 2025.
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+*/
+
+package controller
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"strings"
+	"time"
+
+	corev1 "k8s.io/api/core/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/api/meta"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/types"
+	logf "sigs.k8s.io/controller-runtime/pkg/log"
+
+	authv1alpha1 "github.com/openkube-hub/KubeUser/api/v1alpha1"
+	"github.com/openkube-hub/KubeUser/internal/naming"
+	"github.com/openkube-hub/KubeUser/internal/sshcert"
+)
+
+const (
+	// ConditionSSHCertificateReady reports whether the SSH certificate requested in spec.ssh was issued
+	ConditionSSHCertificateReady = "SSHCertificateReady"
+
+	// DefaultSSHCAKey is the key of the SSH CA private key in the --ssh-ca-secret Secret
+	DefaultSSHCAKey = "ca"
+
+	// sshIssuedForAnnotation records what the SSH certificate in the Secret was issued for, so it
+	// is only re-issued when the client certificate rotates or spec.ssh changes
+	sshIssuedForAnnotation = "auth.openkube.io/ssh-issued-for"
+
+	// sshClockSkew backdates certificates so nodes with slightly late clocks accept them
+	sshClockSkew = 5 * time.Minute
+
+	// Keys of the <user>-ssh Secret; ssh picks up key-cert.pub next to the key automatically
+	sshPrivateKeyKey  = "key"
+	sshPublicKeyKey   = "key.pub"
+	sshCertificateKey = "key-cert.pub"
+)
+
+// ParseSSHCASecret parses an --ssh-ca-secret value of the form <namespace>/<name>[/<key>]
+func ParseSSHCASecret(value string) (types.NamespacedName, string, error) {
+	if value == "" {
+		return types.NamespacedName{}, "", nil
+	}
+	parts := strings.Split(value, "/")
+	if len(parts) < 2 || len(parts) > 3 || parts[0] == "" || parts[1] == "" {
+		return types.NamespacedName{}, "", fmt.Errorf("invalid SSH CA secret %q, must be <namespace>/<name>[/<key>]", value)
+	}
+	key := DefaultSSHCAKey
+	if len(parts) == 3 && parts[2] != "" {
+		key = parts[2]
+	}
+	return types.NamespacedName{Namespace: parts[0], Name: parts[1]}, key, nil
+}
+
+func sshSecretName(username string) string {
+	return naming.Suffixed(naming.MaxNameLength, username, "ssh")
+}
+
+// sshPrincipals returns the login names for the user's certificate
+func sshPrincipals(user *authv1alpha1.User) []string {
+	if len(user.Spec.SSH.Principals) > 0 {
+		return user.Spec.SSH.Principals
+	}
+	return []string{user.Name}
+}
+
+// ensureSSHCertificate issues the SSH certificate requested in spec.ssh into the <user>-ssh Secret.
+// The certificate is valid until the client certificate expires, so it follows the same rotation,
+// and the Secret is removed again when spec.ssh is dropped.
+func (r *UserReconciler) ensureSSHCertificate(ctx context.Context, user *authv1alpha1.User) error {
+	logger := logf.FromContext(ctx)
+	key := types.NamespacedName{Name: sshSecretName(user.Name), Namespace: getKubeUserNamespace()}
+	var secret corev1.Secret
+	err := r.Get(ctx, key, &secret)
+	if err != nil && !apierrors.IsNotFound(err) {
+		return err
+	}
+	exists := err == nil
+
+	if user.Spec.SSH == nil {
+		if exists && secret.Labels["auth.openkube.io/user"] == user.Name {
+			logger.Info("SSH certificate no longer requested, deleting secret", "secret", key.Name)
+			if err := r.Delete(ctx, &secret); err != nil && !apierrors.IsNotFound(err) {
+				return err
+			}
+		}
+		meta.RemoveStatusCondition(&user.Status.Conditions, ConditionSSHCertificateReady)
+		return nil
+	}
+
+	if r.SSHCASecret.Name == "" {
+		r.setSSHCondition(user, metav1.ConditionFalse, "CANotConfigured",
+			"spec.ssh is set but the operator runs without --ssh-ca-secret")
+		return nil
+	}
+	if user.Status.CertificateExpiry != "Certificate" {
+		// Wait for the client certificate, its expiry bounds the SSH certificate
+		return nil
+	}
+	validBefore, err := time.Parse(time.RFC3339, user.Status.ExpiryTime)
+	if err != nil {
+		return fmt.Errorf("failed to parse certificate expiry: %w", err)
+	}
+
+	principals := sshPrincipals(user)
+	publicKeyHash := "generated"
+	if user.Spec.SSH.PublicKey != "" {
+		sum := sha256.Sum256([]byte(strings.TrimSpace(user.Spec.SSH.PublicKey)))
+		publicKeyHash = hex.EncodeToString(sum[:8])
+	}
+	issuedFor := fmt.Sprintf("%s;%s;%s", user.Status.ExpiryTime, strings.Join(principals, ","), publicKeyHash)
+	if exists && secret.Annotations[sshIssuedForAnnotation] == issuedFor {
+		return nil
+	}
+
+	var caSecret corev1.Secret
+	if err := r.Get(ctx, r.SSHCASecret, &caSecret); err != nil {
+		r.setSSHCondition(user, metav1.ConditionFalse, "CAUnavailable", fmt.Sprintf("failed to read SSH CA: %v", err))
+		return err
+	}
+	ca, err := sshcert.ParseCAKey(caSecret.Data[r.SSHCAKey])
+	if err != nil {
+		r.setSSHCondition(user, metav1.ConditionFalse, "CAInvalid", fmt.Sprintf("invalid SSH CA key: %v", err))
+		return err
+	}
+
+	// Certify the user's own key, or keep using the generated one across re-issues
+	data := map[string][]byte{}
+	var publicKey sshcert.PublicKey
+	switch {
+	case user.Spec.SSH.PublicKey != "":
+		if publicKey, err = sshcert.ParseAuthorizedKey(user.Spec.SSH.PublicKey); err != nil {
+			r.setSSHCondition(user, metav1.ConditionFalse, "InvalidPublicKey", err.Error())
+			return nil
+		}
+	case exists && len(secret.Data[sshPrivateKeyKey]) > 0 && len(secret.Data[sshPublicKeyKey]) > 0:
+		if publicKey, err = sshcert.ParseAuthorizedKey(string(secret.Data[sshPublicKeyKey])); err != nil {
+			return fmt.Errorf("failed to parse stored SSH public key: %w", err)
+		}
+		data[sshPrivateKeyKey] = secret.Data[sshPrivateKeyKey]
+	default:
+		privateKey, generated, err := sshcert.GenerateKey(user.Name)
+		if err != nil {
+			return err
+		}
+		publicKey = generated
+		data[sshPrivateKeyKey] = privateKey
+	}
+
+	cert, err := sshcert.Sign(ca, sshcert.Request{
+		Key:         publicKey,
+		KeyID:       "kubeuser:" + user.Name,
+		Principals:  principals,
+		ValidAfter:  time.Now().Add(-sshClockSkew),
+		ValidBefore: validBefore,
+	})
+	if err != nil {
+		r.setSSHCondition(user, metav1.ConditionFalse, "SigningFailed", err.Error())
+		return err
+	}
+	data[sshPublicKeyKey] = publicKey.MarshalAuthorizedKey(user.Name)
+	data[sshCertificateKey] = cert
+
+	logger.Info("Issuing SSH certificate", "secret", key.Name, "principals", principals, "validBefore", validBefore)
+	sshSecret := userSecret(key, user, SSHSecretType, data)
+	sshSecret.Annotations[sshIssuedForAnnotation] = issuedFor
+	setRotatedAt(sshSecret, time.Now())
+	if err := r.writeSecret(ctx, sshSecret); err != nil {
+		return err
+	}
+	r.setSSHCondition(user, metav1.ConditionTrue, "Issued",
+		fmt.Sprintf("SSH certificate for %s valid until %s", strings.Join(principals, ","), user.Status.ExpiryTime))
+	return nil
+}
+
+// setSSHCondition records the state of the user's SSH certificate
+func (r *UserReconciler) setSSHCondition(user *authv1alpha1.User, status metav1.ConditionStatus, reason, message string) {
+	meta.SetStatusCondition(&user.Status.Conditions, metav1.Condition{
+		Type:    ConditionSSHCertificateReady,
+		Status:  status,
+		Reason:  reason,
+		Message: message,
+	})
+}
@@ -0,0 +1,135 @@
+/*
+Copyright 2025.
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+*/
+
+package controller
+
+import (
+	"context"
+	"sync"
+	"time"
+
+	"k8s.io/apimachinery/pkg/api/equality"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/client-go/util/retry"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	logf "sigs.k8s.io/controller-runtime/pkg/log"
+
+	authv1alpha1 "github.com/openkube-hub/KubeUser/api/v1alpha1"
+)
+
+// DefaultStatusMessageInterval is how often a user's status may be written when only
+// human-readable messages changed
+const DefaultStatusMessageInterval = 30 * time.Second
+
+// statusThrottle remembers when each user's status was last written
+type statusThrottle struct {
+	mu        sync.Mutex
+	lastWrite map[string]time.Time
+}
+
+// allow reports whether a message-only change may be written now
+func (t *statusThrottle) allow(name string, now time.Time, interval time.Duration) bool {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	last, ok := t.lastWrite[name]
+	return !ok || now.Sub(last) >= interval
+}
+
+func (t *statusThrottle) written(name string, now time.Time) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	if t.lastWrite == nil {
+		t.lastWrite = map[string]time.Time{}
+	}
+	t.lastWrite[name] = now
+}
+
+func (t *statusThrottle) forget(name string) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	delete(t.lastWrite, name)
+}
+
+// updateStatus writes the changes a reconcile made to user's status since original was read, as
+// a single merge patch. Status that equals the original is never written, so repeating the same
+// message does not create a new resourceVersion. Changes confined to messages are written at
+// most once per StatusMessageInterval; phase, condition status and every other field are
+// written right away. The standard conditions and the observed generation are brought up to
+// date first.
+//
+// The patch only applies to the User as the reconcile last saw it. If it was changed meanwhile,
+// it is read again from the API server, since the cache may not have seen the change yet, and
+// the status written on top of it, so that a concurrent update never makes the reconcile fail.
+func (r *UserReconciler) updateStatus(ctx context.Context, original, user *authv1alpha1.User) error {
+	setStandardConditions(user, time.Now())
+	recordUserMetrics(user)
+
+	if equality.Semantic.DeepEqual(original.Status, user.Status) {
+		return nil
+	}
+	interval := r.StatusMessageInterval
+	if interval == 0 {
+		interval = DefaultStatusMessageInterval
+	}
+	if onlyMessagesDiffer(&original.Status, &user.Status) && !r.statusThrottle.allow(user.Name, time.Now(), interval) {
+		logf.FromContext(ctx).V(1).Info("Throttling message-only status update", "user", user.Name)
+		return nil
+	}
+
+	// Writes of the reconcile itself, such as adding the finalizer, moved the resourceVersion on
+	base := original.DeepCopy()
+	base.ResourceVersion = user.ResourceVersion
+	var reader client.Reader = r.APIReader
+	if reader == nil {
+		reader = r.Client
+	}
+	err := retry.RetryOnConflict(retry.DefaultBackoff, func() error {
+		// Only the status is patched, so user keeps the spec merged with its UserTemplate
+		patched := base.DeepCopy()
+		patched.Status = *user.Status.DeepCopy()
+		err := r.Status().Patch(ctx, patched, client.MergeFromWithOptions(base, client.MergeFromWithOptimisticLock{}))
+		if apierrors.IsConflict(err) {
+			var latest authv1alpha1.User
+			if getErr := reader.Get(ctx, types.NamespacedName{Name: user.Name}, &latest); getErr != nil {
+				return getErr
+			}
+			base = &latest
+		} else if err == nil {
+			user.ResourceVersion = patched.ResourceVersion
+		}
+		return err
+	})
+	if err != nil {
+		return err
+	}
+	r.statusThrottle.written(user.Name, time.Now())
+	r.phaseEvent(ctx, user, original.Status.Phase)
+	return nil
+}
+
+// onlyMessagesDiffer reports whether two statuses differ in nothing but the status message,
+// condition messages and binding errors
+func onlyMessagesDiffer(a, b *authv1alpha1.UserStatus) bool {
+	a, b = a.DeepCopy(), b.DeepCopy()
+	a.Message, b.Message = "", ""
+	stripConditionMessages(a.Conditions)
+	stripConditionMessages(b.Conditions)
+	for i := range a.Bindings {
+		a.Bindings[i].LastError = ""
+	}
+	for i := range b.Bindings {
+		b.Bindings[i].LastError = ""
+	}
+	return equality.Semantic.DeepEqual(a, b)
+}
+
+func stripConditionMessages(conditions []metav1.Condition) {
+	for i := range conditions {
+		conditions[i].Message = ""
+	}
+}
@@ -0,0 +1,166 @@
+/*
+Copyright 2025.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package controller
+
+import (
+	"context"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/types"
+	utilruntime "k8s.io/apimachinery/pkg/util/runtime"
+	clientgoscheme "k8s.io/client-go/kubernetes/scheme"
+	ctrl "sigs.k8s.io/controller-runtime"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+	"sigs.k8s.io/controller-runtime/pkg/client/interceptor"
+
+	authv1alpha1 "github.com/openkube-hub/KubeUser/api/v1alpha1"
+)
+
+// These specs use a fake client rather than the envtest API server, which cannot inject the
+// conflicts they depend on.
+
+// unitScheme returns a scheme with the built-in types and the KubeUser API
+func unitScheme() *runtime.Scheme {
+	scheme := runtime.NewScheme()
+	utilruntime.Must(clientgoscheme.AddToScheme(scheme))
+	utilruntime.Must(authv1alpha1.AddToScheme(scheme))
+	return scheme
+}
+
+// statusClient returns a fake client holding objects that counts the status patches of Users in
+// patches and fails the first failures of them with a conflict
+func statusClient(patches *int, failures int, objects ...client.Object) client.WithWatch {
+	return fake.NewClientBuilder().
+		WithScheme(unitScheme()).
+		WithObjects(objects...).
+		WithStatusSubresource(&authv1alpha1.User{}).
+		WithInterceptorFuncs(interceptor.Funcs{
+			SubResourcePatch: func(ctx context.Context, c client.Client, subResourceName string, obj client.Object,
+				patch client.Patch, opts ...client.SubResourcePatchOption) error {
+				if _, ok := obj.(*authv1alpha1.User); ok && subResourceName == "status" {
+					*patches++
+					if *patches <= failures {
+						return apierrors.NewConflict(authv1alpha1.GroupVersion.WithResource("users").GroupResource(),
+							obj.GetName(), nil)
+					}
+				}
+				return c.SubResource(subResourceName).Patch(ctx, obj, patch, opts...)
+			},
+		}).
+		Build()
+}
+
+// countingReader counts the reads of a reader
+type countingReader struct {
+	client.Reader
+	gets int
+}
+
+func (r *countingReader) Get(ctx context.Context, key client.ObjectKey, obj client.Object, opts ...client.GetOption) error {
+	r.gets++
+	return r.Reader.Get(ctx, key, obj, opts...)
+}
+
+var _ = Describe("User status", func() {
+	It("writes the status of a reconcile once, and not at all when it is unchanged", func() {
+		ctx := context.Background()
+		// The missing UserTemplate fails the reconcile after the initial status was set
+		user := &authv1alpha1.User{
+			ObjectMeta: metav1.ObjectMeta{Name: "jane"},
+			Spec:       authv1alpha1.UserSpec{TemplateRef: &authv1alpha1.UserTemplateReference{Name: "missing"}},
+		}
+		patches := 0
+		c := statusClient(&patches, 0, user)
+		r := &UserReconciler{Client: c, Scheme: c.Scheme()}
+
+		_, err := r.reconcileUser(ctx, ctrl.Request{NamespacedName: types.NamespacedName{Name: "jane"}})
+		Expect(err).To(HaveOccurred())
+		Expect(patches).To(Equal(1))
+
+		var stored authv1alpha1.User
+		Expect(c.Get(ctx, types.NamespacedName{Name: "jane"}, &stored)).To(Succeed())
+		Expect(stored.Finalizers).To(ContainElement(userFinalizer))
+		Expect(stored.Status.Phase).To(Equal(PhaseError))
+		Expect(stored.Status.Message).To(ContainSubstring("Failed to apply UserTemplate"))
+		Expect(stored.Status.ObservedGeneration).To(Equal(stored.Generation))
+
+		// The same outcome again changes nothing, so nothing is written
+		_, err = r.reconcileUser(ctx, ctrl.Request{NamespacedName: types.NamespacedName{Name: "jane"}})
+		Expect(err).To(HaveOccurred())
+		Expect(patches).To(Equal(1))
+	})
+
+	It("re-reads the User from the API server on conflicts", func() {
+		ctx := context.Background()
+		patches := 0
+		c := statusClient(&patches, 1, &authv1alpha1.User{ObjectMeta: metav1.ObjectMeta{Name: "jane"}})
+		reader := &countingReader{Reader: c}
+		cachedGets := 0
+		r := &UserReconciler{
+			Client: interceptor.NewClient(c, interceptor.Funcs{
+				Get: func(ctx context.Context, c client.WithWatch, key client.ObjectKey, obj client.Object, opts ...client.GetOption) error {
+					cachedGets++
+					return c.Get(ctx, key, obj, opts...)
+				},
+			}),
+			APIReader: reader,
+		}
+
+		var original authv1alpha1.User
+		Expect(c.Get(ctx, types.NamespacedName{Name: "jane"}, &original)).To(Succeed())
+		user := original.DeepCopy()
+		user.Status.Phase = PhaseReady
+		user.Status.Message = "ready"
+
+		// Meanwhile someone else labels the User
+		labeled := original.DeepCopy()
+		labeled.Labels = map[string]string{"team": "platform"}
+		Expect(c.Update(ctx, labeled)).To(Succeed())
+
+		Expect(r.updateStatus(ctx, &original, user)).To(Succeed())
+		Expect(patches).To(Equal(2))
+		Expect(reader.gets).To(Equal(1))
+		Expect(cachedGets).To(BeZero())
+
+		var stored authv1alpha1.User
+		Expect(c.Get(ctx, types.NamespacedName{Name: "jane"}, &stored)).To(Succeed())
+		Expect(stored.Labels).To(HaveKeyWithValue("team", "platform"))
+		Expect(stored.Status.Phase).To(Equal(PhaseReady))
+		Expect(stored.Status.Message).To(Equal("ready"))
+		Expect(user.ResourceVersion).To(Equal(stored.ResourceVersion))
+	})
+
+	It("gives up on persistent conflicts", func() {
+		ctx := context.Background()
+		patches := 0
+		c := statusClient(&patches, 1000, &authv1alpha1.User{ObjectMeta: metav1.ObjectMeta{Name: "jane"}})
+		r := &UserReconciler{Client: c}
+
+		var original authv1alpha1.User
+		Expect(c.Get(ctx, types.NamespacedName{Name: "jane"}, &original)).To(Succeed())
+		user := original.DeepCopy()
+		user.Status.Phase = PhaseReady
+
+		Expect(apierrors.IsConflict(r.updateStatus(ctx, &original, user))).To(BeTrue())
+		Expect(patches).To(BeNumerically(">", 1))
+	})
+})
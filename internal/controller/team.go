@@ -0,0 +1,79 @@
+/*
+Copyright 2025.
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+*/
+
+package controller
+
+import (
+	"context"
+	"fmt"
+
+	"k8s.io/apimachinery/pkg/types"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	logf "sigs.k8s.io/controller-runtime/pkg/log"
+	"sigs.k8s.io/controller-runtime/pkg/reconcile"
+
+	authv1alpha1 "github.com/openkube-hub/KubeUser/api/v1alpha1"
+	"github.com/openkube-hub/KubeUser/internal/team"
+)
+
+// applyTeams adds the roles of the Teams listing user as a member, and of the Teams they include,
+// to user for the rest of the reconcile
+func (r *UserReconciler) applyTeams(ctx context.Context, user *authv1alpha1.User) error {
+	teams, err := team.ForUser(ctx, r.Client, user.Name, client.MatchingFields{TeamMemberIndex: user.Name})
+	if err != nil {
+		return err
+	}
+	if teams, err = team.Include(ctx, r.Client, teams); err != nil {
+		return err
+	}
+	team.Apply(user, teams)
+	return nil
+}
+
+// usersForTeam maps a Team change to reconcile requests for its members, including those of its
+// directory group, and for the members of the Teams including it. A member removed from the list
+// is enqueued by the Team's update event for the previous version.
+func (r *UserReconciler) usersForTeam(ctx context.Context, obj client.Object) []reconcile.Request {
+	t, ok := obj.(*authv1alpha1.Team)
+	if !ok {
+		return nil
+	}
+	teams, err := teamsIncluding(ctx, r.Client, t.Name)
+	if err != nil {
+		logf.FromContext(ctx).Error(err, "Failed to list Teams including Team", "team", t.Name)
+	}
+	seen := map[string]bool{}
+	var requests []reconcile.Request
+	for _, affected := range append([]authv1alpha1.Team{*t}, teams...) {
+		for _, name := range team.MemberNames(&affected) {
+			if !seen[name] {
+				seen[name] = true
+				requests = append(requests, reconcile.Request{NamespacedName: types.NamespacedName{Name: name}})
+			}
+		}
+	}
+	return requests
+}
+
+// teamsIncluding returns the Teams including the Team name, directly or through other Teams
+func teamsIncluding(ctx context.Context, reader client.Reader, name string) ([]authv1alpha1.Team, error) {
+	seen := map[string]bool{name: true}
+	var including []authv1alpha1.Team
+	for pending := []string{name}; len(pending) > 0; pending = pending[1:] {
+		var teams authv1alpha1.TeamList
+		if err := reader.List(ctx, &teams, client.MatchingFields{TeamIncludeIndex: pending[0]}); err != nil {
+			return including, fmt.Errorf("failed to list teams including %s: %w", pending[0], err)
+		}
+		for _, t := range teams.Items {
+			if !seen[t.Name] {
+				seen[t.Name] = true
+				including = append(including, t)
+				pending = append(pending, t.Name)
+			}
+		}
+	}
+	return including, nil
+}
@@ -0,0 +1,328 @@
+/*
+Copyright 2025.
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+*/
+
+package controller
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"slices"
+	"strings"
+	"time"
+
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/api/meta"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/types"
+	ctrl "sigs.k8s.io/controller-runtime"
+	"sigs.k8s.io/controller-runtime/pkg/builder"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/controller/controllerutil"
+	"sigs.k8s.io/controller-runtime/pkg/handler"
+	logf "sigs.k8s.io/controller-runtime/pkg/log"
+	"sigs.k8s.io/controller-runtime/pkg/predicate"
+	"sigs.k8s.io/controller-runtime/pkg/reconcile"
+
+	authv1alpha1 "github.com/openkube-hub/KubeUser/api/v1alpha1"
+	"github.com/openkube-hub/KubeUser/internal/directory"
+	"github.com/openkube-hub/KubeUser/internal/team"
+)
+
+// TeamReconciler creates a Team's namespaces and a User for each member without one, and
+// deletes the Users it created once they leave the team. It records the Users its member
+// selector matches. The roles the team grants are bound by the User reconciler.
+type TeamReconciler struct {
+	client.Client
+	Scheme *runtime.Scheme
+
+	// Directories read the groups of spec.directorySync, by provider
+	Directories map[authv1alpha1.DirectoryProvider]directory.Directory
+	// DirectorySyncInterval is how often directory groups are read again;
+	// defaults to DefaultDirectorySyncInterval
+	DirectorySyncInterval time.Duration
+}
+
+// +kubebuilder:rbac:groups=auth.openkube.io,resources=teams,verbs=get;list;watch
+// +kubebuilder:rbac:groups=auth.openkube.io,resources=teams/status,verbs=get;update;patch
+// +kubebuilder:rbac:groups=auth.openkube.io,resources=teams/finalizers,verbs=update
+
+// Reconcile provisions the Team's namespaces and member Users and reports them in its status
+func (r *TeamReconciler) Reconcile(ctx context.Context, req ctrl.Request) (ctrl.Result, error) {
+	var t authv1alpha1.Team
+	if err := r.Get(ctx, req.NamespacedName, &t); err != nil {
+		return ctrl.Result{}, client.IgnoreNotFound(err)
+	}
+	if !t.DeletionTimestamp.IsZero() {
+		// The garbage collector deletes the Users the team owns; namespaces are kept
+		return ctrl.Result{}, nil
+	}
+
+	now := time.Now()
+	requeueAfter := r.syncDirectory(ctx, &t, now)
+	r.expireMembers(ctx, &t, now)
+	requeueAfter = untilNextMemberExpiry(&t, now, requeueAfter)
+
+	var errs []error
+	if err := r.selectMembers(ctx, &t); err != nil {
+		errs = append(errs, err)
+	}
+	for _, ns := range t.Spec.Namespaces {
+		if err := ensureNamespace(ctx, r.Client, ns); err != nil {
+			errs = append(errs, fmt.Errorf("namespace %s: %w", ns, err))
+		}
+	}
+	members, err := r.reconcileMembers(ctx, &t)
+	if err != nil {
+		errs = append(errs, err)
+	}
+	err = errors.Join(errs...)
+
+	condition := metav1.Condition{
+		Type:               "Ready",
+		Status:             metav1.ConditionTrue,
+		Reason:             "Provisioned",
+		Message:            fmt.Sprintf("%d namespaces and %d members provisioned", len(t.Spec.Namespaces), members),
+		ObservedGeneration: t.Generation,
+	}
+	if err != nil {
+		condition.Status = metav1.ConditionFalse
+		condition.Reason = "ProvisioningFailed"
+		condition.Message = err.Error()
+	}
+	t.Status.ObservedGeneration = t.Generation
+	t.Status.Members = members
+	meta.SetStatusCondition(&t.Status.Conditions, condition)
+	if includeErr := r.resolveIncludes(ctx, &t); includeErr != nil {
+		err = errors.Join(err, includeErr)
+	}
+	if statusErr := r.Status().Update(ctx, &t); statusErr != nil {
+		return ctrl.Result{}, errors.Join(err, statusErr)
+	}
+	return ctrl.Result{RequeueAfter: requeueAfter}, err
+}
+
+// reconcileMembers creates a User owned by the team for every member without one, and deletes
+// the Users the team owns whose member was removed. Users the team owns are suspended while
+// the member's directory account is disabled. It returns how many members have a User.
+func (r *TeamReconciler) reconcileMembers(ctx context.Context, t *authv1alpha1.Team) (int32, error) {
+	logger := logf.FromContext(ctx)
+	var errs []error
+	var members int32
+	for _, name := range team.MemberNames(t) {
+		disabled := team.IsDisabled(t, name)
+		var user authv1alpha1.User
+		err := r.Get(ctx, types.NamespacedName{Name: name}, &user)
+		if err == nil {
+			members++
+			if err := r.suspendDisabled(ctx, t, &user, disabled); err != nil {
+				errs = append(errs, fmt.Errorf("user %s: %w", name, err))
+			}
+			continue
+		}
+		if !apierrors.IsNotFound(err) {
+			errs = append(errs, fmt.Errorf("user %s: %w", name, err))
+			continue
+		}
+
+		user = authv1alpha1.User{
+			ObjectMeta: metav1.ObjectMeta{
+				Name:   name,
+				Labels: map[string]string{team.MemberLabel: t.Name},
+			},
+			Spec: authv1alpha1.UserSpec{Suspended: disabled},
+		}
+		if err := controllerutil.SetControllerReference(t, &user, r.Scheme); err != nil {
+			errs = append(errs, fmt.Errorf("user %s: %w", name, err))
+			continue
+		}
+		if err := r.Create(ctx, &user); err != nil && !apierrors.IsAlreadyExists(err) {
+			errs = append(errs, fmt.Errorf("user %s: %w", name, err))
+			continue
+		}
+		logger.Info("Created User for team member", "team", t.Name, "user", name)
+		members++
+	}
+
+	var owned authv1alpha1.UserList
+	if err := r.List(ctx, &owned, client.MatchingLabels{team.MemberLabel: t.Name}); err != nil {
+		return members, errors.Join(append(errs, fmt.Errorf("failed to list team users: %w", err))...)
+	}
+	for i := range owned.Items {
+		user := &owned.Items[i]
+		if !metav1.IsControlledBy(user, t) || team.Lists(t, user.Name) {
+			continue
+		}
+		if err := r.Delete(ctx, user); err != nil && !apierrors.IsNotFound(err) {
+			errs = append(errs, fmt.Errorf("user %s: %w", user.Name, err))
+			continue
+		}
+		logger.Info("Deleted User of removed team member", "team", t.Name, "user", user.Name)
+	}
+	return members, errors.Join(errs...)
+}
+
+// expireMembers records the members of t's spec whose expiresAt has passed in its status. They
+// lose the team's roles, and the Users the team created for them are deleted.
+func (r *TeamReconciler) expireMembers(ctx context.Context, t *authv1alpha1.Team, now time.Time) {
+	expired := team.Expirations(t, now)
+	for _, member := range expired {
+		if !slices.ContainsFunc(t.Status.ExpiredMembers, func(e authv1alpha1.ExpiredMember) bool {
+			return e.Name == member.Name && e.ExpiresAt.Equal(&member.ExpiresAt)
+		}) {
+			logf.FromContext(ctx).Info("Team membership expired", "team", t.Name, "user", member.Name,
+				"expiresAt", member.ExpiresAt.UTC().Format(time.RFC3339))
+		}
+	}
+	t.Status.ExpiredMembers = expired
+}
+
+// untilNextMemberExpiry shortens delay, zero for none, to the time until the next membership of
+// t's spec expires
+func untilNextMemberExpiry(t *authv1alpha1.Team, now time.Time, delay time.Duration) time.Duration {
+	for _, member := range t.Spec.Members {
+		if member.ExpiresAt == nil {
+			continue
+		}
+		if remaining := member.ExpiresAt.Sub(now); remaining > 0 && (delay == 0 || remaining < delay) {
+			delay = remaining
+		}
+	}
+	return delay
+}
+
+// selectMembers records the Users matching t's member selector in its status. The previous
+// members are kept when the Users cannot be listed.
+func (r *TeamReconciler) selectMembers(ctx context.Context, t *authv1alpha1.Team) error {
+	if t.Spec.MemberSelector == nil {
+		t.Status.SelectedMembers = nil
+		return nil
+	}
+	selector, err := metav1.LabelSelectorAsSelector(t.Spec.MemberSelector)
+	if err != nil || selector.Empty() {
+		t.Status.SelectedMembers = nil
+		if err != nil {
+			return fmt.Errorf("invalid memberSelector: %w", err)
+		}
+		return nil
+	}
+	var users authv1alpha1.UserList
+	if err := r.List(ctx, &users, client.MatchingLabelsSelector{Selector: selector}); err != nil {
+		return fmt.Errorf("failed to list users for memberSelector: %w", err)
+	}
+	selected := make([]string, 0, len(users.Items))
+	for _, user := range users.Items {
+		if user.DeletionTimestamp.IsZero() {
+			selected = append(selected, user.Name)
+		}
+	}
+	slices.Sort(selected)
+	t.Status.SelectedMembers = selected
+	return nil
+}
+
+// resolveIncludes sets the IncludesResolved condition of a Team including other Teams: false
+// while one of them does not exist or the includes lead back to t. Members receive the roles of
+// the included Teams that exist either way, each once.
+func (r *TeamReconciler) resolveIncludes(ctx context.Context, t *authv1alpha1.Team) error {
+	if len(t.Spec.IncludeTeams) == 0 {
+		meta.RemoveStatusCondition(&t.Status.Conditions, "IncludesResolved")
+		return nil
+	}
+	condition := metav1.Condition{
+		Type:               "IncludesResolved",
+		Status:             metav1.ConditionTrue,
+		Reason:             "Resolved",
+		Message:            fmt.Sprintf("%d included teams found", len(t.Spec.IncludeTeams)),
+		ObservedGeneration: t.Generation,
+	}
+	var missing []string
+	for _, name := range t.Spec.IncludeTeams {
+		var included authv1alpha1.Team
+		if err := r.Get(ctx, types.NamespacedName{Name: name}, &included); err != nil {
+			if !apierrors.IsNotFound(err) {
+				return fmt.Errorf("failed to get included team %s: %w", name, err)
+			}
+			missing = append(missing, name)
+		}
+	}
+	cycle, err := team.IncludeCycle(ctx, r.Client, t)
+	if err != nil {
+		return err
+	}
+	switch {
+	case cycle != nil:
+		condition.Status = metav1.ConditionFalse
+		condition.Reason = "IncludeCycle"
+		condition.Message = "Teams include each other: " + strings.Join(cycle, " -> ")
+	case len(missing) > 0:
+		condition.Status = metav1.ConditionFalse
+		condition.Reason = "TeamNotFound"
+		condition.Message = "Included teams not found: " + strings.Join(missing, ", ")
+	}
+	meta.SetStatusCondition(&t.Status.Conditions, condition)
+	return nil
+}
+
+// teamsIncludingTeam maps a Team change to reconcile requests for the Teams including it, whose
+// IncludesResolved condition may change with it
+func (r *TeamReconciler) teamsIncludingTeam(ctx context.Context, obj client.Object) []reconcile.Request {
+	teams, err := teamsIncluding(ctx, r.Client, obj.GetName())
+	if err != nil {
+		logf.FromContext(ctx).Error(err, "Failed to list Teams including Team", "team", obj.GetName())
+	}
+	requests := make([]reconcile.Request, 0, len(teams))
+	for _, t := range teams {
+		requests = append(requests, reconcile.Request{NamespacedName: types.NamespacedName{Name: t.Name}})
+	}
+	return requests
+}
+
+// teamsForUser maps a User change to reconcile requests for the Teams selecting it by its
+// labels. A User whose labels changed is mapped for the previous version too, so the Teams it
+// left are reconciled as well.
+func (r *TeamReconciler) teamsForUser(ctx context.Context, obj client.Object) []reconcile.Request {
+	teams, err := team.Selecting(ctx, r.Client, obj.GetLabels())
+	if err != nil {
+		logf.FromContext(ctx).Error(err, "Failed to list Teams selecting User", "user", obj.GetName())
+		return nil
+	}
+	requests := make([]reconcile.Request, 0, len(teams))
+	for _, t := range teams {
+		requests = append(requests, reconcile.Request{NamespacedName: types.NamespacedName{Name: t.Name}})
+	}
+	return requests
+}
+
+// suspendDisabled suspends a User the team owns while its member is in the team only through
+// a disabled directory account, and resumes it once the account is enabled again. Users the
+// team does not own are left alone; they only lose the team's roles.
+func (r *TeamReconciler) suspendDisabled(ctx context.Context, t *authv1alpha1.Team, user *authv1alpha1.User, disabled bool) error {
+	if !metav1.IsControlledBy(user, t) || user.Spec.Suspended == disabled {
+		return nil
+	}
+	user.Spec.Suspended = disabled
+	if err := r.Update(ctx, user); err != nil {
+		return err
+	}
+	logf.FromContext(ctx).Info("Updated suspension of team member with directory account state", "team", t.Name,
+		"user", user.Name, "suspended", disabled)
+	return nil
+}
+
+// SetupWithManager wires the controller
+func (r *TeamReconciler) SetupWithManager(mgr ctrl.Manager) error {
+	return ctrl.NewControllerManagedBy(mgr).
+		For(&authv1alpha1.Team{}).
+		Owns(&authv1alpha1.User{}).
+		Watches(&authv1alpha1.User{}, handler.EnqueueRequestsFromMapFunc(r.teamsForUser),
+			builder.WithPredicates(predicate.LabelChangedPredicate{})).
+		Watches(&authv1alpha1.Team{}, handler.EnqueueRequestsFromMapFunc(r.teamsIncludingTeam),
+			builder.WithPredicates(predicate.GenerationChangedPredicate{})).
+		Named("team").
+		Complete(r)
+}
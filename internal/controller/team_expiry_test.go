@@ -0,0 +1,120 @@
+/*
+Copyright 2025.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package controller
+
+import (
+	"time"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+	rbacv1 "k8s.io/api/rbac/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/types"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/reconcile"
+
+	authv1alpha1 "github.com/openkube-hub/KubeUser/api/v1alpha1"
+)
+
+var _ = Describe("Team membership expiry", func() {
+	const jane = "team-expiry-jane"
+	var (
+		r           *UserReconciler
+		teams       *TeamReconciler
+		clusterRole *rbacv1.ClusterRole
+		rotation    *authv1alpha1.Team
+	)
+
+	// expireAt sets the expiresAt of jane's membership
+	expireAt := func(at time.Time) {
+		GinkgoHelper()
+		Eventually(func() error {
+			var stored authv1alpha1.Team
+			if err := k8sClient.Get(ctx, client.ObjectKeyFromObject(rotation), &stored); err != nil {
+				return err
+			}
+			stored.Spec.Members[0].ExpiresAt = &metav1.Time{Time: at}
+			return k8sClient.Update(ctx, &stored)
+		}, 10*time.Second).Should(Succeed())
+	}
+	// reconcileTeam reconciles the team until check passes on the stored team and the result
+	reconcileTeam := func(check func(g Gomega, stored *authv1alpha1.Team, result reconcile.Result)) {
+		GinkgoHelper()
+		Eventually(func(g Gomega) {
+			result, err := teams.Reconcile(ctx, reconcile.Request{NamespacedName: types.NamespacedName{Name: rotation.Name}})
+			g.Expect(err).NotTo(HaveOccurred())
+			var stored authv1alpha1.Team
+			g.Expect(k8sClient.Get(ctx, client.ObjectKeyFromObject(rotation), &stored)).To(Succeed())
+			check(g, &stored, result)
+		}, 10*time.Second).Should(Succeed())
+	}
+
+	BeforeEach(func() {
+		r = newCachedReconciler(&pendingIssuer{})
+		teams = &TeamReconciler{Client: k8sClient, Scheme: k8sClient.Scheme()}
+		clusterRole = &rbacv1.ClusterRole{
+			ObjectMeta: metav1.ObjectMeta{Name: "team-expiry-reader"},
+			Rules:      []rbacv1.PolicyRule{{APIGroups: []string{""}, Resources: []string{"pods"}, Verbs: []string{"get"}}},
+		}
+		rotation = &authv1alpha1.Team{
+			ObjectMeta: metav1.ObjectMeta{Name: "team-expiry-rotation"},
+			Spec: authv1alpha1.TeamSpec{
+				ClusterRoles: []authv1alpha1.ClusterRoleSpec{{ExistingClusterRole: clusterRole.Name}},
+				Members: []authv1alpha1.TeamMember{
+					{Name: jane, ExpiresAt: &metav1.Time{Time: time.Now().Add(time.Hour)}},
+				},
+			},
+		}
+		Expect(k8sClient.Create(ctx, clusterRole)).To(Succeed())
+		// The User exists already, so the team keeps it when the membership ends
+		Expect(k8sClient.Create(ctx, &authv1alpha1.User{ObjectMeta: metav1.ObjectMeta{Name: jane}})).To(Succeed())
+		Expect(k8sClient.Create(ctx, rotation)).To(Succeed())
+	})
+
+	AfterEach(func() {
+		Expect(k8sClient.Delete(ctx, rotation)).To(Succeed())
+		deleteUser(r, jane)
+		Expect(k8sClient.Delete(ctx, clusterRole)).To(Succeed())
+	})
+
+	It("removes the team's roles when the membership expires and records it", func() {
+		reconcileTeam(func(g Gomega, stored *authv1alpha1.Team, result reconcile.Result) {
+			g.Expect(stored.Status.ExpiredMembers).To(BeEmpty())
+			g.Expect(result.RequeueAfter).To(BeNumerically("~", time.Hour, time.Minute))
+		})
+		binding := &rbacv1.ClusterRoleBinding{ObjectMeta: metav1.ObjectMeta{Name: clusterRoleBindingName(jane, clusterRole.Name)}}
+		reconcileUntil(r, jane, func(g Gomega) { expectExists(g, binding, true) })
+
+		By("letting the membership expire")
+		expiresAt := time.Now().Add(-time.Minute).Truncate(time.Second)
+		expireAt(expiresAt)
+		reconcileTeam(func(g Gomega, stored *authv1alpha1.Team, _ reconcile.Result) {
+			g.Expect(stored.Status.ExpiredMembers).To(HaveLen(1))
+			g.Expect(stored.Status.ExpiredMembers[0].Name).To(Equal(jane))
+			g.Expect(stored.Status.ExpiredMembers[0].ExpiresAt.Time).To(BeTemporally("==", expiresAt))
+		})
+		reconcileUntil(r, jane, func(g Gomega) { expectExists(g, binding, false) })
+		expectExists(Default, &authv1alpha1.User{ObjectMeta: metav1.ObjectMeta{Name: jane}}, true)
+
+		By("extending the membership")
+		expireAt(time.Now().Add(time.Hour))
+		reconcileUntil(r, jane, func(g Gomega) { expectExists(g, binding, true) })
+		reconcileTeam(func(g Gomega, stored *authv1alpha1.Team, _ reconcile.Result) {
+			g.Expect(stored.Status.ExpiredMembers).To(BeEmpty())
+		})
+	})
+})
@@ -0,0 +1,100 @@
+/*
+Copyright 2025.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package controller
+
+import (
+	"time"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+	rbacv1 "k8s.io/api/rbac/v1"
+	"k8s.io/apimachinery/pkg/api/meta"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/types"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/reconcile"
+
+	authv1alpha1 "github.com/openkube-hub/KubeUser/api/v1alpha1"
+)
+
+var _ = Describe("Included teams", func() {
+	const jane = "team-include-jane"
+	var (
+		r                  *UserReconciler
+		teams              *TeamReconciler
+		clusterRole        *rbacv1.ClusterRole
+		baseline, payments *authv1alpha1.Team
+	)
+
+	BeforeEach(func() {
+		r = newCachedReconciler(&pendingIssuer{})
+		teams = &TeamReconciler{Client: k8sClient, Scheme: k8sClient.Scheme()}
+		clusterRole = &rbacv1.ClusterRole{
+			ObjectMeta: metav1.ObjectMeta{Name: "team-include-reader"},
+			Rules:      []rbacv1.PolicyRule{{APIGroups: []string{""}, Resources: []string{"pods"}, Verbs: []string{"get"}}},
+		}
+		baseline = &authv1alpha1.Team{
+			ObjectMeta: metav1.ObjectMeta{Name: "team-include-baseline"},
+			Spec:       authv1alpha1.TeamSpec{ClusterRoles: []authv1alpha1.ClusterRoleSpec{{ExistingClusterRole: clusterRole.Name}}},
+		}
+		payments = &authv1alpha1.Team{
+			ObjectMeta: metav1.ObjectMeta{Name: "team-include-payments"},
+			Spec: authv1alpha1.TeamSpec{
+				IncludeTeams: []string{baseline.Name, "team-include-missing"},
+				Members:      []authv1alpha1.TeamMember{{Name: jane}},
+			},
+		}
+		Expect(k8sClient.Create(ctx, clusterRole)).To(Succeed())
+		Expect(k8sClient.Create(ctx, baseline)).To(Succeed())
+		Expect(k8sClient.Create(ctx, payments)).To(Succeed())
+	})
+
+	AfterEach(func() {
+		Expect(k8sClient.Delete(ctx, payments)).To(Succeed())
+		Expect(k8sClient.Delete(ctx, baseline)).To(Succeed())
+		deleteUser(r, jane)
+		Expect(k8sClient.Delete(ctx, clusterRole)).To(Succeed())
+	})
+
+	It("binds the roles of included teams for the members", func() {
+		Eventually(func(g Gomega) {
+			_, err := teams.Reconcile(ctx, reconcile.Request{NamespacedName: types.NamespacedName{Name: payments.Name}})
+			g.Expect(err).NotTo(HaveOccurred())
+			var stored authv1alpha1.Team
+			g.Expect(k8sClient.Get(ctx, client.ObjectKeyFromObject(payments), &stored)).To(Succeed())
+			condition := meta.FindStatusCondition(stored.Status.Conditions, "IncludesResolved")
+			g.Expect(condition).NotTo(BeNil())
+			g.Expect(condition.Status).To(Equal(metav1.ConditionFalse))
+			g.Expect(condition.Reason).To(Equal("TeamNotFound"))
+			g.Expect(condition.Message).To(Equal("Included teams not found: team-include-missing"))
+		}, 10*time.Second).Should(Succeed())
+
+		binding := &rbacv1.ClusterRoleBinding{ObjectMeta: metav1.ObjectMeta{Name: clusterRoleBindingName(jane, clusterRole.Name)}}
+		reconcileUntil(r, jane, func(g Gomega) { expectExists(g, binding, true) })
+
+		By("no longer including the baseline team")
+		Eventually(func() error {
+			var stored authv1alpha1.Team
+			if err := k8sClient.Get(ctx, client.ObjectKeyFromObject(payments), &stored); err != nil {
+				return err
+			}
+			stored.Spec.IncludeTeams = nil
+			return k8sClient.Update(ctx, &stored)
+		}, 10*time.Second).Should(Succeed())
+		reconcileUntil(r, jane, func(g Gomega) { expectExists(g, binding, false) })
+	})
+})
@@ -0,0 +1,118 @@
+/*
+Copyright 2025.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package controller
+
+import (
+	"time"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+	rbacv1 "k8s.io/api/rbac/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/types"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/reconcile"
+
+	authv1alpha1 "github.com/openkube-hub/KubeUser/api/v1alpha1"
+)
+
+var _ = Describe("Team member selector", func() {
+	const (
+		jane = "team-selector-jane"
+		joe  = "team-selector-joe"
+	)
+	var (
+		r           *UserReconciler
+		teams       *TeamReconciler
+		clusterRole *rbacv1.ClusterRole
+		payments    *authv1alpha1.Team
+	)
+
+	// reconcileTeam reconciles the team until its status lists the selected Users
+	reconcileTeam := func(selected ...string) {
+		GinkgoHelper()
+		Eventually(func(g Gomega) {
+			_, err := teams.Reconcile(ctx, reconcile.Request{NamespacedName: types.NamespacedName{Name: payments.Name}})
+			g.Expect(err).NotTo(HaveOccurred())
+			var stored authv1alpha1.Team
+			g.Expect(k8sClient.Get(ctx, client.ObjectKeyFromObject(payments), &stored)).To(Succeed())
+			g.Expect(stored.Status.SelectedMembers).To(Equal(selected))
+		}, 10*time.Second).Should(Succeed())
+	}
+	// expectBound reconciles the User name until it has the team's binding, or not
+	expectBound := func(name string, bound bool) {
+		GinkgoHelper()
+		reconcileUntil(r, name, func(g Gomega) {
+			expectExists(g, &rbacv1.ClusterRoleBinding{ObjectMeta: metav1.ObjectMeta{
+				Name: clusterRoleBindingName(name, clusterRole.Name)}}, bound)
+		})
+	}
+	label := func(value string) func(user *authv1alpha1.User) {
+		return func(user *authv1alpha1.User) {
+			user.Labels = map[string]string{"team": value}
+		}
+	}
+
+	BeforeEach(func() {
+		r = newCachedReconciler(&pendingIssuer{})
+		teams = &TeamReconciler{Client: k8sClient, Scheme: k8sClient.Scheme()}
+		clusterRole = &rbacv1.ClusterRole{
+			ObjectMeta: metav1.ObjectMeta{Name: "team-selector-reader"},
+			Rules:      []rbacv1.PolicyRule{{APIGroups: []string{""}, Resources: []string{"pods"}, Verbs: []string{"get"}}},
+		}
+		payments = &authv1alpha1.Team{
+			ObjectMeta: metav1.ObjectMeta{Name: "team-selector-payments"},
+			Spec: authv1alpha1.TeamSpec{
+				ClusterRoles:   []authv1alpha1.ClusterRoleSpec{{ExistingClusterRole: clusterRole.Name}},
+				MemberSelector: &metav1.LabelSelector{MatchLabels: map[string]string{"team": "payments"}},
+			},
+		}
+		Expect(k8sClient.Create(ctx, clusterRole)).To(Succeed())
+		Expect(k8sClient.Create(ctx, &authv1alpha1.User{
+			ObjectMeta: metav1.ObjectMeta{Name: jane, Labels: map[string]string{"team": "payments"}},
+		})).To(Succeed())
+		Expect(k8sClient.Create(ctx, &authv1alpha1.User{ObjectMeta: metav1.ObjectMeta{Name: joe}})).To(Succeed())
+		Expect(k8sClient.Create(ctx, payments)).To(Succeed())
+	})
+
+	AfterEach(func() {
+		Expect(k8sClient.Delete(ctx, payments)).To(Succeed())
+		deleteUser(r, jane)
+		deleteUser(r, joe)
+		Expect(k8sClient.Delete(ctx, clusterRole)).To(Succeed())
+	})
+
+	It("binds the team's roles for Users while their labels match", func() {
+		reconcileTeam(jane)
+		expectBound(jane, true)
+		expectBound(joe, false)
+
+		By("labelling a second User")
+		updateUser(joe, label("payments"))
+		reconcileTeam(jane, joe)
+		expectBound(joe, true)
+
+		By("changing the first User's label")
+		updateUser(jane, label("platform"))
+		reconcileTeam(joe)
+		expectBound(jane, false)
+		expectBound(joe, true)
+
+		// Users the team selected are not its to delete
+		expectExists(Default, &authv1alpha1.User{ObjectMeta: metav1.ObjectMeta{Name: jane}}, true)
+	})
+})
@@ -0,0 +1,109 @@
+/*
+Copyright 2025.
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+*/
+
+package controller
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	logf "sigs.k8s.io/controller-runtime/pkg/log"
+	"sigs.k8s.io/controller-runtime/pkg/reconcile"
+
+	authv1alpha1 "github.com/openkube-hub/KubeUser/api/v1alpha1"
+	"github.com/openkube-hub/KubeUser/internal/operatorconfig"
+	"github.com/openkube-hub/KubeUser/internal/usertemplate"
+)
+
+// +kubebuilder:rbac:groups=auth.openkube.io,resources=usertemplates,verbs=get;list;watch
+
+// applyUserTemplate merges the user's UserTemplate into user for the rest of the reconcile.
+// Template labels the User lacks are also written to it, so they can be selected on.
+func (r *UserReconciler) applyUserTemplate(ctx context.Context, user *authv1alpha1.User) error {
+	template, err := usertemplate.Get(ctx, r.Client, user)
+	if err != nil || template == nil {
+		return err
+	}
+	if missing := usertemplate.MissingLabels(user, template); len(missing) > 0 {
+		base := user.DeepCopy()
+		if user.Labels == nil {
+			user.Labels = map[string]string{}
+		}
+		for key, value := range missing {
+			user.Labels[key] = value
+		}
+		if err := r.Patch(ctx, user, client.MergeFrom(base)); err != nil {
+			return err
+		}
+	}
+	usertemplate.Apply(user, template)
+	return nil
+}
+
+// rotationPolicy says when a certificate is renewed: threshold before it expires, or once less
+// than percent of its lifetime remains when percent is set. The zero policy renews at expiry.
+type rotationPolicy struct {
+	threshold time.Duration
+	percent   int32
+}
+
+// renewAt returns when a certificate valid from notBefore until notAfter is renewed
+func (p rotationPolicy) renewAt(notBefore, notAfter time.Time) time.Time {
+	if p.percent > 0 {
+		return notAfter.Add(-notAfter.Sub(notBefore) * time.Duration(p.percent) / 100)
+	}
+	return notAfter.Add(-p.threshold)
+}
+
+func (p rotationPolicy) String() string {
+	if p.percent > 0 {
+		return fmt.Sprintf("%d%% of its lifetime", p.percent)
+	}
+	return p.threshold.String()
+}
+
+// certificateSettings returns the certificate duration, rotation policy and key algorithm for
+// user: the operator's, unless its UserTemplate sets them. Certificates shorter than the
+// operator's rotation threshold are renewed halfway through their lifetime.
+func (r *UserReconciler) certificateSettings(ctx context.Context, user *authv1alpha1.User) (
+	duration time.Duration, rotation rotationPolicy, algorithm authv1alpha1.KeyAlgorithm) {
+	settings := operatorconfig.Current()
+	duration, algorithm = settings.CertificateDuration, settings.KeyAlgorithm
+	rotation = rotationPolicy{threshold: settings.RotationThreshold, percent: settings.RotationThresholdPercent}
+
+	template, err := usertemplate.Get(ctx, r.Client, user)
+	if err != nil {
+		logf.FromContext(ctx).Error(err, "Failed to read UserTemplate, using the operator's certificate settings")
+		return duration, rotation, algorithm
+	}
+	if template == nil {
+		return duration, rotation, algorithm
+	}
+	if template.Spec.CertificateDuration != nil {
+		duration = template.Spec.CertificateDuration.Duration
+		rotation.threshold = min(rotation.threshold, duration/2)
+	}
+	if template.Spec.KeyAlgorithm != "" {
+		algorithm = template.Spec.KeyAlgorithm
+	}
+	return duration, rotation, algorithm
+}
+
+// usersForTemplate maps a UserTemplate change to reconcile requests for the Users referencing it
+func (r *UserReconciler) usersForTemplate(ctx context.Context, obj client.Object) []reconcile.Request {
+	var users authv1alpha1.UserList
+	if err := r.List(ctx, &users, client.MatchingFields{UserTemplateIndex: obj.GetName()}); err != nil {
+		logf.FromContext(ctx).Error(err, "Failed to list users for UserTemplate change")
+		return nil
+	}
+	requests := make([]reconcile.Request, 0, len(users.Items))
+	for _, user := range users.Items {
+		requests = append(requests, reconcile.Request{NamespacedName: client.ObjectKeyFromObject(&user)})
+	}
+	return requests
+}
@@ -0,0 +1,120 @@
+/*
+Copyright 2025.
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+*/
+
+package controller
+
+import (
+	"fmt"
+	"strings"
+	"time"
+
+	"k8s.io/apimachinery/pkg/api/meta"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+
+	authv1alpha1 "github.com/openkube-hub/KubeUser/api/v1alpha1"
+)
+
+// ConditionGrantExpired is True while the spec still lists grants whose expiresAt or duration
+// has passed. Their bindings are removed; the user keeps its other grants.
+const ConditionGrantExpired = "GrantExpired"
+
+// timedGrant is the status entry tracking a grant with an expiresAt or duration
+func timedGrant(user *authv1alpha1.User, kind, namespace, name string) *authv1alpha1.TimedGrant {
+	for i := range user.Status.TimedGrants {
+		grant := &user.Status.TimedGrants[i]
+		if grant.Kind == kind && grant.Namespace == namespace && grant.Name == name {
+			return grant
+		}
+	}
+	return nil
+}
+
+// syncTimedGrants records in status when each grant with an expiresAt or duration was first
+// bound and when it ends. Entries of grants no longer in the spec are dropped, so a grant that
+// is removed and added again starts a new duration.
+func syncTimedGrants(user *authv1alpha1.User, now time.Time) {
+	var grants []authv1alpha1.TimedGrant
+	track := func(kind, namespace, name string, expiresAt *metav1.Time, duration *metav1.Duration) {
+		if expiresAt == nil && duration == nil {
+			return
+		}
+		// Status timestamps are serialized with second precision
+		grant := authv1alpha1.TimedGrant{Kind: kind, Namespace: namespace, Name: name,
+			Since: metav1.NewTime(now.Truncate(time.Second))}
+		if existing := timedGrant(user, kind, namespace, name); existing != nil {
+			grant.Since = existing.Since
+		}
+		if expiresAt != nil {
+			grant.ExpiresAt = *expiresAt
+		} else {
+			grant.ExpiresAt = metav1.NewTime(grant.Since.Add(duration.Duration))
+		}
+		grants = append(grants, grant)
+	}
+	for _, role := range user.Spec.Roles {
+		track("Role", role.Namespace, role.ExistingRole, role.ExpiresAt, role.Duration)
+	}
+	for _, clusterRole := range user.Spec.ClusterRoles {
+		track("ClusterRole", "", clusterRole.ExistingClusterRole, clusterRole.ExpiresAt, clusterRole.Duration)
+	}
+	user.Status.TimedGrants = grants
+}
+
+// grantEnded reports whether the grant's expiresAt or duration has passed
+func grantEnded(user *authv1alpha1.User, kind, namespace, name string, now time.Time) bool {
+	grant := timedGrant(user, kind, namespace, name)
+	return grant != nil && !now.Before(grant.ExpiresAt.Time)
+}
+
+// activeRoles returns the namespaced role grants that have not expired
+func activeRoles(user *authv1alpha1.User, now time.Time) []authv1alpha1.RoleSpec {
+	active := make([]authv1alpha1.RoleSpec, 0, len(user.Spec.Roles))
+	for _, role := range user.Spec.Roles {
+		if !grantEnded(user, "Role", role.Namespace, role.ExistingRole, now) {
+			active = append(active, role)
+		}
+	}
+	return active
+}
+
+// untilNextGrantEnd caps a requeue delay so the controller wakes up when the next elevation or
+// timed grant ends
+func untilNextGrantEnd(user *authv1alpha1.User, now time.Time, delay time.Duration) time.Duration {
+	delay = untilNextElevationEnd(user, now, delay)
+	for _, grant := range user.Status.TimedGrants {
+		if remaining := grant.ExpiresAt.Sub(now); remaining > 0 && remaining < delay {
+			delay = remaining
+		}
+	}
+	return delay
+}
+
+// setGrantExpiryCondition reports timed grants that have ended, or drops the condition once
+// they have been removed from the spec
+func setGrantExpiryCondition(user *authv1alpha1.User, now time.Time) {
+	var ended []string
+	for _, grant := range user.Status.TimedGrants {
+		if now.Before(grant.ExpiresAt.Time) {
+			continue
+		}
+		name := grant.Name
+		if grant.Namespace != "" {
+			name = grant.Namespace + "/" + grant.Name
+		}
+		ended = append(ended, fmt.Sprintf("%s %s at %s", grant.Kind, name, grant.ExpiresAt.UTC().Format(time.RFC3339)))
+	}
+	if len(ended) == 0 {
+		meta.RemoveStatusCondition(&user.Status.Conditions, ConditionGrantExpired)
+		return
+	}
+	meta.SetStatusCondition(&user.Status.Conditions, metav1.Condition{
+		Type:               ConditionGrantExpired,
+		Status:             metav1.ConditionTrue,
+		Reason:             "GrantEnded",
+		Message:            "Access ended: " + strings.Join(ended, ", "),
+		ObservedGeneration: user.Generation,
+	})
+}
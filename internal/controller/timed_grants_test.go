@@ -0,0 +1,178 @@
+/*
+Copyright 2025.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package controller
+
+import (
+	"time"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+	"k8s.io/apimachinery/pkg/api/meta"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+
+	authv1alpha1 "github.com/openkube-hub/KubeUser/api/v1alpha1"
+)
+
+// grantsNow is the time the timed grant tests run at
+var grantsNow = time.Date(2026, 1, 1, 12, 0, 0, 0, time.UTC)
+
+// endingGrant returns a status entry for the ClusterRole name ending at end
+func endingGrant(name string, end time.Time) authv1alpha1.TimedGrant {
+	return authv1alpha1.TimedGrant{Kind: "ClusterRole", Name: name, Since: metav1.NewTime(grantsNow.Add(-time.Hour)),
+		ExpiresAt: metav1.NewTime(end)}
+}
+
+var _ = Describe("Timed grants", func() {
+	expiresAt := metav1.NewTime(grantsNow.Add(48 * time.Hour))
+	earlier := metav1.NewTime(grantsNow.Add(-2 * time.Hour))
+
+	DescribeTable("records when grants end",
+		func(spec authv1alpha1.UserSpec, existing, expected []authv1alpha1.TimedGrant) {
+			user := &authv1alpha1.User{Spec: spec, Status: authv1alpha1.UserStatus{TimedGrants: existing}}
+			syncTimedGrants(user, grantsNow.Add(300*time.Millisecond))
+			Expect(user.Status.TimedGrants).To(Equal(expected))
+		},
+		Entry("grants without an end are not tracked",
+			authv1alpha1.UserSpec{ClusterRoles: []authv1alpha1.ClusterRoleSpec{{ExistingClusterRole: "view"}}},
+			nil, nil),
+		Entry("durations count from the first bind, truncated to seconds",
+			authv1alpha1.UserSpec{Roles: []authv1alpha1.RoleSpec{{
+				Namespace: "dev", ExistingRole: "developer", Duration: &metav1.Duration{Duration: 8 * time.Hour},
+			}}},
+			nil,
+			[]authv1alpha1.TimedGrant{{Kind: "Role", Namespace: "dev", Name: "developer",
+				Since: metav1.NewTime(grantsNow), ExpiresAt: metav1.NewTime(grantsNow.Add(8 * time.Hour))}}),
+		Entry("durations keep counting from the recorded bind",
+			authv1alpha1.UserSpec{ClusterRoles: []authv1alpha1.ClusterRoleSpec{{
+				ExistingClusterRole: "admin", Duration: &metav1.Duration{Duration: time.Hour},
+			}}},
+			[]authv1alpha1.TimedGrant{{Kind: "ClusterRole", Name: "admin", Since: earlier}},
+			[]authv1alpha1.TimedGrant{{Kind: "ClusterRole", Name: "admin",
+				Since: earlier, ExpiresAt: metav1.NewTime(earlier.Add(time.Hour))}}),
+		Entry("expiresAt is taken as it is",
+			authv1alpha1.UserSpec{ClusterRoles: []authv1alpha1.ClusterRoleSpec{{
+				ExistingClusterRole: "admin", ExpiresAt: &expiresAt,
+			}}},
+			nil,
+			[]authv1alpha1.TimedGrant{{Kind: "ClusterRole", Name: "admin",
+				Since: metav1.NewTime(grantsNow), ExpiresAt: expiresAt}}),
+		Entry("grants removed from the spec are dropped",
+			authv1alpha1.UserSpec{},
+			[]authv1alpha1.TimedGrant{{Kind: "ClusterRole", Name: "admin", Since: earlier,
+				ExpiresAt: metav1.NewTime(earlier.Add(time.Hour))}},
+			nil),
+		Entry("the same Role in another namespace is another grant",
+			authv1alpha1.UserSpec{Roles: []authv1alpha1.RoleSpec{{
+				Namespace: "prod", ExistingRole: "developer", Duration: &metav1.Duration{Duration: time.Hour},
+			}}},
+			[]authv1alpha1.TimedGrant{{Kind: "Role", Namespace: "dev", Name: "developer", Since: earlier}},
+			[]authv1alpha1.TimedGrant{{Kind: "Role", Namespace: "prod", Name: "developer",
+				Since: metav1.NewTime(grantsNow), ExpiresAt: metav1.NewTime(grantsNow.Add(time.Hour))}}),
+	)
+
+	end := grantsNow.Add(time.Hour)
+	DescribeTable("ends grants at their expiresAt",
+		func(now time.Time, ended bool) {
+			user := &authv1alpha1.User{
+				Spec: authv1alpha1.UserSpec{Roles: []authv1alpha1.RoleSpec{
+					{Namespace: "dev", ExistingRole: "developer"},
+					{Namespace: "dev", ExistingRole: "operator"},
+				}},
+				Status: authv1alpha1.UserStatus{TimedGrants: []authv1alpha1.TimedGrant{{Kind: "Role", Namespace: "dev",
+					Name: "operator", Since: metav1.NewTime(grantsNow), ExpiresAt: metav1.NewTime(end)}}},
+			}
+			Expect(grantEnded(user, "Role", "dev", "operator", now)).To(Equal(ended))
+			// Grants without an end never end
+			Expect(grantEnded(user, "Role", "dev", "developer", now)).To(BeFalse())
+			// Nor does the same Role in another namespace
+			Expect(grantEnded(user, "Role", "prod", "operator", now)).To(BeFalse())
+
+			active := []string{}
+			for _, role := range activeRoles(user, now) {
+				active = append(active, role.ExistingRole)
+			}
+			if ended {
+				Expect(active).To(Equal([]string{"developer"}))
+			} else {
+				Expect(active).To(Equal([]string{"developer", "operator"}))
+			}
+		},
+		Entry("before the end", end.Add(-time.Nanosecond), false),
+		Entry("at the end", end, true),
+		Entry("after the end", end.Add(time.Nanosecond), true),
+	)
+
+	const delay = 10 * time.Hour
+	DescribeTable("requeues at the next grant end",
+		func(grants []authv1alpha1.TimedGrant, elevations []time.Time, expected time.Duration) {
+			user := &authv1alpha1.User{Status: authv1alpha1.UserStatus{TimedGrants: grants}}
+			for _, until := range elevations {
+				user.Spec.ClusterRoles = append(user.Spec.ClusterRoles, authv1alpha1.ClusterRoleSpec{
+					ExistingClusterRole: "cluster-admin",
+					Elevation:           &authv1alpha1.Elevation{Until: metav1.NewTime(until)},
+				})
+			}
+			Expect(untilNextGrantEnd(user, grantsNow, delay)).To(Equal(expected))
+		},
+		Entry("no timed grants", nil, nil, delay),
+		Entry("a grant ending after the delay",
+			[]authv1alpha1.TimedGrant{endingGrant("admin", grantsNow.Add(11*time.Hour))}, nil, delay),
+		Entry("a grant ending within the delay",
+			[]authv1alpha1.TimedGrant{endingGrant("admin", grantsNow.Add(time.Hour))}, nil, time.Hour),
+		Entry("the grant ending first",
+			[]authv1alpha1.TimedGrant{
+				endingGrant("admin", grantsNow.Add(3*time.Hour)),
+				endingGrant("edit", grantsNow.Add(2*time.Hour)),
+				endingGrant("view", grantsNow.Add(4*time.Hour)),
+			}, nil, 2*time.Hour),
+		Entry("a grant ending now",
+			[]authv1alpha1.TimedGrant{endingGrant("admin", grantsNow)}, nil, delay),
+		Entry("an ended grant",
+			[]authv1alpha1.TimedGrant{
+				endingGrant("admin", grantsNow.Add(-time.Minute)),
+				endingGrant("edit", grantsNow.Add(5*time.Hour)),
+			}, nil, 5*time.Hour),
+		Entry("a grant ending a second from now",
+			[]authv1alpha1.TimedGrant{endingGrant("admin", grantsNow.Add(time.Second))}, nil, time.Second),
+		Entry("an elevation ending first",
+			[]authv1alpha1.TimedGrant{endingGrant("admin", grantsNow.Add(3*time.Hour))},
+			[]time.Time{grantsNow.Add(30 * time.Minute)}, 30*time.Minute),
+		Entry("an ended elevation",
+			[]authv1alpha1.TimedGrant{endingGrant("admin", grantsNow.Add(3*time.Hour))},
+			[]time.Time{grantsNow.Add(-30 * time.Minute)}, 3*time.Hour),
+	)
+
+	It("reports ended grants in a condition", func() {
+		user := &authv1alpha1.User{Status: authv1alpha1.UserStatus{TimedGrants: []authv1alpha1.TimedGrant{
+			endingGrant("admin", grantsNow),
+			{Kind: "Role", Namespace: "dev", Name: "developer", ExpiresAt: metav1.NewTime(grantsNow.Add(-time.Hour))},
+			endingGrant("view", grantsNow.Add(time.Second)),
+		}}}
+
+		setGrantExpiryCondition(user, grantsNow)
+		condition := meta.FindStatusCondition(user.Status.Conditions, ConditionGrantExpired)
+		Expect(condition).NotTo(BeNil())
+		Expect(condition.Status).To(Equal(metav1.ConditionTrue))
+		Expect(condition.Message).To(Equal("Access ended: ClusterRole admin at 2026-01-01T12:00:00Z, " +
+			"Role dev/developer at 2026-01-01T11:00:00Z"))
+
+		// Dropped once the ended grants are gone
+		user.Status.TimedGrants = user.Status.TimedGrants[2:]
+		setGrantExpiryCondition(user, grantsNow)
+		Expect(meta.FindStatusCondition(user.Status.Conditions, ConditionGrantExpired)).To(BeNil())
+	})
+})
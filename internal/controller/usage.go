@@ -0,0 +1,201 @@
+/*
+Copyright 2025.
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+*/
+
+package controller
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	rbacv1 "k8s.io/api/rbac/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/api/meta"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/types"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	logf "sigs.k8s.io/controller-runtime/pkg/log"
+
+	authv1alpha1 "github.com/openkube-hub/KubeUser/api/v1alpha1"
+	"github.com/openkube-hub/KubeUser/internal/usage"
+)
+
+const (
+	// RecommendableLabel marks ClusterRoles that may be suggested as narrower replacements
+	RecommendableLabel = "auth.openkube.io/recommendable"
+
+	// DefaultUsageWindow is how much observed usage recommendations are based on
+	DefaultUsageWindow = 30 * 24 * time.Hour
+
+	// ConditionUnusedPermissions is True while bound roles grant permissions that were not
+	// exercised during the usage window
+	ConditionUnusedPermissions = "UnusedPermissions"
+
+	// ConditionIdle is True while the user holds valid credentials but made no API request
+	// during the usage window
+	ConditionIdle = "Idle"
+)
+
+// usageWindow returns the configured observation window
+func (r *UserReconciler) usageWindow() time.Duration {
+	if r.UsageWindow > 0 {
+		return r.UsageWindow
+	}
+	return DefaultUsageWindow
+}
+
+// analyzeUsage fills the recommendations, unused permissions and the UnusedPermissions condition
+// from observed usage. It does nothing when usage tracking is disabled or the store has not yet
+// observed a full window, so that a controller restart does not make every role look unused.
+// Nothing is ever removed from the user's spec.
+func (r *UserReconciler) analyzeUsage(ctx context.Context, user *authv1alpha1.User) error {
+	if r.UsageStore == nil {
+		return nil
+	}
+	window := r.usageWindow()
+	r.analyzeActivity(user, window, time.Now())
+	if time.Since(r.UsageStore.TrackingSince()) < window {
+		logf.FromContext(ctx).V(1).Info("Not enough usage data for recommendations yet", "window", window)
+		return nil
+	}
+
+	grants, err := r.userGrants(ctx, user)
+	if err != nil {
+		return err
+	}
+	var recommendable rbacv1.ClusterRoleList
+	if err := r.List(ctx, &recommendable, client.MatchingLabels{RecommendableLabel: "true"}); err != nil {
+		return err
+	}
+	candidates := make([]usage.Candidate, 0, len(recommendable.Items))
+	for _, cr := range recommendable.Items {
+		candidates = append(candidates, usage.Candidate{Name: cr.Name, Rules: cr.Rules})
+	}
+
+	observed := r.UsageStore.Accesses(user.Name, time.Now().Add(-window))
+	user.Status.Recommendations = usage.Recommend(grants, observed, candidates, window)
+
+	var unused []authv1alpha1.UnusedPermission
+	total := 0
+	for _, grant := range grants {
+		permissions := usage.UnusedPermissions(grant, observed)
+		if len(permissions) == 0 {
+			continue
+		}
+		total += len(permissions)
+		unused = append(unused, authv1alpha1.UnusedPermission{
+			Kind: grant.Kind, Namespace: grant.Namespace, Name: grant.Name, Permissions: permissions,
+		})
+	}
+	user.Status.UnusedPermissions = unused
+	setUnusedPermissionsCondition(user, total, len(unused), window)
+	return nil
+}
+
+func setUnusedPermissionsCondition(user *authv1alpha1.User, permissions, roles int, window time.Duration) {
+	condition := metav1.Condition{
+		Type:               ConditionUnusedPermissions,
+		Status:             metav1.ConditionFalse,
+		Reason:             "AllPermissionsUsed",
+		Message:            fmt.Sprintf("All granted permissions were used in the last %s", window),
+		ObservedGeneration: user.Generation,
+	}
+	if permissions > 0 {
+		condition.Status = metav1.ConditionTrue
+		condition.Reason = "PermissionsUnused"
+		condition.Message = fmt.Sprintf("%d permissions granted by %d roles were not used in the last %s; see status.unusedPermissions",
+			permissions, roles, window)
+	}
+	meta.SetStatusCondition(&user.Status.Conditions, condition)
+}
+
+// analyzeActivity copies the last observed request into status.lastActivity and sets the Idle
+// condition for users holding valid credentials. Users never seen are only reported idle once
+// the store has observed them for a full window, so a controller restart does not make
+// everyone look idle.
+func (r *UserReconciler) analyzeActivity(user *authv1alpha1.User, window time.Duration, now time.Time) {
+	if seen := r.UsageStore.LastActivity(user.Name); !seen.IsZero() &&
+		(user.Status.LastActivity == nil || seen.Truncate(time.Second).After(user.Status.LastActivity.Time)) {
+		user.Status.LastActivity = &metav1.Time{Time: seen}
+	}
+
+	validUntil, valid := credentialsValidUntil(user, now)
+	if !valid || user.Spec.Revoked || user.Spec.Suspended {
+		meta.RemoveStatusCondition(&user.Status.Conditions, ConditionIdle)
+		return
+	}
+	condition := metav1.Condition{
+		Type:               ConditionIdle,
+		Status:             metav1.ConditionFalse,
+		Reason:             "RecentActivity",
+		ObservedGeneration: user.Generation,
+	}
+	if last := user.Status.LastActivity; last != nil {
+		condition.Message = fmt.Sprintf("Last API request at %s", last.UTC().Format(time.RFC3339))
+		if now.Sub(last.Time) > window {
+			condition.Status = metav1.ConditionTrue
+			condition.Reason = "NoRecentActivity"
+			condition.Message = fmt.Sprintf("No API request since %s, but the credentials are valid until %s",
+				last.UTC().Format(time.RFC3339), validUntil.UTC().Format(time.RFC3339))
+		}
+	} else {
+		observedSince := r.UsageStore.TrackingSince()
+		if user.CreationTimestamp.After(observedSince) {
+			observedSince = user.CreationTimestamp.Time
+		}
+		condition.Message = "No API request seen yet"
+		if now.Sub(observedSince) > window {
+			condition.Status = metav1.ConditionTrue
+			condition.Reason = "NeverUsed"
+			condition.Message = fmt.Sprintf("No API request seen in the last %s, but the credentials are valid until %s",
+				window, validUntil.UTC().Format(time.RFC3339))
+		}
+	}
+	meta.SetStatusCondition(&user.Status.Conditions, condition)
+}
+
+// credentialsValidUntil returns when the user's current certificate or token expires, and
+// whether it is still valid at now
+func credentialsValidUntil(user *authv1alpha1.User, now time.Time) (time.Time, bool) {
+	if isMachine(user) {
+		if user.Status.TokenExpiry == nil {
+			return time.Time{}, false
+		}
+		return user.Status.TokenExpiry.Time, now.Before(user.Status.TokenExpiry.Time)
+	}
+	expiry, err := time.Parse(time.RFC3339, user.Status.ExpiryTime)
+	if err != nil {
+		return time.Time{}, false
+	}
+	return expiry, now.Before(expiry)
+}
+
+// userGrants resolves the rules of every role bound through the user's spec. Roles that do not
+// exist (yet) are skipped; they grant nothing.
+func (r *UserReconciler) userGrants(ctx context.Context, user *authv1alpha1.User) ([]usage.Grant, error) {
+	var grants []usage.Grant
+	for _, role := range user.Spec.Roles {
+		var rbacRole rbacv1.Role
+		if err := r.Get(ctx, types.NamespacedName{Namespace: role.Namespace, Name: role.ExistingRole}, &rbacRole); err != nil {
+			if apierrors.IsNotFound(err) {
+				continue
+			}
+			return nil, err
+		}
+		grants = append(grants, usage.Grant{Kind: "Role", Namespace: role.Namespace, Name: role.ExistingRole, Rules: rbacRole.Rules})
+	}
+	for _, clusterRole := range activeClusterRoles(user, time.Now()) {
+		var rbacClusterRole rbacv1.ClusterRole
+		if err := r.Get(ctx, types.NamespacedName{Name: clusterRole.ExistingClusterRole}, &rbacClusterRole); err != nil {
+			if apierrors.IsNotFound(err) {
+				continue
+			}
+			return nil, err
+		}
+		grants = append(grants, usage.Grant{Kind: "ClusterRole", Name: clusterRole.ExistingClusterRole, Rules: rbacClusterRole.Rules})
+	}
+	return grants, nil
+}
@@ -8,72 +8,232 @@ package controller
 
 import (
 	"context"
+	"crypto"
 	"crypto/rand"
-	"crypto/rsa"
 	"crypto/x509"
-	"crypto/x509/pkix"
-	"encoding/base64"
 	"encoding/pem"
 	"errors"
 	"fmt"
-	"os"
-	"path/filepath"
+	"net/url"
+	"sort"
 	"strings"
+	"sync"
 	"time"
 
 	authv1alpha1 "github.com/openkube-hub/KubeUser/api/v1alpha1"
-	certv1 "k8s.io/api/certificates/v1"
+	"github.com/openkube-hub/KubeUser/internal/aws"
+	"github.com/openkube-hub/KubeUser/internal/ca"
+	"github.com/openkube-hub/KubeUser/internal/credentials"
+	"github.com/openkube-hub/KubeUser/internal/gcp"
+	"github.com/openkube-hub/KubeUser/internal/inventory"
+	"github.com/openkube-hub/KubeUser/internal/issuer"
+	"github.com/openkube-hub/KubeUser/internal/keyprotect"
+	"github.com/openkube-hub/KubeUser/internal/naming"
+	"github.com/openkube-hub/KubeUser/internal/notify"
+	"github.com/openkube-hub/KubeUser/internal/operatorconfig"
+	"github.com/openkube-hub/KubeUser/internal/operatorstatus"
+	"github.com/openkube-hub/KubeUser/internal/policy"
+	"github.com/openkube-hub/KubeUser/internal/secretstore"
+	"github.com/openkube-hub/KubeUser/internal/tracing"
+	"github.com/openkube-hub/KubeUser/internal/usage"
+	"go.opentelemetry.io/otel/attribute"
 	corev1 "k8s.io/api/core/v1"
 	rbacv1 "k8s.io/api/rbac/v1"
 	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/api/meta"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	"k8s.io/apimachinery/pkg/runtime"
 	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/client-go/tools/clientcmd"
+	clientcmdapi "k8s.io/client-go/tools/clientcmd/api"
+	"k8s.io/client-go/tools/record"
+	"k8s.io/client-go/util/workqueue"
 	ctrl "sigs.k8s.io/controller-runtime"
 	"sigs.k8s.io/controller-runtime/pkg/client"
+	crcontroller "sigs.k8s.io/controller-runtime/pkg/controller"
+	"sigs.k8s.io/controller-runtime/pkg/event"
+	"sigs.k8s.io/controller-runtime/pkg/handler"
 	logf "sigs.k8s.io/controller-runtime/pkg/log"
+	"sigs.k8s.io/controller-runtime/pkg/reconcile"
+	"sigs.k8s.io/controller-runtime/pkg/source"
 )
 
 const (
-	inClusterCAPath = "/var/run/secrets/kubernetes.io/serviceaccount/ca.crt"
-
 	userFinalizer = "auth.openkube.io/finalizer"
 
 	// Phase constants to avoid goconst issues
 	PhaseError   = "Error"
 	PhaseExpired = "Expired"
 	PhaseReady   = "Ready"
+	// PhaseSuspended is reported while spec.suspended keeps the user's bindings removed
+	PhaseSuspended = "Suspended"
+	// PhaseRevoked is reported while spec.revoked keeps the user's bindings and credentials removed
+	PhaseRevoked = "Revoked"
+	// PhaseExpiringSoon is reported instead of Active while the certificate is within an expiry warning window
+	PhaseExpiringSoon = "ExpiringSoon"
+
+	// FieldManager owns the fields the controller applies to generated objects
+	FieldManager = "kubeuser-controller"
 )
 
 // UserReconciler reconciles a User object
 type UserReconciler struct {
 	client.Client
 	Scheme *runtime.Scheme
+	// APIReader reads from the API server instead of the cache, for the latest User after a
+	// conflicting status write; defaults to Client
+	APIReader client.Reader
+
+	// BindingMode is the default binding mode (enforce or report-only); the
+	// auth.openkube.io/binding-mode annotation overrides it per user
+	BindingMode string
+
+	// CredentialLayout is the default set of keys written into credential Secrets;
+	// spec.output.keys overrides it per user. Defaults to a kubeconfig under "config".
+	CredentialLayout credentials.Layout
+
+	// CAResolver locates the cluster CA embedded in generated kubeconfigs.
+	// When nil, the default sources (ServiceAccount mount, kube-root-ca.crt) are used.
+	CAResolver *ca.Resolver
+
+	// NotificationTemplates renders the content of user lifecycle notifications
+	NotificationTemplates *notify.Templates
+	// Notifier delivers lifecycle notifications to the configured sinks; defaults to the
+	// built-in senders with NotificationTemplates, nil disables notifications
+	Notifier *notify.Notifier
+
+	// SSHCASecret and SSHCAKey locate the SSH CA private key that signs certificates
+	// requested in spec.ssh; an empty name disables SSH certificates
+	SSHCASecret types.NamespacedName
+	SSHCAKey    string
+
+	// ServiceAccountAnchor is the default for creating per-user ServiceAccount anchors;
+	// spec.serviceAccountAnchor overrides it per user
+	ServiceAccountAnchor bool
+
+	// CredentialReaderRBAC grants each user get on their own credential Secret, so they can
+	// fetch their kubeconfig themselves
+	CredentialReaderRBAC bool
+
+	// NamespaceCleanup controls what happens to the kubeuser namespace when the last
+	// User is deleted: none (default), leftovers or namespace
+	NamespaceCleanup string
+
+	// UsageStore holds observed API usage; nil disables role recommendations
+	UsageStore *usage.Store
+	// UsageWindow is the observation period recommendations are based on
+	UsageWindow time.Duration
+
+	// RulesReviewer, when set, resolves status.effectiveAccess with SelfSubjectRulesReviews made
+	// as the user instead of from the bound roles alone
+	RulesReviewer RulesReviewer
+
+	// ReconcileTimeout bounds a single reconcile of one user; zero disables the timeout
+	ReconcileTimeout time.Duration
+	// CircuitBreakerFailures is how many consecutive failures suspend a user's reconciles for
+	// CircuitBreakerCooldown; zero disables the circuit breaker
+	CircuitBreakerFailures int
+	CircuitBreakerCooldown time.Duration
+
+	// StatusMessageInterval limits how often status is written when only messages changed
+	StatusMessageInterval time.Duration
+	statusThrottle        statusThrottle
+
+	// Recorder records lifecycle Events on Users
+	Recorder record.EventRecorder
+
+	// Issuer signs client certificates; defaults to the Kubernetes CSR API
+	Issuer issuer.Issuer
+
+	// KeyProtector encrypts user private keys before they are stored; nil stores them as PEM
+	KeyProtector keyprotect.Protector
+
+	// CredentialStores opens the credential stores of the KubeUserConfig, which receive copies
+	// of the credential Secrets
+	CredentialStores *secretstore.Opener
+
+	// ConfigEvents re-enqueues Users when a KubeUserConfig change altered the settings in effect
+	ConfigEvents <-chan event.GenericEvent
+
+	// ProxyServer and ProxyCA replace the API server and its CA in generated kubeconfigs while
+	// the impersonation proxy is enabled; spec.output overrides them per user
+	ProxyServer string
+	ProxyCA     []byte
+
+	// MemberClusters enables provisioning users on the member clusters in spec.clusters
+	MemberClusters bool
+	// ClusterAPI adds the Cluster API Clusters of a user's fleets to its member clusters. It
+	// requires MemberClusters and the Cluster API CRDs.
+	ClusterAPI bool
+	// EKS manages the access entries of users with spec.eks on the EKS cluster; nil leaves
+	// spec.eks unreconciled
+	EKS *aws.EKS
+	// GCP grants the Google accounts in spec.gcp their IAM roles on GCPProject; nil leaves
+	// the roles ungranted, though the accounts are still bound
+	GCP        *gcp.Projects
+	GCPProject string
+	// MemberClients connects to the member clusters in spec.clusters; defaults to NewMemberClient
+	MemberClients   MemberClientFactory
+	memberClientsMu sync.Mutex
+	memberClients   map[string]memberClient
+
+	circuitBreakerOnce sync.Once
+	circuitBreaker     *circuitBreaker
+	diagnostics        reconcileDiagnostics
+
+	// MaxConcurrentReconciles is how many Users are reconciled in parallel; defaults to 1
+	MaxConcurrentReconciles int
+	// RateLimiter paces the workqueue; nil uses the controller-runtime default
+	RateLimiter workqueue.TypedRateLimiter[reconcile.Request]
+	// RetryBaseDelay and RetryMaxDelay bound the exponential delay before a User whose
+	// credentials are not ready is reconciled again
+	RetryBaseDelay time.Duration
+	RetryMaxDelay  time.Duration
+	// RenewalCheckInterval is how often a User is reconciled when nothing else is due, and so
+	// how late a certificate may be renewed; defaults to DefaultRenewalCheckInterval
+	RenewalCheckInterval time.Duration
+
+	issuanceBackoffOnce    sync.Once
+	issuanceBackoffLimiter workqueue.TypedRateLimiter[string]
+
+	retryBackoffOnce    sync.Once
+	retryBackoffLimiter workqueue.TypedRateLimiter[string]
 }
 
 // RBAC rules
 // +kubebuilder:rbac:groups=auth.openkube.io,resources=users,verbs=get;list;watch;create;update;patch;delete
 // +kubebuilder:rbac:groups=auth.openkube.io,resources=users/status,verbs=get;update;patch
 // +kubebuilder:rbac:groups=auth.openkube.io,resources=users/finalizers,verbs=update
+// +kubebuilder:rbac:groups=auth.openkube.io,resources=issuedcertificates,verbs=get;list;watch;create
+// +kubebuilder:rbac:groups=auth.openkube.io,resources=issuedcertificates/status,verbs=get;update;patch
+// +kubebuilder:rbac:groups=auth.openkube.io,resources=accessgrantrecords,verbs=list;create;delete
 // Core resources
 // +kubebuilder:rbac:groups="",resources=configmaps;secrets;serviceaccounts,verbs=get;list;watch;create;update;patch;delete
-// +kubebuilder:rbac:groups="",resources=namespaces,verbs=get;list;watch;create
+// +kubebuilder:rbac:groups="",resources=namespaces,verbs=get;list;watch;create;delete
+// +kubebuilder:rbac:groups="",resources=events,verbs=create;patch
 // +kubebuilder:rbac:groups="",resources=services,verbs=get;list;watch
+// +kubebuilder:rbac:groups="",resources=serviceaccounts/token,verbs=create
 // +kubebuilder:rbac:groups="",resources=pods;replicasets,verbs=get;list;watch;create;update;patch;delete
 // Apps resources
 // +kubebuilder:rbac:groups=apps,resources=deployments;replicasets,verbs=get;list;watch;create;update;patch;delete
 // RBAC resources with bind/escalate permissions
 // +kubebuilder:rbac:groups=rbac.authorization.k8s.io,resources=roles;clusterroles,verbs=get;list;watch;bind;escalate
+// +kubebuilder:rbac:groups=rbac.authorization.k8s.io,resources=roles,verbs=create;update;patch;delete,namespace=kubeuser
 // +kubebuilder:rbac:groups=rbac.authorization.k8s.io,resources=rolebindings;clusterrolebindings,verbs=get;list;watch;create;update;patch;delete;bind;escalate
 // CSR resources
 // +kubebuilder:rbac:groups=certificates.k8s.io,resources=certificatesigningrequests,verbs=create;get;list;watch;update;patch;delete
 // +kubebuilder:rbac:groups=certificates.k8s.io,resources=certificatesigningrequests/approval,verbs=update
 // +kubebuilder:rbac:groups=certificates.k8s.io,resources=signers,verbs=approve,resourceNames=kubernetes.io/kube-apiserver-client
+// +kubebuilder:rbac:groups=cert-manager.io,resources=certificaterequests,verbs=create;get;list;watch;delete
+// Cluster API Clusters of fleets
+// +kubebuilder:rbac:groups=cluster.x-k8s.io,resources=clusters,verbs=get;list;watch
 // Admission resources
 // +kubebuilder:rbac:groups=admissionregistration.k8s.io,resources=validatingwebhookconfigurations,verbs=get;patch
+// Report-only spot checks
+// +kubebuilder:rbac:groups=authorization.k8s.io,resources=subjectaccessreviews,verbs=create
 
-// Reconcile main loop
-func (r *UserReconciler) Reconcile(ctx context.Context, req ctrl.Request) (ctrl.Result, error) {
+// reconcileUser is the main loop for a single user, run by Reconcile
+func (r *UserReconciler) reconcileUser(ctx context.Context, req ctrl.Request) (result ctrl.Result, err error) {
 	logger := logf.FromContext(ctx)
 	logger.Info("=== START RECONCILE ===", "user", req.Name)
 
@@ -85,22 +245,6 @@ func (r *UserReconciler) Reconcile(ctx context.Context, req ctrl.Request) (ctrl.
 	username := user.Name
 	logger.Info("Reconciling User", "name", username, "generation", user.Generation, "resourceVersion", user.ResourceVersion)
 
-	// Ensure initial status is set
-	logger.Info("Checking initial status", "currentPhase", user.Status.Phase)
-	if user.Status.Phase == "" {
-		logger.Info("Setting initial status to Pending")
-		user.Status.Phase = "Pending"
-		user.Status.Message = "Initializing user resources"
-		if err := r.Status().Update(ctx, &user); err != nil {
-			logger.Error(err, "Failed to set initial status")
-			// Don't return error, continue with reconciliation
-		} else {
-			logger.Info("Successfully set initial status")
-		}
-	} else {
-		logger.Info("Status already set, skipping initial status", "phase", user.Status.Phase)
-	}
-
 	// Handle deletion
 	logger.Info("Checking deletion", "deletionTimestamp", user.DeletionTimestamp)
 	if !user.DeletionTimestamp.IsZero() {
@@ -115,6 +259,10 @@ func (r *UserReconciler) Reconcile(ctx context.Context, req ctrl.Request) (ctrl.
 				return ctrl.Result{}, err
 			}
 			logger.Info("Successfully cleaned up and removed finalizer")
+			r.statusThrottle.forget(username)
+			r.retryBackoff().Forget(username)
+			forgetUserMetrics(username)
+			r.cleanupEmptyNamespace(ctx, username)
 		}
 		logger.Info("=== END RECONCILE (DELETION) ===")
 		return ctrl.Result{}, nil
@@ -130,68 +278,257 @@ func (r *UserReconciler) Reconcile(ctx context.Context, req ctrl.Request) (ctrl.
 			return ctrl.Result{}, err
 		}
 		logger.Info("Successfully added finalizer")
+		// The finalizer is added once, on the first reconcile of a new User
+		r.notify(ctx, &user, notify.EventCreated, "", nil)
 	} else {
 		logger.Info("Finalizer already exists, skipping")
 	}
 
+	// The status is only changed in memory below and written once the pass is over, whichever
+	// way it ends, unless the User was deleted
+	original := user.DeepCopy()
+	deleted := false
+	defer func() {
+		if deleted {
+			return
+		}
+		if statusErr := r.updateStatus(ctx, original, &user); statusErr != nil {
+			logger.Error(statusErr, "Failed to update user status")
+			if err == nil {
+				err = statusErr
+			}
+		}
+	}()
+
+	// Ensure initial status is set
+	if user.Status.Phase == "" {
+		logger.Info("Setting initial status to Pending")
+		user.Status.Phase = "Pending"
+		user.Status.Message = "Initializing user resources"
+	}
+
+	// The user's UserTemplate applies to everything below
+	if err := r.applyUserTemplate(ctx, &user); err != nil {
+		logger.Error(err, "Failed to apply UserTemplate")
+		user.Status.Phase = PhaseError
+		user.Status.Message = fmt.Sprintf("Failed to apply UserTemplate: %v", err)
+		return ctrl.Result{}, err
+	}
+
+	// So do the roles of the user's Teams
+	if err := r.applyTeams(ctx, &user); err != nil {
+		logger.Error(err, "Failed to apply Teams")
+		user.Status.Phase = PhaseError
+		user.Status.Message = fmt.Sprintf("Failed to apply Teams: %v", err)
+		return ctrl.Result{}, err
+	}
+
+	// And the Cluster API Clusters of the user's fleets
+	if err := r.applyFleets(ctx, &user); err != nil {
+		logger.Error(err, "Failed to apply fleets")
+		user.Status.Phase = PhaseError
+		user.Status.Message = fmt.Sprintf("Failed to apply fleets: %v", err)
+		return ctrl.Result{}, err
+	}
+
+	// Break-glass Users are deleted once their access has ended
+	if deleted, err = r.reconcileBreakGlass(ctx, &user); err != nil {
+		logger.Error(err, "Failed to reconcile break-glass access")
+		return ctrl.Result{}, err
+	} else if deleted {
+		logger.Info("=== END RECONCILE (BREAK-GLASS ENDED) ===")
+		return ctrl.Result{}, nil
+	}
+
+	// Expired Users are deleted once their grace period has passed
+	if deleted, err = r.reconcileExpiredDeletion(ctx, &user); err != nil {
+		logger.Error(err, "Failed to delete expired user")
+		return ctrl.Result{}, err
+	} else if deleted {
+		logger.Info("=== END RECONCILE (EXPIRED USER DELETED) ===")
+		return ctrl.Result{}, nil
+	}
+
 	// Ensure user resources namespace
 	userNamespace := getKubeUserNamespace()
 	logger.Info("Ensuring user resources namespace", "namespace", userNamespace)
-	if err := r.ensureNamespace(ctx, userNamespace); err != nil {
+	if err := ensureNamespace(ctx, r.Client, userNamespace); err != nil {
 		logger.Error(err, "Failed to ensure user resources namespace")
 		return ctrl.Result{}, err
 	}
 	logger.Info("User resources namespace ensured")
 
+	if err := r.ensureServiceAccountAnchor(ctx, &user); err != nil {
+		logger.Error(err, "Failed to ensure ServiceAccount anchor")
+		return ctrl.Result{}, err
+	}
+
+	// In report-only mode binding changes are only recorded in status. Revocation is enforced
+	// regardless.
+	var plan *bindingPlan
+	if r.bindingMode(&user) == BindingModeReportOnly && !user.Spec.Revoked {
+		logger.Info("Binding mode is report-only, bindings will not be changed")
+		plan = &bindingPlan{}
+	}
+
+	gate, err := r.policyGate(ctx, &user)
+	if err != nil {
+		logger.Error(err, "Failed to read ClusterPolicies")
+		return ctrl.Result{}, err
+	}
+
+	// Grants past their expiresAt or duration are left out; their bindings are removed below
+	syncTimedGrants(&user, time.Now())
+
+	// Missing roles are reported before their bindings fail below
+	if err := r.checkRoleReferences(ctx, &user, time.Now()); err != nil {
+		logger.Error(err, "Failed to check referenced roles")
+		return ctrl.Result{}, err
+	}
+
+	// A grant that cannot be bound does not hold up the others; status.bindings tells them apart
+	user.Status.Bindings = nil
+
 	// === Reconcile RoleBindings ===
 	logger.Info("Starting RoleBindings reconciliation", "rolesCount", len(user.Spec.Roles))
-	if err := r.reconcileRoleBindings(ctx, &user); err != nil {
-		logger.Error(err, "Failed to reconcile RoleBindings")
-		user.Status.Phase = PhaseError
-		user.Status.Message = fmt.Sprintf("Failed to reconcile RoleBindings: %v", err)
-		_ = r.Status().Update(ctx, &user)
-		return ctrl.Result{}, err
+	rbErr := r.reconcileRoleBindings(ctx, &user, plan, gate)
+	if rbErr != nil {
+		logger.Error(rbErr, "Failed to reconcile RoleBindings")
+	} else {
+		logger.Info("RoleBindings reconciliation completed")
 	}
-	logger.Info("RoleBindings reconciliation completed")
 
 	// === Reconcile ClusterRoleBindings ===
 	logger.Info("Starting ClusterRoleBindings reconciliation", "clusterRolesCount", len(user.Spec.ClusterRoles))
-	if err := r.reconcileClusterRoleBindings(ctx, &user); err != nil {
-		logger.Error(err, "Failed to reconcile ClusterRoleBindings")
+	crbErr := r.reconcileClusterRoleBindings(ctx, &user, plan, gate)
+	if crbErr != nil {
+		logger.Error(crbErr, "Failed to reconcile ClusterRoleBindings")
+	} else {
+		logger.Info("ClusterRoleBindings reconciliation completed")
+	}
+	sortBindings(&user)
+	if err := errors.Join(rbErr, crbErr); err != nil {
 		user.Status.Phase = PhaseError
-		user.Status.Message = fmt.Sprintf("Failed to reconcile ClusterRoleBindings: %v", err)
-		_ = r.Status().Update(ctx, &user)
+		user.Status.Message = bindingFailureMessage(&user, err)
 		return ctrl.Result{}, err
 	}
-	logger.Info("ClusterRoleBindings reconciliation completed")
+	setElevationCondition(&user, time.Now())
+	setGrantExpiryCondition(&user, time.Now())
+	setAccessWindowCondition(&user, time.Now())
+	r.setPolicyCondition(&user, gate)
+	r.recordAccessPlan(ctx, &user, plan)
 
-	// Update status after successful RBAC reconciliation
-	logger.Info("*** CALLING updateUserStatus ***")
-	if err := r.updateUserStatus(ctx, &user); err != nil {
-		logger.Error(err, "Failed to update user status")
-		// Don't return error, continue with certificate processing
-	} else {
-		logger.Info("*** updateUserStatus completed successfully ***")
+	if err := r.reconcileRevocation(ctx, &user); err != nil {
+		logger.Error(err, "Failed to revoke user credentials")
+		return ctrl.Result{}, err
+	}
+
+	// Usage findings are persisted together with the status below
+	if err := r.analyzeUsage(ctx, &user); err != nil {
+		logger.Error(err, "Failed to analyze role usage")
+	}
+	if err := r.summarizeEffectiveAccess(ctx, &user); err != nil {
+		logger.Error(err, "Failed to summarize effective access")
+	}
+
+	// Derive the phase after successful RBAC reconciliation
+	r.setUserStatus(ctx, &user)
+
+	// Credential reader access failures are reported in the CredentialAccessReady condition
+	retry := r.syncCredentialAccess(ctx, &user)
+
+	// Member clusters follow the bindings above; their failures are reported in status.clusters
+	retry = untilRetry(retry, r.reconcileClusters(ctx, &user))
+
+	// So does the EKS access entry, whose failures are reported in the EKSAccessReady condition
+	retry = untilRetry(retry, r.reconcileEKSAccess(ctx, &user))
+	// and the GCP IAM roles, reported in the GCPAccessReady condition
+	retry = untilRetry(retry, r.reconcileGCPAccess(ctx, &user))
+
+	// Revoked users get no new certificate until spec.revoked is cleared
+	if user.Spec.Revoked {
+		logger.Info("=== END RECONCILE (REVOKED) ===")
+		return ctrl.Result{RequeueAfter: untilBreakGlassEnd(&user, time.Now(), retry)}, nil
+	}
+
+	// Suspended users keep their key and credentials for when they are resumed, but no
+	// certificate is issued or renewed meanwhile
+	if user.Spec.Suspended {
+		logger.Info("=== END RECONCILE (SUSPENDED) ===")
+		return ctrl.Result{RequeueAfter: untilBreakGlassEnd(&user, time.Now(), retry)}, nil
+	}
+
+	// EKS users who sign in through their IAM principal only get no certificate
+	if user.Spec.EKS != nil && user.Spec.EKS.IAMOnly {
+		logger.Info("=== END RECONCILE (IAM ONLY) ===")
+		return ctrl.Result{RequeueAfter: untilBreakGlassEnd(&user, time.Now(), untilAccessWindowChange(&user, time.Now(),
+			untilNextGrantEnd(&user, time.Now(), untilRetry(r.renewalCheckInterval(), retry))))}, nil
+	}
+
+	// Machine users get a token-based kubeconfig instead of a certificate
+	if isMachine(&user) {
+		refresh, err := r.ensureTokenKubeconfig(ctx, &user, time.Now())
+		if err != nil {
+			logger.Error(err, "Failed to ensure token kubeconfig")
+			r.event(&user, corev1.EventTypeWarning, EventProvisioningFailed, "Failed to issue token: %v", err)
+			logger.Info("=== END RECONCILE (TOKEN ERROR) ===")
+			return ctrl.Result{RequeueAfter: r.retryBackoff().When(username)}, nil
+		}
+		r.retryBackoff().Forget(username)
+		requeueAfter := untilAccessWindowChange(&user, time.Now(),
+			untilNextGrantEnd(&user, time.Now(), min(refresh, r.renewalCheckInterval())))
+		if err := r.syncCredentialStores(ctx, &user); err != nil {
+			logger.Error(err, "Failed to write credential stores")
+			r.event(&user, corev1.EventTypeWarning, EventCredentialStoreFailed, "Failed to write credential stores: %v", err)
+			requeueAfter = min(requeueAfter, 30*time.Second)
+		}
+		logger.Info("=== END RECONCILE (MACHINE) ===", "requeueAfter", requeueAfter)
+		return ctrl.Result{RequeueAfter: requeueAfter}, nil
 	}
 
 	// Ensure cert-based kubeconfig
 	logger.Info("Starting certificate/kubeconfig processing")
 	requeue, err := r.ensureCertKubeconfig(ctx, &user)
+	if err != nil && issuer.IsUnavailable(err) {
+		// RBAC is already in place; keep the user usable and retry issuance with backoff
+		delay := r.issuanceBackoff().When(username)
+		logger.Error(err, "Certificate issuance unavailable, retrying with backoff", "retryIn", delay)
+		r.setIssuanceCondition(&user, err)
+		logger.Info("=== END RECONCILE (ISSUANCE UNAVAILABLE) ===")
+		return ctrl.Result{RequeueAfter: delay}, nil
+	}
 	if err != nil {
 		logger.Error(err, "Failed to ensure certificate kubeconfig")
+		r.event(&user, corev1.EventTypeWarning, EventProvisioningFailed, "Failed to issue certificate: %v", err)
 		logger.Info("=== END RECONCILE (CERT ERROR) ===")
-		return ctrl.Result{RequeueAfter: 5 * time.Second}, nil
+		return ctrl.Result{RequeueAfter: r.retryBackoff().When(username)}, nil
 	}
+	r.issuanceBackoff().Forget(username)
+	r.setIssuanceCondition(&user, nil)
 	if requeue {
 		logger.Info("Certificate processing needs requeue")
 		logger.Info("=== END RECONCILE (REQUEUE) ===")
-		return ctrl.Result{RequeueAfter: 3 * time.Second}, nil
+		return ctrl.Result{RequeueAfter: r.retryBackoff().When(username)}, nil
 	}
+	r.retryBackoff().Forget(username)
 	logger.Info("Certificate/kubeconfig processing completed")
 
+	// Credential stores are retried sooner than the regular reconciliation
+	if err := r.syncCredentialStores(ctx, &user); err != nil {
+		logger.Error(err, "Failed to write credential stores")
+		r.event(&user, corev1.EventTypeWarning, EventCredentialStoreFailed, "Failed to write credential stores: %v", err)
+		logger.Info("=== END RECONCILE (CREDENTIAL STORE ERROR) ===")
+		return ctrl.Result{RequeueAfter: 30 * time.Second}, nil
+	}
+
+	// SSH certificates follow the client certificate's lifetime
+	if err := r.ensureSSHCertificate(ctx, &user); err != nil {
+		logger.Error(err, "Failed to ensure SSH certificate")
+	}
+
 	// Requeue if user is close to expiry to handle cleanup
 	logger.Info("Checking expiry for requeue", "phase", user.Status.Phase, "expiryTime", user.Status.ExpiryTime)
-	if user.Status.Phase == "Active" && user.Status.ExpiryTime != "" {
+	if (user.Status.Phase == "Active" || user.Status.Phase == PhaseExpiringSoon) && user.Status.ExpiryTime != "" {
 		if expiryTime, err := time.Parse(time.RFC3339, user.Status.ExpiryTime); err == nil {
 			timeUntilExpiry := time.Until(expiryTime)
 			logger.Info("Time until expiry", "duration", timeUntilExpiry)
@@ -199,70 +536,118 @@ func (r *UserReconciler) Reconcile(ctx context.Context, req ctrl.Request) (ctrl.
 				// User has expired, mark as expired
 				logger.Info("User has expired, updating status")
 				user.Status.Phase = PhaseExpired
-				user.Status.Message = "User access has expired"
-				_ = r.Status().Update(ctx, &user)
+				user.Status.Message = expiredMessage(&user)
 				logger.Info("=== END RECONCILE (EXPIRED) ===")
-				return ctrl.Result{}, nil
+				return ctrl.Result{RequeueAfter: untilExpiredDeletion(&user, time.Now(),
+					untilBreakGlassEnd(&user, time.Now(), 0))}, nil
 			} else if timeUntilExpiry < 24*time.Hour {
 				// Requeue to check expiry more frequently
 				logger.Info("User expires soon, requeueing in 1 hour")
 				logger.Info("=== END RECONCILE (EXPIRY REQUEUE) ===")
-				return ctrl.Result{RequeueAfter: time.Hour}, nil
+				return ctrl.Result{RequeueAfter: untilBreakGlassEnd(&user, time.Now(),
+					untilAccessWindowChange(&user, time.Now(), untilNextGrantEnd(&user, time.Now(), time.Hour)))}, nil
 			}
 		} else {
 			logger.Error(err, "Failed to parse expiry time", "expiryTime", user.Status.ExpiryTime)
 		}
 	}
 
-	logger.Info("=== END RECONCILE (SUCCESS) ===, requeueing in 30 minutes")
-	return ctrl.Result{RequeueAfter: 30 * time.Minute}, nil // Regular reconciliation
+	// Regular reconciliation, earlier if an elevation or timed grant ends or the access window
+	// opens or closes before then
+	requeueAfter := untilBreakGlassEnd(&user, time.Now(), untilAccessWindowChange(&user, time.Now(),
+		untilNextGrantEnd(&user, time.Now(), untilRetry(r.renewalCheckInterval(), retry))))
+	logger.Info("=== END RECONCILE (SUCCESS) ===", "requeueAfter", requeueAfter)
+	return ctrl.Result{RequeueAfter: requeueAfter}, nil
 }
 
 // SetupWithManager wires the controller
 func (r *UserReconciler) SetupWithManager(mgr ctrl.Manager) error {
-	return ctrl.NewControllerManagedBy(mgr).
+	if r.CAResolver == nil {
+		r.CAResolver = ca.NewResolver(mgr.GetClient(), nil)
+	}
+	if r.Issuer == nil {
+		r.Issuer = issuer.NewCSRIssuer(mgr.GetClient())
+	}
+	if r.CredentialStores == nil {
+		r.CredentialStores = &secretstore.Opener{Reader: mgr.GetClient()}
+	}
+	if r.Notifier == nil && r.NotificationTemplates != nil {
+		r.Notifier = notify.NewNotifier(mgr.GetClient(), r.NotificationTemplates)
+	}
+	b := ctrl.NewControllerManagedBy(mgr).
 		For(&authv1alpha1.User{}).
-		Owns(&rbacv1.RoleBinding{}).
-		Owns(&rbacv1.ClusterRoleBinding{}).
+		Watches(&rbacv1.RoleBinding{}, handler.EnqueueRequestsFromMapFunc(usersForBinding)).
+		Watches(&rbacv1.ClusterRoleBinding{}, handler.EnqueueRequestsFromMapFunc(usersForBinding)).
+		Watches(&rbacv1.Role{}, handler.EnqueueRequestsFromMapFunc(r.usersForRole)).
+		Watches(&rbacv1.ClusterRole{}, handler.EnqueueRequestsFromMapFunc(r.usersForRole)).
 		Owns(&corev1.Secret{}).
-		Named("user").
-		Complete(r)
+		Watches(&corev1.Secret{}, handler.EnqueueRequestsFromMapFunc(r.usersForClusterSecret)).
+		Watches(&authv1alpha1.ClusterPolicy{}, handler.EnqueueRequestsFromMapFunc(r.usersForPolicy)).
+		Watches(&authv1alpha1.UserTemplate{}, handler.EnqueueRequestsFromMapFunc(r.usersForTemplate)).
+		Watches(&authv1alpha1.Team{}, handler.EnqueueRequestsFromMapFunc(r.usersForTeam))
+	if r.ClusterAPI {
+		b = b.Watches(newClusterAPICluster(), handler.EnqueueRequestsFromMapFunc(r.usersForClusterAPICluster))
+	}
+	if r.ConfigEvents != nil {
+		b = b.WatchesRawSource(source.Channel(r.ConfigEvents, &handler.EnqueueRequestForObject{}))
+	}
+	return b.WithOptions(crcontroller.TypedOptions[reconcile.Request]{
+		MaxConcurrentReconciles: r.MaxConcurrentReconciles,
+		RateLimiter:             r.RateLimiter,
+	}).Named("user").Complete(r)
 }
 
 // --- helpers ---
 
 // getKubeUserNamespace returns the namespace where all KubeUser resources should be created
 func getKubeUserNamespace() string {
-	namespace := os.Getenv("KUBEUSER_NAMESPACE")
-	if namespace == "" {
-		namespace = "kubeuser" // fallback to default
-	}
-	return namespace
+	return operatorconfig.Namespace()
 }
 
-func (r *UserReconciler) ensureNamespace(ctx context.Context, name string) error {
+// ensureNamespace creates the namespace, labelled as created by the controller, if it does not exist
+func ensureNamespace(ctx context.Context, c client.Client, name string) error {
 	var ns corev1.Namespace
-	if err := r.Get(ctx, types.NamespacedName{Name: name}, &ns); err != nil {
+	if err := c.Get(ctx, types.NamespacedName{Name: name}, &ns); err != nil {
 		if apierrors.IsNotFound(err) {
-			ns = corev1.Namespace{ObjectMeta: metav1.ObjectMeta{Name: name}}
-			return r.Create(ctx, &ns)
+			ns = corev1.Namespace{ObjectMeta: metav1.ObjectMeta{
+				Name: name,
+				Labels: map[string]string{
+					ManagedNamespaceLabel:          "true",
+					"app.kubernetes.io/managed-by": "kubeuser",
+				},
+			}}
+			// Another User or Team may have created it meanwhile
+			if err := c.Create(ctx, &ns); err != nil && !apierrors.IsAlreadyExists(err) {
+				return err
+			}
+			return nil
 		}
 		return err
 	}
+	if !ns.DeletionTimestamp.IsZero() {
+		// Namespace cleanup is still in progress; retry until it is gone and can be recreated
+		return fmt.Errorf("namespace %s is terminating", name)
+	}
 	return nil
 }
 
-func (r *UserReconciler) createOrUpdate(ctx context.Context, obj client.Object) error {
-	key := types.NamespacedName{Name: obj.GetName(), Namespace: obj.GetNamespace()}
-	existing := obj.DeepCopyObject().(client.Object)
-	err := r.Get(ctx, key, existing)
-	if apierrors.IsNotFound(err) {
-		return r.Create(ctx, obj)
-	} else if err != nil {
+// apply creates or updates obj with Server-Side Apply. obj holds the complete desired state of
+// the fields the controller owns: fields it applied before and leaves out are removed, fields
+// set by other managers are kept. Ownership is forced, the controller is authoritative for its fields.
+func (r *UserReconciler) apply(ctx context.Context, obj client.Object) error {
+	return applyTo(ctx, r.Client, obj)
+}
+
+// applyTo applies obj like apply, through c, which may be the client of a member cluster
+func applyTo(ctx context.Context, c client.Client, obj client.Object) error {
+	gvk, err := c.GroupVersionKindFor(obj)
+	if err != nil {
 		return err
 	}
-	obj.SetResourceVersion(existing.GetResourceVersion())
-	return r.Update(ctx, obj)
+	obj.GetObjectKind().SetGroupVersionKind(gvk)
+	obj.SetResourceVersion("")
+	obj.SetManagedFields(nil)
+	return c.Patch(ctx, obj, client.Apply, client.FieldOwner(FieldManager), client.ForceOwnership)
 }
 
 // cleanupUserResources deletes all resources related to the user.
@@ -271,48 +656,65 @@ func (r *UserReconciler) cleanupUserResources(ctx context.Context, user *authv1a
 	userNamespace := getKubeUserNamespace()
 
 	// Delete fixed resources
-	fixed := []client.Object{
-		&corev1.Secret{ObjectMeta: metav1.ObjectMeta{Name: fmt.Sprintf("%s-key", username), Namespace: userNamespace}},
-		&corev1.Secret{ObjectMeta: metav1.ObjectMeta{Name: fmt.Sprintf("%s-kubeconfig", username), Namespace: userNamespace}},
-		&certv1.CertificateSigningRequest{ObjectMeta: metav1.ObjectMeta{Name: fmt.Sprintf("%s-csr", username)}},
+	_ = r.Delete(ctx, &corev1.ServiceAccount{ObjectMeta: metav1.ObjectMeta{Name: username, Namespace: userNamespace}})
+	_ = r.deleteCredentials(ctx, user)
+	_ = r.deleteStaleCredentialAccess(ctx, username, types.NamespacedName{})
+	r.removeFromMemberClusters(ctx, user)
+	r.removeEKSAccess(ctx, user)
+	r.removeGCPAccess(ctx, user)
+	if err := inventory.Revoke(ctx, r.Client, username, authv1alpha1.RevocationReasonUserDeleted, time.Now()); err != nil {
+		logf.FromContext(ctx).Error(err, "Failed to revoke issued certificates of deleted user")
 	}
-	for _, obj := range fixed {
-		_ = r.Delete(ctx, obj)
+	if err := r.recordCredentialsRevoked(ctx, user, ""); err != nil {
+		logf.FromContext(ctx).Error(err, "Failed to record the deletion of the user")
 	}
+	r.notify(ctx, user, notify.EventDeleted, "", nil)
 
 	// Delete RoleBindings across namespaces
 	var rbs rbacv1.RoleBindingList
-	if err := r.List(ctx, &rbs, client.MatchingLabels{"auth.openkube.io/user": username}); err == nil {
+	if err := r.List(ctx, &rbs, client.MatchingFields{BindingUserIndex: username}); err == nil {
 		for _, rb := range rbs.Items {
-			_ = r.Delete(ctx, &rb)
+			if err := r.Delete(ctx, &rb); err == nil {
+				r.recordRemovedOnDeletion(ctx, user, &rb, rb.RoleRef)
+			}
 		}
 	}
 
 	// Delete ClusterRoleBindings
 	var crbs rbacv1.ClusterRoleBindingList
-	if err := r.List(ctx, &crbs, client.MatchingLabels{"auth.openkube.io/user": username}); err == nil {
+	if err := r.List(ctx, &crbs, client.MatchingFields{BindingUserIndex: username}); err == nil {
 		for _, crb := range crbs.Items {
-			_ = r.Delete(ctx, &crb)
+			if err := r.Delete(ctx, &crb); err == nil {
+				r.recordRemovedOnDeletion(ctx, user, &crb, crb.RoleRef)
+			}
 		}
 	}
 
 }
 
-// updateUserStatus calculates and updates the user status based on current state
-func (r *UserReconciler) updateUserStatus(ctx context.Context, user *authv1alpha1.User) error {
+// setUserStatus calculates the user status based on current state
+func (r *UserReconciler) setUserStatus(ctx context.Context, user *authv1alpha1.User) {
 	logger := logf.FromContext(ctx)
-	logger.Info("Updating user status", "name", user.Name)
 
 	// Check if user certificate has expired (only if ExpiryTime is set)
-	if user.Status.ExpiryTime != "" {
+	certificateValid := false
+	if user.Spec.Revoked {
+		user.Status.Phase = PhaseRevoked
+		user.Status.Message = revocationMessage(user)
+	} else if user.Spec.Suspended {
+		user.Status.Phase = PhaseSuspended
+		user.Status.Message = "User is suspended, all role bindings are removed"
+	} else if user.Status.ExpiryTime != "" {
 		if expiry, err := time.Parse(time.RFC3339, user.Status.ExpiryTime); err == nil {
 			if time.Now().After(expiry) {
 				user.Status.Phase = PhaseExpired
-				user.Status.Message = "User certificate has expired"
+				user.Status.Message = expiredMessage(user)
 				logger.Info("User certificate has expired", "expiry", user.Status.ExpiryTime)
 			} else {
-				// Certificate is still valid, set user as active
+				// Certificate is still valid, set user as active unless it expires soon
 				r.setActiveStatus(user)
+				r.setExpiryStatus(ctx, user, expiry, time.Now())
+				certificateValid = true
 			}
 		} else {
 			logger.Error(err, "Failed to parse expiry time", "expiryTime", user.Status.ExpiryTime)
@@ -323,63 +725,13 @@ func (r *UserReconciler) updateUserStatus(ctx context.Context, user *authv1alpha
 		// No expiry time set yet (certificate not issued), set user as active
 		r.setActiveStatus(user)
 	}
-
-	// Add condition for better status tracking
-	now := metav1.NewTime(time.Now())
-	conditionType := PhaseReady
-	conditionStatus := metav1.ConditionTrue
-	conditionReason := "UserProvisioned"
-	conditionMessage := user.Status.Message
-
-	switch user.Status.Phase {
-	case "Error":
-		conditionType = PhaseReady
-		conditionStatus = metav1.ConditionFalse
-		conditionReason = "ProvisioningFailed"
-	case "Expired":
-		conditionType = PhaseReady
-		conditionStatus = metav1.ConditionFalse
-		conditionReason = "CertificateExpired"
-	case "Pending":
-		conditionType = PhaseReady
-		conditionStatus = metav1.ConditionFalse
-		conditionReason = "Provisioning"
-	}
-
-	// Update or add condition
-	updatedConditions := []metav1.Condition{}
-	conditionFound := false
-	for _, condition := range user.Status.Conditions {
-		if condition.Type == conditionType {
-			condition.Status = conditionStatus
-			condition.Reason = conditionReason
-			condition.Message = conditionMessage
-			condition.LastTransitionTime = now
-			conditionFound = true
-		}
-		updatedConditions = append(updatedConditions, condition)
-	}
-
-	if !conditionFound {
-		newCondition := metav1.Condition{
-			Type:               conditionType,
-			Status:             conditionStatus,
-			Reason:             conditionReason,
-			Message:            conditionMessage,
-			LastTransitionTime: now,
-		}
-		updatedConditions = append(updatedConditions, newCondition)
-	}
-	user.Status.Conditions = updatedConditions
-
-	logger.Info("Updating status", "phase", user.Status.Phase, "expiry", user.Status.ExpiryTime, "message", user.Status.Message)
-	err := r.Status().Update(ctx, user)
-	if err != nil {
-		logger.Error(err, "Failed to update user status")
-		return err
+	if !certificateValid {
+		meta.RemoveStatusCondition(&user.Status.Conditions, ConditionExpiringSoon)
 	}
-	logger.Info("Successfully updated user status")
-	return nil
+
+	// The standard conditions follow from the phase and the rest of the status; updateStatus
+	// sets them on every write
+	logger.Info("Calculated status", "phase", user.Status.Phase, "expiry", user.Status.ExpiryTime, "message", user.Status.Message)
 }
 
 // setActiveStatus sets the user status to active based on role assignments
@@ -400,43 +752,85 @@ func (r *UserReconciler) setActiveStatus(user *authv1alpha1.User) {
 	}
 }
 
-// reconcileRoleBindings ensures the correct RoleBindings exist and removes outdated ones
-func (r *UserReconciler) reconcileRoleBindings(ctx context.Context, user *authv1alpha1.User) error {
+// reconcileRoleBindings ensures the correct RoleBindings exist and removes outdated ones.
+// When plan is non-nil the changes are only recorded in it (report-only mode). A grant that
+// cannot be bound does not hold up the others: it is recorded in status.bindings, its existing
+// binding is kept, and the failures are returned together at the end.
+func (r *UserReconciler) reconcileRoleBindings(ctx context.Context, user *authv1alpha1.User, plan *bindingPlan,
+	gate *policyGate) error {
 	username := user.Name
 	logger := logf.FromContext(ctx)
 
 	// Get all existing RoleBindings for this user
 	var existingRBs rbacv1.RoleBindingList
-	if err := r.List(ctx, &existingRBs, client.MatchingLabels{"auth.openkube.io/user": username}); err != nil {
+	if err := r.List(ctx, &existingRBs, client.MatchingFields{BindingUserIndex: username}); err != nil {
 		return fmt.Errorf("failed to list existing RoleBindings: %w", err)
 	}
 
+	// Create a map of existing RoleBindings (namespace/name) for easy lookup. Bindings named by an
+	// older scheme are not found under the new name, so they are replaced: the new one is created
+	// and the old one deleted below, without interrupting access.
+	existingRBMap := make(map[string]*rbacv1.RoleBinding)
+	for i := range existingRBs.Items {
+		rb := &existingRBs.Items[i]
+		existingRBMap[rb.Namespace+"/"+rb.Name] = rb
+	}
+
+	var errs []error
 	// Create a map of desired RoleBindings (namespace:role -> RoleSpec)
 	desiredRBs := make(map[string]authv1alpha1.RoleSpec)
-	for _, role := range user.Spec.Roles {
+	for _, role := range boundRoles(user, time.Now()) {
+		rbName := roleBindingName(username, role.ExistingRole)
+		fail := func(state authv1alpha1.BindingState, err error) {
+			recordBinding(user, "RoleBinding", role.Namespace, rbName,
+				rbacv1.RoleRef{Kind: "Role", Name: role.ExistingRole}, state, err)
+			delete(existingRBMap, role.Namespace+"/"+rbName)
+			errs = append(errs, err)
+		}
+		// Namespaces created together with the User may not exist yet
+		if role.CreateNamespace {
+			if err := ensureNamespace(ctx, r.Client, role.Namespace); err != nil {
+				fail(authv1alpha1.BindingStateFailed, fmt.Errorf("failed to ensure namespace %s: %w", role.Namespace, err))
+				continue
+			}
+		}
 		// Validate that the Role exists
 		var roleObj rbacv1.Role
 		if err := r.Get(ctx, types.NamespacedName{Name: role.ExistingRole, Namespace: role.Namespace}, &roleObj); err != nil {
 			if apierrors.IsNotFound(err) {
-				return fmt.Errorf("role %s not found in namespace %s", role.ExistingRole, role.Namespace)
+				fail(authv1alpha1.BindingStatePending, fmt.Errorf("role %s not found in namespace %s", role.ExistingRole, role.Namespace))
+				continue
 			}
-			return fmt.Errorf("failed to get role %s in namespace %s: %w", role.ExistingRole, role.Namespace, err)
+			fail(authv1alpha1.BindingStateFailed,
+				fmt.Errorf("failed to get role %s in namespace %s: %w", role.ExistingRole, role.Namespace, err))
+			continue
+		}
+		// Grants a ClusterPolicy refuses are left out, removing their bindings below
+		grant := policy.Grant{Name: role.ExistingRole, Namespace: role.Namespace, Labels: roleObj.Labels}
+		if len(gate.policies) > 0 {
+			var ns corev1.Namespace
+			if err := r.Get(ctx, types.NamespacedName{Name: role.Namespace}, &ns); err != nil {
+				fail(authv1alpha1.BindingStateFailed, fmt.Errorf("failed to get namespace %s: %w", role.Namespace, err))
+				continue
+			}
+			grant.NamespaceLabels = ns.Labels
+		}
+		allowed, err := gate.allows(grant)
+		if err != nil {
+			fail(authv1alpha1.BindingStateFailed, err)
+			continue
+		}
+		if !allowed {
+			continue
 		}
 		key := fmt.Sprintf("%s:%s", role.Namespace, role.ExistingRole)
 		desiredRBs[key] = role
 	}
 
-	// Create a map of existing RoleBindings for easy lookup
-	existingRBMap := make(map[string]*rbacv1.RoleBinding)
-	for i := range existingRBs.Items {
-		rb := &existingRBs.Items[i]
-		key := fmt.Sprintf("%s:%s", rb.Namespace, rb.RoleRef.Name)
-		existingRBMap[key] = rb
-	}
-
 	// Create or update desired RoleBindings
-	for key, roleSpec := range desiredRBs {
-		rbName := fmt.Sprintf("%s-%s-rb", username, roleSpec.ExistingRole)
+	for _, roleSpec := range desiredRBs {
+		rbName := roleBindingName(username, roleSpec.ExistingRole)
+		existingKey := roleSpec.Namespace + "/" + rbName
 		desiredRB := &rbacv1.RoleBinding{
 			ObjectMeta: metav1.ObjectMeta{
 				Name:      rbName,
@@ -450,10 +844,7 @@ func (r *UserReconciler) reconcileRoleBindings(ctx context.Context, user *authv1
 					Controller: &[]bool{true}[0],
 				}},
 			},
-			Subjects: []rbacv1.Subject{{
-				Kind: "User",
-				Name: username,
-			}},
+			Subjects: r.userSubjects(user),
 			RoleRef: rbacv1.RoleRef{
 				APIGroup: "rbac.authorization.k8s.io",
 				Kind:     "Role",
@@ -461,72 +852,114 @@ func (r *UserReconciler) reconcileRoleBindings(ctx context.Context, user *authv1
 			},
 		}
 
-		if existingRB, exists := existingRBMap[key]; exists {
+		state := authv1alpha1.BindingStateBound
+		if existingRB, exists := existingRBMap[existingKey]; exists {
 			// Update existing RoleBinding if it differs
 			if !roleBindingMatches(existingRB, desiredRB) {
 				logger.Info("Updating RoleBinding", "name", rbName, "namespace", roleSpec.Namespace)
-				desiredRB.ResourceVersion = existingRB.ResourceVersion
-				if err := r.Update(ctx, desiredRB); err != nil {
-					return fmt.Errorf("failed to update RoleBinding %s in namespace %s: %w", rbName, roleSpec.Namespace, err)
+				if err := r.applyBinding(ctx, user, plan, authv1alpha1.BindingActionUpdate, desiredRB, desiredRB.RoleRef); err != nil {
+					err = fmt.Errorf("failed to update RoleBinding %s in namespace %s: %w", rbName, roleSpec.Namespace, err)
+					recordBinding(user, "RoleBinding", roleSpec.Namespace, rbName, desiredRB.RoleRef, authv1alpha1.BindingStateFailed, err)
+					errs = append(errs, err)
+					delete(existingRBMap, existingKey)
+					continue
 				}
+				state = bindingStateApplied(plan)
 			}
 			// Remove from the map so we know it's been processed
-			delete(existingRBMap, key)
+			delete(existingRBMap, existingKey)
 		} else {
 			// Create new RoleBinding
 			logger.Info("Creating RoleBinding", "name", rbName, "namespace", roleSpec.Namespace)
-			if err := r.Create(ctx, desiredRB); err != nil {
-				return fmt.Errorf("failed to create RoleBinding %s in namespace %s: %w", rbName, roleSpec.Namespace, err)
+			if err := r.applyBinding(ctx, user, plan, authv1alpha1.BindingActionCreate, desiredRB, desiredRB.RoleRef); err != nil {
+				err = fmt.Errorf("failed to create RoleBinding %s in namespace %s: %w", rbName, roleSpec.Namespace, err)
+				recordBinding(user, "RoleBinding", roleSpec.Namespace, rbName, desiredRB.RoleRef, authv1alpha1.BindingStateFailed, err)
+				errs = append(errs, err)
+				continue
+			}
+			state = bindingStateApplied(plan)
+			if plan == nil {
+				r.event(user, corev1.EventTypeNormal, EventRoleBindingCreated, "Bound Role %s in namespace %s with RoleBinding %s",
+					roleSpec.ExistingRole, roleSpec.Namespace, rbName)
 			}
 		}
+		recordBinding(user, "RoleBinding", roleSpec.Namespace, rbName, desiredRB.RoleRef, state, nil)
 	}
 
 	// Delete any remaining RoleBindings (these are no longer desired)
 	for _, rb := range existingRBMap {
 		logger.Info("Deleting outdated RoleBinding", "name", rb.Name, "namespace", rb.Namespace)
-		if err := r.Delete(ctx, rb); err != nil && !apierrors.IsNotFound(err) {
-			return fmt.Errorf("failed to delete outdated RoleBinding %s in namespace %s: %w", rb.Name, rb.Namespace, err)
+		if err := r.applyBinding(ctx, user, plan, authv1alpha1.BindingActionDelete, rb, rb.RoleRef); err != nil && !apierrors.IsNotFound(err) {
+			errs = append(errs, fmt.Errorf("failed to delete outdated RoleBinding %s in namespace %s: %w", rb.Name, rb.Namespace, err))
+			continue
+		}
+		if plan == nil {
+			r.event(user, corev1.EventTypeNormal, EventRoleBindingDeleted, "Removed RoleBinding %s to Role %s in namespace %s",
+				rb.Name, rb.RoleRef.Name, rb.Namespace)
 		}
 	}
 
-	return nil
+	return errors.Join(errs...)
 }
 
-// reconcileClusterRoleBindings ensures the correct ClusterRoleBindings exist and removes outdated ones
-func (r *UserReconciler) reconcileClusterRoleBindings(ctx context.Context, user *authv1alpha1.User) error {
+// reconcileClusterRoleBindings ensures the correct ClusterRoleBindings exist and removes outdated ones.
+// When plan is non-nil the changes are only recorded in it (report-only mode). Grants that
+// cannot be bound are handled as in reconcileRoleBindings.
+func (r *UserReconciler) reconcileClusterRoleBindings(ctx context.Context, user *authv1alpha1.User, plan *bindingPlan,
+	gate *policyGate) error {
 	username := user.Name
 	logger := logf.FromContext(ctx)
 
 	// Get all existing ClusterRoleBindings for this user
 	var existingCRBs rbacv1.ClusterRoleBindingList
-	if err := r.List(ctx, &existingCRBs, client.MatchingLabels{"auth.openkube.io/user": username}); err != nil {
+	if err := r.List(ctx, &existingCRBs, client.MatchingFields{BindingUserIndex: username}); err != nil {
 		return fmt.Errorf("failed to list existing ClusterRoleBindings: %w", err)
 	}
 
+	// Create a map of existing ClusterRoleBindings (name) for easy lookup; as with RoleBindings,
+	// bindings named by an older scheme are replaced
+	existingCRBMap := make(map[string]*rbacv1.ClusterRoleBinding)
+	for i := range existingCRBs.Items {
+		crb := &existingCRBs.Items[i]
+		existingCRBMap[crb.Name] = crb
+	}
+
+	var errs []error
 	// Create a map of desired ClusterRoleBindings (clusterRole -> ClusterRoleSpec)
+	// Elevations past their end time are left out and their bindings removed below
 	desiredCRBs := make(map[string]authv1alpha1.ClusterRoleSpec)
-	for _, clusterRole := range user.Spec.ClusterRoles {
+	for _, clusterRole := range boundClusterRoles(user, time.Now()) {
+		crbName := clusterRoleBindingName(username, clusterRole.ExistingClusterRole)
+		fail := func(state authv1alpha1.BindingState, err error) {
+			recordBinding(user, "ClusterRoleBinding", "", crbName,
+				rbacv1.RoleRef{Kind: "ClusterRole", Name: clusterRole.ExistingClusterRole}, state, err)
+			delete(existingCRBMap, crbName)
+			errs = append(errs, err)
+		}
 		// Validate that the ClusterRole exists
 		var crObj rbacv1.ClusterRole
 		if err := r.Get(ctx, types.NamespacedName{Name: clusterRole.ExistingClusterRole}, &crObj); err != nil {
 			if apierrors.IsNotFound(err) {
-				return fmt.Errorf("clusterrole %s not found", clusterRole.ExistingClusterRole)
+				fail(authv1alpha1.BindingStatePending, fmt.Errorf("clusterrole %s not found", clusterRole.ExistingClusterRole))
+				continue
 			}
-			return fmt.Errorf("failed to get clusterrole %s: %w", clusterRole.ExistingClusterRole, err)
+			fail(authv1alpha1.BindingStateFailed, fmt.Errorf("failed to get clusterrole %s: %w", clusterRole.ExistingClusterRole, err))
+			continue
+		}
+		allowed, err := gate.allows(policy.Grant{ClusterRole: true, Name: clusterRole.ExistingClusterRole, Labels: crObj.Labels})
+		if err != nil {
+			fail(authv1alpha1.BindingStateFailed, err)
+			continue
+		}
+		if !allowed {
+			continue
 		}
 		desiredCRBs[clusterRole.ExistingClusterRole] = clusterRole
 	}
 
-	// Create a map of existing ClusterRoleBindings for easy lookup
-	existingCRBMap := make(map[string]*rbacv1.ClusterRoleBinding)
-	for i := range existingCRBs.Items {
-		crb := &existingCRBs.Items[i]
-		existingCRBMap[crb.RoleRef.Name] = crb
-	}
-
 	// Create or update desired ClusterRoleBindings
 	for clusterRoleName, clusterRoleSpec := range desiredCRBs {
-		crbName := fmt.Sprintf("%s-%s-crb", username, clusterRoleName)
+		crbName := clusterRoleBindingName(username, clusterRoleName)
 		desiredCRB := &rbacv1.ClusterRoleBinding{
 			ObjectMeta: metav1.ObjectMeta{
 				Name:   crbName,
@@ -539,10 +972,7 @@ func (r *UserReconciler) reconcileClusterRoleBindings(ctx context.Context, user
 					Controller: &[]bool{true}[0],
 				}},
 			},
-			Subjects: []rbacv1.Subject{{
-				Kind: "User",
-				Name: username,
-			}},
+			Subjects: r.userSubjects(user),
 			RoleRef: rbacv1.RoleRef{
 				APIGroup: "rbac.authorization.k8s.io",
 				Kind:     "ClusterRole",
@@ -550,421 +980,554 @@ func (r *UserReconciler) reconcileClusterRoleBindings(ctx context.Context, user
 			},
 		}
 
-		if existingCRB, exists := existingCRBMap[clusterRoleName]; exists {
+		// Bindings named by an older scheme are replaced, see reconcileRoleBindings
+		state := authv1alpha1.BindingStateBound
+		if existingCRB, exists := existingCRBMap[crbName]; exists {
 			// Update existing ClusterRoleBinding if it differs
 			if !clusterRoleBindingMatches(existingCRB, desiredCRB) {
 				logger.Info("Updating ClusterRoleBinding", "name", crbName)
-				desiredCRB.ResourceVersion = existingCRB.ResourceVersion
-				if err := r.Update(ctx, desiredCRB); err != nil {
-					return fmt.Errorf("failed to update ClusterRoleBinding %s: %w", crbName, err)
+				if err := r.applyBinding(ctx, user, plan, authv1alpha1.BindingActionUpdate, desiredCRB, desiredCRB.RoleRef); err != nil {
+					err = fmt.Errorf("failed to update ClusterRoleBinding %s: %w", crbName, err)
+					recordBinding(user, "ClusterRoleBinding", "", crbName, desiredCRB.RoleRef, authv1alpha1.BindingStateFailed, err)
+					errs = append(errs, err)
+					delete(existingCRBMap, crbName)
+					continue
 				}
+				state = bindingStateApplied(plan)
 			}
 			// Remove from the map so we know it's been processed
-			delete(existingCRBMap, clusterRoleName)
+			delete(existingCRBMap, crbName)
 		} else {
 			// Create new ClusterRoleBinding
 			logger.Info("Creating ClusterRoleBinding", "name", crbName)
-			if err := r.Create(ctx, desiredCRB); err != nil {
-				return fmt.Errorf("failed to create ClusterRoleBinding %s: %w", crbName, err)
+			if err := r.applyBinding(ctx, user, plan, authv1alpha1.BindingActionCreate, desiredCRB, desiredCRB.RoleRef); err != nil {
+				err = fmt.Errorf("failed to create ClusterRoleBinding %s: %w", crbName, err)
+				recordBinding(user, "ClusterRoleBinding", "", crbName, desiredCRB.RoleRef, authv1alpha1.BindingStateFailed, err)
+				errs = append(errs, err)
+				continue
+			}
+			state = bindingStateApplied(plan)
+			if plan == nil {
+				r.event(user, corev1.EventTypeNormal, EventClusterRoleBindingCreated, "Bound ClusterRole %s with ClusterRoleBinding %s",
+					clusterRoleName, crbName)
 			}
 		}
+		recordBinding(user, "ClusterRoleBinding", "", crbName, desiredCRB.RoleRef, state, nil)
 	}
 
 	// Delete any remaining ClusterRoleBindings (these are no longer desired)
 	for _, crb := range existingCRBMap {
 		logger.Info("Deleting outdated ClusterRoleBinding", "name", crb.Name)
-		if err := r.Delete(ctx, crb); err != nil && !apierrors.IsNotFound(err) {
-			return fmt.Errorf("failed to delete outdated ClusterRoleBinding %s: %w", crb.Name, err)
+		if err := r.applyBinding(ctx, user, plan, authv1alpha1.BindingActionDelete, crb, crb.RoleRef); err != nil && !apierrors.IsNotFound(err) {
+			errs = append(errs, fmt.Errorf("failed to delete outdated ClusterRoleBinding %s: %w", crb.Name, err))
+			continue
+		}
+		if plan == nil {
+			r.event(user, corev1.EventTypeNormal, EventClusterRoleBindingDeleted, "Removed ClusterRoleBinding %s to ClusterRole %s",
+				crb.Name, crb.RoleRef.Name)
+			if endedElevation(user, crb.RoleRef.Name, time.Now()) {
+				r.notify(ctx, user, notify.EventElevationExpired, "", map[string]string{"clusterRole": crb.RoleRef.Name})
+			}
 		}
 	}
 
-	return nil
+	return errors.Join(errs...)
+}
+
+// Generated names stay within the Kubernetes length limits. Bindings always carry a hash of user
+// and role so users with similar names cannot end up with the same binding; the per-user Secrets
+// and the CSR only need one when the user name is too long for the suffix.
+
+func roleBindingName(username, role string) string {
+	return naming.Join(naming.MaxLabelLength, "rb", username, role)
+}
+
+func clusterRoleBindingName(username, clusterRole string) string {
+	return naming.Join(naming.MaxLabelLength, "crb", username, clusterRole)
+}
+
+func userKeySecretName(username string) string {
+	return naming.Suffixed(naming.MaxNameLength, username, "key")
+}
+
+func userKubeconfigSecretName(username string) string {
+	return naming.Suffixed(naming.MaxNameLength, username, "kubeconfig")
+}
+
+func userCSRName(username string) string {
+	return naming.Suffixed(naming.MaxNameLength, username, "csr")
 }
 
 // roleBindingMatches checks if two RoleBindings are functionally equivalent
 func roleBindingMatches(existing, desired *rbacv1.RoleBinding) bool {
 	// Check if RoleRef matches
-	if existing.RoleRef != desired.RoleRef {
+	if existing.RoleRef != desired.RoleRef || !bindingMetadataMatches(existing, desired) {
 		return false
 	}
 
-	// Check if subjects match (we expect exactly one subject)
-	if len(existing.Subjects) != 1 || len(desired.Subjects) != 1 {
-		return false
-	}
-
-	return existing.Subjects[0].Kind == desired.Subjects[0].Kind &&
-		existing.Subjects[0].Name == desired.Subjects[0].Name
+	return subjectsMatch(existing.Subjects, desired.Subjects)
 }
 
 // clusterRoleBindingMatches checks if two ClusterRoleBindings are functionally equivalent
 func clusterRoleBindingMatches(existing, desired *rbacv1.ClusterRoleBinding) bool {
 	// Check if RoleRef matches
-	if existing.RoleRef != desired.RoleRef {
-		return false
-	}
-
-	// Check if subjects match (we expect exactly one subject)
-	if len(existing.Subjects) != 1 || len(desired.Subjects) != 1 {
+	if existing.RoleRef != desired.RoleRef || !bindingMetadataMatches(existing, desired) {
 		return false
 	}
 
-	return existing.Subjects[0].Kind == desired.Subjects[0].Kind &&
-		existing.Subjects[0].Name == desired.Subjects[0].Name
+	return subjectsMatch(existing.Subjects, desired.Subjects)
 }
 
 // === Certificate helpers ===
 
 func (r *UserReconciler) ensureCertKubeconfig(ctx context.Context, user *authv1alpha1.User) (bool, error) {
 	username := user.Name
+	// identity is the name the certificate is issued for, following the username template
+	identity := operatorconfig.Current().Username(username)
 	userNamespace := getKubeUserNamespace()
-	keySecretName := fmt.Sprintf("%s-key", username)
-	cfgSecretName := fmt.Sprintf("%s-kubeconfig", username)
-	csrName := fmt.Sprintf("%s-csr", username)
+	keySecretName := userKeySecretName(username)
+	cfgSecret := credentialSecretKey(user)
+	csrName := userCSRName(username)
 
-	// Check if certificate needs rotation (30 days before expiry)
-	rotationThreshold := 30 * 24 * time.Hour
-	needsRotation, err := r.checkCertificateRotation(ctx, cfgSecretName, rotationThreshold)
+	// Follow spec.output.secretRef to its current location
+	if err := r.moveCredentialSecret(ctx, user, cfgSecret); err != nil {
+		return false, err
+	}
+
+	// Check if certificate needs rotation
+	duration, rotation, keyAlgorithm := r.certificateSettings(ctx, user)
+	if user.Spec.BreakGlass {
+		// Break-glass certificates expire with the access; they are never renewed
+		rotation = rotationPolicy{}
+	}
+	needsRotation, err := r.checkCertificateRotation(ctx, cfgSecret, username, rotation)
 	if err != nil {
 		return false, fmt.Errorf("failed to check certificate rotation: %w", err)
 	}
 
-	if needsRotation {
+	if needsRotation || renewRequested(user) {
 		// Clean up existing resources for rotation
 		logger := logf.FromContext(ctx)
-		logger.Info("Certificate needs rotation, cleaning up existing resources", "user", username)
-		if err := r.cleanupCertificateResources(ctx, cfgSecretName, csrName); err != nil {
+		logger.Info("Certificate needs rotation, cleaning up existing resources", "user", username,
+			"renewRequested", !needsRotation)
+		if err := r.cleanupCertificateResources(ctx, cfgSecret, username, csrName); err != nil {
 			return false, fmt.Errorf("failed to cleanup certificate resources: %w", err)
 		}
+		certificateRotations.Inc()
+		if needsRotation {
+			r.event(user, corev1.EventTypeNormal, EventCertificateRotated,
+				"Certificate is within %s of expiry, requesting a new one", rotation)
+		} else {
+			r.event(user, corev1.EventTypeNormal, EventCertificateRotated,
+				"Renewal requested through the %s annotation, requesting a new certificate", authv1alpha1.RenewAnnotation)
+		}
+		if newKeyRequested(user) && user.Spec.CSR == "" {
+			// ensureUserKey below generates a new one
+			keySecret := &corev1.Secret{ObjectMeta: metav1.ObjectMeta{Name: keySecretName, Namespace: userNamespace}}
+			if err := r.Delete(ctx, keySecret); err != nil && !apierrors.IsNotFound(err) {
+				return false, fmt.Errorf("failed to delete private key secret: %w", err)
+			}
+		}
+		// The old credentials are gone, so a failure from here on still ends in a new certificate
+		if err := r.clearRenewRequest(ctx, user); err != nil {
+			return false, err
+		}
 	}
 
-	// 1. Load/create key Secret
-	var keySecret corev1.Secret
-	err = r.Get(ctx, types.NamespacedName{Name: keySecretName, Namespace: userNamespace}, &keySecret)
+	// 1. The key the certificate is for: the user's own behind spec.csr, or a key held by the controller
 	var keyPEM []byte
-	if apierrors.IsNotFound(err) {
-		key, err := rsa.GenerateKey(rand.Reader, 2048)
+	var publicKey crypto.PublicKey
+	if user.Spec.CSR != "" {
+		csr, err := issuer.ParseCSR([]byte(user.Spec.CSR), identity)
 		if err != nil {
-			return false, err
+			return false, fmt.Errorf("invalid spec.csr: %w", err)
 		}
-		keyPEM = pem.EncodeToMemory(&pem.Block{Type: "RSA PRIVATE KEY", Bytes: x509.MarshalPKCS1PrivateKey(key)})
-		keySecret = corev1.Secret{
-			ObjectMeta: metav1.ObjectMeta{Name: keySecretName, Namespace: userNamespace},
-			Type:       corev1.SecretTypeOpaque,
-			Data:       map[string][]byte{"key.pem": keyPEM},
-		}
-		if err := r.Create(ctx, &keySecret); err != nil {
+		publicKey = csr.PublicKey
+	} else {
+		key, pemData, err := r.ensureUserKey(ctx, keySecretName, user, keyAlgorithm)
+		if err != nil {
 			return false, err
 		}
-	} else if err != nil {
-		return false, err
-	} else {
-		keyPEM = keySecret.Data["key.pem"]
+		keyPEM = pemData
+		publicKey = key.Public()
 	}
 
-	// 2. If kubeconfig already exists, return
-	var existingCfg corev1.Secret
-	if err := r.Get(ctx, types.NamespacedName{Name: cfgSecretName, Namespace: userNamespace}, &existingCfg); err == nil {
-		return false, nil
+	// 2. If the credential secret already exists, only re-render it when the layout, contexts,
+	// cluster endpoint or encryption recipients changed
+	layout := r.credentialLayout(user)
+	contexts := userKubeconfigContexts(user)
+	cluster, err := r.kubeconfigCluster(ctx, user)
+	if err != nil {
+		return false, err
 	}
-
-	// 3. CSR from key
-	csrPEM, err := csrFromKey(username, keyPEM)
+	recipients, err := userCredentialRecipients(user)
 	if err != nil {
 		return false, err
 	}
-
-	// 4. Create/get CSR
-	var csr certv1.CertificateSigningRequest
-	err = r.Get(ctx, types.NamespacedName{Name: csrName}, &csr)
-	if apierrors.IsNotFound(err) {
-		csr = certv1.CertificateSigningRequest{
-			ObjectMeta: metav1.ObjectMeta{Name: csrName, Labels: map[string]string{"auth.openkube.io/user": username}},
-			Spec: certv1.CertificateSigningRequestSpec{
-				Request:    csrPEM,
-				Usages:     []certv1.KeyUsage{certv1.UsageClientAuth},
-				SignerName: certv1.KubeAPIServerClientSignerName,
-			},
+	existingCfg, err := r.getCredentialSecret(ctx, cfgSecret, username)
+	if err != nil && !apierrors.IsNotFound(err) {
+		return false, err
+	} else if err == nil {
+		cert, certErr := secretLayout(existingCfg).ClientCertificate(existingCfg.Data)
+		if certErr == nil && cert != nil {
+			_, certErr = issuer.ParseCertificate(cert)
 		}
-		if err := r.Create(ctx, &csr); err != nil {
-			return false, err
+		usable := certErr == nil && cert != nil
+		if usable {
+			// Bound alongside identity until a certificate for it is issued
+			user.Status.Username = certificateCommonName(cert)
 		}
-		return true, nil
-	} else if err != nil {
-		return false, err
-	}
-
-	// 5. Approve CSR if not approved
-	approved := false
-	for _, c := range csr.Status.Conditions {
-		if c.Type == certv1.CertificateApproved && c.Status == corev1.ConditionTrue {
-			approved = true
+		switch {
+		case !usable:
+			// Whatever the annotations say, there is nothing to re-render from
+			logf.FromContext(ctx).Info("Credential secret holds no usable certificate, requesting a new one",
+				"secret", cfgSecret, "error", certErr)
+			if err := r.cleanupCertificateResources(ctx, cfgSecret, username, csrName); err != nil {
+				return false, fmt.Errorf("failed to cleanup certificate resources: %w", err)
+			}
+		case !certificateMatchesKey(cert, publicKey):
+			// spec.csr was set, changed or removed
+			logf.FromContext(ctx).Info("Certificate does not match the user's key, requesting a new one")
+			if err := r.cleanupCertificateResources(ctx, cfgSecret, username, csrName); err != nil {
+				return false, fmt.Errorf("failed to cleanup certificate resources: %w", err)
+			}
+		case user.Status.Username != identity:
+			logf.FromContext(ctx).Info("Username template changed, requesting a certificate for the new username",
+				"previous", user.Status.Username, "username", identity)
+			if err := r.cleanupCertificateResources(ctx, cfgSecret, username, csrName); err != nil {
+				return false, fmt.Errorf("failed to cleanup certificate resources: %w", err)
+			}
+		case existingCfg.Annotations[certificateSubjectAnnotation] != subjectString(user.Spec.Certificate):
+			logf.FromContext(ctx).Info("spec.certificate changed, requesting a new certificate",
+				"subject", subjectString(user.Spec.Certificate))
+			if err := r.cleanupCertificateResources(ctx, cfgSecret, username, csrName); err != nil {
+				return false, fmt.Errorf("failed to cleanup certificate resources: %w", err)
+			}
+		case existingCfg.Annotations[credentialLayoutAnnotation] == layout.String() &&
+			existingCfg.Annotations[kubeconfigContextsAnnotation] == contexts.String() &&
+			existingCfg.Annotations[kubeconfigClusterAnnotation] == cluster.String() &&
+			existingCfg.Annotations[credentialRecipientsAnnotation] == recipients.String():
+			return false, nil
+		default:
+			logf.FromContext(ctx).Info("Credential layout, contexts, cluster or recipients changed, re-rendering secret",
+				"layout", layout.String(), "contexts", contexts.String(), "cluster", cluster.String(),
+				"recipients", recipients.String())
+			return false, r.writeCredentialSecret(ctx, cfgSecret, user, layout, contexts, cluster, recipients,
+				cert, keyPEM, rotatedAt(existingCfg))
 		}
+		// No certificate to re-render from; issue a new one below
 	}
-	if !approved {
-		csr.Status.Conditions = append(csr.Status.Conditions, certv1.CertificateSigningRequestCondition{
-			Type:           certv1.CertificateApproved,
-			Status:         corev1.ConditionTrue,
-			Reason:         "AutoApproved",
-			Message:        "Approved by kubeuser-operator",
-			LastUpdateTime: metav1.Now(),
-		})
-		if err := r.SubResource("approval").Update(ctx, &csr); err != nil {
-			return false, err
+
+	// 3. The CSR: the user's own, or one for the controller-held key
+	var csrPEM []byte
+	if user.Spec.CSR != "" {
+		csrPEM = []byte(user.Spec.CSR)
+		// The user holds the key; drop one generated before they brought their own CSR
+		keySecret := &corev1.Secret{ObjectMeta: metav1.ObjectMeta{Name: keySecretName, Namespace: userNamespace}}
+		if err := r.Delete(ctx, keySecret); err != nil && !apierrors.IsNotFound(err) {
+			return false, fmt.Errorf("failed to delete private key secret: %w", err)
 		}
-		return true, nil
+	} else if csrPEM, err = csrFromKey(identity, keyPEM, user.Spec.Certificate, r.csrURIs(username)...); err != nil {
+		return false, err
 	}
 
-	// 6. Wait for cert
-	if len(csr.Status.Certificate) == 0 {
+	// 4. Have the issuer sign it; the CSR API takes a few reconciles
+	signCtx, span := tracing.Start(ctx, "Certificate.Issue", attribute.String("csr", csrName),
+		attribute.String("issuer", fmt.Sprintf("%T", r.certIssuer())))
+	cert, err := r.certIssuer().Sign(signCtx, issuer.Request{
+		Name:     csrName,
+		Username: identity,
+		User:     username,
+		CSR:      csrPEM,
+		Duration: breakGlassCertificateDuration(user, duration, time.Now()),
+		Labels:   map[string]string{"auth.openkube.io/user": username},
+	})
+	if errors.Is(err, issuer.ErrPending) {
+		span.SetAttributes(attribute.Bool("pending", true))
+		span.End()
 		return true, nil
+	} else if err != nil {
+		tracing.End(span, err)
+		return false, err
 	}
-	signedCert := csr.Status.Certificate
+	csrIssuanceDuration.Observe(time.Since(cert.RequestedAt).Seconds())
+	span.SetAttributes(attribute.String("issuedAfter", time.Since(cert.RequestedAt).String()))
+	span.End()
+	logf.FromContext(ctx).Info("Certificate issued", "expiry", cert.NotAfter)
 
-	// 7. Cluster CA
-	caDataB64, err := r.getClusterCABase64(ctx)
-	if err != nil {
+	// 5. Add it to the inventory while the issuer still returns it, so a failure is retried
+	if err := inventory.Record(ctx, r.Client, username, csrName, cert.PEM, time.Now()); err != nil {
 		return false, err
 	}
-
-	// 8. API server URL
-	apiServer := os.Getenv("KUBERNETES_API_SERVER")
-	if apiServer == "" {
-		apiServer = "https://kubernetes.default.svc"
+	if err := r.recordCredentialIssued(ctx, user, cert.PEM); err != nil {
+		return false, err
 	}
 
-	// 9. Kubeconfig
-	kcfg := buildCertKubeconfig(apiServer, caDataB64,
-		base64.StdEncoding.EncodeToString(signedCert),
-		base64.StdEncoding.EncodeToString(keyPEM),
-		username)
-
-	// 9.5. Extract certificate expiry time
-	logger := logf.FromContext(ctx)
-	logger.Info("Extracting certificate expiry", "certLength", len(signedCert))
-	logger.Info("Certificate data preview", "first20bytes", string(signedCert[:min(20, len(signedCert))]))
+	// 6. Record the actual certificate expiry in the user status
+	firstIssue := user.Status.ExpiryTime == ""
+	user.Status.ExpiryTime = cert.NotAfter.Format(time.RFC3339)
+	user.Status.CertificateExpiry = "Certificate"
+	user.Status.Username = identity
+	user.Status.CredentialSecret = &corev1.SecretReference{Name: cfgSecret.Name, Namespace: cfgSecret.Namespace}
 
-	// Try to extract certificate expiry with proper format detection
-	certExpiryTime, err := r.extractCertificateExpiryWithFormatDetection(signedCert)
-	if err != nil {
-		return false, fmt.Errorf("failed to extract certificate expiry: %w", err)
+	// 7. Save credentials
+	if err := r.writeCredentialSecret(ctx, cfgSecret, user, layout, contexts, cluster, recipients,
+		cert.PEM, keyPEM, time.Now()); err != nil {
+		return false, err
+	}
+	r.event(user, corev1.EventTypeNormal, EventCertificateIssued,
+		"Issued client certificate valid until %s, credentials in Secret %s", user.Status.ExpiryTime, cfgSecret)
+	if firstIssue {
+		kubeconfig, err := buildCertKubeconfig(cluster, cert.PEM, keyPEM, username, contexts)
+		if err != nil {
+			return false, fmt.Errorf("failed to render kubeconfig: %w", err)
+		}
+		r.notifyProvisioned(ctx, user, cfgSecret, kubeconfig)
+	} else {
+		r.notify(ctx, user, notify.EventRotated, "", map[string]string{"secret": cfgSecret.String()})
 	}
-	logger.Info("Successfully extracted certificate expiry", "expiry", certExpiryTime)
+	return false, nil
+}
 
-	// Update user status with actual certificate expiry
-	user.Status.ExpiryTime = certExpiryTime.Format(time.RFC3339)
-	user.Status.CertificateExpiry = "Certificate"
-	if err := r.Status().Update(ctx, user); err != nil {
-		return false, fmt.Errorf("failed to update user status with certificate expiry: %w", err)
+// ensureUserKey loads the user's private key from its Secret, generating it on first use with
+// the given key algorithm. With a KeyProtector the Secret holds it encrypted.
+func (r *UserReconciler) ensureUserKey(ctx context.Context, name string, user *authv1alpha1.User,
+	algorithm authv1alpha1.KeyAlgorithm) (crypto.Signer, []byte, error) {
+	var keySecret corev1.Secret
+	err := r.Get(ctx, types.NamespacedName{Name: name, Namespace: getKubeUserNamespace()}, &keySecret)
+	if err == nil {
+		keyPEM, err := r.readUserKey(ctx, &keySecret, user)
+		if err != nil {
+			return nil, nil, err
+		}
+		key, err := parsePrivateKey(keyPEM)
+		return key, keyPEM, err
+	} else if !apierrors.IsNotFound(err) {
+		return nil, nil, err
 	}
 
-	// 10. Save kubeconfig
-	cfgSecret := &corev1.Secret{
-		ObjectMeta: metav1.ObjectMeta{Name: cfgSecretName, Namespace: userNamespace},
-		Type:       corev1.SecretTypeOpaque,
-		Data:       map[string][]byte{"config": kcfg},
+	key, err := generatePrivateKey(algorithm)
+	if err != nil {
+		return nil, nil, err
+	}
+	keyPEM, err := encodePrivateKey(key)
+	if err != nil {
+		return nil, nil, err
+	}
+	secret := userSecret(types.NamespacedName{Name: name, Namespace: getKubeUserNamespace()}, user,
+		PrivateKeySecretType, nil)
+	setRotatedAt(secret, time.Now())
+	if err := r.protectUserKey(ctx, secret, user.Name, keyPEM); err != nil {
+		return nil, nil, err
 	}
-	return false, r.createOrUpdate(ctx, cfgSecret)
+	if err := r.Create(ctx, secret); err != nil {
+		return nil, nil, err
+	}
+	return key, keyPEM, nil
 }
 
-func csrFromKey(username string, keyPEM []byte) ([]byte, error) {
-	block, _ := pem.Decode(keyPEM)
+// certificateMatchesKey reports whether the PEM certificate was issued for publicKey
+func certificateMatchesKey(certPEM []byte, publicKey crypto.PublicKey) bool {
+	block, _ := pem.Decode(certPEM)
 	if block == nil {
-		return nil, errors.New("decode key failed")
+		return false
+	}
+	cert, err := x509.ParseCertificate(block.Bytes)
+	if err != nil {
+		return false
+	}
+	pub, ok := cert.PublicKey.(interface{ Equal(crypto.PublicKey) bool })
+	return ok && pub.Equal(publicKey)
+}
+
+// csrFromKey creates a CSR for username with the key in keyPEM, the attributes and SANs of
+// subject and the URI SANs uris
+func csrFromKey(username string, keyPEM []byte, subject *authv1alpha1.CertificateSubject, uris ...*url.URL) ([]byte, error) {
+	key, err := parsePrivateKey(keyPEM)
+	if err != nil {
+		return nil, err
 	}
-	key, err := x509.ParsePKCS1PrivateKey(block.Bytes)
+	template, err := csrTemplate(username, subject, uris)
 	if err != nil {
 		return nil, err
 	}
-	csrTemplate := x509.CertificateRequest{Subject: pkix.Name{CommonName: username}}
-	csrDER, err := x509.CreateCertificateRequest(rand.Reader, &csrTemplate, key)
+	csrDER, err := x509.CreateCertificateRequest(rand.Reader, template, key)
 	if err != nil {
 		return nil, err
 	}
 	return pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE REQUEST", Bytes: csrDER}), nil
 }
 
-func (r *UserReconciler) getClusterCABase64(ctx context.Context) (string, error) {
-	if data, err := os.ReadFile(filepath.Clean(inClusterCAPath)); err == nil && len(data) > 0 {
-		return base64.StdEncoding.EncodeToString(data), nil
+func (r *UserReconciler) getClusterCA(ctx context.Context) ([]byte, error) {
+	resolver := r.CAResolver
+	if resolver == nil {
+		resolver = ca.NewResolver(r.Client, nil)
 	}
-	var cm corev1.ConfigMap
-	if err := r.Get(ctx, types.NamespacedName{Namespace: "default", Name: "kube-root-ca.crt"}, &cm); err == nil {
-		if crt, ok := cm.Data["ca.crt"]; ok {
-			return base64.StdEncoding.EncodeToString([]byte(crt)), nil
-		}
+	data, src, err := resolver.Resolve(ctx)
+	if err != nil {
+		return nil, err
+	}
+	if err := r.setOperatorStatus(ctx, operatorstatus.KeyCASource, src.String()); err != nil {
+		logf.FromContext(ctx).Error(err, "Failed to report active CA source", "source", src.String())
 	}
-	return "", errors.New("CA not found")
+	return data, nil
 }
 
-func buildCertKubeconfig(apiServer, caDataB64, certDataB64, keyDataB64, username string) []byte {
-	return []byte(fmt.Sprintf(`apiVersion: v1
-kind: Config
-clusters:
-- cluster:
-    certificate-authority-data: %s
-    server: %s
-  name: cluster
-contexts:
-- context:
-    cluster: cluster
-    namespace: default
-    user: %s
-  name: %s@cluster
-current-context: %s@cluster
-users:
-- name: %s
-  user:
-    client-certificate-data: %s
-    client-key-data: %s
-`, caDataB64, apiServer, username, username, username, username, certDataB64, keyDataB64))
+// kubeconfigContexts are the contexts of a user's kubeconfig: the current context in
+// DefaultNamespace, and one per namespace the user has a Role in
+type kubeconfigContexts struct {
+	DefaultNamespace string
+	Namespaces       []string
+	// Exec makes the user entry run the credential helper instead of embedding the
+	// certificate and key
+	Exec bool
 }
 
-// extractCertificateExpiryWithFormatDetection tries multiple formats to extract certificate expiry
-func (r *UserReconciler) extractCertificateExpiryWithFormatDetection(certData []byte) (time.Time, error) {
-	// Method 1: Try as base64-encoded PEM (most likely)
-	if certTime, err := r.tryBase64PEM(certData); err == nil {
-		return certTime, nil
-	}
-
-	// Method 2: Try as raw PEM (less likely)
-	if certTime, err := r.tryRawPEM(certData); err == nil {
-		return certTime, nil
-	}
-
-	// Method 3: Try as raw DER (least likely)
-	if certTime, err := r.tryRawDER(certData); err == nil {
-		return certTime, nil
+// userKubeconfigContexts derives the kubeconfig contexts from the user's spec
+func userKubeconfigContexts(user *authv1alpha1.User) kubeconfigContexts {
+	var contexts kubeconfigContexts
+	for _, role := range user.Spec.Roles {
+		if !containsString(contexts.Namespaces, role.Namespace) {
+			contexts.Namespaces = append(contexts.Namespaces, role.Namespace)
+		}
 	}
-
-	return time.Time{}, errors.New("unable to parse certificate in any known format")
+	switch {
+	case user.Spec.DefaultNamespace != "":
+		contexts.DefaultNamespace = user.Spec.DefaultNamespace
+	case len(contexts.Namespaces) > 0:
+		contexts.DefaultNamespace = contexts.Namespaces[0]
+	default:
+		contexts.DefaultNamespace = "default"
+	}
+	sort.Strings(contexts.Namespaces)
+	contexts.Exec = user.Spec.Output != nil && user.Spec.Output.Format == authv1alpha1.KubeconfigFormatExecCredential
+	return contexts
 }
 
-// tryBase64PEM tries to parse as base64-encoded PEM
-func (r *UserReconciler) tryBase64PEM(certData []byte) (time.Time, error) {
-	// Decode base64
-	certPEM, err := base64.StdEncoding.DecodeString(string(certData))
-	if err != nil {
-		return time.Time{}, fmt.Errorf("base64 decode failed: %w", err)
-	}
-
-	// Decode PEM to get DER
-	block, _ := pem.Decode(certPEM)
-	if block == nil {
-		return time.Time{}, errors.New("PEM decode failed")
+// String is recorded on the credential Secret to detect when the contexts changed
+func (c kubeconfigContexts) String() string {
+	s := "default=" + c.DefaultNamespace + ";namespaces=" + strings.Join(c.Namespaces, ",")
+	if c.Exec {
+		s += ";exec"
 	}
+	return s
+}
 
-	// Parse certificate
-	cert, err := x509.ParseCertificate(block.Bytes)
-	if err != nil {
-		return time.Time{}, fmt.Errorf("certificate parse failed: %w", err)
-	}
+// buildCertKubeconfig renders the user's kubeconfig. The current context <user>@cluster uses the
+// default namespace, and <user>@<namespace> switches to each granted namespace. Without key data
+// it is a stub for users who hold their own key, to be completed with kubectl config
+// set-credentials --client-key. With contexts.Exec it embeds neither and runs the credential
+// helper instead.
+func buildCertKubeconfig(cluster kubeconfigCluster, certPEM, keyPEM []byte, username string,
+	contexts kubeconfigContexts) ([]byte, error) {
+	if contexts.Exec {
+		return buildKubeconfig(cluster, username, contexts, execCredentialAuth(username))
+	}
+	return buildKubeconfig(cluster, username, contexts, &clientcmdapi.AuthInfo{
+		ClientCertificateData: certPEM,
+		ClientKeyData:         keyPEM,
+	})
+}
 
-	return cert.NotAfter, nil
+// buildTokenKubeconfig renders the kubeconfig of a machine user, with the same contexts as
+// buildCertKubeconfig and its ServiceAccount token as credential
+func buildTokenKubeconfig(cluster kubeconfigCluster, token, username string, contexts kubeconfigContexts) ([]byte, error) {
+	return buildKubeconfig(cluster, username, contexts, &clientcmdapi.AuthInfo{Token: token})
 }
 
-// tryRawPEM tries to parse as raw PEM data
-func (r *UserReconciler) tryRawPEM(certData []byte) (time.Time, error) {
-	// Decode PEM to get DER
-	block, _ := pem.Decode(certData)
-	if block == nil {
-		return time.Time{}, errors.New("PEM decode failed")
+// execCredentialAuth is the user entry of execCredential kubeconfigs. kubectl runs the
+// kubectl-kubeuser plugin, which reads the certificate and key from the credential Secret with
+// the caller's own cluster access whenever they are needed.
+func execCredentialAuth(username string) *clientcmdapi.AuthInfo {
+	return &clientcmdapi.AuthInfo{
+		Exec: &clientcmdapi.ExecConfig{
+			APIVersion:      "client.authentication.k8s.io/v1",
+			Command:         "kubectl",
+			Args:            []string{"kubeuser", "credential", username},
+			InstallHint:     "Install the kubectl-kubeuser plugin, see https://github.com/openkube-hub/KubeUser",
+			InteractiveMode: clientcmdapi.NeverExecInteractiveMode,
+		},
 	}
+}
 
-	// Parse certificate
-	cert, err := x509.ParseCertificate(block.Bytes)
-	if err != nil {
-		return time.Time{}, fmt.Errorf("certificate parse failed: %w", err)
+// buildKubeconfig renders a kubeconfig with auth as the user entry
+func buildKubeconfig(cluster kubeconfigCluster, username string, contexts kubeconfigContexts,
+	auth *clientcmdapi.AuthInfo) ([]byte, error) {
+	config := clientcmdapi.NewConfig()
+	config.Clusters[hubClusterName] = kubeconfigClusterEntry(cluster)
+	config.AuthInfos[username] = auth
+	config.Contexts[username+"@"+hubClusterName] = &clientcmdapi.Context{
+		Cluster:   hubClusterName,
+		AuthInfo:  username,
+		Namespace: contexts.DefaultNamespace,
+	}
+	for _, namespace := range contexts.Namespaces {
+		config.Contexts[username+"@"+namespace] = &clientcmdapi.Context{
+			Cluster:   hubClusterName,
+			AuthInfo:  username,
+			Namespace: namespace,
+		}
 	}
-
-	return cert.NotAfter, nil
+	config.CurrentContext = username + "@" + hubClusterName
+	return clientcmd.Write(*config)
 }
 
-// tryRawDER tries to parse as raw DER data
-func (r *UserReconciler) tryRawDER(certData []byte) (time.Time, error) {
-	// Parse certificate directly
-	cert, err := x509.ParseCertificate(certData)
-	if err != nil {
-		return time.Time{}, fmt.Errorf("certificate parse failed: %w", err)
+// kubeconfigClusterEntry returns the kubeconfig entry of cluster. The CA is left out when TLS
+// verification is skipped, which kubectl does not accept together.
+func kubeconfigClusterEntry(cluster kubeconfigCluster) *clientcmdapi.Cluster {
+	entry := &clientcmdapi.Cluster{
+		Server:                cluster.Server,
+		TLSServerName:         cluster.TLSServerName,
+		ProxyURL:              cluster.ProxyURL,
+		InsecureSkipTLSVerify: cluster.InsecureSkipTLSVerify,
 	}
-
-	return cert.NotAfter, nil
+	if !cluster.InsecureSkipTLSVerify {
+		entry.CertificateAuthorityData = cluster.CA
+	}
+	return entry
 }
 
 // checkCertificateRotation checks if a certificate needs rotation based on expiry
-func (r *UserReconciler) checkCertificateRotation(ctx context.Context, cfgSecretName string, rotationThreshold time.Duration) (bool, error) {
-	userNamespace := getKubeUserNamespace()
-	var existingCfg corev1.Secret
-	if err := r.Get(ctx, types.NamespacedName{Name: cfgSecretName, Namespace: userNamespace}, &existingCfg); err != nil {
+func (r *UserReconciler) checkCertificateRotation(ctx context.Context, cfgSecret types.NamespacedName, username string,
+	rotation rotationPolicy) (bool, error) {
+	existingCfg, err := r.getCredentialSecret(ctx, cfgSecret, username)
+	if err != nil {
 		if apierrors.IsNotFound(err) {
 			return false, nil // No existing certificate, no rotation needed
 		}
 		return false, err
 	}
 
-	// Extract certificate from kubeconfig
-	kubeconfigData := existingCfg.Data["config"]
-	if kubeconfigData == nil {
-		return false, nil // No kubeconfig data, needs recreation
-	}
-
-	// Parse kubeconfig to extract client certificate
-	certData, err := r.extractClientCertFromKubeconfig(kubeconfigData)
-	if err != nil {
-		return false, fmt.Errorf("failed to extract certificate from kubeconfig: %w", err)
-	}
-
-	// Check certificate expiry
-	certExpiry, err := r.extractCertificateExpiryWithFormatDetection(certData)
-	if err != nil {
-		return false, fmt.Errorf("failed to extract certificate expiry: %w", err)
+	// Extract certificate using the layout the secret was written with. Secrets without a
+	// usable certificate are not rotated but reissued by ensureCertKubeconfig.
+	certData, err := secretLayout(existingCfg).ClientCertificate(existingCfg.Data)
+	if err != nil || certData == nil {
+		return false, nil
 	}
 
 	// Check if certificate is expiring soon
-	timeUntilExpiry := time.Until(certExpiry)
-	return timeUntilExpiry < rotationThreshold, nil
-}
-
-// extractClientCertFromKubeconfig extracts client certificate data from kubeconfig YAML
-func (r *UserReconciler) extractClientCertFromKubeconfig(kubeconfigData []byte) ([]byte, error) {
-	// Simple regex approach to extract client-certificate-data
-	// In a production environment, you might want to use a proper YAML parser
-	lines := strings.Split(string(kubeconfigData), "\n")
-	for _, line := range lines {
-		trimmedLine := strings.TrimSpace(line)
-		if strings.HasPrefix(trimmedLine, "client-certificate-data:") {
-			parts := strings.SplitN(trimmedLine, ":", 2)
-			if len(parts) == 2 {
-				certData := strings.TrimSpace(parts[1])
-				// Return the base64 encoded certificate data as bytes
-				return []byte(certData), nil
-			}
-		}
+	cert, err := issuer.ParseCertificate(certData)
+	if err != nil {
+		return false, nil
 	}
-	return nil, errors.New("client certificate data not found in kubeconfig")
+	return time.Now().After(rotation.renewAt(cert.NotBefore, cert.NotAfter)), nil
 }
 
 // cleanupCertificateResources removes existing certificate resources for rotation
-func (r *UserReconciler) cleanupCertificateResources(ctx context.Context, cfgSecretName, csrName string) error {
+func (r *UserReconciler) cleanupCertificateResources(ctx context.Context, cfgSecret types.NamespacedName,
+	username, csrName string) error {
 	logger := logf.FromContext(ctx)
-	userNamespace := getKubeUserNamespace()
 
 	// Delete kubeconfig secret
-	kubeconfigSecret := &corev1.Secret{}
-	if err := r.Get(ctx, types.NamespacedName{Name: cfgSecretName, Namespace: userNamespace}, kubeconfigSecret); err == nil {
-		logger.Info("Deleting kubeconfig secret for rotation", "secret", cfgSecretName)
-		if err := r.Delete(ctx, kubeconfigSecret); err != nil && !apierrors.IsNotFound(err) {
-			return fmt.Errorf("failed to delete kubeconfig secret: %w", err)
-		}
+	logger.Info("Deleting kubeconfig secret for rotation", "secret", cfgSecret)
+	if err := r.deleteCredentialSecret(ctx, cfgSecret, username); err != nil {
+		return fmt.Errorf("failed to delete kubeconfig secret: %w", err)
 	}
 
-	// Delete existing CSR
-	existingCSR := &certv1.CertificateSigningRequest{}
-	if err := r.Get(ctx, types.NamespacedName{Name: csrName}, existingCSR); err == nil {
-		logger.Info("Deleting existing CSR for rotation", "csr", csrName)
-		if err := r.Delete(ctx, existingCSR); err != nil && !apierrors.IsNotFound(err) {
-			return fmt.Errorf("failed to delete existing CSR: %w", err)
-		}
+	// Forget the previous issuance so a new certificate is signed
+	logger.Info("Resetting certificate issuance for rotation", "request", csrName)
+	if err := r.certIssuer().Reset(ctx, csrName); err != nil {
+		return err
 	}
 
 	// Optionally generate new private key for better security
@@ -975,13 +1538,6 @@ func (r *UserReconciler) cleanupCertificateResources(ctx context.Context, cfgSec
 }
 
 // --- utils ---
-func min(a, b int) int {
-	if a < b {
-		return a
-	}
-	return b
-}
-
 func containsString(slice []string, s string) bool {
 	for _, item := range slice {
 		if item == s {
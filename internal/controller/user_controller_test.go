@@ -18,11 +18,15 @@ package controller
 
 import (
 	"context"
+	"time"
 
 	. "github.com/onsi/ginkgo/v2"
 	. "github.com/onsi/gomega"
+	corev1 "k8s.io/api/core/v1"
+	rbacv1 "k8s.io/api/rbac/v1"
 	"k8s.io/apimachinery/pkg/api/errors"
 	"k8s.io/apimachinery/pkg/types"
+	"sigs.k8s.io/controller-runtime/pkg/client"
 	"sigs.k8s.io/controller-runtime/pkg/reconcile"
 
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
@@ -36,9 +40,9 @@ var _ = Describe("User Controller", func() {
 
 		ctx := context.Background()
 
+		// Users are cluster-scoped
 		typeNamespacedName := types.NamespacedName{
-			Name:      resourceName,
-			Namespace: "default", // TODO(user):Modify as needed
+			Name: resourceName,
 		}
 		user := &authv1alpha1.User{}
 
@@ -48,8 +52,7 @@ var _ = Describe("User Controller", func() {
 			if err != nil && errors.IsNotFound(err) {
 				resource := &authv1alpha1.User{
 					ObjectMeta: metav1.ObjectMeta{
-						Name:      resourceName,
-						Namespace: "default",
+						Name: resourceName,
 					},
 					// TODO(user): Specify other spec details if needed.
 				}
@@ -68,10 +71,16 @@ var _ = Describe("User Controller", func() {
 		})
 		It("should successfully reconcile the resource", func() {
 			By("Reconciling the created resource")
+			// Bindings are looked up through the cache's field indexes, which the API server
+			// does not serve
 			controllerReconciler := &UserReconciler{
-				Client: k8sClient,
-				Scheme: k8sClient.Scheme(),
+				Client:    cachedClient,
+				APIReader: k8sClient,
+				Scheme:    k8sClient.Scheme(),
 			}
+			Eventually(func() error {
+				return cachedClient.Get(ctx, typeNamespacedName, &authv1alpha1.User{})
+			}, 10*time.Second).Should(Succeed())
 
 			_, err := controllerReconciler.Reconcile(ctx, reconcile.Request{
 				NamespacedName: typeNamespacedName,
@@ -82,3 +91,139 @@ var _ = Describe("User Controller", func() {
 		})
 	})
 })
+
+// newCachedReconciler returns a reconciler reading through the cache like the operator's.
+// Certificates stay pending with signer and kubeconfigs point at a fixed server, so reconciles
+// get past issuance without a signer or cluster CA.
+func newCachedReconciler(signer *pendingIssuer) *UserReconciler {
+	return &UserReconciler{
+		Client:      cachedClient,
+		APIReader:   k8sClient,
+		Scheme:      k8sClient.Scheme(),
+		Issuer:      signer,
+		ProxyServer: "https://kubeuser-proxy.example.org",
+		ProxyCA:     []byte("ca"),
+	}
+}
+
+// reconcileCached reconciles the User name once the cache has caught up with it, so the
+// reconcile sees the changes of the spec; objects the reconciler wrote itself may take another
+// round to show up there. It returns the error of the reconcile.
+func reconcileCached(g Gomega, r *UserReconciler, name string) error {
+	key := types.NamespacedName{Name: name}
+	var latest, cached authv1alpha1.User
+	if err := k8sClient.Get(ctx, key, &latest); err == nil {
+		g.Expect(cachedClient.Get(ctx, key, &cached)).To(Succeed())
+		g.Expect(cached.ResourceVersion).To(Equal(latest.ResourceVersion), "the cache is behind")
+	} else {
+		g.Expect(errors.IsNotFound(err)).To(BeTrue())
+	}
+	_, err := r.Reconcile(ctx, reconcile.Request{NamespacedName: key})
+	return err
+}
+
+// reconcileUntil reconciles the User name with reconcileCached until it succeeds and check passes
+func reconcileUntil(r *UserReconciler, name string, check func(g Gomega)) {
+	GinkgoHelper()
+	Eventually(func(g Gomega) {
+		g.Expect(reconcileCached(g, r, name)).To(Succeed())
+		check(g)
+	}, 10*time.Second).Should(Succeed())
+}
+
+// updateUser changes the spec of the User name with change
+func updateUser(name string, change func(user *authv1alpha1.User)) {
+	GinkgoHelper()
+	Eventually(func() error {
+		var user authv1alpha1.User
+		if err := k8sClient.Get(ctx, types.NamespacedName{Name: name}, &user); err != nil {
+			return err
+		}
+		change(&user)
+		return k8sClient.Update(ctx, &user)
+	}, 10*time.Second).Should(Succeed())
+}
+
+// deleteUser deletes the User name and reconciles it until the finalizer has cleaned up after it
+func deleteUser(r *UserReconciler, name string) {
+	GinkgoHelper()
+	user := &authv1alpha1.User{ObjectMeta: metav1.ObjectMeta{Name: name}}
+	Expect(client.IgnoreNotFound(k8sClient.Delete(ctx, user))).To(Succeed())
+	reconcileUntil(r, name, func(g Gomega) {
+		err := k8sClient.Get(ctx, types.NamespacedName{Name: name}, user)
+		g.Expect(errors.IsNotFound(err)).To(BeTrue())
+	})
+}
+
+// expectExists checks that obj, named by its key, exists (or not)
+func expectExists(g Gomega, obj client.Object, exists bool) {
+	err := k8sClient.Get(ctx, client.ObjectKeyFromObject(obj), obj)
+	if exists {
+		g.Expect(err).NotTo(HaveOccurred())
+	} else {
+		g.Expect(errors.IsNotFound(err)).To(BeTrue(), "%T %s still exists", obj, obj.GetName())
+	}
+}
+
+var _ = Describe("Suspended users", func() {
+	const name = "suspended-user"
+	var (
+		r           *UserReconciler
+		signer      *pendingIssuer
+		clusterRole *rbacv1.ClusterRole
+	)
+
+	BeforeEach(func() {
+		signer = &pendingIssuer{}
+		r = newCachedReconciler(signer)
+		clusterRole = &rbacv1.ClusterRole{
+			ObjectMeta: metav1.ObjectMeta{Name: "suspended-user-reader"},
+			Rules:      []rbacv1.PolicyRule{{APIGroups: []string{""}, Resources: []string{"pods"}, Verbs: []string{"get"}}},
+		}
+		Expect(k8sClient.Create(ctx, clusterRole)).To(Succeed())
+		Expect(k8sClient.Create(ctx, &authv1alpha1.User{
+			ObjectMeta: metav1.ObjectMeta{Name: name},
+			Spec: authv1alpha1.UserSpec{
+				ClusterRoles: []authv1alpha1.ClusterRoleSpec{{ExistingClusterRole: clusterRole.Name}},
+			},
+		})).To(Succeed())
+	})
+
+	AfterEach(func() {
+		deleteUser(r, name)
+		Expect(k8sClient.Delete(ctx, clusterRole)).To(Succeed())
+	})
+
+	It("removes the bindings but keeps the key until the user is resumed", func() {
+		binding := &rbacv1.ClusterRoleBinding{ObjectMeta: metav1.ObjectMeta{
+			Name: clusterRoleBindingName(name, clusterRole.Name)}}
+		key := &corev1.Secret{ObjectMeta: metav1.ObjectMeta{
+			Name: userKeySecretName(name), Namespace: getKubeUserNamespace()}}
+		reconcileUntil(r, name, func(g Gomega) {
+			expectExists(g, binding, true)
+			expectExists(g, key, true)
+		})
+
+		By("suspending the user")
+		updateUser(name, func(user *authv1alpha1.User) { user.Spec.Suspended = true })
+		signed := len(signer.signed)
+		reconcileUntil(r, name, func(g Gomega) {
+			expectExists(g, binding, false)
+			var user authv1alpha1.User
+			g.Expect(k8sClient.Get(ctx, types.NamespacedName{Name: name}, &user)).To(Succeed())
+			g.Expect(user.Status.Phase).To(Equal(PhaseSuspended))
+		})
+		// No certificate is requested meanwhile
+		Expect(signer.signed).To(HaveLen(signed))
+		expectExists(Default, key, true)
+
+		By("resuming the user")
+		updateUser(name, func(user *authv1alpha1.User) { user.Spec.Suspended = false })
+		reconcileUntil(r, name, func(g Gomega) {
+			expectExists(g, binding, true)
+			var user authv1alpha1.User
+			g.Expect(k8sClient.Get(ctx, types.NamespacedName{Name: name}, &user)).To(Succeed())
+			g.Expect(user.Status.Phase).NotTo(Equal(PhaseSuspended))
+		})
+	})
+})
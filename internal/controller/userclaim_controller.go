@@ -0,0 +1,175 @@
+/*
+Copyright 2025.
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+*/
+
+package controller
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"k8s.io/apimachinery/pkg/api/equality"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/api/meta"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/types"
+	ctrl "sigs.k8s.io/controller-runtime"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/controller/controllerutil"
+	"sigs.k8s.io/controller-runtime/pkg/handler"
+	logf "sigs.k8s.io/controller-runtime/pkg/log"
+	"sigs.k8s.io/controller-runtime/pkg/reconcile"
+
+	authv1alpha1 "github.com/openkube-hub/KubeUser/api/v1alpha1"
+	"github.com/openkube-hub/KubeUser/internal/userclaim"
+)
+
+// claimRecheckInterval is how often a claim that cannot be fulfilled is checked again, since
+// namespaces joining its tenant or a conflicting User going away do not trigger a reconcile
+const claimRecheckInterval = 5 * time.Minute
+
+// UserClaimReconciler creates the cluster-scoped User a UserClaim requests, keeps its roles in
+// line with the claim and deletes it with the claim
+type UserClaimReconciler struct {
+	client.Client
+}
+
+// +kubebuilder:rbac:groups=auth.openkube.io,resources=userclaims,verbs=get;list;watch;update;patch
+// +kubebuilder:rbac:groups=auth.openkube.io,resources=userclaims/status,verbs=get;update;patch
+// +kubebuilder:rbac:groups=auth.openkube.io,resources=userclaims/finalizers,verbs=update
+
+// Reconcile materializes the claim's User and reports it in the claim's status
+func (r *UserClaimReconciler) Reconcile(ctx context.Context, req ctrl.Request) (ctrl.Result, error) {
+	logger := logf.FromContext(ctx)
+
+	var claim authv1alpha1.UserClaim
+	if err := r.Get(ctx, req.NamespacedName, &claim); err != nil {
+		return ctrl.Result{}, client.IgnoreNotFound(err)
+	}
+
+	if !claim.DeletionTimestamp.IsZero() {
+		if err := r.deleteUser(ctx, &claim); err != nil {
+			return ctrl.Result{}, err
+		}
+		if controllerutil.RemoveFinalizer(&claim, userclaim.Finalizer) {
+			return ctrl.Result{}, r.Update(ctx, &claim)
+		}
+		return ctrl.Result{}, nil
+	}
+	if controllerutil.AddFinalizer(&claim, userclaim.Finalizer) {
+		if err := r.Update(ctx, &claim); err != nil {
+			return ctrl.Result{}, err
+		}
+	}
+
+	reason, err := r.reconcileUser(ctx, &claim)
+	condition := metav1.Condition{
+		Type:               "Ready",
+		Status:             metav1.ConditionTrue,
+		Reason:             "UserReady",
+		Message:            fmt.Sprintf("User %s is in place", claim.Name),
+		ObservedGeneration: claim.Generation,
+	}
+	if err != nil {
+		logger.Error(err, "Failed to reconcile User for claim", "reason", reason)
+		condition.Status = metav1.ConditionFalse
+		condition.Reason = reason
+		condition.Message = err.Error()
+	} else {
+		claim.Status.UserName = claim.Name
+	}
+	claim.Status.ObservedGeneration = claim.Generation
+	meta.SetStatusCondition(&claim.Status.Conditions, condition)
+	if statusErr := r.Status().Update(ctx, &claim); statusErr != nil {
+		return ctrl.Result{}, statusErr
+	}
+
+	switch {
+	case err == nil:
+		return ctrl.Result{}, nil
+	case reason == "Failed":
+		return ctrl.Result{}, err
+	default:
+		// The claim cannot be fulfilled as it is; retrying at once will not help
+		return ctrl.Result{RequeueAfter: claimRecheckInterval}, nil
+	}
+}
+
+// reconcileUser creates the claim's User or updates its roles. On error it also returns the
+// Ready condition reason: Failed for errors worth retrying, otherwise what the claim conflicts with.
+func (r *UserClaimReconciler) reconcileUser(ctx context.Context, claim *authv1alpha1.UserClaim) (string, error) {
+	tenant, err := userclaim.TenantNamespaces(ctx, r.Client, claim.Namespace)
+	if err != nil {
+		return "Failed", err
+	}
+	roles, err := userclaim.Roles(claim, tenant)
+	if err != nil {
+		return "OutsideTenant", err
+	}
+
+	var user authv1alpha1.User
+	err = r.Get(ctx, types.NamespacedName{Name: claim.Name}, &user)
+	if apierrors.IsNotFound(err) {
+		user = authv1alpha1.User{
+			ObjectMeta: metav1.ObjectMeta{Name: claim.Name, Labels: userclaim.UserLabels(claim)},
+			Spec:       authv1alpha1.UserSpec{Roles: roles},
+		}
+		if err := r.Create(ctx, &user); err != nil {
+			return "Failed", fmt.Errorf("failed to create User %s: %w", claim.Name, err)
+		}
+		logf.FromContext(ctx).Info("Created User for claim", "user", claim.Name)
+		return "", nil
+	}
+	if err != nil {
+		return "Failed", err
+	}
+	if !userclaim.Owns(claim, &user) {
+		return "UserExists", fmt.Errorf("user %s exists and was not created for this claim", claim.Name)
+	}
+	if equality.Semantic.DeepEqual(user.Spec.Roles, roles) {
+		return "", nil
+	}
+	user.Spec.Roles = roles
+	if err := r.Update(ctx, &user); err != nil {
+		return "Failed", fmt.Errorf("failed to update User %s: %w", claim.Name, err)
+	}
+	return "", nil
+}
+
+// deleteUser deletes the User created for claim, if any
+func (r *UserClaimReconciler) deleteUser(ctx context.Context, claim *authv1alpha1.UserClaim) error {
+	var user authv1alpha1.User
+	if err := r.Get(ctx, types.NamespacedName{Name: claim.Name}, &user); err != nil {
+		return client.IgnoreNotFound(err)
+	}
+	if !userclaim.Owns(claim, &user) {
+		return nil
+	}
+	if err := r.Delete(ctx, &user); err != nil && !apierrors.IsNotFound(err) {
+		return err
+	}
+	logf.FromContext(ctx).Info("Deleted User of removed claim", "user", claim.Name)
+	return nil
+}
+
+// claimForUser maps a User change to a reconcile request for the claim it was created for
+func claimForUser(_ context.Context, obj client.Object) []reconcile.Request {
+	labels := obj.GetLabels()
+	namespace, name := labels[userclaim.NamespaceLabel], labels[userclaim.NameLabel]
+	if namespace == "" || name == "" {
+		return nil
+	}
+	return []reconcile.Request{{NamespacedName: types.NamespacedName{Namespace: namespace, Name: name}}}
+}
+
+// SetupWithManager wires the controller
+func (r *UserClaimReconciler) SetupWithManager(mgr ctrl.Manager) error {
+	return ctrl.NewControllerManagedBy(mgr).
+		For(&authv1alpha1.UserClaim{}).
+		Watches(&authv1alpha1.User{}, handler.EnqueueRequestsFromMapFunc(claimForUser)).
+		Named("userclaim").
+		Complete(r)
+}
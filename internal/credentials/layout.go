@@ -0,0 +1,299 @@
+/*
+Copyright 2025.
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+*/
+
+// Package credentials renders issued user credentials into the keys of the credential Secret.
+package credentials
+
+import (
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"sort"
+	"strings"
+
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/util/validation"
+	"k8s.io/client-go/tools/clientcmd"
+	clientcmdapi "k8s.io/client-go/tools/clientcmd/api"
+	clientcmdlatest "k8s.io/client-go/tools/clientcmd/api/latest"
+	"sigs.k8s.io/yaml"
+
+	authv1alpha1 "github.com/openkube-hub/KubeUser/api/v1alpha1"
+	"github.com/openkube-hub/KubeUser/internal/pgp"
+)
+
+// Environment variable names written by the env format
+const (
+	EnvServer         = "KUBE_SERVER"
+	EnvUser           = "KUBE_USER"
+	EnvCAData         = "KUBE_CA_DATA"
+	EnvClientCertData = "KUBE_CLIENT_CERT_DATA"
+	EnvClientKeyData  = "KUBE_CLIENT_KEY_DATA"
+	EnvToken          = "KUBE_TOKEN"
+)
+
+// Layout maps credential Secret keys to the format stored under them
+type Layout map[string]authv1alpha1.CredentialFormat
+
+// DefaultLayout is the historical single kubeconfig under "config"
+func DefaultLayout() Layout {
+	return Layout{"config": authv1alpha1.CredentialFormatKubeconfig}
+}
+
+// Material is everything a credential Secret can be rendered from
+type Material struct {
+	Server   string
+	Username string
+	// CA, Cert and Key are PEM encoded
+	CA   []byte
+	Cert []byte
+	Key  []byte
+	// Token is the bearer token of a machine user, which has no certificate and key
+	Token string
+	// Kubeconfig is the YAML kubeconfig for the user
+	Kubeconfig []byte
+}
+
+// ParseLayout parses a comma separated list of key=format pairs, e.g. "config=kubeconfig,tls.crt=client-cert"
+func ParseLayout(spec string) (Layout, error) {
+	layout := Layout{}
+	for _, pair := range strings.Split(spec, ",") {
+		pair = strings.TrimSpace(pair)
+		if pair == "" {
+			continue
+		}
+		key, format, found := strings.Cut(pair, "=")
+		if !found {
+			return nil, fmt.Errorf("invalid credential key %q, expected key=format", pair)
+		}
+		layout[strings.TrimSpace(key)] = authv1alpha1.CredentialFormat(strings.TrimSpace(format))
+	}
+	if len(layout) == 0 {
+		return DefaultLayout(), nil
+	}
+	return layout, layout.Validate()
+}
+
+// FromSpec returns the layout requested by a User, or fallback when the User does not set one.
+// A key in the Secret reference is a single kubeconfig under that key.
+func FromSpec(output *authv1alpha1.OutputSpec, fallback Layout) Layout {
+	if output == nil {
+		return fallback
+	}
+	if len(output.Keys) == 0 {
+		if output.SecretRef != nil && output.SecretRef.Key != "" {
+			return Layout{output.SecretRef.Key: authv1alpha1.CredentialFormatKubeconfig}
+		}
+		return fallback
+	}
+	layout := Layout{}
+	for _, k := range output.Keys {
+		layout[k.Key] = k.Format
+	}
+	return layout
+}
+
+// Validate checks that every key is a valid Secret key, every format is known, and that the
+// certificate is stored somewhere so it can be checked for rotation
+func (l Layout) Validate() error {
+	var errs []error
+	hasCert := false
+	for key, format := range l {
+		hasCert = hasCert || format != authv1alpha1.CredentialFormatCACert && format != authv1alpha1.CredentialFormatClientKey
+		if msgs := validation.IsConfigMapKey(key); len(msgs) > 0 {
+			errs = append(errs, fmt.Errorf("invalid credential key %q: %s", key, strings.Join(msgs, ", ")))
+		}
+		if !knownFormat(format) {
+			errs = append(errs, fmt.Errorf("unknown credential format %q for key %q", format, key))
+		}
+	}
+	if !hasCert {
+		errs = append(errs, errors.New("credential layout must include a kubeconfig, kubeconfig-json, client-cert or env key"))
+	}
+	return errors.Join(errs...)
+}
+
+// String renders the layout in the ParseLayout syntax with sorted keys
+func (l Layout) String() string {
+	pairs := make([]string, 0, len(l))
+	for key, format := range l {
+		pairs = append(pairs, key+"="+string(format))
+	}
+	sort.Strings(pairs)
+	return strings.Join(pairs, ",")
+}
+
+// Render produces the Secret data for the layout
+func (l Layout) Render(m Material) (map[string][]byte, error) {
+	data := make(map[string][]byte, len(l))
+	for key, format := range l {
+		switch format {
+		case authv1alpha1.CredentialFormatKubeconfig:
+			data[key] = m.Kubeconfig
+		case authv1alpha1.CredentialFormatKubeconfigJSON:
+			config, err := clientcmd.Load(m.Kubeconfig)
+			if err != nil {
+				return nil, fmt.Errorf("parsing kubeconfig: %w", err)
+			}
+			// The clientcmd codec only writes YAML
+			encoded, err := runtime.Encode(clientcmdlatest.Codec, config)
+			if err != nil {
+				return nil, fmt.Errorf("encoding kubeconfig: %w", err)
+			}
+			if data[key], err = yaml.YAMLToJSON(encoded); err != nil {
+				return nil, fmt.Errorf("encoding kubeconfig as JSON: %w", err)
+			}
+		case authv1alpha1.CredentialFormatCACert:
+			data[key] = m.CA
+		case authv1alpha1.CredentialFormatClientCert:
+			data[key] = m.Cert
+		case authv1alpha1.CredentialFormatClientKey:
+			data[key] = m.Key
+		case authv1alpha1.CredentialFormatEnv:
+			data[key] = renderEnv(m)
+		default:
+			return nil, fmt.Errorf("unknown credential format %q for key %q", format, key)
+		}
+	}
+	return data, nil
+}
+
+// Encrypt replaces every value in data that holds a private key or token with an OpenPGP
+// message to recipients. CA and client certificates are public and stay readable.
+func (l Layout) Encrypt(data map[string][]byte, recipients []*pgp.Recipient) error {
+	for key, format := range l {
+		if format == authv1alpha1.CredentialFormatCACert || format == authv1alpha1.CredentialFormatClientCert ||
+			len(data[key]) == 0 {
+			continue
+		}
+		encrypted, err := pgp.Encrypt(data[key], recipients)
+		if err != nil {
+			return fmt.Errorf("encrypting key %q: %w", key, err)
+		}
+		data[key] = encrypted
+	}
+	return nil
+}
+
+// ClientCertificate finds the client certificate in Secret data written with this layout.
+// It returns nil when the data holds no certificate.
+func (l Layout) ClientCertificate(data map[string][]byte) ([]byte, error) {
+	return l.find(data, authv1alpha1.CredentialFormatClientCert, EnvClientCertData,
+		func(auth *clientcmdapi.AuthInfo) []byte { return auth.ClientCertificateData })
+}
+
+// ClientKey finds the client private key in Secret data written with this layout. It returns
+// nil when the data holds no key, e.g. for users who brought their own CSR.
+func (l Layout) ClientKey(data map[string][]byte) ([]byte, error) {
+	return l.find(data, authv1alpha1.CredentialFormatClientKey, EnvClientKeyData,
+		func(auth *clientcmdapi.AuthInfo) []byte { return auth.ClientKeyData })
+}
+
+// DefaultKubeconfigKey is where the default layout stores the kubeconfig
+const DefaultKubeconfigKey = "config"
+
+// FindKubeconfig finds the kubeconfig in credential Secret data: a key output declares as
+// kubeconfig, then the default key, then any key that holds a kubeconfig (operator-wide
+// layouts). An encrypted kubeconfig is only found under the declared or default key.
+func FindKubeconfig(output *authv1alpha1.OutputSpec, data map[string][]byte) ([]byte, error) {
+	var candidates []string
+	if output != nil {
+		if ref := output.SecretRef; ref != nil && ref.Key != "" {
+			candidates = append(candidates, ref.Key)
+		}
+		for _, key := range output.Keys {
+			switch key.Format {
+			case authv1alpha1.CredentialFormatKubeconfig, authv1alpha1.CredentialFormatKubeconfigJSON:
+				candidates = append(candidates, key.Key)
+			}
+		}
+	}
+	candidates = append(candidates, DefaultKubeconfigKey)
+	declared := len(candidates)
+	keys := make([]string, 0, len(data))
+	for key := range data {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	candidates = append(candidates, keys...)
+
+	for i, key := range candidates {
+		value, ok := data[key]
+		if !ok {
+			continue
+		}
+		if i < declared && pgp.IsMessage(value) {
+			return value, nil
+		}
+		if config, err := clientcmd.Load(value); err == nil && len(config.Contexts) > 0 {
+			return value, nil
+		}
+	}
+	return nil, fmt.Errorf("no kubeconfig in keys %v", keys)
+}
+
+// find returns the first value stored in format, in a kubeconfig user entry as returned by
+// fromAuth, or base64 encoded under envName in an env file. Encrypted values are skipped.
+func (l Layout) find(data map[string][]byte, format authv1alpha1.CredentialFormat, envName string,
+	fromAuth func(*clientcmdapi.AuthInfo) []byte) ([]byte, error) {
+	keys := make([]string, 0, len(l))
+	for key := range l {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+
+	for _, key := range keys {
+		value, ok := data[key]
+		if !ok || len(value) == 0 || pgp.IsMessage(value) {
+			continue
+		}
+		switch l[key] {
+		case format:
+			return value, nil
+		case authv1alpha1.CredentialFormatKubeconfig, authv1alpha1.CredentialFormatKubeconfigJSON:
+			config, err := clientcmd.Load(value)
+			if err != nil {
+				return nil, fmt.Errorf("parsing kubeconfig in key %q: %w", key, err)
+			}
+			for _, auth := range config.AuthInfos {
+				if found := fromAuth(auth); len(found) > 0 {
+					return found, nil
+				}
+			}
+		case authv1alpha1.CredentialFormatEnv:
+			for _, line := range strings.Split(string(value), "\n") {
+				if encoded, found := strings.CutPrefix(line, envName+"="); found {
+					return base64.StdEncoding.DecodeString(encoded)
+				}
+			}
+		}
+	}
+	return nil, nil
+}
+
+func renderEnv(m Material) []byte {
+	var b strings.Builder
+	fmt.Fprintf(&b, "%s=%s\n", EnvServer, m.Server)
+	fmt.Fprintf(&b, "%s=%s\n", EnvUser, m.Username)
+	fmt.Fprintf(&b, "%s=%s\n", EnvCAData, base64.StdEncoding.EncodeToString(m.CA))
+	if m.Token != "" {
+		fmt.Fprintf(&b, "%s=%s\n", EnvToken, m.Token)
+		return []byte(b.String())
+	}
+	fmt.Fprintf(&b, "%s=%s\n", EnvClientCertData, base64.StdEncoding.EncodeToString(m.Cert))
+	fmt.Fprintf(&b, "%s=%s\n", EnvClientKeyData, base64.StdEncoding.EncodeToString(m.Key))
+	return []byte(b.String())
+}
+
+func knownFormat(format authv1alpha1.CredentialFormat) bool {
+	switch format {
+	case authv1alpha1.CredentialFormatKubeconfig, authv1alpha1.CredentialFormatKubeconfigJSON,
+		authv1alpha1.CredentialFormatCACert, authv1alpha1.CredentialFormatClientCert,
+		authv1alpha1.CredentialFormatClientKey, authv1alpha1.CredentialFormatEnv:
+		return true
+	}
+	return false
+}
@@ -0,0 +1,193 @@
+/*
+Copyright 2025.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package credentials
+
+import (
+	"encoding/base64"
+	"encoding/json"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+
+	authv1alpha1 "github.com/openkube-hub/KubeUser/api/v1alpha1"
+	"github.com/openkube-hub/KubeUser/internal/pgp"
+)
+
+// recipientKey is an OpenPGP public key with a Curve25519 encryption subkey
+const recipientKey = `-----BEGIN PGP PUBLIC KEY BLOCK-----
+
+mDMEatHd7BYJKwYBBAHaRw8BAQdAwMp07+epxmH5tdVwn2+Yih9bJM/c/VugFRmK
+7gGTwG20HkN1cnZlIFVzZXIgPGN1cnZlQGV4YW1wbGUuY29tPoiQBBMWCAA4FiEE
+xClaecwB8dEXb86L0UV/y8ey5AMFAmrR3ewCGwMFCwkIBwIGFQoJCAsCBBYCAwEC
+HgECF4AACgkQ0UV/y8ey5ANd2gD+K+sbPf2QcjJZ8luDvIq6MFlwUt7uYW0zY6Jy
+rITgkNABAIz+EF0dA25W+eZNWIEEGJqo9oyioQycGCwimooQT2kGuDgEatHd7BIK
+KwYBBAGXVQEFAQEHQIOcnIVtwV3M/mRHYaioU0jRbWncV5MzGVbX7KL3cVweAwEI
+B4h4BBgWCAAgFiEExClaecwB8dEXb86L0UV/y8ey5AMFAmrR3ewCGwwACgkQ0UV/
+y8ey5AOnRAD+LEzYd7ErAPmQsyEDCXJYnAeDHtegJzCvuTTDX/3M/vgBANj+9Ohm
+l4J3kCL9IQ4E7awlk7KOd9dGIc58anEneQUJ
+=aUs+
+-----END PGP PUBLIC KEY BLOCK-----
+`
+
+var _ = Describe("Layout", func() {
+	cert := []byte("-----BEGIN CERTIFICATE-----\nY2VydA==\n-----END CERTIFICATE-----\n")
+	material := Material{
+		Server:   "https://api.example.com:6443",
+		Username: "jane",
+		CA:       []byte("ca-pem"),
+		Cert:     cert,
+		Key:      []byte("key-pem"),
+		Kubeconfig: []byte(`apiVersion: v1
+kind: Config
+clusters:
+- cluster:
+    server: https://api.example.com:6443
+  name: cluster
+users:
+- name: jane
+  user:
+    client-certificate-data: ` + base64.StdEncoding.EncodeToString(cert) + `
+contexts:
+- context:
+    cluster: cluster
+    user: jane
+  name: jane@cluster
+current-context: jane@cluster
+`),
+	}
+
+	It("parses and renders layouts in a stable order", func() {
+		layout, err := ParseLayout("tls.crt=client-cert, config=kubeconfig")
+		Expect(err).NotTo(HaveOccurred())
+		Expect(layout.String()).To(Equal("config=kubeconfig,tls.crt=client-cert"))
+	})
+
+	It("defaults to a kubeconfig under config", func() {
+		layout, err := ParseLayout("")
+		Expect(err).NotTo(HaveOccurred())
+		Expect(layout).To(Equal(DefaultLayout()))
+	})
+
+	It("rejects unknown formats and invalid keys", func() {
+		_, err := ParseLayout("config=yaml")
+		Expect(err).To(MatchError(ContainSubstring("unknown credential format")))
+		_, err = ParseLayout("a/b=kubeconfig")
+		Expect(err).To(MatchError(ContainSubstring("invalid credential key")))
+		_, err = ParseLayout("ca.crt=ca-cert,tls.key=client-key")
+		Expect(err).To(MatchError(ContainSubstring("must include")))
+	})
+
+	It("prefers the User's keys over the fallback", func() {
+		output := &authv1alpha1.OutputSpec{Keys: []authv1alpha1.CredentialKey{{Key: "tls.key", Format: authv1alpha1.CredentialFormatClientKey}}}
+		Expect(FromSpec(output, DefaultLayout())).To(Equal(Layout{"tls.key": authv1alpha1.CredentialFormatClientKey}))
+		Expect(FromSpec(nil, DefaultLayout())).To(Equal(DefaultLayout()))
+	})
+
+	It("uses the Secret reference's key as kubeconfig key", func() {
+		output := &authv1alpha1.OutputSpec{SecretRef: &authv1alpha1.OutputSecretRef{Name: "ci", Key: "kubeconfig"}}
+		Expect(FromSpec(output, DefaultLayout())).To(Equal(Layout{"kubeconfig": authv1alpha1.CredentialFormatKubeconfig}))
+		output.SecretRef.Key = ""
+		Expect(FromSpec(output, DefaultLayout())).To(Equal(DefaultLayout()))
+	})
+
+	It("renders every format and finds the certificate again", func() {
+		layout := Layout{
+			"config":      authv1alpha1.CredentialFormatKubeconfig,
+			"config.json": authv1alpha1.CredentialFormatKubeconfigJSON,
+			"ca.crt":      authv1alpha1.CredentialFormatCACert,
+			"tls.crt":     authv1alpha1.CredentialFormatClientCert,
+			"tls.key":     authv1alpha1.CredentialFormatClientKey,
+			"kube.env":    authv1alpha1.CredentialFormatEnv,
+		}
+		data, err := layout.Render(material)
+		Expect(err).NotTo(HaveOccurred())
+		Expect(data["ca.crt"]).To(Equal([]byte("ca-pem")))
+		Expect(data["tls.key"]).To(Equal([]byte("key-pem")))
+		Expect(json.Valid(data["config.json"])).To(BeTrue())
+		Expect(string(data["kube.env"])).To(ContainSubstring("KUBE_SERVER=https://api.example.com:6443\n"))
+
+		for key, format := range layout {
+			if format == authv1alpha1.CredentialFormatCACert || format == authv1alpha1.CredentialFormatClientKey {
+				continue
+			}
+			single := Layout{key: format}
+			Expect(single.ClientCertificate(data)).To(Equal(cert), "format %s", format)
+		}
+	})
+
+	It("writes the token of a machine user instead of a certificate and key", func() {
+		data, err := Layout{"kube.env": authv1alpha1.CredentialFormatEnv}.Render(Material{
+			Server: "https://api.example.com:6443", Username: "ci", CA: []byte("ca-pem"), Token: "token",
+		})
+		Expect(err).NotTo(HaveOccurred())
+		Expect(string(data["kube.env"])).To(ContainSubstring("KUBE_TOKEN=token\n"))
+		Expect(string(data["kube.env"])).NotTo(ContainSubstring(EnvClientCertData))
+	})
+
+	It("finds the client key next to a kubeconfig without credentials", func() {
+		layout := Layout{
+			"config":  authv1alpha1.CredentialFormatKubeconfig,
+			"tls.crt": authv1alpha1.CredentialFormatClientCert,
+			"tls.key": authv1alpha1.CredentialFormatClientKey,
+		}
+		data, err := layout.Render(material)
+		Expect(err).NotTo(HaveOccurred())
+		Expect(layout.ClientKey(data)).To(Equal([]byte("key-pem")))
+		Expect(Layout{"kube.env": authv1alpha1.CredentialFormatEnv}.ClientKey(map[string][]byte{
+			"kube.env": []byte(EnvClientKeyData + "=" + base64.StdEncoding.EncodeToString([]byte("key-pem")) + "\n"),
+		})).To(Equal([]byte("key-pem")))
+		Expect(DefaultLayout().ClientKey(data)).To(BeNil())
+	})
+
+	It("encrypts everything but certificates and still finds the certificate", func() {
+		recipient, err := pgp.ParseRecipient(recipientKey)
+		Expect(err).NotTo(HaveOccurred())
+		layout := Layout{
+			"config":   authv1alpha1.CredentialFormatKubeconfig,
+			"ca.crt":   authv1alpha1.CredentialFormatCACert,
+			"tls.crt":  authv1alpha1.CredentialFormatClientCert,
+			"tls.key":  authv1alpha1.CredentialFormatClientKey,
+			"kube.env": authv1alpha1.CredentialFormatEnv,
+		}
+		data, err := layout.Render(material)
+		Expect(err).NotTo(HaveOccurred())
+		Expect(layout.Encrypt(data, []*pgp.Recipient{recipient})).To(Succeed())
+
+		Expect(pgp.IsMessage(data["config"])).To(BeTrue())
+		Expect(pgp.IsMessage(data["tls.key"])).To(BeTrue())
+		Expect(pgp.IsMessage(data["kube.env"])).To(BeTrue())
+		Expect(data["ca.crt"]).To(Equal([]byte("ca-pem")))
+		Expect(layout.ClientCertificate(data)).To(Equal(cert))
+		Expect(layout.ClientKey(data)).To(BeNil())
+	})
+
+	It("returns nil when the secret holds no certificate", func() {
+		Expect(DefaultLayout().ClientCertificate(map[string][]byte{"other": []byte("x")})).To(BeNil())
+	})
+
+	It("finds the kubeconfig under declared, default and other keys", func() {
+		output := &authv1alpha1.OutputSpec{SecretRef: &authv1alpha1.OutputSecretRef{Name: "ci", Key: "kubeconfig"}}
+		Expect(FindKubeconfig(output, map[string][]byte{
+			"config":     []byte("not a kubeconfig"),
+			"kubeconfig": material.Kubeconfig,
+		})).To(Equal(material.Kubeconfig))
+		Expect(FindKubeconfig(nil, map[string][]byte{"custom": material.Kubeconfig})).To(Equal(material.Kubeconfig))
+
+		_, err := FindKubeconfig(nil, map[string][]byte{"tls.crt": cert})
+		Expect(err).To(MatchError("no kubeconfig in keys [tls.crt]"))
+	})
+})
@@ -0,0 +1,48 @@
+/*
+Copyright 2025.
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+*/
+
+// Package dashboard serves the web dashboard: a page listing users with their phase, teams,
+// credential expiry and timed grants, with buttons to renew, suspend, resume and revoke them.
+// The page runs in the browser and calls the admin API with the viewer's Kubernetes token, so
+// the admin API's SubjectAccessReviews decide what the viewer may see and do.
+package dashboard
+
+import (
+	"embed"
+	"io/fs"
+	"net/http"
+)
+
+// Path is where the dashboard is served
+const Path = "/dashboard/"
+
+//go:embed static
+var static embed.FS
+
+// Handler serves the dashboard's page and assets under Path
+func Handler() http.Handler {
+	assets, err := fs.Sub(static, "static")
+	if err != nil {
+		panic(err)
+	}
+	files := http.StripPrefix(Path, http.FileServerFS(assets))
+	return http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+		if req.Method != http.MethodGet && req.Method != http.MethodHead {
+			w.Header().Set("Allow", "GET, HEAD")
+			http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
+			return
+		}
+		header := w.Header()
+		header.Set("Cache-Control", "no-cache")
+		header.Set("X-Content-Type-Options", "nosniff")
+		header.Set("Referrer-Policy", "no-referrer")
+		// Only the dashboard's own script may run, and only the admin API on the same origin
+		// receives the token
+		header.Set("Content-Security-Policy", "default-src 'none'; script-src 'self'; style-src 'self'; "+
+			"connect-src 'self'; form-action 'none'; frame-ancestors 'none'; base-uri 'none'")
+		files.ServeHTTP(w, req)
+	})
+}
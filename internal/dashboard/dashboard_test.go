@@ -0,0 +1,51 @@
+/*
+Copyright 2025.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package dashboard
+
+import (
+	"net/http"
+	"net/http/httptest"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("Dashboard", func() {
+	serve := func(method, path string) *httptest.ResponseRecorder {
+		recorder := httptest.NewRecorder()
+		Handler().ServeHTTP(recorder, httptest.NewRequest(method, path, nil))
+		return recorder
+	}
+
+	It("serves the page and its assets", func() {
+		page := serve(http.MethodGet, "/dashboard/")
+		Expect(page.Code).To(Equal(http.StatusOK))
+		Expect(page.Body.String()).To(ContainSubstring(`<script src="dashboard.js" defer></script>`))
+		Expect(page.Header().Get("Content-Security-Policy")).To(ContainSubstring("script-src 'self'"))
+		Expect(page.Header().Get("Content-Security-Policy")).To(ContainSubstring("frame-ancestors 'none'"))
+
+		script := serve(http.MethodGet, "/dashboard/dashboard.js")
+		Expect(script.Code).To(Equal(http.StatusOK))
+		Expect(script.Body.String()).To(ContainSubstring("/api/v1/users"))
+		Expect(serve(http.MethodGet, "/dashboard/dashboard.css").Code).To(Equal(http.StatusOK))
+	})
+
+	It("serves nothing else", func() {
+		Expect(serve(http.MethodGet, "/dashboard/missing.js").Code).To(Equal(http.StatusNotFound))
+		Expect(serve(http.MethodPost, "/dashboard/").Code).To(Equal(http.StatusMethodNotAllowed))
+	})
+})
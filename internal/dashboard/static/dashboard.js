@@ -0,0 +1,207 @@
+// KubeUser dashboard. Everything is read from and done through the admin API with the
+// viewer's token, which the API checks with SubjectAccessReviews.
+"use strict";
+
+const tokenKey = "kubeuser-token";
+// Credentials expiring within this many days are highlighted, like the ExpiringSoon phase
+const soonDays = 7;
+// The expiry bar is full for credentials valid this many days or longer
+const timelineDays = 90;
+const day = 24 * 60 * 60 * 1000;
+
+let users = [];
+
+function $(id) {
+  return document.getElementById(id);
+}
+
+function el(tag, text, className) {
+  const node = document.createElement(tag);
+  if (text !== undefined) node.textContent = text;
+  if (className) node.className = className;
+  return node;
+}
+
+function showMessage(text, isError) {
+  $("message").textContent = text || "";
+  $("message").className = isError ? "error" : "";
+}
+
+async function api(method, path, body) {
+  const options = {method, headers: {Authorization: "Bearer " + sessionStorage.getItem(tokenKey)}};
+  if (body !== undefined) {
+    options.headers["Content-Type"] = "application/json";
+    options.body = JSON.stringify(body);
+  }
+  const response = await fetch(path, options);
+  if (response.status === 401) {
+    signOut("Your token was not accepted. Sign in again.");
+    throw new Error("unauthorized");
+  }
+  if (!response.ok) {
+    let message = response.statusText;
+    try {
+      message = (await response.json()).message || message;
+    } catch (e) {
+      // not an API error body
+    }
+    throw new Error(message);
+  }
+  return response.status === 200 ? response.json() : null;
+}
+
+function relative(time) {
+  const days = Math.round((time - Date.now()) / day);
+  if (days === 0) return "today";
+  return days > 0 ? "in " + days + "d" : days + "d ago";
+}
+
+function expiryClass(time) {
+  if (time <= Date.now()) return "past";
+  return time - Date.now() < soonDays * day ? "soon" : "";
+}
+
+function expiryCell(user) {
+  const cell = el("td");
+  const expiry = user.status.expiryTime || user.status.certificateExpiry;
+  if (!expiry) {
+    cell.textContent = "-";
+    return cell;
+  }
+  const time = Date.parse(expiry);
+  cell.className = expiryClass(time);
+  cell.title = new Date(time).toLocaleString();
+  cell.append(el("span", relative(time)));
+  const bar = el("div", undefined, "timeline");
+  const fill = el("div");
+  const left = Math.max(0, Math.min(1, (time - Date.now()) / (timelineDays * day)));
+  fill.style.width = Math.round(left * 100) + "%";
+  bar.append(fill);
+  cell.append(bar);
+  return cell;
+}
+
+function grantsCell(user) {
+  const cell = el("td");
+  const grants = user.status.timedGrants || [];
+  if (grants.length === 0) {
+    cell.textContent = "-";
+    return cell;
+  }
+  const list = el("ul");
+  for (const grant of grants) {
+    const time = Date.parse(grant.expiresAt);
+    const name = grant.namespace ? grant.namespace + "/" + grant.name : grant.name;
+    const item = el("li", grant.kind + " " + name + " ends " + relative(time), expiryClass(time));
+    item.title = new Date(time).toLocaleString();
+    list.append(item);
+  }
+  cell.append(list);
+  return cell;
+}
+
+function actionButton(user, action, label, confirmation) {
+  const button = el("button", label);
+  button.addEventListener("click", async () => {
+    if (confirmation && !window.confirm(confirmation)) return;
+    button.disabled = true;
+    try {
+      await api("POST", "/api/v1/users/" + encodeURIComponent(user.name) + "/" + action);
+      showMessage(label + " requested for " + user.name + ".");
+      await load();
+    } catch (e) {
+      showMessage(label + " " + user.name + ": " + e.message, true);
+      button.disabled = false;
+    }
+  });
+  return button;
+}
+
+function actionsCell(user) {
+  const cell = el("td");
+  if (user.status.revoked) return cell;
+  cell.append(actionButton(user, "renew", "Renew"));
+  if (user.status.suspended) {
+    cell.append(actionButton(user, "resume", "Resume"));
+  } else {
+    cell.append(actionButton(user, "suspend", "Suspend", "Suspend " + user.name + "? Their bindings are removed until resumed."));
+  }
+  cell.append(actionButton(user, "revoke", "Revoke", "Revoke " + user.name + "? This removes all their access and cannot be undone."));
+  return cell;
+}
+
+function render() {
+  const filter = $("filter").value.trim().toLowerCase();
+  const rows = $("rows");
+  rows.replaceChildren();
+  let shown = 0;
+  for (const user of users) {
+    const teams = user.status.teams || [];
+    const phase = user.status.phase || "Pending";
+    const text = [user.name, phase].concat(teams).join(" ").toLowerCase();
+    if (filter && !text.includes(filter)) continue;
+    shown++;
+
+    const row = el("tr");
+    const name = el("td", user.name);
+    if (user.spec.email) name.title = user.spec.email;
+    row.append(name);
+    const phaseCell = el("td");
+    const badge = el("span", phase, "phase phase-" + phase);
+    if (user.status.message) badge.title = user.status.message;
+    phaseCell.append(badge);
+    row.append(phaseCell);
+    row.append(el("td", teams.join(", ") || "-"));
+    row.append(expiryCell(user));
+    row.append(grantsCell(user));
+    row.append(actionsCell(user));
+    rows.append(row);
+  }
+  $("summary").textContent = shown + " of " + users.length + " users";
+}
+
+async function load() {
+  const list = await api("GET", "/api/v1/users");
+  users = list.items.sort((a, b) => a.name.localeCompare(b.name));
+  render();
+}
+
+function showUsers() {
+  $("sign-in").hidden = true;
+  $("users").hidden = false;
+  $("sign-out").hidden = false;
+  load().catch((e) => showMessage("Listing users: " + e.message, true));
+}
+
+function signOut(message) {
+  sessionStorage.removeItem(tokenKey);
+  users = [];
+  $("rows").replaceChildren();
+  $("users").hidden = true;
+  $("sign-out").hidden = true;
+  $("sign-in").hidden = false;
+  showMessage(message, Boolean(message));
+}
+
+document.addEventListener("DOMContentLoaded", () => {
+  $("sign-in-button").addEventListener("click", () => {
+    const token = $("token").value.trim();
+    if (!token) return;
+    sessionStorage.setItem(tokenKey, token);
+    $("token").value = "";
+    showMessage("");
+    showUsers();
+  });
+  $("sign-out").addEventListener("click", () => signOut());
+  $("refresh").addEventListener("click", () => load().catch((e) => showMessage(e.message, true)));
+  $("filter").addEventListener("input", render);
+  setInterval(() => {
+    if (sessionStorage.getItem(tokenKey)) load().catch(() => {});
+  }, 60 * 1000);
+
+  if (sessionStorage.getItem(tokenKey)) {
+    showUsers();
+  } else {
+    signOut();
+  }
+});
@@ -0,0 +1,83 @@
+/*
+Copyright 2025.
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+*/
+
+// Package directory reads group memberships from external identity directories, so Teams can
+// take their members from the groups people are already managed in.
+package directory
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"slices"
+	"strings"
+
+	"k8s.io/apimachinery/pkg/util/validation"
+)
+
+// Member is an account in a directory group
+type Member struct {
+	// Name is the User name the account maps to
+	Name string
+	// Disabled is set when the account may not sign in
+	Disabled bool
+}
+
+// Directory lists the members of directory groups
+type Directory interface {
+	// Members returns the accounts in group, including those of nested groups, sorted by name.
+	// Accounts whose name attribute cannot be mapped to a User name are skipped.
+	Members(ctx context.Context, group string) ([]Member, error)
+}
+
+// UserName maps an account attribute such as an email address or user principal name to a
+// User name: the part before '@', lowercased, with dots and underscores replaced by dashes.
+// It fails when the result is not a valid User name.
+func UserName(value string) (string, error) {
+	name, _, _ := strings.Cut(value, "@")
+	name = strings.NewReplacer(".", "-", "_", "-").Replace(strings.ToLower(name))
+	if errs := validation.IsDNS1123Label(name); len(errs) > 0 {
+		return "", fmt.Errorf("%q is not a valid user name: %s", name, strings.Join(errs, "; "))
+	}
+	return name, nil
+}
+
+// collect returns members sorted by name with one entry per name. Accounts mapping to the
+// same name, e.g. in different domains, count as one member that is disabled only when all of
+// them are.
+func collect(members []Member) []Member {
+	byName := map[string]int{}
+	var collected []Member
+	for _, m := range members {
+		if i, ok := byName[m.Name]; ok {
+			collected[i].Disabled = collected[i].Disabled && m.Disabled
+			continue
+		}
+		byName[m.Name] = len(collected)
+		collected = append(collected, m)
+	}
+	slices.SortFunc(collected, func(a, b Member) int { return strings.Compare(a.Name, b.Name) })
+	return collected
+}
+
+// send sends req and decodes a successful JSON response into out
+func send(client *http.Client, req *http.Request, out any) error {
+	resp, err := client.Do(req)
+	if err != nil {
+		return err
+	}
+	defer func() { _ = resp.Body.Close() }()
+	if resp.StatusCode < 200 || resp.StatusCode > 299 {
+		body, _ := io.ReadAll(io.LimitReader(resp.Body, 1024))
+		return fmt.Errorf("%s returned %d: %s", req.URL.Host, resp.StatusCode, strings.TrimSpace(string(body)))
+	}
+	if err := json.NewDecoder(resp.Body).Decode(out); err != nil {
+		return fmt.Errorf("failed to decode response of %s: %w", req.URL.Host, err)
+	}
+	return nil
+}
@@ -0,0 +1,192 @@
+/*
+Copyright 2025.
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+*/
+
+package directory
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/url"
+	"strings"
+	"sync"
+	"time"
+)
+
+const (
+	// DefaultEntraAuthority is the Microsoft Entra ID endpoint of the global cloud
+	DefaultEntraAuthority = "https://login.microsoftonline.com"
+	// DefaultGraphURL is the Microsoft Graph endpoint of the global cloud
+	DefaultGraphURL = "https://graph.microsoft.com"
+	// DefaultEntraUsernameAttribute is the user property mapped to User names
+	DefaultEntraUsernameAttribute = "userPrincipalName"
+)
+
+// EntraConfig configures reading groups from Microsoft Entra ID. The application needs the
+// GroupMember.Read.All and User.Read.All application permissions of Microsoft Graph.
+type EntraConfig struct {
+	// TenantID is the directory (tenant) ID
+	TenantID string
+	// ClientID is the application (client) ID the controller signs in as
+	ClientID string
+	// ClientSecret is a client secret of the application
+	ClientSecret string
+	// UsernameAttribute is the user property mapped to User names: userPrincipalName, mail or
+	// mailNickname
+	UsernameAttribute string
+	// Authority and GraphURL select the national cloud; empty uses the global one
+	Authority string
+	GraphURL  string
+}
+
+// Entra reads group memberships through Microsoft Graph, signing in with the client
+// credentials flow
+type Entra struct {
+	config EntraConfig
+	http   *http.Client
+	// now is replaced in tests
+	now func() time.Time
+
+	mu          sync.Mutex
+	token       string
+	tokenExpiry time.Time
+}
+
+// NewEntra validates config and returns a directory for it
+func NewEntra(config EntraConfig) (*Entra, error) {
+	if config.TenantID == "" || config.ClientID == "" || config.ClientSecret == "" {
+		return nil, errors.New("entra tenant ID, client ID and client secret are required")
+	}
+	switch config.UsernameAttribute {
+	case "":
+		config.UsernameAttribute = DefaultEntraUsernameAttribute
+	case "userPrincipalName", "mail", "mailNickname":
+	default:
+		return nil, fmt.Errorf("unsupported entra username attribute %q", config.UsernameAttribute)
+	}
+	if config.Authority == "" {
+		config.Authority = DefaultEntraAuthority
+	}
+	if config.GraphURL == "" {
+		config.GraphURL = DefaultGraphURL
+	}
+	config.Authority = strings.TrimSuffix(config.Authority, "/")
+	config.GraphURL = strings.TrimSuffix(config.GraphURL, "/")
+	return &Entra{
+		config: config,
+		http:   &http.Client{Timeout: 30 * time.Second},
+		now:    time.Now,
+	}, nil
+}
+
+var _ Directory = &Entra{}
+
+// entraUser holds the properties of a user selected from Microsoft Graph
+type entraUser struct {
+	UserPrincipalName string `json:"userPrincipalName"`
+	Mail              string `json:"mail"`
+	MailNickname      string `json:"mailNickname"`
+	AccountEnabled    *bool  `json:"accountEnabled"`
+}
+
+// Members implements Directory. Transitive members include those of nested groups; devices,
+// service principals and other non-user members are left out.
+func (e *Entra) Members(ctx context.Context, group string) ([]Member, error) {
+	query := url.Values{
+		"$select": {"userPrincipalName,mail,mailNickname,accountEnabled"},
+		"$top":    {"999"},
+	}
+	next := fmt.Sprintf("%s/v1.0/groups/%s/transitiveMembers/microsoft.graph.user?%s",
+		e.config.GraphURL, url.PathEscape(group), query.Encode())
+
+	var members []Member
+	for next != "" {
+		var page struct {
+			Value    []entraUser `json:"value"`
+			NextLink string      `json:"@odata.nextLink"`
+		}
+		if err := e.get(ctx, next, &page); err != nil {
+			return nil, fmt.Errorf("entra group %s: %w", group, err)
+		}
+		for _, u := range page.Value {
+			name, err := UserName(e.attribute(u))
+			if err != nil {
+				continue
+			}
+			// accountEnabled is only omitted when it was not selected; treat that as enabled
+			disabled := u.AccountEnabled != nil && !*u.AccountEnabled
+			members = append(members, Member{Name: name, Disabled: disabled})
+		}
+		next = page.NextLink
+	}
+	return collect(members), nil
+}
+
+// attribute returns the configured username attribute of u
+func (e *Entra) attribute(u entraUser) string {
+	switch e.config.UsernameAttribute {
+	case "mail":
+		return u.Mail
+	case "mailNickname":
+		return u.MailNickname
+	default:
+		return u.UserPrincipalName
+	}
+}
+
+// get fetches a Microsoft Graph URL and decodes the response into out
+func (e *Entra) get(ctx context.Context, target string, out any) error {
+	token, err := e.accessToken(ctx)
+	if err != nil {
+		return err
+	}
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, target, nil)
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Authorization", "Bearer "+token)
+	req.Header.Set("Accept", "application/json")
+	return send(e.http, req, out)
+}
+
+// accessToken returns the cached Microsoft Graph token, requesting a new one with the client
+// credentials when it is missing or about to expire
+func (e *Entra) accessToken(ctx context.Context) (string, error) {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	if e.token != "" && e.now().Before(e.tokenExpiry) {
+		return e.token, nil
+	}
+
+	form := url.Values{
+		"grant_type":    {"client_credentials"},
+		"client_id":     {e.config.ClientID},
+		"client_secret": {e.config.ClientSecret},
+		"scope":         {e.config.GraphURL + "/.default"},
+	}
+	target := fmt.Sprintf("%s/%s/oauth2/v2.0/token", e.config.Authority, url.PathEscape(e.config.TenantID))
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, target, strings.NewReader(form.Encode()))
+	if err != nil {
+		return "", err
+	}
+	req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+	var response struct {
+		AccessToken string `json:"access_token"`
+		ExpiresIn   int    `json:"expires_in"`
+	}
+	if err := send(e.http, req, &response); err != nil {
+		return "", fmt.Errorf("entra sign-in: %w", err)
+	}
+	if response.AccessToken == "" {
+		return "", errors.New("entra sign-in returned no access token")
+	}
+
+	e.token = response.AccessToken
+	// Renew well before the token ends
+	e.tokenExpiry = e.now().Add(time.Duration(response.ExpiresIn) * time.Second * 3 / 4)
+	return e.token, nil
+}
@@ -0,0 +1,139 @@
+/*
+Copyright 2025.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package directory
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"time"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("Entra", func() {
+	var (
+		ctx       context.Context
+		server    *httptest.Server
+		e         *Entra
+		signIns   int
+		memberErr bool
+	)
+
+	BeforeEach(func() {
+		ctx = context.Background()
+		signIns, memberErr = 0, false
+
+		mux := http.NewServeMux()
+		mux.HandleFunc("POST /tenant/oauth2/v2.0/token", func(w http.ResponseWriter, r *http.Request) {
+			Expect(r.ParseForm()).To(Succeed())
+			Expect(r.PostForm.Get("grant_type")).To(Equal("client_credentials"))
+			Expect(r.PostForm.Get("client_id")).To(Equal("client"))
+			Expect(r.PostForm.Get("client_secret")).To(Equal("secret"))
+			Expect(r.PostForm.Get("scope")).To(Equal(server.URL + "/.default"))
+			signIns++
+			_ = json.NewEncoder(w).Encode(map[string]any{"access_token": "graph-token", "expires_in": 3600})
+		})
+		mux.HandleFunc("GET /v1.0/groups/platform/transitiveMembers/microsoft.graph.user", func(w http.ResponseWriter, r *http.Request) {
+			if r.Header.Get("Authorization") != "Bearer graph-token" || memberErr {
+				w.WriteHeader(http.StatusForbidden)
+				_, _ = w.Write([]byte(`{"error":{"code":"Authorization_RequestDenied"}}`))
+				return
+			}
+			if r.URL.Query().Get("page") == "2" {
+				_ = json.NewEncoder(w).Encode(map[string]any{"value": []map[string]any{
+					{"userPrincipalName": "Alice@contoso.com", "mail": "alice@contoso.com", "accountEnabled": true},
+					{"userPrincipalName": "carol@fabrikam.com", "accountEnabled": false},
+				}})
+				return
+			}
+			Expect(r.URL.Query().Get("$select")).To(ContainSubstring("accountEnabled"))
+			_ = json.NewEncoder(w).Encode(map[string]any{
+				"value": []map[string]any{
+					{"userPrincipalName": "bob@contoso.com", "accountEnabled": true},
+					{"userPrincipalName": "carol@contoso.com", "accountEnabled": false},
+					{"userPrincipalName": "not a name@contoso.com", "accountEnabled": true},
+				},
+				"@odata.nextLink": server.URL + r.URL.Path + "?page=2",
+			})
+		})
+		server = httptest.NewServer(mux)
+		DeferCleanup(server.Close)
+
+		var err error
+		e, err = NewEntra(EntraConfig{
+			TenantID:     "tenant",
+			ClientID:     "client",
+			ClientSecret: "secret",
+			Authority:    server.URL,
+			GraphURL:     server.URL + "/",
+		})
+		Expect(err).NotTo(HaveOccurred())
+	})
+
+	It("requires credentials and a supported attribute", func() {
+		_, err := NewEntra(EntraConfig{TenantID: "tenant"})
+		Expect(err).To(HaveOccurred())
+		_, err = NewEntra(EntraConfig{TenantID: "tenant", ClientID: "client", ClientSecret: "secret", UsernameAttribute: "displayName"})
+		Expect(err).To(HaveOccurred())
+	})
+
+	It("lists the group's users across pages and marks disabled accounts", func() {
+		members, err := e.Members(ctx, "platform")
+		Expect(err).NotTo(HaveOccurred())
+		Expect(members).To(Equal([]Member{
+			{Name: "alice"},
+			{Name: "bob"},
+			{Name: "carol", Disabled: true},
+		}))
+
+		_, err = e.Members(ctx, "platform")
+		Expect(err).NotTo(HaveOccurred())
+		Expect(signIns).To(Equal(1))
+	})
+
+	It("signs in again when the token expired", func() {
+		_, _ = e.Members(ctx, "platform")
+		e.now = func() time.Time { return time.Now().Add(time.Hour) }
+		_, err := e.Members(ctx, "platform")
+		Expect(err).NotTo(HaveOccurred())
+		Expect(signIns).To(Equal(2))
+	})
+
+	It("reports errors of Microsoft Graph", func() {
+		memberErr = true
+		_, err := e.Members(ctx, "platform")
+		Expect(err).To(MatchError(ContainSubstring("Authorization_RequestDenied")))
+	})
+})
+
+var _ = Describe("UserName", func() {
+	It("uses the lowercased part before the domain", func() {
+		Expect(UserName("Jane.Doe@contoso.com")).To(Equal("jane-doe"))
+		Expect(UserName("jane_doe@contoso.com")).To(Equal("jane-doe"))
+		Expect(UserName("jane")).To(Equal("jane"))
+	})
+
+	It("rejects values that are not valid names", func() {
+		_, err := UserName("jane doe@contoso.com")
+		Expect(err).To(HaveOccurred())
+		_, err = UserName("")
+		Expect(err).To(HaveOccurred())
+	})
+})
@@ -0,0 +1,287 @@
+/*
+Copyright 2025.
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+*/
+
+package directory
+
+import (
+	"context"
+	"crypto"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/sha256"
+	"crypto/x509"
+	"encoding/base64"
+	"encoding/json"
+	"encoding/pem"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/url"
+	"os"
+	"strings"
+	"sync"
+	"time"
+)
+
+const (
+	// DefaultGoogleAdminURL is the endpoint of the Admin SDK Directory API
+	DefaultGoogleAdminURL = "https://admin.googleapis.com"
+	// DefaultGoogleUsernameAttribute maps the primary email address to User names
+	DefaultGoogleUsernameAttribute = "primaryEmail"
+
+	googleTokenURL = "https://oauth2.googleapis.com/token"
+	googleScopes   = "https://www.googleapis.com/auth/admin.directory.group.member.readonly " +
+		"https://www.googleapis.com/auth/admin.directory.user.readonly"
+)
+
+// GoogleConfig configures reading groups from Google Workspace through a service account with
+// domain-wide delegation of the read-only group member and user scopes
+type GoogleConfig struct {
+	// CredentialsFile is the JSON key of the service account
+	CredentialsFile string
+	// Subject is the Workspace administrator the service account acts as
+	Subject string
+	// UsernameAttribute is the user attribute mapped to User names: primaryEmail, or a custom
+	// schema field as <schema>.<field>
+	UsernameAttribute string
+	// AdminURL overrides the Directory API endpoint; empty uses the public one
+	AdminURL string
+}
+
+// Google reads group memberships through the Admin SDK Directory API
+type Google struct {
+	config GoogleConfig
+	key    *rsa.PrivateKey
+	email  string
+	// tokenURL is where the signed assertion is exchanged for an access token
+	tokenURL string
+	http     *http.Client
+	// now is replaced in tests
+	now func() time.Time
+
+	mu          sync.Mutex
+	token       string
+	tokenExpiry time.Time
+}
+
+// NewGoogle reads the service account key of config and returns a directory for it
+func NewGoogle(config GoogleConfig) (*Google, error) {
+	if config.CredentialsFile == "" || config.Subject == "" {
+		return nil, errors.New("google credentials file and administrator subject are required")
+	}
+	if config.UsernameAttribute == "" {
+		config.UsernameAttribute = DefaultGoogleUsernameAttribute
+	}
+	if config.UsernameAttribute != DefaultGoogleUsernameAttribute {
+		if schema, field, ok := strings.Cut(config.UsernameAttribute, "."); !ok || schema == "" || field == "" {
+			return nil, fmt.Errorf("google username attribute %q is neither %s nor <schema>.<field>",
+				config.UsernameAttribute, DefaultGoogleUsernameAttribute)
+		}
+	}
+	if config.AdminURL == "" {
+		config.AdminURL = DefaultGoogleAdminURL
+	}
+	config.AdminURL = strings.TrimSuffix(config.AdminURL, "/")
+
+	data, err := os.ReadFile(config.CredentialsFile)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read google credentials: %w", err)
+	}
+	var credentials struct {
+		Type        string `json:"type"`
+		ClientEmail string `json:"client_email"`
+		PrivateKey  string `json:"private_key"`
+		TokenURI    string `json:"token_uri"`
+	}
+	if err := json.Unmarshal(data, &credentials); err != nil {
+		return nil, fmt.Errorf("failed to parse google credentials: %w", err)
+	}
+	if credentials.Type != "service_account" || credentials.ClientEmail == "" {
+		return nil, errors.New("google credentials are not a service account key")
+	}
+	key, err := parseRSAKey([]byte(credentials.PrivateKey))
+	if err != nil {
+		return nil, fmt.Errorf("google service account key: %w", err)
+	}
+	tokenURL := credentials.TokenURI
+	if tokenURL == "" {
+		tokenURL = googleTokenURL
+	}
+	return &Google{
+		config:   config,
+		key:      key,
+		email:    credentials.ClientEmail,
+		tokenURL: tokenURL,
+		http:     &http.Client{Timeout: 30 * time.Second},
+		now:      time.Now,
+	}, nil
+}
+
+var _ Directory = &Google{}
+
+// Members implements Directory. Derived membership includes the members of nested groups;
+// members that are groups or the whole customer are left out.
+func (g *Google) Members(ctx context.Context, group string) ([]Member, error) {
+	var members []Member
+	pageToken := ""
+	for {
+		query := url.Values{"includeDerivedMembership": {"true"}, "maxResults": {"200"}}
+		if pageToken != "" {
+			query.Set("pageToken", pageToken)
+		}
+		var page struct {
+			Members []struct {
+				ID     string `json:"id"`
+				Email  string `json:"email"`
+				Type   string `json:"type"`
+				Status string `json:"status"`
+			} `json:"members"`
+			NextPageToken string `json:"nextPageToken"`
+		}
+		target := fmt.Sprintf("%s/admin/directory/v1/groups/%s/members?%s",
+			g.config.AdminURL, url.PathEscape(group), query.Encode())
+		if err := g.get(ctx, target, &page); err != nil {
+			return nil, fmt.Errorf("google group %s: %w", group, err)
+		}
+		for _, m := range page.Members {
+			if m.Type != "USER" {
+				continue
+			}
+			value := m.Email
+			if g.config.UsernameAttribute != DefaultGoogleUsernameAttribute {
+				var err error
+				if value, err = g.customAttribute(ctx, m.ID); err != nil {
+					return nil, fmt.Errorf("google user %s: %w", m.Email, err)
+				}
+			}
+			name, err := UserName(value)
+			if err != nil {
+				continue
+			}
+			members = append(members, Member{Name: name, Disabled: m.Status != "ACTIVE"})
+		}
+		if pageToken = page.NextPageToken; pageToken == "" {
+			return collect(members), nil
+		}
+	}
+}
+
+// customAttribute returns the custom schema field named by the username attribute of the user
+// with the given ID, or an empty string when it is not set
+func (g *Google) customAttribute(ctx context.Context, id string) (string, error) {
+	schema, field, _ := strings.Cut(g.config.UsernameAttribute, ".")
+	query := url.Values{"projection": {"custom"}, "customFieldMask": {schema}}
+	target := fmt.Sprintf("%s/admin/directory/v1/users/%s?%s", g.config.AdminURL, url.PathEscape(id), query.Encode())
+	var user struct {
+		CustomSchemas map[string]map[string]any `json:"customSchemas"`
+	}
+	if err := g.get(ctx, target, &user); err != nil {
+		return "", err
+	}
+	value, _ := user.CustomSchemas[schema][field].(string)
+	return value, nil
+}
+
+// get fetches a Directory API URL and decodes the response into out
+func (g *Google) get(ctx context.Context, target string, out any) error {
+	token, err := g.accessToken(ctx)
+	if err != nil {
+		return err
+	}
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, target, nil)
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Authorization", "Bearer "+token)
+	return send(g.http, req, out)
+}
+
+// accessToken returns the cached access token, exchanging a newly signed assertion for the
+// administrator subject when it is missing or about to expire
+func (g *Google) accessToken(ctx context.Context) (string, error) {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+	if g.token != "" && g.now().Before(g.tokenExpiry) {
+		return g.token, nil
+	}
+
+	assertion, err := g.assertion()
+	if err != nil {
+		return "", err
+	}
+	form := url.Values{
+		"grant_type": {"urn:ietf:params:oauth:grant-type:jwt-bearer"},
+		"assertion":  {assertion},
+	}
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, g.tokenURL, strings.NewReader(form.Encode()))
+	if err != nil {
+		return "", err
+	}
+	req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+	var response struct {
+		AccessToken string `json:"access_token"`
+		ExpiresIn   int    `json:"expires_in"`
+	}
+	if err := send(g.http, req, &response); err != nil {
+		return "", fmt.Errorf("google sign-in: %w", err)
+	}
+	if response.AccessToken == "" {
+		return "", errors.New("google sign-in returned no access token")
+	}
+
+	g.token = response.AccessToken
+	// Renew well before the token ends
+	g.tokenExpiry = g.now().Add(time.Duration(response.ExpiresIn) * time.Second * 3 / 4)
+	return g.token, nil
+}
+
+// assertion returns a JWT signed with the service account key, asking for the directory
+// scopes on behalf of the configured subject
+func (g *Google) assertion() (string, error) {
+	now := g.now()
+	header, err := json.Marshal(map[string]string{"alg": "RS256", "typ": "JWT"})
+	if err != nil {
+		return "", err
+	}
+	claims, err := json.Marshal(map[string]any{
+		"iss":   g.email,
+		"sub":   g.config.Subject,
+		"scope": googleScopes,
+		"aud":   g.tokenURL,
+		"iat":   now.Unix(),
+		"exp":   now.Add(time.Hour).Unix(),
+	})
+	if err != nil {
+		return "", err
+	}
+	signed := base64.RawURLEncoding.EncodeToString(header) + "." + base64.RawURLEncoding.EncodeToString(claims)
+	digest := sha256.Sum256([]byte(signed))
+	signature, err := rsa.SignPKCS1v15(rand.Reader, g.key, crypto.SHA256, digest[:])
+	if err != nil {
+		return "", fmt.Errorf("failed to sign google assertion: %w", err)
+	}
+	return signed + "." + base64.RawURLEncoding.EncodeToString(signature), nil
+}
+
+// parseRSAKey parses a PEM encoded PKCS#8 or PKCS#1 RSA private key
+func parseRSAKey(data []byte) (*rsa.PrivateKey, error) {
+	block, _ := pem.Decode(data)
+	if block == nil {
+		return nil, errors.New("no PEM encoded private key")
+	}
+	if key, err := x509.ParsePKCS1PrivateKey(block.Bytes); err == nil {
+		return key, nil
+	}
+	parsed, err := x509.ParsePKCS8PrivateKey(block.Bytes)
+	if err != nil {
+		return nil, err
+	}
+	key, ok := parsed.(*rsa.PrivateKey)
+	if !ok {
+		return nil, errors.New("private key is not an RSA key")
+	}
+	return key, nil
+}
@@ -0,0 +1,147 @@
+/*
+Copyright 2025.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package directory
+
+import (
+	"context"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/x509"
+	"encoding/base64"
+	"encoding/json"
+	"encoding/pem"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("Google", func() {
+	var (
+		ctx             context.Context
+		server          *httptest.Server
+		credentialsFile string
+		signIns         int
+	)
+
+	BeforeEach(func() {
+		ctx = context.Background()
+		signIns = 0
+
+		mux := http.NewServeMux()
+		mux.HandleFunc("POST /token", func(w http.ResponseWriter, r *http.Request) {
+			Expect(r.ParseForm()).To(Succeed())
+			Expect(r.PostForm.Get("grant_type")).To(Equal("urn:ietf:params:oauth:grant-type:jwt-bearer"))
+			parts := strings.Split(r.PostForm.Get("assertion"), ".")
+			Expect(parts).To(HaveLen(3))
+			payload, err := base64.RawURLEncoding.DecodeString(parts[1])
+			Expect(err).NotTo(HaveOccurred())
+			var claims map[string]any
+			Expect(json.Unmarshal(payload, &claims)).To(Succeed())
+			Expect(claims).To(HaveKeyWithValue("iss", "kubeuser@project.iam.gserviceaccount.com"))
+			Expect(claims).To(HaveKeyWithValue("sub", "admin@example.com"))
+			signIns++
+			_ = json.NewEncoder(w).Encode(map[string]any{"access_token": "google-token", "expires_in": 3600})
+		})
+		mux.HandleFunc("GET /admin/directory/v1/groups/platform@example.com/members", func(w http.ResponseWriter, r *http.Request) {
+			Expect(r.Header.Get("Authorization")).To(Equal("Bearer google-token"))
+			Expect(r.URL.Query().Get("includeDerivedMembership")).To(Equal("true"))
+			if r.URL.Query().Get("pageToken") == "next" {
+				_ = json.NewEncoder(w).Encode(map[string]any{"members": []map[string]any{
+					{"id": "3", "email": "carol@example.com", "type": "USER", "status": "SUSPENDED"},
+				}})
+				return
+			}
+			_ = json.NewEncoder(w).Encode(map[string]any{
+				"members": []map[string]any{
+					{"id": "1", "email": "Alice@example.com", "type": "USER", "status": "ACTIVE"},
+					{"id": "2", "email": "bob@example.com", "type": "USER", "status": "ACTIVE"},
+					{"id": "9", "email": "oncall@example.com", "type": "GROUP", "status": "ACTIVE"},
+				},
+				"nextPageToken": "next",
+			})
+		})
+		mux.HandleFunc("GET /admin/directory/v1/users/{id}", func(w http.ResponseWriter, r *http.Request) {
+			Expect(r.URL.Query().Get("customFieldMask")).To(Equal("Kubernetes"))
+			usernames := map[string]string{"1": "alice-k8s", "2": "bob-k8s"}
+			schemas := map[string]any{}
+			if name, ok := usernames[r.PathValue("id")]; ok {
+				schemas["Kubernetes"] = map[string]any{"username": name}
+			}
+			_ = json.NewEncoder(w).Encode(map[string]any{"customSchemas": schemas})
+		})
+		server = httptest.NewServer(mux)
+		DeferCleanup(server.Close)
+
+		key, err := rsa.GenerateKey(rand.Reader, 2048)
+		Expect(err).NotTo(HaveOccurred())
+		keyDER, err := x509.MarshalPKCS8PrivateKey(key)
+		Expect(err).NotTo(HaveOccurred())
+		credentials, err := json.Marshal(map[string]string{
+			"type":         "service_account",
+			"client_email": "kubeuser@project.iam.gserviceaccount.com",
+			"private_key":  string(pem.EncodeToMemory(&pem.Block{Type: "PRIVATE KEY", Bytes: keyDER})),
+			"token_uri":    server.URL + "/token",
+		})
+		Expect(err).NotTo(HaveOccurred())
+		credentialsFile = filepath.Join(GinkgoT().TempDir(), "credentials.json")
+		Expect(os.WriteFile(credentialsFile, credentials, 0o600)).To(Succeed())
+	})
+
+	newGoogle := func(attribute string) *Google {
+		g, err := NewGoogle(GoogleConfig{
+			CredentialsFile:   credentialsFile,
+			Subject:           "admin@example.com",
+			UsernameAttribute: attribute,
+			AdminURL:          server.URL,
+		})
+		Expect(err).NotTo(HaveOccurred())
+		return g
+	}
+
+	It("requires a service account key, a subject and a valid attribute", func() {
+		_, err := NewGoogle(GoogleConfig{CredentialsFile: credentialsFile})
+		Expect(err).To(HaveOccurred())
+		_, err = NewGoogle(GoogleConfig{CredentialsFile: credentialsFile, Subject: "admin@example.com", UsernameAttribute: "Kubernetes"})
+		Expect(err).To(HaveOccurred())
+	})
+
+	It("lists the group's users across pages and marks suspended accounts", func() {
+		g := newGoogle("")
+		members, err := g.Members(ctx, "platform@example.com")
+		Expect(err).NotTo(HaveOccurred())
+		Expect(members).To(Equal([]Member{
+			{Name: "alice"},
+			{Name: "bob"},
+			{Name: "carol", Disabled: true},
+		}))
+
+		_, err = g.Members(ctx, "platform@example.com")
+		Expect(err).NotTo(HaveOccurred())
+		Expect(signIns).To(Equal(1))
+	})
+
+	It("maps users by a custom schema field and skips those without it", func() {
+		members, err := newGoogle("Kubernetes.username").Members(ctx, "platform@example.com")
+		Expect(err).NotTo(HaveOccurred())
+		Expect(members).To(Equal([]Member{{Name: "alice-k8s"}, {Name: "bob-k8s"}}))
+	})
+})
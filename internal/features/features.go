@@ -0,0 +1,191 @@
+/*
+Copyright 2025.
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+*/
+
+// Package features implements feature gates for experimental KubeUser subsystems.
+// Gates are set with --feature-gates=Name=true,Other=false (or KUBEUSER_FEATURE_GATES)
+// so new functionality can ship disabled by default and be enabled per cluster.
+package features
+
+import (
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+)
+
+// Feature is the name of a feature gate
+type Feature string
+
+// Stage describes the maturity of a feature
+type Stage string
+
+const (
+	// Alpha features are disabled by default and may change or be removed
+	Alpha Stage = "ALPHA"
+	// Beta features are usually enabled by default and considered stable enough for use
+	Beta Stage = "BETA"
+	// GA features are always enabled; the gate only remains for compatibility
+	GA Stage = "GA"
+)
+
+// Spec describes a feature gate
+type Spec struct {
+	Default     bool
+	Stage       Stage
+	Description string
+}
+
+// Known feature gates
+const (
+	// OIDC enables the KubeUser token issuer for OIDC based logins
+	OIDC Feature = "OIDC"
+	// MultiCluster enables propagating users to member clusters
+	MultiCluster Feature = "MultiCluster"
+	// SelfServiceAPI enables the kubeconfig download portal, the admin API and the dashboard
+	// served by the manager
+	SelfServiceAPI Feature = "SelfServiceAPI"
+	// UsageTracking enables the audit webhook backend and usage based role recommendations
+	UsageTracking Feature = "UsageTracking"
+	// ImpersonationProxy enables the proxy forwarding user requests to the API server with impersonation
+	ImpersonationProxy Feature = "ImpersonationProxy"
+)
+
+var defaultFeatures = map[Feature]Spec{
+	OIDC:               {Default: false, Stage: Alpha, Description: "Issue OIDC tokens for users"},
+	MultiCluster:       {Default: false, Stage: Alpha, Description: "Propagate users to member clusters"},
+	SelfServiceAPI:     {Default: false, Stage: Alpha, Description: "Serve the kubeconfig download portal, admin API and dashboard from the manager"},
+	UsageTracking:      {Default: false, Stage: Alpha, Description: "Ingest audit events to track last activity and recommend narrower roles"},
+	ImpersonationProxy: {Default: false, Stage: Alpha, Description: "Proxy user requests with impersonation for instant revocation"},
+}
+
+// Gate holds the enabled state of every known feature. It implements flag.Value.
+type Gate struct {
+	mu      sync.RWMutex
+	known   map[Feature]Spec
+	enabled map[Feature]bool
+}
+
+// DefaultGate is the process-wide gate configured from the command line
+var DefaultGate = NewGate(defaultFeatures)
+
+// NewGate returns a gate for the given features, all at their defaults
+func NewGate(known map[Feature]Spec) *Gate {
+	g := &Gate{known: map[Feature]Spec{}, enabled: map[Feature]bool{}}
+	for f, spec := range known {
+		g.known[f] = spec
+	}
+	return g
+}
+
+// Enabled reports whether the feature is enabled on the default gate
+func Enabled(f Feature) bool {
+	return DefaultGate.Enabled(f)
+}
+
+// Enabled reports whether the feature is enabled. Unknown features are disabled.
+func (g *Gate) Enabled(f Feature) bool {
+	g.mu.RLock()
+	defer g.mu.RUnlock()
+	spec, ok := g.known[f]
+	if !ok {
+		return false
+	}
+	if spec.Stage == GA {
+		return true
+	}
+	if enabled, ok := g.enabled[f]; ok {
+		return enabled
+	}
+	return spec.Default
+}
+
+// Set parses a comma separated list of Name=bool pairs. It implements flag.Value.
+func (g *Gate) Set(value string) error {
+	m := map[string]bool{}
+	for _, pair := range strings.Split(value, ",") {
+		pair = strings.TrimSpace(pair)
+		if pair == "" {
+			continue
+		}
+		name, raw, found := strings.Cut(pair, "=")
+		if !found {
+			return fmt.Errorf("missing bool value for feature gate %q", name)
+		}
+		enabled, err := strconv.ParseBool(strings.TrimSpace(raw))
+		if err != nil {
+			return fmt.Errorf("invalid value %q for feature gate %q: %w", raw, name, err)
+		}
+		m[strings.TrimSpace(name)] = enabled
+	}
+	return g.SetFromMap(m)
+}
+
+// SetFromMap applies explicit settings. Unknown features and attempts to disable GA features are rejected.
+func (g *Gate) SetFromMap(m map[string]bool) error {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+
+	for name, enabled := range m {
+		spec, ok := g.known[Feature(name)]
+		if !ok {
+			return fmt.Errorf("unknown feature gate %q", name)
+		}
+		if spec.Stage == GA && !enabled {
+			return fmt.Errorf("feature gate %q is GA and cannot be disabled", name)
+		}
+	}
+	for name, enabled := range m {
+		g.enabled[Feature(name)] = enabled
+	}
+	return nil
+}
+
+// String renders the explicitly set gates. It implements flag.Value.
+func (g *Gate) String() string {
+	if g == nil {
+		return ""
+	}
+	g.mu.RLock()
+	defer g.mu.RUnlock()
+	pairs := make([]string, 0, len(g.enabled))
+	for f, enabled := range g.enabled {
+		pairs = append(pairs, fmt.Sprintf("%s=%t", f, enabled))
+	}
+	sort.Strings(pairs)
+	return strings.Join(pairs, ",")
+}
+
+// KnownFeatures describes every gate for flag help output
+func (g *Gate) KnownFeatures() []string {
+	g.mu.RLock()
+	defer g.mu.RUnlock()
+	out := make([]string, 0, len(g.known))
+	for f, spec := range g.known {
+		out = append(out, fmt.Sprintf("%s=true|false (%s - default=%t): %s", f, spec.Stage, spec.Default, spec.Description))
+	}
+	sort.Strings(out)
+	return out
+}
+
+// EnabledFeatures lists the names of all enabled features
+func (g *Gate) EnabledFeatures() []string {
+	g.mu.RLock()
+	known := make([]Feature, 0, len(g.known))
+	for f := range g.known {
+		known = append(known, f)
+	}
+	g.mu.RUnlock()
+
+	var out []string
+	for _, f := range known {
+		if g.Enabled(f) {
+			out = append(out, string(f))
+		}
+	}
+	sort.Strings(out)
+	return out
+}
@@ -0,0 +1,56 @@
+/*
+Copyright 2025.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package features
+
+import (
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("Gate", func() {
+	var gate *Gate
+
+	BeforeEach(func() {
+		gate = NewGate(map[Feature]Spec{
+			"AlphaThing": {Default: false, Stage: Alpha},
+			"BetaThing":  {Default: true, Stage: Beta},
+			"GAThing":    {Default: true, Stage: GA},
+		})
+	})
+
+	It("uses defaults when nothing is set", func() {
+		Expect(gate.Enabled("AlphaThing")).To(BeFalse())
+		Expect(gate.Enabled("BetaThing")).To(BeTrue())
+		Expect(gate.Enabled("Unknown")).To(BeFalse())
+		Expect(gate.EnabledFeatures()).To(Equal([]string{"BetaThing", "GAThing"}))
+	})
+
+	It("parses flag values", func() {
+		Expect(gate.Set("AlphaThing=true, BetaThing=false")).To(Succeed())
+		Expect(gate.Enabled("AlphaThing")).To(BeTrue())
+		Expect(gate.Enabled("BetaThing")).To(BeFalse())
+		Expect(gate.String()).To(Equal("AlphaThing=true,BetaThing=false"))
+	})
+
+	It("rejects invalid settings without applying any of them", func() {
+		Expect(gate.Set("AlphaThing=true,Unknown=true")).To(MatchError(ContainSubstring("unknown feature gate")))
+		Expect(gate.Enabled("AlphaThing")).To(BeFalse())
+		Expect(gate.Set("AlphaThing")).To(HaveOccurred())
+		Expect(gate.Set("AlphaThing=maybe")).To(HaveOccurred())
+		Expect(gate.Set("GAThing=false")).To(MatchError(ContainSubstring("cannot be disabled")))
+	})
+})
@@ -0,0 +1,246 @@
+/*
+Copyright 2025.
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+*/
+
+// Package gcp is a minimal client for the IAM policy of a Google Cloud project. It signs in
+// through the metadata server, as the Google service account of the controller's Kubernetes
+// ServiceAccount with Workload Identity, so KubeUser does not depend on the Google Cloud SDK.
+package gcp
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"os"
+	"slices"
+	"strings"
+	"sync"
+	"time"
+)
+
+const (
+	// DefaultResourceManagerURL is the endpoint of the Cloud Resource Manager API
+	DefaultResourceManagerURL = "https://cloudresourcemanager.googleapis.com"
+	// defaultMetadataHost serves access tokens on GKE and Compute Engine; GCE_METADATA_HOST
+	// overrides it
+	defaultMetadataHost = "metadata.google.internal"
+)
+
+// Error is an error response of a Google API
+type Error struct {
+	Status  int
+	Message string
+}
+
+func (e *Error) Error() string {
+	return fmt.Sprintf("google returned %d: %s", e.Status, e.Message)
+}
+
+// IsConflict reports whether err is the rejection of a policy that changed since it was read
+func IsConflict(err error) bool {
+	var googleErr *Error
+	return errors.As(err, &googleErr) && googleErr.Status == http.StatusConflict
+}
+
+// Binding grants role to members, unless it has a condition
+type Binding struct {
+	Role      string          `json:"role"`
+	Members   []string        `json:"members,omitempty"`
+	Condition json.RawMessage `json:"condition,omitempty"`
+}
+
+// Policy is an IAM policy. Audit configs are passed through unchanged.
+type Policy struct {
+	Version      int             `json:"version,omitempty"`
+	Etag         string          `json:"etag,omitempty"`
+	Bindings     []Binding       `json:"bindings,omitempty"`
+	AuditConfigs json.RawMessage `json:"auditConfigs,omitempty"`
+}
+
+// HasMember reports whether member holds role without a condition
+func (p *Policy) HasMember(role, member string) bool {
+	return slices.ContainsFunc(p.Bindings, func(b Binding) bool {
+		return b.Role == role && b.Condition == nil && slices.Contains(b.Members, member)
+	})
+}
+
+// AddMember grants role to member without a condition
+func (p *Policy) AddMember(role, member string) {
+	if p.HasMember(role, member) {
+		return
+	}
+	for i := range p.Bindings {
+		if p.Bindings[i].Role == role && p.Bindings[i].Condition == nil {
+			p.Bindings[i].Members = append(p.Bindings[i].Members, member)
+			return
+		}
+	}
+	p.Bindings = append(p.Bindings, Binding{Role: role, Members: []string{member}})
+}
+
+// RemoveMember revokes role from member where it was granted without a condition. Bindings
+// left without members are dropped.
+func (p *Policy) RemoveMember(role, member string) {
+	bindings := p.Bindings[:0]
+	for _, b := range p.Bindings {
+		if b.Role == role && b.Condition == nil {
+			b.Members = slices.DeleteFunc(b.Members, func(m string) bool { return m == member })
+			if len(b.Members) == 0 {
+				continue
+			}
+		}
+		bindings = append(bindings, b)
+	}
+	p.Bindings = bindings
+}
+
+// Member returns the IAM member of a Google account: a service account for addresses of the
+// iam.gserviceaccount.com domain, else a user
+func Member(account string) string {
+	if strings.HasSuffix(account, ".gserviceaccount.com") {
+		return "serviceAccount:" + account
+	}
+	return "user:" + account
+}
+
+// Projects reads and writes the IAM policies of projects
+type Projects struct {
+	endpoint string
+	tokens   *metadataTokens
+	http     *http.Client
+}
+
+// NewProjects returns a client signing in through the metadata server
+func NewProjects(httpClient *http.Client) *Projects {
+	host := os.Getenv("GCE_METADATA_HOST")
+	if host == "" {
+		host = defaultMetadataHost
+	}
+	return &Projects{
+		endpoint: DefaultResourceManagerURL,
+		tokens: &metadataTokens{
+			url:  "http://" + host + "/computeMetadata/v1/instance/service-accounts/default/token",
+			http: httpClient,
+			now:  time.Now,
+		},
+		http: httpClient,
+	}
+}
+
+// GetPolicy returns the IAM policy of project
+func (c *Projects) GetPolicy(ctx context.Context, project string) (*Policy, error) {
+	var policy Policy
+	input := map[string]any{"options": map[string]int{"requestedPolicyVersion": 3}}
+	if err := c.call(ctx, project, "getIamPolicy", input, &policy); err != nil {
+		return nil, err
+	}
+	return &policy, nil
+}
+
+// SetPolicy writes policy, as read by GetPolicy and changed, to project. It fails with a
+// conflict when the policy changed since it was read.
+func (c *Projects) SetPolicy(ctx context.Context, project string, policy *Policy) error {
+	return c.call(ctx, project, "setIamPolicy", map[string]any{"policy": policy}, nil)
+}
+
+// call invokes a custom method of a project in the Resource Manager API
+func (c *Projects) call(ctx context.Context, project, method string, input, output any) error {
+	body, err := json.Marshal(input)
+	if err != nil {
+		return err
+	}
+	token, err := c.tokens.token(ctx)
+	if err != nil {
+		return err
+	}
+	target := fmt.Sprintf("%s/v1/projects/%s:%s", c.endpoint, url.PathEscape(project), method)
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, target, bytes.NewReader(body))
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Authorization", "Bearer "+token)
+	req.Header.Set("Content-Type", "application/json")
+	resp, err := c.http.Do(req)
+	if err != nil {
+		return err
+	}
+	defer func() { _ = resp.Body.Close() }()
+	respBody, err := io.ReadAll(io.LimitReader(resp.Body, 4<<20))
+	if err != nil {
+		return err
+	}
+	if resp.StatusCode >= http.StatusBadRequest {
+		googleErr := &Error{Status: resp.StatusCode, Message: strings.TrimSpace(string(respBody))}
+		var errorBody struct {
+			Error struct {
+				Message string `json:"message"`
+			} `json:"error"`
+		}
+		if json.Unmarshal(respBody, &errorBody) == nil && errorBody.Error.Message != "" {
+			googleErr.Message = errorBody.Error.Message
+		}
+		return googleErr
+	}
+	if output == nil {
+		return nil
+	}
+	if err := json.Unmarshal(respBody, output); err != nil {
+		return fmt.Errorf("invalid response of %s: %w", method, err)
+	}
+	return nil
+}
+
+// metadataTokens caches the access tokens of the metadata server
+type metadataTokens struct {
+	url  string
+	http *http.Client
+	now  func() time.Time
+
+	mu      sync.Mutex
+	cached  string
+	expires time.Time
+}
+
+func (m *metadataTokens) token(ctx context.Context) (string, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	if m.cached != "" && m.now().Before(m.expires) {
+		return m.cached, nil
+	}
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, m.url, nil)
+	if err != nil {
+		return "", err
+	}
+	req.Header.Set("Metadata-Flavor", "Google")
+	resp, err := m.http.Do(req)
+	if err != nil {
+		return "", fmt.Errorf("google sign-in through the metadata server: %w", err)
+	}
+	defer func() { _ = resp.Body.Close() }()
+	if resp.StatusCode != http.StatusOK {
+		body, _ := io.ReadAll(io.LimitReader(resp.Body, 1024))
+		return "", fmt.Errorf("google sign-in: metadata server returned %d: %s", resp.StatusCode,
+			strings.TrimSpace(string(body)))
+	}
+	var response struct {
+		AccessToken string `json:"access_token"`
+		ExpiresIn   int    `json:"expires_in"`
+	}
+	if err := json.NewDecoder(resp.Body).Decode(&response); err != nil {
+		return "", fmt.Errorf("google sign-in: %w", err)
+	}
+	if response.AccessToken == "" {
+		return "", errors.New("google sign-in returned no access token")
+	}
+	m.cached = response.AccessToken
+	// Renew well before the token ends
+	m.expires = m.now().Add(time.Duration(response.ExpiresIn) * time.Second * 3 / 4)
+	return m.cached, nil
+}
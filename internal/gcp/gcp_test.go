@@ -0,0 +1,111 @@
+/*
+Copyright 2025.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package gcp
+
+import (
+	"context"
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("Policy", func() {
+	It("grants and revokes roles without touching conditional bindings", func() {
+		policy := &Policy{Bindings: []Binding{
+			{Role: "roles/container.viewer", Members: []string{"user:bob@example.com"}},
+			{Role: "roles/container.developer", Members: []string{"user:jane@example.com"},
+				Condition: json.RawMessage(`{"expression":"request.time < timestamp('2030-01-01T00:00:00Z')"}`)},
+		}}
+		policy.AddMember("roles/container.viewer", "user:jane@example.com")
+		policy.AddMember("roles/container.developer", "user:jane@example.com")
+		Expect(policy.HasMember("roles/container.viewer", "user:jane@example.com")).To(BeTrue())
+		Expect(policy.Bindings).To(HaveLen(3))
+		Expect(policy.Bindings[0].Members).To(Equal([]string{"user:bob@example.com", "user:jane@example.com"}))
+
+		policy.RemoveMember("roles/container.developer", "user:jane@example.com")
+		Expect(policy.Bindings).To(HaveLen(2))
+		Expect(policy.Bindings[1].Condition).NotTo(BeNil())
+		Expect(policy.HasMember("roles/container.developer", "user:jane@example.com")).To(BeFalse())
+	})
+
+	It("tells service accounts from users", func() {
+		Expect(Member("jane@example.com")).To(Equal("user:jane@example.com"))
+		Expect(Member("ci@project.iam.gserviceaccount.com")).To(Equal("serviceAccount:ci@project.iam.gserviceaccount.com"))
+	})
+})
+
+var _ = Describe("Projects", func() {
+	var (
+		projects *Projects
+		tokens   int
+		bodies   map[string]string
+		conflict bool
+	)
+
+	BeforeEach(func() {
+		tokens, bodies, conflict = 0, map[string]string{}, false
+		mux := http.NewServeMux()
+		mux.HandleFunc("/computeMetadata/v1/instance/service-accounts/default/token", func(w http.ResponseWriter, r *http.Request) {
+			Expect(r.Header.Get("Metadata-Flavor")).To(Equal("Google"))
+			tokens++
+			_, _ = io.WriteString(w, `{"access_token":"ya29.token","expires_in":3600}`)
+		})
+		mux.HandleFunc("/v1/projects/", func(w http.ResponseWriter, r *http.Request) {
+			Expect(r.Header.Get("Authorization")).To(Equal("Bearer ya29.token"))
+			body, _ := io.ReadAll(r.Body)
+			bodies[r.URL.Path] = string(body)
+			switch {
+			case strings.HasSuffix(r.URL.Path, ":getIamPolicy"):
+				_, _ = io.WriteString(w, `{"version":1,"etag":"BwX=","bindings":[{"role":"roles/viewer","members":["user:bob@example.com"]}]}`)
+			case conflict:
+				w.WriteHeader(http.StatusConflict)
+				_, _ = io.WriteString(w, `{"error":{"code":409,"message":"There were concurrent policy changes.","status":"ABORTED"}}`)
+			default:
+				_, _ = io.WriteString(w, `{}`)
+			}
+		})
+		server := httptest.NewServer(mux)
+		DeferCleanup(server.Close)
+		GinkgoT().Setenv("GCE_METADATA_HOST", strings.TrimPrefix(server.URL, "http://"))
+		projects = NewProjects(server.Client())
+		projects.endpoint = server.URL
+	})
+
+	It("reads and writes the policy with its etag", func() {
+		policy, err := projects.GetPolicy(context.Background(), "prod")
+		Expect(err).NotTo(HaveOccurred())
+		Expect(bodies["/v1/projects/prod:getIamPolicy"]).To(MatchJSON(`{"options":{"requestedPolicyVersion":3}}`))
+		policy.AddMember("roles/container.clusterViewer", "user:jane@example.com")
+		Expect(projects.SetPolicy(context.Background(), "prod", policy)).To(Succeed())
+		Expect(bodies["/v1/projects/prod:setIamPolicy"]).To(MatchJSON(`{"policy":{"version":1,"etag":"BwX=","bindings":[
+			{"role":"roles/viewer","members":["user:bob@example.com"]},
+			{"role":"roles/container.clusterViewer","members":["user:jane@example.com"]}]}}`))
+		Expect(tokens).To(Equal(1))
+	})
+
+	It("reports concurrent changes as conflicts", func() {
+		conflict = true
+		err := projects.SetPolicy(context.Background(), "prod", &Policy{Etag: "BwX="})
+		Expect(IsConflict(err)).To(BeTrue())
+		Expect(err).To(MatchError(ContainSubstring("concurrent policy changes")))
+	})
+})
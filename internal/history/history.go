@@ -0,0 +1,227 @@
+/*
+Copyright 2025.
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+*/
+
+// Package history keeps the access grant history: an AccessGrantRecord for every binding
+// KubeUser creates, changes or removes for a user, every certificate it issues and every
+// revocation. Records are never changed and are deleted after a retention period, so auditors
+// can tell who held a role at any time within it.
+package history
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"time"
+
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	logf "sigs.k8s.io/controller-runtime/pkg/log"
+
+	authv1alpha1 "github.com/openkube-hub/KubeUser/api/v1alpha1"
+)
+
+const (
+	// UserLabel names the User a record belongs to
+	UserLabel = "auth.openkube.io/user"
+	// ActionLabel is the record's action, for selecting e.g. only revocations
+	ActionLabel = "auth.openkube.io/action"
+	// DefaultRetention is how long records are kept by default, a little over a year so the
+	// last annual audit period is always covered
+	DefaultRetention = 400 * 24 * time.Hour
+)
+
+// Reasons of removals and revocations
+const (
+	ReasonUserRevoked   = "UserRevoked"
+	ReasonUserSuspended = "UserSuspended"
+	ReasonUserDeleted   = "UserDeleted"
+)
+
+// Record adds record to the history of user. The User, its generation and who last changed
+// it are filled in from user.
+func Record(ctx context.Context, c client.Client, user *authv1alpha1.User, record authv1alpha1.AccessGrantRecordSpec,
+	now time.Time) error {
+	record.User = user.Name
+	record.UserGeneration = user.Generation
+	record.Time = metav1.NewTime(now)
+	if record.ChangedBy == "" {
+		record.ChangedBy = user.Annotations[authv1alpha1.ChangedByAnnotation]
+	}
+	entry := &authv1alpha1.AccessGrantRecord{
+		ObjectMeta: metav1.ObjectMeta{
+			GenerateName: user.Name + "-",
+			Labels:       map[string]string{UserLabel: user.Name, ActionLabel: string(record.Action)},
+		},
+		Spec: record,
+	}
+	// Issuance is retried until the certificate is stored, so each certificate gets a record
+	// of its own name that is created once
+	if record.Action == authv1alpha1.AccessGrantCredentialIssued && record.SerialNumber != "" {
+		entry.Name = user.Name + "-issued-" + record.SerialNumber
+	}
+	if err := c.Create(ctx, entry); err != nil && (entry.Name == "" || !apierrors.IsAlreadyExists(err)) {
+		return fmt.Errorf("failed to record %s access change of user %s: %w", record.Action, user.Name, err)
+	}
+	return nil
+}
+
+// Prune deletes the records made before cutoff
+func Prune(ctx context.Context, reader client.Reader, writer client.Writer, cutoff time.Time) (int, error) {
+	var records authv1alpha1.AccessGrantRecordList
+	if err := reader.List(ctx, &records); err != nil {
+		return 0, fmt.Errorf("failed to list access grant records: %w", err)
+	}
+	deleted := 0
+	for i := range records.Items {
+		if !records.Items[i].Spec.Time.Time.Before(cutoff) {
+			continue
+		}
+		if err := writer.Delete(ctx, &records.Items[i]); err != nil && !apierrors.IsNotFound(err) {
+			return deleted, fmt.Errorf("failed to delete access grant record %s: %w", records.Items[i].Name, err)
+		}
+		deleted++
+	}
+	return deleted, nil
+}
+
+// Pruner deletes records older than Retention every Interval. It runs on the leader only.
+type Pruner struct {
+	// Reader lists the records; it should read from the API server, so the records are not
+	// cached by the manager
+	Reader client.Reader
+	Writer client.Writer
+	// Retention is how long records are kept; zero keeps them forever
+	Retention time.Duration
+	// Interval defaults to an hour
+	Interval time.Duration
+}
+
+// NeedLeaderElection implements manager.LeaderElectionRunnable
+func (p *Pruner) NeedLeaderElection() bool {
+	return true
+}
+
+// Start implements manager.Runnable; it prunes until ctx is done
+func (p *Pruner) Start(ctx context.Context) error {
+	if p.Retention <= 0 {
+		return nil
+	}
+	logger := logf.FromContext(ctx).WithName("access-history")
+	interval := p.Interval
+	if interval <= 0 {
+		interval = time.Hour
+	}
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+	for {
+		deleted, err := Prune(ctx, p.Reader, p.Writer, time.Now().Add(-p.Retention))
+		if err != nil {
+			logger.Error(err, "Failed to prune access grant records")
+		} else if deleted > 0 {
+			logger.Info("Pruned access grant records", "deleted", deleted, "retention", p.Retention)
+		}
+		select {
+		case <-ctx.Done():
+			return nil
+		case <-ticker.C:
+		}
+	}
+}
+
+// Grant is a period a user held a binding
+type Grant struct {
+	User    string
+	Binding authv1alpha1.GrantedBinding
+	// From is when the binding was created, or the start of the history if it existed before
+	From time.Time
+	// Until is when the binding was removed; zero while it still exists
+	Until time.Time
+	// GrantedBy and RemovedBy are who changed the User before the binding was created and
+	// removed
+	GrantedBy string
+	RemovedBy string
+	// RemovalReason is set when the binding was removed by a revocation, suspension or deletion
+	RemovalReason string
+	// SerialNumbers are the certificates issued for the user while it held the binding
+	SerialNumbers []string
+}
+
+// Overlaps reports whether the grant was held at some point in [from, until). Zero times leave
+// the period open.
+func (g *Grant) Overlaps(from, until time.Time) bool {
+	if !until.IsZero() && !g.From.Before(until) {
+		return false
+	}
+	return from.IsZero() || g.Until.IsZero() || g.Until.After(from)
+}
+
+type bindingKey struct {
+	user, kind, namespace, name string
+}
+
+// Grants replays records into the periods users held their bindings, ordered by start
+func Grants(records []authv1alpha1.AccessGrantRecord) []Grant {
+	sorted := append([]authv1alpha1.AccessGrantRecord(nil), records...)
+	sort.SliceStable(sorted, func(i, j int) bool {
+		return sorted[i].Spec.Time.Before(&sorted[j].Spec.Time)
+	})
+
+	var grants []Grant
+	open := map[bindingKey]int{}
+	for _, record := range sorted {
+		spec := record.Spec
+		switch spec.Action {
+		case authv1alpha1.AccessGrantCredentialIssued:
+			for key, i := range open {
+				if key.user == spec.User {
+					grants[i].SerialNumbers = append(grants[i].SerialNumbers, spec.SerialNumber)
+				}
+			}
+			continue
+		case authv1alpha1.AccessGrantCredentialsRevoked:
+			continue
+		}
+		if spec.Binding == nil {
+			continue
+		}
+		key := bindingKey{spec.User, spec.Binding.Kind, spec.Binding.Namespace, spec.Binding.Name}
+		i, held := open[key]
+		switch spec.Action {
+		case authv1alpha1.AccessGrantGranted, authv1alpha1.AccessGrantChanged:
+			if held && grants[i].Binding == *spec.Binding {
+				continue
+			}
+			if held {
+				grants[i].Until = spec.Time.Time
+				grants[i].RemovedBy = spec.ChangedBy
+			}
+			grant := Grant{User: spec.User, Binding: *spec.Binding, From: spec.Time.Time, GrantedBy: spec.ChangedBy}
+			if spec.Action == authv1alpha1.AccessGrantChanged && !held {
+				// Created before the history starts
+				grant.From = time.Time{}
+				grant.GrantedBy = ""
+			}
+			if spec.SerialNumber != "" {
+				grant.SerialNumbers = []string{spec.SerialNumber}
+			}
+			open[key] = len(grants)
+			grants = append(grants, grant)
+		case authv1alpha1.AccessGrantRemoved:
+			if !held {
+				grants = append(grants, Grant{User: spec.User, Binding: *spec.Binding, Until: spec.Time.Time,
+					RemovedBy: spec.ChangedBy, RemovalReason: spec.Reason})
+				continue
+			}
+			grants[i].Until = spec.Time.Time
+			grants[i].RemovedBy = spec.ChangedBy
+			grants[i].RemovalReason = spec.Reason
+			delete(open, key)
+		}
+	}
+	sort.SliceStable(grants, func(i, j int) bool { return grants[i].From.Before(grants[j].From) })
+	return grants
+}
@@ -0,0 +1,149 @@
+/*
+Copyright 2025.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package history
+
+import (
+	"context"
+	"time"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+
+	authv1alpha1 "github.com/openkube-hub/KubeUser/api/v1alpha1"
+)
+
+var _ = Describe("Access history", func() {
+	var (
+		ctx  context.Context
+		c    client.Client
+		now  time.Time
+		jane *authv1alpha1.User
+		edit = authv1alpha1.GrantedBinding{
+			Kind: "RoleBinding", Namespace: "prod", Name: "jane-edit", RoleKind: "ClusterRole", RoleName: "edit",
+		}
+	)
+
+	BeforeEach(func() {
+		ctx = context.Background()
+		now = time.Date(2025, 5, 10, 12, 0, 0, 0, time.UTC)
+		scheme := runtime.NewScheme()
+		Expect(authv1alpha1.AddToScheme(scheme)).To(Succeed())
+		c = fake.NewClientBuilder().WithScheme(scheme).Build()
+		jane = &authv1alpha1.User{ObjectMeta: metav1.ObjectMeta{
+			Name:        "jane",
+			Generation:  3,
+			Annotations: map[string]string{authv1alpha1.ChangedByAnnotation: "admin@example.com"},
+		}}
+	})
+
+	records := func() []authv1alpha1.AccessGrantRecord {
+		var list authv1alpha1.AccessGrantRecordList
+		Expect(c.List(ctx, &list)).To(Succeed())
+		return list.Items
+	}
+
+	record := func(action authv1alpha1.AccessGrantAction, at time.Time, by string) authv1alpha1.AccessGrantRecord {
+		binding := edit
+		return authv1alpha1.AccessGrantRecord{Spec: authv1alpha1.AccessGrantRecordSpec{
+			User: "jane", Action: action, Time: metav1.NewTime(at), ChangedBy: by, Binding: &binding,
+		}}
+	}
+
+	It("records changes with the user and who changed it", func() {
+		binding := edit
+		Expect(Record(ctx, c, jane, authv1alpha1.AccessGrantRecordSpec{
+			Action: authv1alpha1.AccessGrantGranted, Binding: &binding, SerialNumber: "1a",
+		}, now)).To(Succeed())
+
+		items := records()
+		Expect(items).To(HaveLen(1))
+		Expect(items[0].Labels).To(HaveKeyWithValue(UserLabel, "jane"))
+		Expect(items[0].Labels).To(HaveKeyWithValue(ActionLabel, "Granted"))
+		Expect(items[0].Spec.User).To(Equal("jane"))
+		Expect(items[0].Spec.UserGeneration).To(BeEquivalentTo(3))
+		Expect(items[0].Spec.ChangedBy).To(Equal("admin@example.com"))
+		Expect(items[0].Spec.Time.Time).To(BeTemporally("==", now))
+		Expect(items[0].Spec.SerialNumber).To(Equal("1a"))
+	})
+
+	It("records each issued certificate once", func() {
+		issued := authv1alpha1.AccessGrantRecordSpec{Action: authv1alpha1.AccessGrantCredentialIssued, SerialNumber: "2b"}
+		Expect(Record(ctx, c, jane, issued, now)).To(Succeed())
+		Expect(Record(ctx, c, jane, issued, now.Add(time.Minute))).To(Succeed())
+
+		items := records()
+		Expect(items).To(HaveLen(1))
+		Expect(items[0].Name).To(Equal("jane-issued-2b"))
+	})
+
+	It("prunes records past the retention", func() {
+		for _, at := range []time.Time{now.Add(-48 * time.Hour), now.Add(-time.Hour)} {
+			Expect(Record(ctx, c, jane, authv1alpha1.AccessGrantRecordSpec{
+				Action: authv1alpha1.AccessGrantCredentialsRevoked,
+			}, at)).To(Succeed())
+		}
+		deleted, err := Prune(ctx, c, c, now.Add(-24*time.Hour))
+		Expect(err).NotTo(HaveOccurred())
+		Expect(deleted).To(Equal(1))
+		Expect(records()).To(HaveLen(1))
+	})
+
+	It("replays records into grant periods", func() {
+		may := time.Date(2025, 5, 1, 0, 0, 0, 0, time.UTC)
+		june := time.Date(2025, 6, 1, 0, 0, 0, 0, time.UTC)
+		removed := record(authv1alpha1.AccessGrantRemoved, may.Add(20*24*time.Hour), "security@example.com")
+		removed.Spec.Reason = ReasonUserRevoked
+		issued := authv1alpha1.AccessGrantRecord{Spec: authv1alpha1.AccessGrantRecordSpec{
+			User: "jane", Action: authv1alpha1.AccessGrantCredentialIssued, Time: metav1.NewTime(may.Add(5 * 24 * time.Hour)),
+			SerialNumber: "3c",
+		}}
+
+		grants := Grants([]authv1alpha1.AccessGrantRecord{
+			removed,
+			issued,
+			record(authv1alpha1.AccessGrantChanged, may.Add(3*24*time.Hour), "admin@example.com"),
+			record(authv1alpha1.AccessGrantGranted, may.Add(-10*24*time.Hour), "admin@example.com"),
+			record(authv1alpha1.AccessGrantGranted, june.Add(24*time.Hour), "admin@example.com"),
+		})
+		Expect(grants).To(HaveLen(2))
+		Expect(grants[0].From).To(BeTemporally("==", may.Add(-10*24*time.Hour)))
+		Expect(grants[0].Until).To(BeTemporally("==", may.Add(20*24*time.Hour)))
+		Expect(grants[0].GrantedBy).To(Equal("admin@example.com"))
+		Expect(grants[0].RemovedBy).To(Equal("security@example.com"))
+		Expect(grants[0].RemovalReason).To(Equal(ReasonUserRevoked))
+		Expect(grants[0].SerialNumbers).To(Equal([]string{"3c"}))
+		Expect(grants[0].Overlaps(may, june)).To(BeTrue())
+
+		Expect(grants[1].Until.IsZero()).To(BeTrue())
+		Expect(grants[1].Overlaps(may, june)).To(BeFalse())
+		Expect(grants[1].Overlaps(june, time.Time{})).To(BeTrue())
+	})
+
+	It("keeps grants made before the history started open-ended", func() {
+		grants := Grants([]authv1alpha1.AccessGrantRecord{
+			record(authv1alpha1.AccessGrantRemoved, now, "admin@example.com"),
+		})
+		Expect(grants).To(HaveLen(1))
+		Expect(grants[0].From.IsZero()).To(BeTrue())
+		Expect(grants[0].Overlaps(time.Time{}, now.Add(-time.Hour))).To(BeTrue())
+		Expect(grants[0].Overlaps(now, time.Time{})).To(BeFalse())
+	})
+})
@@ -0,0 +1,185 @@
+/*
+Copyright 2025.
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+*/
+
+// Package inventory keeps an IssuedCertificate for every client certificate KubeUser issues
+// and serves the list of those it revoked. Kubernetes cannot revoke client certificates, so
+// the list is for tooling in front of the API server and for audits after a compromise.
+package inventory
+
+import (
+	"context"
+	"crypto/x509"
+	"encoding/json"
+	"encoding/pem"
+	"errors"
+	"fmt"
+	"net/http"
+	"time"
+
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+
+	authv1alpha1 "github.com/openkube-hub/KubeUser/api/v1alpha1"
+)
+
+// Record adds the certificate issued for username through csrName to the inventory and
+// revokes the user's earlier certificates as superseded
+func Record(ctx context.Context, c client.Client, username, csrName string, certPEM []byte, now time.Time) error {
+	cert, err := parseCertificate(certPEM)
+	if err != nil {
+		return err
+	}
+	serial := cert.SerialNumber.Text(16)
+	issued := &authv1alpha1.IssuedCertificate{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:   username + "-" + serial,
+			Labels: map[string]string{"auth.openkube.io/user": username},
+		},
+		Spec: authv1alpha1.IssuedCertificateSpec{
+			User:         username,
+			SerialNumber: serial,
+			CommonName:   cert.Subject.CommonName,
+			NotBefore:    metav1.NewTime(cert.NotBefore),
+			NotAfter:     metav1.NewTime(cert.NotAfter),
+			CSRName:      csrName,
+		},
+	}
+	if err := c.Create(ctx, issued); err != nil && !apierrors.IsAlreadyExists(err) {
+		return fmt.Errorf("failed to record issued certificate %s: %w", serial, err)
+	}
+	return revoke(ctx, c, username, authv1alpha1.RevocationReasonSuperseded, serial, now)
+}
+
+// SerialNumber returns the serial number of the PEM encoded certificate in lowercase hex, as
+// the inventory records it
+func SerialNumber(certPEM []byte) (string, error) {
+	cert, err := parseCertificate(certPEM)
+	if err != nil {
+		return "", err
+	}
+	return cert.SerialNumber.Text(16), nil
+}
+
+func parseCertificate(certPEM []byte) (*x509.Certificate, error) {
+	block, _ := pem.Decode(certPEM)
+	if block == nil {
+		return nil, errors.New("expected a PEM encoded certificate")
+	}
+	cert, err := x509.ParseCertificate(block.Bytes)
+	if err != nil {
+		return nil, fmt.Errorf("unable to parse certificate: %w", err)
+	}
+	return cert, nil
+}
+
+// Revoke marks all certificates issued for username as revoked for reason
+func Revoke(ctx context.Context, c client.Client, username, reason string, now time.Time) error {
+	return revoke(ctx, c, username, reason, "", now)
+}
+
+// revoke marks the certificates of username other than the one with serial keep as revoked
+func revoke(ctx context.Context, c client.Client, username, reason, keep string, now time.Time) error {
+	var issued authv1alpha1.IssuedCertificateList
+	if err := c.List(ctx, &issued, client.MatchingLabels{"auth.openkube.io/user": username}); err != nil {
+		return fmt.Errorf("failed to list issued certificates: %w", err)
+	}
+	for i := range issued.Items {
+		cert := &issued.Items[i]
+		if cert.Spec.User != username || cert.Spec.SerialNumber == keep || cert.Status.RevokedAt != nil {
+			continue
+		}
+		revokedAt := metav1.NewTime(now)
+		cert.Status.RevokedAt = &revokedAt
+		cert.Status.Reason = reason
+		if err := c.Status().Update(ctx, cert); err != nil {
+			return fmt.Errorf("failed to revoke issued certificate %s: %w", cert.Spec.SerialNumber, err)
+		}
+	}
+	return nil
+}
+
+// CurrentSerial returns the serial number of the newest certificate of username that is not
+// revoked, or "" if there is none
+func CurrentSerial(ctx context.Context, r client.Reader, username string) (string, error) {
+	var issued authv1alpha1.IssuedCertificateList
+	if err := r.List(ctx, &issued, client.MatchingLabels{"auth.openkube.io/user": username}); err != nil {
+		return "", fmt.Errorf("failed to list issued certificates: %w", err)
+	}
+	var current *authv1alpha1.IssuedCertificate
+	for i := range issued.Items {
+		cert := &issued.Items[i]
+		if cert.Spec.User != username || cert.Status.RevokedAt != nil {
+			continue
+		}
+		if current == nil || cert.Spec.NotBefore.After(current.Spec.NotBefore.Time) {
+			current = cert
+		}
+	}
+	if current == nil {
+		return "", nil
+	}
+	return current.Spec.SerialNumber, nil
+}
+
+// RevocationList lists the revoked certificates that have not expired yet
+type RevocationList struct {
+	GeneratedAt  time.Time            `json:"generatedAt"`
+	Certificates []RevokedCertificate `json:"certificates"`
+}
+
+// RevokedCertificate is an entry of the RevocationList
+type RevokedCertificate struct {
+	SerialNumber string    `json:"serialNumber"`
+	User         string    `json:"user"`
+	CommonName   string    `json:"commonName"`
+	NotAfter     time.Time `json:"notAfter"`
+	RevokedAt    time.Time `json:"revokedAt"`
+	Reason       string    `json:"reason"`
+}
+
+// Revoked returns the revoked certificates that are still valid at now
+func Revoked(ctx context.Context, r client.Reader, now time.Time) (*RevocationList, error) {
+	var issued authv1alpha1.IssuedCertificateList
+	if err := r.List(ctx, &issued); err != nil {
+		return nil, fmt.Errorf("failed to list issued certificates: %w", err)
+	}
+	list := &RevocationList{GeneratedAt: now.UTC(), Certificates: []RevokedCertificate{}}
+	for _, cert := range issued.Items {
+		if cert.Status.RevokedAt == nil || !now.Before(cert.Spec.NotAfter.Time) {
+			continue
+		}
+		list.Certificates = append(list.Certificates, RevokedCertificate{
+			SerialNumber: cert.Spec.SerialNumber,
+			User:         cert.Spec.User,
+			CommonName:   cert.Spec.CommonName,
+			NotAfter:     cert.Spec.NotAfter.UTC(),
+			RevokedAt:    cert.Status.RevokedAt.UTC(),
+			Reason:       cert.Status.Reason,
+		})
+	}
+	return list, nil
+}
+
+// RevocationListHandler serves the RevocationList as JSON
+type RevocationListHandler struct {
+	Reader client.Reader
+}
+
+// ServeHTTP implements http.Handler
+func (h *RevocationListHandler) ServeHTTP(w http.ResponseWriter, req *http.Request) {
+	if req.Method != http.MethodGet {
+		http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+	list, err := Revoked(req.Context(), h.Reader, time.Now())
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+	w.Header().Set("Content-Type", "application/json")
+	_ = json.NewEncoder(w).Encode(list)
+}
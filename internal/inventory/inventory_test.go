@@ -0,0 +1,152 @@
+/*
+Copyright 2025.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package inventory
+
+import (
+	"context"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/json"
+	"encoding/pem"
+	"math/big"
+	"net/http"
+	"net/http/httptest"
+	"time"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+	"k8s.io/apimachinery/pkg/runtime"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+
+	authv1alpha1 "github.com/openkube-hub/KubeUser/api/v1alpha1"
+)
+
+func certificatePEM(serial int64, notAfter time.Time) []byte {
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	Expect(err).NotTo(HaveOccurred())
+	tmpl := &x509.Certificate{
+		SerialNumber: big.NewInt(serial),
+		Subject:      pkix.Name{CommonName: "jane"},
+		NotBefore:    notAfter.Add(-time.Hour),
+		NotAfter:     notAfter,
+	}
+	der, err := x509.CreateCertificate(rand.Reader, tmpl, tmpl, &key.PublicKey, key)
+	Expect(err).NotTo(HaveOccurred())
+	return pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: der})
+}
+
+var _ = Describe("Inventory", func() {
+	var (
+		ctx context.Context
+		c   client.Client
+		now time.Time
+	)
+
+	BeforeEach(func() {
+		ctx = context.Background()
+		now = time.Now().Truncate(time.Second)
+		scheme := runtime.NewScheme()
+		Expect(authv1alpha1.AddToScheme(scheme)).To(Succeed())
+		c = fake.NewClientBuilder().WithScheme(scheme).
+			WithStatusSubresource(&authv1alpha1.IssuedCertificate{}).Build()
+	})
+
+	issued := func() map[string]authv1alpha1.IssuedCertificate {
+		var list authv1alpha1.IssuedCertificateList
+		Expect(c.List(ctx, &list)).To(Succeed())
+		bySerial := map[string]authv1alpha1.IssuedCertificate{}
+		for _, cert := range list.Items {
+			bySerial[cert.Spec.SerialNumber] = cert
+		}
+		return bySerial
+	}
+
+	It("records certificates and supersedes earlier ones", func() {
+		Expect(Record(ctx, c, "jane", "jane-csr", certificatePEM(0x1a, now.Add(time.Hour)), now)).To(Succeed())
+		Expect(Record(ctx, c, "jane", "jane-csr", certificatePEM(0x2b, now.Add(2*time.Hour)), now)).To(Succeed())
+		// Recording the same certificate again changes nothing
+		Expect(Record(ctx, c, "jane", "jane-csr", certificatePEM(0x2b, now.Add(2*time.Hour)), now)).To(Succeed())
+
+		certs := issued()
+		Expect(certs).To(HaveLen(2))
+		Expect(certs["1a"].Name).To(Equal("jane-1a"))
+		Expect(certs["1a"].Spec.CommonName).To(Equal("jane"))
+		Expect(certs["1a"].Spec.CSRName).To(Equal("jane-csr"))
+		Expect(certs["1a"].Status.Reason).To(Equal(authv1alpha1.RevocationReasonSuperseded))
+		Expect(certs["2b"].Status.RevokedAt).To(BeNil())
+
+		serial, err := CurrentSerial(ctx, c, "jane")
+		Expect(err).NotTo(HaveOccurred())
+		Expect(serial).To(Equal("2b"))
+		Expect(Revoke(ctx, c, "jane", authv1alpha1.RevocationReasonUserRevoked, now)).To(Succeed())
+		Expect(CurrentSerial(ctx, c, "jane")).To(BeEmpty())
+	})
+
+	It("revokes all certificates of a user only", func() {
+		Expect(Record(ctx, c, "jane", "jane-csr", certificatePEM(0x1a, now.Add(time.Hour)), now)).To(Succeed())
+		Expect(Record(ctx, c, "john", "john-csr", certificatePEM(0x3c, now.Add(time.Hour)), now)).To(Succeed())
+		Expect(Revoke(ctx, c, "jane", authv1alpha1.RevocationReasonUserRevoked, now)).To(Succeed())
+		// A later revocation keeps the first reason
+		Expect(Revoke(ctx, c, "jane", authv1alpha1.RevocationReasonUserDeleted, now.Add(time.Minute))).To(Succeed())
+
+		certs := issued()
+		Expect(certs["1a"].Status.Reason).To(Equal(authv1alpha1.RevocationReasonUserRevoked))
+		Expect(certs["1a"].Status.RevokedAt.Time).To(BeTemporally("==", now))
+		Expect(certs["3c"].Status.RevokedAt).To(BeNil())
+	})
+
+	It("lists revoked certificates until they expire", func() {
+		Expect(Record(ctx, c, "jane", "jane-csr", certificatePEM(0x1a, now.Add(time.Hour)), now)).To(Succeed())
+		Expect(Record(ctx, c, "jane", "jane-csr", certificatePEM(0x2b, now.Add(2*time.Hour)), now)).To(Succeed())
+
+		list, err := Revoked(ctx, c, now)
+		Expect(err).NotTo(HaveOccurred())
+		Expect(list.Certificates).To(ConsistOf(RevokedCertificate{
+			SerialNumber: "1a",
+			User:         "jane",
+			CommonName:   "jane",
+			NotAfter:     now.Add(time.Hour).UTC(),
+			RevokedAt:    now.UTC(),
+			Reason:       authv1alpha1.RevocationReasonSuperseded,
+		}))
+
+		list, err = Revoked(ctx, c, now.Add(time.Hour))
+		Expect(err).NotTo(HaveOccurred())
+		Expect(list.Certificates).To(BeEmpty())
+	})
+
+	It("serves the revocation list as JSON", func() {
+		Expect(Record(ctx, c, "jane", "jane-csr", certificatePEM(0x1a, now.Add(time.Hour)), now)).To(Succeed())
+		Expect(Revoke(ctx, c, "jane", authv1alpha1.RevocationReasonUserDeleted, now)).To(Succeed())
+
+		rec := httptest.NewRecorder()
+		(&RevocationListHandler{Reader: c}).ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/certificates/revoked", nil))
+		Expect(rec.Code).To(Equal(http.StatusOK))
+		var list RevocationList
+		Expect(json.Unmarshal(rec.Body.Bytes(), &list)).To(Succeed())
+		Expect(list.Certificates).To(HaveLen(1))
+		Expect(list.Certificates[0].Reason).To(Equal(authv1alpha1.RevocationReasonUserDeleted))
+
+		rec = httptest.NewRecorder()
+		(&RevocationListHandler{Reader: c}).ServeHTTP(rec, httptest.NewRequest(http.MethodPost, "/certificates/revoked", nil))
+		Expect(rec.Code).To(Equal(http.StatusMethodNotAllowed))
+	})
+})
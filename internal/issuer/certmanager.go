@@ -0,0 +1,160 @@
+/*
+Copyright 2025.
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+*/
+
+package issuer
+
+import (
+	"context"
+	"encoding/base64"
+	"fmt"
+	"strings"
+	"time"
+
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"k8s.io/apimachinery/pkg/types"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+)
+
+// certificateRequestGVK is cert-manager's CertificateRequest. It is handled as unstructured
+// so the operator does not depend on cert-manager's API module.
+var certificateRequestGVK = schema.GroupVersionKind{Group: "cert-manager.io", Version: "v1", Kind: "CertificateRequest"}
+
+// IssuerRef names a cert-manager Issuer or ClusterIssuer
+type IssuerRef struct {
+	Kind string
+	Name string
+}
+
+// ParseIssuerRef parses [Issuer/|ClusterIssuer/]<name>; a bare name refers to a ClusterIssuer
+func ParseIssuerRef(value string) (IssuerRef, error) {
+	kind, name, found := strings.Cut(value, "/")
+	if !found {
+		kind, name = "ClusterIssuer", value
+	}
+	if name == "" || (kind != "Issuer" && kind != "ClusterIssuer") {
+		return IssuerRef{}, fmt.Errorf("invalid cert-manager issuer %q, must be [Issuer/|ClusterIssuer/]<name>", value)
+	}
+	return IssuerRef{Kind: kind, Name: name}, nil
+}
+
+// CertManagerIssuer issues certificates through cert-manager CertificateRequests against a
+// configured Issuer or ClusterIssuer, e.g. a dedicated intermediate CA with custom lifetimes.
+// CertificateRequests are created in Namespace, which must also hold a namespaced Issuer.
+type CertManagerIssuer struct {
+	client    client.Client
+	Namespace string
+	Ref       IssuerRef
+	// now is replaced in tests
+	now func() time.Time
+}
+
+// NewCertManagerIssuer returns an issuer creating CertificateRequests in namespace
+func NewCertManagerIssuer(c client.Client, namespace string, ref IssuerRef) *CertManagerIssuer {
+	return &CertManagerIssuer{client: c, Namespace: namespace, Ref: ref, now: time.Now}
+}
+
+var _ Issuer = &CertManagerIssuer{}
+
+// Sign implements Issuer. cert-manager approves and signs asynchronously, so the first calls
+// return ErrPending.
+func (i *CertManagerIssuer) Sign(ctx context.Context, req Request) (*Certificate, error) {
+	cr := &unstructured.Unstructured{}
+	cr.SetGroupVersionKind(certificateRequestGVK)
+	err := i.client.Get(ctx, types.NamespacedName{Namespace: i.Namespace, Name: req.Name}, cr)
+	if apierrors.IsNotFound(err) && !isMissingResource(err) {
+		return nil, i.create(ctx, req)
+	} else if err != nil {
+		return nil, err
+	}
+
+	if reason, message, failed := certificateRequestFailed(cr); failed {
+		// Failed and denied requests are terminal; drop it so the next attempt submits a fresh one
+		if err := i.client.Delete(ctx, cr); err != nil && !apierrors.IsNotFound(err) {
+			return nil, fmt.Errorf("failed to delete CertificateRequest %s: %w", req.Name, err)
+		}
+		return nil, &UnavailableError{Reason: fmt.Sprintf("%s %s %s CertificateRequest %s/%s: %s",
+			i.Ref.Kind, i.Ref.Name, strings.ToLower(reason), i.Namespace, req.Name, message)}
+	}
+
+	encoded, _, _ := unstructured.NestedString(cr.Object, "status", "certificate")
+	if encoded == "" {
+		created := cr.GetCreationTimestamp()
+		if !created.IsZero() && i.now().Sub(created.Time) > SignerTimeout {
+			return nil, &UnavailableError{Reason: fmt.Sprintf("%s %s has not signed CertificateRequest %s/%s within %s",
+				i.Ref.Kind, i.Ref.Name, i.Namespace, req.Name, SignerTimeout)}
+		}
+		return nil, ErrPending
+	}
+	certPEM, err := base64.StdEncoding.DecodeString(encoded)
+	if err != nil {
+		return nil, fmt.Errorf("certificate of CertificateRequest %s/%s: %w", i.Namespace, req.Name, err)
+	}
+	notAfter, err := ParseNotAfter(certPEM)
+	if err != nil {
+		return nil, fmt.Errorf("certificate of CertificateRequest %s/%s: %w", i.Namespace, req.Name, err)
+	}
+	return &Certificate{PEM: certPEM, NotAfter: notAfter, RequestedAt: cr.GetCreationTimestamp().Time}, nil
+}
+
+func (i *CertManagerIssuer) create(ctx context.Context, req Request) error {
+	spec := map[string]any{
+		"request": base64.StdEncoding.EncodeToString(req.CSR),
+		"usages":  []any{"client auth", "digital signature", "key encipherment"},
+		"issuerRef": map[string]any{
+			"group": "cert-manager.io",
+			"kind":  i.Ref.Kind,
+			"name":  i.Ref.Name,
+		},
+	}
+	if req.Duration > 0 {
+		spec["duration"] = req.Duration.String()
+	}
+	cr := &unstructured.Unstructured{Object: map[string]any{"spec": spec}}
+	cr.SetGroupVersionKind(certificateRequestGVK)
+	cr.SetNamespace(i.Namespace)
+	cr.SetName(req.Name)
+	cr.SetLabels(req.Labels)
+	if err := i.client.Create(ctx, cr); err != nil {
+		return err
+	}
+	return ErrPending
+}
+
+// Reset implements Issuer by deleting the CertificateRequest
+func (i *CertManagerIssuer) Reset(ctx context.Context, name string) error {
+	cr := &unstructured.Unstructured{}
+	cr.SetGroupVersionKind(certificateRequestGVK)
+	cr.SetNamespace(i.Namespace)
+	cr.SetName(name)
+	if err := i.client.Delete(ctx, cr); err != nil && !apierrors.IsNotFound(err) && !IsUnavailable(err) {
+		return fmt.Errorf("failed to delete CertificateRequest %s/%s: %w", i.Namespace, name, err)
+	}
+	return nil
+}
+
+// certificateRequestFailed reports whether the request was denied or failed by its issuer
+func certificateRequestFailed(cr *unstructured.Unstructured) (reason, message string, failed bool) {
+	conditions, _, _ := unstructured.NestedSlice(cr.Object, "status", "conditions")
+	for _, c := range conditions {
+		condition, ok := c.(map[string]any)
+		if !ok {
+			continue
+		}
+		conditionType, _ := condition["type"].(string)
+		status, _ := condition["status"].(string)
+		conditionReason, _ := condition["reason"].(string)
+		message, _ = condition["message"].(string)
+		switch {
+		case conditionType == "Denied" && status == "True":
+			return "Denied", message, true
+		case conditionType == "Ready" && status == "False" && conditionReason == "Failed":
+			return "Failed", message, true
+		}
+	}
+	return "", "", false
+}
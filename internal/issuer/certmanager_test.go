@@ -0,0 +1,151 @@
+/*
+Copyright 2025.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package issuer
+
+import (
+	"context"
+	"encoding/base64"
+	"time"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/types"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+)
+
+var _ = Describe("ParseIssuerRef", func() {
+	It("defaults to a ClusterIssuer", func() {
+		Expect(ParseIssuerRef("users-ca")).To(Equal(IssuerRef{Kind: "ClusterIssuer", Name: "users-ca"}))
+		Expect(ParseIssuerRef("Issuer/users-ca")).To(Equal(IssuerRef{Kind: "Issuer", Name: "users-ca"}))
+	})
+
+	It("rejects unknown kinds and empty names", func() {
+		for _, value := range []string{"", "Issuer/", "Certificate/users-ca"} {
+			_, err := ParseIssuerRef(value)
+			Expect(err).To(HaveOccurred(), value)
+		}
+	})
+})
+
+var _ = Describe("CertManagerIssuer", func() {
+	var (
+		ctx     context.Context
+		c       client.Client
+		builder *fake.ClientBuilder
+		i       *CertManagerIssuer
+		now     time.Time
+		req     Request
+		crKey   = types.NamespacedName{Namespace: "kubeuser", Name: "jane-csr"}
+	)
+
+	newCertificateRequest := func() *unstructured.Unstructured {
+		cr := &unstructured.Unstructured{}
+		cr.SetGroupVersionKind(certificateRequestGVK)
+		return cr
+	}
+	setStatus := func(status map[string]any) {
+		cr := newCertificateRequest()
+		Expect(c.Get(ctx, crKey, cr)).To(Succeed())
+		cr.Object["status"] = status
+		Expect(c.Update(ctx, cr)).To(Succeed())
+	}
+
+	BeforeEach(func() {
+		ctx = context.Background()
+		scheme := runtime.NewScheme()
+		scheme.AddKnownTypeWithName(certificateRequestGVK, &unstructured.Unstructured{})
+		listGVK := certificateRequestGVK.GroupVersion().WithKind("CertificateRequestList")
+		scheme.AddKnownTypeWithName(listGVK, &unstructured.UnstructuredList{})
+		builder = fake.NewClientBuilder().WithScheme(scheme)
+		now = time.Now()
+		req = Request{Name: crKey.Name, Username: "jane", CSR: []byte("csr"), Duration: 90 * 24 * time.Hour,
+			Labels: map[string]string{"app": "test"}}
+	})
+
+	JustBeforeEach(func() {
+		c = builder.Build()
+		i = NewCertManagerIssuer(c, crKey.Namespace, IssuerRef{Kind: "ClusterIssuer", Name: "users-ca"})
+		i.now = func() time.Time { return now }
+	})
+
+	sign := func() (*Certificate, error) { return i.Sign(ctx, req) }
+
+	It("submits a CertificateRequest and returns the issued certificate", func() {
+		_, err := sign()
+		Expect(err).To(MatchError(ErrPending))
+		cr := newCertificateRequest()
+		Expect(c.Get(ctx, crKey, cr)).To(Succeed())
+		Expect(cr.GetLabels()).To(HaveKeyWithValue("app", "test"))
+		spec := cr.Object["spec"]
+		Expect(spec).To(HaveKeyWithValue("request", base64.StdEncoding.EncodeToString(req.CSR)))
+		Expect(spec).To(HaveKeyWithValue("duration", "2160h0m0s"))
+		Expect(spec).To(HaveKeyWithValue("issuerRef", map[string]any{
+			"group": "cert-manager.io", "kind": "ClusterIssuer", "name": "users-ca"}))
+
+		_, err = sign()
+		Expect(err).To(MatchError(ErrPending))
+
+		notAfter := time.Now().Add(24 * time.Hour).Truncate(time.Second)
+		certPEM := certificatePEM(notAfter)
+		setStatus(map[string]any{"certificate": base64.StdEncoding.EncodeToString(certPEM)})
+		cert, err := sign()
+		Expect(err).NotTo(HaveOccurred())
+		Expect(cert.PEM).To(Equal(certPEM))
+		Expect(cert.NotAfter).To(BeTemporally("==", notAfter))
+	})
+
+	It("drops requests denied or failed by cert-manager", func() {
+		for _, condition := range []map[string]any{
+			{"type": "Denied", "status": "True", "message": "policy says no"},
+			{"type": "Ready", "status": "False", "reason": "Failed", "message": "policy says no"},
+		} {
+			_, _ = sign()
+			setStatus(map[string]any{"conditions": []any{condition}})
+
+			_, err := sign()
+			Expect(IsUnavailable(err)).To(BeTrue())
+			Expect(err).To(MatchError(ContainSubstring("policy says no")))
+			Expect(c.Get(ctx, crKey, newCertificateRequest())).NotTo(Succeed())
+		}
+	})
+
+	Context("with a request that has been pending for too long", func() {
+		BeforeEach(func() {
+			cr := newCertificateRequest()
+			cr.SetNamespace(crKey.Namespace)
+			cr.SetName(crKey.Name)
+			cr.SetCreationTimestamp(metav1.NewTime(now.Add(-SignerTimeout - time.Second)))
+			builder = builder.WithObjects(cr)
+		})
+
+		It("reports the issuer as unavailable", func() {
+			_, err := sign()
+			Expect(IsUnavailable(err)).To(BeTrue())
+		})
+	})
+
+	It("starts over after Reset", func() {
+		_, _ = sign()
+		Expect(i.Reset(ctx, crKey.Name)).To(Succeed())
+		Expect(i.Reset(ctx, crKey.Name)).To(Succeed())
+		Expect(c.Get(ctx, crKey, newCertificateRequest())).NotTo(Succeed())
+	})
+})
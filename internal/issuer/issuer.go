@@ -0,0 +1,157 @@
+/*
+Copyright 2025.
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+*/
+
+// Package issuer signs client certificates for Users. The controller asks its Issuer for a
+// certificate on every reconcile and requeues while issuance is pending, so asynchronous
+// signers like the Kubernetes CSR API and synchronous ones share the same interface.
+package issuer
+
+import (
+	"context"
+	"crypto/x509"
+	"encoding/base64"
+	"encoding/pem"
+	"errors"
+	"fmt"
+	"time"
+
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/api/meta"
+)
+
+// ErrPending is returned by Sign while the certificate is still being issued; call Sign again later
+var ErrPending = errors.New("certificate issuance pending")
+
+// Request describes a certificate to issue
+type Request struct {
+	// Name identifies the request across calls, e.g. the name of the CSR object
+	Name string
+	// Username is the user the certificate is issued for, its common name
+	Username string
+	// User is the name of the User object, when it differs from Username; issuers deriving
+	// further names from the user, like SPIRE's SPIFFE ID, use it
+	User string
+	// CSR is the PEM-encoded certificate signing request
+	CSR []byte
+	// Duration is the requested validity; zero leaves it to the signer
+	Duration time.Duration
+	// Labels are set on objects the issuer creates for the request
+	Labels map[string]string
+}
+
+// user returns the name of the User the request is for
+func (r Request) user() string {
+	if r.User != "" {
+		return r.User
+	}
+	return r.Username
+}
+
+// Certificate is a signed client certificate
+type Certificate struct {
+	// PEM is the PEM-encoded certificate
+	PEM []byte
+	// NotAfter is when the certificate expires
+	NotAfter time.Time
+	// RequestedAt is when issuance of the certificate started
+	RequestedAt time.Time
+}
+
+// Issuer signs client certificates
+type Issuer interface {
+	// Sign returns the certificate for req, or ErrPending while it is being issued
+	Sign(ctx context.Context, req Request) (*Certificate, error)
+	// Reset forgets the issuance named name, pending or complete, so the next Sign issues a new certificate
+	Reset(ctx context.Context, name string) error
+}
+
+// UnavailableError marks errors caused by the signing backend rather than by the request
+type UnavailableError struct {
+	Reason string
+	Err    error
+}
+
+func (e *UnavailableError) Error() string {
+	if e.Err == nil {
+		return e.Reason
+	}
+	return fmt.Sprintf("%s: %v", e.Reason, e.Err)
+}
+
+func (e *UnavailableError) Unwrap() error {
+	return e.Err
+}
+
+// IsUnavailable reports whether err means certificates cannot be issued right now
+func IsUnavailable(err error) bool {
+	var unavailable *UnavailableError
+	if errors.As(err, &unavailable) {
+		return true
+	}
+	// API not served at all, or served but the resource is missing
+	return meta.IsNoMatchError(err) || apierrors.IsServiceUnavailable(err) ||
+		(apierrors.IsNotFound(err) && isMissingResource(err))
+}
+
+// isMissingResource distinguishes "the server could not find the requested resource"
+// (API disabled) from a NotFound for a specific object.
+func isMissingResource(err error) bool {
+	var status apierrors.APIStatus
+	if !errors.As(err, &status) {
+		return false
+	}
+	details := status.Status().Details
+	return details == nil || details.Name == ""
+}
+
+// ParseNotAfter returns the expiry of a certificate given as PEM, base64-encoded PEM or DER
+func ParseNotAfter(data []byte) (time.Time, error) {
+	cert, err := ParseCertificate(data)
+	if err != nil {
+		return time.Time{}, err
+	}
+	return cert.NotAfter, nil
+}
+
+// ParseCertificate parses a certificate given as PEM, base64-encoded PEM or DER
+func ParseCertificate(data []byte) (*x509.Certificate, error) {
+	der := data
+	if decoded, err := base64.StdEncoding.DecodeString(string(data)); err == nil {
+		data = decoded
+	}
+	if block, _ := pem.Decode(data); block != nil {
+		der = block.Bytes
+	}
+	cert, err := x509.ParseCertificate(der)
+	if err != nil {
+		return nil, fmt.Errorf("unable to parse certificate: %w", err)
+	}
+	return cert, nil
+}
+
+// ParseCSR parses a PEM CSR supplied by a user and checks that it is self-signed and requests
+// exactly the identity of username. Organizations are rejected because the API server maps
+// them to groups, which would let the CSR claim e.g. system:masters.
+func ParseCSR(data []byte, username string) (*x509.CertificateRequest, error) {
+	block, _ := pem.Decode(data)
+	if block == nil || block.Type != "CERTIFICATE REQUEST" {
+		return nil, errors.New("expected a PEM encoded CERTIFICATE REQUEST")
+	}
+	csr, err := x509.ParseCertificateRequest(block.Bytes)
+	if err != nil {
+		return nil, fmt.Errorf("unable to parse certificate request: %w", err)
+	}
+	if err := csr.CheckSignature(); err != nil {
+		return nil, fmt.Errorf("invalid certificate request signature: %w", err)
+	}
+	if csr.Subject.CommonName != username {
+		return nil, fmt.Errorf("certificate request common name %q does not match user %q", csr.Subject.CommonName, username)
+	}
+	if len(csr.Subject.Organization) > 0 {
+		return nil, errors.New("certificate request must not contain organizations, they would be used as groups")
+	}
+	return csr, nil
+}
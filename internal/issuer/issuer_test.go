@@ -0,0 +1,203 @@
+/*
+Copyright 2025.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package issuer
+
+import (
+	"context"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/base64"
+	"encoding/pem"
+	"errors"
+	"math/big"
+	"time"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+	certv1 "k8s.io/api/certificates/v1"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/types"
+	clientgoscheme "k8s.io/client-go/kubernetes/scheme"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+)
+
+func certificatePEM(notAfter time.Time) []byte {
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	Expect(err).NotTo(HaveOccurred())
+	tmpl := &x509.Certificate{
+		SerialNumber: big.NewInt(1),
+		Subject:      pkix.Name{CommonName: "jane"},
+		NotBefore:    time.Now(),
+		NotAfter:     notAfter,
+	}
+	der, err := x509.CreateCertificate(rand.Reader, tmpl, tmpl, &key.PublicKey, key)
+	Expect(err).NotTo(HaveOccurred())
+	return pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: der})
+}
+
+var _ = Describe("ParseNotAfter", func() {
+	notAfter := time.Now().Add(time.Hour).Truncate(time.Second).UTC()
+
+	It("accepts PEM, base64 PEM and DER", func() {
+		certPEM := certificatePEM(notAfter)
+		block, _ := pem.Decode(certPEM)
+		for _, data := range [][]byte{certPEM, []byte(base64.StdEncoding.EncodeToString(certPEM)), block.Bytes} {
+			parsed, err := ParseNotAfter(data)
+			Expect(err).NotTo(HaveOccurred())
+			Expect(parsed).To(BeTemporally("==", notAfter))
+		}
+	})
+
+	It("rejects garbage", func() {
+		_, err := ParseNotAfter([]byte("not a certificate"))
+		Expect(err).To(HaveOccurred())
+	})
+})
+
+var _ = Describe("ParseCSR", func() {
+	csrPEM := func(subject pkix.Name) []byte {
+		key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+		Expect(err).NotTo(HaveOccurred())
+		der, err := x509.CreateCertificateRequest(rand.Reader, &x509.CertificateRequest{Subject: subject}, key)
+		Expect(err).NotTo(HaveOccurred())
+		return pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE REQUEST", Bytes: der})
+	}
+
+	It("accepts a CSR for the user", func() {
+		csr, err := ParseCSR(csrPEM(pkix.Name{CommonName: "jane"}), "jane")
+		Expect(err).NotTo(HaveOccurred())
+		Expect(csr.PublicKey).NotTo(BeNil())
+	})
+
+	It("rejects CSRs for other identities or groups", func() {
+		_, err := ParseCSR(csrPEM(pkix.Name{CommonName: "john"}), "jane")
+		Expect(err).To(MatchError(ContainSubstring("does not match")))
+		_, err = ParseCSR(csrPEM(pkix.Name{CommonName: "jane", Organization: []string{"system:masters"}}), "jane")
+		Expect(err).To(MatchError(ContainSubstring("organizations")))
+	})
+
+	It("rejects anything but a PEM CSR", func() {
+		_, err := ParseCSR(certificatePEM(time.Now().Add(time.Hour)), "jane")
+		Expect(err).To(HaveOccurred())
+		_, err = ParseCSR([]byte("garbage"), "jane")
+		Expect(err).To(HaveOccurred())
+	})
+})
+
+var _ = Describe("CSRIssuer", func() {
+	var (
+		ctx    context.Context
+		c      client.Client
+		i      *CSRIssuer
+		now    time.Time
+		req    Request
+		csrKey = types.NamespacedName{Name: "jane-csr"}
+	)
+
+	BeforeEach(func() {
+		ctx = context.Background()
+		scheme := runtime.NewScheme()
+		Expect(clientgoscheme.AddToScheme(scheme)).To(Succeed())
+		c = fake.NewClientBuilder().WithScheme(scheme).
+			WithStatusSubresource(&certv1.CertificateSigningRequest{}).Build()
+		now = time.Now()
+		i = NewCSRIssuer(c)
+		i.now = func() time.Time { return now }
+		req = Request{Name: csrKey.Name, Username: "jane", CSR: []byte("csr"), Labels: map[string]string{"app": "test"}}
+	})
+
+	sign := func() (*Certificate, error) { return i.Sign(ctx, req) }
+
+	It("submits, approves and returns the signed certificate", func() {
+		_, err := sign()
+		Expect(err).To(MatchError(ErrPending))
+		var csr certv1.CertificateSigningRequest
+		Expect(c.Get(ctx, csrKey, &csr)).To(Succeed())
+		Expect(csr.Spec.SignerName).To(Equal(certv1.KubeAPIServerClientSignerName))
+		Expect(csr.Spec.Usages).To(ConsistOf(certv1.UsageClientAuth))
+		Expect(csr.Labels).To(HaveKeyWithValue("app", "test"))
+
+		_, err = sign()
+		Expect(err).To(MatchError(ErrPending))
+		Expect(c.Get(ctx, csrKey, &csr)).To(Succeed())
+		Expect(csrApproved(&csr)).To(BeTrue())
+
+		_, err = sign()
+		Expect(err).To(MatchError(ErrPending))
+
+		notAfter := time.Now().Add(24 * time.Hour).Truncate(time.Second)
+		csr.Status.Certificate = certificatePEM(notAfter)
+		Expect(c.Status().Update(ctx, &csr)).To(Succeed())
+		cert, err := sign()
+		Expect(err).NotTo(HaveOccurred())
+		Expect(cert.PEM).To(Equal(csr.Status.Certificate))
+		Expect(cert.NotAfter).To(BeTemporally("==", notAfter))
+	})
+
+	It("requests the duration, at least the API minimum", func() {
+		req.Duration = time.Minute
+		_, err := sign()
+		Expect(err).To(MatchError(ErrPending))
+		var csr certv1.CertificateSigningRequest
+		Expect(c.Get(ctx, csrKey, &csr)).To(Succeed())
+		Expect(csr.Spec.ExpirationSeconds).To(HaveValue(BeEquivalentTo(600)))
+	})
+
+	It("reports a signer that does not sign as unavailable", func() {
+		_, _ = sign()
+		_, _ = sign()
+		now = now.Add(SignerTimeout + time.Second)
+		_, err := sign()
+		Expect(IsUnavailable(err)).To(BeTrue())
+	})
+
+	It("drops CSRs failed by the signer", func() {
+		_, _ = sign()
+		var csr certv1.CertificateSigningRequest
+		Expect(c.Get(ctx, csrKey, &csr)).To(Succeed())
+		csr.Status.Conditions = append(csr.Status.Conditions,
+			certv1.CertificateSigningRequestCondition{Type: certv1.CertificateApproved, Status: corev1.ConditionTrue},
+			certv1.CertificateSigningRequestCondition{Type: certv1.CertificateFailed, Status: corev1.ConditionTrue, Message: "boom"})
+		Expect(c.Status().Update(ctx, &csr)).To(Succeed())
+
+		_, err := sign()
+		Expect(IsUnavailable(err)).To(BeTrue())
+		Expect(err).To(MatchError(ContainSubstring("boom")))
+		Expect(c.Get(ctx, csrKey, &csr)).NotTo(Succeed())
+	})
+
+	It("starts over after Reset", func() {
+		_, _ = sign()
+		Expect(i.Reset(ctx, csrKey.Name)).To(Succeed())
+		Expect(i.Reset(ctx, csrKey.Name)).To(Succeed())
+		var csr certv1.CertificateSigningRequest
+		Expect(c.Get(ctx, csrKey, &csr)).NotTo(Succeed())
+	})
+})
+
+var _ = Describe("IsUnavailable", func() {
+	It("recognizes backend failures only", func() {
+		Expect(IsUnavailable(&UnavailableError{Reason: "down"})).To(BeTrue())
+		Expect(IsUnavailable(errors.New("other"))).To(BeFalse())
+		Expect(IsUnavailable(ErrPending)).To(BeFalse())
+	})
+})
@@ -0,0 +1,161 @@
+/*
+Copyright 2025.
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+*/
+
+package issuer
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/trace"
+	certv1 "k8s.io/api/certificates/v1"
+	corev1 "k8s.io/api/core/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/types"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+
+	"github.com/openkube-hub/KubeUser/internal/tracing"
+)
+
+const (
+	// SignerTimeout is how long an approved CSR may stay unsigned before the signer is considered down
+	SignerTimeout = 2 * time.Minute
+
+	// minCSRDuration is the shortest expirationSeconds the CSR API accepts
+	minCSRDuration = 10 * time.Minute
+)
+
+// CSRIssuer issues certificates through the Kubernetes CertificateSigningRequest API. It
+// submits a CSR named after the request, approves it itself and waits for the signer.
+type CSRIssuer struct {
+	client client.Client
+	// SignerName is the signer the CSRs are addressed to
+	SignerName string
+	// now is replaced in tests
+	now func() time.Time
+}
+
+// NewCSRIssuer returns an issuer using the kube-apiserver client signer
+func NewCSRIssuer(c client.Client) *CSRIssuer {
+	return &CSRIssuer{client: c, SignerName: certv1.KubeAPIServerClientSignerName, now: time.Now}
+}
+
+var _ Issuer = &CSRIssuer{}
+
+// Sign implements Issuer. Each step that changes the CSR returns ErrPending, so the caller
+// observes the result on its next call.
+func (i *CSRIssuer) Sign(ctx context.Context, req Request) (*Certificate, error) {
+	var csr certv1.CertificateSigningRequest
+	err := i.client.Get(ctx, types.NamespacedName{Name: req.Name}, &csr)
+	if apierrors.IsNotFound(err) && !isMissingResource(err) {
+		csr = certv1.CertificateSigningRequest{
+			ObjectMeta: metav1.ObjectMeta{Name: req.Name, Labels: req.Labels},
+			Spec: certv1.CertificateSigningRequestSpec{
+				Request:    req.CSR,
+				Usages:     []certv1.KeyUsage{certv1.UsageClientAuth},
+				SignerName: i.SignerName,
+			},
+		}
+		if req.Duration > 0 {
+			seconds := int32(max(req.Duration, minCSRDuration).Seconds())
+			csr.Spec.ExpirationSeconds = &seconds
+		}
+		createCtx, span := tracing.Start(ctx, "CSR.Create", attribute.String("csr", req.Name))
+		err := i.client.Create(createCtx, &csr)
+		tracing.End(span, err)
+		if err != nil {
+			return nil, err
+		}
+		return nil, ErrPending
+	} else if err != nil {
+		return nil, err
+	}
+
+	if !csrApproved(&csr) {
+		csr.Status.Conditions = append(csr.Status.Conditions, certv1.CertificateSigningRequestCondition{
+			Type:           certv1.CertificateApproved,
+			Status:         corev1.ConditionTrue,
+			Reason:         "AutoApproved",
+			Message:        "Approved by kubeuser-operator",
+			LastUpdateTime: metav1.NewTime(i.now()),
+		})
+		approveCtx, span := tracing.Start(ctx, "CSR.Approve", attribute.String("csr", csr.Name))
+		err := i.client.SubResource("approval").Update(approveCtx, &csr)
+		tracing.End(span, err)
+		if err != nil {
+			return nil, err
+		}
+		return nil, ErrPending
+	}
+
+	// Wait for the certificate, unless the signer failed the CSR or never got to it
+	if len(csr.Status.Certificate) == 0 {
+		trace.SpanFromContext(ctx).AddEvent("Waiting for signer", trace.WithAttributes(
+			attribute.String("csr", csr.Name), attribute.String("waited", i.now().Sub(csr.CreationTimestamp.Time).String())))
+		if err := i.checkSigner(&csr); err != nil {
+			// A failed CSR is terminal; drop it so the next attempt submits a fresh one
+			if _, failed := csrFailed(&csr); failed {
+				if delErr := i.client.Delete(ctx, &csr); delErr != nil && !apierrors.IsNotFound(delErr) {
+					return nil, fmt.Errorf("failed to delete failed CSR %s: %w", csr.Name, delErr)
+				}
+			}
+			return nil, err
+		}
+		return nil, ErrPending
+	}
+
+	notAfter, err := ParseNotAfter(csr.Status.Certificate)
+	if err != nil {
+		return nil, fmt.Errorf("certificate of CSR %s: %w", csr.Name, err)
+	}
+	return &Certificate{PEM: csr.Status.Certificate, NotAfter: notAfter, RequestedAt: csr.CreationTimestamp.Time}, nil
+}
+
+// Reset implements Issuer by deleting the CSR, so its certificate is not picked up again
+func (i *CSRIssuer) Reset(ctx context.Context, name string) error {
+	csr := &certv1.CertificateSigningRequest{ObjectMeta: metav1.ObjectMeta{Name: name}}
+	if err := i.client.Delete(ctx, csr); err != nil && !apierrors.IsNotFound(err) {
+		return fmt.Errorf("failed to delete CSR %s: %w", name, err)
+	}
+	return nil
+}
+
+func csrApproved(csr *certv1.CertificateSigningRequest) bool {
+	for _, c := range csr.Status.Conditions {
+		if c.Type == certv1.CertificateApproved && c.Status == corev1.ConditionTrue {
+			return true
+		}
+	}
+	return false
+}
+
+// csrFailed reports whether the signer marked the CSR as failed, which is terminal for that CSR
+func csrFailed(csr *certv1.CertificateSigningRequest) (string, bool) {
+	for _, c := range csr.Status.Conditions {
+		if c.Type == certv1.CertificateFailed && c.Status == corev1.ConditionTrue {
+			return c.Message, true
+		}
+	}
+	return "", false
+}
+
+// checkSigner turns a CSR that was failed or left unsigned by its signer into an UnavailableError
+func (i *CSRIssuer) checkSigner(csr *certv1.CertificateSigningRequest) error {
+	if msg, failed := csrFailed(csr); failed {
+		return &UnavailableError{Reason: fmt.Sprintf("signer %s failed CSR %s: %s", csr.Spec.SignerName, csr.Name, msg)}
+	}
+	for _, c := range csr.Status.Conditions {
+		if c.Type == certv1.CertificateApproved && c.Status == corev1.ConditionTrue &&
+			!c.LastUpdateTime.IsZero() && i.now().Sub(c.LastUpdateTime.Time) > SignerTimeout {
+			return &UnavailableError{Reason: fmt.Sprintf("signer %s has not signed approved CSR %s within %s",
+				csr.Spec.SignerName, csr.Name, SignerTimeout)}
+		}
+	}
+	return nil
+}
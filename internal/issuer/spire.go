@@ -0,0 +1,123 @@
+/*
+Copyright 2025.
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+*/
+
+package issuer
+
+import (
+	"context"
+	"encoding/pem"
+	"errors"
+	"fmt"
+	"net/url"
+	"path"
+	"time"
+
+	"github.com/openkube-hub/KubeUser/internal/spire"
+)
+
+// DefaultSPIFFEIDPath is the path of users' SPIFFE IDs in the trust domain, followed by the name
+const DefaultSPIFFEIDPath = "/kubeuser/user"
+
+// SPIREConfig configures signing through the SPIRE server
+type SPIREConfig struct {
+	// SocketPath is the SPIRE server's API socket, shared with the controller
+	SocketPath string
+	// TrustDomain is the SPIRE server's trust domain, e.g. example.org
+	TrustDomain string
+	// IDPath is the path users' SPIFFE IDs start with; DefaultSPIFFEIDPath when empty
+	IDPath string
+}
+
+// URIIssuer is implemented by issuers whose certificates carry URI SANs. The controller adds
+// them to the CSRs it creates for the user's key.
+type URIIssuer interface {
+	// URIs returns the URI SANs of the user's certificate
+	URIs(username string) []*url.URL
+}
+
+// SPIREIssuer mints X509-SVIDs for users through the SPIRE server API, so they are identified by
+// the SPIFFE ID spiffe://<trust domain><path>/<user> in the same trust domain as workloads.
+// The subject of the CSR is kept, so the API server still takes the user name from the common
+// name. SVIDs are minted synchronously and nothing is stored, so Reset has nothing to forget.
+type SPIREIssuer struct {
+	config SPIREConfig
+	client *spire.Client
+	// now is replaced in tests
+	now func() time.Time
+}
+
+// NewSPIREIssuer validates config and returns an issuer for it
+func NewSPIREIssuer(config SPIREConfig) (*SPIREIssuer, error) {
+	if config.TrustDomain == "" {
+		return nil, errors.New("spire trust domain is required")
+	}
+	if config.SocketPath == "" {
+		config.SocketPath = spire.DefaultSocketPath
+	}
+	if config.IDPath == "" {
+		config.IDPath = DefaultSPIFFEIDPath
+	}
+	config.IDPath = path.Clean("/" + config.IDPath)
+	client, err := spire.NewClient(config.SocketPath)
+	if err != nil {
+		return nil, err
+	}
+	return &SPIREIssuer{config: config, client: client, now: time.Now}, nil
+}
+
+var (
+	_ Issuer    = &SPIREIssuer{}
+	_ URIIssuer = &SPIREIssuer{}
+)
+
+// SPIFFEID returns the SPIFFE ID of username
+func (i *SPIREIssuer) SPIFFEID(username string) *url.URL {
+	return &url.URL{Scheme: "spiffe", Host: i.config.TrustDomain, Path: path.Join(i.config.IDPath, username)}
+}
+
+// URIs implements URIIssuer
+func (i *SPIREIssuer) URIs(username string) []*url.URL {
+	return []*url.URL{i.SPIFFEID(username)}
+}
+
+// Sign implements Issuer. The CSR must carry the user's SPIFFE ID as its only URI SAN.
+func (i *SPIREIssuer) Sign(ctx context.Context, req Request) (*Certificate, error) {
+	csr, err := ParseCSR(req.CSR, req.Username)
+	if err != nil {
+		return nil, err
+	}
+	id := i.SPIFFEID(req.user())
+	if len(csr.URIs) != 1 || csr.URIs[0].String() != id.String() {
+		return nil, fmt.Errorf("the CSR must carry the SPIFFE ID %s as its only URI SAN", id)
+	}
+	requestedAt := i.now()
+
+	svid, err := i.client.MintX509SVID(ctx, csr.Raw, req.Duration)
+	if err != nil {
+		if errors.Is(err, spire.ErrUnavailable) {
+			return nil, &UnavailableError{Reason: "spire cannot mint X509-SVIDs", Err: err}
+		}
+		return nil, err
+	}
+	if svid.ID != id.String() {
+		return nil, fmt.Errorf("spire minted an X509-SVID for %s instead of %s", svid.ID, id)
+	}
+	// The leaf comes first, so the certificate is checked and renewed by it
+	var certPEM []byte
+	for _, der := range svid.CertChain {
+		certPEM = append(certPEM, pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: der})...)
+	}
+	notAfter, err := ParseNotAfter(certPEM)
+	if err != nil {
+		return nil, fmt.Errorf("X509-SVID minted by spire: %w", err)
+	}
+	return &Certificate{PEM: certPEM, NotAfter: notAfter, RequestedAt: requestedAt}, nil
+}
+
+// Reset implements Issuer; every Sign already mints a new X509-SVID
+func (i *SPIREIssuer) Reset(context.Context, string) error {
+	return nil
+}
@@ -0,0 +1,197 @@
+/*
+Copyright 2025.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package issuer
+
+import (
+	"context"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/pem"
+	"net"
+	"net/url"
+	"os"
+	"path/filepath"
+	"time"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+	"google.golang.org/protobuf/encoding/protowire"
+)
+
+// bytesCodec lets the fake SPIRE server read and write messages encoded by hand
+type bytesCodec struct{}
+
+func (bytesCodec) Marshal(v any) ([]byte, error)      { return *v.(*[]byte), nil }
+func (bytesCodec) Unmarshal(data []byte, v any) error { *v.(*[]byte) = data; return nil }
+func (bytesCodec) Name() string                       { return "proto" }
+
+var _ = Describe("SPIREIssuer", func() {
+	var (
+		ctx      context.Context
+		i        *SPIREIssuer
+		method   string
+		minted   []byte
+		ttl      uint64
+		notAfter time.Time
+		// mintErr is returned by the server when set
+		mintErr error
+		// mintedID overrides the path of the SPIFFE ID the server returns when set
+		mintedID string
+	)
+
+	BeforeEach(func() {
+		ctx = context.Background()
+		method, minted, ttl, mintErr, mintedID = "", nil, 0, nil, ""
+		notAfter = time.Now().Add(time.Hour).Truncate(time.Second)
+
+		dir, err := os.MkdirTemp("", "spire")
+		Expect(err).NotTo(HaveOccurred())
+		DeferCleanup(os.RemoveAll, dir)
+		socket := filepath.Join(dir, "api.sock")
+		listener, err := net.Listen("unix", socket)
+		Expect(err).NotTo(HaveOccurred())
+
+		server := grpc.NewServer(grpc.ForceServerCodec(bytesCodec{}), grpc.UnknownServiceHandler(
+			func(_ any, stream grpc.ServerStream) error {
+				method, _ = grpc.MethodFromServerStream(stream)
+				var request []byte
+				if err := stream.RecvMsg(&request); err != nil {
+					return err
+				}
+				if mintErr != nil {
+					return mintErr
+				}
+				for len(request) > 0 {
+					num, typ, n := protowire.ConsumeTag(request)
+					request = request[n:]
+					switch typ {
+					case protowire.BytesType:
+						value, m := protowire.ConsumeBytes(request)
+						if num == 1 {
+							minted = value
+						}
+						request = request[m:]
+					case protowire.VarintType:
+						value, m := protowire.ConsumeVarint(request)
+						if num == 2 {
+							ttl = value
+						}
+						request = request[m:]
+					}
+				}
+				path := "/kubeuser/user/jane"
+				if mintedID != "" {
+					path = mintedID
+				}
+				var id []byte
+				id = protowire.AppendTag(id, 1, protowire.BytesType)
+				id = protowire.AppendString(id, "example.org")
+				id = protowire.AppendTag(id, 2, protowire.BytesType)
+				id = protowire.AppendString(id, path)
+				block, _ := pem.Decode(certificatePEM(notAfter))
+				var svid []byte
+				svid = protowire.AppendTag(svid, 1, protowire.BytesType)
+				svid = protowire.AppendBytes(svid, id)
+				svid = protowire.AppendTag(svid, 2, protowire.BytesType)
+				svid = protowire.AppendBytes(svid, block.Bytes)
+				svid = protowire.AppendTag(svid, 3, protowire.VarintType)
+				svid = protowire.AppendVarint(svid, uint64(notAfter.Unix()))
+				var response []byte
+				response = protowire.AppendTag(response, 1, protowire.BytesType)
+				response = protowire.AppendBytes(response, svid)
+				return stream.SendMsg(&response)
+			}))
+		go func() { _ = server.Serve(listener) }()
+		DeferCleanup(server.Stop)
+
+		i, err = NewSPIREIssuer(SPIREConfig{SocketPath: socket, TrustDomain: "example.org"})
+		Expect(err).NotTo(HaveOccurred())
+	})
+
+	csr := func(uris ...string) []byte {
+		key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+		Expect(err).NotTo(HaveOccurred())
+		tmpl := &x509.CertificateRequest{Subject: pkix.Name{CommonName: "jane"}}
+		for _, uri := range uris {
+			parsed, err := url.Parse(uri)
+			Expect(err).NotTo(HaveOccurred())
+			tmpl.URIs = append(tmpl.URIs, parsed)
+		}
+		der, err := x509.CreateCertificateRequest(rand.Reader, tmpl, key)
+		Expect(err).NotTo(HaveOccurred())
+		return pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE REQUEST", Bytes: der})
+	}
+	sign := func(csrPEM []byte) (*Certificate, error) {
+		return i.Sign(ctx, Request{Name: "jane-csr", Username: "jane", CSR: csrPEM, Duration: time.Hour})
+	}
+
+	It("requires a trust domain", func() {
+		_, err := NewSPIREIssuer(SPIREConfig{})
+		Expect(err).To(HaveOccurred())
+	})
+
+	It("asks for the user's SPIFFE ID in the CSR", func() {
+		Expect(i.URIs("jane")).To(HaveExactElements(HaveField("String()", "spiffe://example.org/kubeuser/user/jane")))
+	})
+
+	It("mints an X509-SVID for the user", func() {
+		request := csr("spiffe://example.org/kubeuser/user/jane")
+		cert, err := sign(request)
+		Expect(err).NotTo(HaveOccurred())
+		Expect(method).To(Equal("/spire.api.server.svid.v1.SVID/MintX509SVID"))
+		block, _ := pem.Decode(request)
+		Expect(minted).To(Equal(block.Bytes))
+		Expect(ttl).To(Equal(uint64(3600)))
+		Expect(cert.NotAfter).To(BeTemporally("==", notAfter))
+		parsed, err := ParseNotAfter(cert.PEM)
+		Expect(err).NotTo(HaveOccurred())
+		Expect(parsed).To(BeTemporally("==", notAfter))
+	})
+
+	It("rejects CSRs without the user's SPIFFE ID", func() {
+		_, err := sign(csr())
+		Expect(err).To(MatchError(ContainSubstring("spiffe://example.org/kubeuser/user/jane")))
+		_, err = sign(csr("spiffe://example.org/kubeuser/user/john"))
+		Expect(err).To(HaveOccurred())
+		Expect(method).To(BeEmpty())
+	})
+
+	It("rejects X509-SVIDs for another SPIFFE ID", func() {
+		mintedID = "/kubeuser/user/john"
+		_, err := sign(csr("spiffe://example.org/kubeuser/user/jane"))
+		Expect(err).To(MatchError(ContainSubstring("instead of")))
+	})
+
+	It("reports an unreachable server as unavailable", func() {
+		mintErr = status.Error(codes.Unavailable, "datastore is down")
+		_, err := sign(csr("spiffe://example.org/kubeuser/user/jane"))
+		Expect(IsUnavailable(err)).To(BeTrue())
+	})
+
+	It("reports rejected requests as errors", func() {
+		mintErr = status.Error(codes.PermissionDenied, "not an admin")
+		_, err := sign(csr("spiffe://example.org/kubeuser/user/jane"))
+		Expect(err).To(MatchError(ContainSubstring("not an admin")))
+		Expect(IsUnavailable(err)).To(BeFalse())
+	})
+})
@@ -0,0 +1,114 @@
+/*
+Copyright 2025.
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+*/
+
+package issuer
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/openkube-hub/KubeUser/internal/vault"
+)
+
+// DefaultServiceAccountTokenPath is the projected token used to log in to Vault
+const DefaultServiceAccountTokenPath = vault.DefaultServiceAccountTokenPath
+
+// VaultConfig configures signing through Vault's PKI secrets engine
+type VaultConfig struct {
+	// Address of the Vault server, e.g. https://vault.vault.svc:8200
+	Address string
+	// PKIMount is the mount path of the PKI secrets engine
+	PKIMount string
+	// Role is the PKI role used by /sign; it decides allowed names and the maximum TTL
+	Role string
+	// AuthMount is the mount path of the Kubernetes auth method
+	AuthMount string
+	// AuthRole is the Kubernetes auth role the controller logs in as
+	AuthRole string
+	// TokenPath is the ServiceAccount token presented to the Kubernetes auth method
+	TokenPath string
+	// CACert is a PEM file with the CA of Vault's TLS certificate; empty uses the system roots
+	CACert string
+}
+
+// Client returns the configuration of the connection to Vault
+func (c VaultConfig) Client() vault.Config {
+	return vault.Config{
+		Address:   c.Address,
+		AuthMount: c.AuthMount,
+		AuthRole:  c.AuthRole,
+		TokenPath: c.TokenPath,
+		CACert:    c.CACert,
+	}
+}
+
+// VaultIssuer signs CSRs synchronously with Vault's pki/sign endpoint, logging in through the
+// Kubernetes auth method. Nothing is stored in the cluster, so Reset has nothing to forget.
+type VaultIssuer struct {
+	config VaultConfig
+	client *vault.Client
+	// now is replaced in tests
+	now func() time.Time
+}
+
+// NewVaultIssuer validates config and returns an issuer for it
+func NewVaultIssuer(config VaultConfig) (*VaultIssuer, error) {
+	if config.Address == "" || config.Role == "" || config.AuthRole == "" {
+		return nil, errors.New("vault address, PKI role and Kubernetes auth role are required")
+	}
+	if config.PKIMount == "" {
+		config.PKIMount = "pki"
+	}
+	client, err := vault.NewClient(config.Client())
+	if err != nil {
+		return nil, err
+	}
+	i := &VaultIssuer{config: config, client: client, now: time.Now}
+	client.Now = func() time.Time { return i.now() }
+	return i, nil
+}
+
+var _ Issuer = &VaultIssuer{}
+
+// Sign implements Issuer
+func (i *VaultIssuer) Sign(ctx context.Context, req Request) (*Certificate, error) {
+	body := map[string]any{
+		"csr":         string(req.CSR),
+		"common_name": req.Username,
+		"format":      "pem",
+	}
+	if req.Duration > 0 {
+		body["ttl"] = req.Duration.String()
+	}
+	requestedAt := i.now()
+
+	var response struct {
+		Data struct {
+			Certificate string `json:"certificate"`
+		} `json:"data"`
+	}
+	path := fmt.Sprintf("%s/sign/%s", i.config.PKIMount, i.config.Role)
+	if err := i.client.Write(ctx, path, body, &response); err != nil {
+		if errors.Is(err, vault.ErrUnavailable) {
+			return nil, &UnavailableError{Reason: "vault cannot sign certificates", Err: err}
+		}
+		return nil, err
+	}
+
+	certPEM := []byte(response.Data.Certificate)
+	notAfter, err := ParseNotAfter(certPEM)
+	if err != nil {
+		return nil, fmt.Errorf("certificate signed by vault: %w", err)
+	}
+	return &Certificate{PEM: certPEM, NotAfter: notAfter, RequestedAt: requestedAt}, nil
+}
+
+// Reset implements Issuer; every Sign already issues a new certificate
+func (i *VaultIssuer) Reset(context.Context, string) error {
+	return nil
+}
@@ -0,0 +1,133 @@
+/*
+Copyright 2025.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package issuer
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"time"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("VaultIssuer", func() {
+	var (
+		ctx      context.Context
+		server   *httptest.Server
+		i        *VaultIssuer
+		logins   int
+		signed   map[string]any
+		certPEM  []byte
+		notAfter time.Time
+		// signStatus overrides the response of the sign endpoint when set
+		signStatus int
+	)
+
+	BeforeEach(func() {
+		ctx = context.Background()
+		logins, signed, signStatus = 0, nil, 0
+		notAfter = time.Now().Add(24 * time.Hour).Truncate(time.Second)
+		certPEM = certificatePEM(notAfter)
+
+		mux := http.NewServeMux()
+		mux.HandleFunc("POST /v1/auth/kubernetes/login", func(w http.ResponseWriter, r *http.Request) {
+			var body map[string]string
+			Expect(json.NewDecoder(r.Body).Decode(&body)).To(Succeed())
+			Expect(body).To(Equal(map[string]string{"role": "kubeuser", "jwt": "sa-token"}))
+			logins++
+			_ = json.NewEncoder(w).Encode(map[string]any{
+				"auth": map[string]any{"client_token": "vault-token", "lease_duration": 3600},
+			})
+		})
+		mux.HandleFunc("POST /v1/pki_users/sign/users", func(w http.ResponseWriter, r *http.Request) {
+			if r.Header.Get("X-Vault-Token") != "vault-token" || signStatus != 0 {
+				status := signStatus
+				if status == 0 {
+					status = http.StatusForbidden
+				}
+				w.WriteHeader(status)
+				_ = json.NewEncoder(w).Encode(map[string]any{"errors": []string{"permission denied"}})
+				return
+			}
+			Expect(json.NewDecoder(r.Body).Decode(&signed)).To(Succeed())
+			_ = json.NewEncoder(w).Encode(map[string]any{"data": map[string]any{"certificate": string(certPEM)}})
+		})
+		server = httptest.NewServer(mux)
+		DeferCleanup(server.Close)
+
+		tokenPath := filepath.Join(GinkgoT().TempDir(), "token")
+		Expect(os.WriteFile(tokenPath, []byte("sa-token\n"), 0o600)).To(Succeed())
+		var err error
+		i, err = NewVaultIssuer(VaultConfig{
+			Address:   server.URL + "/",
+			PKIMount:  "pki_users",
+			Role:      "users",
+			AuthRole:  "kubeuser",
+			TokenPath: tokenPath,
+		})
+		Expect(err).NotTo(HaveOccurred())
+	})
+
+	sign := func() (*Certificate, error) {
+		return i.Sign(ctx, Request{Name: "jane-csr", Username: "jane", CSR: []byte("csr"), Duration: time.Hour})
+	}
+
+	It("requires an address and roles", func() {
+		_, err := NewVaultIssuer(VaultConfig{Address: server.URL})
+		Expect(err).To(HaveOccurred())
+	})
+
+	It("logs in and signs the CSR for the user", func() {
+		cert, err := sign()
+		Expect(err).NotTo(HaveOccurred())
+		Expect(cert.PEM).To(Equal(certPEM))
+		Expect(cert.NotAfter).To(BeTemporally("==", notAfter))
+		Expect(signed).To(HaveKeyWithValue("csr", "csr"))
+		Expect(signed).To(HaveKeyWithValue("common_name", "jane"))
+		Expect(signed).To(HaveKeyWithValue("ttl", "1h0m0s"))
+
+		_, err = sign()
+		Expect(err).NotTo(HaveOccurred())
+		Expect(logins).To(Equal(1))
+	})
+
+	It("logs in again when the token expired", func() {
+		_, _ = sign()
+		i.now = func() time.Time { return time.Now().Add(time.Hour) }
+		_, err := sign()
+		Expect(err).NotTo(HaveOccurred())
+		Expect(logins).To(Equal(2))
+	})
+
+	It("reports a sealed vault as unavailable", func() {
+		signStatus = http.StatusServiceUnavailable
+		_, err := sign()
+		Expect(IsUnavailable(err)).To(BeTrue())
+	})
+
+	It("reports rejected requests as errors", func() {
+		signStatus = http.StatusBadRequest
+		_, err := sign()
+		Expect(err).To(MatchError(ContainSubstring("permission denied")))
+		Expect(IsUnavailable(err)).To(BeFalse())
+	})
+})
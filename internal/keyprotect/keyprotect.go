@@ -0,0 +1,98 @@
+/*
+Copyright 2025.
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+*/
+
+// Package keyprotect encrypts the private keys the controller holds for users with an external
+// key management service before they are stored in Secrets. Keys are only decrypted in memory
+// while a CSR or kubeconfig is assembled from them.
+package keyprotect
+
+import (
+	"context"
+	"encoding/base64"
+	"errors"
+	"fmt"
+
+	"github.com/openkube-hub/KubeUser/internal/vault"
+)
+
+// Protector encrypts and decrypts user private keys
+type Protector interface {
+	// Name identifies the protector and its key; it is recorded next to every ciphertext
+	Name() string
+	// Encrypt returns the ciphertext stored instead of the PEM private key of username
+	Encrypt(ctx context.Context, username string, keyPEM []byte) ([]byte, error)
+	// Decrypt returns the PEM private key of username from its ciphertext
+	Decrypt(ctx context.Context, username string, ciphertext []byte) ([]byte, error)
+}
+
+// VaultTransit encrypts keys with a key of Vault's transit secrets engine, which never leaves
+// Vault. The username is bound to the ciphertext as associated data, so a ciphertext copied to
+// another user's Secret does not decrypt; the transit key must be of an AEAD type such as the
+// default aes256-gcm96.
+type VaultTransit struct {
+	client *vault.Client
+	mount  string
+	key    string
+}
+
+var _ Protector = &VaultTransit{}
+
+// NewVaultTransit returns a protector using the transit key named key under mount
+func NewVaultTransit(client *vault.Client, mount, key string) (*VaultTransit, error) {
+	if key == "" {
+		return nil, errors.New("vault transit key is required")
+	}
+	if mount == "" {
+		mount = "transit"
+	}
+	return &VaultTransit{client: client, mount: mount, key: key}, nil
+}
+
+// Name implements Protector
+func (t *VaultTransit) Name() string {
+	return fmt.Sprintf("vault-transit:%s/%s", t.mount, t.key)
+}
+
+// Encrypt implements Protector
+func (t *VaultTransit) Encrypt(ctx context.Context, username string, keyPEM []byte) ([]byte, error) {
+	var response struct {
+		Data struct {
+			Ciphertext string `json:"ciphertext"`
+		} `json:"data"`
+	}
+	body := map[string]any{
+		"plaintext":       base64.StdEncoding.EncodeToString(keyPEM),
+		"associated_data": base64.StdEncoding.EncodeToString([]byte(username)),
+	}
+	if err := t.client.Write(ctx, fmt.Sprintf("%s/encrypt/%s", t.mount, t.key), body, &response); err != nil {
+		return nil, fmt.Errorf("encrypting private key of user %s: %w", username, err)
+	}
+	if response.Data.Ciphertext == "" {
+		return nil, fmt.Errorf("encrypting private key of user %s: vault returned no ciphertext", username)
+	}
+	return []byte(response.Data.Ciphertext), nil
+}
+
+// Decrypt implements Protector
+func (t *VaultTransit) Decrypt(ctx context.Context, username string, ciphertext []byte) ([]byte, error) {
+	var response struct {
+		Data struct {
+			Plaintext string `json:"plaintext"`
+		} `json:"data"`
+	}
+	body := map[string]any{
+		"ciphertext":      string(ciphertext),
+		"associated_data": base64.StdEncoding.EncodeToString([]byte(username)),
+	}
+	if err := t.client.Write(ctx, fmt.Sprintf("%s/decrypt/%s", t.mount, t.key), body, &response); err != nil {
+		return nil, fmt.Errorf("decrypting private key of user %s: %w", username, err)
+	}
+	keyPEM, err := base64.StdEncoding.DecodeString(response.Data.Plaintext)
+	if err != nil {
+		return nil, fmt.Errorf("decrypting private key of user %s: %w", username, err)
+	}
+	return keyPEM, nil
+}
@@ -0,0 +1,20 @@
+/*
+Copyright 2025.
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+*/
+
+package keyprotect
+
+import (
+	"testing"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+)
+
+func TestKeyProtect(t *testing.T) {
+	RegisterFailHandler(Fail)
+
+	RunSpecs(t, "KeyProtect Suite")
+}
@@ -0,0 +1,63 @@
+/*
+Copyright 2025.
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+*/
+
+// Package naming builds names for objects generated by the controller. Names never exceed
+// the Kubernetes length limits and never collide between different inputs, shortening
+// readable components and adding a hash of the full input where needed.
+package naming
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"strings"
+)
+
+const (
+	// MaxNameLength is the length limit of most object names (DNS subdomain)
+	MaxNameLength = 253
+	// MaxLabelLength is the length limit of a DNS label and of label values. Binding names are kept
+	// to it so they stay readable in kubectl output.
+	MaxLabelLength = 63
+
+	hashLength = 10
+)
+
+// Join returns <part>-<part>-<hash>-<suffix>. The hash covers the parts individually, so part
+// lists that read the same once joined with "-", like ("ann-a", "dev") and ("ann", "a-dev"),
+// still get different names. The readable parts are shortened to keep the name within maxLength.
+func Join(maxLength int, suffix string, parts ...string) string {
+	return build(maxLength, strings.Join(parts, "-"), hash(parts...), suffix)
+}
+
+// Suffixed returns <base>-<suffix>, which is unique as long as base is. Only when that exceeds
+// maxLength is base shortened and a hash of the full base added.
+func Suffixed(maxLength int, base, suffix string) string {
+	if name := base + "-" + suffix; len(name) <= maxLength {
+		return name
+	}
+	return build(maxLength, base, hash(base), suffix)
+}
+
+func build(maxLength int, readable, sum, suffix string) string {
+	tail := "-" + sum
+	if suffix != "" {
+		tail += "-" + suffix
+	}
+	if budget := maxLength - len(tail); len(readable) > budget {
+		// Names must start and end alphanumeric, so never leave a separator at the cut
+		readable = strings.TrimRight(readable[:max(budget, 0)], "-.:")
+	}
+	if readable == "" {
+		return strings.TrimPrefix(tail, "-")
+	}
+	return readable + tail
+}
+
+// hash returns a short hex digest of parts; the NUL separator cannot appear in any name
+func hash(parts ...string) string {
+	sum := sha256.Sum256([]byte(strings.Join(parts, "\x00")))
+	return hex.EncodeToString(sum[:])[:hashLength]
+}
@@ -0,0 +1,60 @@
+/*
+Copyright 2025.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package naming
+
+import (
+	"strings"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("Naming", func() {
+	It("keeps the readable parts and appends a hash", func() {
+		name := Join(MaxLabelLength, "rb", "jane", "developer")
+		Expect(name).To(MatchRegexp(`^jane-developer-[0-9a-f]{10}-rb$`))
+		Expect(Join(MaxLabelLength, "rb", "jane", "developer")).To(Equal(name))
+	})
+
+	It("does not collide when the joined parts read the same", func() {
+		Expect(Join(MaxLabelLength, "rb", "ann-a", "dev")).NotTo(Equal(Join(MaxLabelLength, "rb", "ann", "a-dev")))
+	})
+
+	It("shortens long parts to the limit", func() {
+		long := strings.Repeat("a", 100)
+		name := Join(MaxLabelLength, "crb", long, "cluster-admin")
+		Expect(name).To(HaveLen(MaxLabelLength))
+		Expect(name).To(HaveSuffix("-crb"))
+		Expect(name).NotTo(Equal(Join(MaxLabelLength, "crb", long, "cluster-admin-2")))
+	})
+
+	It("never leaves a separator at the cut", func() {
+		name := Join(20, "rb", "abcdef-", "x")
+		Expect(name).NotTo(ContainSubstring("--"))
+		Expect(len(name)).To(BeNumerically("<=", 20))
+	})
+
+	It("only hashes suffixed names that are too long", func() {
+		Expect(Suffixed(MaxNameLength, "jane", "kubeconfig")).To(Equal("jane-kubeconfig"))
+
+		long := strings.Repeat("b", MaxNameLength)
+		name := Suffixed(MaxNameLength, long, "kubeconfig")
+		Expect(name).To(HaveLen(MaxNameLength))
+		Expect(name).To(HaveSuffix("-kubeconfig"))
+		Expect(name).NotTo(Equal(Suffixed(MaxNameLength, long+"c", "kubeconfig")))
+	})
+})
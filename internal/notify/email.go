@@ -0,0 +1,232 @@
+/*
+Copyright 2025.
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+*/
+
+package notify
+
+import (
+	"bytes"
+	"context"
+	"crypto/tls"
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"io"
+	"mime"
+	"mime/multipart"
+	"mime/quotedprintable"
+	"net"
+	"net/mail"
+	"net/smtp"
+	"net/textproto"
+	"strings"
+	"time"
+)
+
+// Keys of the Secret referenced by an email sink
+const (
+	// EmailHostKey holds the SMTP server's host name
+	EmailHostKey = "host"
+	// EmailPortKey holds the SMTP port; 587 when unset. Port 465 uses implicit TLS, other
+	// ports STARTTLS when the server offers it.
+	EmailPortKey = "port"
+	// EmailUsernameKey and EmailPasswordKey hold the SMTP login, if the server needs one
+	EmailUsernameKey = "username"
+	EmailPasswordKey = "password"
+	// EmailFromKey holds the sender address
+	EmailFromKey = "from"
+	// EmailToKey holds comma separated addresses that receive every notification of the
+	// sink in addition to the user's spec.email
+	EmailToKey = "to"
+)
+
+// EmailSender sends notifications through an SMTP server
+type EmailSender struct {
+	// Timeout bounds connecting and sending; defaults to 10s
+	Timeout time.Duration
+	// TLSConfig is used for implicit TLS and STARTTLS; nil verifies the server's certificate
+	// against the system roots
+	TLSConfig *tls.Config
+}
+
+// Send implements Sender. Notifications go to the user's spec.email and the sink's fixed
+// recipients; without either there is nobody to tell and nothing is sent.
+func (s *EmailSender) Send(ctx context.Context, delivery Delivery) error {
+	host := string(delivery.Secret[EmailHostKey])
+	from := string(delivery.Secret[EmailFromKey])
+	if host == "" || from == "" {
+		return fmt.Errorf("secret needs %s and %s", EmailHostKey, EmailFromKey)
+	}
+	port := string(delivery.Secret[EmailPortKey])
+	if port == "" {
+		port = "587"
+	}
+
+	var recipients []string
+	if delivery.User != nil && delivery.User.Spec.Email != "" {
+		recipients = append(recipients, delivery.User.Spec.Email)
+	}
+	for _, to := range strings.Split(string(delivery.Secret[EmailToKey]), ",") {
+		if to = strings.TrimSpace(to); to != "" {
+			recipients = append(recipients, to)
+		}
+	}
+	if len(recipients) == 0 {
+		return nil
+	}
+
+	msg, err := buildEmail(from, recipients, delivery.Message, delivery.Event.Attachments, time.Now())
+	if err != nil {
+		return err
+	}
+	return s.deliver(ctx, host, port, delivery.Secret, from, recipients, msg)
+}
+
+func (s *EmailSender) deliver(ctx context.Context, host, port string, secret map[string][]byte,
+	from string, recipients []string, msg []byte) error {
+	timeout := s.Timeout
+	if timeout == 0 {
+		timeout = sendTimeout
+	}
+	deadline := time.Now().Add(timeout)
+	if d, ok := ctx.Deadline(); ok && d.Before(deadline) {
+		deadline = d
+	}
+	tlsConfig := s.TLSConfig.Clone()
+	if tlsConfig == nil {
+		tlsConfig = &tls.Config{MinVersion: tls.VersionTLS12}
+	}
+	tlsConfig.ServerName = host
+
+	addr := net.JoinHostPort(host, port)
+	dialer := &net.Dialer{Deadline: deadline}
+	var conn net.Conn
+	var err error
+	if port == "465" {
+		conn, err = (&tls.Dialer{NetDialer: dialer, Config: tlsConfig}).DialContext(ctx, "tcp", addr)
+	} else {
+		conn, err = dialer.DialContext(ctx, "tcp", addr)
+	}
+	if err != nil {
+		return fmt.Errorf("connecting to %s: %w", addr, err)
+	}
+	_ = conn.SetDeadline(deadline)
+	c, err := smtp.NewClient(conn, host)
+	if err != nil {
+		_ = conn.Close()
+		return fmt.Errorf("connecting to %s: %w", addr, err)
+	}
+	defer func() { _ = c.Close() }()
+
+	if port != "465" {
+		if ok, _ := c.Extension("STARTTLS"); ok {
+			if err := c.StartTLS(tlsConfig); err != nil {
+				return fmt.Errorf("starting TLS: %w", err)
+			}
+		}
+	}
+	// PlainAuth refuses to send the password over a connection without TLS
+	if username := string(secret[EmailUsernameKey]); username != "" {
+		if err := c.Auth(smtp.PlainAuth("", username, string(secret[EmailPasswordKey]), host)); err != nil {
+			return fmt.Errorf("authenticating as %s: %w", username, err)
+		}
+	}
+	if err := c.Mail(from); err != nil {
+		return err
+	}
+	for _, rcpt := range recipients {
+		if err := c.Rcpt(rcpt); err != nil {
+			return fmt.Errorf("recipient %s: %w", rcpt, err)
+		}
+	}
+	w, err := c.Data()
+	if err != nil {
+		return err
+	}
+	if _, err := w.Write(msg); err != nil {
+		return err
+	}
+	if err := w.Close(); err != nil {
+		return err
+	}
+	return c.Quit()
+}
+
+// buildEmail renders a MIME message with the body as text and attachments, if any, as files
+func buildEmail(from string, to []string, msg Message, attachments []Attachment, now time.Time) ([]byte, error) {
+	for _, addr := range append([]string{from}, to...) {
+		if _, err := mail.ParseAddress(addr); err != nil {
+			return nil, fmt.Errorf("invalid address %q: %w", addr, err)
+		}
+	}
+	if strings.ContainsAny(msg.Subject, "\r\n") {
+		return nil, errors.New("subject must be a single line")
+	}
+
+	var buf bytes.Buffer
+	fmt.Fprintf(&buf, "From: %s\r\n", from)
+	fmt.Fprintf(&buf, "To: %s\r\n", strings.Join(to, ", "))
+	fmt.Fprintf(&buf, "Subject: %s\r\n", mime.QEncoding.Encode("utf-8", msg.Subject))
+	fmt.Fprintf(&buf, "Date: %s\r\n", now.Format(time.RFC1123Z))
+	buf.WriteString("MIME-Version: 1.0\r\n")
+
+	if len(attachments) == 0 {
+		buf.WriteString("Content-Type: text/plain; charset=utf-8\r\n")
+		buf.WriteString("Content-Transfer-Encoding: quoted-printable\r\n\r\n")
+		if err := writeQuotedPrintable(&buf, msg.Body); err != nil {
+			return nil, err
+		}
+		return buf.Bytes(), nil
+	}
+
+	mw := multipart.NewWriter(&buf)
+	fmt.Fprintf(&buf, "Content-Type: multipart/mixed; boundary=%s\r\n\r\n", mw.Boundary())
+	part, err := mw.CreatePart(textproto.MIMEHeader{
+		"Content-Type":              {"text/plain; charset=utf-8"},
+		"Content-Transfer-Encoding": {"quoted-printable"},
+	})
+	if err != nil {
+		return nil, err
+	}
+	if err := writeQuotedPrintable(part, msg.Body); err != nil {
+		return nil, err
+	}
+	for _, a := range attachments {
+		contentType := a.ContentType
+		if contentType == "" {
+			contentType = "application/octet-stream"
+		}
+		part, err := mw.CreatePart(textproto.MIMEHeader{
+			"Content-Type":              {contentType},
+			"Content-Transfer-Encoding": {"base64"},
+			"Content-Disposition":       {mime.FormatMediaType("attachment", map[string]string{"filename": a.Name})},
+		})
+		if err != nil {
+			return nil, err
+		}
+		encoded := base64.StdEncoding.EncodeToString(a.Data)
+		for len(encoded) > 76 {
+			if _, err := part.Write([]byte(encoded[:76] + "\r\n")); err != nil {
+				return nil, err
+			}
+			encoded = encoded[76:]
+		}
+		if _, err := part.Write([]byte(encoded + "\r\n")); err != nil {
+			return nil, err
+		}
+	}
+	if err := mw.Close(); err != nil {
+		return nil, err
+	}
+	return buf.Bytes(), nil
+}
+
+func writeQuotedPrintable(w io.Writer, text string) error {
+	qp := quotedprintable.NewWriter(w)
+	if _, err := qp.Write([]byte(text)); err != nil {
+		return err
+	}
+	return qp.Close()
+}
@@ -0,0 +1,214 @@
+/*
+Copyright 2025.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package notify
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/pbkdf2"
+	"crypto/sha256"
+	"encoding/base64"
+	"io"
+	"mime"
+	"mime/multipart"
+	"net"
+	"net/mail"
+	"strings"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+
+	authv1alpha1 "github.com/openkube-hub/KubeUser/api/v1alpha1"
+)
+
+// receivedMail is a message accepted by smtpServer
+type receivedMail struct {
+	from string
+	to   []string
+	data string
+}
+
+// smtpServer accepts mail without TLS or authentication and returns its port
+func smtpServer() (string, chan receivedMail) {
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	Expect(err).NotTo(HaveOccurred())
+	DeferCleanup(listener.Close)
+	received := make(chan receivedMail, 10)
+	go func() {
+		defer GinkgoRecover()
+		for {
+			conn, err := listener.Accept()
+			if err != nil {
+				return
+			}
+			serveSMTP(conn, received)
+		}
+	}()
+	_, port, _ := net.SplitHostPort(listener.Addr().String())
+	return port, received
+}
+
+func serveSMTP(conn net.Conn, received chan<- receivedMail) {
+	defer func() { _ = conn.Close() }()
+	r := bufio.NewReader(conn)
+	reply := func(line string) { _, _ = io.WriteString(conn, line+"\r\n") }
+	reply("220 localhost ESMTP")
+	var msg receivedMail
+	for {
+		line, err := r.ReadString('\n')
+		if err != nil {
+			return
+		}
+		cmd := strings.TrimRight(line, "\r\n")
+		switch {
+		case strings.HasPrefix(cmd, "EHLO"), strings.HasPrefix(cmd, "HELO"):
+			reply("250 localhost")
+		case strings.HasPrefix(cmd, "MAIL FROM:"):
+			msg.from = strings.Trim(strings.TrimPrefix(cmd, "MAIL FROM:"), "<>")
+			reply("250 OK")
+		case strings.HasPrefix(cmd, "RCPT TO:"):
+			msg.to = append(msg.to, strings.Trim(strings.TrimPrefix(cmd, "RCPT TO:"), "<>"))
+			reply("250 OK")
+		case cmd == "DATA":
+			reply("354 go ahead")
+			var data strings.Builder
+			for {
+				line, err := r.ReadString('\n')
+				if err != nil {
+					return
+				}
+				if line == ".\r\n" {
+					break
+				}
+				data.WriteString(line)
+			}
+			msg.data = data.String()
+			received <- msg
+			reply("250 OK")
+		case cmd == "QUIT":
+			reply("221 bye")
+			return
+		default:
+			reply("502 not implemented")
+		}
+	}
+}
+
+// decrypt reverses Encrypt like openssl enc -d does
+func decrypt(data []byte, passphrase string) []byte {
+	Expect(string(data[:8])).To(Equal("Salted__"))
+	keyIV, err := pbkdf2.Key(sha256.New, passphrase, data[8:16], EncryptionIterations, 48)
+	Expect(err).NotTo(HaveOccurred())
+	block, err := aes.NewCipher(keyIV[:32])
+	Expect(err).NotTo(HaveOccurred())
+	plain := make([]byte, len(data)-16)
+	cipher.NewCBCDecrypter(block, keyIV[32:]).CryptBlocks(plain, data[16:])
+	return plain[:len(plain)-int(plain[len(plain)-1])]
+}
+
+var _ = Describe("EmailSender", func() {
+	var (
+		ctx  context.Context
+		user *authv1alpha1.User
+	)
+
+	BeforeEach(func() {
+		ctx = context.Background()
+		user = &authv1alpha1.User{
+			ObjectMeta: metav1.ObjectMeta{Name: "jane"},
+			Spec:       authv1alpha1.UserSpec{Email: "jane@example.com"},
+		}
+	})
+
+	It("sends notices to the user's address and the sink's recipients", func() {
+		port, received := smtpServer()
+		err := (&EmailSender{}).Send(ctx, Delivery{
+			Secret: map[string][]byte{
+				EmailHostKey: []byte("127.0.0.1"), EmailPortKey: []byte(port),
+				EmailFromKey: []byte("kubeuser@example.com"), EmailToKey: []byte("ops@example.com, "),
+			},
+			User:    user,
+			Event:   Event{Type: EventExpiring, User: "jane"},
+			Message: Message{Subject: "Access for jane expires in 24h", Body: "Hello jane"},
+		})
+		Expect(err).NotTo(HaveOccurred())
+
+		var got receivedMail
+		Eventually(received).Should(Receive(&got))
+		Expect(got.from).To(Equal("kubeuser@example.com"))
+		Expect(got.to).To(Equal([]string{"jane@example.com", "ops@example.com"}))
+		parsed, err := mail.ReadMessage(strings.NewReader(got.data))
+		Expect(err).NotTo(HaveOccurred())
+		Expect(parsed.Header.Get("Subject")).To(Equal("Access for jane expires in 24h"))
+		Expect(parsed.Header.Get("Content-Type")).To(HavePrefix("text/plain"))
+	})
+
+	It("skips users without an address", func() {
+		user.Spec.Email = ""
+		err := (&EmailSender{}).Send(ctx, Delivery{
+			Secret:  map[string][]byte{EmailHostKey: []byte("127.0.0.1"), EmailFromKey: []byte("kubeuser@example.com")},
+			User:    user,
+			Message: Message{Subject: "s", Body: "b"},
+		})
+		Expect(err).NotTo(HaveOccurred())
+	})
+
+	It("attaches files that openssl can decrypt", func() {
+		port, received := smtpServer()
+		kubeconfig := []byte("apiVersion: v1\nkind: Config\n")
+		encrypted, err := Encrypt(kubeconfig, "passphrase")
+		Expect(err).NotTo(HaveOccurred())
+
+		err = (&EmailSender{}).Send(ctx, Delivery{
+			Secret: map[string][]byte{
+				EmailHostKey: []byte("127.0.0.1"), EmailPortKey: []byte(port), EmailFromKey: []byte("kubeuser@example.com"),
+			},
+			User: user,
+			Event: Event{Type: EventProvisioned, User: "jane", Attachments: []Attachment{
+				{Name: "jane.kubeconfig.enc", ContentType: EncryptedContentType, Data: encrypted},
+			}},
+			Message: Message{Subject: "Access ready", Body: "Hello jane"},
+		})
+		Expect(err).NotTo(HaveOccurred())
+
+		var got receivedMail
+		Eventually(received).Should(Receive(&got))
+		parsed, err := mail.ReadMessage(strings.NewReader(got.data))
+		Expect(err).NotTo(HaveOccurred())
+		mediaType, params, err := mime.ParseMediaType(parsed.Header.Get("Content-Type"))
+		Expect(err).NotTo(HaveOccurred())
+		Expect(mediaType).To(Equal("multipart/mixed"))
+
+		// multipart.Reader decodes quoted-printable but not base64 parts
+		mr := multipart.NewReader(parsed.Body, params["boundary"])
+		text, err := mr.NextPart()
+		Expect(err).NotTo(HaveOccurred())
+		Expect(io.ReadAll(text)).To(BeEquivalentTo("Hello jane"))
+		file, err := mr.NextPart()
+		Expect(err).NotTo(HaveOccurred())
+		Expect(file.FileName()).To(Equal("jane.kubeconfig.enc"))
+		raw, err := io.ReadAll(file)
+		Expect(err).NotTo(HaveOccurred())
+		data, err := io.ReadAll(base64.NewDecoder(base64.StdEncoding, bytes.NewReader(raw)))
+		Expect(err).NotTo(HaveOccurred())
+		Expect(decrypt(data, "passphrase")).To(Equal(kubeconfig))
+	})
+})
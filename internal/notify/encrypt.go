@@ -0,0 +1,63 @@
+/*
+Copyright 2025.
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+*/
+
+package notify
+
+import (
+	"bytes"
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/pbkdf2"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/base64"
+)
+
+// EncryptedContentType is the content type of attachments produced by Encrypt
+const EncryptedContentType = "application/octet-stream"
+
+// EncryptionIterations is the PBKDF2 iteration count of Encrypt; the built-in provisioned
+// email tells recipients to pass it to openssl
+const EncryptionIterations = 600000
+
+// opensslMagic starts the output of openssl enc when a salt is used
+const opensslMagic = "Salted__"
+
+// Encrypt encrypts data with passphrase in the format of
+//
+//	openssl enc -aes-256-cbc -pbkdf2 -iter 600000
+//
+// so recipients need nothing but openssl to decrypt it
+func Encrypt(data []byte, passphrase string) ([]byte, error) {
+	salt := make([]byte, 8)
+	if _, err := rand.Read(salt); err != nil {
+		return nil, err
+	}
+	keyIV, err := pbkdf2.Key(sha256.New, passphrase, salt, EncryptionIterations, 32+aes.BlockSize)
+	if err != nil {
+		return nil, err
+	}
+	block, err := aes.NewCipher(keyIV[:32])
+	if err != nil {
+		return nil, err
+	}
+	padding := aes.BlockSize - len(data)%aes.BlockSize
+	plain := append(bytes.Clone(data), bytes.Repeat([]byte{byte(padding)}, padding)...)
+	out := make([]byte, len(opensslMagic)+len(salt)+len(plain))
+	copy(out, opensslMagic)
+	copy(out[len(opensslMagic):], salt)
+	cipher.NewCBCEncrypter(block, keyIV[32:]).CryptBlocks(out[len(opensslMagic)+len(salt):], plain)
+	return out, nil
+}
+
+// NewPassphrase returns a random passphrase for Encrypt
+func NewPassphrase() (string, error) {
+	b := make([]byte, 24)
+	if _, err := rand.Read(b); err != nil {
+		return "", err
+	}
+	return base64.RawURLEncoding.EncodeToString(b), nil
+}
@@ -0,0 +1,91 @@
+/*
+Copyright 2025.
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+*/
+
+// Package notify renders user lifecycle notifications from Go templates that can be
+// customized per event type and channel.
+package notify
+
+import "time"
+
+// EventType is a user lifecycle event that can be notified about
+type EventType string
+
+const (
+	// EventCreated is sent when a new User is first reconciled
+	EventCreated EventType = "created"
+	// EventActive is sent when a user becomes Active, e.g. after provisioning or resuming
+	EventActive EventType = "active"
+	// EventProvisioned is sent when a user's credentials are first issued
+	EventProvisioned EventType = "provisioned"
+	// EventExpiring is sent when a user's access expires soon
+	EventExpiring EventType = "expiring"
+	// EventRotated is sent when a user's certificate was rotated
+	EventRotated EventType = "rotated"
+	// EventRevoked is sent when a user was revoked
+	EventRevoked EventType = "revoked"
+	// EventExpired is sent when a user's certificate expired
+	EventExpired EventType = "expired"
+	// EventDeleted is sent when a User was deleted and its resources removed
+	EventDeleted EventType = "deleted"
+	// EventElevationExpired is sent when a temporary cluster role grant was removed
+	EventElevationExpired EventType = "elevationExpired"
+	// EventReconcileFailed is sent when a user cannot be reconciled
+	EventReconcileFailed EventType = "reconcileFailed"
+)
+
+// EventTypes lists all event types in a stable order
+var EventTypes = []EventType{
+	EventCreated, EventActive, EventProvisioned, EventExpiring, EventRotated, EventRevoked, EventExpired,
+	EventDeleted, EventElevationExpired, EventReconcileFailed,
+}
+
+// Channel is a delivery channel; each channel can use its own wording and format
+type Channel string
+
+const (
+	ChannelSlack   Channel = "slack"
+	ChannelEmail   Channel = "email"
+	ChannelWebhook Channel = "webhook"
+
+	// channelDefault holds the templates used by channels without their own
+	channelDefault Channel = "default"
+)
+
+// Channels lists all delivery channels
+var Channels = []Channel{ChannelSlack, ChannelEmail, ChannelWebhook}
+
+// Event is the data available to templates
+type Event struct {
+	Type EventType
+	// User is the name of the User the event is about
+	User string
+	// Time is when the event happened
+	Time time.Time
+	// Expiry is when the user's current credentials expire; zero when unknown
+	Expiry time.Time
+	// Message carries event specific text, e.g. the reconcile error
+	Message string
+	// Details holds additional event specific values, e.g. "clusterRole" for elevations
+	Details map[string]string
+	// Attachments are files sent along by channels that support them, e.g. the encrypted
+	// kubeconfig of provisioned emails
+	Attachments []Attachment
+}
+
+// Attachment is a file attached to a notification
+type Attachment struct {
+	Name        string
+	ContentType string
+	Data        []byte
+}
+
+// Message is a rendered notification
+type Message struct {
+	// Subject is a one line summary, used as email subject
+	Subject string
+	// Body is the message text, or the JSON payload for webhooks
+	Body string
+}
@@ -0,0 +1,102 @@
+/*
+Copyright 2025.
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+*/
+
+package notify
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"slices"
+
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/types"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+
+	authv1alpha1 "github.com/openkube-hub/KubeUser/api/v1alpha1"
+)
+
+// Delivery is a rendered notification on its way to one sink
+type Delivery struct {
+	Sink authv1alpha1.NotificationSink
+	// Secret is the data of the sink's secretRef: endpoint and credentials
+	Secret map[string][]byte
+	// User is the User the notification is about, for per-user overrides
+	User    *authv1alpha1.User
+	Event   Event
+	Message Message
+}
+
+// Sender delivers notifications through one channel
+type Sender interface {
+	Send(ctx context.Context, delivery Delivery) error
+}
+
+// Notifier renders events and sends them to the notification sinks subscribed to them
+type Notifier struct {
+	// Reader reads the sinks' Secrets
+	Reader    client.Reader
+	Templates *Templates
+	Senders   map[Channel]Sender
+}
+
+// NewNotifier returns a Notifier with the built-in senders
+func NewNotifier(reader client.Reader, templates *Templates) *Notifier {
+	return &Notifier{
+		Reader:    reader,
+		Templates: templates,
+		Senders: map[Channel]Sender{
+			ChannelSlack:   &SlackSender{},
+			ChannelEmail:   &EmailSender{},
+			ChannelWebhook: &WebhookSender{},
+		},
+	}
+}
+
+// Notify sends event about user to every sink subscribed to its type. Sink Secrets are read
+// from namespace. A failing sink does not keep the others from being notified; all errors
+// are returned together.
+func (n *Notifier) Notify(ctx context.Context, sinks []authv1alpha1.NotificationSink, namespace string,
+	user *authv1alpha1.User, event Event) error {
+	var errs []error
+	for _, sink := range sinks {
+		if !Subscribed(sink, event.Type) {
+			continue
+		}
+		if err := n.send(ctx, sink, namespace, user, event); err != nil {
+			errs = append(errs, fmt.Errorf("notification sink %s: %w", sink.Name, err))
+		}
+	}
+	return errors.Join(errs...)
+}
+
+func (n *Notifier) send(ctx context.Context, sink authv1alpha1.NotificationSink, namespace string,
+	user *authv1alpha1.User, event Event) error {
+	channel := Channel(sink.Channel)
+	sender, ok := n.Senders[channel]
+	if !ok {
+		return fmt.Errorf("channel %s is not supported", channel)
+	}
+	var secret corev1.Secret
+	if sink.SecretRef != "" {
+		if err := n.Reader.Get(ctx, types.NamespacedName{Name: sink.SecretRef, Namespace: namespace}, &secret); err != nil {
+			return fmt.Errorf("failed to read Secret %s/%s: %w", namespace, sink.SecretRef, err)
+		}
+	}
+	if !sink.AttachKubeconfig {
+		event.Attachments = nil
+	}
+	msg, err := n.Templates.Render(channel, event)
+	if err != nil {
+		return err
+	}
+	return sender.Send(ctx, Delivery{Sink: sink, Secret: secret.Data, User: user, Event: event, Message: msg})
+}
+
+// Subscribed reports whether sink receives events of eventType
+func Subscribed(sink authv1alpha1.NotificationSink, eventType EventType) bool {
+	return len(sink.Events) == 0 || slices.Contains(sink.Events, string(eventType))
+}
@@ -0,0 +1,136 @@
+/*
+Copyright 2025.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package notify
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	clientgoscheme "k8s.io/client-go/kubernetes/scheme"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+
+	authv1alpha1 "github.com/openkube-hub/KubeUser/api/v1alpha1"
+)
+
+// slackServer records the messages posted to it and answers with response
+func slackServer(response string) (*httptest.Server, *[]map[string]string, *[]string) {
+	var messages []map[string]string
+	var auth []string
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+		var msg map[string]string
+		Expect(json.NewDecoder(req.Body).Decode(&msg)).To(Succeed())
+		messages = append(messages, msg)
+		auth = append(auth, req.Header.Get("Authorization"))
+		_, _ = w.Write([]byte(response))
+	}))
+	DeferCleanup(server.Close)
+	return server, &messages, &auth
+}
+
+var _ = Describe("Notifier", func() {
+	var (
+		ctx       context.Context
+		templates *Templates
+		user      *authv1alpha1.User
+	)
+
+	BeforeEach(func() {
+		ctx = context.Background()
+		var err error
+		templates, err = New(nil, nil)
+		Expect(err).NotTo(HaveOccurred())
+		user = &authv1alpha1.User{ObjectMeta: metav1.ObjectMeta{Name: "jane"}}
+	})
+
+	notifier := func(secrets ...*corev1.Secret) *Notifier {
+		b := fake.NewClientBuilder().WithScheme(clientgoscheme.Scheme)
+		for _, s := range secrets {
+			b = b.WithObjects(s)
+		}
+		return NewNotifier(b.Build(), templates)
+	}
+
+	secret := func(data map[string]string) *corev1.Secret {
+		s := &corev1.Secret{ObjectMeta: metav1.ObjectMeta{Name: "slack", Namespace: "kubeuser"}, Data: map[string][]byte{}}
+		for k, v := range data {
+			s.Data[k] = []byte(v)
+		}
+		return s
+	}
+
+	It("posts to the incoming webhook of subscribed Slack sinks", func() {
+		server, messages, _ := slackServer("ok")
+		n := notifier(secret(map[string]string{SlackWebhookURLKey: server.URL}))
+		sinks := []authv1alpha1.NotificationSink{
+			{Name: "ops", Channel: "slack", SecretRef: "slack", Events: []string{"rotated"}},
+			{Name: "all", Channel: "slack", SecretRef: "slack"},
+		}
+
+		Expect(n.Notify(ctx, sinks, "kubeuser", user, Event{Type: EventRevoked, User: "jane"})).To(Succeed())
+		Expect(*messages).To(HaveLen(1))
+		Expect((*messages)[0]["text"]).To(ContainSubstring("jane"))
+		Expect((*messages)[0]).NotTo(HaveKey("channel"))
+
+		Expect(n.Notify(ctx, sinks, "kubeuser", user, Event{Type: EventRotated, User: "jane"})).To(Succeed())
+		Expect(*messages).To(HaveLen(3))
+	})
+
+	It("posts with a bot token to the channel from the user's annotation", func() {
+		server, messages, auth := slackServer(`{"ok":true}`)
+		n := notifier(secret(map[string]string{SlackTokenKey: "xoxb-1", SlackChannelKey: "#access"}))
+		n.Senders[ChannelSlack] = &SlackSender{APIURL: server.URL}
+		sinks := []authv1alpha1.NotificationSink{{Name: "bot", Channel: "slack", SecretRef: "slack"}}
+
+		Expect(n.Notify(ctx, sinks, "kubeuser", user, Event{Type: EventProvisioned, User: "jane"})).To(Succeed())
+		user.Annotations = map[string]string{SlackChannelAnnotation: "#team-jane"}
+		Expect(n.Notify(ctx, sinks, "kubeuser", user, Event{Type: EventProvisioned, User: "jane"})).To(Succeed())
+
+		Expect(*messages).To(HaveLen(2))
+		Expect((*messages)[0]["channel"]).To(Equal("#access"))
+		Expect((*messages)[1]["channel"]).To(Equal("#team-jane"))
+		Expect(*auth).To(HaveEach("Bearer xoxb-1"))
+	})
+
+	It("reports Slack API errors", func() {
+		server, _, _ := slackServer(`{"ok":false,"error":"channel_not_found"}`)
+		n := notifier(secret(map[string]string{SlackTokenKey: "xoxb-1", SlackChannelKey: "#gone"}))
+		n.Senders[ChannelSlack] = &SlackSender{APIURL: server.URL}
+		sinks := []authv1alpha1.NotificationSink{{Name: "bot", Channel: "slack", SecretRef: "slack"}}
+
+		err := n.Notify(ctx, sinks, "kubeuser", user, Event{Type: EventProvisioned, User: "jane"})
+		Expect(err).To(MatchError(ContainSubstring("channel_not_found")))
+	})
+
+	It("notifies the remaining sinks when one fails", func() {
+		server, messages, _ := slackServer("ok")
+		n := notifier(secret(map[string]string{SlackWebhookURLKey: server.URL}))
+		sinks := []authv1alpha1.NotificationSink{
+			{Name: "missing", Channel: "slack", SecretRef: "absent"},
+			{Name: "ops", Channel: "slack", SecretRef: "slack"},
+		}
+
+		err := n.Notify(ctx, sinks, "kubeuser", user, Event{Type: EventReconcileFailed, User: "jane"})
+		Expect(err).To(MatchError(ContainSubstring("notification sink missing")))
+		Expect(*messages).To(HaveLen(1))
+	})
+})
@@ -0,0 +1,126 @@
+/*
+Copyright 2025.
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+*/
+
+package notify
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"time"
+)
+
+// SlackChannelAnnotation on a User sends its Slack notifications to another channel
+const SlackChannelAnnotation = "auth.openkube.io/slack-channel"
+
+// Keys of the Secret referenced by a Slack sink. Either webhookURL, or token and channel are set.
+const (
+	// SlackWebhookURLKey holds the URL of a Slack incoming webhook
+	SlackWebhookURLKey = "webhookURL"
+	// SlackTokenKey holds a bot token with the chat:write scope
+	SlackTokenKey = "token"
+	// SlackChannelKey holds the channel bot token messages are posted to by default
+	SlackChannelKey = "channel"
+)
+
+// DefaultSlackAPIURL is the Slack method posting messages with a bot token
+const DefaultSlackAPIURL = "https://slack.com/api/chat.postMessage"
+
+// sendTimeout bounds a single delivery so a slow endpoint cannot stall reconciliation
+const sendTimeout = 10 * time.Second
+
+// SlackSender posts notifications to Slack, through an incoming webhook or with a bot token
+type SlackSender struct {
+	// Client defaults to an HTTP client with a 10s timeout
+	Client *http.Client
+	// APIURL defaults to DefaultSlackAPIURL
+	APIURL string
+}
+
+type slackMessage struct {
+	Channel string `json:"channel,omitempty"`
+	Text    string `json:"text"`
+}
+
+// Send implements Sender. The User's SlackChannelAnnotation overrides the channel; incoming
+// webhooks created as Slack apps always post to their own channel and ignore it.
+func (s *SlackSender) Send(ctx context.Context, delivery Delivery) error {
+	msg := slackMessage{Text: delivery.Message.Body, Channel: string(delivery.Secret[SlackChannelKey])}
+	if delivery.User != nil && delivery.User.Annotations[SlackChannelAnnotation] != "" {
+		msg.Channel = delivery.User.Annotations[SlackChannelAnnotation]
+	}
+
+	if webhookURL := string(delivery.Secret[SlackWebhookURLKey]); webhookURL != "" {
+		_, err := s.post(ctx, webhookURL, "", msg)
+		return err
+	}
+	token := string(delivery.Secret[SlackTokenKey])
+	if token == "" {
+		return fmt.Errorf("secret needs %s, or %s and %s", SlackWebhookURLKey, SlackTokenKey, SlackChannelKey)
+	}
+	if msg.Channel == "" {
+		return fmt.Errorf("no channel: set %s in the secret or the %s annotation", SlackChannelKey, SlackChannelAnnotation)
+	}
+	apiURL := s.APIURL
+	if apiURL == "" {
+		apiURL = DefaultSlackAPIURL
+	}
+	body, err := s.post(ctx, apiURL, token, msg)
+	if err != nil {
+		return err
+	}
+	// The Web API answers 200 with ok=false on errors
+	var result struct {
+		OK    bool   `json:"ok"`
+		Error string `json:"error"`
+	}
+	if err := json.Unmarshal(body, &result); err != nil {
+		return fmt.Errorf("unexpected Slack response: %w", err)
+	}
+	if !result.OK {
+		return fmt.Errorf("slack rejected the message: %s", result.Error)
+	}
+	return nil
+}
+
+func (s *SlackSender) post(ctx context.Context, endpoint, token string, msg slackMessage) ([]byte, error) {
+	payload, err := json.Marshal(msg)
+	if err != nil {
+		return nil, err
+	}
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, endpoint, bytes.NewReader(payload))
+	if err != nil {
+		return nil, err
+	}
+	req.Header.Set("Content-Type", "application/json; charset=utf-8")
+	if token != "" {
+		req.Header.Set("Authorization", "Bearer "+token)
+	}
+	client := s.Client
+	if client == nil {
+		client = &http.Client{Timeout: sendTimeout}
+	}
+	resp, err := client.Do(req)
+	if err != nil {
+		// The URL carries the webhook's credentials; keep it out of errors and logs
+		var urlErr *url.Error
+		if errors.As(err, &urlErr) {
+			err = urlErr.Err
+		}
+		return nil, fmt.Errorf("posting to Slack: %w", err)
+	}
+	defer func() { _ = resp.Body.Close() }()
+	body, _ := io.ReadAll(io.LimitReader(resp.Body, 64*1024))
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("slack responded %s: %s", resp.Status, bytes.TrimSpace(body))
+	}
+	return body, nil
+}
@@ -0,0 +1,289 @@
+/*
+Copyright 2025.
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+*/
+
+package notify
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"text/template"
+	"time"
+
+	"sigs.k8s.io/yaml"
+)
+
+// OrgFile is the file (or ConfigMap key) in a template directory holding organization variables
+const OrgFile = "org.yaml"
+
+// Template parts; every event has a subject and a body per channel
+const (
+	partSubject = "subject"
+	partBody    = "body"
+
+	// allEvents in place of an event type matches every event of a channel
+	allEvents = "all"
+)
+
+// defaultTemplates is the built-in wording, keyed like overrides: <channel>.<event>.<part>.
+// Channels fall back to the "default" channel when they have no template of their own.
+var defaultTemplates = map[string]string{
+	"default.created.subject":          `Kubernetes user {{.User}} created`,
+	"default.created.body":             `User {{.User}}{{with .Org.cluster}} on {{.}}{{end}} was created and is being provisioned.`,
+	"default.active.subject":           `Kubernetes access active for {{.User}}`,
+	"default.active.body":              `Access for {{.User}}{{with .Org.cluster}} to {{.}}{{end}} is active.{{with .Message}} {{.}}{{end}}`,
+	"default.provisioned.subject":      `Kubernetes access ready for {{.User}}`,
+	"default.provisioned.body":         `Access for {{.User}}{{with .Org.cluster}} to {{.}}{{end}} is ready. Credentials expire {{formatTime .Expiry}}.`,
+	"default.expiring.subject":         `Kubernetes access for {{.User}} expires in {{until .Expiry}}`,
+	"default.expiring.body":            `Access for {{.User}}{{with .Org.cluster}} to {{.}}{{end}} expires {{formatTime .Expiry}}.{{with .Org.contact}} Contact {{.}} to extend it.{{end}}`,
+	"default.rotated.subject":          `Kubernetes credentials rotated for {{.User}}`,
+	"default.rotated.body":             `The client certificate for {{.User}} was rotated. Fetch the new kubeconfig; it expires {{formatTime .Expiry}}.`,
+	"default.revoked.subject":          `Kubernetes access revoked for {{.User}}`,
+	"default.revoked.body":             `Access for {{.User}}{{with .Org.cluster}} to {{.}}{{end}} was revoked.{{with .Message}} {{.}}{{end}}`,
+	"default.expired.subject":          `Kubernetes access expired for {{.User}}`,
+	"default.expired.body":             `The client certificate for {{.User}}{{with .Org.cluster}} on {{.}}{{end}} expired {{formatTime .Expiry}}.`,
+	"default.deleted.subject":          `Kubernetes user {{.User}} deleted`,
+	"default.deleted.body":             `User {{.User}}{{with .Org.cluster}} on {{.}}{{end}} was deleted together with its bindings and credentials.`,
+	"default.elevationExpired.subject": `Elevated access ended for {{.User}}`,
+	"default.elevationExpired.body":    `The temporary{{with index .Details "clusterRole"}} {{.}}{{end}} grant for {{.User}} ended and was removed.`,
+	"default.reconcileFailed.subject":  `KubeUser could not reconcile {{.User}}`,
+	"default.reconcileFailed.body":     `Reconciling {{.User}} failed: {{.Message}}`,
+
+	"slack.provisioned.body":     `:white_check_mark: Access for *{{.User}}*{{with .Org.cluster}} to *{{.}}*{{end}} is ready. Credentials expire {{formatTime .Expiry}}.`,
+	"slack.expiring.body":        `:hourglass: Access for *{{.User}}* expires in {{until .Expiry}} ({{formatTime .Expiry}}).{{with .Org.contact}} Contact {{.}} to extend it.{{end}}`,
+	"slack.revoked.body":         `:no_entry: Access for *{{.User}}*{{with .Org.cluster}} to *{{.}}*{{end}} was revoked.{{with .Message}} {{.}}{{end}}`,
+	"slack.reconcileFailed.body": `:warning: Reconciling *{{.User}}* failed: {{.Message}}`,
+
+	"email.provisioned.body": `Hello {{.User}},
+
+your access{{with .Org.cluster}} to {{.}}{{end}} is ready. Your credentials expire {{formatTime .Expiry}}.
+{{range .Attachments}}
+Your kubeconfig is attached as {{.Name}}. It is encrypted with a passphrase you receive
+separately. Decrypt it with
+
+    openssl enc -d -aes-256-cbc -pbkdf2 -iter 600000 -in {{.Name}} -out kubeconfig
+{{end}}{{with .Org.name}}
+-- 
+{{.}}
+{{end}}`,
+
+	"email.expiring.body": `Hello {{.User}},
+
+your access{{with .Org.cluster}} to {{.}}{{end}} expires {{formatTime .Expiry}} ({{until .Expiry}} from now).
+{{- with .Org.contact}} Contact {{.}} to extend it.{{end}}
+{{with .Org.name}}
+-- 
+{{.}}
+{{end}}`,
+
+	"webhook.all.body": `{"event":{{json .Type}},"user":{{json .User}},"time":{{json .Time}},` +
+		`{{if not .Expiry.IsZero}}"expiry":{{json .Expiry}},{{end}}"message":{{json .Message}},` +
+		`"details":{{json .Details}},"org":{{json .Org}}}`,
+}
+
+// Templates renders notifications for every channel and event type
+type Templates struct {
+	org map[string]string
+	// overrides are searched before the built-in templates, so any override wins
+	overrides map[string]*template.Template
+	builtin   map[string]*template.Template
+}
+
+// New parses the built-in templates together with overrides keyed <channel>.<event>.<part>,
+// where channel may be "default" to change all channels and event may be "all" for every event.
+// Every template is rendered once against a sample event so mistakes fail at startup.
+func New(overrides map[string]string, org map[string]string) (*Templates, error) {
+	var errs []error
+	t := &Templates{org: org, overrides: map[string]*template.Template{}, builtin: map[string]*template.Template{}}
+	if t.org == nil {
+		t.org = map[string]string{}
+	}
+	for key, text := range defaultTemplates {
+		t.builtin[key] = template.Must(parse(key, text))
+	}
+	for key, text := range overrides {
+		if err := validKey(key); err != nil {
+			errs = append(errs, err)
+			continue
+		}
+		tmpl, err := parse(key, text)
+		if err != nil {
+			errs = append(errs, err)
+			continue
+		}
+		t.overrides[key] = tmpl
+	}
+	if len(errs) > 0 {
+		return nil, errors.Join(errs...)
+	}
+
+	sample := Event{
+		User:    "jane",
+		Time:    time.Now(),
+		Expiry:  time.Now().Add(24 * time.Hour),
+		Message: "sample message",
+		Details: map[string]string{"clusterRole": "cluster-admin"},
+		Attachments: []Attachment{
+			{Name: "jane.kubeconfig.enc", ContentType: EncryptedContentType, Data: []byte("sample")},
+		},
+	}
+	for _, channel := range Channels {
+		for _, eventType := range EventTypes {
+			sample.Type = eventType
+			if _, err := t.Render(channel, sample); err != nil {
+				errs = append(errs, err)
+			}
+		}
+	}
+	if len(errs) > 0 {
+		return nil, errors.Join(errs...)
+	}
+	return t, nil
+}
+
+// LoadDir reads overrides from a directory, typically a mounted ConfigMap: every file is a
+// template named <channel>.<event>.<part>, except org.yaml which holds organization variables.
+func LoadDir(dir string) (*Templates, error) {
+	entries, err := os.ReadDir(dir)
+	if err != nil {
+		return nil, err
+	}
+	overrides := map[string]string{}
+	org := map[string]string{}
+	for _, entry := range entries {
+		// Skip ConfigMap volume internals (..data, ..2025_01_01...) and subdirectories
+		if strings.HasPrefix(entry.Name(), ".") || entry.IsDir() {
+			continue
+		}
+		data, err := os.ReadFile(filepath.Join(dir, entry.Name()))
+		if err != nil {
+			return nil, err
+		}
+		if entry.Name() == OrgFile {
+			if err := yaml.Unmarshal(data, &org); err != nil {
+				return nil, fmt.Errorf("invalid %s: %w", OrgFile, err)
+			}
+			continue
+		}
+		overrides[entry.Name()] = string(data)
+	}
+	return New(overrides, org)
+}
+
+// Render renders the subject and body of an event for a channel
+func (t *Templates) Render(channel Channel, event Event) (Message, error) {
+	data := struct {
+		Event
+		Channel Channel
+		Org     map[string]string
+	}{Event: event, Channel: channel, Org: t.org}
+
+	var msg Message
+	for _, part := range []string{partSubject, partBody} {
+		tmpl := t.lookup(channel, event.Type, part)
+		if tmpl == nil {
+			return Message{}, fmt.Errorf("no %s template for %s %s", part, channel, event.Type)
+		}
+		var buf bytes.Buffer
+		if err := tmpl.Execute(&buf, data); err != nil {
+			return Message{}, err
+		}
+		if part == partSubject {
+			msg.Subject = strings.TrimSpace(buf.String())
+		} else {
+			msg.Body = buf.String()
+		}
+	}
+	return msg, nil
+}
+
+// lookup finds the most specific template, overrides before built-ins: the channel's own
+// before the default channel, and the event's own before the channel's catch-all
+func (t *Templates) lookup(channel Channel, eventType EventType, part string) *template.Template {
+	for _, templates := range []map[string]*template.Template{t.overrides, t.builtin} {
+		for _, c := range []Channel{channel, channelDefault} {
+			for _, e := range []string{string(eventType), allEvents} {
+				if tmpl, ok := templates[fmt.Sprintf("%s.%s.%s", c, e, part)]; ok {
+					return tmpl
+				}
+			}
+		}
+	}
+	return nil
+}
+
+func parse(name, text string) (*template.Template, error) {
+	return template.New(name).Option("missingkey=zero").Funcs(funcs).Parse(text)
+}
+
+// validKey checks that an override key names a known channel, event type and part
+func validKey(key string) error {
+	parts := strings.Split(key, ".")
+	if len(parts) != 3 {
+		return fmt.Errorf("invalid template name %q, expected <channel>.<event>.<part>", key)
+	}
+	channelOK := Channel(parts[0]) == channelDefault
+	for _, c := range Channels {
+		channelOK = channelOK || Channel(parts[0]) == c
+	}
+	eventOK := parts[1] == allEvents
+	for _, e := range EventTypes {
+		eventOK = eventOK || EventType(parts[1]) == e
+	}
+	switch {
+	case !channelOK:
+		return fmt.Errorf("template %q: unknown channel %q", key, parts[0])
+	case !eventOK:
+		return fmt.Errorf("template %q: unknown event %q", key, parts[1])
+	case parts[2] != partSubject && parts[2] != partBody:
+		return fmt.Errorf("template %q: unknown part %q, expected %s or %s", key, parts[2], partSubject, partBody)
+	}
+	return nil
+}
+
+// funcs are available to all templates
+var funcs = template.FuncMap{
+	// formatTime renders a time for humans, "unknown" when it is not set
+	"formatTime": func(t time.Time) string {
+		if t.IsZero() {
+			return "unknown"
+		}
+		return t.UTC().Format("2006-01-02 15:04 MST")
+	},
+	// until renders the time left until t, rounded to minutes
+	"until": func(t time.Time) string {
+		if t.IsZero() {
+			return "unknown"
+		}
+		d := time.Until(t).Round(time.Minute)
+		if d < time.Minute {
+			return "less than a minute"
+		}
+		s := strings.TrimSuffix(d.String(), "0s")
+		if strings.HasSuffix(s, "h0m") {
+			s = strings.TrimSuffix(s, "0m")
+		}
+		return s
+	},
+	// json encodes a value for JSON payloads
+	"json": func(v any) (string, error) {
+		b, err := json.Marshal(v)
+		return string(b), err
+	},
+	"upper": strings.ToUpper,
+	"lower": strings.ToLower,
+	// default returns def when value is empty
+	"default": func(def, value string) string {
+		if value == "" {
+			return def
+		}
+		return value
+	},
+}
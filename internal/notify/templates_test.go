@@ -0,0 +1,129 @@
+/*
+Copyright 2025.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package notify
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"time"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("Templates", func() {
+	expiring := Event{
+		Type:   EventExpiring,
+		User:   "jane",
+		Time:   time.Date(2025, 1, 1, 12, 0, 0, 0, time.UTC),
+		Expiry: time.Date(2025, 1, 2, 12, 0, 0, 0, time.UTC),
+	}
+
+	It("renders the built-in wording for every channel", func() {
+		templates, err := New(nil, map[string]string{"cluster": "prod"})
+		Expect(err).NotTo(HaveOccurred())
+
+		msg, err := templates.Render(ChannelEmail, expiring)
+		Expect(err).NotTo(HaveOccurred())
+		Expect(msg.Subject).To(HavePrefix("Kubernetes access for jane expires in"))
+		Expect(msg.Body).To(ContainSubstring("your access to prod expires 2025-01-02 12:00 UTC"))
+
+		msg, err = templates.Render(ChannelSlack, expiring)
+		Expect(err).NotTo(HaveOccurred())
+		Expect(msg.Body).To(HavePrefix(":hourglass: Access for *jane*"))
+	})
+
+	It("renders webhook payloads as JSON", func() {
+		templates, err := New(nil, map[string]string{"name": "ACME"})
+		Expect(err).NotTo(HaveOccurred())
+
+		msg, err := templates.Render(ChannelWebhook, Event{Type: EventReconcileFailed, User: "jane", Message: `quote " here`})
+		Expect(err).NotTo(HaveOccurred())
+		var payload map[string]any
+		Expect(json.Unmarshal([]byte(msg.Body), &payload)).To(Succeed())
+		Expect(payload).To(HaveKeyWithValue("event", "reconcileFailed"))
+		Expect(payload).To(HaveKeyWithValue("message", `quote " here`))
+		Expect(payload).NotTo(HaveKey("expiry"))
+		Expect(payload["org"]).To(HaveKeyWithValue("name", "ACME"))
+	})
+
+	It("tells email recipients how to decrypt attached kubeconfigs", func() {
+		templates, err := New(nil, nil)
+		Expect(err).NotTo(HaveOccurred())
+
+		provisioned := Event{Type: EventProvisioned, User: "jane", Expiry: expiring.Expiry}
+		msg, err := templates.Render(ChannelEmail, provisioned)
+		Expect(err).NotTo(HaveOccurred())
+		Expect(msg.Body).NotTo(ContainSubstring("attached"))
+
+		provisioned.Attachments = []Attachment{{Name: "jane.kubeconfig.enc"}}
+		msg, err = templates.Render(ChannelEmail, provisioned)
+		Expect(err).NotTo(HaveOccurred())
+		Expect(msg.Body).To(ContainSubstring("attached as jane.kubeconfig.enc"))
+		Expect(msg.Body).To(ContainSubstring(fmt.Sprintf("-iter %d -in jane.kubeconfig.enc", EncryptionIterations)))
+	})
+
+	It("prefers channel and event specific overrides", func() {
+		templates, err := New(map[string]string{
+			"default.all.subject":  "[{{.Org.name}}] {{.Type}} for {{.User}}",
+			"email.expiring.body":  "Custom {{.User}}",
+			"default.rotated.body": "Rotated {{.User}}",
+		}, map[string]string{"name": "ACME"})
+		Expect(err).NotTo(HaveOccurred())
+
+		msg, err := templates.Render(ChannelEmail, expiring)
+		Expect(err).NotTo(HaveOccurred())
+		Expect(msg).To(Equal(Message{Subject: "[ACME] expiring for jane", Body: "Custom jane"}))
+
+		// Overrides win over more specific built-in templates
+		msg, err = templates.Render(ChannelSlack, Event{Type: EventRotated, User: "jane"})
+		Expect(err).NotTo(HaveOccurred())
+		Expect(msg).To(Equal(Message{Subject: "[ACME] rotated for jane", Body: "Rotated jane"}))
+
+		msg, err = templates.Render(ChannelSlack, expiring)
+		Expect(err).NotTo(HaveOccurred())
+		Expect(msg.Body).To(HavePrefix(":hourglass:"))
+	})
+
+	It("rejects unknown names and broken templates", func() {
+		_, err := New(map[string]string{"slak.expiring.body": "x"}, nil)
+		Expect(err).To(MatchError(ContainSubstring(`unknown channel "slak"`)))
+		_, err = New(map[string]string{"slack.expird.body": "x"}, nil)
+		Expect(err).To(MatchError(ContainSubstring(`unknown event "expird"`)))
+		_, err = New(map[string]string{"slack.expiring.title": "x"}, nil)
+		Expect(err).To(MatchError(ContainSubstring(`unknown part "title"`)))
+		_, err = New(map[string]string{"slack.expiring.body": "{{.User"}, nil)
+		Expect(err).To(HaveOccurred())
+		_, err = New(map[string]string{"slack.expiring.body": "{{.Nope}}"}, nil)
+		Expect(err).To(MatchError(ContainSubstring("Nope")))
+	})
+
+	It("loads templates and organization variables from a directory", func() {
+		dir := GinkgoT().TempDir()
+		Expect(os.WriteFile(filepath.Join(dir, "email.expiring.body"), []byte("{{.Org.contact}}"), 0o600)).To(Succeed())
+		Expect(os.WriteFile(filepath.Join(dir, OrgFile), []byte("contact: platform@example.com\n"), 0o600)).To(Succeed())
+		Expect(os.Mkdir(filepath.Join(dir, "..data"), 0o700)).To(Succeed())
+
+		templates, err := LoadDir(dir)
+		Expect(err).NotTo(HaveOccurred())
+		msg, err := templates.Render(ChannelEmail, expiring)
+		Expect(err).NotTo(HaveOccurred())
+		Expect(msg.Body).To(Equal("platform@example.com"))
+	})
+})
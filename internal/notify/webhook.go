@@ -0,0 +1,154 @@
+/*
+Copyright 2025.
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+*/
+
+package notify
+
+import (
+	"bytes"
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"strconv"
+	"time"
+
+	"k8s.io/apimachinery/pkg/util/uuid"
+)
+
+// Keys of the Secret referenced by a webhook sink
+const (
+	// WebhookURLKey holds the URL events are POSTed to
+	WebhookURLKey = "url"
+	// WebhookAuthorizationKey holds the value of the Authorization header, e.g. "Bearer <token>"
+	WebhookAuthorizationKey = "authorization"
+	// WebhookSigningKeyKey holds the key deliveries are signed with
+	WebhookSigningKeyKey = "signingKey"
+)
+
+// Headers of webhook deliveries
+const (
+	// WebhookEventHeader carries the event type
+	WebhookEventHeader = "X-KubeUser-Event"
+	// WebhookDeliveryHeader identifies a delivery; retries reuse it so receivers can drop duplicates
+	WebhookDeliveryHeader = "X-KubeUser-Delivery"
+	// WebhookTimestampHeader carries the Unix time the delivery was signed at
+	WebhookTimestampHeader = "X-KubeUser-Timestamp"
+	// WebhookSignatureHeader carries "sha256=" and the hex HMAC-SHA256 of "<timestamp>.<body>"
+	// keyed with the sink's signing key
+	WebhookSignatureHeader = "X-KubeUser-Signature"
+)
+
+// Defaults of WebhookSender
+const (
+	DefaultWebhookAttempts = 3
+	DefaultWebhookBackoff  = time.Second
+)
+
+// WebhookSender POSTs notifications to an HTTP endpoint. Deliveries that fail with a network
+// error, 429 or a 5xx status are retried with exponential backoff.
+type WebhookSender struct {
+	// Client defaults to an HTTP client with a 10s timeout
+	Client *http.Client
+	// Attempts is how often a delivery is tried; defaults to DefaultWebhookAttempts
+	Attempts int
+	// Backoff is the wait before the first retry, doubled for each further one; defaults to
+	// DefaultWebhookBackoff
+	Backoff time.Duration
+}
+
+// errPermanent marks delivery failures that a retry cannot fix
+type errPermanent struct{ error }
+
+// Send implements Sender
+func (s *WebhookSender) Send(ctx context.Context, delivery Delivery) error {
+	target := string(delivery.Secret[WebhookURLKey])
+	if u, err := url.Parse(target); err != nil || (u.Scheme != "https" && u.Scheme != "http") || u.Host == "" {
+		return fmt.Errorf("secret needs %s with an http(s) URL", WebhookURLKey)
+	}
+	attempts := s.Attempts
+	if attempts <= 0 {
+		attempts = DefaultWebhookAttempts
+	}
+	backoff := s.Backoff
+	if backoff <= 0 {
+		backoff = DefaultWebhookBackoff
+	}
+
+	id := string(uuid.NewUUID())
+	var err error
+	for attempt := 1; ; attempt++ {
+		err = s.post(ctx, target, id, delivery)
+		var permanent errPermanent
+		if err == nil || errors.As(err, &permanent) || attempt == attempts {
+			break
+		}
+		select {
+		case <-ctx.Done():
+			return fmt.Errorf("%w, giving up: %w", err, ctx.Err())
+		case <-time.After(backoff):
+		}
+		backoff *= 2
+	}
+	return err
+}
+
+func (s *WebhookSender) post(ctx context.Context, target, id string, delivery Delivery) error {
+	body := []byte(delivery.Message.Body)
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, target, bytes.NewReader(body))
+	if err != nil {
+		return errPermanent{err}
+	}
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set(WebhookEventHeader, string(delivery.Event.Type))
+	req.Header.Set(WebhookDeliveryHeader, id)
+	if auth := delivery.Secret[WebhookAuthorizationKey]; len(auth) > 0 {
+		req.Header.Set("Authorization", string(auth))
+	}
+	if key := delivery.Secret[WebhookSigningKeyKey]; len(key) > 0 {
+		timestamp := strconv.FormatInt(time.Now().Unix(), 10)
+		req.Header.Set(WebhookTimestampHeader, timestamp)
+		req.Header.Set(WebhookSignatureHeader, Sign(key, timestamp, body))
+	}
+
+	client := s.Client
+	if client == nil {
+		client = &http.Client{Timeout: sendTimeout}
+	}
+	resp, err := client.Do(req)
+	if err != nil {
+		// The URL may carry credentials; keep it out of errors and logs
+		var urlErr *url.Error
+		if errors.As(err, &urlErr) {
+			err = urlErr.Err
+		}
+		return fmt.Errorf("posting to webhook: %w", err)
+	}
+	defer func() { _ = resp.Body.Close() }()
+	respBody, _ := io.ReadAll(io.LimitReader(resp.Body, 4096))
+	switch {
+	case resp.StatusCode >= 200 && resp.StatusCode < 300:
+		return nil
+	case resp.StatusCode == http.StatusTooManyRequests || resp.StatusCode >= 500:
+		return fmt.Errorf("webhook responded %s: %s", resp.Status, bytes.TrimSpace(respBody))
+	default:
+		return errPermanent{fmt.Errorf("webhook responded %s: %s", resp.Status, bytes.TrimSpace(respBody))}
+	}
+}
+
+// Sign returns the WebhookSignatureHeader value of a delivery. Receivers recompute it from the
+// timestamp header and the raw body and compare with hmac.Equal.
+func Sign(key []byte, timestamp string, body []byte) string {
+	mac := hmac.New(sha256.New, key)
+	mac.Write([]byte(timestamp))
+	mac.Write([]byte("."))
+	mac.Write(body)
+	return "sha256=" + hex.EncodeToString(mac.Sum(nil))
+}
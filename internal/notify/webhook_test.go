@@ -0,0 +1,115 @@
+/*
+Copyright 2025.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package notify
+
+import (
+	"context"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"time"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("WebhookSender", func() {
+	var (
+		ctx      context.Context
+		mu       sync.Mutex
+		requests []*http.Request
+		bodies   []string
+		statuses []int
+		server   *httptest.Server
+		sender   *WebhookSender
+	)
+
+	BeforeEach(func() {
+		ctx = context.Background()
+		requests, bodies, statuses = nil, nil, nil
+		server = httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+			mu.Lock()
+			defer mu.Unlock()
+			body, _ := io.ReadAll(req.Body)
+			requests = append(requests, req)
+			bodies = append(bodies, string(body))
+			status := http.StatusNoContent
+			if len(statuses) > 0 {
+				status, statuses = statuses[0], statuses[1:]
+			}
+			w.WriteHeader(status)
+		}))
+		DeferCleanup(server.Close)
+		sender = &WebhookSender{Backoff: time.Millisecond}
+	})
+
+	delivery := func(secret map[string]string) Delivery {
+		d := Delivery{
+			Secret:  map[string][]byte{WebhookURLKey: []byte(server.URL)},
+			Event:   Event{Type: EventCreated, User: "jane"},
+			Message: Message{Body: `{"event":"created","user":"jane"}`},
+		}
+		for k, v := range secret {
+			d.Secret[k] = []byte(v)
+		}
+		return d
+	}
+
+	It("posts the payload with authorization and a verifiable signature", func() {
+		Expect(sender.Send(ctx, delivery(map[string]string{
+			WebhookAuthorizationKey: "Bearer t0ken",
+			WebhookSigningKeyKey:    "s3cret",
+		}))).To(Succeed())
+
+		Expect(requests).To(HaveLen(1))
+		req := requests[0]
+		Expect(bodies[0]).To(Equal(`{"event":"created","user":"jane"}`))
+		Expect(req.Header.Get("Authorization")).To(Equal("Bearer t0ken"))
+		Expect(req.Header.Get(WebhookEventHeader)).To(Equal("created"))
+		Expect(req.Header.Get(WebhookDeliveryHeader)).NotTo(BeEmpty())
+		timestamp := req.Header.Get(WebhookTimestampHeader)
+		Expect(req.Header.Get(WebhookSignatureHeader)).To(Equal(Sign([]byte("s3cret"), timestamp, []byte(bodies[0]))))
+	})
+
+	It("retries server errors with the same delivery id", func() {
+		statuses = []int{http.StatusServiceUnavailable, http.StatusTooManyRequests}
+		Expect(sender.Send(ctx, delivery(nil))).To(Succeed())
+
+		Expect(requests).To(HaveLen(3))
+		id := requests[0].Header.Get(WebhookDeliveryHeader)
+		Expect(requests[1].Header.Get(WebhookDeliveryHeader)).To(Equal(id))
+		Expect(requests[2].Header.Get(WebhookDeliveryHeader)).To(Equal(id))
+	})
+
+	It("gives up after the configured attempts", func() {
+		statuses = []int{500, 500, 500, 500}
+		sender.Attempts = 2
+		Expect(sender.Send(ctx, delivery(nil))).To(MatchError(ContainSubstring("500")))
+		Expect(requests).To(HaveLen(2))
+	})
+
+	It("does not retry client errors", func() {
+		statuses = []int{http.StatusUnauthorized}
+		Expect(sender.Send(ctx, delivery(nil))).To(MatchError(ContainSubstring("401")))
+		Expect(requests).To(HaveLen(1))
+	})
+
+	It("needs a URL", func() {
+		Expect(sender.Send(ctx, Delivery{Secret: map[string][]byte{}})).To(MatchError(ContainSubstring(WebhookURLKey)))
+	})
+})
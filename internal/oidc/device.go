@@ -0,0 +1,376 @@
+/*
+Copyright 2025.
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+*/
+
+package oidc
+
+import (
+	"context"
+	"crypto/rand"
+	"crypto/sha256"
+	"crypto/subtle"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"html/template"
+	"math/big"
+	"net/http"
+	"strings"
+	"time"
+
+	corev1 "k8s.io/api/core/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/types"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	logf "sigs.k8s.io/controller-runtime/pkg/log"
+
+	"github.com/openkube-hub/KubeUser/internal/operatorconfig"
+	"github.com/openkube-hub/KubeUser/internal/proxy"
+)
+
+const (
+	// DeviceCodeLabel marks the Secrets that hold device authorizations
+	DeviceCodeLabel = "auth.openkube.io/device-code"
+	// UserCodeLabel holds a digest of the user code of a device authorization, which finds it
+	// when the user enters the code
+	UserCodeLabel = "auth.openkube.io/user-code"
+	// ScopeAnnotation is the scope a device requested
+	ScopeAnnotation = "auth.openkube.io/scope"
+	// DeviceStatusAnnotation is approved or denied once the user decided
+	DeviceStatusAnnotation = "auth.openkube.io/device-status"
+
+	deviceApproved = "approved"
+	deviceDenied   = "denied"
+
+	// deviceCodeGrant is the grant type of RFC 8628 devices poll the token endpoint with
+	deviceCodeGrant = "urn:ietf:params:oauth:grant-type:device_code"
+	// deviceCodeTTL is how long the user has to approve a device
+	deviceCodeTTL = 10 * time.Minute
+	// pollInterval is how many seconds devices wait between polls
+	pollInterval = 5
+	// userCodeAlphabet has no vowels or look-alike characters, so codes spell no words and are
+	// easy to type
+	userCodeAlphabet = "BCDFGHJKLMNPQRSTVWXZ"
+	// csrfCookie holds the token that ties an approval to the page it was submitted from
+	csrfCookie = "kubeuser_device_csrf"
+)
+
+// errInvalidDeviceCode is returned for unknown and used device codes alike
+var errInvalidDeviceCode = &oauthError{http.StatusBadRequest, "invalid_grant", "the device code is invalid or was already used"}
+
+// DeviceCodeSecretName returns the name of the Secret of device code. Only a digest of the code
+// is stored, so reading the Secret does not reveal it.
+func DeviceCodeSecretName(code string) string {
+	sum := sha256.Sum256([]byte(code))
+	return "kubeuser-device-" + hex.EncodeToString(sum[:20])
+}
+
+// userCodeDigest returns the value of UserCodeLabel for code, ignoring case, spaces and dashes
+func userCodeDigest(code string) string {
+	code = strings.ToUpper(strings.NewReplacer("-", "", " ", "").Replace(code))
+	sum := sha256.Sum256([]byte(code))
+	return hex.EncodeToString(sum[:20])
+}
+
+// newUserCode returns a random user code like BCDF-GHJK
+func newUserCode() (string, error) {
+	var code strings.Builder
+	for n := 0; n < 8; n++ {
+		if n == 4 {
+			code.WriteByte('-')
+		}
+		i, err := rand.Int(rand.Reader, big.NewInt(int64(len(userCodeAlphabet))))
+		if err != nil {
+			return "", err
+		}
+		code.WriteByte(userCodeAlphabet[i.Int64()])
+	}
+	return code.String(), nil
+}
+
+// deviceAuthorizationResponse is the response of the device authorization endpoint
+type deviceAuthorizationResponse struct {
+	DeviceCode              string `json:"device_code"`
+	UserCode                string `json:"user_code"`
+	VerificationURI         string `json:"verification_uri"`
+	VerificationURIComplete string `json:"verification_uri_complete"`
+	ExpiresIn               int64  `json:"expires_in"`
+	Interval                int64  `json:"interval"`
+}
+
+// deviceAuthorization starts the device authorization grant of RFC 8628: the device gets a
+// device code to poll the token endpoint with, and the user a code to approve it with
+func (i *Issuer) deviceAuthorization(w http.ResponseWriter, req *http.Request) {
+	ctx := req.Context()
+	if err := req.ParseForm(); err != nil {
+		i.fail(w, req, &oauthError{http.StatusBadRequest, "invalid_request", "malformed form"})
+		return
+	}
+	if id := req.PostForm.Get("client_id"); id != "" && id != i.ClientID {
+		i.fail(w, req, &oauthError{http.StatusUnauthorized, "invalid_client", "unknown client " + id})
+		return
+	}
+	b, err := randomBytes(32)
+	if err != nil {
+		i.fail(w, req, err)
+		return
+	}
+	deviceCode := base64URL(b)
+	userCode, err := newUserCode()
+	if err != nil {
+		i.fail(w, req, err)
+		return
+	}
+	secret := &corev1.Secret{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      DeviceCodeSecretName(deviceCode),
+			Namespace: i.Namespace(),
+			Labels:    map[string]string{DeviceCodeLabel: "true", UserCodeLabel: userCodeDigest(userCode)},
+			Annotations: map[string]string{
+				ExpiresAtAnnotation: i.Now().Add(deviceCodeTTL).UTC().Format(time.RFC3339),
+				ScopeAnnotation:     req.PostForm.Get("scope"),
+			},
+		},
+		Type: corev1.SecretTypeOpaque,
+	}
+	if err := i.Writer.Create(ctx, secret); err != nil {
+		i.fail(w, req, fmt.Errorf("failed to store device authorization: %w", err))
+		return
+	}
+	writeToken(w, deviceAuthorizationResponse{
+		DeviceCode:              deviceCode,
+		UserCode:                userCode,
+		VerificationURI:         i.URL + "/device",
+		VerificationURIComplete: i.URL + "/device?user_code=" + userCode,
+		ExpiresIn:               int64(deviceCodeTTL.Seconds()),
+		Interval:                pollInterval,
+	})
+}
+
+// deviceToken issues a token to a device the user approved. The device code is used up with the
+// token, and the User is looked up again in case it was suspended after approving.
+func (i *Issuer) deviceToken(w http.ResponseWriter, req *http.Request) {
+	ctx := req.Context()
+	code := req.PostForm.Get("device_code")
+	if code == "" {
+		i.fail(w, req, &oauthError{http.StatusBadRequest, "invalid_request", "device_code is required"})
+		return
+	}
+	var secret corev1.Secret
+	err := i.Secrets.Get(ctx, types.NamespacedName{Namespace: i.Namespace(), Name: DeviceCodeSecretName(code)}, &secret)
+	if apierrors.IsNotFound(err) || (err == nil && secret.Labels[DeviceCodeLabel] != "true") {
+		i.fail(w, req, errInvalidDeviceCode)
+		return
+	}
+	if err != nil {
+		i.fail(w, req, fmt.Errorf("failed to read device authorization: %w", err))
+		return
+	}
+	status := secret.Annotations[DeviceStatusAnnotation]
+	switch {
+	case expired(&secret, i.Now()):
+		err = &oauthError{http.StatusBadRequest, "expired_token", "the device code expired"}
+	case status == "":
+		i.fail(w, req, &oauthError{http.StatusBadRequest, "authorization_pending", "the user has not approved the device yet"})
+		return
+	case status != deviceApproved:
+		err = &oauthError{http.StatusBadRequest, "access_denied", "the user denied the device"}
+	}
+	// The preconditions make the device code single-use even when it is polled twice at once
+	if delErr := i.Writer.Delete(ctx, &secret, client.Preconditions{UID: &secret.UID, ResourceVersion: &secret.ResourceVersion}); delErr != nil {
+		if apierrors.IsNotFound(delErr) || apierrors.IsConflict(delErr) {
+			delErr = errInvalidDeviceCode
+		}
+		i.fail(w, req, delErr)
+		return
+	}
+	if err != nil {
+		i.fail(w, req, err)
+		return
+	}
+
+	identity, err := (&proxy.Proxy{Reader: i.Reader}).AuthenticateUser(ctx, secret.Labels[UserLabel], secret.Annotations[UsernameAnnotation])
+	if inactiveUser(err) {
+		err = &oauthError{http.StatusBadRequest, "invalid_grant", err.Error()}
+	}
+	if err != nil {
+		i.fail(w, req, err)
+		return
+	}
+	l, err := i.newLogin(secret.Annotations[ScopeAnnotation])
+	if err != nil {
+		i.fail(w, req, err)
+		return
+	}
+	i.issue(w, req, identity, l)
+}
+
+var devicePage = template.Must(template.New("device").Parse(`<!DOCTYPE html>
+<html>
+<head><meta charset="utf-8"><title>KubeUser</title></head>
+<body style="font-family: sans-serif; max-width: 40em; margin: 4em auto">
+<h1>Device login</h1>
+{{- if .Message }}
+<p>{{ .Message }}</p>
+{{- end }}
+{{- if .CSRF }}
+<form method="post">
+<input type="hidden" name="csrf" value="{{ .CSRF }}">
+<p><label>Code shown on your device<br><input name="user_code" value="{{ .UserCode }}" autocomplete="off" required></label></p>
+{{- if .Certificate }}
+<p>Signing in as {{ .Certificate }} with your client certificate.</p>
+{{- else }}
+<p><label>Kubernetes token<br><input name="token" type="password" autocomplete="off"></label></p>
+{{- end }}
+<button type="submit" name="action" value="approve">Approve</button>
+<button type="submit" name="action" value="deny">Deny</button>
+</form>
+{{- end }}
+</body>
+</html>
+`))
+
+type devicePageData struct {
+	Message     string
+	UserCode    string
+	CSRF        string
+	Certificate string
+}
+
+// writeDevicePage writes the verification page. It must not be framed, since approving a device
+// is what a clickjacking page would trick the user into.
+func writeDevicePage(w http.ResponseWriter, status int, data devicePageData) {
+	header := w.Header()
+	header.Set("Content-Type", "text/html; charset=utf-8")
+	header.Set("Cache-Control", "no-store")
+	header.Set("Referrer-Policy", "no-referrer")
+	header.Set("Content-Security-Policy", "default-src 'none'; style-src 'unsafe-inline'; form-action 'self'; frame-ancestors 'none'")
+	w.WriteHeader(status)
+	_ = devicePage.Execute(w, data)
+}
+
+// verificationPage shows the form a user approves a device with
+func (i *Issuer) verificationPage(w http.ResponseWriter, req *http.Request) {
+	b, err := randomBytes(32)
+	if err != nil {
+		logf.FromContext(req.Context()).Error(err, "Failed to generate CSRF token")
+		writeDevicePage(w, http.StatusInternalServerError, devicePageData{Message: "Something went wrong. Try again later."})
+		return
+	}
+	csrf := base64URL(b)
+	http.SetCookie(w, &http.Cookie{
+		Name:     csrfCookie,
+		Value:    csrf,
+		Path:     req.URL.Path,
+		Secure:   true,
+		HttpOnly: true,
+		SameSite: http.SameSiteStrictMode,
+	})
+	data := devicePageData{UserCode: req.URL.Query().Get("user_code"), CSRF: csrf}
+	if req.TLS != nil && len(req.TLS.VerifiedChains) > 0 {
+		data.Certificate = req.TLS.VerifiedChains[0][0].Subject.CommonName
+	}
+	writeDevicePage(w, http.StatusOK, data)
+}
+
+// pageError is an error shown on the verification page
+type pageError struct {
+	status  int
+	message string
+}
+
+func (e *pageError) Error() string {
+	return e.message
+}
+
+// errInvalidUserCode is shown for unknown, expired and used user codes alike
+var errInvalidUserCode = &pageError{http.StatusBadRequest, "The code is invalid, has expired or was already used. Start the login on your device again."}
+
+// approveDevice records the decision of the user on a device. The user authenticates with the
+// client certificate KubeUser issued them or a bearer token, like at the impersonation proxy.
+func (i *Issuer) approveDevice(w http.ResponseWriter, req *http.Request) {
+	ctx := req.Context()
+	err := i.decideDevice(ctx, req)
+	var perr *pageError
+	switch {
+	case errors.As(err, &perr):
+		logf.FromContext(ctx).V(1).Info("Rejected device approval", "reason", perr.message)
+		writeDevicePage(w, perr.status, devicePageData{Message: perr.message})
+	case err != nil:
+		logf.FromContext(ctx).Error(err, "Failed to approve device")
+		writeDevicePage(w, http.StatusInternalServerError, devicePageData{Message: "Something went wrong. Try again later."})
+	case req.PostForm.Get("action") == "approve":
+		writeDevicePage(w, http.StatusOK, devicePageData{Message: "Device approved. You can close this page and return to your device."})
+	default:
+		writeDevicePage(w, http.StatusOK, devicePageData{Message: "Device login denied."})
+	}
+}
+
+func (i *Issuer) decideDevice(ctx context.Context, req *http.Request) error {
+	if err := req.ParseForm(); err != nil {
+		return &pageError{http.StatusBadRequest, "The form is malformed."}
+	}
+	cookie, err := req.Cookie(csrfCookie)
+	csrf := req.PostForm.Get("csrf")
+	if err != nil || csrf == "" || subtle.ConstantTimeCompare([]byte(cookie.Value), []byte(csrf)) != 1 {
+		return &pageError{http.StatusForbidden, "The form expired. Reload the page and try again."}
+	}
+	identity, err := i.approver(ctx, req)
+	if err != nil {
+		return err
+	}
+
+	var secrets corev1.SecretList
+	if err := i.Secrets.List(ctx, &secrets, client.InNamespace(i.Namespace()),
+		client.MatchingLabels{DeviceCodeLabel: "true", UserCodeLabel: userCodeDigest(req.PostForm.Get("user_code"))}); err != nil {
+		return fmt.Errorf("failed to list device authorizations: %w", err)
+	}
+	if len(secrets.Items) != 1 {
+		return errInvalidUserCode
+	}
+	secret := &secrets.Items[0]
+	if expired(secret, i.Now()) || secret.Annotations[DeviceStatusAnnotation] != "" {
+		return errInvalidUserCode
+	}
+	secret.Annotations[DeviceStatusAnnotation] = deviceDenied
+	if req.PostForm.Get("action") == "approve" {
+		secret.Annotations[DeviceStatusAnnotation] = deviceApproved
+		// The username of the User rather than the approver's, which is the ServiceAccount
+		// anchor's when a machine user approves with its token
+		secret.Annotations[UsernameAnnotation] = operatorconfig.Current().Username(identity.User)
+		secret.Labels[UserLabel] = identity.User
+	}
+	// The update carries the resourceVersion that was read, so a code is decided on once
+	if err := i.Writer.Update(ctx, secret); err != nil {
+		if apierrors.IsConflict(err) || apierrors.IsNotFound(err) {
+			return errInvalidUserCode
+		}
+		return fmt.Errorf("failed to record device approval: %w", err)
+	}
+	logf.FromContext(ctx).Info("Device login decided", "user", identity.User, "status", secret.Annotations[DeviceStatusAnnotation])
+	return nil
+}
+
+// approver returns the identity of the user approving a device
+func (i *Issuer) approver(ctx context.Context, req *http.Request) (proxy.Identity, error) {
+	p := &proxy.Proxy{Reader: i.Reader, Reviewer: i.Reviewer}
+	var identity proxy.Identity
+	var err error
+	token := strings.TrimSpace(req.PostForm.Get("token"))
+	switch {
+	case req.TLS != nil && len(req.TLS.VerifiedChains) > 0:
+		identity, err = p.Authenticate(ctx, req.TLS.VerifiedChains[0][0])
+	case token != "" && i.Reviewer != nil:
+		identity, err = p.AuthenticateToken(ctx, token)
+	default:
+		return proxy.Identity{}, &pageError{http.StatusUnauthorized, "Sign in with the client certificate KubeUser issued you, or a Kubernetes token."}
+	}
+	var status apierrors.APIStatus
+	if errors.As(err, &status) && (apierrors.IsUnauthorized(err) || apierrors.IsForbidden(err)) {
+		return proxy.Identity{}, &pageError{http.StatusUnauthorized, "Sign-in failed: " + status.Status().Message}
+	}
+	return identity, err
+}
@@ -0,0 +1,217 @@
+/*
+Copyright 2025.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package oidc
+
+import (
+	"context"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/tls"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/json"
+	"math/big"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strings"
+	"time"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+	authenticationv1 "k8s.io/api/authentication/v1"
+	corev1 "k8s.io/api/core/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+
+	authv1alpha1 "github.com/openkube-hub/KubeUser/api/v1alpha1"
+)
+
+var _ = Describe("Device authorization", func() {
+	var (
+		now  time.Time
+		key  *rsa.PrivateKey
+		c    client.WithWatch
+		i    *Issuer
+		cert *x509.Certificate
+	)
+
+	BeforeEach(func() {
+		now = time.Date(2025, 6, 1, 12, 0, 0, 0, time.UTC)
+		var err error
+		key, err = rsa.GenerateKey(rand.Reader, 2048)
+		Expect(err).NotTo(HaveOccurred())
+		cert = &x509.Certificate{SerialNumber: big.NewInt(0x2a), Subject: pkix.Name{CommonName: "jane"}}
+		c = newClient(
+			&authv1alpha1.User{ObjectMeta: metav1.ObjectMeta{Name: "jane"}},
+			&authv1alpha1.IssuedCertificate{
+				ObjectMeta: metav1.ObjectMeta{Name: "jane-2a"},
+				Spec:       authv1alpha1.IssuedCertificateSpec{User: "jane", SerialNumber: "2a", CommonName: "jane"},
+			},
+			&authv1alpha1.Team{
+				ObjectMeta: metav1.ObjectMeta{Name: "platform"},
+				Spec:       authv1alpha1.TeamSpec{Members: []authv1alpha1.TeamMember{{Name: "jane"}}},
+			},
+		)
+		i, err = New(issuerURL, DefaultClientID, DefaultTokenTTL, c)
+		Expect(err).NotTo(HaveOccurred())
+		i.Now = func() time.Time { return now }
+		i.RefreshTTL, i.Secrets, i.Writer = DefaultRefreshTokenTTL, c, c
+		i.Namespace = func() string { return "kubeuser" }
+		i.SetKey(key)
+	})
+
+	post := func(path string, form url.Values) (*httptest.ResponseRecorder, map[string]any) {
+		req := httptest.NewRequest(http.MethodPost, issuerURL+path, strings.NewReader(form.Encode()))
+		req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+		rec := httptest.NewRecorder()
+		i.ServeHTTP(rec, req)
+		body := map[string]any{}
+		Expect(json.Unmarshal(rec.Body.Bytes(), &body)).To(Succeed())
+		return rec, body
+	}
+
+	// authorize starts a device login and returns its device and user code
+	authorize := func() (string, string) {
+		rec, body := post("/device_authorization", url.Values{"client_id": {DefaultClientID}, "scope": {"openid offline_access"}})
+		Expect(rec.Code).To(Equal(http.StatusOK), rec.Body.String())
+		Expect(body).To(HaveKeyWithValue("verification_uri", issuerURL+"/device"))
+		Expect(body).To(HaveKeyWithValue("interval", BeNumerically("==", pollInterval)))
+		return body["device_code"].(string), body["user_code"].(string)
+	}
+
+	poll := func(deviceCode string) (*httptest.ResponseRecorder, map[string]any) {
+		return post("/token", url.Values{"grant_type": {deviceCodeGrant}, "device_code": {deviceCode}})
+	}
+
+	// decide opens the verification page and submits action for userCode, with the client
+	// certificate of jane or form fields like a token
+	decide := func(userCode, action string, withCert bool, form url.Values) *httptest.ResponseRecorder {
+		var state *tls.ConnectionState
+		if withCert {
+			state = &tls.ConnectionState{VerifiedChains: [][]*x509.Certificate{{cert}}}
+		}
+		req := httptest.NewRequest(http.MethodGet, issuerURL+"/device?user_code="+userCode, nil)
+		req.TLS = state
+		rec := httptest.NewRecorder()
+		i.ServeHTTP(rec, req)
+		Expect(rec.Code).To(Equal(http.StatusOK))
+		Expect(rec.Header().Get("Content-Security-Policy")).To(ContainSubstring("frame-ancestors 'none'"))
+		cookies := rec.Result().Cookies()
+		Expect(cookies).To(HaveLen(1))
+
+		if form == nil {
+			form = url.Values{}
+		}
+		form.Set("user_code", strings.ToLower(userCode))
+		form.Set("action", action)
+		if !form.Has("csrf") {
+			form.Set("csrf", cookies[0].Value)
+		}
+		req = httptest.NewRequest(http.MethodPost, issuerURL+"/device", strings.NewReader(form.Encode()))
+		req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+		req.AddCookie(cookies[0])
+		req.TLS = state
+		rec = httptest.NewRecorder()
+		i.ServeHTTP(rec, req)
+		return rec
+	}
+
+	It("issues a token to a device the user approved, once", func() {
+		deviceCode, userCode := authorize()
+		Expect(userCode).To(MatchRegexp(`^[A-Z]{4}-[A-Z]{4}$`))
+		var secret corev1.Secret
+		Expect(c.Get(context.Background(), client.ObjectKey{Namespace: "kubeuser", Name: DeviceCodeSecretName(deviceCode)}, &secret)).To(Succeed())
+		// Only digests of the codes are stored
+		Expect(secret.Labels).NotTo(ContainElement(userCode))
+		Expect(secret.Data).To(BeEmpty())
+
+		rec, body := poll(deviceCode)
+		Expect(rec.Code).To(Equal(http.StatusBadRequest))
+		Expect(body).To(HaveKeyWithValue("error", "authorization_pending"))
+
+		rec = decide(userCode, "approve", true, nil)
+		Expect(rec.Code).To(Equal(http.StatusOK), rec.Body.String())
+		Expect(rec.Body.String()).To(ContainSubstring("Device approved"))
+
+		rec, body = poll(deviceCode)
+		Expect(rec.Code).To(Equal(http.StatusOK), rec.Body.String())
+		claims := verify(body["id_token"].(string), &key.PublicKey)
+		Expect(claims.Subject).To(Equal("jane"))
+		Expect(claims.Groups).To(ContainElement("kubeuser:team:platform"))
+		Expect(body).To(HaveKey("refresh_token"))
+
+		rec, body = poll(deviceCode)
+		Expect(rec.Code).To(Equal(http.StatusBadRequest))
+		Expect(body).To(HaveKeyWithValue("error", "invalid_grant"))
+
+		// A user code is decided on once
+		rec = decide(userCode, "approve", true, nil)
+		Expect(rec.Code).To(Equal(http.StatusBadRequest))
+	})
+
+	It("tells the device when the user denied it", func() {
+		deviceCode, userCode := authorize()
+		Expect(decide(userCode, "deny", true, nil).Code).To(Equal(http.StatusOK))
+		rec, body := poll(deviceCode)
+		Expect(rec.Code).To(Equal(http.StatusBadRequest))
+		Expect(body).To(HaveKeyWithValue("error", "access_denied"))
+	})
+
+	It("rejects approvals without the CSRF token of the page or a sign-in", func() {
+		deviceCode, userCode := authorize()
+		Expect(decide(userCode, "approve", true, url.Values{"csrf": {"forged"}}).Code).To(Equal(http.StatusForbidden))
+		Expect(decide(userCode, "approve", false, nil).Code).To(Equal(http.StatusUnauthorized))
+		_, body := poll(deviceCode)
+		Expect(body).To(HaveKeyWithValue("error", "authorization_pending"))
+	})
+
+	It("accepts the token of a machine user's ServiceAccount anchor", func() {
+		i.Reviewer = func(_ context.Context, token string) (authenticationv1.UserInfo, error) {
+			if token != "sa-token" {
+				return authenticationv1.UserInfo{}, apierrors.NewUnauthorized("invalid token")
+			}
+			return authenticationv1.UserInfo{Username: "system:serviceaccount:kubeuser:jane"}, nil
+		}
+		deviceCode, userCode := authorize()
+		Expect(decide(userCode, "approve", false, url.Values{"token": {"wrong"}}).Code).To(Equal(http.StatusUnauthorized))
+		rec := decide(userCode, "approve", false, url.Values{"token": {"sa-token"}})
+		Expect(rec.Code).To(Equal(http.StatusOK), rec.Body.String())
+
+		rec, body := poll(deviceCode)
+		Expect(rec.Code).To(Equal(http.StatusOK), rec.Body.String())
+		// The token is for the User, not its ServiceAccount
+		Expect(verify(body["id_token"].(string), &key.PublicKey).Subject).To(Equal("jane"))
+	})
+
+	It("expires device codes and sweeps them", func() {
+		deviceCode, userCode := authorize()
+		now = now.Add(deviceCodeTTL)
+		Expect(decide(userCode, "approve", true, nil).Code).To(Equal(http.StatusBadRequest))
+		rec, body := poll(deviceCode)
+		Expect(rec.Code).To(Equal(http.StatusBadRequest))
+		Expect(body).To(HaveKeyWithValue("error", "expired_token"))
+
+		authorize()
+		now = now.Add(deviceCodeTTL)
+		Expect(i.Sweep(context.Background())).To(Succeed())
+		var secrets corev1.SecretList
+		Expect(c.List(context.Background(), &secrets, client.MatchingLabels{DeviceCodeLabel: "true"})).To(Succeed())
+		Expect(secrets.Items).To(BeEmpty())
+	})
+})
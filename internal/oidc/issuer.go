@@ -0,0 +1,380 @@
+/*
+Copyright 2025.
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+*/
+
+// Package oidc issues OpenID Connect tokens for KubeUser users. The API server trusts the
+// issuer through its --oidc-* flags, so users authenticate with short-lived tokens instead of
+// their client certificate, and the groups in a token include the Teams of its User. Users
+// obtain tokens with the client certificate KubeUser issued them or by approving a device login,
+// and keep their login going with refresh tokens, which end as soon as the User is suspended or
+// revoked. API servers that cannot set those flags authenticate tokens through the TokenReview
+// webhook of the issuer instead.
+package oidc
+
+import (
+	"context"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/x509"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/url"
+	"slices"
+	"strings"
+	"sync"
+	"time"
+
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	logf "sigs.k8s.io/controller-runtime/pkg/log"
+
+	"github.com/openkube-hub/KubeUser/internal/proxy"
+	"github.com/openkube-hub/KubeUser/internal/team"
+)
+
+const (
+	// TeamGroupPrefix prefixes the names of a user's Teams among its groups
+	TeamGroupPrefix = "kubeuser:team:"
+
+	// DefaultClientID is the default audience of tokens
+	DefaultClientID = "kubeuser"
+	// DefaultTokenTTL is how long tokens are valid by default
+	DefaultTokenTTL = 15 * time.Minute
+	// MaxTokenTTL is the longest tokens may be valid; the API server cannot revoke them
+	MaxTokenTTL = 24 * time.Hour
+)
+
+// Issuer serves the discovery document and keys of the KubeUser OIDC issuer, and issues ID
+// tokens to users authenticating with the client certificate KubeUser issued them, a refresh
+// token, or a device login they approved
+type Issuer struct {
+	// URL is the issuer identifier, the iss claim of its tokens; the endpoints are served below
+	// its path
+	URL string
+	// ClientID is the aud claim of tokens, which the API server's --oidc-client-id must match
+	ClientID string
+	// TTL is how long tokens are valid
+	TTL time.Duration
+	// Reader looks up Users, IssuedCertificates and Teams
+	Reader client.Reader
+	// Reviewer authenticates the bearer tokens users approve devices with; without one only
+	// client certificates are accepted
+	Reviewer proxy.TokenReviewer
+	// RefreshTTL is how long a login can be refreshed; no refresh tokens are issued when it is
+	// zero
+	RefreshTTL time.Duration
+	// Secrets reads refresh tokens and device authorizations; it should read from the API
+	// server, so they work right after they are created
+	Secrets client.Reader
+	// Writer stores, rotates and deletes refresh tokens and device authorizations
+	Writer client.Writer
+	// Namespace returns the KubeUser namespace, which holds refresh tokens and device
+	// authorizations
+	Namespace func() string
+	// Now is replaced in tests
+	Now func() time.Time
+
+	mu       sync.RWMutex
+	key      *rsa.PrivateKey
+	previous *rsa.PublicKey
+	mux      *http.ServeMux
+}
+
+// New returns an Issuer identified by issuerURL, which must be an https URL without query,
+// fragment or trailing slash. It serves no tokens until its signing key is set.
+func New(issuerURL, clientID string, ttl time.Duration, reader client.Reader) (*Issuer, error) {
+	u, err := url.Parse(issuerURL)
+	if err != nil {
+		return nil, fmt.Errorf("invalid issuer URL: %w", err)
+	}
+	if u.Scheme != "https" || u.Host == "" || u.RawQuery != "" || u.Fragment != "" || strings.HasSuffix(u.Path, "/") {
+		return nil, fmt.Errorf("issuer URL %q must be an https URL without query, fragment or trailing slash", issuerURL)
+	}
+	if clientID == "" {
+		return nil, errors.New("client ID must not be empty")
+	}
+	if ttl <= 0 || ttl > MaxTokenTTL {
+		return nil, fmt.Errorf("token lifetime must be positive and at most %s", MaxTokenTTL)
+	}
+	i := &Issuer{URL: issuerURL, ClientID: clientID, TTL: ttl, Reader: reader, Now: time.Now}
+	i.mux = http.NewServeMux()
+	i.mux.HandleFunc("GET "+u.Path+"/.well-known/openid-configuration", i.discovery)
+	i.mux.HandleFunc("GET "+u.Path+"/keys", i.keys)
+	i.mux.HandleFunc("POST "+u.Path+"/token", i.token)
+	i.mux.HandleFunc("POST "+u.Path+"/revoke", i.revoke)
+	i.mux.HandleFunc("POST "+u.Path+"/device_authorization", i.deviceAuthorization)
+	i.mux.HandleFunc("GET "+u.Path+"/device", i.verificationPage)
+	i.mux.HandleFunc("POST "+u.Path+"/device", i.approveDevice)
+	i.mux.HandleFunc("POST "+u.Path+"/authenticate", i.authenticateToken)
+	return i, nil
+}
+
+// SetKey makes key the signing key and reports whether it changed. The previous key is still
+// published, so the tokens it signed stay valid until they expire.
+func (i *Issuer) SetKey(key *rsa.PrivateKey) bool {
+	i.mu.Lock()
+	defer i.mu.Unlock()
+	if i.key != nil && i.key.PublicKey.Equal(&key.PublicKey) {
+		return false
+	}
+	if i.key != nil {
+		i.previous = &i.key.PublicKey
+	}
+	i.key = key
+	return true
+}
+
+// signingKey returns the current signing key, or nil before one is set
+func (i *Issuer) signingKey() *rsa.PrivateKey {
+	i.mu.RLock()
+	defer i.mu.RUnlock()
+	return i.key
+}
+
+// publicKeys returns the keys tokens may be signed with: the current and the previous key
+func (i *Issuer) publicKeys() []*rsa.PublicKey {
+	i.mu.RLock()
+	defer i.mu.RUnlock()
+	if i.key == nil {
+		return nil
+	}
+	return []*rsa.PublicKey{&i.key.PublicKey, i.previous}
+}
+
+// ServeHTTP implements http.Handler
+func (i *Issuer) ServeHTTP(w http.ResponseWriter, req *http.Request) {
+	w.Header().Set("X-Content-Type-Options", "nosniff")
+	i.mux.ServeHTTP(w, req)
+}
+
+// discoveryDocument is the OpenID Provider Metadata of the issuer
+type discoveryDocument struct {
+	Issuer                            string   `json:"issuer"`
+	JWKSURI                           string   `json:"jwks_uri"`
+	TokenEndpoint                     string   `json:"token_endpoint"`
+	RevocationEndpoint                string   `json:"revocation_endpoint"`
+	DeviceAuthorizationEndpoint       string   `json:"device_authorization_endpoint"`
+	GrantTypesSupported               []string `json:"grant_types_supported"`
+	ResponseTypesSupported            []string `json:"response_types_supported"`
+	ScopesSupported                   []string `json:"scopes_supported"`
+	SubjectTypesSupported             []string `json:"subject_types_supported"`
+	IDTokenSigningAlgValuesSupported  []string `json:"id_token_signing_alg_values_supported"`
+	TokenEndpointAuthMethodsSupported []string `json:"token_endpoint_auth_methods_supported"`
+	ClaimsSupported                   []string `json:"claims_supported"`
+}
+
+func (i *Issuer) discovery(w http.ResponseWriter, _ *http.Request) {
+	writePublic(w, discoveryDocument{
+		Issuer:                            i.URL,
+		JWKSURI:                           i.URL + "/keys",
+		TokenEndpoint:                     i.URL + "/token",
+		RevocationEndpoint:                i.URL + "/revoke",
+		DeviceAuthorizationEndpoint:       i.URL + "/device_authorization",
+		GrantTypesSupported:               []string{"client_credentials", "refresh_token", deviceCodeGrant},
+		ResponseTypesSupported:            []string{"id_token"},
+		ScopesSupported:                   []string{"openid", offlineAccess},
+		SubjectTypesSupported:             []string{"public"},
+		IDTokenSigningAlgValuesSupported:  []string{"RS256"},
+		TokenEndpointAuthMethodsSupported: []string{"tls_client_auth", "none"},
+		ClaimsSupported:                   []string{"iss", "sub", "aud", "iat", "exp", "jti", "groups"},
+	})
+}
+
+func (i *Issuer) keys(w http.ResponseWriter, _ *http.Request) {
+	i.mu.RLock()
+	keys := []jwk{}
+	if i.key != nil {
+		keys = append(keys, newJWK(&i.key.PublicKey))
+	}
+	if i.previous != nil {
+		keys = append(keys, newJWK(i.previous))
+	}
+	i.mu.RUnlock()
+	writePublic(w, struct {
+		Keys []jwk `json:"keys"`
+	}{keys})
+}
+
+// writePublic writes a document the API server may cache for a while
+func writePublic(w http.ResponseWriter, v any) {
+	w.Header().Set("Content-Type", "application/json")
+	w.Header().Set("Cache-Control", "public, max-age=60")
+	_ = json.NewEncoder(w).Encode(v)
+}
+
+// tokenResponse is the successful response of the token endpoint. The API server accepts ID
+// tokens, and the same token is returned as access token for clients that only read that.
+type tokenResponse struct {
+	AccessToken string `json:"access_token"`
+	IDToken     string `json:"id_token"`
+	TokenType   string `json:"token_type"`
+	ExpiresIn   int64  `json:"expires_in"`
+	// RefreshToken is returned when the client requested the offline_access scope
+	RefreshToken string `json:"refresh_token,omitempty"`
+}
+
+// oauthError is an error response of RFC 6749 section 5.2
+type oauthError struct {
+	status      int
+	Code        string `json:"error"`
+	Description string `json:"error_description,omitempty"`
+}
+
+func (e *oauthError) Error() string {
+	return e.Code + ": " + e.Description
+}
+
+func (i *Issuer) token(w http.ResponseWriter, req *http.Request) {
+	if err := req.ParseForm(); err != nil {
+		i.fail(w, req, &oauthError{http.StatusBadRequest, "invalid_request", "malformed form"})
+		return
+	}
+	if id := req.PostForm.Get("client_id"); id != "" && id != i.ClientID {
+		i.fail(w, req, &oauthError{http.StatusUnauthorized, "invalid_client", "unknown client " + id})
+		return
+	}
+	switch grantType := req.PostForm.Get("grant_type"); grantType {
+	case "client_credentials":
+		i.clientCredentials(w, req)
+	case "refresh_token":
+		i.refresh(w, req)
+	case deviceCodeGrant:
+		i.deviceToken(w, req)
+	default:
+		i.fail(w, req, &oauthError{http.StatusBadRequest, "unsupported_grant_type", "unsupported grant type " + grantType})
+	}
+}
+
+// clientCredentials issues a token to the user of the client certificate of the request, and
+// starts a login with a refresh token when the client requests the offline_access scope
+func (i *Issuer) clientCredentials(w http.ResponseWriter, req *http.Request) {
+	if req.TLS == nil || len(req.TLS.VerifiedChains) == 0 {
+		i.fail(w, req, &oauthError{http.StatusUnauthorized, "invalid_client", "a client certificate issued by KubeUser is required"})
+		return
+	}
+	identity, err := i.authenticate(req.Context(), req.TLS.VerifiedChains[0][0])
+	if err != nil {
+		i.fail(w, req, err)
+		return
+	}
+	l, err := i.newLogin(req.PostForm.Get("scope"))
+	if err != nil {
+		i.fail(w, req, err)
+		return
+	}
+	i.issue(w, req, identity, l)
+}
+
+// authenticate returns the identity of a client certificate like the impersonation proxy does,
+// so it fails for certificates KubeUser revoked and Users that are not active
+func (i *Issuer) authenticate(ctx context.Context, cert *x509.Certificate) (proxy.Identity, error) {
+	identity, err := (&proxy.Proxy{Reader: i.Reader}).Authenticate(ctx, cert)
+	var status apierrors.APIStatus
+	if errors.As(err, &status) && (apierrors.IsUnauthorized(err) || apierrors.IsForbidden(err)) {
+		return proxy.Identity{}, &oauthError{http.StatusUnauthorized, "invalid_client", status.Status().Message}
+	}
+	return identity, err
+}
+
+// issue writes a token for identity, with a refresh token of l unless it is nil
+func (i *Issuer) issue(w http.ResponseWriter, req *http.Request, identity proxy.Identity, l *login) {
+	ctx := req.Context()
+	key := i.signingKey()
+	if key == nil {
+		i.fail(w, req, &oauthError{http.StatusServiceUnavailable, "temporarily_unavailable", "the signing key is not loaded yet"})
+		return
+	}
+	groups, err := i.Groups(ctx, identity)
+	if err != nil {
+		i.fail(w, req, err)
+		return
+	}
+	b, err := randomBytes(16)
+	if err != nil {
+		i.fail(w, req, err)
+		return
+	}
+	id, now := base64URL(b), i.Now()
+	token, err := sign(key, Claims{
+		Issuer:   i.URL,
+		Subject:  identity.Username,
+		Audience: i.ClientID,
+		IssuedAt: now.Unix(),
+		Expiry:   now.Add(i.TTL).Unix(),
+		ID:       id,
+		Groups:   groups,
+		User:     identity.User,
+	})
+	if err != nil {
+		i.fail(w, req, err)
+		return
+	}
+	response := tokenResponse{AccessToken: token, IDToken: token, TokenType: "Bearer", ExpiresIn: int64(i.TTL.Seconds())}
+	if l != nil {
+		if response.RefreshToken, err = i.newRefreshToken(ctx, identity, l); err != nil {
+			i.fail(w, req, err)
+			return
+		}
+	}
+	logf.FromContext(ctx).Info("Issued OIDC token", "user", identity.User, "jti", id, "refreshable", l != nil)
+	writeToken(w, response)
+}
+
+// Groups returns the groups of identity: the organizations of its User and a group for each
+// Team it is a member of, directly or through an included Team
+func (i *Issuer) Groups(ctx context.Context, identity proxy.Identity) ([]string, error) {
+	teams, err := team.ForUser(ctx, i.Reader, identity.User)
+	if err != nil {
+		return nil, err
+	}
+	if teams, err = team.Include(ctx, i.Reader, teams); err != nil {
+		return nil, err
+	}
+	groups := slices.Clone(identity.Groups)
+	for _, t := range teams {
+		groups = append(groups, TeamGroupPrefix+t.Name)
+	}
+	return groups, nil
+}
+
+// randomBytes returns size random bytes
+func randomBytes(size int) ([]byte, error) {
+	b := make([]byte, size)
+	if _, err := rand.Read(b); err != nil {
+		return nil, err
+	}
+	return b, nil
+}
+
+func base64URL(b []byte) string {
+	return base64.RawURLEncoding.EncodeToString(b)
+}
+
+// writeToken writes a response of the token endpoint, which must never be cached
+func writeToken(w http.ResponseWriter, v any) {
+	w.Header().Set("Content-Type", "application/json")
+	w.Header().Set("Cache-Control", "no-store")
+	w.Header().Set("Pragma", "no-cache")
+	_ = json.NewEncoder(w).Encode(v)
+}
+
+// fail writes err as an OAuth error response; errors other than oauthError are logged and
+// reported as server_error
+func (i *Issuer) fail(w http.ResponseWriter, req *http.Request, err error) {
+	var oerr *oauthError
+	if !errors.As(err, &oerr) {
+		logf.FromContext(req.Context()).Error(err, "Failed to serve OIDC request", "path", req.URL.Path)
+		oerr = &oauthError{http.StatusInternalServerError, "server_error", "internal error"}
+	} else {
+		logf.FromContext(req.Context()).V(1).Info("Rejected OIDC request", "path", req.URL.Path, "reason", oerr.Error())
+	}
+	w.Header().Set("Content-Type", "application/json")
+	w.Header().Set("Cache-Control", "no-store")
+	w.WriteHeader(oerr.status)
+	_ = json.NewEncoder(w).Encode(oerr)
+}
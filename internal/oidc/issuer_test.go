@@ -0,0 +1,274 @@
+/*
+Copyright 2025.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package oidc
+
+import (
+	"context"
+	"crypto"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/sha256"
+	"crypto/tls"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/base64"
+	"encoding/json"
+	"math/big"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strings"
+	"time"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/types"
+	clientgoscheme "k8s.io/client-go/kubernetes/scheme"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+
+	authv1alpha1 "github.com/openkube-hub/KubeUser/api/v1alpha1"
+)
+
+const issuerURL = "https://kubeuser.example.com/oidc"
+
+// newClient returns a fake client holding objects
+func newClient(objects ...client.Object) client.WithWatch {
+	scheme := runtime.NewScheme()
+	Expect(clientgoscheme.AddToScheme(scheme)).To(Succeed())
+	Expect(authv1alpha1.AddToScheme(scheme)).To(Succeed())
+	return fake.NewClientBuilder().WithScheme(scheme).WithObjects(objects...).Build()
+}
+
+// verify returns the claims of token after checking its signature with key
+func verify(token string, key *rsa.PublicKey) Claims {
+	parts := strings.Split(token, ".")
+	Expect(parts).To(HaveLen(3))
+	var h header
+	data, err := base64.RawURLEncoding.DecodeString(parts[0])
+	Expect(err).NotTo(HaveOccurred())
+	Expect(json.Unmarshal(data, &h)).To(Succeed())
+	Expect(h).To(Equal(header{Algorithm: "RS256", KeyID: KeyID(key), Type: "JWT"}))
+	signature, err := base64.RawURLEncoding.DecodeString(parts[2])
+	Expect(err).NotTo(HaveOccurred())
+	digest := sha256.Sum256([]byte(parts[0] + "." + parts[1]))
+	Expect(rsa.VerifyPKCS1v15(key, crypto.SHA256, digest[:], signature)).To(Succeed())
+	var claims Claims
+	data, err = base64.RawURLEncoding.DecodeString(parts[1])
+	Expect(err).NotTo(HaveOccurred())
+	Expect(json.Unmarshal(data, &claims)).To(Succeed())
+	return claims
+}
+
+var _ = Describe("Issuer", func() {
+	var (
+		now     time.Time
+		key     *rsa.PrivateKey
+		user    *authv1alpha1.User
+		issued  *authv1alpha1.IssuedCertificate
+		cert    *x509.Certificate
+		objects []client.Object
+	)
+
+	BeforeEach(func() {
+		now = time.Date(2025, 6, 1, 12, 0, 0, 0, time.UTC)
+		var err error
+		key, err = rsa.GenerateKey(rand.Reader, 2048)
+		Expect(err).NotTo(HaveOccurred())
+		user = &authv1alpha1.User{
+			ObjectMeta: metav1.ObjectMeta{Name: "jane"},
+			Spec: authv1alpha1.UserSpec{
+				Certificate: &authv1alpha1.CertificateSubject{Organizations: []string{"developers"}},
+			},
+		}
+		issued = &authv1alpha1.IssuedCertificate{
+			ObjectMeta: metav1.ObjectMeta{Name: "jane-2a"},
+			Spec:       authv1alpha1.IssuedCertificateSpec{User: "jane", SerialNumber: "2a", CommonName: "jane"},
+		}
+		cert = &x509.Certificate{SerialNumber: big.NewInt(0x2a), Subject: pkix.Name{CommonName: "jane"}}
+		objects = []client.Object{
+			&authv1alpha1.Team{
+				ObjectMeta: metav1.ObjectMeta{Name: "platform"},
+				Spec: authv1alpha1.TeamSpec{
+					Members:      []authv1alpha1.TeamMember{{Name: "jane"}},
+					IncludeTeams: []string{"oncall"},
+				},
+			},
+			&authv1alpha1.Team{ObjectMeta: metav1.ObjectMeta{Name: "oncall"}},
+			&authv1alpha1.Team{
+				ObjectMeta: metav1.ObjectMeta{Name: "security"},
+				Spec:       authv1alpha1.TeamSpec{Members: []authv1alpha1.TeamMember{{Name: "john"}}},
+			},
+		}
+	})
+
+	newIssuer := func() *Issuer {
+		objs := objects
+		if user != nil {
+			objs = append(objs, user)
+		}
+		if issued != nil {
+			objs = append(objs, issued)
+		}
+		i, err := New(issuerURL, DefaultClientID, DefaultTokenTTL, newClient(objs...))
+		Expect(err).NotTo(HaveOccurred())
+		i.Now = func() time.Time { return now }
+		if key != nil {
+			i.SetKey(key)
+		}
+		return i
+	}
+
+	get := func(i *Issuer, path string) *httptest.ResponseRecorder {
+		rec := httptest.NewRecorder()
+		i.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, issuerURL+path, nil))
+		return rec
+	}
+
+	postToken := func(i *Issuer, form url.Values, withCert bool) (*httptest.ResponseRecorder, map[string]any) {
+		req := httptest.NewRequest(http.MethodPost, issuerURL+"/token", strings.NewReader(form.Encode()))
+		req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+		if withCert {
+			req.TLS = &tls.ConnectionState{VerifiedChains: [][]*x509.Certificate{{cert}}}
+		}
+		rec := httptest.NewRecorder()
+		i.ServeHTTP(rec, req)
+		var body map[string]any
+		Expect(json.Unmarshal(rec.Body.Bytes(), &body)).To(Succeed())
+		return rec, body
+	}
+
+	clientCredentials := url.Values{"grant_type": {"client_credentials"}}
+
+	It("serves the discovery document and the signing key", func() {
+		i := newIssuer()
+		rec := get(i, "/.well-known/openid-configuration")
+		Expect(rec.Code).To(Equal(http.StatusOK))
+		var discovery discoveryDocument
+		Expect(json.Unmarshal(rec.Body.Bytes(), &discovery)).To(Succeed())
+		Expect(discovery.Issuer).To(Equal(issuerURL))
+		Expect(discovery.JWKSURI).To(Equal(issuerURL + "/keys"))
+		Expect(discovery.TokenEndpoint).To(Equal(issuerURL + "/token"))
+
+		rec = get(i, "/keys")
+		Expect(rec.Code).To(Equal(http.StatusOK))
+		var keys struct{ Keys []jwk }
+		Expect(json.Unmarshal(rec.Body.Bytes(), &keys)).To(Succeed())
+		Expect(keys.Keys).To(Equal([]jwk{newJWK(&key.PublicKey)}))
+		Expect(keys.Keys[0].Exponent).To(Equal("AQAB"))
+	})
+
+	It("issues tokens to users authenticating with their client certificate", func() {
+		rec, body := postToken(newIssuer(), clientCredentials, true)
+		Expect(rec.Code).To(Equal(http.StatusOK), rec.Body.String())
+		Expect(rec.Header().Get("Cache-Control")).To(Equal("no-store"))
+		Expect(body).To(HaveKeyWithValue("token_type", "Bearer"))
+		Expect(body).To(HaveKeyWithValue("expires_in", BeNumerically("==", 900)))
+		Expect(body["access_token"]).To(Equal(body["id_token"]))
+
+		claims := verify(body["id_token"].(string), &key.PublicKey)
+		Expect(claims.Issuer).To(Equal(issuerURL))
+		Expect(claims.Subject).To(Equal("jane"))
+		Expect(claims.Audience).To(Equal(DefaultClientID))
+		Expect(claims.User).To(Equal("jane"))
+		Expect(claims.IssuedAt).To(Equal(now.Unix()))
+		Expect(claims.Expiry).To(Equal(now.Add(DefaultTokenTTL).Unix()))
+		Expect(claims.ID).NotTo(BeEmpty())
+		// The organizations of the User, its Teams and the Teams they include
+		Expect(claims.Groups).To(Equal([]string{"developers", "kubeuser:team:platform", "kubeuser:team:oncall"}))
+	})
+
+	It("rejects requests without a certificate of an active User", func() {
+		rec, body := postToken(newIssuer(), clientCredentials, false)
+		Expect(rec.Code).To(Equal(http.StatusUnauthorized))
+		Expect(body).To(HaveKeyWithValue("error", "invalid_client"))
+
+		revokedAt := metav1.NewTime(now)
+		issued.Status = authv1alpha1.IssuedCertificateStatus{RevokedAt: &revokedAt, Reason: authv1alpha1.RevocationReasonSuperseded}
+		rec, body = postToken(newIssuer(), clientCredentials, true)
+		Expect(rec.Code).To(Equal(http.StatusUnauthorized))
+		Expect(body).To(HaveKeyWithValue("error_description", ContainSubstring("revoked")))
+
+		issued.Status = authv1alpha1.IssuedCertificateStatus{}
+		user.Spec.Suspended = true
+		rec, body = postToken(newIssuer(), clientCredentials, true)
+		Expect(rec.Code).To(Equal(http.StatusUnauthorized))
+		Expect(body).To(HaveKeyWithValue("error_description", ContainSubstring("suspended")))
+	})
+
+	It("rejects other clients and grant types", func() {
+		rec, body := postToken(newIssuer(), url.Values{"grant_type": {"client_credentials"}, "client_id": {"other"}}, true)
+		Expect(rec.Code).To(Equal(http.StatusUnauthorized))
+		Expect(body).To(HaveKeyWithValue("error", "invalid_client"))
+
+		rec, body = postToken(newIssuer(), url.Values{"grant_type": {"password"}}, true)
+		Expect(rec.Code).To(Equal(http.StatusBadRequest))
+		Expect(body).To(HaveKeyWithValue("error", "unsupported_grant_type"))
+	})
+
+	It("issues no tokens before the signing key is loaded", func() {
+		key = nil
+		rec, body := postToken(newIssuer(), clientCredentials, true)
+		Expect(rec.Code).To(Equal(http.StatusServiceUnavailable))
+		Expect(body).To(HaveKeyWithValue("error", "temporarily_unavailable"))
+	})
+
+	It("keeps publishing the previous key after a rotation", func() {
+		i := newIssuer()
+		Expect(i.SetKey(key)).To(BeFalse())
+		rotated, err := rsa.GenerateKey(rand.Reader, 2048)
+		Expect(err).NotTo(HaveOccurred())
+		Expect(i.SetKey(rotated)).To(BeTrue())
+
+		var keys struct{ Keys []jwk }
+		Expect(json.Unmarshal(get(i, "/keys").Body.Bytes(), &keys)).To(Succeed())
+		Expect(keys.Keys).To(Equal([]jwk{newJWK(&rotated.PublicKey), newJWK(&key.PublicKey)}))
+
+		_, body := postToken(i, clientCredentials, true)
+		verify(body["id_token"].(string), &rotated.PublicKey)
+	})
+
+	It("requires an https issuer URL without trailing slash", func() {
+		for _, u := range []string{"http://kubeuser.example.com", "https://kubeuser.example.com/", "https://kubeuser.example.com?a=b"} {
+			_, err := New(u, DefaultClientID, DefaultTokenTTL, newClient())
+			Expect(err).To(HaveOccurred(), u)
+		}
+		_, err := New(issuerURL, DefaultClientID, MaxTokenTTL+time.Minute, newClient())
+		Expect(err).To(HaveOccurred())
+	})
+})
+
+var _ = Describe("EnsureSigningKey", func() {
+	It("generates the signing key once and reads it afterwards", func() {
+		c := newClient()
+		name := types.NamespacedName{Namespace: "kubeuser", Name: SigningKeySecret}
+		generated, err := EnsureSigningKey(context.Background(), c, c, name)
+		Expect(err).NotTo(HaveOccurred())
+
+		var secret corev1.Secret
+		Expect(c.Get(context.Background(), name, &secret)).To(Succeed())
+		Expect(secret.Data).To(HaveKey(SigningKeyKey))
+
+		read, err := EnsureSigningKey(context.Background(), c, c, name)
+		Expect(err).NotTo(HaveOccurred())
+		Expect(read.Equal(generated)).To(BeTrue())
+		Expect(KeyID(&read.PublicKey)).To(Equal(KeyID(&generated.PublicKey)))
+	})
+})
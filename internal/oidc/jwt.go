@@ -0,0 +1,107 @@
+/*
+Copyright 2025.
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+*/
+
+package oidc
+
+import (
+	"crypto"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"strings"
+)
+
+// Claims are the claims of the tokens KubeUser issues
+type Claims struct {
+	Issuer   string `json:"iss"`
+	Subject  string `json:"sub"`
+	Audience string `json:"aud"`
+	IssuedAt int64  `json:"iat"`
+	Expiry   int64  `json:"exp"`
+	ID       string `json:"jti"`
+	// Groups are the groups of the user when the token was issued
+	Groups []string `json:"groups,omitempty"`
+	// User is the name of the KubeUser User, which the subject is derived from by the username
+	// template
+	User string `json:"auth.openkube.io/user"`
+}
+
+// header is the JOSE header of a signed token
+type header struct {
+	Algorithm string `json:"alg"`
+	KeyID     string `json:"kid"`
+	Type      string `json:"typ"`
+}
+
+// sign returns claims as a JWT signed with key using RS256
+func sign(key *rsa.PrivateKey, claims Claims) (string, error) {
+	h, err := json.Marshal(header{Algorithm: "RS256", KeyID: KeyID(&key.PublicKey), Type: "JWT"})
+	if err != nil {
+		return "", err
+	}
+	payload, err := json.Marshal(claims)
+	if err != nil {
+		return "", err
+	}
+	signed := base64.RawURLEncoding.EncodeToString(h) + "." + base64.RawURLEncoding.EncodeToString(payload)
+	digest := sha256.Sum256([]byte(signed))
+	signature, err := rsa.SignPKCS1v15(rand.Reader, key, crypto.SHA256, digest[:])
+	if err != nil {
+		return "", fmt.Errorf("failed to sign token: %w", err)
+	}
+	return signed + "." + base64.RawURLEncoding.EncodeToString(signature), nil
+}
+
+// parse returns the claims of token after checking it is signed using RS256 with whichever of
+// keys its header names. Checking the claims is left to the caller.
+func parse(token string, keys ...*rsa.PublicKey) (Claims, error) {
+	parts := strings.Split(token, ".")
+	if len(parts) != 3 {
+		return Claims{}, errors.New("malformed token")
+	}
+	var h header
+	if err := decodeSegment(parts[0], &h); err != nil {
+		return Claims{}, fmt.Errorf("malformed token header: %w", err)
+	}
+	if h.Algorithm != "RS256" {
+		return Claims{}, fmt.Errorf("unsupported signing algorithm %q", h.Algorithm)
+	}
+	var key *rsa.PublicKey
+	for _, k := range keys {
+		if k != nil && KeyID(k) == h.KeyID {
+			key = k
+		}
+	}
+	if key == nil {
+		return Claims{}, fmt.Errorf("unknown signing key %q", h.KeyID)
+	}
+	signature, err := base64.RawURLEncoding.DecodeString(parts[2])
+	if err != nil {
+		return Claims{}, fmt.Errorf("malformed token signature: %w", err)
+	}
+	digest := sha256.Sum256([]byte(parts[0] + "." + parts[1]))
+	if err := rsa.VerifyPKCS1v15(key, crypto.SHA256, digest[:], signature); err != nil {
+		return Claims{}, errors.New("invalid token signature")
+	}
+	var claims Claims
+	if err := decodeSegment(parts[1], &claims); err != nil {
+		return Claims{}, fmt.Errorf("malformed token claims: %w", err)
+	}
+	return claims, nil
+}
+
+// decodeSegment decodes the base64url encoded JSON segment of a token into v
+func decodeSegment(segment string, v any) error {
+	data, err := base64.RawURLEncoding.DecodeString(segment)
+	if err != nil {
+		return err
+	}
+	return json.Unmarshal(data, v)
+}
@@ -0,0 +1,173 @@
+/*
+Copyright 2025.
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+*/
+
+package oidc
+
+import (
+	"context"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/sha256"
+	"crypto/x509"
+	"encoding/base64"
+	"encoding/pem"
+	"errors"
+	"fmt"
+	"math/big"
+	"time"
+
+	corev1 "k8s.io/api/core/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/types"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	logf "sigs.k8s.io/controller-runtime/pkg/log"
+
+	"github.com/openkube-hub/KubeUser/internal/certs"
+)
+
+const (
+	// SigningKeySecret is the Secret in the KubeUser namespace holding the key tokens are
+	// signed with. Deleting it rotates the key; the previous key is still published until the
+	// replicas restart, so tokens it signed stay valid until they expire.
+	SigningKeySecret = "kubeuser-oidc-signing-key"
+	// SigningKeyKey is the key of the PEM encoded RSA private key in the Secret
+	SigningKeyKey = "signing.key"
+
+	// DefaultKeySyncInterval is how often every replica reads the signing key
+	DefaultKeySyncInterval = time.Minute
+)
+
+// EnsureSigningKey returns the signing key in the Secret key, generating it when the Secret
+// does not exist. reader should read from the API server: replicas racing to create the
+// Secret all end up with the key written first.
+func EnsureSigningKey(ctx context.Context, reader client.Reader, writer client.Writer, key types.NamespacedName) (*rsa.PrivateKey, error) {
+	for attempt := 0; ; attempt++ {
+		var secret corev1.Secret
+		err := reader.Get(ctx, key, &secret)
+		if err == nil {
+			return parseSigningKey(secret.Data[SigningKeyKey])
+		}
+		if !apierrors.IsNotFound(err) {
+			return nil, fmt.Errorf("failed to read OIDC signing key Secret %s: %w", key, err)
+		}
+
+		signingKey, err := rsa.GenerateKey(rand.Reader, 2048)
+		if err != nil {
+			return nil, fmt.Errorf("failed to generate OIDC signing key: %w", err)
+		}
+		secret = corev1.Secret{
+			ObjectMeta: metav1.ObjectMeta{
+				Name:      key.Name,
+				Namespace: key.Namespace,
+				Labels:    map[string]string{certs.ManagedByLabel: "kubeuser"},
+			},
+			Type: corev1.SecretTypeOpaque,
+			Data: map[string][]byte{
+				SigningKeyKey: pem.EncodeToMemory(&pem.Block{Type: "RSA PRIVATE KEY", Bytes: x509.MarshalPKCS1PrivateKey(signingKey)}),
+			},
+		}
+		err = writer.Create(ctx, &secret)
+		if apierrors.IsAlreadyExists(err) && attempt == 0 {
+			continue
+		} else if err != nil {
+			return nil, fmt.Errorf("failed to create OIDC signing key Secret %s: %w", key, err)
+		}
+		logf.FromContext(ctx).Info("Generated OIDC signing key", "secret", key)
+		return signingKey, nil
+	}
+}
+
+// parseSigningKey parses a PEM encoded PKCS #1 or PKCS #8 RSA private key
+func parseSigningKey(data []byte) (*rsa.PrivateKey, error) {
+	block, _ := pem.Decode(data)
+	if block == nil {
+		return nil, errors.New("no PEM encoded OIDC signing key")
+	}
+	if key, err := x509.ParsePKCS1PrivateKey(block.Bytes); err == nil {
+		return key, nil
+	}
+	parsed, err := x509.ParsePKCS8PrivateKey(block.Bytes)
+	if err != nil {
+		return nil, fmt.Errorf("failed to parse OIDC signing key: %w", err)
+	}
+	key, ok := parsed.(*rsa.PrivateKey)
+	if !ok {
+		return nil, errors.New("OIDC signing key is not an RSA key")
+	}
+	return key, nil
+}
+
+// KeyID returns the kid of key: a digest of the public key, so every replica derives the same
+// ID and a new key gets a new one
+func KeyID(key *rsa.PublicKey) string {
+	sum := sha256.Sum256(x509.MarshalPKCS1PublicKey(key))
+	return base64.RawURLEncoding.EncodeToString(sum[:16])
+}
+
+// jwk is an RSA public key in the JSON Web Key format of RFC 7517
+type jwk struct {
+	KeyType   string `json:"kty"`
+	Use       string `json:"use"`
+	Algorithm string `json:"alg"`
+	KeyID     string `json:"kid"`
+	Modulus   string `json:"n"`
+	Exponent  string `json:"e"`
+}
+
+func newJWK(key *rsa.PublicKey) jwk {
+	return jwk{
+		KeyType:   "RSA",
+		Use:       "sig",
+		Algorithm: "RS256",
+		KeyID:     KeyID(key),
+		Modulus:   base64.RawURLEncoding.EncodeToString(key.N.Bytes()),
+		Exponent:  base64.RawURLEncoding.EncodeToString(big.NewInt(int64(key.E)).Bytes()),
+	}
+}
+
+// KeySyncer keeps the signing key of an Issuer equal to the Secret, so every replica signs
+// with the same key and picks up a rotated one. It runs on every replica, since each serves
+// tokens.
+type KeySyncer struct {
+	// Reader should read from the API server
+	Reader client.Reader
+	Writer client.Writer
+	// Secret is the Secret holding the signing key
+	Secret types.NamespacedName
+	Issuer *Issuer
+	// Interval defaults to DefaultKeySyncInterval
+	Interval time.Duration
+}
+
+// NeedLeaderElection implements manager.LeaderElectionRunnable
+func (s *KeySyncer) NeedLeaderElection() bool {
+	return false
+}
+
+// Start implements manager.Runnable; it syncs until ctx is done
+func (s *KeySyncer) Start(ctx context.Context) error {
+	logger := logf.FromContext(ctx).WithName("oidc-keys")
+	interval := s.Interval
+	if interval <= 0 {
+		interval = DefaultKeySyncInterval
+	}
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+	for {
+		key, err := EnsureSigningKey(ctx, s.Reader, s.Writer, s.Secret)
+		if err != nil {
+			logger.Error(err, "Failed to sync OIDC signing key", "secret", s.Secret)
+		} else if s.Issuer.SetKey(key) {
+			logger.Info("Loaded OIDC signing key", "kid", KeyID(&key.PublicKey))
+		}
+		select {
+		case <-ctx.Done():
+			return nil
+		case <-ticker.C:
+		}
+	}
+}
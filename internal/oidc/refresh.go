@@ -0,0 +1,318 @@
+/*
+Copyright 2025.
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+*/
+
+package oidc
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"net/http"
+	"slices"
+	"strings"
+	"time"
+
+	corev1 "k8s.io/api/core/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/types"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	logf "sigs.k8s.io/controller-runtime/pkg/log"
+
+	"github.com/openkube-hub/KubeUser/internal/proxy"
+)
+
+const (
+	// RefreshTokenLabel marks the Secrets that hold refresh tokens
+	RefreshTokenLabel = "auth.openkube.io/refresh-token"
+	// UserLabel names the User a refresh token was issued to
+	UserLabel = "auth.openkube.io/user"
+	// FamilyLabel groups the refresh tokens rotated from the same login, which end together
+	FamilyLabel = "auth.openkube.io/token-family"
+	// UsernameAnnotation is the username the login authenticated as, the sub of refreshed tokens
+	UsernameAnnotation = "auth.openkube.io/username"
+	// ExpiresAtAnnotation is when the login ends, in RFC 3339; refreshing does not extend it
+	ExpiresAtAnnotation = "auth.openkube.io/expires-at"
+	// UsedAtAnnotation marks a refresh token that was exchanged for a new one, in RFC 3339
+	UsedAtAnnotation = "auth.openkube.io/used-at"
+
+	// DefaultRefreshTokenTTL is how long a login can be refreshed by default
+	DefaultRefreshTokenTTL = 24 * time.Hour
+	// MaxRefreshTokenTTL is the longest a login can be refreshed
+	MaxRefreshTokenTTL = 30 * 24 * time.Hour
+
+	// offlineAccess is the scope a client requests a refresh token with
+	offlineAccess = "offline_access"
+)
+
+// errInvalidRefreshToken is returned for unknown, used, expired and revoked refresh tokens alike
+var errInvalidRefreshToken = &oauthError{http.StatusBadRequest, "invalid_grant", "the refresh token is invalid, expired or was revoked"}
+
+// RefreshTokenSecretName returns the name of the Secret of refresh token. Only a digest of the
+// token is stored, so reading the Secret does not reveal the token.
+func RefreshTokenSecretName(token string) string {
+	sum := sha256.Sum256([]byte(token))
+	return "kubeuser-refresh-" + hex.EncodeToString(sum[:20])
+}
+
+// login is the refresh token family a token is issued in
+type login struct {
+	family    string
+	expiresAt time.Time
+}
+
+// newLogin returns a login for a client that requested a refresh token with scope, or nil
+func (i *Issuer) newLogin(scope string) (*login, error) {
+	if i.RefreshTTL <= 0 || !slices.Contains(strings.Fields(scope), offlineAccess) {
+		return nil, nil
+	}
+	b, err := randomBytes(16)
+	if err != nil {
+		return nil, err
+	}
+	return &login{family: hex.EncodeToString(b), expiresAt: i.Now().Add(i.RefreshTTL)}, nil
+}
+
+// newRefreshToken stores a refresh token of identity in l and returns it
+func (i *Issuer) newRefreshToken(ctx context.Context, identity proxy.Identity, l *login) (string, error) {
+	b, err := randomBytes(32)
+	if err != nil {
+		return "", err
+	}
+	token := base64URL(b)
+	secret := &corev1.Secret{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      RefreshTokenSecretName(token),
+			Namespace: i.Namespace(),
+			Labels:    map[string]string{RefreshTokenLabel: "true", UserLabel: identity.User, FamilyLabel: l.family},
+			Annotations: map[string]string{
+				UsernameAnnotation:  identity.Username,
+				ExpiresAtAnnotation: l.expiresAt.UTC().Format(time.RFC3339),
+			},
+		},
+		Type: corev1.SecretTypeOpaque,
+	}
+	if err := i.Writer.Create(ctx, secret); err != nil {
+		return "", fmt.Errorf("failed to store refresh token: %w", err)
+	}
+	return token, nil
+}
+
+// refresh issues a token for the login of a refresh token, in exchange for a new refresh token.
+// The identity is resolved again, so a User that was suspended or revoked in the meantime ends
+// the login, and the groups follow its current Teams.
+func (i *Issuer) refresh(w http.ResponseWriter, req *http.Request) {
+	ctx := req.Context()
+	if i.RefreshTTL <= 0 {
+		i.fail(w, req, &oauthError{http.StatusBadRequest, "unsupported_grant_type", "refresh tokens are disabled"})
+		return
+	}
+	token := req.PostForm.Get("refresh_token")
+	if token == "" {
+		i.fail(w, req, &oauthError{http.StatusBadRequest, "invalid_request", "refresh_token is required"})
+		return
+	}
+	secret, err := i.redeemRefreshToken(ctx, token)
+	if err != nil {
+		i.fail(w, req, err)
+		return
+	}
+	l := &login{family: secret.Labels[FamilyLabel]}
+	l.expiresAt, _ = time.Parse(time.RFC3339, secret.Annotations[ExpiresAtAnnotation])
+	identity, err := (&proxy.Proxy{Reader: i.Reader}).AuthenticateUser(ctx, secret.Labels[UserLabel], secret.Annotations[UsernameAnnotation])
+	if inactiveUser(err) {
+		if err := i.revokeLogin(ctx, l.family); err != nil {
+			i.fail(w, req, err)
+			return
+		}
+		i.fail(w, req, &oauthError{http.StatusBadRequest, "invalid_grant", err.Error()})
+		return
+	}
+	if err != nil {
+		i.fail(w, req, err)
+		return
+	}
+	i.issue(w, req, identity, l)
+}
+
+// redeemRefreshToken marks the refresh token used and returns its Secret. A refresh token is
+// only exchanged once: presenting the last used one again means the client or someone else
+// holds a stolen copy, and revokes every refresh token of its login.
+func (i *Issuer) redeemRefreshToken(ctx context.Context, token string) (*corev1.Secret, error) {
+	var secret corev1.Secret
+	err := i.Secrets.Get(ctx, types.NamespacedName{Namespace: i.Namespace(), Name: RefreshTokenSecretName(token)}, &secret)
+	if apierrors.IsNotFound(err) || (err == nil && secret.Labels[RefreshTokenLabel] != "true") {
+		return nil, errInvalidRefreshToken
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to read refresh token: %w", err)
+	}
+	family := secret.Labels[FamilyLabel]
+	if _, used := secret.Annotations[UsedAtAnnotation]; used {
+		logf.FromContext(ctx).Info("Refresh token was used twice, revoking its login",
+			"user", secret.Labels[UserLabel], "family", family)
+		if err := i.revokeLogin(ctx, family); err != nil {
+			return nil, err
+		}
+		return nil, errInvalidRefreshToken
+	}
+	now := i.Now()
+	if expired(&secret, now) {
+		if err := i.revokeLogin(ctx, family); err != nil {
+			return nil, err
+		}
+		return nil, errInvalidRefreshToken
+	}
+	// The update carries the resourceVersion that was read, so only one of several requests
+	// presenting the token at the same time gets a new one
+	secret.Annotations[UsedAtAnnotation] = now.UTC().Format(time.RFC3339)
+	if err := i.Writer.Update(ctx, &secret); err != nil {
+		if apierrors.IsConflict(err) || apierrors.IsNotFound(err) {
+			return nil, errInvalidRefreshToken
+		}
+		return nil, fmt.Errorf("failed to mark refresh token used: %w", err)
+	}
+	// Only the token used last is kept to detect its reuse, so a login holds at most two
+	if err := i.deleteRefreshTokens(ctx, family, func(other *corev1.Secret) bool {
+		_, used := other.Annotations[UsedAtAnnotation]
+		return used && other.Name != secret.Name
+	}); err != nil {
+		return nil, err
+	}
+	return &secret, nil
+}
+
+// expired reports whether the refresh token or device authorization Secret is past its expiry or
+// has none
+func expired(secret *corev1.Secret, now time.Time) bool {
+	expiresAt, err := time.Parse(time.RFC3339, secret.Annotations[ExpiresAtAnnotation])
+	return err != nil || !now.Before(expiresAt)
+}
+
+// revokeLogin deletes the refresh tokens of the login family. Tokens already issued stay valid
+// until they expire.
+func (i *Issuer) revokeLogin(ctx context.Context, family string) error {
+	return i.deleteRefreshTokens(ctx, family, func(*corev1.Secret) bool { return true })
+}
+
+// deleteRefreshTokens deletes the refresh tokens of the login family that match
+func (i *Issuer) deleteRefreshTokens(ctx context.Context, family string, match func(*corev1.Secret) bool) error {
+	var secrets corev1.SecretList
+	if err := i.Secrets.List(ctx, &secrets, client.InNamespace(i.Namespace()),
+		client.MatchingLabels{RefreshTokenLabel: "true", FamilyLabel: family}); err != nil {
+		return fmt.Errorf("failed to list refresh tokens: %w", err)
+	}
+	for j := range secrets.Items {
+		if !match(&secrets.Items[j]) {
+			continue
+		}
+		if err := i.Writer.Delete(ctx, &secrets.Items[j]); err != nil && !apierrors.IsNotFound(err) {
+			return fmt.Errorf("failed to delete refresh token: %w", err)
+		}
+	}
+	return nil
+}
+
+// inactiveUser reports whether err rejects a User that is gone, suspended or revoked
+func inactiveUser(err error) bool {
+	return apierrors.IsUnauthorized(err) || apierrors.IsForbidden(err)
+}
+
+// revoke implements token revocation as in RFC 7009. Revoking a refresh token ends its login.
+// Unknown tokens are answered like revoked ones; tokens the issuer signed are not revocable,
+// since the API server verifies them on its own.
+func (i *Issuer) revoke(w http.ResponseWriter, req *http.Request) {
+	ctx := req.Context()
+	if err := req.ParseForm(); err != nil {
+		i.fail(w, req, &oauthError{http.StatusBadRequest, "invalid_request", "malformed form"})
+		return
+	}
+	if id := req.PostForm.Get("client_id"); id != "" && id != i.ClientID {
+		i.fail(w, req, &oauthError{http.StatusUnauthorized, "invalid_client", "unknown client " + id})
+		return
+	}
+	token := req.PostForm.Get("token")
+	if token == "" {
+		i.fail(w, req, &oauthError{http.StatusBadRequest, "invalid_request", "token is required"})
+		return
+	}
+	revoked, err := i.revokeRefreshToken(ctx, token)
+	if err != nil {
+		i.fail(w, req, err)
+		return
+	}
+	if !revoked && strings.Count(token, ".") == 2 {
+		i.fail(w, req, &oauthError{http.StatusBadRequest, "unsupported_token_type",
+			"ID and access tokens cannot be revoked; they stay valid until they expire"})
+		return
+	}
+	w.Header().Set("Cache-Control", "no-store")
+	w.WriteHeader(http.StatusOK)
+}
+
+// revokeRefreshToken ends the login of token and reports whether it was a refresh token
+func (i *Issuer) revokeRefreshToken(ctx context.Context, token string) (bool, error) {
+	if i.RefreshTTL <= 0 {
+		return false, nil
+	}
+	var secret corev1.Secret
+	err := i.Secrets.Get(ctx, types.NamespacedName{Namespace: i.Namespace(), Name: RefreshTokenSecretName(token)}, &secret)
+	if apierrors.IsNotFound(err) || (err == nil && secret.Labels[RefreshTokenLabel] != "true") {
+		return false, nil
+	}
+	if err != nil {
+		return false, fmt.Errorf("failed to read refresh token: %w", err)
+	}
+	if err := i.revokeLogin(ctx, secret.Labels[FamilyLabel]); err != nil {
+		return false, err
+	}
+	logf.FromContext(ctx).Info("Revoked refresh token", "user", secret.Labels[UserLabel], "family", secret.Labels[FamilyLabel])
+	return true, nil
+}
+
+// Sweep deletes expired device authorizations, the refresh tokens whose login ended, and those
+// of Users that are gone, suspended or revoked. They are rejected anyway; sweeping keeps them
+// from piling up.
+func (i *Issuer) Sweep(ctx context.Context) error {
+	now := i.Now()
+	var devices corev1.SecretList
+	if err := i.Secrets.List(ctx, &devices, client.InNamespace(i.Namespace()), client.MatchingLabels{DeviceCodeLabel: "true"}); err != nil {
+		return err
+	}
+	for j := range devices.Items {
+		if !expired(&devices.Items[j], now) {
+			continue
+		}
+		if err := i.Writer.Delete(ctx, &devices.Items[j]); err != nil && !apierrors.IsNotFound(err) {
+			return err
+		}
+	}
+
+	var secrets corev1.SecretList
+	if err := i.Secrets.List(ctx, &secrets, client.InNamespace(i.Namespace()), client.MatchingLabels{RefreshTokenLabel: "true"}); err != nil {
+		return err
+	}
+	inactive := map[string]bool{}
+	for j := range secrets.Items {
+		secret := &secrets.Items[j]
+		user := secret.Labels[UserLabel]
+		if _, checked := inactive[user]; !checked {
+			_, err := (&proxy.Proxy{Reader: i.Reader}).AuthenticateUser(ctx, user, "")
+			if err != nil && !inactiveUser(err) {
+				return err
+			}
+			inactive[user] = err != nil
+		}
+		if !inactive[user] && !expired(secret, now) {
+			continue
+		}
+		if err := i.Writer.Delete(ctx, secret); err != nil && !apierrors.IsNotFound(err) {
+			return err
+		}
+	}
+	return nil
+}
@@ -0,0 +1,215 @@
+/*
+Copyright 2025.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package oidc
+
+import (
+	"context"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/tls"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/json"
+	"math/big"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strings"
+	"time"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/types"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+
+	authv1alpha1 "github.com/openkube-hub/KubeUser/api/v1alpha1"
+)
+
+var _ = Describe("Refresh tokens", func() {
+	var (
+		now  time.Time
+		key  *rsa.PrivateKey
+		c    client.WithWatch
+		i    *Issuer
+		cert *x509.Certificate
+	)
+
+	BeforeEach(func() {
+		now = time.Date(2025, 6, 1, 12, 0, 0, 0, time.UTC)
+		var err error
+		key, err = rsa.GenerateKey(rand.Reader, 2048)
+		Expect(err).NotTo(HaveOccurred())
+		cert = &x509.Certificate{SerialNumber: big.NewInt(0x2a), Subject: pkix.Name{CommonName: "jane"}}
+		c = newClient(
+			&authv1alpha1.User{ObjectMeta: metav1.ObjectMeta{Name: "jane"}},
+			&authv1alpha1.IssuedCertificate{
+				ObjectMeta: metav1.ObjectMeta{Name: "jane-2a"},
+				Spec:       authv1alpha1.IssuedCertificateSpec{User: "jane", SerialNumber: "2a", CommonName: "jane"},
+			},
+			&authv1alpha1.Team{
+				ObjectMeta: metav1.ObjectMeta{Name: "platform"},
+				Spec:       authv1alpha1.TeamSpec{Members: []authv1alpha1.TeamMember{{Name: "jane"}}},
+			},
+		)
+		i, err = New(issuerURL, DefaultClientID, DefaultTokenTTL, c)
+		Expect(err).NotTo(HaveOccurred())
+		i.Now = func() time.Time { return now }
+		i.RefreshTTL, i.Secrets, i.Writer = DefaultRefreshTokenTTL, c, c
+		i.Namespace = func() string { return "kubeuser" }
+		i.SetKey(key)
+	})
+
+	post := func(path string, form url.Values, withCert bool) (*httptest.ResponseRecorder, map[string]any) {
+		req := httptest.NewRequest(http.MethodPost, issuerURL+path, strings.NewReader(form.Encode()))
+		req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+		if withCert {
+			req.TLS = &tls.ConnectionState{VerifiedChains: [][]*x509.Certificate{{cert}}}
+		}
+		rec := httptest.NewRecorder()
+		i.ServeHTTP(rec, req)
+		body := map[string]any{}
+		if rec.Body.Len() > 0 {
+			Expect(json.Unmarshal(rec.Body.Bytes(), &body)).To(Succeed())
+		}
+		return rec, body
+	}
+
+	login := func() string {
+		rec, body := post("/token", url.Values{"grant_type": {"client_credentials"}, "scope": {"openid offline_access"}}, true)
+		Expect(rec.Code).To(Equal(http.StatusOK), rec.Body.String())
+		Expect(body).To(HaveKey("refresh_token"))
+		return body["refresh_token"].(string)
+	}
+
+	refresh := func(token string) (*httptest.ResponseRecorder, map[string]any) {
+		return post("/token", url.Values{"grant_type": {"refresh_token"}, "refresh_token": {token}}, false)
+	}
+
+	refreshTokens := func() []corev1.Secret {
+		var secrets corev1.SecretList
+		Expect(c.List(context.Background(), &secrets, client.MatchingLabels{RefreshTokenLabel: "true"})).To(Succeed())
+		return secrets.Items
+	}
+
+	It("issues refresh tokens only with the offline_access scope", func() {
+		_, body := post("/token", url.Values{"grant_type": {"client_credentials"}}, true)
+		Expect(body).NotTo(HaveKey("refresh_token"))
+		Expect(refreshTokens()).To(BeEmpty())
+
+		token := login()
+		secrets := refreshTokens()
+		Expect(secrets).To(HaveLen(1))
+		Expect(secrets[0].Name).To(Equal(RefreshTokenSecretName(token)))
+		Expect(secrets[0].Labels).To(HaveKeyWithValue(UserLabel, "jane"))
+		Expect(secrets[0].Annotations).To(HaveKeyWithValue(ExpiresAtAnnotation, "2025-06-02T12:00:00Z"))
+		// Only a digest of the token is stored
+		Expect(secrets[0].Data).To(BeEmpty())
+	})
+
+	It("rotates refresh tokens and follows the current Teams of the User", func() {
+		first := login()
+
+		var platform authv1alpha1.Team
+		Expect(c.Get(context.Background(), types.NamespacedName{Name: "platform"}, &platform)).To(Succeed())
+		platform.Spec.Members = nil
+		Expect(c.Update(context.Background(), &platform)).To(Succeed())
+
+		now = now.Add(time.Hour)
+		rec, body := refresh(first)
+		Expect(rec.Code).To(Equal(http.StatusOK), rec.Body.String())
+		claims := verify(body["id_token"].(string), &key.PublicKey)
+		Expect(claims.Subject).To(Equal("jane"))
+		Expect(claims.Groups).To(BeEmpty())
+		Expect(claims.IssuedAt).To(Equal(now.Unix()))
+		second := body["refresh_token"].(string)
+		Expect(second).NotTo(Equal(first))
+
+		rec, body = refresh(second)
+		Expect(rec.Code).To(Equal(http.StatusOK), rec.Body.String())
+		third := body["refresh_token"].(string)
+		// The login holds the token used last and the current one
+		Expect(refreshTokens()).To(HaveLen(2))
+
+		// Refreshing does not extend the login
+		now = now.Add(DefaultRefreshTokenTTL)
+		rec, body = refresh(third)
+		Expect(rec.Code).To(Equal(http.StatusBadRequest))
+		Expect(body).To(HaveKeyWithValue("error", "invalid_grant"))
+		Expect(refreshTokens()).To(BeEmpty())
+	})
+
+	It("revokes the whole login when a used refresh token is presented again", func() {
+		first := login()
+		_, body := refresh(first)
+		second := body["refresh_token"].(string)
+
+		rec, body := refresh(first)
+		Expect(rec.Code).To(Equal(http.StatusBadRequest))
+		Expect(body).To(HaveKeyWithValue("error", "invalid_grant"))
+		Expect(refreshTokens()).To(BeEmpty())
+
+		rec, _ = refresh(second)
+		Expect(rec.Code).To(Equal(http.StatusBadRequest))
+	})
+
+	It("ends the login of a User that was suspended", func() {
+		token := login()
+		var user authv1alpha1.User
+		Expect(c.Get(context.Background(), types.NamespacedName{Name: "jane"}, &user)).To(Succeed())
+		user.Spec.Suspended = true
+		Expect(c.Update(context.Background(), &user)).To(Succeed())
+
+		rec, body := refresh(token)
+		Expect(rec.Code).To(Equal(http.StatusBadRequest))
+		Expect(body).To(HaveKeyWithValue("error", "invalid_grant"))
+		Expect(body).To(HaveKeyWithValue("error_description", ContainSubstring("suspended")))
+		Expect(refreshTokens()).To(BeEmpty())
+	})
+
+	It("revokes refresh tokens at the revocation endpoint", func() {
+		token := login()
+		rec, _ := post("/revoke", url.Values{"token": {token}, "token_type_hint": {"refresh_token"}}, false)
+		Expect(rec.Code).To(Equal(http.StatusOK))
+		Expect(refreshTokens()).To(BeEmpty())
+		rec, _ = refresh(token)
+		Expect(rec.Code).To(Equal(http.StatusBadRequest))
+
+		// Unknown tokens are answered like revoked ones
+		rec, _ = post("/revoke", url.Values{"token": {"unknown"}}, false)
+		Expect(rec.Code).To(Equal(http.StatusOK))
+
+		_, body := post("/token", url.Values{"grant_type": {"client_credentials"}}, true)
+		rec, body = post("/revoke", url.Values{"token": {body["id_token"].(string)}}, false)
+		Expect(rec.Code).To(Equal(http.StatusBadRequest))
+		Expect(body).To(HaveKeyWithValue("error", "unsupported_token_type"))
+	})
+
+	It("sweeps refresh tokens of ended logins and inactive Users", func() {
+		login()
+		Expect(i.Sweep(context.Background())).To(Succeed())
+		Expect(refreshTokens()).To(HaveLen(1))
+
+		var user authv1alpha1.User
+		Expect(c.Get(context.Background(), types.NamespacedName{Name: "jane"}, &user)).To(Succeed())
+		user.Spec.Revoked = true
+		Expect(c.Update(context.Background(), &user)).To(Succeed())
+		Expect(i.Sweep(context.Background())).To(Succeed())
+		Expect(refreshTokens()).To(BeEmpty())
+	})
+})
@@ -0,0 +1,118 @@
+/*
+Copyright 2025.
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+*/
+
+package oidc
+
+import (
+	"context"
+	"crypto/tls"
+	"crypto/x509"
+	"errors"
+	"fmt"
+	"net"
+	"net/http"
+	"path/filepath"
+	"time"
+
+	"sigs.k8s.io/controller-runtime/pkg/certwatcher"
+	logf "sigs.k8s.io/controller-runtime/pkg/log"
+)
+
+// sweepInterval is how often refresh tokens that can no longer be used are deleted
+const sweepInterval = 10 * time.Minute
+
+// Server serves an Issuer over TLS, requesting client certificates signed by the CA that signs
+// KubeUser certificates, and deletes refresh tokens that can no longer be used. The discovery
+// document and keys are served without a client certificate, since the API server fetches them
+// anonymously. It runs on every replica, not only the leader.
+type Server struct {
+	// Addr is the address the issuer listens on, e.g. :8446
+	Addr string
+	// CertDir, CertName and KeyName locate the issuer's serving certificate, which is reloaded
+	// when it changes
+	CertDir  string
+	CertName string
+	KeyName  string
+	// ClientCA returns the PEM bundle client certificates are verified against
+	ClientCA func(ctx context.Context) ([]byte, error)
+	// TLSOpts are applied to the TLS configuration, like those of the webhook server
+	TLSOpts []func(*tls.Config)
+
+	Issuer *Issuer
+}
+
+// NeedLeaderElection implements manager.LeaderElectionRunnable
+func (s *Server) NeedLeaderElection() bool {
+	return false
+}
+
+// Start implements manager.Runnable; it serves until ctx is done
+func (s *Server) Start(ctx context.Context) error {
+	logger := logf.FromContext(ctx).WithName("oidc-issuer")
+
+	caPEM, err := s.ClientCA(ctx)
+	if err != nil {
+		return fmt.Errorf("failed to resolve the client CA of the OIDC issuer: %w", err)
+	}
+	clientCAs := x509.NewCertPool()
+	if !clientCAs.AppendCertsFromPEM(caPEM) {
+		return errors.New("no certificates in the client CA of the OIDC issuer")
+	}
+
+	watcher, err := certwatcher.New(filepath.Join(s.CertDir, s.CertName), filepath.Join(s.CertDir, s.KeyName))
+	if err != nil {
+		return fmt.Errorf("failed to load the OIDC issuer certificate: %w", err)
+	}
+	go func() {
+		if err := watcher.Start(ctx); err != nil {
+			logger.Error(err, "Certificate watcher of the OIDC issuer failed")
+		}
+	}()
+
+	config := &tls.Config{
+		MinVersion:     tls.VersionTLS12,
+		GetCertificate: watcher.GetCertificate,
+		ClientCAs:      clientCAs,
+		// The discovery document and keys are fetched without a certificate
+		ClientAuth: tls.VerifyClientCertIfGiven,
+	}
+	for _, opt := range s.TLSOpts {
+		opt(config)
+	}
+	listener, err := tls.Listen("tcp", s.Addr, config)
+	if err != nil {
+		return fmt.Errorf("failed to listen for the OIDC issuer: %w", err)
+	}
+
+	server := &http.Server{
+		Handler:           s.Issuer,
+		ReadHeaderTimeout: 10 * time.Second,
+		BaseContext:       func(net.Listener) context.Context { return logf.IntoContext(context.Background(), logger) },
+	}
+	go func() {
+		ticker := time.NewTicker(sweepInterval)
+		defer ticker.Stop()
+		for {
+			select {
+			case <-ctx.Done():
+				shutdownCtx, cancel := context.WithTimeout(context.Background(), 30*time.Second)
+				defer cancel()
+				_ = server.Shutdown(shutdownCtx)
+				return
+			case <-ticker.C:
+				if err := s.Issuer.Sweep(ctx); err != nil {
+					logger.Error(err, "Failed to delete unusable refresh tokens")
+				}
+			}
+		}
+	}()
+
+	logger.Info("Serving OIDC issuer", "addr", s.Addr)
+	if err := server.Serve(listener); err != nil && !errors.Is(err, http.ErrServerClosed) {
+		return err
+	}
+	return nil
+}
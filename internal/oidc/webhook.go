@@ -0,0 +1,111 @@
+/*
+Copyright 2025.
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+*/
+
+package oidc
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"slices"
+	"time"
+
+	authenticationv1 "k8s.io/api/authentication/v1"
+	logf "sigs.k8s.io/controller-runtime/pkg/log"
+
+	"github.com/openkube-hub/KubeUser/internal/proxy"
+)
+
+// maxTokenReviewSize bounds the TokenReviews the webhook reads
+const maxTokenReviewSize = 1 << 20
+
+// authenticateToken answers the TokenReviews of API servers that authenticate KubeUser tokens
+// through --authentication-token-webhook-config-file instead of the --oidc-* flags. The User is
+// looked up on every review, so a token stops working as soon as its User is suspended or
+// revoked, and carries the Teams the User is a member of now rather than when it was issued.
+func (i *Issuer) authenticateToken(w http.ResponseWriter, req *http.Request) {
+	ctx := req.Context()
+	var review authenticationv1.TokenReview
+	if err := json.NewDecoder(io.LimitReader(req.Body, maxTokenReviewSize)).Decode(&review); err != nil {
+		http.Error(w, "malformed TokenReview", http.StatusBadRequest)
+		return
+	}
+
+	status, err := i.review(req, review.Spec)
+	if err != nil {
+		logf.FromContext(ctx).Error(err, "Failed to review token")
+		http.Error(w, "internal error", http.StatusInternalServerError)
+		return
+	}
+	if !status.Authenticated {
+		logf.FromContext(ctx).V(1).Info("Rejected token", "reason", status.Error)
+	}
+	// The response is of the version the API server sent
+	if review.APIVersion == "" {
+		review.APIVersion = authenticationv1.SchemeGroupVersion.String()
+	}
+	w.Header().Set("Content-Type", "application/json")
+	w.Header().Set("Cache-Control", "no-store")
+	_ = json.NewEncoder(w).Encode(&authenticationv1.TokenReview{
+		TypeMeta: review.TypeMeta,
+		Status:   status,
+	})
+}
+
+// review returns the status of a TokenReview of spec. Tokens that do not authenticate are an
+// unauthenticated status, not an error.
+func (i *Issuer) review(req *http.Request, spec authenticationv1.TokenReviewSpec) (authenticationv1.TokenReviewStatus, error) {
+	unauthenticated := func(reason string) (authenticationv1.TokenReviewStatus, error) {
+		return authenticationv1.TokenReviewStatus{Error: reason}, nil
+	}
+	if len(spec.Audiences) > 0 && !slices.Contains(spec.Audiences, i.ClientID) {
+		return unauthenticated(fmt.Sprintf("the token audience %s is not among the requested audiences", i.ClientID))
+	}
+	keys := i.publicKeys()
+	if keys == nil {
+		return authenticationv1.TokenReviewStatus{}, errors.New("the signing key is not loaded yet")
+	}
+	claims, err := parse(spec.Token, keys...)
+	if err != nil {
+		return unauthenticated(err.Error())
+	}
+	switch {
+	case claims.Issuer != i.URL:
+		return unauthenticated("the token was issued by " + claims.Issuer)
+	case claims.Audience != i.ClientID:
+		return unauthenticated("the token is for " + claims.Audience)
+	case !i.Now().Before(time.Unix(claims.Expiry, 0)):
+		return unauthenticated("the token expired")
+	case claims.User == "" || claims.Subject == "":
+		return unauthenticated("the token names no user")
+	}
+
+	identity, err := (&proxy.Proxy{Reader: i.Reader}).AuthenticateUser(req.Context(), claims.User, claims.Subject)
+	if inactiveUser(err) {
+		return unauthenticated(err.Error())
+	}
+	if err != nil {
+		return authenticationv1.TokenReviewStatus{}, err
+	}
+	groups, err := i.Groups(req.Context(), identity)
+	if err != nil {
+		return authenticationv1.TokenReviewStatus{}, err
+	}
+	status := authenticationv1.TokenReviewStatus{
+		Authenticated: true,
+		User: authenticationv1.UserInfo{
+			Username: identity.Username,
+			Groups:   groups,
+			Extra:    map[string]authenticationv1.ExtraValue{UserLabel: {identity.User}},
+		},
+	}
+	if len(spec.Audiences) > 0 {
+		status.Audiences = []string{i.ClientID}
+	}
+	return status, nil
+}
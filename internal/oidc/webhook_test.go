@@ -0,0 +1,174 @@
+/*
+Copyright 2025.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package oidc
+
+import (
+	"bytes"
+	"context"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/tls"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/json"
+	"math/big"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strings"
+	"time"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+	authenticationv1 "k8s.io/api/authentication/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/types"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+
+	authv1alpha1 "github.com/openkube-hub/KubeUser/api/v1alpha1"
+)
+
+var _ = Describe("TokenReview webhook", func() {
+	var (
+		now time.Time
+		key *rsa.PrivateKey
+		c   client.WithWatch
+		i   *Issuer
+	)
+
+	BeforeEach(func() {
+		now = time.Date(2025, 6, 1, 12, 0, 0, 0, time.UTC)
+		var err error
+		key, err = rsa.GenerateKey(rand.Reader, 2048)
+		Expect(err).NotTo(HaveOccurred())
+		c = newClient(
+			&authv1alpha1.User{
+				ObjectMeta: metav1.ObjectMeta{Name: "jane"},
+				Spec:       authv1alpha1.UserSpec{Certificate: &authv1alpha1.CertificateSubject{Organizations: []string{"developers"}}},
+			},
+			&authv1alpha1.IssuedCertificate{
+				ObjectMeta: metav1.ObjectMeta{Name: "jane-2a"},
+				Spec:       authv1alpha1.IssuedCertificateSpec{User: "jane", SerialNumber: "2a", CommonName: "jane"},
+			},
+			&authv1alpha1.Team{
+				ObjectMeta: metav1.ObjectMeta{Name: "platform"},
+				Spec:       authv1alpha1.TeamSpec{Members: []authv1alpha1.TeamMember{{Name: "jane"}}},
+			},
+		)
+		i, err = New(issuerURL, DefaultClientID, DefaultTokenTTL, c)
+		Expect(err).NotTo(HaveOccurred())
+		i.Now = func() time.Time { return now }
+		i.SetKey(key)
+	})
+
+	issue := func() string {
+		form := url.Values{"grant_type": {"client_credentials"}}
+		req := httptest.NewRequest(http.MethodPost, issuerURL+"/token", strings.NewReader(form.Encode()))
+		req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+		cert := &x509.Certificate{SerialNumber: big.NewInt(0x2a), Subject: pkix.Name{CommonName: "jane"}}
+		req.TLS = &tls.ConnectionState{VerifiedChains: [][]*x509.Certificate{{cert}}}
+		rec := httptest.NewRecorder()
+		i.ServeHTTP(rec, req)
+		Expect(rec.Code).To(Equal(http.StatusOK), rec.Body.String())
+		var body map[string]any
+		Expect(json.Unmarshal(rec.Body.Bytes(), &body)).To(Succeed())
+		return body["id_token"].(string)
+	}
+
+	review := func(token string, audiences ...string) authenticationv1.TokenReviewStatus {
+		data, err := json.Marshal(&authenticationv1.TokenReview{
+			TypeMeta: metav1.TypeMeta{APIVersion: "authentication.k8s.io/v1", Kind: "TokenReview"},
+			Spec:     authenticationv1.TokenReviewSpec{Token: token, Audiences: audiences},
+		})
+		Expect(err).NotTo(HaveOccurred())
+		rec := httptest.NewRecorder()
+		i.ServeHTTP(rec, httptest.NewRequest(http.MethodPost, issuerURL+"/authenticate", bytes.NewReader(data)))
+		Expect(rec.Code).To(Equal(http.StatusOK), rec.Body.String())
+		var response authenticationv1.TokenReview
+		Expect(json.Unmarshal(rec.Body.Bytes(), &response)).To(Succeed())
+		Expect(response.Kind).To(Equal("TokenReview"))
+		return response.Status
+	}
+
+	It("authenticates tokens as their user with its current groups", func() {
+		token := issue()
+		status := review(token)
+		Expect(status.Authenticated).To(BeTrue(), status.Error)
+		Expect(status.User.Username).To(Equal("jane"))
+		Expect(status.User.Groups).To(ConsistOf("developers", "kubeuser:team:platform"))
+		Expect(status.User.Extra).To(HaveKeyWithValue(UserLabel, authenticationv1.ExtraValue{"jane"}))
+
+		// Leaving a Team takes effect before the token expires
+		var platform authv1alpha1.Team
+		Expect(c.Get(context.Background(), types.NamespacedName{Name: "platform"}, &platform)).To(Succeed())
+		platform.Spec.Members = nil
+		Expect(c.Update(context.Background(), &platform)).To(Succeed())
+		Expect(review(token).User.Groups).To(ConsistOf("developers"))
+	})
+
+	It("stops authenticating the tokens of a suspended User", func() {
+		token := issue()
+		var user authv1alpha1.User
+		Expect(c.Get(context.Background(), types.NamespacedName{Name: "jane"}, &user)).To(Succeed())
+		user.Spec.Suspended = true
+		Expect(c.Update(context.Background(), &user)).To(Succeed())
+
+		status := review(token)
+		Expect(status.Authenticated).To(BeFalse())
+		Expect(status.Error).To(ContainSubstring("suspended"))
+	})
+
+	It("checks audiences", func() {
+		token := issue()
+		status := review(token, "https://kubernetes.default.svc", DefaultClientID)
+		Expect(status.Authenticated).To(BeTrue(), status.Error)
+		Expect(status.Audiences).To(Equal([]string{DefaultClientID}))
+		Expect(review(token, "https://kubernetes.default.svc").Authenticated).To(BeFalse())
+	})
+
+	It("rejects expired, foreign and tampered tokens", func() {
+		token := issue()
+
+		now = now.Add(DefaultTokenTTL)
+		Expect(review(token).Authenticated).To(BeFalse())
+		now = now.Add(-DefaultTokenTTL)
+
+		other, err := rsa.GenerateKey(rand.Reader, 2048)
+		Expect(err).NotTo(HaveOccurred())
+		foreign, err := sign(other, Claims{Issuer: issuerURL, Subject: "jane", Audience: DefaultClientID,
+			Expiry: now.Add(time.Hour).Unix(), User: "jane"})
+		Expect(err).NotTo(HaveOccurred())
+		Expect(review(foreign).Authenticated).To(BeFalse())
+
+		parts := strings.Split(token, ".")
+		claims, err := json.Marshal(Claims{Issuer: issuerURL, Subject: "admin", Audience: DefaultClientID,
+			Expiry: now.Add(time.Hour).Unix(), User: "jane"})
+		Expect(err).NotTo(HaveOccurred())
+		tampered := parts[0] + "." + base64URL(claims) + "." + parts[2]
+		Expect(review(tampered).Authenticated).To(BeFalse())
+
+		Expect(review("not-a-token").Authenticated).To(BeFalse())
+	})
+
+	It("keeps authenticating tokens of the previous key after a rotation", func() {
+		token := issue()
+		next, err := rsa.GenerateKey(rand.Reader, 2048)
+		Expect(err).NotTo(HaveOccurred())
+		Expect(i.SetKey(next)).To(BeTrue())
+		Expect(review(token).Authenticated).To(BeTrue())
+	})
+})
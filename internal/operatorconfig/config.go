@@ -0,0 +1,387 @@
+/*
+Copyright 2025.
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+*/
+
+// Package operatorconfig holds the operator-wide defaults in effect. They start out as the
+// controller's flags and are overridden by the KubeUserConfig named "default", which the
+// controller watches so changes apply without a restart.
+package operatorconfig
+
+import (
+	"errors"
+	"fmt"
+	"net/url"
+	"os"
+	"reflect"
+	"slices"
+	"strings"
+	"sync"
+	"time"
+
+	"k8s.io/apimachinery/pkg/util/validation"
+
+	authv1alpha1 "github.com/openkube-hub/KubeUser/api/v1alpha1"
+	"github.com/openkube-hub/KubeUser/internal/secretstore"
+)
+
+const (
+	// DefaultRotationThreshold is how long before expiry certificates are renewed by default
+	DefaultRotationThreshold = 30 * 24 * time.Hour
+	// DefaultBreakGlassDuration is how long break-glass access lasts by default
+	DefaultBreakGlassDuration = time.Hour
+	// MinBreakGlassDuration is the shortest certificate lifetime the Kubernetes CSR API issues
+	MinBreakGlassDuration = 10 * time.Minute
+	// DefaultKeyAlgorithm is the algorithm of generated user keys by default
+	DefaultKeyAlgorithm = authv1alpha1.KeyAlgorithmRSA2048
+	// DefaultNamespace is the KubeUser namespace when neither the KubeUserConfig,
+	// --kubeuser-namespace nor KUBEUSER_NAMESPACE name one
+	DefaultNamespace = "kubeuser"
+	// UsernamePlaceholder stands for the User's name in UsernameTemplate
+	UsernamePlaceholder = "{{.Name}}"
+	// MaxUsernameTemplateLength leaves room for the User's name in certificate common names
+	MaxUsernameTemplateLength = 64
+)
+
+// Settings are operator-wide defaults
+type Settings struct {
+	// CertificateDuration is the requested certificate lifetime; zero leaves it to the issuer
+	CertificateDuration time.Duration
+	// RotationThreshold is how long before expiry certificates are renewed
+	RotationThreshold time.Duration
+	// RotationThresholdPercent, if set, renews certificates once less than this percentage of
+	// their lifetime remains instead
+	RotationThresholdPercent int32
+	// BreakGlassDuration is how long break-glass access lasts
+	BreakGlassDuration time.Duration
+	// ExpiryWarnings are the warning windows before certificate expiry, longest first
+	ExpiryWarnings []time.Duration
+	// DeleteAfterExpiry is how long Expired Users are kept before they are deleted; zero keeps them
+	DeleteAfterExpiry time.Duration
+	// KeyAlgorithm is used for newly generated user keys
+	KeyAlgorithm authv1alpha1.KeyAlgorithm
+	// Namespace is where per-user resources are created; empty means KUBEUSER_NAMESPACE, see
+	// EffectiveNamespace
+	Namespace string
+	// APIServer is the API server URL in generated kubeconfigs; empty means discovery
+	APIServer string
+	// ProxyURL, TLSServerName and InsecureSkipTLSVerify are written into the cluster entry of
+	// generated kubeconfigs
+	ProxyURL              string
+	TLSServerName         string
+	InsecureSkipTLSVerify bool
+	// NotificationSinks receive user lifecycle notifications
+	NotificationSinks []authv1alpha1.NotificationSink
+	// CredentialStores receive copies of issued credentials
+	CredentialStores []authv1alpha1.CredentialStore
+	// UsernameTemplate builds the name the API server authenticates a User as from
+	// UsernamePlaceholder; empty uses the User's name as it is
+	UsernameTemplate string
+}
+
+// Username returns the name the API server authenticates the User name as: the common name of
+// its certificates and the subject of its bindings
+func (s Settings) Username(name string) string {
+	if s.UsernameTemplate == "" {
+		return name
+	}
+	return strings.Replace(s.UsernameTemplate, UsernamePlaceholder, name, 1)
+}
+
+// UserName returns the name of the User the API server authenticates as username, or false
+// when username does not follow UsernameTemplate
+func (s Settings) UserName(username string) (string, bool) {
+	if s.UsernameTemplate == "" {
+		return username, true
+	}
+	prefix, suffix, _ := strings.Cut(s.UsernameTemplate, UsernamePlaceholder)
+	name, ok := strings.CutPrefix(username, prefix)
+	if !ok {
+		return "", false
+	}
+	name, ok = strings.CutSuffix(name, suffix)
+	return name, ok && name != ""
+}
+
+// Defaults returns the built-in settings
+func Defaults() Settings {
+	return Settings{
+		RotationThreshold:  DefaultRotationThreshold,
+		BreakGlassDuration: DefaultBreakGlassDuration,
+		ExpiryWarnings:     DefaultExpiryWarnings(),
+		KeyAlgorithm:       DefaultKeyAlgorithm,
+	}
+}
+
+// DefaultExpiryWarnings returns the expiry warning windows used by default: 14 days, 7 days
+// and 24 hours before expiry
+func DefaultExpiryWarnings() []time.Duration {
+	return []time.Duration{14 * 24 * time.Hour, 7 * 24 * time.Hour, 24 * time.Hour}
+}
+
+// Merge returns defaults with the fields set in spec applied on top. A nil spec yields defaults.
+func Merge(defaults Settings, spec *authv1alpha1.KubeUserConfigSpec) (Settings, error) {
+	settings := defaults
+	if spec == nil {
+		return settings, nil
+	}
+	if spec.CertificateDuration != nil {
+		settings.CertificateDuration = spec.CertificateDuration.Duration
+	}
+	if spec.RotationThreshold != nil {
+		settings.RotationThreshold = spec.RotationThreshold.Duration
+	}
+	if spec.RotationThresholdPercent != nil {
+		settings.RotationThresholdPercent = *spec.RotationThresholdPercent
+	}
+	if spec.BreakGlassDuration != nil {
+		settings.BreakGlassDuration = spec.BreakGlassDuration.Duration
+	}
+	if len(spec.ExpiryWarnings) > 0 {
+		settings.ExpiryWarnings = make([]time.Duration, 0, len(spec.ExpiryWarnings))
+		for _, warning := range spec.ExpiryWarnings {
+			settings.ExpiryWarnings = append(settings.ExpiryWarnings, warning.Duration)
+		}
+		slices.Sort(settings.ExpiryWarnings)
+		slices.Reverse(settings.ExpiryWarnings)
+	}
+	if spec.DeleteAfterExpiry != nil {
+		settings.DeleteAfterExpiry = spec.DeleteAfterExpiry.Duration
+	}
+	if spec.KeyAlgorithm != "" {
+		settings.KeyAlgorithm = spec.KeyAlgorithm
+	}
+	if spec.Namespace != "" {
+		settings.Namespace = spec.Namespace
+	}
+	if spec.APIServer != "" {
+		settings.APIServer = spec.APIServer
+	}
+	if spec.ProxyURL != "" {
+		settings.ProxyURL = spec.ProxyURL
+	}
+	if spec.TLSServerName != "" {
+		settings.TLSServerName = spec.TLSServerName
+	}
+	if spec.InsecureSkipTLSVerify != nil {
+		settings.InsecureSkipTLSVerify = *spec.InsecureSkipTLSVerify
+	}
+	if len(spec.NotificationSinks) > 0 {
+		settings.NotificationSinks = spec.DeepCopy().NotificationSinks
+	}
+	if len(spec.CredentialStores) > 0 {
+		settings.CredentialStores = spec.DeepCopy().CredentialStores
+	}
+	if spec.UsernameTemplate != "" {
+		settings.UsernameTemplate = spec.UsernameTemplate
+	}
+	return settings, settings.Validate()
+}
+
+// Validate checks the settings for values the controller cannot work with
+func (s Settings) Validate() error {
+	var errs []error
+	if s.CertificateDuration < 0 {
+		errs = append(errs, fmt.Errorf("certificateDuration must not be negative"))
+	}
+	if s.RotationThreshold <= 0 {
+		errs = append(errs, fmt.Errorf("rotationThreshold must be positive"))
+	}
+	if s.CertificateDuration > 0 && s.RotationThresholdPercent == 0 && s.RotationThreshold >= s.CertificateDuration {
+		errs = append(errs, fmt.Errorf("rotationThreshold %s must be shorter than certificateDuration %s",
+			s.RotationThreshold, s.CertificateDuration))
+	}
+	if s.RotationThresholdPercent < 0 || s.RotationThresholdPercent > 99 {
+		errs = append(errs, fmt.Errorf("rotationThresholdPercent %d must be between 1 and 99", s.RotationThresholdPercent))
+	}
+	if s.BreakGlassDuration < MinBreakGlassDuration {
+		errs = append(errs, fmt.Errorf("breakGlassDuration %s must be at least %s", s.BreakGlassDuration, MinBreakGlassDuration))
+	}
+	if s.DeleteAfterExpiry < 0 {
+		errs = append(errs, fmt.Errorf("deleteAfterExpiry must not be negative"))
+	}
+	for i, warning := range s.ExpiryWarnings {
+		if warning <= 0 {
+			errs = append(errs, fmt.Errorf("expiryWarnings must be positive, got %s", warning))
+		} else if i > 0 && warning == s.ExpiryWarnings[i-1] {
+			errs = append(errs, fmt.Errorf("duplicate expiryWarning %s", warning))
+		}
+	}
+	switch s.KeyAlgorithm {
+	case authv1alpha1.KeyAlgorithmRSA2048, authv1alpha1.KeyAlgorithmRSA4096,
+		authv1alpha1.KeyAlgorithmECDSAP256, authv1alpha1.KeyAlgorithmECDSAP384:
+	default:
+		errs = append(errs, fmt.Errorf("unknown keyAlgorithm %q", s.KeyAlgorithm))
+	}
+	if s.Namespace != "" {
+		if msgs := validation.IsDNS1123Label(s.Namespace); len(msgs) > 0 {
+			errs = append(errs, fmt.Errorf("invalid namespace %q: %s", s.Namespace, strings.Join(msgs, ", ")))
+		}
+	}
+	if s.APIServer != "" {
+		if u, err := url.Parse(s.APIServer); err != nil || u.Scheme != "https" || u.Host == "" {
+			errs = append(errs, fmt.Errorf("invalid apiServer %q: must be an https:// URL", s.APIServer))
+		}
+	}
+	if err := ValidateProxyURL(s.ProxyURL); err != nil {
+		errs = append(errs, fmt.Errorf("invalid proxyURL: %w", err))
+	}
+	if s.TLSServerName != "" {
+		if msgs := validation.IsDNS1123Subdomain(s.TLSServerName); len(msgs) > 0 {
+			errs = append(errs, fmt.Errorf("invalid tlsServerName %q: %s", s.TLSServerName, strings.Join(msgs, ", ")))
+		}
+	}
+	if err := ValidateUsernameTemplate(s.UsernameTemplate); err != nil {
+		errs = append(errs, fmt.Errorf("invalid usernameTemplate: %w", err))
+	}
+	seen := map[string]bool{}
+	for _, sink := range s.NotificationSinks {
+		if seen[sink.Name] {
+			errs = append(errs, fmt.Errorf("duplicate notification sink %q", sink.Name))
+		}
+		seen[sink.Name] = true
+		if sink.AttachKubeconfig && sink.Channel != "email" {
+			errs = append(errs, fmt.Errorf("notification sink %q: attachKubeconfig needs the email channel", sink.Name))
+		}
+	}
+	seen = map[string]bool{}
+	for _, store := range s.CredentialStores {
+		if seen[store.Name] {
+			errs = append(errs, fmt.Errorf("duplicate credential store %q", store.Name))
+		}
+		seen[store.Name] = true
+		if _, err := secretstore.ParsePath(store.Path); err != nil {
+			errs = append(errs, fmt.Errorf("credential store %q: invalid path: %w", store.Name, err))
+		}
+		if store.Vault != nil && store.SecretRef != "" {
+			errs = append(errs, fmt.Errorf("credential store %q: vault stores use the controller's Vault login, not a secretRef", store.Name))
+		}
+	}
+	return errors.Join(errs...)
+}
+
+// ValidateProxyURL checks a kubeconfig proxy-url is an http, https or socks5 URL; empty is valid
+func ValidateProxyURL(proxyURL string) error {
+	if proxyURL == "" {
+		return nil
+	}
+	u, err := url.Parse(proxyURL)
+	if err != nil {
+		return err
+	}
+	switch u.Scheme {
+	case "http", "https", "socks5":
+	default:
+		return fmt.Errorf("%q must be an http, https or socks5 URL", proxyURL)
+	}
+	if u.Host == "" {
+		return fmt.Errorf("%q has no host", proxyURL)
+	}
+	return nil
+}
+
+// ValidateUsernameTemplate checks a username template holds UsernamePlaceholder exactly once
+// and nothing else that needs rendering, and does not build names in the API server's reserved
+// system: namespace; empty is valid
+func ValidateUsernameTemplate(template string) error {
+	if template == "" {
+		return nil
+	}
+	if strings.Count(template, UsernamePlaceholder) != 1 {
+		return fmt.Errorf("%q must contain %s exactly once", template, UsernamePlaceholder)
+	}
+	literal := strings.Replace(template, UsernamePlaceholder, "", 1)
+	if strings.Contains(literal, "{{") || strings.Contains(literal, "}}") {
+		return fmt.Errorf("%q may contain no other template actions than %s", template, UsernamePlaceholder)
+	}
+	if strings.ContainsAny(literal, " \t\n") {
+		return fmt.Errorf("%q must not contain whitespace", template)
+	}
+	if len(literal) > MaxUsernameTemplateLength {
+		return fmt.Errorf("%q is longer than %d characters besides %s", template, MaxUsernameTemplateLength, UsernamePlaceholder)
+	}
+	if strings.HasPrefix(template, "system:") {
+		return fmt.Errorf("%q uses the API server's reserved prefix system:", template)
+	}
+	return nil
+}
+
+// Store holds the settings in effect. It is safe for concurrent use.
+type Store struct {
+	mu       sync.RWMutex
+	defaults Settings
+	current  Settings
+}
+
+// DefaultStore is the process-wide store read by the controllers
+var DefaultStore = NewStore(Defaults())
+
+// NewStore returns a store with defaults in effect
+func NewStore(defaults Settings) *Store {
+	return &Store{defaults: defaults, current: defaults}
+}
+
+// Current returns the settings in effect on the default store
+func Current() Settings {
+	return DefaultStore.Get()
+}
+
+// Namespace returns the namespace per-user resources are created in according to the settings
+// in effect on the default store
+func Namespace() string {
+	return Current().EffectiveNamespace()
+}
+
+// UserName returns the User the API server authenticates as username according to the
+// settings in effect on the default store, see Settings.UserName
+func UserName(username string) (string, bool) {
+	return Current().UserName(username)
+}
+
+// EffectiveNamespace returns the namespace per-user resources are created in: s.Namespace, then
+// KUBEUSER_NAMESPACE, then DefaultNamespace
+func (s Settings) EffectiveNamespace() string {
+	if s.Namespace != "" {
+		return s.Namespace
+	}
+	if namespace := os.Getenv("KUBEUSER_NAMESPACE"); namespace != "" {
+		return namespace
+	}
+	return DefaultNamespace
+}
+
+// Get returns the settings in effect
+func (s *Store) Get() Settings {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	return s.current
+}
+
+// SetDefaults replaces the settings a KubeUserConfig is applied on top of, typically from flags
+// at startup. It also resets the settings in effect until the next Apply.
+func (s *Store) SetDefaults(defaults Settings) error {
+	if err := defaults.Validate(); err != nil {
+		return err
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.defaults = defaults
+	s.current = defaults
+	return nil
+}
+
+// Apply puts spec into effect on top of the defaults; a nil spec restores the defaults.
+// An invalid spec is rejected and the settings in effect are kept. Apply reports whether
+// the settings changed.
+func (s *Store) Apply(spec *authv1alpha1.KubeUserConfigSpec) (bool, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	settings, err := Merge(s.defaults, spec)
+	if err != nil {
+		return false, err
+	}
+	changed := !reflect.DeepEqual(settings, s.current)
+	s.current = settings
+	return changed, nil
+}
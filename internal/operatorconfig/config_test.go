@@ -0,0 +1,249 @@
+/*
+Copyright 2025.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package operatorconfig
+
+import (
+	"time"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+
+	authv1alpha1 "github.com/openkube-hub/KubeUser/api/v1alpha1"
+)
+
+var _ = Describe("Store", func() {
+	var store *Store
+
+	BeforeEach(func() {
+		defaults := Defaults()
+		defaults.APIServer = "https://flag.example.com"
+		store = NewStore(Defaults())
+		Expect(store.SetDefaults(defaults)).To(Succeed())
+	})
+
+	It("applies set fields on top of the defaults", func() {
+		changed, err := store.Apply(&authv1alpha1.KubeUserConfigSpec{
+			CertificateDuration: &metav1.Duration{Duration: 24 * time.Hour},
+			RotationThreshold:   &metav1.Duration{Duration: 6 * time.Hour},
+			KeyAlgorithm:        authv1alpha1.KeyAlgorithmECDSAP256,
+		})
+		Expect(err).NotTo(HaveOccurred())
+		Expect(changed).To(BeTrue())
+
+		settings := store.Get()
+		Expect(settings.CertificateDuration).To(Equal(24 * time.Hour))
+		Expect(settings.RotationThreshold).To(Equal(6 * time.Hour))
+		Expect(settings.KeyAlgorithm).To(Equal(authv1alpha1.KeyAlgorithmECDSAP256))
+		Expect(settings.APIServer).To(Equal("https://flag.example.com"))
+	})
+
+	It("reports whether anything changed", func() {
+		spec := &authv1alpha1.KubeUserConfigSpec{APIServer: "https://api.example.com:6443"}
+		changed, err := store.Apply(spec)
+		Expect(err).NotTo(HaveOccurred())
+		Expect(changed).To(BeTrue())
+
+		changed, err = store.Apply(spec)
+		Expect(err).NotTo(HaveOccurred())
+		Expect(changed).To(BeFalse())
+	})
+
+	It("restores the defaults when the config is removed", func() {
+		_, err := store.Apply(&authv1alpha1.KubeUserConfigSpec{Namespace: "identity"})
+		Expect(err).NotTo(HaveOccurred())
+		Expect(store.Get().Namespace).To(Equal("identity"))
+
+		changed, err := store.Apply(nil)
+		Expect(err).NotTo(HaveOccurred())
+		Expect(changed).To(BeTrue())
+		Expect(store.Get().Namespace).To(BeEmpty())
+	})
+
+	It("keeps the settings in effect when the spec is invalid", func() {
+		_, err := store.Apply(&authv1alpha1.KubeUserConfigSpec{KeyAlgorithm: authv1alpha1.KeyAlgorithmRSA4096})
+		Expect(err).NotTo(HaveOccurred())
+
+		_, err = store.Apply(&authv1alpha1.KubeUserConfigSpec{
+			CertificateDuration: &metav1.Duration{Duration: 24 * time.Hour},
+			RotationThreshold:   &metav1.Duration{Duration: 48 * time.Hour},
+		})
+		Expect(err).To(MatchError(ContainSubstring("must be shorter than certificateDuration")))
+		Expect(store.Get().KeyAlgorithm).To(Equal(authv1alpha1.KeyAlgorithmRSA4096))
+	})
+
+	It("renews at a percentage of the lifetime instead of the rotation threshold", func() {
+		percent := int32(75)
+		_, err := store.Apply(&authv1alpha1.KubeUserConfigSpec{
+			CertificateDuration:      &metav1.Duration{Duration: 24 * time.Hour},
+			RotationThresholdPercent: &percent,
+		})
+		Expect(err).NotTo(HaveOccurred())
+		Expect(store.Get().RotationThresholdPercent).To(Equal(int32(75)))
+
+		percent = 100
+		_, err = store.Apply(&authv1alpha1.KubeUserConfigSpec{RotationThresholdPercent: &percent})
+		Expect(err).To(MatchError(ContainSubstring("rotationThresholdPercent 100 must be between 1 and 99")))
+	})
+
+	It("rejects break-glass durations shorter than the CSR API issues", func() {
+		_, err := store.Apply(&authv1alpha1.KubeUserConfigSpec{
+			BreakGlassDuration: &metav1.Duration{Duration: 5 * time.Minute},
+		})
+		Expect(err).To(MatchError(ContainSubstring("breakGlassDuration 5m0s must be at least 10m0s")))
+		Expect(store.Get().BreakGlassDuration).To(Equal(DefaultBreakGlassDuration))
+	})
+
+	It("keeps expired users unless deleteAfterExpiry is set", func() {
+		Expect(store.Get().DeleteAfterExpiry).To(BeZero())
+		_, err := store.Apply(&authv1alpha1.KubeUserConfigSpec{
+			DeleteAfterExpiry: &metav1.Duration{Duration: 720 * time.Hour},
+		})
+		Expect(err).NotTo(HaveOccurred())
+		Expect(store.Get().DeleteAfterExpiry).To(Equal(720 * time.Hour))
+
+		_, err = store.Apply(&authv1alpha1.KubeUserConfigSpec{
+			DeleteAfterExpiry: &metav1.Duration{Duration: -time.Hour},
+		})
+		Expect(err).To(MatchError(ContainSubstring("deleteAfterExpiry must not be negative")))
+	})
+
+	It("orders expiry warnings longest first and rejects invalid ones", func() {
+		Expect(store.Get().ExpiryWarnings).To(Equal(DefaultExpiryWarnings()))
+		_, err := store.Apply(&authv1alpha1.KubeUserConfigSpec{ExpiryWarnings: []metav1.Duration{
+			{Duration: time.Hour}, {Duration: 72 * time.Hour}, {Duration: 12 * time.Hour},
+		}})
+		Expect(err).NotTo(HaveOccurred())
+		Expect(store.Get().ExpiryWarnings).To(Equal([]time.Duration{72 * time.Hour, 12 * time.Hour, time.Hour}))
+
+		_, err = store.Apply(&authv1alpha1.KubeUserConfigSpec{ExpiryWarnings: []metav1.Duration{
+			{Duration: time.Hour}, {Duration: time.Hour}, {Duration: -time.Hour},
+		}})
+		Expect(err).To(MatchError(ContainSubstring("duplicate expiryWarning 1h0m0s")))
+		Expect(err).To(MatchError(ContainSubstring("expiryWarnings must be positive")))
+	})
+
+	It("rejects invalid defaults", func() {
+		defaults := Defaults()
+		defaults.APIServer = "http://insecure.example.com"
+		Expect(store.SetDefaults(defaults)).To(MatchError(ContainSubstring("must be an https:// URL")))
+	})
+
+	It("applies and validates kubeconfig connection settings", func() {
+		insecure := true
+		_, err := store.Apply(&authv1alpha1.KubeUserConfigSpec{
+			ProxyURL:              "socks5://proxy.example.com:1080",
+			TLSServerName:         "api.internal.example.com",
+			InsecureSkipTLSVerify: &insecure,
+		})
+		Expect(err).NotTo(HaveOccurred())
+		Expect(store.Get().ProxyURL).To(Equal("socks5://proxy.example.com:1080"))
+		Expect(store.Get().TLSServerName).To(Equal("api.internal.example.com"))
+		Expect(store.Get().InsecureSkipTLSVerify).To(BeTrue())
+
+		_, err = store.Apply(&authv1alpha1.KubeUserConfigSpec{ProxyURL: "ftp://proxy.example.com", TLSServerName: "Not_A_Name"})
+		Expect(err).To(MatchError(ContainSubstring("must be an http, https or socks5 URL")))
+		Expect(err).To(MatchError(ContainSubstring("invalid tlsServerName")))
+	})
+
+	It("rejects duplicate notification sinks", func() {
+		_, err := store.Apply(&authv1alpha1.KubeUserConfigSpec{NotificationSinks: []authv1alpha1.NotificationSink{
+			{Name: "ops", Channel: "slack"},
+			{Name: "ops", Channel: "email"},
+		}})
+		Expect(err).To(MatchError(ContainSubstring(`duplicate notification sink "ops"`)))
+	})
+
+	It("only attaches kubeconfigs to email", func() {
+		_, err := store.Apply(&authv1alpha1.KubeUserConfigSpec{NotificationSinks: []authv1alpha1.NotificationSink{
+			{Name: "mail", Channel: "email", AttachKubeconfig: true},
+			{Name: "ops", Channel: "slack", AttachKubeconfig: true},
+		}})
+		Expect(err).To(MatchError(ContainSubstring(`notification sink "ops": attachKubeconfig needs the email channel`)))
+	})
+
+	It("validates credential stores", func() {
+		_, err := store.Apply(&authv1alpha1.KubeUserConfigSpec{CredentialStores: []authv1alpha1.CredentialStore{
+			{Name: "vault", Path: "kubeuser/{{ .User }}", Vault: &authv1alpha1.VaultKVStore{}},
+		}})
+		Expect(err).NotTo(HaveOccurred())
+		Expect(store.Get().CredentialStores).To(HaveLen(1))
+
+		_, err = store.Apply(&authv1alpha1.KubeUserConfigSpec{CredentialStores: []authv1alpha1.CredentialStore{
+			{Name: "vault", Path: "kubeuser/{{ .Group }}", Vault: &authv1alpha1.VaultKVStore{}, SecretRef: "creds"},
+			{Name: "vault", Path: "kubeuser", AWSSecretsManager: &authv1alpha1.AWSSecretsManagerStore{Region: "eu-west-1"}},
+		}})
+		Expect(err).To(MatchError(ContainSubstring(`duplicate credential store "vault"`)))
+		Expect(err).To(MatchError(ContainSubstring(`credential store "vault": invalid path`)))
+		Expect(err).To(MatchError(ContainSubstring("not a secretRef")))
+	})
+
+	It("names users by the username template", func() {
+		Expect(store.Get().Username("jane")).To(Equal("jane"))
+
+		_, err := store.Apply(&authv1alpha1.KubeUserConfigSpec{UsernameTemplate: "kubeuser:{{.Name}}"})
+		Expect(err).NotTo(HaveOccurred())
+		settings := store.Get()
+		Expect(settings.Username("jane")).To(Equal("kubeuser:jane"))
+		name, ok := settings.UserName("kubeuser:jane")
+		Expect(ok).To(BeTrue())
+		Expect(name).To(Equal("jane"))
+		_, ok = settings.UserName("jane")
+		Expect(ok).To(BeFalse())
+		_, ok = settings.UserName("kubeuser:")
+		Expect(ok).To(BeFalse())
+	})
+
+	It("rejects invalid username templates", func() {
+		for _, template := range []string{"kubeuser", "{{.Name}}:{{.Name}}", "kube user:{{.Name}}",
+			"{{.Group}}:{{.Name}}", "system:kubeuser:{{.Name}}"} {
+			_, err := store.Apply(&authv1alpha1.KubeUserConfigSpec{UsernameTemplate: template})
+			Expect(err).To(MatchError(ContainSubstring("usernameTemplate")), template)
+		}
+		Expect(store.Get().UsernameTemplate).To(BeEmpty())
+	})
+})
+
+var _ = Describe("Namespace", func() {
+	AfterEach(func() {
+		Expect(DefaultStore.SetDefaults(Defaults())).To(Succeed())
+	})
+
+	It("prefers the KubeUserConfig over KUBEUSER_NAMESPACE", func() {
+		GinkgoT().Setenv("KUBEUSER_NAMESPACE", "")
+		Expect(Namespace()).To(Equal(DefaultNamespace))
+
+		GinkgoT().Setenv("KUBEUSER_NAMESPACE", "identity")
+		Expect(Namespace()).To(Equal("identity"))
+
+		_, err := DefaultStore.Apply(&authv1alpha1.KubeUserConfigSpec{Namespace: "access"})
+		Expect(err).NotTo(HaveOccurred())
+		Expect(Namespace()).To(Equal("access"))
+	})
+
+	It("prefers the --kubeuser-namespace default over KUBEUSER_NAMESPACE", func() {
+		GinkgoT().Setenv("KUBEUSER_NAMESPACE", "identity")
+		defaults := Defaults()
+		defaults.Namespace = "team-a-kubeuser"
+		Expect(DefaultStore.SetDefaults(defaults)).To(Succeed())
+		Expect(Namespace()).To(Equal("team-a-kubeuser"))
+
+		_, err := DefaultStore.Apply(&authv1alpha1.KubeUserConfigSpec{Namespace: "access"})
+		Expect(err).NotTo(HaveOccurred())
+		Expect(Namespace()).To(Equal("access"))
+	})
+})
@@ -0,0 +1,126 @@
+/*
+Copyright 2025.
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+*/
+
+// Package operatorstatus publishes operator-wide status to a ConfigMap in the KubeUser namespace.
+package operatorstatus
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+
+	corev1 "k8s.io/api/core/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/api/meta"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/client-go/util/retry"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+)
+
+const (
+	// ConfigMapName is the name of the operator status ConfigMap
+	ConfigMapName = "kubeuser-operator-status"
+
+	// KeyCASource records which CA source is currently used for kubeconfigs
+	KeyCASource = "caSource"
+	// KeyConditions holds the JSON encoded operator conditions
+	KeyConditions = "conditions"
+)
+
+// Reporter writes keys and conditions to the operator status ConfigMap
+type Reporter struct {
+	Client    client.Client
+	Namespace string
+}
+
+// Set records a single key, creating the ConfigMap if needed. Unchanged values are not written again.
+func (r *Reporter) Set(ctx context.Context, key, value string) error {
+	return r.mutate(ctx, func(data map[string]string) {
+		data[key] = value
+	})
+}
+
+// SetCondition adds or updates an operator condition
+func (r *Reporter) SetCondition(ctx context.Context, condition metav1.Condition) error {
+	var mutateErr error
+	err := r.mutate(ctx, func(data map[string]string) {
+		var conditions []metav1.Condition
+		if raw := data[KeyConditions]; raw != "" {
+			if err := json.Unmarshal([]byte(raw), &conditions); err != nil {
+				mutateErr = fmt.Errorf("failed to decode operator conditions: %w", err)
+				return
+			}
+		}
+		if !meta.SetStatusCondition(&conditions, condition) {
+			return
+		}
+		raw, err := json.Marshal(conditions)
+		if err != nil {
+			mutateErr = fmt.Errorf("failed to encode operator conditions: %w", err)
+			return
+		}
+		data[KeyConditions] = string(raw)
+	})
+	if mutateErr != nil {
+		return mutateErr
+	}
+	return err
+}
+
+// mutate applies fn to the ConfigMap data. Every replica reports, so a write that raced with
+// another replica's is retried on the latest ConfigMap.
+func (r *Reporter) mutate(ctx context.Context, fn func(map[string]string)) error {
+	return retry.OnError(retry.DefaultBackoff, func(err error) bool {
+		return apierrors.IsConflict(err) || apierrors.IsAlreadyExists(err)
+	}, func() error {
+		return r.mutateOnce(ctx, fn)
+	})
+}
+
+func (r *Reporter) mutateOnce(ctx context.Context, fn func(map[string]string)) error {
+	var cm corev1.ConfigMap
+	err := r.Client.Get(ctx, types.NamespacedName{Name: ConfigMapName, Namespace: r.Namespace}, &cm)
+	if apierrors.IsNotFound(err) {
+		cm = corev1.ConfigMap{
+			ObjectMeta: metav1.ObjectMeta{
+				Name:      ConfigMapName,
+				Namespace: r.Namespace,
+				Labels:    map[string]string{"app.kubernetes.io/managed-by": "kubeuser"},
+			},
+			Data: map[string]string{},
+		}
+		fn(cm.Data)
+		return r.Client.Create(ctx, &cm)
+	} else if err != nil {
+		return err
+	}
+
+	original := make(map[string]string, len(cm.Data))
+	for k, v := range cm.Data {
+		original[k] = v
+	}
+	if cm.Data == nil {
+		cm.Data = map[string]string{}
+	}
+	fn(cm.Data)
+	if equalData(original, cm.Data) {
+		return nil
+	}
+	return r.Client.Update(ctx, &cm)
+}
+
+func equalData(a, b map[string]string) bool {
+	if len(a) != len(b) {
+		return false
+	}
+	for k, v := range a {
+		if bv, ok := b[k]; !ok || bv != v {
+			return false
+		}
+	}
+	return true
+}
@@ -0,0 +1,198 @@
+/*
+Copyright 2025.
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+*/
+
+package pgp
+
+import (
+	"bytes"
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/sha1"
+	"crypto/sha256"
+	"crypto/sha512"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"hash"
+)
+
+// Symmetric algorithms and hashes
+const (
+	cipherAES128 = 7
+	cipherAES192 = 8
+	cipherAES256 = 9
+	hashSHA256   = 8
+	hashSHA384   = 9
+	hashSHA512   = 10
+)
+
+// Encrypt encrypts plaintext to recipients as an ASCII-armored OpenPGP message
+func Encrypt(plaintext []byte, recipients []*Recipient) ([]byte, error) {
+	if len(recipients) == 0 {
+		return nil, errors.New("pgp: no recipients")
+	}
+	sessionKey := make([]byte, 32)
+	if _, err := rand.Read(sessionKey); err != nil {
+		return nil, err
+	}
+
+	var message []byte
+	for _, recipient := range recipients {
+		body, err := encryptSessionKey(recipient, sessionKey)
+		if err != nil {
+			return nil, fmt.Errorf("pgp: encrypting to %s: %w", recipient.Fingerprint(), err)
+		}
+		message = appendPacket(message, tagPKESK, body)
+	}
+
+	// The encrypted data is a random block with its last two bytes repeated, the literal data
+	// packet and a SHA-1 modification detection code over all of it
+	literal := append([]byte{'b', 0, 0, 0, 0, 0}, plaintext...)
+	prefix := make([]byte, aes.BlockSize, aes.BlockSize+2)
+	if _, err := rand.Read(prefix); err != nil {
+		return nil, err
+	}
+	data := append(prefix, prefix[aes.BlockSize-2:]...)
+	data = appendPacket(data, tagLiteralData, literal)
+	data = append(data, 0xc0|tagModDetectCode, sha1.Size)
+	mdc := sha1.Sum(data)
+	data = append(data, mdc[:]...)
+
+	block, err := aes.NewCipher(sessionKey)
+	if err != nil {
+		return nil, err
+	}
+	encrypted := make([]byte, 1+len(data))
+	encrypted[0] = 1
+	// OpenPGP requires CFB; the modification detection code authenticates the data
+	cipher.NewCFBEncrypter(block, make([]byte, aes.BlockSize)).XORKeyStream(encrypted[1:], data) // nolint:staticcheck
+	message = appendPacket(message, tagSEIPD, encrypted)
+	return encodeArmor(message, armorMessage), nil
+}
+
+// IsMessage reports whether data is an ASCII-armored OpenPGP message
+func IsMessage(data []byte) bool {
+	return bytes.HasPrefix(data, []byte("-----BEGIN "+armorMessage+"-----"))
+}
+
+// encryptSessionKey returns the body of a public-key encrypted session key packet
+func encryptSessionKey(r *Recipient, sessionKey []byte) ([]byte, error) {
+	// The session key is sent with its algorithm and a checksum
+	var checksum uint16
+	for _, b := range sessionKey {
+		checksum += uint16(b)
+	}
+	key := append([]byte{cipherAES256}, sessionKey...)
+	key = binary.BigEndian.AppendUint16(key, checksum)
+
+	body := append([]byte{3}, r.keyID()...)
+	body = append(body, r.algorithm)
+	switch r.algorithm {
+	case algorithmRSA, algorithmRSAEncrypt:
+		// OpenPGP requires PKCS #1 v1.5 for RSA
+		encrypted, err := rsa.EncryptPKCS1v15(rand.Reader, r.rsa, key) // nolint:staticcheck
+		if err != nil {
+			return nil, err
+		}
+		return appendMPI(body, encrypted), nil
+	case algorithmECDH:
+		ephemeral, err := r.curve.curve.GenerateKey(rand.Reader)
+		if err != nil {
+			return nil, err
+		}
+		shared, err := ephemeral.ECDH(r.ecdh)
+		if err != nil {
+			return nil, err
+		}
+		kek, err := r.ecdhKEK(shared)
+		if err != nil {
+			return nil, err
+		}
+		// PKCS #5 padding to the 8 byte blocks of the key wrap
+		padding := 8 - len(key)%8
+		wrapped, err := keyWrap(kek, append(key, bytes.Repeat([]byte{byte(padding)}, padding)...))
+		if err != nil {
+			return nil, err
+		}
+		body = appendMPI(body, append(bytes.Clone(r.curve.prefix), ephemeral.PublicKey().Bytes()...))
+		body = append(body, byte(len(wrapped)))
+		return append(body, wrapped...), nil
+	default:
+		return nil, fmt.Errorf("unsupported public key algorithm %d", r.algorithm)
+	}
+}
+
+// ecdhKEK derives the key encryption key from the ECDH shared secret (RFC 6637, section 7)
+func (r *Recipient) ecdhKEK(shared []byte) ([]byte, error) {
+	newHash, err := kdfHashFunc(r.kdfHash)
+	if err != nil {
+		return nil, err
+	}
+	size, err := cipherKeySize(r.kdfCipher)
+	if err != nil {
+		return nil, err
+	}
+	param := append([]byte{byte(len(r.oid))}, r.oid...)
+	param = append(param, algorithmECDH, 3, 1, r.kdfHash, r.kdfCipher)
+	param = append(param, "Anonymous Sender    "...)
+	param = append(param, r.fingerprint...)
+
+	h := newHash()
+	h.Write([]byte{0, 0, 0, 1})
+	h.Write(shared)
+	h.Write(param)
+	return h.Sum(nil)[:size], nil
+}
+
+// keyWrap is the AES key wrap of RFC 3394
+func keyWrap(kek, plaintext []byte) ([]byte, error) {
+	block, err := aes.NewCipher(kek)
+	if err != nil {
+		return nil, err
+	}
+	n := len(plaintext) / 8
+	out := make([]byte, 8+len(plaintext))
+	copy(out, bytes.Repeat([]byte{0xa6}, 8))
+	copy(out[8:], plaintext)
+	buf := make([]byte, aes.BlockSize)
+	for j := range 6 {
+		for i := 1; i <= n; i++ {
+			copy(buf, out[:8])
+			copy(buf[8:], out[8*i:8*i+8])
+			block.Encrypt(buf, buf)
+			t := uint64(n*j + i)
+			binary.BigEndian.PutUint64(out[:8], binary.BigEndian.Uint64(buf[:8])^t)
+			copy(out[8*i:], buf[8:])
+		}
+	}
+	return out, nil
+}
+
+func kdfHashFunc(id byte) (func() hash.Hash, error) {
+	switch id {
+	case hashSHA256:
+		return sha256.New, nil
+	case hashSHA384:
+		return sha512.New384, nil
+	case hashSHA512:
+		return sha512.New, nil
+	}
+	return nil, fmt.Errorf("unsupported KDF hash %d", id)
+}
+
+func cipherKeySize(id byte) (int, error) {
+	switch id {
+	case cipherAES128:
+		return 16, nil
+	case cipherAES192:
+		return 24, nil
+	case cipherAES256:
+		return 32, nil
+	}
+	return 0, fmt.Errorf("unsupported key wrap algorithm %d", id)
+}
@@ -0,0 +1,277 @@
+/*
+Copyright 2025.
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+*/
+
+// Package pgp encrypts credentials to OpenPGP public keys (RFC 4880 and RFC 6637), so only the
+// holder of the private key can read them. Messages are what gpg --decrypt expects: a session
+// key encrypted to each recipient and AES-256 encrypted data protected by a modification
+// detection code. Keys are parsed, not verified; they come from the User resource they encrypt for.
+package pgp
+
+import (
+	"bytes"
+	"crypto/ecdh"
+	"crypto/rsa"
+	"crypto/sha1"
+	"encoding/binary"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"math/big"
+	"strings"
+)
+
+// Public key algorithms
+const (
+	algorithmRSA        = 1
+	algorithmRSAEncrypt = 2
+	algorithmECDH       = 18
+)
+
+// Key flags allowing a key to encrypt
+const (
+	flagEncryptCommunications = 0x04
+	flagEncryptStorage        = 0x08
+)
+
+// Signature types and subpackets that decide which key messages are encrypted to
+const (
+	sigSubkeyBinding    = 0x18
+	sigSubkeyRevocation = 0x28
+	subpacketKeyFlags   = 27
+)
+
+// ecdhCurve is a curve ECDH keys can be on
+type ecdhCurve struct {
+	curve ecdh.Curve
+	// prefix is prepended to the native X25519 encoding of points
+	prefix []byte
+}
+
+// ecdhCurves maps curve OIDs to their curves
+var ecdhCurves = map[string]ecdhCurve{
+	"\x2b\x06\x01\x04\x01\x97\x55\x01\x05\x01": {curve: ecdh.X25519(), prefix: []byte{0x40}},
+	"\x2a\x86\x48\xce\x3d\x03\x01\x07":         {curve: ecdh.P256()},
+	"\x2b\x81\x04\x00\x22":                     {curve: ecdh.P384()},
+	"\x2b\x81\x04\x00\x23":                     {curve: ecdh.P521()},
+}
+
+// Recipient is the encryption key of an OpenPGP public key: an encryption subkey, or the
+// primary key when it has none
+type Recipient struct {
+	// UserID is the first user ID of the key, e.g. "Jane Doe <jane@example.com>"
+	UserID string
+
+	fingerprint []byte
+	algorithm   byte
+	created     uint32
+	rsa         *rsa.PublicKey
+	ecdh        *ecdh.PublicKey
+	curve       ecdhCurve
+	oid         []byte
+	// kdfHash and kdfCipher are the KDF parameters of an ECDH key
+	kdfHash, kdfCipher byte
+}
+
+// Fingerprint returns the fingerprint of the encryption key in hex
+func (r *Recipient) Fingerprint() string {
+	return strings.ToUpper(hex.EncodeToString(r.fingerprint))
+}
+
+func (r *Recipient) keyID() []byte {
+	return r.fingerprint[len(r.fingerprint)-8:]
+}
+
+// candidate is a key of a transferable public key and what its signatures say about it
+type candidate struct {
+	recipient *Recipient
+	flags     byte
+	hasFlags  bool
+	revoked   bool
+}
+
+func (c *candidate) canEncrypt() bool {
+	return c.recipient != nil && !c.revoked && (!c.hasFlags || c.flags&(flagEncryptCommunications|flagEncryptStorage) != 0)
+}
+
+// ParseRecipient parses an ASCII-armored public key and returns its newest key that can encrypt
+func ParseRecipient(armored string) (*Recipient, error) {
+	data, err := decodeArmor(armored, armorPublicKey)
+	if err != nil {
+		return nil, err
+	}
+	packets, err := readPackets(data)
+	if err != nil {
+		return nil, err
+	}
+	if len(packets) == 0 || packets[0].tag != tagPublicKey {
+		return nil, errors.New("pgp: key does not start with a public key packet")
+	}
+
+	var primary *candidate
+	var subkeys []*candidate
+	var current *candidate
+	userID := ""
+packets:
+	for _, p := range packets {
+		switch p.tag {
+		case tagPublicKey:
+			if primary != nil {
+				// A keyring; only the first key is used
+				break packets
+			}
+			primary = &candidate{recipient: parsePublicKey(p.body)}
+			current = primary
+		case tagPublicSubkey:
+			current = &candidate{recipient: parsePublicKey(p.body)}
+			subkeys = append(subkeys, current)
+		case tagUserID:
+			if userID == "" {
+				userID = string(p.body)
+			}
+		case tagSignature:
+			if current != nil {
+				current.applySignature(p.body, current != primary)
+			}
+		}
+	}
+
+	var best *Recipient
+	for _, subkey := range subkeys {
+		if subkey.canEncrypt() && (best == nil || subkey.recipient.created >= best.created) {
+			best = subkey.recipient
+		}
+	}
+	if best == nil && primary.canEncrypt() {
+		best = primary.recipient
+	}
+	if best == nil {
+		return nil, errors.New("pgp: key has no RSA key of 2048 bits or more or ECDH key that can encrypt")
+	}
+	best.UserID = userID
+	return best, nil
+}
+
+// applySignature records the key flags and revocation of a signature over the current key.
+// Subkeys take them from their binding signatures, the primary key from its self-signatures.
+func (c *candidate) applySignature(body []byte, subkey bool) {
+	if len(body) < 6 || body[0] != 4 {
+		return
+	}
+	sigType := body[1]
+	switch {
+	case subkey && sigType == sigSubkeyRevocation:
+		c.revoked = true
+		return
+	case subkey && sigType != sigSubkeyBinding:
+		return
+	case !subkey && (sigType < 0x10 || sigType > 0x13) && sigType != 0x1f:
+		return
+	}
+	hashedLength := int(binary.BigEndian.Uint16(body[4:6]))
+	if len(body)-6 < hashedLength {
+		return
+	}
+	subpackets := body[6 : 6+hashedLength]
+	for len(subpackets) > 0 {
+		length, offset := int(subpackets[0]), 1
+		switch {
+		case length >= 255:
+			if len(subpackets) < 5 {
+				return
+			}
+			length, offset = int(binary.BigEndian.Uint32(subpackets[1:5])), 5
+		case length >= 192:
+			if len(subpackets) < 2 {
+				return
+			}
+			length, offset = (length-192)<<8+int(subpackets[1])+192, 2
+		}
+		if length < 1 || len(subpackets)-offset < length {
+			return
+		}
+		content := subpackets[offset : offset+length]
+		if content[0]&0x7f == subpacketKeyFlags && len(content) > 1 {
+			c.flags, c.hasFlags = content[1], true
+		}
+		subpackets = subpackets[offset+length:]
+	}
+}
+
+// parsePublicKey parses a version 4 public key packet, returning nil for keys that cannot encrypt
+func parsePublicKey(body []byte) *Recipient {
+	if len(body) < 6 || body[0] != 4 {
+		return nil
+	}
+	digest := sha1.New()
+	digest.Write([]byte{0x99, byte(len(body) >> 8), byte(len(body))})
+	digest.Write(body)
+	r := &Recipient{
+		fingerprint: digest.Sum(nil),
+		created:     binary.BigEndian.Uint32(body[1:5]),
+		algorithm:   body[5],
+	}
+	fields := body[6:]
+
+	switch r.algorithm {
+	case algorithmRSA, algorithmRSAEncrypt:
+		n, rest, err := readMPI(fields)
+		if err != nil {
+			return nil
+		}
+		e, _, err := readMPI(rest)
+		if err != nil || len(e) == 0 || len(e) > 4 {
+			return nil
+		}
+		exponent := new(big.Int).SetBytes(e)
+		r.rsa = &rsa.PublicKey{N: new(big.Int).SetBytes(n), E: int(exponent.Int64())}
+		if r.rsa.N.BitLen() < 2048 {
+			return nil
+		}
+	case algorithmECDH:
+		if len(fields) < 1 || len(fields) < 1+int(fields[0]) {
+			return nil
+		}
+		r.oid = fields[1 : 1+fields[0]]
+		curve, ok := ecdhCurves[string(r.oid)]
+		if !ok {
+			return nil
+		}
+		point, rest, err := readMPI(fields[1+fields[0]:])
+		if err != nil || !bytes.HasPrefix(point, curve.prefix) {
+			return nil
+		}
+		if r.ecdh, err = curve.curve.NewPublicKey(point[len(curve.prefix):]); err != nil {
+			return nil
+		}
+		// KDF parameters: length 3, reserved 1, hash and key wrap algorithm
+		if len(rest) < 4 || rest[0] != 3 || rest[1] != 1 {
+			return nil
+		}
+		r.curve, r.kdfHash, r.kdfCipher = curve, rest[2], rest[3]
+		if _, err := kdfHashFunc(r.kdfHash); err != nil {
+			return nil
+		}
+		if _, err := cipherKeySize(r.kdfCipher); err != nil {
+			return nil
+		}
+	default:
+		return nil
+	}
+	return r
+}
+
+// ParseRecipients parses every armored key in keys
+func ParseRecipients(keys []string) ([]*Recipient, error) {
+	recipients := make([]*Recipient, 0, len(keys))
+	for i, key := range keys {
+		recipient, err := ParseRecipient(key)
+		if err != nil {
+			return nil, fmt.Errorf("recipient %d: %w", i, err)
+		}
+		recipients = append(recipients, recipient)
+	}
+	return recipients, nil
+}
@@ -0,0 +1,262 @@
+/*
+Copyright 2025.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package pgp
+
+import (
+	"bytes"
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/ecdh"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/sha1"
+	"encoding/binary"
+	"math/big"
+	"strings"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+)
+
+// gpgKey was exported by gpg: an Ed25519 primary key with a Curve25519 encryption subkey
+const gpgKey = `-----BEGIN PGP PUBLIC KEY BLOCK-----
+
+mDMEatHd7BYJKwYBBAHaRw8BAQdAwMp07+epxmH5tdVwn2+Yih9bJM/c/VugFRmK
+7gGTwG20HkN1cnZlIFVzZXIgPGN1cnZlQGV4YW1wbGUuY29tPoiQBBMWCAA4FiEE
+xClaecwB8dEXb86L0UV/y8ey5AMFAmrR3ewCGwMFCwkIBwIGFQoJCAsCBBYCAwEC
+HgECF4AACgkQ0UV/y8ey5ANd2gD+K+sbPf2QcjJZ8luDvIq6MFlwUt7uYW0zY6Jy
+rITgkNABAIz+EF0dA25W+eZNWIEEGJqo9oyioQycGCwimooQT2kGuDgEatHd7BIK
+KwYBBAGXVQEFAQEHQIOcnIVtwV3M/mRHYaioU0jRbWncV5MzGVbX7KL3cVweAwEI
+B4h4BBgWCAAgFiEExClaecwB8dEXb86L0UV/y8ey5AMFAmrR3ewCGwwACgkQ0UV/
+y8ey5AOnRAD+LEzYd7ErAPmQsyEDCXJYnAeDHtegJzCvuTTDX/3M/vgBANj+9Ohm
+l4J3kCL9IQ4E7awlk7KOd9dGIc58anEneQUJ
+=aUs+
+-----END PGP PUBLIC KEY BLOCK-----
+`
+
+const cv25519OID = "\x2b\x06\x01\x04\x01\x97\x55\x01\x05\x01"
+
+// keyBody returns a version 4 public key packet body
+func keyBody(created uint32, algorithm byte, fields []byte) []byte {
+	body := binary.BigEndian.AppendUint32([]byte{4}, created)
+	return append(append(body, algorithm), fields...)
+}
+
+func rsaFields(key *rsa.PublicKey) []byte {
+	return appendMPI(appendMPI(nil, key.N.Bytes()), big.NewInt(int64(key.E)).Bytes())
+}
+
+func x25519Fields(key *ecdh.PublicKey) []byte {
+	fields := append([]byte{byte(len(cv25519OID))}, cv25519OID...)
+	fields = appendMPI(fields, append([]byte{0x40}, key.Bytes()...))
+	return append(fields, 3, 1, hashSHA256, cipherAES128)
+}
+
+// signature returns a version 4 signature packet body with the given key flags
+func signature(sigType byte, flags ...byte) []byte {
+	var hashed []byte
+	if len(flags) > 0 {
+		hashed = []byte{2, subpacketKeyFlags, flags[0]}
+	}
+	body := []byte{4, sigType, algorithmRSA, hashSHA256}
+	body = binary.BigEndian.AppendUint16(body, uint16(len(hashed)))
+	body = append(body, hashed...)
+	// No unhashed subpackets, a made up hash prefix and signature
+	return append(body, 0, 0, 0, 0, 0, 8, 0xff)
+}
+
+func armorKey(packets ...packet) string {
+	var data []byte
+	for _, p := range packets {
+		data = appendPacket(data, p.tag, p.body)
+	}
+	return string(encodeArmor(data, armorPublicKey))
+}
+
+// decrypt decrypts message with sessionKey, which decrypts the session key from the packet
+// encrypted to the recipient with keyID
+func decrypt(message []byte, keyID []byte, sessionKey func(body []byte) []byte) []byte {
+	data, err := decodeArmor(string(message), armorMessage)
+	ExpectWithOffset(1, err).NotTo(HaveOccurred())
+	packets, err := readPackets(data)
+	ExpectWithOffset(1, err).NotTo(HaveOccurred())
+
+	var key []byte
+	for _, p := range packets {
+		switch p.tag {
+		case tagPKESK:
+			if bytes.Equal(p.body[1:9], keyID) {
+				decrypted := sessionKey(p.body[10:])
+				ExpectWithOffset(1, decrypted[0]).To(Equal(byte(cipherAES256)))
+				key = decrypted[1:33]
+				var checksum uint16
+				for _, b := range key {
+					checksum += uint16(b)
+				}
+				ExpectWithOffset(1, binary.BigEndian.Uint16(decrypted[33:35])).To(Equal(checksum))
+			}
+		case tagSEIPD:
+			ExpectWithOffset(1, key).NotTo(BeNil(), "no session key for the recipient")
+			ExpectWithOffset(1, p.body[0]).To(Equal(byte(1)))
+			block, err := aes.NewCipher(key)
+			ExpectWithOffset(1, err).NotTo(HaveOccurred())
+			plain := make([]byte, len(p.body)-1)
+			cipher.NewCFBDecrypter(block, make([]byte, aes.BlockSize)).XORKeyStream(plain, p.body[1:]) // nolint:staticcheck
+			ExpectWithOffset(1, plain[14:16]).To(Equal(plain[16:18]))
+			mdc := sha1.Sum(plain[:len(plain)-sha1.Size])
+			ExpectWithOffset(1, plain[len(plain)-sha1.Size:]).To(Equal(mdc[:]))
+			inner, err := readPackets(plain[18 : len(plain)-sha1.Size-2])
+			ExpectWithOffset(1, err).NotTo(HaveOccurred())
+			ExpectWithOffset(1, inner).To(HaveLen(1))
+			ExpectWithOffset(1, inner[0].tag).To(Equal(byte(tagLiteralData)))
+			return inner[0].body[6:]
+		}
+	}
+	Fail("message has no encrypted data")
+	return nil
+}
+
+// keyUnwrap reverses keyWrap
+func keyUnwrap(kek, wrapped []byte) []byte {
+	block, err := aes.NewCipher(kek)
+	ExpectWithOffset(1, err).NotTo(HaveOccurred())
+	n := len(wrapped)/8 - 1
+	a, r := bytes.Clone(wrapped[:8]), bytes.Clone(wrapped[8:])
+	buf := make([]byte, aes.BlockSize)
+	for j := 5; j >= 0; j-- {
+		for i := n; i >= 1; i-- {
+			binary.BigEndian.PutUint64(buf[:8], binary.BigEndian.Uint64(a)^uint64(n*j+i))
+			copy(buf[8:], r[8*(i-1):8*i])
+			block.Decrypt(buf, buf)
+			copy(a, buf[:8])
+			copy(r[8*(i-1):], buf[8:])
+		}
+	}
+	ExpectWithOffset(1, a).To(Equal(bytes.Repeat([]byte{0xa6}, 8)))
+	return r
+}
+
+var _ = Describe("OpenPGP", func() {
+	It("uses the encryption subkey of a key exported by gpg", func() {
+		recipient, err := ParseRecipient(gpgKey)
+		Expect(err).NotTo(HaveOccurred())
+		Expect(recipient.Fingerprint()).To(Equal("52400E9D76D0DE13E5219639E69E3698DD8C4481"))
+		Expect(recipient.UserID).To(Equal("Curve User <curve@example.com>"))
+		Expect(recipient.algorithm).To(Equal(byte(algorithmECDH)))
+	})
+
+	It("encrypts to RSA keys", func() {
+		key, err := rsa.GenerateKey(rand.Reader, 2048)
+		Expect(err).NotTo(HaveOccurred())
+		recipient, err := ParseRecipient(armorKey(
+			packet{tag: tagPublicKey, body: keyBody(1700000000, algorithmRSA, rsaFields(&key.PublicKey))},
+			packet{tag: tagUserID, body: []byte("Jane Doe <jane@example.com>")},
+		))
+		Expect(err).NotTo(HaveOccurred())
+
+		message, err := Encrypt([]byte("apiVersion: v1\n"), []*Recipient{recipient})
+		Expect(err).NotTo(HaveOccurred())
+		Expect(IsMessage(message)).To(BeTrue())
+		plaintext := decrypt(message, recipient.keyID(), func(body []byte) []byte {
+			encrypted, _, err := readMPI(body)
+			Expect(err).NotTo(HaveOccurred())
+			sessionKey, err := rsa.DecryptPKCS1v15(nil, key, encrypted) // nolint:staticcheck
+			Expect(err).NotTo(HaveOccurred())
+			return sessionKey
+		})
+		Expect(string(plaintext)).To(Equal("apiVersion: v1\n"))
+	})
+
+	It("encrypts to Curve25519 subkeys and every other recipient", func() {
+		key, err := ecdh.X25519().GenerateKey(rand.Reader)
+		Expect(err).NotTo(HaveOccurred())
+		recipient, err := ParseRecipient(armorKey(
+			packet{tag: tagPublicKey, body: keyBody(1700000000, 22, nil)},
+			packet{tag: tagUserID, body: []byte("Jane Doe <jane@example.com>")},
+			packet{tag: tagPublicSubkey, body: keyBody(1700000000, algorithmECDH, x25519Fields(key.PublicKey()))},
+			packet{tag: tagSignature, body: signature(sigSubkeyBinding, flagEncryptCommunications|flagEncryptStorage)},
+		))
+		Expect(err).NotTo(HaveOccurred())
+		other, err := ParseRecipient(gpgKey)
+		Expect(err).NotTo(HaveOccurred())
+
+		plaintext := bytes.Repeat([]byte("kubeconfig "), 1000)
+		message, err := Encrypt(plaintext, []*Recipient{other, recipient})
+		Expect(err).NotTo(HaveOccurred())
+		Expect(decrypt(message, recipient.keyID(), func(body []byte) []byte {
+			point, rest, err := readMPI(body)
+			Expect(err).NotTo(HaveOccurred())
+			Expect(point[0]).To(Equal(byte(0x40)))
+			ephemeral, err := ecdh.X25519().NewPublicKey(point[1:])
+			Expect(err).NotTo(HaveOccurred())
+			shared, err := key.ECDH(ephemeral)
+			Expect(err).NotTo(HaveOccurred())
+			kek, err := recipient.ecdhKEK(shared)
+			Expect(err).NotTo(HaveOccurred())
+			Expect(int(rest[0])).To(Equal(len(rest) - 1))
+			padded := keyUnwrap(kek, rest[1:])
+			return padded[:len(padded)-int(padded[len(padded)-1])]
+		})).To(Equal(plaintext))
+	})
+
+	It("picks the newest subkey that can encrypt", func() {
+		primary, err := rsa.GenerateKey(rand.Reader, 2048)
+		Expect(err).NotTo(HaveOccurred())
+		subkey := func(created uint32) packet {
+			key, err := ecdh.X25519().GenerateKey(rand.Reader)
+			Expect(err).NotTo(HaveOccurred())
+			return packet{tag: tagPublicSubkey, body: keyBody(created, algorithmECDH, x25519Fields(key.PublicKey()))}
+		}
+		older, newest, signing, revoked := subkey(100), subkey(200), subkey(300), subkey(400)
+		recipient, err := ParseRecipient(armorKey(
+			packet{tag: tagPublicKey, body: keyBody(50, algorithmRSA, rsaFields(&primary.PublicKey))},
+			packet{tag: tagSignature, body: signature(0x13, 0x03)},
+			older, packet{tag: tagSignature, body: signature(sigSubkeyBinding, flagEncryptStorage)},
+			newest, packet{tag: tagSignature, body: signature(sigSubkeyBinding, flagEncryptCommunications)},
+			signing, packet{tag: tagSignature, body: signature(sigSubkeyBinding, 0x02)},
+			revoked, packet{tag: tagSignature, body: signature(sigSubkeyBinding, flagEncryptCommunications)},
+			packet{tag: tagSignature, body: signature(sigSubkeyRevocation)},
+		))
+		Expect(err).NotTo(HaveOccurred())
+		Expect(recipient.created).To(Equal(uint32(200)))
+	})
+
+	It("rejects keys that cannot encrypt", func() {
+		weak, err := rsa.GenerateKey(rand.Reader, 1024)
+		Expect(err).NotTo(HaveOccurred())
+		_, err = ParseRecipient(armorKey(
+			packet{tag: tagPublicKey, body: keyBody(1700000000, algorithmRSA, rsaFields(&weak.PublicKey))},
+		))
+		Expect(err).To(MatchError(ContainSubstring("no RSA key of 2048 bits or more or ECDH key")))
+
+		signOnly, err := rsa.GenerateKey(rand.Reader, 2048)
+		Expect(err).NotTo(HaveOccurred())
+		_, err = ParseRecipient(armorKey(
+			packet{tag: tagPublicKey, body: keyBody(1700000000, algorithmRSA, rsaFields(&signOnly.PublicKey))},
+			packet{tag: tagSignature, body: signature(0x13, 0x03)},
+		))
+		Expect(err).To(HaveOccurred())
+	})
+
+	It("rejects malformed armor", func() {
+		_, err := ParseRecipient("ssh-ed25519 AAAA")
+		Expect(err).To(MatchError(ContainSubstring("no PGP PUBLIC KEY BLOCK found")))
+
+		_, err = ParseRecipient(strings.Replace(gpgKey, "=aUs+", "=aUs/", 1))
+		Expect(err).To(MatchError(ContainSubstring("checksum mismatch")))
+	})
+})
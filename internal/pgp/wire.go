@@ -0,0 +1,218 @@
+/*
+Copyright 2025.
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+*/
+
+package pgp
+
+import (
+	"bytes"
+	"encoding/base64"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"math/bits"
+	"strings"
+)
+
+// The OpenPGP wire format (RFC 4880): ASCII armor around a sequence of packets, each a tag and
+// length header followed by its body. Only what public keys and encrypted messages need is here.
+
+var errShortBuffer = errors.New("pgp: short buffer")
+
+// Packet tags
+const (
+	tagPKESK         = 1
+	tagSignature     = 2
+	tagPublicKey     = 6
+	tagLiteralData   = 11
+	tagUserID        = 13
+	tagPublicSubkey  = 14
+	tagSEIPD         = 18
+	tagModDetectCode = 19
+)
+
+type packet struct {
+	tag  byte
+	body []byte
+}
+
+// readPackets splits data into packets, accepting both the old and the new header format
+func readPackets(data []byte) ([]packet, error) {
+	var packets []packet
+	for len(data) > 0 {
+		header := data[0]
+		if header&0x80 == 0 {
+			return nil, errors.New("pgp: invalid packet header")
+		}
+		var tag byte
+		var length, offset int
+		if header&0x40 != 0 {
+			tag = header & 0x3f
+			if len(data) < 2 {
+				return nil, errShortBuffer
+			}
+			switch first := int(data[1]); {
+			case first < 192:
+				length, offset = first, 2
+			case first < 224:
+				if len(data) < 3 {
+					return nil, errShortBuffer
+				}
+				length, offset = (first-192)<<8+int(data[2])+192, 3
+			case first == 255:
+				if len(data) < 6 {
+					return nil, errShortBuffer
+				}
+				length, offset = int(binary.BigEndian.Uint32(data[2:6])), 6
+			default:
+				return nil, errors.New("pgp: partial body lengths are not supported in keys")
+			}
+		} else {
+			tag = header >> 2 & 0x0f
+			switch header & 0x03 {
+			case 0:
+				if len(data) < 2 {
+					return nil, errShortBuffer
+				}
+				length, offset = int(data[1]), 2
+			case 1:
+				if len(data) < 3 {
+					return nil, errShortBuffer
+				}
+				length, offset = int(binary.BigEndian.Uint16(data[1:3])), 3
+			case 2:
+				if len(data) < 5 {
+					return nil, errShortBuffer
+				}
+				length, offset = int(binary.BigEndian.Uint32(data[1:5])), 5
+			default:
+				length, offset = len(data)-1, 1
+			}
+		}
+		if length < 0 || len(data)-offset < length {
+			return nil, errShortBuffer
+		}
+		packets = append(packets, packet{tag: tag, body: data[offset : offset+length]})
+		data = data[offset+length:]
+	}
+	return packets, nil
+}
+
+// appendPacket appends body with a new format header
+func appendPacket(buf []byte, tag byte, body []byte) []byte {
+	buf = append(buf, 0xc0|tag)
+	switch n := len(body); {
+	case n < 192:
+		buf = append(buf, byte(n))
+	case n < 8384:
+		n -= 192
+		buf = append(buf, byte(n>>8)+192, byte(n))
+	default:
+		buf = append(buf, 255)
+		buf = binary.BigEndian.AppendUint32(buf, uint32(n))
+	}
+	return append(buf, body...)
+}
+
+// readMPI reads a multiprecision integer: a bit count followed by the big-endian bytes
+func readMPI(data []byte) (value, rest []byte, err error) {
+	if len(data) < 2 {
+		return nil, nil, errShortBuffer
+	}
+	n := (int(binary.BigEndian.Uint16(data)) + 7) / 8
+	if len(data)-2 < n {
+		return nil, nil, errShortBuffer
+	}
+	return data[2 : 2+n], data[2+n:], nil
+}
+
+// appendMPI appends value as a multiprecision integer
+func appendMPI(buf, value []byte) []byte {
+	value = bytes.TrimLeft(value, "\x00")
+	bitCount := 0
+	if len(value) > 0 {
+		bitCount = (len(value)-1)*8 + bits.Len8(value[0])
+	}
+	buf = binary.BigEndian.AppendUint16(buf, uint16(bitCount))
+	return append(buf, value...)
+}
+
+// Armor block types
+const (
+	armorPublicKey = "PGP PUBLIC KEY BLOCK"
+	armorMessage   = "PGP MESSAGE"
+)
+
+// decodeArmor returns the data of the first armored block of blockType in text
+func decodeArmor(text, blockType string) ([]byte, error) {
+	begin, end := "-----BEGIN "+blockType+"-----", "-----END "+blockType+"-----"
+	_, rest, found := strings.Cut(text, begin)
+	if !found {
+		return nil, fmt.Errorf("pgp: no %s found", blockType)
+	}
+	rest, _, found = strings.Cut(rest, end)
+	if !found {
+		return nil, fmt.Errorf("pgp: %s is not terminated", blockType)
+	}
+
+	var encoded, checksum strings.Builder
+	inHeaders := true
+	for _, line := range strings.Split(rest, "\n") {
+		line = strings.TrimSpace(line)
+		switch {
+		case inHeaders && strings.Contains(line, ": "):
+			// Armor headers such as Version or Comment
+		case line == "":
+			inHeaders = false
+		case strings.HasPrefix(line, "=") && len(line) == 5:
+			checksum.WriteString(line[1:])
+		default:
+			inHeaders = false
+			encoded.WriteString(line)
+		}
+	}
+	data, err := base64.StdEncoding.DecodeString(encoded.String())
+	if err != nil {
+		return nil, fmt.Errorf("pgp: invalid armor: %w", err)
+	}
+	if checksum.Len() > 0 {
+		sum, err := base64.StdEncoding.DecodeString(checksum.String())
+		if err != nil || len(sum) != 3 || uint32(sum[0])<<16|uint32(sum[1])<<8|uint32(sum[2]) != crc24(data) {
+			return nil, errors.New("pgp: armor checksum mismatch")
+		}
+	}
+	return data, nil
+}
+
+// encodeArmor armors data as a block of blockType
+func encodeArmor(data []byte, blockType string) []byte {
+	var b strings.Builder
+	b.WriteString("-----BEGIN " + blockType + "-----\n\n")
+	encoded := base64.StdEncoding.EncodeToString(data)
+	for len(encoded) > 64 {
+		b.WriteString(encoded[:64] + "\n")
+		encoded = encoded[64:]
+	}
+	b.WriteString(encoded + "\n")
+	sum := crc24(data)
+	b.WriteString("=" + base64.StdEncoding.EncodeToString([]byte{byte(sum >> 16), byte(sum >> 8), byte(sum)}) + "\n")
+	b.WriteString("-----END " + blockType + "-----\n")
+	return []byte(b.String())
+}
+
+// crc24 is the armor checksum
+func crc24(data []byte) uint32 {
+	crc := uint32(0xb704ce)
+	for _, b := range data {
+		crc ^= uint32(b) << 16
+		for range 8 {
+			crc <<= 1
+			if crc&0x1000000 != 0 {
+				crc ^= 0x1864cfb
+			}
+		}
+	}
+	return crc & 0xffffff
+}
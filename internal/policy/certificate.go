@@ -0,0 +1,104 @@
+/*
+Copyright 2025.
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+*/
+
+package policy
+
+import (
+	"fmt"
+	"slices"
+	"strings"
+
+	authv1alpha1 "github.com/openkube-hub/KubeUser/api/v1alpha1"
+)
+
+// SubjectViolation is returned for a value of spec.certificate a ClusterPolicy does not allow
+type SubjectViolation struct {
+	Policy string
+	// Field is the field of spec.certificate holding the value, e.g. organizations
+	Field  string
+	Value  string
+	Denied bool // matched a deny pattern, rather than no allow pattern
+}
+
+func (v *SubjectViolation) Error() string {
+	if v.Denied {
+		return fmt.Sprintf("spec.certificate.%s %q is denied by ClusterPolicy %s", v.Field, v.Value, v.Policy)
+	}
+	return fmt.Sprintf("spec.certificate.%s %q is not in the allow list of ClusterPolicy %s", v.Field, v.Value, v.Policy)
+}
+
+// CheckCertificate returns a *SubjectViolation for the first value of subject that a policy
+// applying to creator does not allow. Values in previous were allowed before and are not
+// checked again.
+func CheckCertificate(policies []authv1alpha1.ClusterPolicy, subject, previous *authv1alpha1.CertificateSubject,
+	creator *Creator) error {
+	if subject == nil {
+		return nil
+	}
+	if previous == nil {
+		previous = &authv1alpha1.CertificateSubject{}
+	}
+	for i := range policies {
+		p := &policies[i]
+		if !appliesTo(&p.Spec.Scope, creator) {
+			continue
+		}
+		rules := &p.Spec.Certificate
+		for _, field := range []struct {
+			name              string
+			values, unchanged []string
+			rules             authv1alpha1.ValueRules
+		}{
+			{"organizations", subject.Organizations, previous.Organizations, rules.Organizations},
+			{"organizationalUnits", subject.OrganizationalUnits, previous.OrganizationalUnits, rules.OrganizationalUnits},
+			{"emailAddresses", subject.EmailAddresses, previous.EmailAddresses, rules.EmailAddresses},
+			{"dnsNames", subject.DNSNames, previous.DNSNames, rules.DNSNames},
+			{"uris", subject.URIs, previous.URIs, rules.URIs},
+		} {
+			for _, value := range field.values {
+				if slices.Contains(field.unchanged, value) {
+					continue
+				}
+				if matchesPattern(field.rules.Deny, value) {
+					return &SubjectViolation{Policy: p.Name, Field: field.name, Value: value, Denied: true}
+				}
+				if len(field.rules.Allow) > 0 && !matchesPattern(field.rules.Allow, value) {
+					return &SubjectViolation{Policy: p.Name, Field: field.name, Value: value}
+				}
+			}
+		}
+	}
+	return nil
+}
+
+// matchesPattern reports whether value matches one of patterns, in which * matches any characters
+func matchesPattern(patterns []string, value string) bool {
+	for _, pattern := range patterns {
+		if matchPattern(pattern, value) {
+			return true
+		}
+	}
+	return false
+}
+
+func matchPattern(pattern, value string) bool {
+	parts := strings.Split(pattern, "*")
+	if len(parts) == 1 {
+		return pattern == value
+	}
+	if !strings.HasPrefix(value, parts[0]) {
+		return false
+	}
+	value = value[len(parts[0]):]
+	for _, part := range parts[1 : len(parts)-1] {
+		i := strings.Index(value, part)
+		if i < 0 {
+			return false
+		}
+		value = value[i+len(part):]
+	}
+	return strings.HasSuffix(value, parts[len(parts)-1])
+}
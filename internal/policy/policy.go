@@ -0,0 +1,210 @@
+/*
+Copyright 2025.
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+*/
+
+// Package policy evaluates ClusterPolicies, which restrict the Roles and ClusterRoles KubeUser
+// may bind, the namespaces Roles may be bound in and the subjects of client certificates. The admission webhook applies them when Users are created or changed, the
+// controller again before it binds roles.
+package policy
+
+import (
+	"context"
+	"fmt"
+	"slices"
+
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/labels"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+
+	authv1alpha1 "github.com/openkube-hub/KubeUser/api/v1alpha1"
+)
+
+// Grant is a Role or ClusterRole KubeUser is asked to bind
+type Grant struct {
+	// ClusterRole is true for spec.clusterRoles grants, false for spec.roles grants
+	ClusterRole bool
+	Name        string
+	// Namespace of a Role grant
+	Namespace string
+	// Labels of the granted role, matched by label selectors
+	Labels map[string]string
+	// NamespaceLabels are the labels of a Role grant's namespace, matched by namespace selectors
+	NamespaceLabels map[string]string
+}
+
+func (g Grant) String() string {
+	if g.ClusterRole {
+		return fmt.Sprintf("ClusterRole %s", g.Name)
+	}
+	return fmt.Sprintf("Role %s in namespace %s", g.Name, g.Namespace)
+}
+
+// Creator is the identity that created or changed a User
+type Creator struct {
+	Username string
+	Groups   []string
+}
+
+// Violation is returned for grants a ClusterPolicy does not allow
+type Violation struct {
+	Policy string
+	Grant  Grant
+	Denied bool // matched a deny entry, rather than no allow entry
+	// Namespace is set when the policy refuses the Role grant's namespace rather than the role
+	Namespace bool
+}
+
+func (v *Violation) Error() string {
+	if v.Namespace {
+		if v.Denied {
+			return fmt.Sprintf("%s: the namespace is denied by ClusterPolicy %s", v.Grant, v.Policy)
+		}
+		return fmt.Sprintf("%s: the namespace is not in the allow list of ClusterPolicy %s", v.Grant, v.Policy)
+	}
+	if v.Denied {
+		return fmt.Sprintf("%s is denied by ClusterPolicy %s", v.Grant, v.Policy)
+	}
+	return fmt.Sprintf("%s is not in the allow list of ClusterPolicy %s", v.Grant, v.Policy)
+}
+
+// Check returns a *Violation for the first policy that does not allow the grant. Policies
+// scoped to users or groups only apply when the creator is known; the controller passes nil.
+func Check(policies []authv1alpha1.ClusterPolicy, grant Grant, creator *Creator) error {
+	for i := range policies {
+		p := &policies[i]
+		if !applies(&p.Spec.Scope, grant, creator) {
+			continue
+		}
+		if err := checkNamespace(p, grant); err != nil {
+			return err
+		}
+		rules := p.Spec.Roles
+		if grant.ClusterRole {
+			rules = p.Spec.ClusterRoles
+		}
+		denied, err := matchesAny(rules.Deny, grant)
+		if err != nil {
+			return fmt.Errorf("invalid ClusterPolicy %s: %w", p.Name, err)
+		}
+		if denied {
+			return &Violation{Policy: p.Name, Grant: grant, Denied: true}
+		}
+		if len(rules.Allow) == 0 {
+			continue
+		}
+		allowed, err := matchesAny(rules.Allow, grant)
+		if err != nil {
+			return fmt.Errorf("invalid ClusterPolicy %s: %w", p.Name, err)
+		}
+		if !allowed {
+			return &Violation{Policy: p.Name, Grant: grant}
+		}
+	}
+	return nil
+}
+
+// CheckNamespace is Check for Role grants in namespaces that do not exist yet: only the
+// namespace rules apply, by name, since neither the Role nor the namespace has labels yet
+func CheckNamespace(policies []authv1alpha1.ClusterPolicy, grant Grant, creator *Creator) error {
+	for i := range policies {
+		p := &policies[i]
+		if !applies(&p.Spec.Scope, grant, creator) {
+			continue
+		}
+		if err := checkNamespace(p, grant); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// checkNamespace returns a *Violation when p's namespace rules refuse a Role grant's namespace
+func checkNamespace(p *authv1alpha1.ClusterPolicy, grant Grant) error {
+	if grant.ClusterRole {
+		return nil
+	}
+	rules := p.Spec.Namespaces
+	for _, s := range rules.Deny {
+		denied, err := matches(s.Names, s.Selector, grant.Namespace, grant.NamespaceLabels)
+		if err != nil {
+			return fmt.Errorf("invalid ClusterPolicy %s: %w", p.Name, err)
+		}
+		if denied {
+			return &Violation{Policy: p.Name, Grant: grant, Denied: true, Namespace: true}
+		}
+	}
+	if len(rules.Allow) == 0 {
+		return nil
+	}
+	for _, s := range rules.Allow {
+		allowed, err := matches(s.Names, s.Selector, grant.Namespace, grant.NamespaceLabels)
+		if err != nil {
+			return fmt.Errorf("invalid ClusterPolicy %s: %w", p.Name, err)
+		}
+		if allowed {
+			return nil
+		}
+	}
+	return &Violation{Policy: p.Name, Grant: grant, Namespace: true}
+}
+
+// List returns all ClusterPolicies
+func List(ctx context.Context, c client.Reader) ([]authv1alpha1.ClusterPolicy, error) {
+	var policies authv1alpha1.ClusterPolicyList
+	if err := c.List(ctx, &policies); err != nil {
+		return nil, fmt.Errorf("failed to list ClusterPolicies: %w", err)
+	}
+	return policies.Items, nil
+}
+
+func applies(scope *authv1alpha1.PolicyScope, grant Grant, creator *Creator) bool {
+	if len(scope.Namespaces) > 0 && !grant.ClusterRole && !slices.Contains(scope.Namespaces, grant.Namespace) {
+		return false
+	}
+	return appliesTo(scope, creator)
+}
+
+// appliesTo reports whether a policy with scope applies to Users created or changed by creator
+func appliesTo(scope *authv1alpha1.PolicyScope, creator *Creator) bool {
+	if len(scope.Users) == 0 && len(scope.Groups) == 0 {
+		return true
+	}
+	if creator == nil {
+		return false
+	}
+	if slices.Contains(scope.Users, creator.Username) {
+		return true
+	}
+	for _, group := range creator.Groups {
+		if slices.Contains(scope.Groups, group) {
+			return true
+		}
+	}
+	return false
+}
+
+func matchesAny(selectors []authv1alpha1.RoleSelector, grant Grant) (bool, error) {
+	for _, s := range selectors {
+		if ok, err := matches(s.Names, s.Selector, grant.Name, grant.Labels); ok || err != nil {
+			return ok, err
+		}
+	}
+	return false, nil
+}
+
+// matches reports whether an object with name and objectLabels is one of names or matches selector
+func matches(names []string, selector *metav1.LabelSelector, name string, objectLabels map[string]string) (bool, error) {
+	if slices.Contains(names, name) {
+		return true, nil
+	}
+	if selector == nil {
+		return false, nil
+	}
+	s, err := metav1.LabelSelectorAsSelector(selector)
+	if err != nil {
+		return false, err
+	}
+	return s.Matches(labels.Set(objectLabels)), nil
+}
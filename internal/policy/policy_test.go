@@ -0,0 +1,167 @@
+/*
+Copyright 2025.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package policy
+
+import (
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+
+	authv1alpha1 "github.com/openkube-hub/KubeUser/api/v1alpha1"
+)
+
+func clusterPolicy(name string, spec authv1alpha1.ClusterPolicySpec) authv1alpha1.ClusterPolicy {
+	return authv1alpha1.ClusterPolicy{ObjectMeta: metav1.ObjectMeta{Name: name}, Spec: spec}
+}
+
+var _ = Describe("Check", func() {
+	noClusterAdmin := clusterPolicy("no-cluster-admin", authv1alpha1.ClusterPolicySpec{
+		ClusterRoles: authv1alpha1.RoleRules{Deny: []authv1alpha1.RoleSelector{{Names: []string{"cluster-admin"}}}},
+	})
+	labelledOnly := clusterPolicy("labelled-only", authv1alpha1.ClusterPolicySpec{
+		ClusterRoles: authv1alpha1.RoleRules{Allow: []authv1alpha1.RoleSelector{{
+			Selector: &metav1.LabelSelector{MatchLabels: map[string]string{"auth.openkube.io/grantable": "true"}},
+		}}},
+	})
+
+	It("denies roles matching a deny entry", func() {
+		err := Check([]authv1alpha1.ClusterPolicy{noClusterAdmin}, Grant{ClusterRole: true, Name: "cluster-admin"}, nil)
+		var violation *Violation
+		Expect(err).To(BeAssignableToTypeOf(violation))
+		Expect(err).To(MatchError("ClusterRole cluster-admin is denied by ClusterPolicy no-cluster-admin"))
+
+		Expect(Check([]authv1alpha1.ClusterPolicy{noClusterAdmin}, Grant{ClusterRole: true, Name: "view"}, nil)).To(Succeed())
+	})
+
+	It("requires a match when allow entries are given", func() {
+		policies := []authv1alpha1.ClusterPolicy{labelledOnly}
+		Expect(Check(policies, Grant{ClusterRole: true, Name: "view"}, nil)).To(
+			MatchError("ClusterRole view is not in the allow list of ClusterPolicy labelled-only"))
+		Expect(Check(policies, Grant{ClusterRole: true, Name: "view",
+			Labels: map[string]string{"auth.openkube.io/grantable": "true"}}, nil)).To(Succeed())
+	})
+
+	It("keeps Role and ClusterRole rules apart", func() {
+		policies := []authv1alpha1.ClusterPolicy{noClusterAdmin}
+		Expect(Check(policies, Grant{Name: "cluster-admin", Namespace: "dev"}, nil)).To(Succeed())
+	})
+
+	It("limits roles rules to the scoped namespaces", func() {
+		policies := []authv1alpha1.ClusterPolicy{clusterPolicy("prod", authv1alpha1.ClusterPolicySpec{
+			Scope: authv1alpha1.PolicyScope{Namespaces: []string{"prod"}},
+			Roles: authv1alpha1.RoleRules{Allow: []authv1alpha1.RoleSelector{{Names: []string{"viewer"}}}},
+		})}
+		Expect(Check(policies, Grant{Name: "admin", Namespace: "prod"}, nil)).To(HaveOccurred())
+		Expect(Check(policies, Grant{Name: "admin", Namespace: "dev"}, nil)).To(Succeed())
+	})
+
+	It("applies creator-scoped policies only to matching known creators", func() {
+		policies := []authv1alpha1.ClusterPolicy{clusterPolicy("contractors", authv1alpha1.ClusterPolicySpec{
+			Scope:        authv1alpha1.PolicyScope{Groups: []string{"contractors"}},
+			ClusterRoles: authv1alpha1.RoleRules{Deny: []authv1alpha1.RoleSelector{{Names: []string{"edit"}}}},
+		})}
+		grant := Grant{ClusterRole: true, Name: "edit"}
+		Expect(Check(policies, grant, &Creator{Username: "ann", Groups: []string{"contractors"}})).To(HaveOccurred())
+		Expect(Check(policies, grant, &Creator{Username: "bob", Groups: []string{"platform"}})).To(Succeed())
+		Expect(Check(policies, grant, nil)).To(Succeed())
+	})
+
+	It("confines creators to their tenant's namespaces", func() {
+		policies := []authv1alpha1.ClusterPolicy{clusterPolicy("tenant-a", authv1alpha1.ClusterPolicySpec{
+			Scope: authv1alpha1.PolicyScope{Groups: []string{"tenant-a-admins"}},
+			Namespaces: authv1alpha1.NamespaceRules{Allow: []authv1alpha1.NamespaceSelector{
+				{Names: []string{"shared"}},
+				{Selector: &metav1.LabelSelector{MatchLabels: map[string]string{"auth.openkube.io/tenant": "a"}}},
+			}},
+		})}
+		admin := &Creator{Username: "ann", Groups: []string{"tenant-a-admins"}}
+		tenantA := map[string]string{"auth.openkube.io/tenant": "a"}
+
+		Expect(Check(policies, Grant{Name: "edit", Namespace: "a-dev", NamespaceLabels: tenantA}, admin)).To(Succeed())
+		Expect(Check(policies, Grant{Name: "view", Namespace: "shared"}, admin)).To(Succeed())
+		err := Check(policies, Grant{Name: "edit", Namespace: "b-dev"}, admin)
+		var violation *Violation
+		Expect(err).To(BeAssignableToTypeOf(violation))
+		Expect(err).To(MatchError("Role edit in namespace b-dev: the namespace is not in the allow list of ClusterPolicy tenant-a"))
+		Expect(Check(policies, Grant{ClusterRole: true, Name: "view"}, admin)).To(Succeed())
+		Expect(Check(policies, Grant{Name: "edit", Namespace: "b-dev"}, &Creator{Username: "bob"})).To(Succeed())
+
+		Expect(CheckNamespace(policies, Grant{Name: "edit", Namespace: "shared"}, admin)).To(Succeed())
+		Expect(CheckNamespace(policies, Grant{Name: "edit", Namespace: "a-new"}, admin)).To(HaveOccurred())
+	})
+
+	It("denies namespaces matching a deny entry", func() {
+		policies := []authv1alpha1.ClusterPolicy{clusterPolicy("no-system", authv1alpha1.ClusterPolicySpec{
+			Namespaces: authv1alpha1.NamespaceRules{Deny: []authv1alpha1.NamespaceSelector{{Names: []string{"kube-system"}}}},
+		})}
+		Expect(Check(policies, Grant{Name: "view", Namespace: "kube-system"}, nil)).To(
+			MatchError("Role view in namespace kube-system: the namespace is denied by ClusterPolicy no-system"))
+		Expect(Check(policies, Grant{Name: "view", Namespace: "dev"}, nil)).To(Succeed())
+	})
+
+	It("reports invalid selectors", func() {
+		policies := []authv1alpha1.ClusterPolicy{clusterPolicy("broken", authv1alpha1.ClusterPolicySpec{
+			ClusterRoles: authv1alpha1.RoleRules{Deny: []authv1alpha1.RoleSelector{{Selector: &metav1.LabelSelector{
+				MatchExpressions: []metav1.LabelSelectorRequirement{{Key: "tier", Operator: "Bogus"}},
+			}}}},
+		})}
+		Expect(Check(policies, Grant{ClusterRole: true, Name: "view"}, nil)).To(
+			MatchError(ContainSubstring("invalid ClusterPolicy broken")))
+	})
+})
+
+var _ = Describe("CheckCertificate", func() {
+	corporate := clusterPolicy("corporate", authv1alpha1.ClusterPolicySpec{
+		Certificate: authv1alpha1.CertificateRules{
+			Organizations:  authv1alpha1.ValueRules{Allow: []string{"team-*"}, Deny: []string{"team-admins"}},
+			EmailAddresses: authv1alpha1.ValueRules{Allow: []string{"*@example.com"}},
+		},
+	})
+
+	It("matches values against allow and deny patterns", func() {
+		policies := []authv1alpha1.ClusterPolicy{corporate}
+		Expect(CheckCertificate(policies, &authv1alpha1.CertificateSubject{
+			Organizations:       []string{"team-payments"},
+			OrganizationalUnits: []string{"Payments"},
+			EmailAddresses:      []string{"jane@example.com"},
+		}, nil, nil)).To(Succeed())
+
+		err := CheckCertificate(policies, &authv1alpha1.CertificateSubject{Organizations: []string{"team-admins"}}, nil, nil)
+		var violation *SubjectViolation
+		Expect(err).To(BeAssignableToTypeOf(violation))
+		Expect(err).To(MatchError(`spec.certificate.organizations "team-admins" is denied by ClusterPolicy corporate`))
+
+		Expect(CheckCertificate(policies, &authv1alpha1.CertificateSubject{EmailAddresses: []string{"jane@example.org"}}, nil, nil)).To(
+			MatchError(`spec.certificate.emailAddresses "jane@example.org" is not in the allow list of ClusterPolicy corporate`))
+	})
+
+	It("does not check values allowed before again", func() {
+		previous := &authv1alpha1.CertificateSubject{Organizations: []string{"team-admins"}}
+		subject := &authv1alpha1.CertificateSubject{Organizations: []string{"team-admins", "team-web"}}
+		Expect(CheckCertificate([]authv1alpha1.ClusterPolicy{corporate}, subject, previous, nil)).To(Succeed())
+	})
+
+	It("applies creator-scoped policies only to matching creators", func() {
+		contractors := corporate
+		contractors.Spec.Scope.Groups = []string{"contractors"}
+		subject := &authv1alpha1.CertificateSubject{Organizations: []string{"finance"}}
+		policies := []authv1alpha1.ClusterPolicy{contractors}
+		Expect(CheckCertificate(policies, subject, nil, &Creator{Username: "bob", Groups: []string{"staff"}})).To(Succeed())
+		Expect(CheckCertificate(policies, subject, nil, &Creator{Username: "eve", Groups: []string{"contractors"}})).To(
+			MatchError(ContainSubstring("not in the allow list")))
+	})
+})
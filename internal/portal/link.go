@@ -0,0 +1,74 @@
+/*
+Copyright 2025.
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+*/
+
+// Package portal serves the self-service download of kubeconfigs through one-time links. An
+// admin mints a link for a user, and the user redeems it once to download their kubeconfig,
+// instead of receiving the credentials through chat or email.
+package portal
+
+import (
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/hex"
+	"errors"
+	"time"
+
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+const (
+	// LinkLabel marks the Secrets that hold download links
+	LinkLabel = "auth.openkube.io/download-link"
+	// UserLabel names the user a link downloads the kubeconfig of
+	UserLabel = "auth.openkube.io/user"
+	// ExpiresAtAnnotation is when a link stops working, in RFC 3339
+	ExpiresAtAnnotation = "auth.openkube.io/expires-at"
+	// MaxLinkTTL is the longest a link may stay valid
+	MaxLinkTTL = 7 * 24 * time.Hour
+)
+
+// NewToken returns a random link token
+func NewToken() (string, error) {
+	b := make([]byte, 32)
+	if _, err := rand.Read(b); err != nil {
+		return "", err
+	}
+	return base64.RawURLEncoding.EncodeToString(b), nil
+}
+
+// LinkSecretName returns the name of the Secret of the link with token. Only a digest of the
+// token is stored, so reading the Secret does not reveal the link.
+func LinkSecretName(token string) string {
+	sum := sha256.Sum256([]byte(token))
+	return "kubeuser-link-" + hex.EncodeToString(sum[:20])
+}
+
+// NewLink returns the Secret that makes token a link to the kubeconfig of username, valid for
+// ttl. It is created in the KubeUser namespace.
+func NewLink(token, username, namespace string, ttl time.Duration, now time.Time) (*corev1.Secret, error) {
+	if ttl <= 0 || ttl > MaxLinkTTL {
+		return nil, errors.New("link lifetime must be positive and at most 168h")
+	}
+	return &corev1.Secret{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      LinkSecretName(token),
+			Namespace: namespace,
+			Labels:    map[string]string{LinkLabel: "true", UserLabel: username},
+			Annotations: map[string]string{
+				ExpiresAtAnnotation: now.Add(ttl).UTC().Format(time.RFC3339),
+			},
+		},
+		Type: corev1.SecretTypeOpaque,
+	}, nil
+}
+
+// linkExpired reports whether the link Secret is past its expiry or has none
+func linkExpired(secret *corev1.Secret, now time.Time) bool {
+	expiresAt, err := time.Parse(time.RFC3339, secret.Annotations[ExpiresAtAnnotation])
+	return err != nil || !now.Before(expiresAt)
+}
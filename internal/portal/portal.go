@@ -0,0 +1,248 @@
+/*
+Copyright 2025.
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+*/
+
+package portal
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"html/template"
+	"net/http"
+	"time"
+
+	corev1 "k8s.io/api/core/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/types"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	logf "sigs.k8s.io/controller-runtime/pkg/log"
+
+	authv1alpha1 "github.com/openkube-hub/KubeUser/api/v1alpha1"
+	"github.com/openkube-hub/KubeUser/internal/credentials"
+	"github.com/openkube-hub/KubeUser/internal/naming"
+	"github.com/openkube-hub/KubeUser/internal/pgp"
+)
+
+// Portal redeems download links. Opening a link shows a page with a download button, and only
+// submitting it uses up the link, so chat and mail previews that fetch the link do not.
+type Portal struct {
+	// Reader reads links, Users and credential Secrets; it should read from the API server, so
+	// links work right after they are created
+	Reader client.Reader
+	// Writer deletes redeemed and expired links
+	Writer client.Writer
+	// Namespace returns the KubeUser namespace, which holds the links
+	Namespace func() string
+	// Now is replaced in tests
+	Now func() time.Time
+
+	mux *http.ServeMux
+}
+
+// New returns a Portal keeping links in the namespace returned by namespace
+func New(reader client.Reader, writer client.Writer, namespace func() string) *Portal {
+	p := &Portal{Reader: reader, Writer: writer, Namespace: namespace, Now: time.Now}
+	p.mux = http.NewServeMux()
+	p.mux.HandleFunc("GET /download/{token}", p.confirm)
+	p.mux.HandleFunc("POST /download/{token}", p.redeem)
+	return p
+}
+
+// ServeHTTP implements http.Handler
+func (p *Portal) ServeHTTP(w http.ResponseWriter, req *http.Request) {
+	header := w.Header()
+	header.Set("Cache-Control", "no-store")
+	header.Set("X-Content-Type-Options", "nosniff")
+	// The link is the credential; never pass it on to other sites
+	header.Set("Referrer-Policy", "no-referrer")
+	header.Set("Content-Security-Policy", "default-src 'none'; style-src 'unsafe-inline'; form-action 'self'")
+	p.mux.ServeHTTP(w, req)
+}
+
+var page = template.Must(template.New("page").Parse(`<!DOCTYPE html>
+<html>
+<head><meta charset="utf-8"><title>KubeUser</title></head>
+<body style="font-family: sans-serif; max-width: 40em; margin: 4em auto">
+{{- if .User }}
+<h1>Kubeconfig for {{ .User }}</h1>
+<p>This link works once and expires at {{ .ExpiresAt }}. Save the file, it cannot be downloaded again.</p>
+<form method="post"><button type="submit">Download kubeconfig</button></form>
+{{- else }}
+<h1>Link not available</h1>
+<p>{{ .Message }}</p>
+{{- end }}
+</body>
+</html>
+`))
+
+type pageData struct {
+	User      string
+	ExpiresAt string
+	Message   string
+}
+
+func writePage(w http.ResponseWriter, status int, data pageData) {
+	w.Header().Set("Content-Type", "text/html; charset=utf-8")
+	w.WriteHeader(status)
+	_ = page.Execute(w, data)
+}
+
+// linkGone is shown for unknown, used and expired links alike, so guessing tokens reveals nothing
+const linkGone = "This link is invalid, has expired or was already used. Ask your administrator for a new one."
+
+// confirm shows the download page of a link without using it up
+func (p *Portal) confirm(w http.ResponseWriter, req *http.Request) {
+	link, err := p.link(req.Context(), req.PathValue("token"))
+	if err != nil {
+		p.fail(w, req, err)
+		return
+	}
+	writePage(w, http.StatusOK, pageData{
+		User:      link.Labels[UserLabel],
+		ExpiresAt: link.Annotations[ExpiresAtAnnotation],
+	})
+}
+
+// redeem sends the kubeconfig of a link and deletes the link
+func (p *Portal) redeem(w http.ResponseWriter, req *http.Request) {
+	ctx := req.Context()
+	link, err := p.link(ctx, req.PathValue("token"))
+	if err != nil {
+		p.fail(w, req, err)
+		return
+	}
+	username := link.Labels[UserLabel]
+	data, err := p.kubeconfig(ctx, username)
+	if err != nil {
+		p.fail(w, req, err)
+		return
+	}
+	// Whoever deletes the link first gets the kubeconfig; the preconditions make the link
+	// single-use even when it is submitted twice at the same time
+	err = p.Writer.Delete(ctx, link, client.Preconditions{UID: &link.UID, ResourceVersion: &link.ResourceVersion})
+	if err != nil {
+		if apierrors.IsNotFound(err) || apierrors.IsConflict(err) {
+			err = errGone
+		}
+		p.fail(w, req, err)
+		return
+	}
+	logf.FromContext(ctx).Info("Kubeconfig downloaded through link", "user", username, "remoteAddr", req.RemoteAddr)
+
+	filename := username + ".kubeconfig"
+	contentType := "application/yaml"
+	if pgp.IsMessage(data) {
+		filename += ".asc"
+		contentType = "application/pgp-encrypted"
+	}
+	w.Header().Set("Content-Type", contentType)
+	w.Header().Set("Content-Disposition", fmt.Sprintf("attachment; filename=%q", filename))
+	_, _ = w.Write(data)
+}
+
+// portalError is shown to the user with its status
+type portalError struct {
+	status  int
+	message string
+}
+
+func (e *portalError) Error() string {
+	return e.message
+}
+
+var (
+	errGone = &portalError{status: http.StatusGone, message: linkGone}
+	// errNotReady keeps the link, so it can be used once the credentials are issued
+	errNotReady = &portalError{status: http.StatusServiceUnavailable,
+		message: "The kubeconfig is not issued yet. Try the link again in a few minutes."}
+)
+
+// link returns the unexpired link Secret of token. Expired links are deleted.
+func (p *Portal) link(ctx context.Context, token string) (*corev1.Secret, error) {
+	if token == "" {
+		return nil, errGone
+	}
+	var link corev1.Secret
+	err := p.Reader.Get(ctx, types.NamespacedName{Name: LinkSecretName(token), Namespace: p.Namespace()}, &link)
+	if apierrors.IsNotFound(err) {
+		return nil, errGone
+	} else if err != nil {
+		return nil, err
+	}
+	if link.Labels[LinkLabel] != "true" || link.Labels[UserLabel] == "" {
+		return nil, errGone
+	}
+	if linkExpired(&link, p.Now()) {
+		if err := p.Writer.Delete(ctx, &link); err != nil && !apierrors.IsNotFound(err) {
+			return nil, err
+		}
+		return nil, errGone
+	}
+	return &link, nil
+}
+
+// kubeconfig returns the kubeconfig in the credential Secret of username. Revoked and suspended
+// users get none.
+func (p *Portal) kubeconfig(ctx context.Context, username string) ([]byte, error) {
+	var user authv1alpha1.User
+	if err := p.Reader.Get(ctx, types.NamespacedName{Name: username}, &user); err != nil {
+		if apierrors.IsNotFound(err) {
+			return nil, errGone
+		}
+		return nil, err
+	}
+	if user.Spec.Revoked || user.Spec.Suspended {
+		return nil, errGone
+	}
+
+	key := types.NamespacedName{Name: naming.Suffixed(naming.MaxNameLength, username, "kubeconfig"), Namespace: p.Namespace()}
+	if ref := user.Status.CredentialSecret; ref != nil && ref.Name != "" {
+		key = types.NamespacedName{Name: ref.Name, Namespace: ref.Namespace}
+	}
+	var secret corev1.Secret
+	if err := p.Reader.Get(ctx, key, &secret); err != nil {
+		if apierrors.IsNotFound(err) {
+			return nil, errNotReady
+		}
+		return nil, err
+	}
+	if secret.Labels[UserLabel] != username {
+		return nil, fmt.Errorf("credential secret %s is not managed by KubeUser for user %s", key, username)
+	}
+	data, err := credentials.FindKubeconfig(user.Spec.Output, secret.Data)
+	if err != nil {
+		return nil, errNotReady
+	}
+	return data, nil
+}
+
+func (p *Portal) fail(w http.ResponseWriter, req *http.Request, err error) {
+	var portalErr *portalError
+	if errors.As(err, &portalErr) {
+		writePage(w, portalErr.status, pageData{Message: portalErr.message})
+		return
+	}
+	logf.FromContext(req.Context()).Error(err, "Failed to serve download link")
+	writePage(w, http.StatusInternalServerError, pageData{Message: "Something went wrong. Try the link again later."})
+}
+
+// Sweep deletes the links that expired
+func (p *Portal) Sweep(ctx context.Context) error {
+	var links corev1.SecretList
+	if err := p.Reader.List(ctx, &links, client.InNamespace(p.Namespace()), client.MatchingLabels{LinkLabel: "true"}); err != nil {
+		return err
+	}
+	now := p.Now()
+	for i := range links.Items {
+		if !linkExpired(&links.Items[i], now) {
+			continue
+		}
+		if err := p.Writer.Delete(ctx, &links.Items[i]); err != nil && !apierrors.IsNotFound(err) {
+			return err
+		}
+	}
+	return nil
+}
@@ -0,0 +1,188 @@
+/*
+Copyright 2025.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package portal
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"time"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+	corev1 "k8s.io/api/core/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	clientgoscheme "k8s.io/client-go/kubernetes/scheme"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+
+	authv1alpha1 "github.com/openkube-hub/KubeUser/api/v1alpha1"
+)
+
+const kubeconfig = `apiVersion: v1
+kind: Config
+clusters:
+- name: kubernetes
+  cluster:
+    server: https://api.example.com:6443
+contexts:
+- name: jane@kubernetes
+  context:
+    cluster: kubernetes
+    user: jane
+current-context: jane@kubernetes
+users:
+- name: jane
+  user:
+    token: secret
+`
+
+var _ = Describe("Portal", func() {
+	var (
+		c      client.Client
+		portal *Portal
+		token  string
+		now    time.Time
+	)
+
+	BeforeEach(func() {
+		scheme := runtime.NewScheme()
+		Expect(clientgoscheme.AddToScheme(scheme)).To(Succeed())
+		Expect(authv1alpha1.AddToScheme(scheme)).To(Succeed())
+		now = time.Date(2025, 6, 1, 12, 0, 0, 0, time.UTC)
+
+		var err error
+		token, err = NewToken()
+		Expect(err).NotTo(HaveOccurred())
+		link, err := NewLink(token, "jane", "kubeuser", time.Hour, now)
+		Expect(err).NotTo(HaveOccurred())
+		c = fake.NewClientBuilder().WithScheme(scheme).WithObjects(
+			&authv1alpha1.User{ObjectMeta: metav1.ObjectMeta{Name: "jane"}},
+			&corev1.Secret{
+				ObjectMeta: metav1.ObjectMeta{
+					Name:      "jane-kubeconfig",
+					Namespace: "kubeuser",
+					Labels:    map[string]string{UserLabel: "jane"},
+				},
+				Data: map[string][]byte{"config": []byte(kubeconfig)},
+			},
+			link,
+		).Build()
+		portal = New(c, c, func() string { return "kubeuser" })
+		portal.Now = func() time.Time { return now }
+	})
+
+	serve := func(method, token string) *httptest.ResponseRecorder {
+		rec := httptest.NewRecorder()
+		portal.ServeHTTP(rec, httptest.NewRequest(method, "https://portal/download/"+token, nil))
+		return rec
+	}
+
+	linkExists := func() bool {
+		err := c.Get(context.Background(), client.ObjectKey{Name: LinkSecretName(token), Namespace: "kubeuser"}, &corev1.Secret{})
+		if apierrors.IsNotFound(err) {
+			return false
+		}
+		Expect(err).NotTo(HaveOccurred())
+		return true
+	}
+
+	It("stores only a digest of the token", func() {
+		Expect(LinkSecretName(token)).NotTo(ContainSubstring(token))
+		_, err := NewLink(token, "jane", "kubeuser", 8*24*time.Hour, now)
+		Expect(err).To(HaveOccurred())
+	})
+
+	It("shows a download page without using up the link", func() {
+		rec := serve(http.MethodGet, token)
+		Expect(rec.Code).To(Equal(http.StatusOK))
+		Expect(rec.Body.String()).To(ContainSubstring("Kubeconfig for jane"))
+		Expect(rec.Body.String()).To(ContainSubstring(`<form method="post">`))
+		Expect(rec.Header().Get("Referrer-Policy")).To(Equal("no-referrer"))
+		Expect(linkExists()).To(BeTrue())
+	})
+
+	It("downloads the kubeconfig once", func() {
+		rec := serve(http.MethodPost, token)
+		Expect(rec.Code).To(Equal(http.StatusOK))
+		Expect(rec.Body.String()).To(Equal(kubeconfig))
+		Expect(rec.Header().Get("Content-Disposition")).To(Equal(`attachment; filename="jane.kubeconfig"`))
+		Expect(rec.Header().Get("Cache-Control")).To(Equal("no-store"))
+		Expect(linkExists()).To(BeFalse())
+
+		Expect(serve(http.MethodPost, token).Code).To(Equal(http.StatusGone))
+		Expect(serve(http.MethodGet, token).Code).To(Equal(http.StatusGone))
+	})
+
+	It("hands out the kubeconfig only once to concurrent requests", func() {
+		codes := make(chan int, 5)
+		var wg sync.WaitGroup
+		for range 5 {
+			wg.Add(1)
+			go func() {
+				defer wg.Done()
+				codes <- serve(http.MethodPost, token).Code
+			}()
+		}
+		wg.Wait()
+		close(codes)
+		ok := 0
+		for code := range codes {
+			if code == http.StatusOK {
+				ok++
+			} else {
+				Expect(code).To(Equal(http.StatusGone))
+			}
+		}
+		Expect(ok).To(Equal(1))
+	})
+
+	It("rejects unknown and expired links", func() {
+		Expect(serve(http.MethodPost, "guessed").Code).To(Equal(http.StatusGone))
+
+		now = now.Add(2 * time.Hour)
+		Expect(serve(http.MethodPost, token).Code).To(Equal(http.StatusGone))
+		Expect(linkExists()).To(BeFalse())
+	})
+
+	It("keeps the link until the kubeconfig is issued", func() {
+		Expect(c.Delete(context.Background(), &corev1.Secret{
+			ObjectMeta: metav1.ObjectMeta{Name: "jane-kubeconfig", Namespace: "kubeuser"},
+		})).To(Succeed())
+		Expect(serve(http.MethodPost, token).Code).To(Equal(http.StatusServiceUnavailable))
+		Expect(linkExists()).To(BeTrue())
+	})
+
+	It("gives revoked users nothing", func() {
+		var user authv1alpha1.User
+		Expect(c.Get(context.Background(), client.ObjectKey{Name: "jane"}, &user)).To(Succeed())
+		user.Spec.Revoked = true
+		Expect(c.Update(context.Background(), &user)).To(Succeed())
+		Expect(serve(http.MethodPost, token).Code).To(Equal(http.StatusGone))
+	})
+
+	It("sweeps expired links", func() {
+		Expect(portal.Sweep(context.Background())).To(Succeed())
+		Expect(linkExists()).To(BeTrue())
+		now = now.Add(2 * time.Hour)
+		Expect(portal.Sweep(context.Background())).To(Succeed())
+		Expect(linkExists()).To(BeFalse())
+	})
+})
@@ -0,0 +1,105 @@
+/*
+Copyright 2025.
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+*/
+
+package portal
+
+import (
+	"context"
+	"crypto/tls"
+	"errors"
+	"fmt"
+	"net"
+	"net/http"
+	"path/filepath"
+	"time"
+
+	"sigs.k8s.io/controller-runtime/pkg/certwatcher"
+	logf "sigs.k8s.io/controller-runtime/pkg/log"
+)
+
+// sweepInterval is how often expired links are deleted
+const sweepInterval = 10 * time.Minute
+
+// Server serves a Portal over TLS and deletes expired links. It runs on every replica, not only
+// the leader.
+type Server struct {
+	// Addr is the address the portal listens on, e.g. :8445
+	Addr string
+	// CertDir, CertName and KeyName locate the portal's serving certificate, which is reloaded
+	// when it changes
+	CertDir  string
+	CertName string
+	KeyName  string
+	// TLSOpts are applied to the TLS configuration, like those of the webhook server
+	TLSOpts []func(*tls.Config)
+
+	// Portal's expired links are swept
+	Portal *Portal
+	// Handler serves the requests; it defaults to Portal and lets other APIs share the listener
+	Handler http.Handler
+}
+
+// NeedLeaderElection implements manager.LeaderElectionRunnable
+func (s *Server) NeedLeaderElection() bool {
+	return false
+}
+
+// Start implements manager.Runnable; it serves until ctx is done
+func (s *Server) Start(ctx context.Context) error {
+	logger := logf.FromContext(ctx).WithName("portal")
+
+	watcher, err := certwatcher.New(filepath.Join(s.CertDir, s.CertName), filepath.Join(s.CertDir, s.KeyName))
+	if err != nil {
+		return fmt.Errorf("failed to load the portal certificate: %w", err)
+	}
+	go func() {
+		if err := watcher.Start(ctx); err != nil {
+			logger.Error(err, "Certificate watcher of the portal failed")
+		}
+	}()
+
+	config := &tls.Config{MinVersion: tls.VersionTLS12, GetCertificate: watcher.GetCertificate}
+	for _, opt := range s.TLSOpts {
+		opt(config)
+	}
+	listener, err := tls.Listen("tcp", s.Addr, config)
+	if err != nil {
+		return fmt.Errorf("failed to listen for the portal: %w", err)
+	}
+
+	handler := s.Handler
+	if handler == nil {
+		handler = s.Portal
+	}
+	server := &http.Server{
+		Handler:           handler,
+		ReadHeaderTimeout: 10 * time.Second,
+		BaseContext:       func(net.Listener) context.Context { return logf.IntoContext(context.Background(), logger) },
+	}
+	go func() {
+		ticker := time.NewTicker(sweepInterval)
+		defer ticker.Stop()
+		for {
+			select {
+			case <-ctx.Done():
+				shutdownCtx, cancel := context.WithTimeout(context.Background(), 30*time.Second)
+				defer cancel()
+				_ = server.Shutdown(shutdownCtx)
+				return
+			case <-ticker.C:
+				if err := s.Portal.Sweep(ctx); err != nil {
+					logger.Error(err, "Failed to delete expired download links")
+				}
+			}
+		}
+	}()
+
+	logger.Info("Serving portal", "addr", s.Addr)
+	if err := server.Serve(listener); err != nil && !errors.Is(err, http.ErrServerClosed) {
+		return err
+	}
+	return nil
+}
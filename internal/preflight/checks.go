@@ -0,0 +1,243 @@
+/*
+Copyright 2025.
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+*/
+
+package preflight
+
+import (
+	"context"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/pem"
+	"fmt"
+	"strings"
+
+	appsv1 "k8s.io/api/apps/v1"
+	authorizationv1 "k8s.io/api/authorization/v1"
+	certv1 "k8s.io/api/certificates/v1"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/apimachinery/pkg/util/intstr"
+	"k8s.io/client-go/discovery"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/healthz"
+
+	"github.com/openkube-hub/KubeUser/internal/ca"
+)
+
+// webhookPortName is the container port name used by the manifests and chart
+const webhookPortName = "webhook-server"
+
+// Permission is a single RBAC verb the operator needs
+type Permission struct {
+	Group        string
+	Resource     string
+	Subresource  string
+	Verb         string
+	ResourceName string
+	// Namespace is where the permission is needed; empty needs it cluster-wide
+	Namespace string
+}
+
+func (p Permission) String() string {
+	resource := p.Resource
+	if p.Subresource != "" {
+		resource += "/" + p.Subresource
+	}
+	if p.Group != "" {
+		resource += "." + p.Group
+	}
+	if p.ResourceName != "" {
+		resource += "/" + p.ResourceName
+	}
+	if p.Namespace != "" {
+		return fmt.Sprintf("%s %s in namespace %s", p.Verb, resource, p.Namespace)
+	}
+	return fmt.Sprintf("%s %s", p.Verb, resource)
+}
+
+// RequiredPermissions returns the permissions of the kubebuilder RBAC markers the reconciler
+// cannot work without, for per-user resources in namespace. A test checks config/rbac grants them.
+func RequiredPermissions(namespace string) []Permission {
+	permissions := append([]Permission(nil), clusterPermissions...)
+	return append(permissions,
+		Permission{Group: "rbac.authorization.k8s.io", Resource: "roles", Verb: "create", Namespace: namespace},
+		Permission{Group: "rbac.authorization.k8s.io", Resource: "roles", Verb: "delete", Namespace: namespace},
+	)
+}
+
+// clusterPermissions are the required permissions the operator holds cluster-wide
+var clusterPermissions = []Permission{
+	{Group: "auth.openkube.io", Resource: "users", Verb: "update"},
+	{Group: "auth.openkube.io", Resource: "users", Subresource: "status", Verb: "update"},
+	{Resource: "secrets", Verb: "create"},
+	{Resource: "secrets", Verb: "delete"},
+	{Group: "rbac.authorization.k8s.io", Resource: "rolebindings", Verb: "create"},
+	{Group: "rbac.authorization.k8s.io", Resource: "clusterrolebindings", Verb: "create"},
+	{Group: "rbac.authorization.k8s.io", Resource: "clusterroles", Verb: "bind"},
+	{Group: "certificates.k8s.io", Resource: "certificatesigningrequests", Verb: "create"},
+	{Group: "certificates.k8s.io", Resource: "certificatesigningrequests", Subresource: "approval", Verb: "update"},
+	{Group: "certificates.k8s.io", Resource: "signers", Verb: "approve", ResourceName: certv1.KubeAPIServerClientSignerName},
+}
+
+// CSRSignerCheck verifies that the certificates.k8s.io API is served and accepts a
+// CertificateSigningRequest for the kube-apiserver client signer (dry-run, nothing is persisted).
+func CSRSignerCheck(disco discovery.DiscoveryInterface, c client.Client) Check {
+	return Check{
+		Name: "csr-signer",
+		Run: func(ctx context.Context) error {
+			resources, err := disco.ServerResourcesForGroupVersion(certv1.SchemeGroupVersion.String())
+			if err != nil {
+				return fmt.Errorf("certificates.k8s.io/v1 is not served: %w", err)
+			}
+			served := false
+			for _, r := range resources.APIResources {
+				if r.Name == "certificatesigningrequests" {
+					served = true
+				}
+			}
+			if !served {
+				return fmt.Errorf("certificatesigningrequests are not served by %s", certv1.SchemeGroupVersion)
+			}
+
+			request, err := probeCSR()
+			if err != nil {
+				return err
+			}
+			csr := &certv1.CertificateSigningRequest{
+				ObjectMeta: metav1.ObjectMeta{GenerateName: "kubeuser-preflight-"},
+				Spec: certv1.CertificateSigningRequestSpec{
+					Request:    request,
+					Usages:     []certv1.KeyUsage{certv1.UsageClientAuth},
+					SignerName: certv1.KubeAPIServerClientSignerName,
+				},
+			}
+			if err := c.Create(ctx, csr, client.DryRunAll); err != nil {
+				return fmt.Errorf("signer %s rejected a dry-run CSR: %w", certv1.KubeAPIServerClientSignerName, err)
+			}
+			return nil
+		},
+	}
+}
+
+// RBACCheck verifies through SelfSubjectAccessReviews that the operator holds every required permission
+func RBACCheck(c client.Client, permissions []Permission) Check {
+	return Check{
+		Name: "rbac",
+		Run: func(ctx context.Context) error {
+			var missing []string
+			for _, p := range permissions {
+				review := &authorizationv1.SelfSubjectAccessReview{
+					Spec: authorizationv1.SelfSubjectAccessReviewSpec{
+						ResourceAttributes: &authorizationv1.ResourceAttributes{
+							Namespace:   p.Namespace,
+							Group:       p.Group,
+							Resource:    p.Resource,
+							Subresource: p.Subresource,
+							Verb:        p.Verb,
+							Name:        p.ResourceName,
+						},
+					},
+				}
+				if err := c.Create(ctx, review); err != nil {
+					return fmt.Errorf("failed to review permission %q: %w", p, err)
+				}
+				if !review.Status.Allowed {
+					missing = append(missing, p.String())
+				}
+			}
+			if len(missing) > 0 {
+				return fmt.Errorf("missing permissions: %s", strings.Join(missing, ", "))
+			}
+			return nil
+		},
+	}
+}
+
+// CACheck verifies that a cluster CA can be resolved for generated kubeconfigs
+func CACheck(resolver *ca.Resolver) Check {
+	return Check{
+		Name: "cluster-ca",
+		Run: func(ctx context.Context) error {
+			_, _, err := resolver.Resolve(ctx)
+			return err
+		},
+	}
+}
+
+// WebhookCheck verifies that the webhook Service exists, targets the webhook server port,
+// and that the local webhook server is serving TLS.
+func WebhookCheck(reader client.Reader, namespace, serviceName string, port int, started healthz.Checker) Check {
+	return Check{
+		Name: "webhook",
+		Run: func(ctx context.Context) error {
+			var svc corev1.Service
+			if err := reader.Get(ctx, types.NamespacedName{Namespace: namespace, Name: serviceName}, &svc); err != nil {
+				return fmt.Errorf("webhook service %s/%s: %w", namespace, serviceName, err)
+			}
+			targeted := false
+			for _, p := range svc.Spec.Ports {
+				switch {
+				case p.TargetPort.Type == intstr.String:
+					targeted = targeted || p.TargetPort.StrVal == webhookPortName
+				case p.TargetPort.IntVal == 0:
+					targeted = targeted || int(p.Port) == port
+				default:
+					targeted = targeted || int(p.TargetPort.IntVal) == port
+				}
+			}
+			if !targeted {
+				return fmt.Errorf("webhook service %s/%s does not target port %d", namespace, serviceName, port)
+			}
+			if started != nil {
+				if err := started(nil); err != nil {
+					return fmt.Errorf("webhook server is not serving: %w", err)
+				}
+			}
+			return nil
+		},
+	}
+}
+
+// Replicas returns how many replicas the ReplicaSet of the named pod runs, so that a controller
+// without leader election can refuse to run beside others. A pod that no ReplicaSet owns
+// counts as a single replica.
+func Replicas(ctx context.Context, reader client.Reader, namespace, podName string) (int32, error) {
+	var pod corev1.Pod
+	if err := reader.Get(ctx, types.NamespacedName{Namespace: namespace, Name: podName}, &pod); err != nil {
+		return 0, fmt.Errorf("pod %s/%s: %w", namespace, podName, err)
+	}
+	owner := metav1.GetControllerOf(&pod)
+	if owner == nil || owner.Kind != "ReplicaSet" {
+		return 1, nil
+	}
+	var replicaSet appsv1.ReplicaSet
+	if err := reader.Get(ctx, types.NamespacedName{Namespace: namespace, Name: owner.Name}, &replicaSet); err != nil {
+		return 0, fmt.Errorf("replicaset %s/%s: %w", namespace, owner.Name, err)
+	}
+	if replicaSet.Spec.Replicas == nil {
+		return 1, nil
+	}
+	return *replicaSet.Spec.Replicas, nil
+}
+
+// probeCSR builds a throwaway CSR used for the dry-run signer check
+func probeCSR() ([]byte, error) {
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		return nil, err
+	}
+	der, err := x509.CreateCertificateRequest(rand.Reader, &x509.CertificateRequest{
+		Subject: pkix.Name{CommonName: "kubeuser-preflight"},
+	}, key)
+	if err != nil {
+		return nil, err
+	}
+	return pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE REQUEST", Bytes: der}), nil
+}
@@ -0,0 +1,128 @@
+/*
+Copyright 2025.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package preflight
+
+import (
+	"bytes"
+	"context"
+	"os"
+	"slices"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+	authorizationv1 "k8s.io/api/authorization/v1"
+	rbacv1 "k8s.io/api/rbac/v1"
+	"k8s.io/client-go/kubernetes/scheme"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+	"sigs.k8s.io/controller-runtime/pkg/client/interceptor"
+	"sigs.k8s.io/yaml"
+)
+
+// grants reports whether rule grants p
+func grants(rule rbacv1.PolicyRule, p Permission) bool {
+	matches := func(values []string, value string) bool {
+		return slices.Contains(values, rbacv1.ResourceAll) || slices.Contains(values, value)
+	}
+	resource := p.Resource
+	if p.Subresource != "" {
+		resource += "/" + p.Subresource
+	}
+	return matches(rule.APIGroups, p.Group) && matches(rule.Resources, resource) && matches(rule.Verbs, p.Verb) &&
+		(len(rule.ResourceNames) == 0 || slices.Contains(rule.ResourceNames, p.ResourceName))
+}
+
+var _ = Describe("RequiredPermissions", func() {
+	It("are granted by the manager role of config/rbac", func() {
+		data, err := os.ReadFile("../../config/rbac/role.yaml")
+		Expect(err).NotTo(HaveOccurred())
+		// role.yaml holds the ClusterRole and the Role in the kubeuser namespace
+		// controller-gen generates from the RBAC markers
+		var roles []rbacv1.Role
+		for _, document := range bytes.Split(data, []byte("\n---\n")) {
+			var role rbacv1.Role
+			Expect(yaml.Unmarshal(document, &role)).To(Succeed())
+			if role.Kind != "" {
+				roles = append(roles, role)
+			}
+		}
+		Expect(roles).To(ContainElement(HaveField("Kind", "ClusterRole")))
+
+		for _, p := range RequiredPermissions("kubeuser") {
+			granted := slices.ContainsFunc(roles, func(role rbacv1.Role) bool {
+				if role.Kind == "Role" && role.Namespace != p.Namespace {
+					return false
+				}
+				return slices.ContainsFunc(role.Rules, func(rule rbacv1.PolicyRule) bool { return grants(rule, p) })
+			})
+			Expect(granted).To(BeTrue(), "config/rbac/role.yaml does not grant %s", p)
+		}
+	})
+
+	It("need Roles in the namespace of per-user resources only", func() {
+		for _, p := range RequiredPermissions("users") {
+			if p.Resource == "roles" {
+				Expect(p.Namespace).To(Equal("users"))
+				Expect(p.String()).To(HaveSuffix(" in namespace users"))
+			} else {
+				Expect(p.Namespace).To(BeEmpty())
+			}
+		}
+	})
+})
+
+var _ = Describe("RBACCheck", func() {
+	var reviews []authorizationv1.ResourceAttributes
+
+	// rbacClient allows the SelfSubjectAccessReviews allow accepts
+	rbacClient := func(allow func(authorizationv1.ResourceAttributes) bool) client.Client {
+		reviews = nil
+		return fake.NewClientBuilder().WithScheme(scheme.Scheme).WithInterceptorFuncs(interceptor.Funcs{
+			Create: func(ctx context.Context, c client.WithWatch, obj client.Object, opts ...client.CreateOption) error {
+				review, ok := obj.(*authorizationv1.SelfSubjectAccessReview)
+				if !ok {
+					return c.Create(ctx, obj, opts...)
+				}
+				reviews = append(reviews, *review.Spec.ResourceAttributes)
+				review.Status.Allowed = allow(*review.Spec.ResourceAttributes)
+				return nil
+			},
+		}).Build()
+	}
+
+	It("passes holding every permission", func() {
+		check := RBACCheck(rbacClient(func(authorizationv1.ResourceAttributes) bool { return true }),
+			RequiredPermissions("kubeuser"))
+		Expect(check.Run(context.Background())).To(Succeed())
+		Expect(reviews).To(ContainElement(authorizationv1.ResourceAttributes{
+			Namespace: "kubeuser", Group: "rbac.authorization.k8s.io", Resource: "roles", Verb: "create",
+		}))
+		Expect(reviews).To(ContainElement(authorizationv1.ResourceAttributes{
+			Group: "certificates.k8s.io", Resource: "signers", Verb: "approve", Name: "kubernetes.io/kube-apiserver-client",
+		}))
+	})
+
+	It("lists the missing permissions", func() {
+		check := RBACCheck(rbacClient(func(a authorizationv1.ResourceAttributes) bool {
+			return a.Resource != "roles" && a.Subresource != "approval"
+		}), RequiredPermissions("kubeuser"))
+		Expect(check.Run(context.Background())).To(MatchError("missing permissions: " +
+			"update certificatesigningrequests/approval.certificates.k8s.io, " +
+			"create roles.rbac.authorization.k8s.io in namespace kubeuser, " +
+			"delete roles.rbac.authorization.k8s.io in namespace kubeuser"))
+	})
+})
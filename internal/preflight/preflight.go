@@ -0,0 +1,168 @@
+/*
+Copyright 2025.
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+*/
+
+// Package preflight verifies on startup that the cluster and the operator installation
+// provide everything user provisioning depends on.
+package preflight
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	logf "sigs.k8s.io/controller-runtime/pkg/log"
+
+	"github.com/openkube-hub/KubeUser/internal/operatorstatus"
+)
+
+const (
+	// ConditionType is the operator condition reporting the preflight result
+	ConditionType = "PreflightPassed"
+
+	// DefaultRetryInterval is how often failed checks are retried
+	DefaultRetryInterval = 30 * time.Second
+)
+
+// Check is a single named preflight verification
+type Check struct {
+	Name string
+	Run  func(ctx context.Context) error
+}
+
+// Checker runs all checks on startup, retries the failing ones, and exposes the
+// outcome as a readiness check and an operator condition.
+type Checker struct {
+	Checks        []Check
+	Reporter      *operatorstatus.Reporter
+	RetryInterval time.Duration
+
+	mu       sync.RWMutex
+	failures map[string]error
+	ran      bool
+}
+
+// Start implements manager.Runnable
+func (c *Checker) Start(ctx context.Context) error {
+	logger := logf.FromContext(ctx).WithName("preflight")
+	interval := c.RetryInterval
+	if interval <= 0 {
+		interval = DefaultRetryInterval
+	}
+
+	pending := c.Checks
+	for {
+		pending = c.run(ctx, pending)
+		if len(pending) == 0 {
+			logger.Info("All preflight checks passed")
+			return nil
+		}
+		for _, check := range pending {
+			logger.Error(c.failure(check.Name), "Preflight check failed, retrying", "check", check.Name, "retryIn", interval)
+		}
+
+		select {
+		case <-ctx.Done():
+			return nil
+		case <-time.After(interval):
+		}
+	}
+}
+
+// NeedLeaderElection implements manager.LeaderElectionRunnable; every replica checks its own setup
+func (c *Checker) NeedLeaderElection() bool {
+	return false
+}
+
+// ReadyzCheck fails until every check has passed at least once
+func (c *Checker) ReadyzCheck(_ *http.Request) error {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+	if !c.ran {
+		return errors.New("preflight checks have not completed yet")
+	}
+	return c.summaryLocked()
+}
+
+// run executes the given checks and returns those that failed
+func (c *Checker) run(ctx context.Context, checks []Check) []Check {
+	var failed []Check
+	results := make(map[string]error, len(checks))
+	for _, check := range checks {
+		err := check.Run(ctx)
+		results[check.Name] = err
+		if err != nil {
+			failed = append(failed, check)
+		}
+	}
+
+	c.mu.Lock()
+	if c.failures == nil {
+		c.failures = map[string]error{}
+	}
+	for name, err := range results {
+		if err != nil {
+			c.failures[name] = err
+		} else {
+			delete(c.failures, name)
+		}
+	}
+	c.ran = true
+	summary := c.summaryLocked()
+	c.mu.Unlock()
+
+	c.report(ctx, summary)
+	return failed
+}
+
+func (c *Checker) failure(name string) error {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+	return c.failures[name]
+}
+
+// summaryLocked joins all current failures in a stable order
+func (c *Checker) summaryLocked() error {
+	if len(c.failures) == 0 {
+		return nil
+	}
+	names := make([]string, 0, len(c.failures))
+	for name := range c.failures {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	msgs := make([]string, 0, len(names))
+	for _, name := range names {
+		msgs = append(msgs, fmt.Sprintf("%s: %v", name, c.failures[name]))
+	}
+	return errors.New(strings.Join(msgs, "; "))
+}
+
+func (c *Checker) report(ctx context.Context, summary error) {
+	if c.Reporter == nil {
+		return
+	}
+	condition := metav1.Condition{
+		Type:    ConditionType,
+		Status:  metav1.ConditionTrue,
+		Reason:  "ChecksPassed",
+		Message: "All preflight checks passed",
+	}
+	if summary != nil {
+		condition.Status = metav1.ConditionFalse
+		condition.Reason = "ChecksFailed"
+		condition.Message = summary.Error()
+	}
+	if err := c.Reporter.SetCondition(ctx, condition); err != nil {
+		logf.FromContext(ctx).Error(err, "Failed to report preflight condition")
+	}
+}
@@ -0,0 +1,159 @@
+/*
+Copyright 2025.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package preflight
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"sync"
+	"time"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/meta"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/client-go/kubernetes/scheme"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+
+	"github.com/openkube-hub/KubeUser/internal/operatorstatus"
+)
+
+// flakyCheck fails until it has run failures times
+type flakyCheck struct {
+	mu       sync.Mutex
+	runs     int
+	failures int
+}
+
+func (f *flakyCheck) check(name string) Check {
+	return Check{Name: name, Run: func(context.Context) error {
+		f.mu.Lock()
+		defer f.mu.Unlock()
+		f.runs++
+		if f.runs <= f.failures {
+			return errors.New("not yet")
+		}
+		return nil
+	}}
+}
+
+func (f *flakyCheck) count() int {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	return f.runs
+}
+
+var _ = Describe("Checker", func() {
+	var (
+		ctx context.Context
+		c   client.Client
+	)
+
+	BeforeEach(func() {
+		ctx = context.Background()
+		c = fake.NewClientBuilder().WithScheme(scheme.Scheme).Build()
+	})
+
+	// condition returns the preflight condition reported to the operator status ConfigMap
+	condition := func() *metav1.Condition {
+		var cm corev1.ConfigMap
+		Expect(c.Get(ctx, types.NamespacedName{Name: operatorstatus.ConfigMapName, Namespace: "kubeuser"}, &cm)).To(Succeed())
+		var conditions []metav1.Condition
+		Expect(json.Unmarshal([]byte(cm.Data[operatorstatus.KeyConditions]), &conditions)).To(Succeed())
+		return meta.FindStatusCondition(conditions, ConditionType)
+	}
+
+	It("is not ready before the checks have run", func() {
+		checker := &Checker{}
+		Expect(checker.ReadyzCheck(nil)).To(MatchError(ContainSubstring("have not completed")))
+	})
+
+	It("becomes ready once every check has passed", func() {
+		checker := &Checker{
+			Checks:   []Check{(&flakyCheck{}).check("one"), (&flakyCheck{}).check("two")},
+			Reporter: &operatorstatus.Reporter{Client: c, Namespace: "kubeuser"},
+		}
+		Expect(checker.Start(ctx)).To(Succeed())
+		Expect(checker.ReadyzCheck(nil)).To(Succeed())
+		Expect(condition()).To(And(
+			HaveField("Status", metav1.ConditionTrue),
+			HaveField("Reason", "ChecksPassed"),
+		))
+	})
+
+	It("retries only the failing checks until they pass", func() {
+		passing, failing := &flakyCheck{}, &flakyCheck{failures: 2}
+		checker := &Checker{
+			Checks:        []Check{passing.check("passing"), failing.check("failing")},
+			Reporter:      &operatorstatus.Reporter{Client: c, Namespace: "kubeuser"},
+			RetryInterval: time.Millisecond,
+		}
+		Expect(checker.Start(ctx)).To(Succeed())
+		Expect(passing.count()).To(Equal(1))
+		Expect(failing.count()).To(Equal(3))
+		Expect(checker.ReadyzCheck(nil)).To(Succeed())
+		Expect(condition().Status).To(Equal(metav1.ConditionTrue))
+	})
+
+	It("reports every failure until the checks pass", func() {
+		first, second := &flakyCheck{failures: 1}, &flakyCheck{failures: 1000}
+		checker := &Checker{
+			Checks:        []Check{second.check("second"), first.check("first")},
+			Reporter:      &operatorstatus.Reporter{Client: c, Namespace: "kubeuser"},
+			RetryInterval: time.Hour,
+		}
+		ctx, cancel := context.WithCancel(ctx)
+		done := make(chan error)
+		go func() { done <- checker.Start(ctx) }()
+
+		Eventually(func() error { return checker.ReadyzCheck(nil) }).
+			Should(MatchError("first: not yet; second: not yet"))
+		Expect(condition()).To(And(
+			HaveField("Status", metav1.ConditionFalse),
+			HaveField("Reason", "ChecksFailed"),
+			HaveField("Message", "first: not yet; second: not yet"),
+		))
+
+		// Stopping during the retry interval ends Start without passing
+		cancel()
+		Eventually(done).Should(Receive(BeNil()))
+		Expect(checker.ReadyzCheck(nil)).To(HaveOccurred())
+		Expect(first.count()).To(Equal(1))
+	})
+
+	It("drops failures once their check passes on a retry", func() {
+		first, second := &flakyCheck{failures: 1}, &flakyCheck{failures: 1000}
+		checker := &Checker{
+			Checks:        []Check{first.check("first"), second.check("second")},
+			RetryInterval: time.Millisecond,
+		}
+		ctx, cancel := context.WithCancel(ctx)
+		defer cancel()
+		go func() { _ = checker.Start(ctx) }()
+
+		Eventually(first.count).Should(Equal(2))
+		Eventually(func() error { return checker.ReadyzCheck(nil) }).Should(MatchError("second: not yet"))
+	})
+
+	It("runs on every replica", func() {
+		Expect((&Checker{}).NeedLeaderElection()).To(BeFalse())
+	})
+})
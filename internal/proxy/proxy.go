@@ -0,0 +1,273 @@
+/*
+Copyright 2025.
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+*/
+
+// Package proxy forwards the requests of KubeUser users to the API server, impersonating the
+// user they authenticate as, with a client certificate KubeUser issued or a bearer token. Every
+// request is checked against the current User and certificate inventory, so suspending or
+// revoking a User, or superseding a certificate, takes effect immediately instead of when the
+// credential expires, and the groups of the User follow its spec.
+package proxy
+
+import (
+	"context"
+	"crypto/x509"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/http/httputil"
+	"net/url"
+	"strings"
+
+	authenticationv1 "k8s.io/api/authentication/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/types"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	logf "sigs.k8s.io/controller-runtime/pkg/log"
+
+	authv1alpha1 "github.com/openkube-hub/KubeUser/api/v1alpha1"
+	"github.com/openkube-hub/KubeUser/internal/operatorconfig"
+)
+
+// TokenReviewer authenticates a bearer token the way the API server does, e.g. with a
+// TokenReview. It returns an Unauthorized error for tokens that do not authenticate.
+type TokenReviewer func(ctx context.Context, token string) (authenticationv1.UserInfo, error)
+
+// Reviewing returns a TokenReviewer creating TokenReviews through c
+func Reviewing(c client.Client) TokenReviewer {
+	return func(ctx context.Context, token string) (authenticationv1.UserInfo, error) {
+		review := &authenticationv1.TokenReview{Spec: authenticationv1.TokenReviewSpec{Token: token}}
+		if err := c.Create(ctx, review); err != nil {
+			return authenticationv1.UserInfo{}, fmt.Errorf("failed to review the bearer token: %w", err)
+		}
+		if !review.Status.Authenticated {
+			return authenticationv1.UserInfo{}, apierrors.NewUnauthorized("the bearer token is not valid")
+		}
+		return review.Status.User, nil
+	}
+}
+
+// Identity is who the proxy forwards a request as
+type Identity struct {
+	// User is the name of the KubeUser User the request was authenticated for
+	User string
+	// Username and Groups are impersonated
+	Username string
+	Groups   []string
+}
+
+// Proxy authenticates requests by the client certificate KubeUser issued, or by a bearer token
+// of a User, and forwards them to the API server impersonating the user with the groups of its
+// User
+type Proxy struct {
+	// Reader looks up Users and IssuedCertificates
+	Reader client.Reader
+	// Reviewer authenticates bearer tokens; without one only client certificates are accepted
+	Reviewer TokenReviewer
+	// Target is the API server
+	Target *url.URL
+	// Transport authenticates to the API server as an identity allowed to impersonate users
+	// and groups
+	Transport http.RoundTripper
+
+	reverse *httputil.ReverseProxy
+}
+
+// New returns a Proxy forwarding to target through transport
+func New(reader client.Reader, reviewer TokenReviewer, target *url.URL, transport http.RoundTripper) *Proxy {
+	p := &Proxy{Reader: reader, Reviewer: reviewer, Target: target, Transport: transport}
+	p.reverse = &httputil.ReverseProxy{
+		Rewrite: func(r *httputil.ProxyRequest) {
+			r.SetURL(target)
+			// Never pass on credentials or impersonation the client sent itself
+			r.Out.Header.Del("Authorization")
+			for name := range r.Out.Header {
+				if strings.HasPrefix(name, "Impersonate-") {
+					r.Out.Header.Del(name)
+				}
+			}
+			identity, _ := r.In.Context().Value(identityKey{}).(Identity)
+			r.Out.Header.Set(authenticationv1.ImpersonateUserHeader, identity.Username)
+			for _, group := range identity.Groups {
+				r.Out.Header.Add(authenticationv1.ImpersonateGroupHeader, group)
+			}
+		},
+		Transport: transport,
+		// Stream watches and logs as they arrive
+		FlushInterval: -1,
+		ErrorHandler: func(w http.ResponseWriter, req *http.Request, err error) {
+			logf.FromContext(req.Context()).Error(err, "Failed to forward request to the API server", "path", req.URL.Path)
+			writeStatus(w, apierrors.NewServiceUnavailable("the API server cannot be reached"))
+		},
+	}
+	return p
+}
+
+// identityKey is the context key of the Identity to impersonate, passed from ServeHTTP to the
+// request rewrite
+type identityKey struct{}
+
+// ServeHTTP implements http.Handler. A client certificate takes precedence over a bearer token.
+func (p *Proxy) ServeHTTP(w http.ResponseWriter, req *http.Request) {
+	var identity Identity
+	var err error
+	token, hasToken := bearerToken(req)
+	switch {
+	case req.TLS != nil && len(req.TLS.VerifiedChains) > 0:
+		identity, err = p.Authenticate(req.Context(), req.TLS.VerifiedChains[0][0])
+	case hasToken && p.Reviewer != nil:
+		identity, err = p.AuthenticateToken(req.Context(), token)
+	default:
+		err = apierrors.NewUnauthorized("a client certificate issued by KubeUser or a bearer token is required")
+	}
+	if err != nil {
+		logf.FromContext(req.Context()).V(1).Info("Rejected proxy request", "reason", err.Error())
+		var status apierrors.APIStatus
+		if !errors.As(err, &status) {
+			status = apierrors.NewInternalError(err)
+		}
+		writeStatus(w, status)
+		return
+	}
+	p.reverse.ServeHTTP(w, req.WithContext(context.WithValue(req.Context(), identityKey{}, identity)))
+}
+
+// bearerToken returns the token of the request's Authorization header, if it has one
+func bearerToken(req *http.Request) (string, bool) {
+	scheme, token, ok := strings.Cut(req.Header.Get("Authorization"), " ")
+	if !ok || !strings.EqualFold(scheme, "Bearer") || strings.TrimSpace(token) == "" {
+		return "", false
+	}
+	return strings.TrimSpace(token), true
+}
+
+// Authenticate returns the identity of a request made with cert. It fails when KubeUser has no
+// record of the certificate or revoked it, or when the User is gone, suspended or revoked. The
+// User is found by the username template, or by the common name itself for certificates issued
+// before the template changed.
+func (p *Proxy) Authenticate(ctx context.Context, cert *x509.Certificate) (Identity, error) {
+	commonName := cert.Subject.CommonName
+	serial := cert.SerialNumber.Text(16)
+	candidates := []string{commonName}
+	if name, ok := operatorconfig.UserName(commonName); ok && name != commonName {
+		candidates = []string{name, commonName}
+	}
+
+	var issued authv1alpha1.IssuedCertificate
+	var username string
+	var err error
+	for _, username = range candidates {
+		if err = p.Reader.Get(ctx, types.NamespacedName{Name: username + "-" + serial}, &issued); !apierrors.IsNotFound(err) {
+			break
+		}
+	}
+	if apierrors.IsNotFound(err) || (err == nil && (issued.Spec.User != username || issued.Spec.SerialNumber != serial ||
+		issued.Spec.CommonName != commonName)) {
+		return Identity{}, apierrors.NewUnauthorized(fmt.Sprintf("certificate %s was not issued by KubeUser", serial))
+	}
+	if err != nil {
+		return Identity{}, fmt.Errorf("failed to look up certificate %s: %w", serial, err)
+	}
+	if issued.Status.RevokedAt != nil {
+		return Identity{}, apierrors.NewUnauthorized(fmt.Sprintf("certificate %s was revoked (%s)", serial, issued.Status.Reason))
+	}
+
+	return p.AuthenticateUser(ctx, username, commonName)
+}
+
+// AuthenticateUser returns the identity of the User name, which authenticated as username by
+// other means, e.g. a refresh token KubeUser issued. It fails when the User is gone, suspended
+// or revoked.
+func (p *Proxy) AuthenticateUser(ctx context.Context, name, username string) (Identity, error) {
+	user, err := p.activeUser(ctx, name)
+	if err != nil {
+		return Identity{}, err
+	}
+	return Identity{User: name, Username: username, Groups: userGroups(user)}, nil
+}
+
+// AuthenticateToken returns the identity of a request made with token. The token must
+// authenticate as the ServiceAccount anchor of a User, as machine users' tokens do, or as a
+// username the username template maps to a User, and that User must be active.
+func (p *Proxy) AuthenticateToken(ctx context.Context, token string) (Identity, error) {
+	info, err := p.Reviewer(ctx, token)
+	if err != nil {
+		return Identity{}, err
+	}
+	name, anchor := serviceAccountUser(info.Username)
+	if !anchor {
+		var ok bool
+		if name, ok = operatorconfig.UserName(info.Username); !ok {
+			return Identity{}, apierrors.NewUnauthorized(fmt.Sprintf("%s is not a KubeUser user", info.Username))
+		}
+	}
+	user, err := p.activeUser(ctx, name)
+	if err != nil {
+		return Identity{}, err
+	}
+	identity := Identity{User: name, Username: info.Username}
+	// The API server adds the groups of a ServiceAccount itself when none are impersonated
+	if !anchor {
+		identity.Groups = userGroups(user)
+	}
+	return identity, nil
+}
+
+// serviceAccountUser returns the User whose ServiceAccount anchor username is, if it is one
+func serviceAccountUser(username string) (string, bool) {
+	rest, ok := strings.CutPrefix(username, "system:serviceaccount:")
+	if !ok {
+		return "", false
+	}
+	namespace, name, ok := strings.Cut(rest, ":")
+	if !ok || namespace != operatorconfig.Namespace() || name == "" {
+		return "", false
+	}
+	return name, true
+}
+
+// activeUser returns the User named name, unless it is gone, suspended or revoked
+func (p *Proxy) activeUser(ctx context.Context, name string) (*authv1alpha1.User, error) {
+	var user authv1alpha1.User
+	err := p.Reader.Get(ctx, types.NamespacedName{Name: name}, &user)
+	if apierrors.IsNotFound(err) || (err == nil && !user.DeletionTimestamp.IsZero()) {
+		return nil, apierrors.NewUnauthorized(fmt.Sprintf("user %s does not exist", name))
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to look up user %s: %w", name, err)
+	}
+	if user.Spec.Revoked {
+		return nil, apierrors.NewUnauthorized(fmt.Sprintf("user %s is revoked", name))
+	}
+	if user.Spec.Suspended {
+		return nil, apierrors.NewForbidden(authv1alpha1.GroupVersion.WithResource("users").GroupResource(), name,
+			fmt.Errorf("user %s is suspended", name))
+	}
+	return &user, nil
+}
+
+// userGroups returns the groups the User currently grants: the organizations of its
+// certificate, which the API server takes as groups when the certificate is used directly. They
+// are read from the User rather than the certificate, so changes apply to the next request.
+func userGroups(user *authv1alpha1.User) []string {
+	if user.Spec.Certificate == nil {
+		return nil
+	}
+	return user.Spec.Certificate.Organizations
+}
+
+// writeStatus writes status the way the API server reports errors, so kubectl shows its message
+func writeStatus(w http.ResponseWriter, status apierrors.APIStatus) {
+	s := status.Status()
+	s.APIVersion, s.Kind = "v1", "Status"
+	if s.Status == "" {
+		s.Status = metav1.StatusFailure
+	}
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(int(s.Code))
+	_ = json.NewEncoder(w).Encode(s)
+}
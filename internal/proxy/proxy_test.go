@@ -0,0 +1,247 @@
+/*
+Copyright 2025.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package proxy
+
+import (
+	"context"
+	"crypto/tls"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"math/big"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+	authenticationv1 "k8s.io/api/authentication/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+	"sigs.k8s.io/controller-runtime/pkg/client/interceptor"
+
+	authv1alpha1 "github.com/openkube-hub/KubeUser/api/v1alpha1"
+	"github.com/openkube-hub/KubeUser/internal/operatorconfig"
+)
+
+var _ = Describe("Proxy", func() {
+	var (
+		upstream  *httptest.Server
+		forwarded http.Header
+		user      *authv1alpha1.User
+		issued    *authv1alpha1.IssuedCertificate
+		cert      *x509.Certificate
+		reviewer  TokenReviewer
+	)
+
+	BeforeEach(func() {
+		forwarded, reviewer = nil, nil
+		upstream = httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			forwarded = r.Header.Clone()
+			_, _ = w.Write([]byte(`{"kind":"PodList"}`))
+		}))
+		DeferCleanup(upstream.Close)
+
+		user = &authv1alpha1.User{ObjectMeta: metav1.ObjectMeta{Name: "jane"}}
+		issued = &authv1alpha1.IssuedCertificate{
+			ObjectMeta: metav1.ObjectMeta{Name: "jane-2a"},
+			Spec:       authv1alpha1.IssuedCertificateSpec{User: "jane", SerialNumber: "2a", CommonName: "jane"},
+		}
+		cert = &x509.Certificate{SerialNumber: big.NewInt(0x2a), Subject: pkix.Name{CommonName: "jane"}}
+	})
+
+	serve := func(withCert bool) *httptest.ResponseRecorder {
+		scheme := runtime.NewScheme()
+		Expect(authv1alpha1.AddToScheme(scheme)).To(Succeed())
+		var objects []client.Object
+		if user != nil {
+			objects = append(objects, user)
+		}
+		if issued != nil {
+			objects = append(objects, issued)
+		}
+		c := fake.NewClientBuilder().WithScheme(scheme).WithObjects(objects...).Build()
+		target, err := url.Parse(upstream.URL)
+		Expect(err).NotTo(HaveOccurred())
+
+		req := httptest.NewRequest(http.MethodGet, "https://proxy/api/v1/namespaces/dev/pods", nil)
+		req.Header.Set("Authorization", "Bearer stolen")
+		req.Header.Set("Impersonate-User", "admin")
+		req.Header.Set("Impersonate-Group", "system:masters")
+		if withCert {
+			req.TLS = &tls.ConnectionState{VerifiedChains: [][]*x509.Certificate{{cert}}}
+		}
+		rec := httptest.NewRecorder()
+		New(c, reviewer, target, http.DefaultTransport).ServeHTTP(rec, req)
+		return rec
+	}
+
+	It("forwards requests impersonating the certificate's user", func() {
+		rec := serve(true)
+		Expect(rec.Code).To(Equal(http.StatusOK))
+		Expect(rec.Body.String()).To(ContainSubstring("PodList"))
+		Expect(forwarded.Get("Impersonate-User")).To(Equal("jane"))
+		Expect(forwarded.Values("Impersonate-Group")).To(BeEmpty())
+		Expect(forwarded.Get("Authorization")).To(BeEmpty())
+	})
+
+	It("maps usernames to users by the username template", func() {
+		_, err := operatorconfig.DefaultStore.Apply(&authv1alpha1.KubeUserConfigSpec{UsernameTemplate: "kubeuser:{{.Name}}"})
+		Expect(err).NotTo(HaveOccurred())
+		DeferCleanup(func() { _, _ = operatorconfig.DefaultStore.Apply(nil) })
+
+		// Certificates issued before the template changed keep working until replaced
+		Expect(serve(true).Code).To(Equal(http.StatusOK))
+		Expect(forwarded.Get("Impersonate-User")).To(Equal("jane"))
+
+		issued.Spec.CommonName = "kubeuser:jane"
+		cert.Subject.CommonName = "kubeuser:jane"
+		Expect(serve(true).Code).To(Equal(http.StatusOK))
+		Expect(forwarded.Get("Impersonate-User")).To(Equal("kubeuser:jane"))
+	})
+
+	It("impersonates the organizations of the User as groups", func() {
+		user.Spec.Certificate = &authv1alpha1.CertificateSubject{Organizations: []string{"developers", "oncall"}}
+		Expect(serve(true).Code).To(Equal(http.StatusOK))
+		Expect(forwarded.Values("Impersonate-Group")).To(Equal([]string{"developers", "oncall"}))
+	})
+
+	It("requires a client certificate or a bearer token", func() {
+		Expect(serve(false).Code).To(Equal(http.StatusUnauthorized))
+		Expect(forwarded).To(BeNil())
+	})
+
+	It("rejects certificates KubeUser did not issue", func() {
+		issued = nil
+		Expect(serve(true).Code).To(Equal(http.StatusUnauthorized))
+		Expect(forwarded).To(BeNil())
+	})
+
+	It("rejects revoked certificates", func() {
+		revokedAt := metav1.Now()
+		issued.Status = authv1alpha1.IssuedCertificateStatus{RevokedAt: &revokedAt, Reason: authv1alpha1.RevocationReasonSuperseded}
+		rec := serve(true)
+		Expect(rec.Code).To(Equal(http.StatusUnauthorized))
+		Expect(rec.Body.String()).To(ContainSubstring("Superseded"))
+	})
+
+	It("rejects deleted, revoked and suspended users", func() {
+		user.Spec.Suspended = true
+		Expect(serve(true).Code).To(Equal(http.StatusForbidden))
+
+		user.Spec.Revoked = true
+		Expect(serve(true).Code).To(Equal(http.StatusUnauthorized))
+
+		user = nil
+		Expect(serve(true).Code).To(Equal(http.StatusUnauthorized))
+		Expect(forwarded).To(BeNil())
+	})
+
+	Context("with bearer tokens", func() {
+		var reviewed []string
+
+		BeforeEach(func() {
+			reviewed = nil
+			reviewer = func(_ context.Context, token string) (authenticationv1.UserInfo, error) {
+				reviewed = append(reviewed, token)
+				switch token {
+				case "machine":
+					return authenticationv1.UserInfo{Username: "system:serviceaccount:kubeuser:jane",
+						Groups: []string{"system:serviceaccounts", "system:serviceaccounts:kubeuser"}}, nil
+				case "other-namespace":
+					return authenticationv1.UserInfo{Username: "system:serviceaccount:default:jane"}, nil
+				case "oidc":
+					return authenticationv1.UserInfo{Username: "jane", Groups: []string{"idp:admins"}}, nil
+				}
+				return authenticationv1.UserInfo{}, apierrors.NewUnauthorized("the bearer token is not valid")
+			}
+		})
+
+		serveToken := func(token string) *httptest.ResponseRecorder {
+			scheme := runtime.NewScheme()
+			Expect(authv1alpha1.AddToScheme(scheme)).To(Succeed())
+			var objects []client.Object
+			if user != nil {
+				objects = append(objects, user)
+			}
+			c := fake.NewClientBuilder().WithScheme(scheme).WithObjects(objects...).Build()
+			target, err := url.Parse(upstream.URL)
+			Expect(err).NotTo(HaveOccurred())
+			req := httptest.NewRequest(http.MethodGet, "https://proxy/api/v1/namespaces/dev/pods", nil)
+			req.Header.Set("Authorization", "Bearer "+token)
+			req.Header.Set("Impersonate-Group", "system:masters")
+			rec := httptest.NewRecorder()
+			New(c, reviewer, target, http.DefaultTransport).ServeHTTP(rec, req)
+			return rec
+		}
+
+		It("impersonates the ServiceAccount anchor of machine users", func() {
+			GinkgoT().Setenv("KUBEUSER_NAMESPACE", "kubeuser")
+			Expect(serveToken("machine").Code).To(Equal(http.StatusOK))
+			Expect(reviewed).To(Equal([]string{"machine"}))
+			Expect(forwarded.Get("Impersonate-User")).To(Equal("system:serviceaccount:kubeuser:jane"))
+			// The API server adds the ServiceAccount's groups itself
+			Expect(forwarded.Values("Impersonate-Group")).To(BeEmpty())
+			Expect(forwarded.Get("Authorization")).To(BeEmpty())
+		})
+
+		It("impersonates users of other tokens with the groups of their User", func() {
+			user.Spec.Certificate = &authv1alpha1.CertificateSubject{Organizations: []string{"developers"}}
+			Expect(serveToken("oidc").Code).To(Equal(http.StatusOK))
+			Expect(forwarded.Get("Impersonate-User")).To(Equal("jane"))
+			Expect(forwarded.Values("Impersonate-Group")).To(Equal([]string{"developers"}))
+		})
+
+		It("rejects invalid tokens and tokens of other identities", func() {
+			GinkgoT().Setenv("KUBEUSER_NAMESPACE", "kubeuser")
+			Expect(serveToken("forged").Code).To(Equal(http.StatusUnauthorized))
+			Expect(serveToken("other-namespace").Code).To(Equal(http.StatusUnauthorized))
+			Expect(forwarded).To(BeNil())
+		})
+
+		It("rejects tokens of suspended or deleted users", func() {
+			user.Spec.Suspended = true
+			Expect(serveToken("oidc").Code).To(Equal(http.StatusForbidden))
+			user = nil
+			Expect(serveToken("oidc").Code).To(Equal(http.StatusUnauthorized))
+			Expect(forwarded).To(BeNil())
+		})
+	})
+})
+
+var _ = Describe("Reviewing", func() {
+	It("authenticates tokens with TokenReviews", func() {
+		c := fake.NewClientBuilder().WithInterceptorFuncs(interceptor.Funcs{
+			Create: func(ctx context.Context, c client.WithWatch, obj client.Object, opts ...client.CreateOption) error {
+				review := obj.(*authenticationv1.TokenReview)
+				if review.Spec.Token == "valid" {
+					review.Status.Authenticated = true
+					review.Status.User = authenticationv1.UserInfo{Username: "jane"}
+				}
+				return nil
+			},
+		}).Build()
+		info, err := Reviewing(c)(context.Background(), "valid")
+		Expect(err).NotTo(HaveOccurred())
+		Expect(info.Username).To(Equal("jane"))
+
+		_, err = Reviewing(c)(context.Background(), "forged")
+		Expect(apierrors.IsUnauthorized(err)).To(BeTrue())
+	})
+})
@@ -0,0 +1,141 @@
+/*
+Copyright 2025.
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+*/
+
+// Package rbacsummary condenses RBAC rules into a readable summary of the access they allow:
+// every resource once, with the verbs allowed on it, and resources allowing the same verbs
+// grouped together.
+package rbacsummary
+
+import (
+	"slices"
+	"strings"
+
+	authorizationv1 "k8s.io/api/authorization/v1"
+	rbacv1 "k8s.io/api/rbac/v1"
+
+	authv1alpha1 "github.com/openkube-hub/KubeUser/api/v1alpha1"
+)
+
+// Rules condenses rules into AccessRules. The verbs of a resource are the union of those every
+// rule allows on it; a "*" verb absorbs all others. Resources come first, then non-resource
+// URLs, each group ordered by its first entry.
+func Rules(rules []rbacv1.PolicyRule) []authv1alpha1.AccessRule {
+	resources := map[string]map[string]bool{}
+	urls := map[string]map[string]bool{}
+	for _, rule := range rules {
+		for _, url := range rule.NonResourceURLs {
+			addVerbs(urls, url, rule.Verbs)
+		}
+		for _, resource := range resourceNames(rule) {
+			addVerbs(resources, resource, rule.Verbs)
+		}
+	}
+	summary := group(resources, func(verbs, names []string) authv1alpha1.AccessRule {
+		return authv1alpha1.AccessRule{Verbs: verbs, Resources: names}
+	})
+	return append(summary, group(urls, func(verbs, names []string) authv1alpha1.AccessRule {
+		return authv1alpha1.AccessRule{Verbs: verbs, NonResourceURLs: names}
+	})...)
+}
+
+// ReviewRules condenses the rules of a SubjectRulesReview like Rules does
+func ReviewRules(status authorizationv1.SubjectRulesReviewStatus) []authv1alpha1.AccessRule {
+	rules := make([]rbacv1.PolicyRule, 0, len(status.ResourceRules)+len(status.NonResourceRules))
+	for _, rule := range status.ResourceRules {
+		rules = append(rules, rbacv1.PolicyRule{
+			Verbs: rule.Verbs, APIGroups: rule.APIGroups, Resources: rule.Resources, ResourceNames: rule.ResourceNames,
+		})
+	}
+	for _, rule := range status.NonResourceRules {
+		rules = append(rules, rbacv1.PolicyRule{Verbs: rule.Verbs, NonResourceURLs: rule.NonResourceURLs})
+	}
+	return Rules(rules)
+}
+
+// Summarize condenses the rules applying in each namespace, keyed by namespace with "" for
+// cluster-wide rules. Namespaces without rules are left out; the rest are sorted by name, so
+// cluster-wide access comes first.
+func Summarize(rules map[string][]rbacv1.PolicyRule) []authv1alpha1.NamespaceAccess {
+	var summary []authv1alpha1.NamespaceAccess
+	for namespace, namespaceRules := range rules {
+		if access := Rules(namespaceRules); len(access) > 0 {
+			summary = append(summary, authv1alpha1.NamespaceAccess{Namespace: namespace, Rules: access})
+		}
+	}
+	slices.SortFunc(summary, func(a, b authv1alpha1.NamespaceAccess) int {
+		return strings.Compare(a.Namespace, b.Namespace)
+	})
+	return summary
+}
+
+// resourceNames returns the resources a rule covers as "resource[.group]", with the objects it
+// is limited to in brackets
+func resourceNames(rule rbacv1.PolicyRule) []string {
+	var names []string
+	for _, group := range rule.APIGroups {
+		for _, resource := range rule.Resources {
+			name := resource
+			if group != "" {
+				name += "." + group
+			}
+			if len(rule.ResourceNames) == 0 {
+				names = append(names, name)
+				continue
+			}
+			for _, object := range rule.ResourceNames {
+				names = append(names, name+"["+object+"]")
+			}
+		}
+	}
+	return names
+}
+
+func addVerbs(into map[string]map[string]bool, key string, verbs []string) {
+	if len(verbs) == 0 {
+		return
+	}
+	if into[key] == nil {
+		into[key] = map[string]bool{}
+	}
+	for _, verb := range verbs {
+		into[key][verb] = true
+	}
+}
+
+// group collects the keys allowing the same verbs into one rule built by build
+func group(verbsByKey map[string]map[string]bool, build func(verbs, keys []string) authv1alpha1.AccessRule) []authv1alpha1.AccessRule {
+	keysByVerbs := map[string][]string{}
+	verbLists := map[string][]string{}
+	for key, set := range verbsByKey {
+		verbs := []string{rbacv1.VerbAll}
+		if !set[rbacv1.VerbAll] {
+			verbs = make([]string, 0, len(set))
+			for verb := range set {
+				verbs = append(verbs, verb)
+			}
+			slices.Sort(verbs)
+		}
+		id := strings.Join(verbs, ",")
+		keysByVerbs[id] = append(keysByVerbs[id], key)
+		verbLists[id] = verbs
+	}
+	rules := make([]authv1alpha1.AccessRule, 0, len(keysByVerbs))
+	for id, keys := range keysByVerbs {
+		slices.Sort(keys)
+		rules = append(rules, build(verbLists[id], keys))
+	}
+	slices.SortFunc(rules, func(a, b authv1alpha1.AccessRule) int {
+		return strings.Compare(firstOf(a), firstOf(b))
+	})
+	return rules
+}
+
+func firstOf(rule authv1alpha1.AccessRule) string {
+	if len(rule.Resources) > 0 {
+		return rule.Resources[0]
+	}
+	return rule.NonResourceURLs[0]
+}
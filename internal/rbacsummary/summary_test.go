@@ -0,0 +1,90 @@
+/*
+Copyright 2025.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package rbacsummary
+
+import (
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+	authorizationv1 "k8s.io/api/authorization/v1"
+	rbacv1 "k8s.io/api/rbac/v1"
+
+	authv1alpha1 "github.com/openkube-hub/KubeUser/api/v1alpha1"
+)
+
+var _ = Describe("Rules", func() {
+	It("groups resources allowing the same verbs", func() {
+		Expect(Rules([]rbacv1.PolicyRule{
+			{APIGroups: []string{""}, Resources: []string{"pods", "services"}, Verbs: []string{"list", "get"}},
+			{APIGroups: []string{"apps"}, Resources: []string{"deployments"}, Verbs: []string{"get", "list"}},
+			{APIGroups: []string{""}, Resources: []string{"pods/log"}, Verbs: []string{"get"}},
+		})).To(Equal([]authv1alpha1.AccessRule{
+			{Verbs: []string{"get", "list"}, Resources: []string{"deployments.apps", "pods", "services"}},
+			{Verbs: []string{"get"}, Resources: []string{"pods/log"}},
+		}))
+	})
+
+	It("merges the verbs of rules covering the same resource", func() {
+		Expect(Rules([]rbacv1.PolicyRule{
+			{APIGroups: []string{""}, Resources: []string{"configmaps"}, Verbs: []string{"get"}},
+			{APIGroups: []string{""}, Resources: []string{"configmaps"}, Verbs: []string{"update", "get"}},
+			{APIGroups: []string{"batch"}, Resources: []string{"jobs"}, Verbs: []string{"create"}},
+			{APIGroups: []string{"batch"}, Resources: []string{"jobs"}, Verbs: []string{"*"}},
+		})).To(Equal([]authv1alpha1.AccessRule{
+			{Verbs: []string{"get", "update"}, Resources: []string{"configmaps"}},
+			{Verbs: []string{"*"}, Resources: []string{"jobs.batch"}},
+		}))
+	})
+
+	It("names the objects rules are limited to and lists non-resource URLs last", func() {
+		Expect(Rules([]rbacv1.PolicyRule{
+			{APIGroups: []string{""}, Resources: []string{"secrets"}, ResourceNames: []string{"db", "api"}, Verbs: []string{"get"}},
+			{NonResourceURLs: []string{"/healthz", "/metrics"}, Verbs: []string{"get"}},
+			{APIGroups: []string{""}, Resources: []string{"events"}, Verbs: []string{}},
+		})).To(Equal([]authv1alpha1.AccessRule{
+			{Verbs: []string{"get"}, Resources: []string{"secrets[api]", "secrets[db]"}},
+			{Verbs: []string{"get"}, NonResourceURLs: []string{"/healthz", "/metrics"}},
+		}))
+	})
+
+	It("condenses SubjectRulesReview results", func() {
+		Expect(ReviewRules(authorizationv1.SubjectRulesReviewStatus{
+			ResourceRules: []authorizationv1.ResourceRule{
+				{APIGroups: []string{"authorization.k8s.io"}, Resources: []string{"selfsubjectrulesreviews"}, Verbs: []string{"create"}},
+			},
+			NonResourceRules: []authorizationv1.NonResourceRule{{NonResourceURLs: []string{"/api"}, Verbs: []string{"get"}}},
+		})).To(Equal([]authv1alpha1.AccessRule{
+			{Verbs: []string{"create"}, Resources: []string{"selfsubjectrulesreviews.authorization.k8s.io"}},
+			{Verbs: []string{"get"}, NonResourceURLs: []string{"/api"}},
+		}))
+	})
+})
+
+var _ = Describe("Summarize", func() {
+	It("orders namespaces after cluster-wide access and leaves out empty ones", func() {
+		summary := Summarize(map[string][]rbacv1.PolicyRule{
+			"team-b": {{APIGroups: []string{""}, Resources: []string{"pods"}, Verbs: []string{"get"}}},
+			"team-a": {{APIGroups: []string{""}, Resources: []string{"pods"}, Verbs: []string{"delete"}}},
+			"":       {{APIGroups: []string{""}, Resources: []string{"namespaces"}, Verbs: []string{"list"}}},
+			"empty":  nil,
+		})
+		Expect(summary).To(HaveLen(3))
+		Expect(summary[0].Namespace).To(BeEmpty())
+		Expect(summary[1].Namespace).To(Equal("team-a"))
+		Expect(summary[1].Rules).To(Equal([]authv1alpha1.AccessRule{{Verbs: []string{"delete"}, Resources: []string{"pods"}}}))
+		Expect(summary[2].Namespace).To(Equal("team-b"))
+	})
+})
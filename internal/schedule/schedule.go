@@ -0,0 +1,256 @@
+/*
+Copyright 2025.
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+*/
+
+// Package schedule evaluates access schedules: recurring windows, each opened by a cron
+// expression and open for a fixed duration, in which a user's roles are bound.
+package schedule
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+
+	authv1alpha1 "github.com/openkube-hub/KubeUser/api/v1alpha1"
+)
+
+// searchYears bounds how far ahead Next looks for a matching time, so expressions that never
+// match (e.g. February 30) terminate
+const searchYears = 5
+
+// maxSteps bounds how many overlapping windows At follows to find where they close
+const maxSteps = 1000
+
+// Cron is a parsed cron expression: minute, hour, day of month, month and day of week.
+// Fields take *, values, ranges (1-5), lists (1,3) and steps (*/15, 8-18/2); months and days
+// of week also take three-letter names (JAN, MON). Sunday is 0 or 7.
+type Cron struct {
+	minute, hour, dom, month, dow uint64
+	// domStar and dowStar record unrestricted day fields: when both day fields are
+	// restricted a day matches either of them, as in cron
+	domStar, dowStar bool
+}
+
+type field struct {
+	name     string
+	min, max int
+	names    []string // names[i] is value min+i
+}
+
+var (
+	minuteField = field{name: "minute", min: 0, max: 59}
+	hourField   = field{name: "hour", min: 0, max: 23}
+	domField    = field{name: "day of month", min: 1, max: 31}
+	monthField  = field{name: "month", min: 1, max: 12,
+		names: []string{"jan", "feb", "mar", "apr", "may", "jun", "jul", "aug", "sep", "oct", "nov", "dec"}}
+	dowField = field{name: "day of week", min: 0, max: 7,
+		names: []string{"sun", "mon", "tue", "wed", "thu", "fri", "sat"}}
+)
+
+// ParseCron parses a cron expression with five fields
+func ParseCron(expr string) (*Cron, error) {
+	fields := strings.Fields(expr)
+	if len(fields) != 5 {
+		return nil, fmt.Errorf("cron expression %q needs 5 fields, got %d", expr, len(fields))
+	}
+	var c Cron
+	var err error
+	if c.minute, err = minuteField.parse(fields[0]); err != nil {
+		return nil, err
+	}
+	if c.hour, err = hourField.parse(fields[1]); err != nil {
+		return nil, err
+	}
+	if c.dom, err = domField.parse(fields[2]); err != nil {
+		return nil, err
+	}
+	if c.month, err = monthField.parse(fields[3]); err != nil {
+		return nil, err
+	}
+	if c.dow, err = dowField.parse(fields[4]); err != nil {
+		return nil, err
+	}
+	// 7 is another name for Sunday
+	if c.dow&(1<<7) != 0 {
+		c.dow |= 1
+	}
+	c.domStar = strings.HasPrefix(fields[2], "*")
+	c.dowStar = strings.HasPrefix(fields[4], "*")
+	return &c, nil
+}
+
+func (f field) parse(expr string) (uint64, error) {
+	var bits uint64
+	for _, part := range strings.Split(expr, ",") {
+		rangeExpr, stepExpr, hasStep := strings.Cut(part, "/")
+		step := 1
+		if hasStep {
+			var err error
+			if step, err = strconv.Atoi(stepExpr); err != nil || step < 1 {
+				return 0, fmt.Errorf("invalid step %q in %s field", stepExpr, f.name)
+			}
+		}
+
+		var low, high int
+		if rangeExpr == "*" {
+			low, high = f.min, f.max
+		} else {
+			lowExpr, highExpr, isRange := strings.Cut(rangeExpr, "-")
+			var err error
+			if low, err = f.value(lowExpr); err != nil {
+				return 0, err
+			}
+			high = low
+			if isRange {
+				if high, err = f.value(highExpr); err != nil {
+					return 0, err
+				}
+			} else if hasStep {
+				// "a/n" runs from a to the end of the field
+				high = f.max
+			}
+			if low > high {
+				return 0, fmt.Errorf("invalid range %q in %s field", rangeExpr, f.name)
+			}
+		}
+		for v := low; v <= high; v += step {
+			bits |= 1 << uint(v)
+		}
+	}
+	return bits, nil
+}
+
+func (f field) value(expr string) (int, error) {
+	for i, name := range f.names {
+		if strings.EqualFold(expr, name) {
+			return f.min + i, nil
+		}
+	}
+	v, err := strconv.Atoi(expr)
+	if err != nil || v < f.min || v > f.max {
+		return 0, fmt.Errorf("invalid value %q in %s field, must be %d-%d", expr, f.name, f.min, f.max)
+	}
+	return v, nil
+}
+
+func (c *Cron) dayMatches(t time.Time) bool {
+	dom := c.dom&(1<<uint(t.Day())) != 0
+	dow := c.dow&(1<<uint(t.Weekday())) != 0
+	if c.domStar || c.dowStar {
+		return dom && dow
+	}
+	return dom || dow
+}
+
+// Next returns the first time the expression matches after t, in t's location. It is zero
+// when there is none within the next years.
+func (c *Cron) Next(t time.Time) time.Time {
+	loc := t.Location()
+	t = t.Truncate(time.Minute).Add(time.Minute)
+	limit := t.Year() + searchYears
+	for t.Year() <= limit {
+		switch {
+		case c.month&(1<<uint(t.Month())) == 0:
+			t = time.Date(t.Year(), t.Month()+1, 1, 0, 0, 0, 0, loc)
+		case !c.dayMatches(t):
+			t = time.Date(t.Year(), t.Month(), t.Day()+1, 0, 0, 0, 0, loc)
+		case c.hour&(1<<uint(t.Hour())) == 0:
+			t = time.Date(t.Year(), t.Month(), t.Day(), t.Hour()+1, 0, 0, 0, loc)
+		case c.minute&(1<<uint(t.Minute())) == 0:
+			t = t.Add(time.Minute)
+		default:
+			return t
+		}
+	}
+	return time.Time{}
+}
+
+// Window opens whenever Start matches and stays open for Duration
+type Window struct {
+	Start    *Cron
+	Duration time.Duration
+}
+
+// openAt returns the earliest start of the window that covers t, or zero when it is closed
+func (w Window) openAt(t time.Time) time.Time {
+	start := w.Start.Next(t.Add(-w.Duration))
+	if start.IsZero() || start.After(t) {
+		return time.Time{}
+	}
+	return start
+}
+
+// Schedule is a set of windows evaluated in one time zone
+type Schedule struct {
+	Location *time.Location
+	Windows  []Window
+}
+
+// New parses an access schedule
+func New(spec *authv1alpha1.AccessSchedule) (*Schedule, error) {
+	s := &Schedule{Location: time.UTC}
+	if spec.TimeZone != "" {
+		loc, err := time.LoadLocation(spec.TimeZone)
+		if err != nil {
+			return nil, fmt.Errorf("invalid time zone %q: %w", spec.TimeZone, err)
+		}
+		s.Location = loc
+	}
+	if len(spec.Windows) == 0 {
+		return nil, fmt.Errorf("at least one window is required")
+	}
+	for i, window := range spec.Windows {
+		start, err := ParseCron(window.Start)
+		if err != nil {
+			return nil, fmt.Errorf("window %d: %w", i, err)
+		}
+		if window.Duration.Duration < time.Minute {
+			return nil, fmt.Errorf("window %d: duration %s must be at least 1m", i, window.Duration.Duration)
+		}
+		s.Windows = append(s.Windows, Window{Start: start, Duration: window.Duration.Duration})
+	}
+	return s, nil
+}
+
+// At reports whether a window is open at t and when that changes: when the open windows have
+// all closed, or when the next one opens. The change is zero when none is found, e.g. because
+// overlapping windows keep access open.
+func (s *Schedule) At(t time.Time) (open bool, change time.Time) {
+	t = t.In(s.Location)
+	if !s.open(t) {
+		for _, w := range s.Windows {
+			if next := w.Start.Next(t); !next.IsZero() && (change.IsZero() || next.Before(change)) {
+				change = next
+			}
+		}
+		return false, change
+	}
+
+	// Follow overlapping windows until none covers the end of the previous one
+	end := t
+	for range maxSteps {
+		var next time.Time
+		for _, w := range s.Windows {
+			if start := w.openAt(end); !start.IsZero() && start.Add(w.Duration).After(next) {
+				next = start.Add(w.Duration)
+			}
+		}
+		if next.IsZero() {
+			return true, end
+		}
+		end = next
+	}
+	return true, time.Time{}
+}
+
+func (s *Schedule) open(t time.Time) bool {
+	for _, w := range s.Windows {
+		if !w.openAt(t).IsZero() {
+			return true
+		}
+	}
+	return false
+}
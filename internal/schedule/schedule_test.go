@@ -0,0 +1,149 @@
+/*
+Copyright 2025.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package schedule
+
+import (
+	"time"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+
+	authv1alpha1 "github.com/openkube-hub/KubeUser/api/v1alpha1"
+)
+
+var _ = Describe("Cron", func() {
+	// 2025-06-02 is a Monday
+	monday := time.Date(2025, 6, 2, 7, 30, 0, 0, time.UTC)
+
+	next := func(expr string, t time.Time) time.Time {
+		cron, err := ParseCron(expr)
+		Expect(err).NotTo(HaveOccurred())
+		return cron.Next(t)
+	}
+
+	It("finds the next matching minute", func() {
+		Expect(next("0 8 * * 1-5", monday)).To(Equal(time.Date(2025, 6, 2, 8, 0, 0, 0, time.UTC)))
+		Expect(next("0 8 * * MON-FRI", monday.Add(time.Hour))).To(Equal(time.Date(2025, 6, 3, 8, 0, 0, 0, time.UTC)))
+		Expect(next("*/15 * * * *", monday)).To(Equal(time.Date(2025, 6, 2, 7, 45, 0, 0, time.UTC)))
+		Expect(next("0 0 * * 7", monday)).To(Equal(time.Date(2025, 6, 8, 0, 0, 0, 0, time.UTC)))
+		Expect(next("0 9 1 jan *", monday)).To(Equal(time.Date(2026, 1, 1, 9, 0, 0, 0, time.UTC)))
+	})
+
+	It("matches either day field when both are restricted", func() {
+		// The 15th, or any Friday
+		Expect(next("0 0 15 * 5", monday)).To(Equal(time.Date(2025, 6, 6, 0, 0, 0, 0, time.UTC)))
+		Expect(next("0 0 15 * *", monday)).To(Equal(time.Date(2025, 6, 15, 0, 0, 0, 0, time.UTC)))
+	})
+
+	It("skips local times that do not exist", func() {
+		berlin, err := time.LoadLocation("Europe/Berlin")
+		Expect(err).NotTo(HaveOccurred())
+		// 02:30 does not exist on 2025-03-30 in Berlin
+		got := next("30 2 * * *", time.Date(2025, 3, 29, 12, 0, 0, 0, berlin))
+		Expect(got).To(Equal(time.Date(2025, 3, 31, 2, 30, 0, 0, berlin)))
+	})
+
+	It("returns zero for expressions that never match", func() {
+		Expect(next("0 0 30 2 *", monday)).To(BeZero())
+	})
+
+	It("rejects invalid expressions", func() {
+		for expr, msg := range map[string]string{
+			"0 8 * *":      "needs 5 fields",
+			"60 8 * * *":   `invalid value "60" in minute field`,
+			"0 8 * * 1-8":  `invalid value "8" in day of week field`,
+			"0 18-8 * * *": `invalid range "18-8" in hour field`,
+			"*/0 * * * *":  `invalid step "0"`,
+			"0 8 * foo *":  `invalid value "foo" in month field`,
+		} {
+			_, err := ParseCron(expr)
+			Expect(err).To(MatchError(ContainSubstring(msg)), expr)
+		}
+	})
+})
+
+var _ = Describe("Schedule", func() {
+	window := func(start string, duration time.Duration) authv1alpha1.AccessWindow {
+		return authv1alpha1.AccessWindow{Start: start, Duration: metav1.Duration{Duration: duration}}
+	}
+
+	It("tells whether business hours are open and when that changes", func() {
+		s, err := New(&authv1alpha1.AccessSchedule{
+			TimeZone: "Europe/Berlin",
+			Windows:  []authv1alpha1.AccessWindow{window("0 8 * * 1-5", 10*time.Hour)},
+		})
+		Expect(err).NotTo(HaveOccurred())
+
+		// Monday 07:00 UTC is 09:00 in Berlin
+		open, change := s.At(time.Date(2025, 6, 2, 7, 0, 0, 0, time.UTC))
+		Expect(open).To(BeTrue())
+		Expect(change).To(BeTemporally("==", time.Date(2025, 6, 2, 16, 0, 0, 0, time.UTC)))
+
+		// The window closes at 18:00 local time, exclusively
+		open, change = s.At(time.Date(2025, 6, 2, 16, 0, 0, 0, time.UTC))
+		Expect(open).To(BeFalse())
+		Expect(change).To(BeTemporally("==", time.Date(2025, 6, 3, 6, 0, 0, 0, time.UTC)))
+
+		// Friday evening waits for Monday
+		open, change = s.At(time.Date(2025, 6, 6, 20, 0, 0, 0, time.UTC))
+		Expect(open).To(BeFalse())
+		Expect(change).To(BeTemporally("==", time.Date(2025, 6, 9, 6, 0, 0, 0, time.UTC)))
+	})
+
+	It("joins overlapping windows", func() {
+		s, err := New(&authv1alpha1.AccessSchedule{Windows: []authv1alpha1.AccessWindow{
+			window("0 8 * * *", 4*time.Hour),
+			window("0 11 * * *", 4*time.Hour),
+		}})
+		Expect(err).NotTo(HaveOccurred())
+		open, change := s.At(time.Date(2025, 6, 2, 9, 0, 0, 0, time.UTC))
+		Expect(open).To(BeTrue())
+		Expect(change).To(Equal(time.Date(2025, 6, 2, 15, 0, 0, 0, time.UTC)))
+	})
+
+	It("covers windows spanning several days", func() {
+		s, err := New(&authv1alpha1.AccessSchedule{Windows: []authv1alpha1.AccessWindow{
+			window("0 9 * * MON", 4*24*time.Hour+9*time.Hour),
+		}})
+		Expect(err).NotTo(HaveOccurred())
+		open, change := s.At(time.Date(2025, 6, 4, 12, 0, 0, 0, time.UTC))
+		Expect(open).To(BeTrue())
+		Expect(change).To(Equal(time.Date(2025, 6, 6, 18, 0, 0, 0, time.UTC)))
+	})
+
+	It("reports no change while windows keep overlapping", func() {
+		s, err := New(&authv1alpha1.AccessSchedule{Windows: []authv1alpha1.AccessWindow{
+			window("0 0 * * *", 25*time.Hour),
+		}})
+		Expect(err).NotTo(HaveOccurred())
+		open, change := s.At(time.Date(2025, 6, 4, 12, 0, 0, 0, time.UTC))
+		Expect(open).To(BeTrue())
+		Expect(change).To(BeZero())
+	})
+
+	It("rejects invalid schedules", func() {
+		_, err := New(&authv1alpha1.AccessSchedule{TimeZone: "Mars/Olympus", Windows: []authv1alpha1.AccessWindow{
+			window("0 8 * * *", time.Hour),
+		}})
+		Expect(err).To(MatchError(ContainSubstring(`invalid time zone "Mars/Olympus"`)))
+		_, err = New(&authv1alpha1.AccessSchedule{Windows: []authv1alpha1.AccessWindow{window("0 8 * * *", 0)}})
+		Expect(err).To(MatchError(ContainSubstring("window 0: duration 0s must be at least 1m")))
+		_, err = New(&authv1alpha1.AccessSchedule{})
+		Expect(err).To(MatchError(ContainSubstring("at least one window")))
+	})
+})
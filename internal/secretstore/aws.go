@@ -0,0 +1,147 @@
+/*
+Copyright 2025.
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+*/
+
+package secretstore
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/openkube-hub/KubeUser/internal/aws"
+)
+
+// Keys of the Secret referenced by an AWS Secrets Manager store
+const (
+	AWSAccessKeyIDKey     = "accessKeyID"
+	AWSSecretAccessKeyKey = "secretAccessKey"
+	// AWSSessionTokenKey is optional, for temporary credentials
+	AWSSessionTokenKey = "sessionToken"
+)
+
+// awsCredentialsFrom returns the credentials in a store Secret, else those of the controller's
+// environment: static keys or an IAM role for service accounts (a web identity token)
+func awsCredentialsFrom(secret map[string][]byte, region string, httpClient *http.Client) (aws.CredentialSource, error) {
+	static := aws.Credentials{
+		AccessKeyID:     string(secret[AWSAccessKeyIDKey]),
+		SecretAccessKey: string(secret[AWSSecretAccessKeyKey]),
+		SessionToken:    string(secret[AWSSessionTokenKey]),
+	}
+	if len(secret) == 0 {
+		credentials, err := aws.EnvironmentCredentials(region, httpClient)
+		if !errors.Is(err, aws.ErrNoCredentials) {
+			return credentials, err
+		}
+	}
+	if static.AccessKeyID == "" || static.SecretAccessKey == "" {
+		return nil, fmt.Errorf("%w: set %s and %s in the store Secret, or give the controller "+
+			"an IAM role for its ServiceAccount", aws.ErrNoCredentials, AWSAccessKeyIDKey, AWSSecretAccessKeyKey)
+	}
+	return aws.StaticCredentials(static), nil
+}
+
+// AWSSecretsManager writes credentials to AWS Secrets Manager as secrets whose value is a JSON
+// object of the credential Secret's keys. Deleted secrets are deleted without recovery window,
+// so a user created again under the same name can be written at once.
+type AWSSecretsManager struct {
+	region      string
+	endpoint    string
+	credentials aws.CredentialSource
+	http        *http.Client
+	// now is replaced in tests
+	now func() time.Time
+}
+
+var _ Store = &AWSSecretsManager{}
+
+// NewAWSSecretsManager returns a store for Secrets Manager in region
+func NewAWSSecretsManager(region string, credentials aws.CredentialSource, httpClient *http.Client) *AWSSecretsManager {
+	return &AWSSecretsManager{
+		region:      region,
+		endpoint:    fmt.Sprintf("https://secretsmanager.%s.amazonaws.com/", region),
+		credentials: credentials,
+		http:        httpClient,
+		now:         time.Now,
+	}
+}
+
+// Put implements Store
+func (s *AWSSecretsManager) Put(ctx context.Context, path string, data map[string]string) error {
+	value, err := json.Marshal(data)
+	if err != nil {
+		return err
+	}
+	err = s.call(ctx, "PutSecretValue", map[string]any{"SecretId": path, "SecretString": string(value)})
+	if aws.IsError(err, "ResourceNotFoundException") {
+		err = s.call(ctx, "CreateSecret", map[string]any{
+			"Name":         path,
+			"SecretString": string(value),
+			"Description":  "Credentials managed by KubeUser",
+		})
+	}
+	if err != nil {
+		return fmt.Errorf("writing secret %s: %w", path, err)
+	}
+	return nil
+}
+
+// Delete implements Store
+func (s *AWSSecretsManager) Delete(ctx context.Context, path string) error {
+	err := s.call(ctx, "DeleteSecret", map[string]any{"SecretId": path, "ForceDeleteWithoutRecovery": true})
+	if err != nil && !aws.IsError(err, "ResourceNotFoundException") {
+		return fmt.Errorf("deleting secret %s: %w", path, err)
+	}
+	return nil
+}
+
+// call invokes action of the Secrets Manager JSON API with a SigV4 signed request
+func (s *AWSSecretsManager) call(ctx context.Context, action string, input any) error {
+	body, err := json.Marshal(input)
+	if err != nil {
+		return err
+	}
+	credentials, err := s.credentials(ctx)
+	if err != nil {
+		return err
+	}
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, s.endpoint, bytes.NewReader(body))
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Content-Type", "application/x-amz-json-1.1")
+	req.Header.Set("X-Amz-Target", "secretsmanager."+action)
+	aws.SignV4(req, body, credentials, s.region, "secretsmanager", s.now())
+
+	resp, err := s.http.Do(req)
+	if err != nil {
+		return err
+	}
+	defer func() { _ = resp.Body.Close() }()
+	respBody, err := io.ReadAll(io.LimitReader(resp.Body, 1<<20))
+	if err != nil {
+		return err
+	}
+	if resp.StatusCode < http.StatusBadRequest {
+		return nil
+	}
+	awsErr := &aws.Error{Status: resp.StatusCode}
+	var errorBody struct {
+		Type    string `json:"__type"`
+		Message string `json:"message"`
+	}
+	if json.Unmarshal(respBody, &errorBody) == nil {
+		// Types may be qualified, e.g. "com.amazonaws.secretsmanager#ResourceNotFoundException"
+		awsErr.Type = errorBody.Type[strings.LastIndex(errorBody.Type, "#")+1:]
+		awsErr.Message = errorBody.Message
+	}
+	return awsErr
+}
@@ -0,0 +1,221 @@
+/*
+Copyright 2025.
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+*/
+
+package secretstore
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"os"
+	"regexp"
+	"strings"
+	"sync"
+	"time"
+)
+
+// Keys of the Secret referenced by an Azure Key Vault store
+const (
+	AzureTenantIDKey     = "tenantID"
+	AzureClientIDKey     = "clientID"
+	AzureClientSecretKey = "clientSecret"
+)
+
+// azureVaultScope is the OAuth scope of the Key Vault data plane
+const azureVaultScope = "https://vault.azure.net/.default"
+
+// azureSecretName is what Key Vault accepts as secret name
+var azureSecretName = regexp.MustCompile(`^[0-9a-zA-Z-]{1,127}$`)
+
+// azureTokenSource returns an access token for Key Vault
+type azureTokenSource func(ctx context.Context) (string, error)
+
+// azureTokenFrom returns tokens for the service principal in a store Secret, else for the
+// controller's workload identity
+func azureTokenFrom(secret map[string][]byte, httpClient *http.Client) (azureTokenSource, error) {
+	authority := strings.TrimSuffix(os.Getenv("AZURE_AUTHORITY_HOST"), "/")
+	if authority == "" {
+		authority = "https://login.microsoftonline.com"
+	}
+	credential := &azureClientCredential{
+		authority: authority,
+		tenantID:  string(secret[AzureTenantIDKey]),
+		clientID:  string(secret[AzureClientIDKey]),
+		secret:    string(secret[AzureClientSecretKey]),
+		http:      httpClient,
+	}
+	if len(secret) == 0 {
+		credential.tenantID = os.Getenv("AZURE_TENANT_ID")
+		credential.clientID = os.Getenv("AZURE_CLIENT_ID")
+		credential.assertionFile = os.Getenv("AZURE_FEDERATED_TOKEN_FILE")
+		if credential.assertionFile == "" {
+			credential.secret = os.Getenv("AZURE_CLIENT_SECRET")
+		}
+	}
+	if credential.tenantID == "" || credential.clientID == "" ||
+		(credential.secret == "" && credential.assertionFile == "") {
+		return nil, fmt.Errorf("no Azure credentials: set %s, %s and %s in the store Secret, or give the "+
+			"controller a workload identity", AzureTenantIDKey, AzureClientIDKey, AzureClientSecretKey)
+	}
+	return credential.token, nil
+}
+
+// azureClientCredential gets tokens with the OAuth client credentials flow, authenticating with
+// a client secret or a federated token
+type azureClientCredential struct {
+	authority     string
+	tenantID      string
+	clientID      string
+	secret        string
+	assertionFile string
+	http          *http.Client
+
+	mu      sync.Mutex
+	cached  string
+	expires time.Time
+}
+
+func (c *azureClientCredential) token(ctx context.Context) (string, error) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if c.cached != "" && time.Now().Add(5*time.Minute).Before(c.expires) {
+		return c.cached, nil
+	}
+	form := url.Values{
+		"grant_type": {"client_credentials"},
+		"client_id":  {c.clientID},
+		"scope":      {azureVaultScope},
+	}
+	if c.assertionFile != "" {
+		assertion, err := os.ReadFile(c.assertionFile)
+		if err != nil {
+			return "", fmt.Errorf("failed to read federated token: %w", err)
+		}
+		form.Set("client_assertion_type", "urn:ietf:params:oauth:client-assertion-type:jwt-bearer")
+		form.Set("client_assertion", strings.TrimSpace(string(assertion)))
+	} else {
+		form.Set("client_secret", c.secret)
+	}
+	endpoint := fmt.Sprintf("%s/%s/oauth2/v2.0/token", c.authority, url.PathEscape(c.tenantID))
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, endpoint, strings.NewReader(form.Encode()))
+	if err != nil {
+		return "", err
+	}
+	req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+	resp, err := c.http.Do(req)
+	if err != nil {
+		return "", fmt.Errorf("getting Azure token: %w", err)
+	}
+	defer func() { _ = resp.Body.Close() }()
+	body, err := io.ReadAll(io.LimitReader(resp.Body, 1<<20))
+	if err != nil {
+		return "", err
+	}
+	if resp.StatusCode != http.StatusOK {
+		return "", fmt.Errorf("getting Azure token: %d: %s", resp.StatusCode, strings.TrimSpace(string(body)))
+	}
+	var result struct {
+		AccessToken string `json:"access_token"`
+		ExpiresIn   int64  `json:"expires_in"`
+	}
+	if err := json.Unmarshal(body, &result); err != nil {
+		return "", fmt.Errorf("getting Azure token: %w", err)
+	}
+	if result.AccessToken == "" {
+		return "", errors.New("getting Azure token: no access_token in response")
+	}
+	c.cached = result.AccessToken
+	c.expires = time.Now().Add(time.Duration(result.ExpiresIn) * time.Second)
+	return c.cached, nil
+}
+
+// AzureKeyVault writes credentials to Azure Key Vault as secrets whose value is a JSON object of
+// the credential Secret's keys. Key Vault names only allow letters, digits and dashes, so paths
+// have to render to such names.
+type AzureKeyVault struct {
+	vaultURL string
+	token    azureTokenSource
+	http     *http.Client
+}
+
+var _ Store = &AzureKeyVault{}
+
+// NewAzureKeyVault returns a store for the Key Vault at vaultURL
+func NewAzureKeyVault(vaultURL string, token azureTokenSource, httpClient *http.Client) *AzureKeyVault {
+	return &AzureKeyVault{vaultURL: strings.TrimSuffix(vaultURL, "/"), token: token, http: httpClient}
+}
+
+// Put implements Store
+func (s *AzureKeyVault) Put(ctx context.Context, path string, data map[string]string) error {
+	value, err := json.Marshal(data)
+	if err != nil {
+		return err
+	}
+	body, err := json.Marshal(map[string]any{
+		"value":       string(value),
+		"contentType": "application/json",
+		"tags":        map[string]string{"managed-by": "kubeuser"},
+	})
+	if err != nil {
+		return err
+	}
+	if _, err := s.call(ctx, http.MethodPut, path, body); err != nil {
+		return fmt.Errorf("writing secret %s: %w", path, err)
+	}
+	return nil
+}
+
+// Delete implements Store. Vaults with soft delete keep the secret as deleted for their
+// retention period.
+func (s *AzureKeyVault) Delete(ctx context.Context, path string) error {
+	status, err := s.call(ctx, http.MethodDelete, path, nil)
+	if err != nil && status != http.StatusNotFound {
+		return fmt.Errorf("deleting secret %s: %w", path, err)
+	}
+	return nil
+}
+
+func (s *AzureKeyVault) call(ctx context.Context, method, name string, body []byte) (int, error) {
+	if !azureSecretName.MatchString(name) {
+		return 0, fmt.Errorf("%q is not a Key Vault secret name: use up to 127 letters, digits and dashes", name)
+	}
+	token, err := s.token(ctx)
+	if err != nil {
+		return 0, err
+	}
+	var reader io.Reader
+	if body != nil {
+		reader = bytes.NewReader(body)
+	}
+	req, err := http.NewRequestWithContext(ctx, method,
+		fmt.Sprintf("%s/secrets/%s?api-version=7.4", s.vaultURL, name), reader)
+	if err != nil {
+		return 0, err
+	}
+	req.Header.Set("Authorization", "Bearer "+token)
+	if body != nil {
+		req.Header.Set("Content-Type", "application/json")
+	}
+	resp, err := s.http.Do(req)
+	if err != nil {
+		return 0, err
+	}
+	defer func() { _ = resp.Body.Close() }()
+	respBody, err := io.ReadAll(io.LimitReader(resp.Body, 1<<20))
+	if err != nil {
+		return resp.StatusCode, err
+	}
+	if resp.StatusCode >= http.StatusBadRequest {
+		return resp.StatusCode, fmt.Errorf("Key Vault returned %d: %s", resp.StatusCode,
+			strings.TrimSpace(string(respBody)))
+	}
+	return resp.StatusCode, nil
+}
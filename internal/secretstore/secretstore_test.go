@@ -0,0 +1,243 @@
+/*
+Copyright 2025.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package secretstore
+
+import (
+	"context"
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+
+	"github.com/openkube-hub/KubeUser/internal/aws"
+	"github.com/openkube-hub/KubeUser/internal/vault"
+)
+
+// recordedRequest is a request received by a fake store API
+type recordedRequest struct {
+	Method string
+	Path   string
+	Header http.Header
+	Body   string
+}
+
+// fakeAPI records requests and answers them with respond
+type fakeAPI struct {
+	mu       sync.Mutex
+	requests []recordedRequest
+	respond  func(w http.ResponseWriter, r recordedRequest)
+}
+
+func (f *fakeAPI) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	body, _ := io.ReadAll(r.Body)
+	req := recordedRequest{Method: r.Method, Path: r.URL.RequestURI(), Header: r.Header.Clone(), Body: string(body)}
+	f.mu.Lock()
+	f.requests = append(f.requests, req)
+	f.mu.Unlock()
+	f.respond(w, req)
+}
+
+var _ = Describe("Path templates", func() {
+	It("renders the user name", func() {
+		Expect(RenderPath("/kubeuser/{{ .User }}/", "alice")).To(Equal("kubeuser/alice"))
+	})
+
+	It("rejects unknown fields and empty paths", func() {
+		_, err := ParsePath("kubeuser/{{ .Group }}")
+		Expect(err).To(HaveOccurred())
+		_, err = RenderPath("/", "alice")
+		Expect(err).To(MatchError(ContainSubstring("empty")))
+	})
+})
+
+var _ = Describe("VaultKV", func() {
+	var (
+		api   *fakeAPI
+		store *VaultKV
+	)
+
+	BeforeEach(func() {
+		api = &fakeAPI{respond: func(w http.ResponseWriter, r recordedRequest) {
+			switch {
+			case strings.HasSuffix(r.Path, "/login"):
+				_, _ = io.WriteString(w, `{"auth":{"client_token":"s.token","lease_duration":3600}}`)
+			case r.Method == http.MethodDelete && strings.Contains(r.Path, "missing"):
+				w.WriteHeader(http.StatusNotFound)
+				_, _ = io.WriteString(w, `{"errors":[]}`)
+			case r.Method == http.MethodDelete:
+				w.WriteHeader(http.StatusNoContent)
+			default:
+				_, _ = io.WriteString(w, `{"data":{"version":1}}`)
+			}
+		}}
+		server := httptest.NewServer(api)
+		DeferCleanup(server.Close)
+		tokenPath := filepath.Join(GinkgoT().TempDir(), "token")
+		Expect(os.WriteFile(tokenPath, []byte("jwt"), 0o600)).To(Succeed())
+		client, err := vault.NewClient(vault.Config{Address: server.URL, AuthRole: "kubeuser", TokenPath: tokenPath})
+		Expect(err).NotTo(HaveOccurred())
+		store = NewVaultKV(client, "")
+	})
+
+	It("writes the data to the KV v2 data path", func() {
+		Expect(store.Put(context.Background(), "kubeuser/alice", map[string]string{"config": "kubeconfig"})).To(Succeed())
+		Expect(api.requests).To(HaveLen(2))
+		write := api.requests[1]
+		Expect(write.Method).To(Equal(http.MethodPost))
+		Expect(write.Path).To(Equal("/v1/secret/data/kubeuser/alice"))
+		Expect(write.Header.Get("X-Vault-Token")).To(Equal("s.token"))
+		Expect(write.Body).To(MatchJSON(`{"data":{"config":"kubeconfig"}}`))
+	})
+
+	It("deletes the metadata and ignores missing secrets", func() {
+		Expect(store.Delete(context.Background(), "kubeuser/alice")).To(Succeed())
+		Expect(store.Delete(context.Background(), "kubeuser/missing")).To(Succeed())
+		Expect(api.requests[1].Method).To(Equal(http.MethodDelete))
+		Expect(api.requests[1].Path).To(Equal("/v1/secret/metadata/kubeuser/alice"))
+	})
+})
+
+var _ = Describe("AWSSecretsManager", func() {
+	credentials := func(context.Context) (aws.Credentials, error) {
+		return aws.Credentials{AccessKeyID: "AKIDEXAMPLE", SecretAccessKey: "secret", SessionToken: "session"}, nil
+	}
+
+	newStore := func(api *fakeAPI) *AWSSecretsManager {
+		server := httptest.NewServer(api)
+		DeferCleanup(server.Close)
+		store := NewAWSSecretsManager("eu-west-1", credentials, server.Client())
+		store.endpoint = server.URL + "/"
+		return store
+	}
+
+	notFound := func(w http.ResponseWriter) {
+		w.WriteHeader(http.StatusBadRequest)
+		_, _ = io.WriteString(w, `{"__type":"ResourceNotFoundException","message":"Secrets Manager can't find the specified secret."}`)
+	}
+
+	It("creates the secret when there is none to update", func() {
+		api := &fakeAPI{respond: func(w http.ResponseWriter, r recordedRequest) {
+			if r.Header.Get("X-Amz-Target") == "secretsmanager.PutSecretValue" {
+				notFound(w)
+				return
+			}
+			_, _ = io.WriteString(w, `{}`)
+		}}
+		store := newStore(api)
+		Expect(store.Put(context.Background(), "kubeuser/alice", map[string]string{"config": "kubeconfig"})).To(Succeed())
+
+		Expect(api.requests).To(HaveLen(2))
+		create := api.requests[1]
+		Expect(create.Header.Get("X-Amz-Target")).To(Equal("secretsmanager.CreateSecret"))
+		Expect(create.Header.Get("X-Amz-Security-Token")).To(Equal("session"))
+		Expect(create.Header.Get("Authorization")).To(HavePrefix(
+			"AWS4-HMAC-SHA256 Credential=AKIDEXAMPLE/"))
+		Expect(create.Header.Get("Authorization")).To(ContainSubstring("/eu-west-1/secretsmanager/aws4_request"))
+		var input map[string]any
+		Expect(json.Unmarshal([]byte(create.Body), &input)).To(Succeed())
+		Expect(input).To(HaveKeyWithValue("Name", "kubeuser/alice"))
+		Expect(input["SecretString"]).To(MatchJSON(`{"config":"kubeconfig"}`))
+	})
+
+	It("deletes without recovery and ignores missing secrets", func() {
+		api := &fakeAPI{respond: func(w http.ResponseWriter, r recordedRequest) { notFound(w) }}
+		store := newStore(api)
+		Expect(store.Delete(context.Background(), "kubeuser/alice")).To(Succeed())
+		Expect(api.requests[0].Header.Get("X-Amz-Target")).To(Equal("secretsmanager.DeleteSecret"))
+		Expect(api.requests[0].Body).To(MatchJSON(`{"SecretId":"kubeuser/alice","ForceDeleteWithoutRecovery":true}`))
+	})
+
+	It("reports other errors", func() {
+		api := &fakeAPI{respond: func(w http.ResponseWriter, r recordedRequest) {
+			w.WriteHeader(http.StatusBadRequest)
+			_, _ = io.WriteString(w, `{"__type":"com.amazonaws.secretsmanager#AccessDeniedException","message":"denied"}`)
+		}}
+		store := newStore(api)
+		err := store.Put(context.Background(), "kubeuser/alice", map[string]string{"config": "kubeconfig"})
+		Expect(err).To(MatchError(ContainSubstring("AccessDeniedException: denied")))
+		Expect(api.requests).To(HaveLen(1))
+	})
+})
+
+var _ = Describe("AzureKeyVault", func() {
+	var (
+		api   *fakeAPI
+		store *AzureKeyVault
+	)
+
+	BeforeEach(func() {
+		api = &fakeAPI{respond: func(w http.ResponseWriter, r recordedRequest) {
+			switch {
+			case strings.HasSuffix(r.Path, "/oauth2/v2.0/token"):
+				_, _ = io.WriteString(w, `{"access_token":"azure-token","expires_in":3600}`)
+			case strings.Contains(r.Path, "missing"):
+				w.WriteHeader(http.StatusNotFound)
+				_, _ = io.WriteString(w, `{"error":{"code":"SecretNotFound"}}`)
+			default:
+				_, _ = io.WriteString(w, `{}`)
+			}
+		}}
+		server := httptest.NewServer(api)
+		DeferCleanup(server.Close)
+		GinkgoT().Setenv("AZURE_AUTHORITY_HOST", server.URL)
+		token, err := azureTokenFrom(map[string][]byte{
+			AzureTenantIDKey:     []byte("tenant"),
+			AzureClientIDKey:     []byte("client"),
+			AzureClientSecretKey: []byte("secret"),
+		}, server.Client())
+		Expect(err).NotTo(HaveOccurred())
+		store = NewAzureKeyVault(server.URL+"/", token, server.Client())
+	})
+
+	It("gets a token and writes the secret as JSON", func() {
+		Expect(store.Put(context.Background(), "kubeuser-alice", map[string]string{"config": "kubeconfig"})).To(Succeed())
+		Expect(store.Put(context.Background(), "kubeuser-alice", map[string]string{"config": "rotated"})).To(Succeed())
+
+		Expect(api.requests).To(HaveLen(3), "the token is cached")
+		login := api.requests[0]
+		Expect(login.Path).To(Equal("/tenant/oauth2/v2.0/token"))
+		Expect(login.Body).To(ContainSubstring("client_secret=secret"))
+		Expect(login.Body).To(ContainSubstring("scope=https%3A%2F%2Fvault.azure.net%2F.default"))
+
+		write := api.requests[1]
+		Expect(write.Method).To(Equal(http.MethodPut))
+		Expect(write.Path).To(Equal("/secrets/kubeuser-alice?api-version=7.4"))
+		Expect(write.Header.Get("Authorization")).To(Equal("Bearer azure-token"))
+		var body map[string]any
+		Expect(json.Unmarshal([]byte(write.Body), &body)).To(Succeed())
+		Expect(body["value"]).To(MatchJSON(`{"config":"kubeconfig"}`))
+	})
+
+	It("rejects names Key Vault does not allow", func() {
+		err := store.Put(context.Background(), "kubeuser/alice", map[string]string{"config": "kubeconfig"})
+		Expect(err).To(MatchError(ContainSubstring("not a Key Vault secret name")))
+		Expect(api.requests).To(BeEmpty())
+	})
+
+	It("ignores missing secrets on delete", func() {
+		Expect(store.Delete(context.Background(), "missing")).To(Succeed())
+		Expect(store.Delete(context.Background(), "kubeuser-alice")).To(Succeed())
+		Expect(api.requests[2].Method).To(Equal(http.MethodDelete))
+	})
+})
@@ -0,0 +1,150 @@
+/*
+Copyright 2025.
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+*/
+
+// Package secretstore copies user credentials into external secret stores: Vault KV, AWS
+// Secrets Manager and Azure Key Vault. The stores are called through their HTTP APIs, so no
+// cloud SDK is needed.
+package secretstore
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"strings"
+	"sync"
+	"text/template"
+	"time"
+
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/types"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+
+	authv1alpha1 "github.com/openkube-hub/KubeUser/api/v1alpha1"
+	"github.com/openkube-hub/KubeUser/internal/vault"
+)
+
+// Store keeps copies of user credentials at paths of an external secret store
+type Store interface {
+	// Put creates or updates the secret at path with data, the keys of the credential Secret
+	Put(ctx context.Context, path string, data map[string]string) error
+	// Delete deletes the secret at path; a missing secret is not an error
+	Delete(ctx context.Context, path string) error
+}
+
+// pathData is what path templates are rendered with
+type pathData struct {
+	User string
+}
+
+// ParsePath parses the path template of a store
+func ParsePath(path string) (*template.Template, error) {
+	tmpl, err := template.New("path").Option("missingkey=error").Parse(path)
+	if err != nil {
+		return nil, err
+	}
+	if _, err := render(tmpl, "user"); err != nil {
+		return nil, err
+	}
+	return tmpl, nil
+}
+
+// RenderPath renders the path template of a store for username
+func RenderPath(path, username string) (string, error) {
+	tmpl, err := ParsePath(path)
+	if err != nil {
+		return "", err
+	}
+	return render(tmpl, username)
+}
+
+func render(tmpl *template.Template, username string) (string, error) {
+	var b strings.Builder
+	if err := tmpl.Execute(&b, pathData{User: username}); err != nil {
+		return "", err
+	}
+	path := strings.Trim(b.String(), "/")
+	if path == "" {
+		return "", errors.New("path is empty")
+	}
+	return path, nil
+}
+
+// Opener opens the stores configured in the KubeUserConfig. Stores are cached while their
+// spec and Secret are unchanged, so cloud credentials and Vault tokens are reused.
+type Opener struct {
+	// Reader reads the stores' Secrets
+	Reader client.Reader
+	// Vault is the connection of Vault stores
+	Vault vault.Config
+	// HTTP calls AWS and Azure; defaults to a client with a 30s timeout
+	HTTP *http.Client
+
+	mu          sync.Mutex
+	vaultClient *vault.Client
+	stores      map[string]cachedStore
+}
+
+type cachedStore struct {
+	key   string
+	store Store
+}
+
+// Open returns the store described by spec, reading its Secret from namespace
+func (o *Opener) Open(ctx context.Context, spec authv1alpha1.CredentialStore, namespace string) (Store, error) {
+	var secret corev1.Secret
+	if spec.SecretRef != "" {
+		if err := o.Reader.Get(ctx, types.NamespacedName{Name: spec.SecretRef, Namespace: namespace}, &secret); err != nil {
+			return nil, fmt.Errorf("failed to read Secret %s/%s: %w", namespace, spec.SecretRef, err)
+		}
+	}
+	encoded, err := json.Marshal(spec)
+	if err != nil {
+		return nil, err
+	}
+	key := string(encoded) + "@" + secret.ResourceVersion
+
+	o.mu.Lock()
+	defer o.mu.Unlock()
+	if cached, ok := o.stores[spec.Name]; ok && cached.key == key {
+		return cached.store, nil
+	}
+	httpClient := o.HTTP
+	if httpClient == nil {
+		httpClient = &http.Client{Timeout: 30 * time.Second}
+	}
+
+	var store Store
+	switch {
+	case spec.Vault != nil:
+		if o.vaultClient == nil {
+			if o.vaultClient, err = vault.NewClient(o.Vault); err != nil {
+				return nil, fmt.Errorf("vault stores need the --vault-* connection: %w", err)
+			}
+		}
+		store = NewVaultKV(o.vaultClient, spec.Vault.Mount)
+	case spec.AWSSecretsManager != nil:
+		credentials, err := awsCredentialsFrom(secret.Data, spec.AWSSecretsManager.Region, httpClient)
+		if err != nil {
+			return nil, err
+		}
+		store = NewAWSSecretsManager(spec.AWSSecretsManager.Region, credentials, httpClient)
+	case spec.AzureKeyVault != nil:
+		token, err := azureTokenFrom(secret.Data, httpClient)
+		if err != nil {
+			return nil, err
+		}
+		store = NewAzureKeyVault(spec.AzureKeyVault.VaultURL, token, httpClient)
+	default:
+		return nil, errors.New("no vault, awsSecretsManager or azureKeyVault set")
+	}
+	if o.stores == nil {
+		o.stores = map[string]cachedStore{}
+	}
+	o.stores[spec.Name] = cachedStore{key: key, store: store}
+	return store, nil
+}
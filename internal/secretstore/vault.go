@@ -0,0 +1,55 @@
+/*
+Copyright 2025.
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+*/
+
+package secretstore
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+
+	"github.com/openkube-hub/KubeUser/internal/vault"
+)
+
+// VaultKV writes credentials to a Vault KV version 2 secrets engine. Every write is a new
+// version of the secret; Delete removes all versions and the metadata.
+type VaultKV struct {
+	client *vault.Client
+	mount  string
+}
+
+var _ Store = &VaultKV{}
+
+// NewVaultKV returns a store for the KV engine under mount, "secret" when empty
+func NewVaultKV(client *vault.Client, mount string) *VaultKV {
+	if mount == "" {
+		mount = "secret"
+	}
+	return &VaultKV{client: client, mount: mount}
+}
+
+// Put implements Store
+func (v *VaultKV) Put(ctx context.Context, path string, data map[string]string) error {
+	var response struct{}
+	if err := v.client.Write(ctx, fmt.Sprintf("%s/data/%s", v.mount, path), map[string]any{"data": data}, &response); err != nil {
+		return fmt.Errorf("writing %s/%s: %w", v.mount, path, err)
+	}
+	return nil
+}
+
+// Delete implements Store
+func (v *VaultKV) Delete(ctx context.Context, path string) error {
+	err := v.client.Delete(ctx, fmt.Sprintf("%s/metadata/%s", v.mount, path))
+	var vaultErr *vault.Error
+	if errors.As(err, &vaultErr) && vaultErr.Status == http.StatusNotFound {
+		return nil
+	}
+	if err != nil {
+		return fmt.Errorf("deleting %s/%s: %w", v.mount, path, err)
+	}
+	return nil
+}
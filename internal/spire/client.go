@@ -0,0 +1,193 @@
+/*
+Copyright 2025.
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+*/
+
+// Package spire is a minimal client for the SPIRE Server API, reached through the server's
+// socket. It only mints X509-SVIDs, and encodes the few messages it needs by hand so KubeUser
+// does not depend on the SPIRE SDK.
+package spire
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/credentials/insecure"
+	"google.golang.org/grpc/status"
+	"google.golang.org/protobuf/encoding/protowire"
+)
+
+// DefaultSocketPath is where the SPIRE server listens for local API calls
+const DefaultSocketPath = "/tmp/spire-server/private/api.sock"
+
+// mintX509SVIDMethod is the SVID service method that signs a CSR for the SPIFFE ID in its URI SAN
+const mintX509SVIDMethod = "/spire.api.server.svid.v1.SVID/MintX509SVID"
+
+// ErrUnavailable marks failures of the SPIRE server itself rather than of the request
+var ErrUnavailable = errors.New("spire server is unavailable")
+
+// X509SVID is a minted X509-SVID
+type X509SVID struct {
+	// ID is the SPIFFE ID, spiffe://<trust domain><path>
+	ID string
+	// CertChain is the DER-encoded leaf certificate followed by its intermediates
+	CertChain [][]byte
+	// ExpiresAt is when the leaf certificate expires
+	ExpiresAt time.Time
+}
+
+// Client calls the SPIRE Server API
+type Client struct {
+	conn *grpc.ClientConn
+}
+
+// NewClient returns a client for the SPIRE server listening on the unix socket at socketPath.
+// It connects on first use.
+func NewClient(socketPath string) (*Client, error) {
+	conn, err := grpc.NewClient("unix://"+socketPath, grpc.WithTransportCredentials(insecure.NewCredentials()))
+	if err != nil {
+		return nil, fmt.Errorf("invalid spire server socket %q: %w", socketPath, err)
+	}
+	return &Client{conn: conn}, nil
+}
+
+// MintX509SVID has the SPIRE server sign csr, a DER-encoded CSR with the SPIFFE ID as its only URI
+// SAN, for ttl; zero leaves the TTL to the server
+func (c *Client) MintX509SVID(ctx context.Context, csr []byte, ttl time.Duration) (*X509SVID, error) {
+	var request []byte
+	request = protowire.AppendTag(request, 1, protowire.BytesType)
+	request = protowire.AppendBytes(request, csr)
+	if ttl > 0 {
+		request = protowire.AppendTag(request, 2, protowire.VarintType)
+		request = protowire.AppendVarint(request, uint64(ttl/time.Second))
+	}
+	var response []byte
+	if err := c.conn.Invoke(ctx, mintX509SVIDMethod, &request, &response, grpc.ForceCodec(rawCodec{})); err != nil {
+		switch status.Code(err) {
+		case codes.Unavailable, codes.DeadlineExceeded, codes.ResourceExhausted:
+			return nil, fmt.Errorf("%w: %v", ErrUnavailable, err)
+		}
+		return nil, err
+	}
+
+	// MintX509SVIDResponse{X509SVID svid = 1}
+	var svid *X509SVID
+	err := eachField(response, func(num protowire.Number, typ protowire.Type, value []byte) error {
+		if num != 1 || typ != protowire.BytesType {
+			return nil
+		}
+		var err error
+		svid, err = parseX509SVID(value)
+		return err
+	})
+	if err != nil {
+		return nil, fmt.Errorf("invalid MintX509SVID response: %w", err)
+	}
+	if svid == nil || len(svid.CertChain) == 0 {
+		return nil, errors.New("invalid MintX509SVID response: no certificate")
+	}
+	return svid, nil
+}
+
+// parseX509SVID decodes X509SVID{SPIFFEID id = 1; repeated bytes cert_chain = 2; int64 expires_at = 3}
+func parseX509SVID(data []byte) (*X509SVID, error) {
+	svid := &X509SVID{}
+	err := eachField(data, func(num protowire.Number, typ protowire.Type, value []byte) error {
+		switch {
+		case num == 1 && typ == protowire.BytesType:
+			// SPIFFEID{string trust_domain = 1; string path = 2}
+			var trustDomain, path string
+			if err := eachField(value, func(num protowire.Number, typ protowire.Type, value []byte) error {
+				switch {
+				case num == 1 && typ == protowire.BytesType:
+					trustDomain = string(value)
+				case num == 2 && typ == protowire.BytesType:
+					path = string(value)
+				}
+				return nil
+			}); err != nil {
+				return err
+			}
+			svid.ID = "spiffe://" + trustDomain + path
+		case num == 2 && typ == protowire.BytesType:
+			svid.CertChain = append(svid.CertChain, append([]byte(nil), value...))
+		case num == 3 && typ == protowire.VarintType:
+			seconds, n := protowire.ConsumeVarint(value)
+			if n < 0 {
+				return protowire.ParseError(n)
+			}
+			svid.ExpiresAt = time.Unix(int64(seconds), 0)
+		}
+		return nil
+	})
+	return svid, err
+}
+
+// eachField calls fn with every field of the protobuf message in data. Varint fields are
+// passed in their encoded form; fields of other wire types than varint and bytes are skipped.
+func eachField(data []byte, fn func(num protowire.Number, typ protowire.Type, value []byte) error) error {
+	for len(data) > 0 {
+		num, typ, n := protowire.ConsumeTag(data)
+		if n < 0 {
+			return protowire.ParseError(n)
+		}
+		data = data[n:]
+		var value []byte
+		switch typ {
+		case protowire.BytesType:
+			v, m := protowire.ConsumeBytes(data)
+			if m < 0 {
+				return protowire.ParseError(m)
+			}
+			value, n = v, m
+		case protowire.VarintType:
+			_, m := protowire.ConsumeVarint(data)
+			if m < 0 {
+				return protowire.ParseError(m)
+			}
+			value, n = data[:m], m
+		default:
+			n = protowire.ConsumeFieldValue(num, typ, data)
+			if n < 0 {
+				return protowire.ParseError(n)
+			}
+			data = data[n:]
+			continue
+		}
+		if err := fn(num, typ, value); err != nil {
+			return err
+		}
+		data = data[n:]
+	}
+	return nil
+}
+
+// rawCodec passes messages encoded by hand through gRPC as they are
+type rawCodec struct{}
+
+func (rawCodec) Marshal(v any) ([]byte, error) {
+	message, ok := v.(*[]byte)
+	if !ok {
+		return nil, fmt.Errorf("unexpected message type %T", v)
+	}
+	return *message, nil
+}
+
+func (rawCodec) Unmarshal(data []byte, v any) error {
+	message, ok := v.(*[]byte)
+	if !ok {
+		return fmt.Errorf("unexpected message type %T", v)
+	}
+	*message = append([]byte(nil), data...)
+	return nil
+}
+
+// Name is that of the protobuf codec, the content subtype the SPIRE server expects
+func (rawCodec) Name() string {
+	return "proto"
+}
@@ -0,0 +1,255 @@
+/*
+Copyright 2025.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package spire
+
+import (
+	"context"
+	"net"
+	"time"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/credentials/insecure"
+	"google.golang.org/grpc/status"
+	"google.golang.org/grpc/test/bufconn"
+	"google.golang.org/protobuf/encoding/protowire"
+)
+
+// spiffeID encodes SPIFFEID{string trust_domain = 1; string path = 2}
+func spiffeID(trustDomain, path string) []byte {
+	var id []byte
+	id = protowire.AppendTag(id, 1, protowire.BytesType)
+	id = protowire.AppendString(id, trustDomain)
+	id = protowire.AppendTag(id, 2, protowire.BytesType)
+	id = protowire.AppendString(id, path)
+	return id
+}
+
+// x509SVID encodes X509SVID{SPIFFEID id = 1; repeated bytes cert_chain = 2; int64 expires_at = 3}
+func x509SVID(id []byte, expiresAt int64, chain ...[]byte) []byte {
+	var svid []byte
+	svid = protowire.AppendTag(svid, 1, protowire.BytesType)
+	svid = protowire.AppendBytes(svid, id)
+	for _, cert := range chain {
+		svid = protowire.AppendTag(svid, 2, protowire.BytesType)
+		svid = protowire.AppendBytes(svid, cert)
+	}
+	svid = protowire.AppendTag(svid, 3, protowire.VarintType)
+	svid = protowire.AppendVarint(svid, uint64(expiresAt))
+	return svid
+}
+
+// mintResponse encodes MintX509SVIDResponse{X509SVID svid = 1}
+func mintResponse(svid []byte) []byte {
+	var response []byte
+	response = protowire.AppendTag(response, 1, protowire.BytesType)
+	return protowire.AppendBytes(response, svid)
+}
+
+var _ = Describe("parseX509SVID", func() {
+	It("decodes the SPIFFE ID, certificate chain and expiry", func() {
+		svid, err := parseX509SVID(x509SVID(spiffeID("example.org", "/kubeuser/user/jane"), 1767225600,
+			[]byte("leaf"), []byte("intermediate")))
+		Expect(err).NotTo(HaveOccurred())
+		Expect(svid.ID).To(Equal("spiffe://example.org/kubeuser/user/jane"))
+		Expect(svid.CertChain).To(Equal([][]byte{[]byte("leaf"), []byte("intermediate")}))
+		Expect(svid.ExpiresAt).To(BeTemporally("==", time.Unix(1767225600, 0)))
+	})
+
+	It("assembles the SPIFFE ID from its fields in any order", func() {
+		var id []byte
+		id = protowire.AppendTag(id, 2, protowire.BytesType)
+		id = protowire.AppendString(id, "/workload")
+		id = protowire.AppendTag(id, 1, protowire.BytesType)
+		id = protowire.AppendString(id, "example.org")
+		svid, err := parseX509SVID(x509SVID(id, 0, []byte("leaf")))
+		Expect(err).NotTo(HaveOccurred())
+		Expect(svid.ID).To(Equal("spiffe://example.org/workload"))
+
+		svid, err = parseX509SVID(x509SVID(spiffeID("example.org", ""), 0, []byte("leaf")))
+		Expect(err).NotTo(HaveOccurred())
+		Expect(svid.ID).To(Equal("spiffe://example.org"))
+	})
+
+	It("skips unknown fields and wire types", func() {
+		data := x509SVID(spiffeID("example.org", "/jane"), 1767225600, []byte("leaf"))
+		data = protowire.AppendTag(data, 4, protowire.Fixed64Type)
+		data = protowire.AppendFixed64(data, 42)
+		data = protowire.AppendTag(data, 5, protowire.Fixed32Type)
+		data = protowire.AppendFixed32(data, 42)
+		data = protowire.AppendTag(data, 6, protowire.BytesType)
+		data = protowire.AppendString(data, "hint")
+		// A cert_chain entry with the wrong wire type is not a certificate
+		data = protowire.AppendTag(data, 2, protowire.VarintType)
+		data = protowire.AppendVarint(data, 1)
+		svid, err := parseX509SVID(data)
+		Expect(err).NotTo(HaveOccurred())
+		Expect(svid.ID).To(Equal("spiffe://example.org/jane"))
+		Expect(svid.CertChain).To(Equal([][]byte{[]byte("leaf")}))
+	})
+
+	It("does not alias the decoded buffer", func() {
+		data := x509SVID(spiffeID("example.org", "/jane"), 0, []byte("leaf"))
+		svid, err := parseX509SVID(data)
+		Expect(err).NotTo(HaveOccurred())
+		for i := range data {
+			data[i] = 0
+		}
+		Expect(svid.CertChain).To(Equal([][]byte{[]byte("leaf")}))
+	})
+
+	DescribeTable("rejects malformed messages",
+		func(data []byte) {
+			_, err := parseX509SVID(data)
+			Expect(err).To(HaveOccurred())
+		},
+		Entry("a truncated certificate",
+			func() []byte {
+				data := x509SVID(spiffeID("example.org", "/jane"), 0, []byte("leaf"))
+				return data[:len(data)-4]
+			}()),
+		Entry("a truncated expiry", append(protowire.AppendTag(nil, 3, protowire.VarintType), 0x80)),
+		Entry("a truncated tag", []byte{0x80}),
+		Entry("field number zero", protowire.AppendVarint(protowire.AppendTag(nil, 0, protowire.VarintType), 1)),
+		Entry("a truncated SPIFFE ID",
+			protowire.AppendBytes(protowire.AppendTag(nil, 1, protowire.BytesType),
+				spiffeID("example.org", "/jane")[:5])),
+		Entry("a truncated fixed64 field", append(protowire.AppendTag(nil, 4, protowire.Fixed64Type), 1, 2)),
+		Entry("a group end without a start", protowire.AppendTag(nil, 4, protowire.EndGroupType)),
+	)
+})
+
+var _ = Describe("Client", func() {
+	var (
+		client   *Client
+		method   string
+		request  []byte
+		response []byte
+		// mintErr is returned by the server when set
+		mintErr error
+	)
+
+	BeforeEach(func() {
+		method, request, mintErr = "", nil, nil
+		response = mintResponse(x509SVID(spiffeID("example.org", "/jane"), 1767225600, []byte("leaf")))
+
+		listener := bufconn.Listen(1 << 20)
+		server := grpc.NewServer(grpc.ForceServerCodec(rawCodec{}), grpc.UnknownServiceHandler(
+			func(_ any, stream grpc.ServerStream) error {
+				method, _ = grpc.MethodFromServerStream(stream)
+				if err := stream.RecvMsg(&request); err != nil {
+					return err
+				}
+				if mintErr != nil {
+					return mintErr
+				}
+				return stream.SendMsg(&response)
+			}))
+		go func() { _ = server.Serve(listener) }()
+		DeferCleanup(server.Stop)
+
+		conn, err := grpc.NewClient("passthrough:///bufconn",
+			grpc.WithContextDialer(func(ctx context.Context, _ string) (net.Conn, error) {
+				return listener.DialContext(ctx)
+			}),
+			grpc.WithTransportCredentials(insecure.NewCredentials()))
+		Expect(err).NotTo(HaveOccurred())
+		DeferCleanup(conn.Close)
+		client = &Client{conn: conn}
+	})
+
+	// requestFields decodes the MintX509SVIDRequest the server received
+	requestFields := func() map[protowire.Number][]byte {
+		fields := map[protowire.Number][]byte{}
+		Expect(eachField(request, func(num protowire.Number, _ protowire.Type, value []byte) error {
+			fields[num] = value
+			return nil
+		})).To(Succeed())
+		return fields
+	}
+
+	It("sends the CSR and TTL and decodes the minted SVID", func() {
+		svid, err := client.MintX509SVID(context.Background(), []byte("csr"), 90*time.Minute)
+		Expect(err).NotTo(HaveOccurred())
+		Expect(method).To(Equal(mintX509SVIDMethod))
+		fields := requestFields()
+		Expect(fields).To(HaveKeyWithValue(protowire.Number(1), []byte("csr")))
+		ttl, n := protowire.ConsumeVarint(fields[2])
+		Expect(n).To(BeNumerically(">", 0))
+		Expect(ttl).To(Equal(uint64(5400)))
+		Expect(svid.ID).To(Equal("spiffe://example.org/jane"))
+		Expect(svid.CertChain).To(Equal([][]byte{[]byte("leaf")}))
+		Expect(svid.ExpiresAt).To(BeTemporally("==", time.Unix(1767225600, 0)))
+	})
+
+	DescribeTable("encodes the TTL in whole seconds",
+		func(ttl time.Duration, seconds uint64, sent bool) {
+			_, err := client.MintX509SVID(context.Background(), []byte("csr"), ttl)
+			Expect(err).NotTo(HaveOccurred())
+			fields := requestFields()
+			if !sent {
+				Expect(fields).NotTo(HaveKey(protowire.Number(2)))
+				return
+			}
+			value, _ := protowire.ConsumeVarint(fields[2])
+			Expect(value).To(Equal(seconds))
+		},
+		Entry("leaving zero to the server", time.Duration(0), uint64(0), false),
+		Entry("one hour", time.Hour, uint64(3600), true),
+		Entry("dropping fractions of a second", 1500*time.Millisecond, uint64(1), true),
+		Entry("a year", 365*24*time.Hour, uint64(31536000), true),
+	)
+
+	DescribeTable("rejects malformed responses",
+		func(data []byte, message string) {
+			response = data
+			_, err := client.MintX509SVID(context.Background(), []byte("csr"), 0)
+			Expect(err).To(MatchError(ContainSubstring(message)))
+		},
+		Entry("an empty response", []byte{}, "no certificate"),
+		Entry("an SVID without a certificate",
+			mintResponse(x509SVID(spiffeID("example.org", "/jane"), 1767225600)), "no certificate"),
+		Entry("an SVID of the wrong wire type",
+			protowire.AppendVarint(protowire.AppendTag(nil, 1, protowire.VarintType), 1), "no certificate"),
+		Entry("a truncated response",
+			func() []byte {
+				data := mintResponse(x509SVID(spiffeID("example.org", "/jane"), 1767225600, []byte("leaf")))
+				return data[:len(data)-3]
+			}(), "invalid MintX509SVID response"),
+		Entry("a truncated SVID",
+			mintResponse(x509SVID(spiffeID("example.org", "/jane"), 1767225600, []byte("leaf"))[:10]),
+			"invalid MintX509SVID response"),
+	)
+
+	It("reports an unreachable server as unavailable", func() {
+		for _, code := range []codes.Code{codes.Unavailable, codes.DeadlineExceeded, codes.ResourceExhausted} {
+			mintErr = status.Error(code, "datastore is down")
+			_, err := client.MintX509SVID(context.Background(), []byte("csr"), 0)
+			Expect(err).To(MatchError(ErrUnavailable))
+			Expect(err).To(MatchError(ContainSubstring("datastore is down")))
+		}
+	})
+
+	It("returns rejected requests as they are", func() {
+		mintErr = status.Error(codes.PermissionDenied, "not an admin")
+		_, err := client.MintX509SVID(context.Background(), []byte("csr"), 0)
+		Expect(err).NotTo(MatchError(ErrUnavailable))
+		Expect(status.Code(err)).To(Equal(codes.PermissionDenied))
+	})
+})
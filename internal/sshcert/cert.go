@@ -0,0 +1,148 @@
+/*
+Copyright 2025.
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+*/
+
+// Package sshcert issues OpenSSH user certificates (PROTOCOL.certkeys) signed by an SSH CA key.
+package sshcert
+
+import (
+	"crypto"
+	"crypto/ed25519"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/sha512"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"time"
+)
+
+// userCert is the certificate type for user certificates; host certificates are not issued
+const userCert = 1
+
+// certTypes maps the supported public key types to their certificate types
+var certTypes = map[string]string{
+	keyTypeED25519:        "ssh-ed25519-cert-v01@openssh.com",
+	keyTypeRSA:            "ssh-rsa-cert-v01@openssh.com",
+	"ecdsa-sha2-nistp256": "ecdsa-sha2-nistp256-cert-v01@openssh.com",
+	"ecdsa-sha2-nistp384": "ecdsa-sha2-nistp384-cert-v01@openssh.com",
+	"ecdsa-sha2-nistp521": "ecdsa-sha2-nistp521-cert-v01@openssh.com",
+}
+
+// defaultExtensions are the permissions ssh-keygen grants user certificates by default, in the
+// lexical order the protocol requires
+var defaultExtensions = []string{
+	"permit-X11-forwarding",
+	"permit-agent-forwarding",
+	"permit-port-forwarding",
+	"permit-pty",
+	"permit-user-rc",
+}
+
+// Request describes the certificate to issue
+type Request struct {
+	// Key is the user's public key to certify
+	Key PublicKey
+	// KeyID identifies the certificate in the server's logs
+	KeyID string
+	// Principals are the login names the certificate is valid for
+	Principals []string
+	// ValidAfter and ValidBefore bound the certificate's validity
+	ValidAfter  time.Time
+	ValidBefore time.Time
+}
+
+// Sign issues a user certificate for the request and returns it as an authorized_keys line,
+// the format ssh expects in <identity>-cert.pub
+func Sign(ca crypto.Signer, req Request) ([]byte, error) {
+	certType, ok := certTypes[req.Key.Type]
+	if !ok {
+		return nil, fmt.Errorf("unsupported ssh public key type %q", req.Key.Type)
+	}
+	if len(req.Principals) == 0 {
+		return nil, errors.New("ssh certificate needs at least one principal")
+	}
+	if !req.ValidBefore.After(req.ValidAfter) {
+		return nil, errors.New("ssh certificate validity ends before it starts")
+	}
+	caKey, err := publicKeyOf(ca)
+	if err != nil {
+		return nil, err
+	}
+
+	nonce := make([]byte, 32)
+	if _, err := rand.Read(nonce); err != nil {
+		return nil, err
+	}
+	serial := make([]byte, 8)
+	if _, err := rand.Read(serial); err != nil {
+		return nil, err
+	}
+
+	// The certified key's fields follow its type name in the public key blob
+	keyFields := reader{buf: req.Key.Blob}
+	keyFields.string()
+	if keyFields.err != nil {
+		return nil, keyFields.err
+	}
+
+	var principals writer
+	for _, p := range req.Principals {
+		principals.string([]byte(p))
+	}
+	var extensions writer
+	for _, name := range defaultExtensions {
+		extensions.string([]byte(name))
+		extensions.string(nil)
+	}
+
+	var cert writer
+	cert.string([]byte(certType))
+	cert.string(nonce)
+	cert.raw(keyFields.buf)
+	cert.uint64(binary.BigEndian.Uint64(serial))
+	cert.uint32(userCert)
+	cert.string([]byte(req.KeyID))
+	cert.string(principals.buf)
+	cert.uint64(uint64(req.ValidAfter.Unix()))
+	cert.uint64(uint64(req.ValidBefore.Unix()))
+	cert.string(nil) // critical options
+	cert.string(extensions.buf)
+	cert.string(nil) // reserved
+	cert.string(caKey.Blob)
+
+	signature, err := sign(ca, cert.buf)
+	if err != nil {
+		return nil, err
+	}
+	cert.string(signature)
+
+	return PublicKey{Type: certType, Blob: cert.buf}.MarshalAuthorizedKey(req.KeyID), nil
+}
+
+// sign returns the SSH signature blob over data
+func sign(ca crypto.Signer, data []byte) ([]byte, error) {
+	var w writer
+	switch ca.Public().(type) {
+	case ed25519.PublicKey:
+		sig, err := ca.Sign(rand.Reader, data, crypto.Hash(0))
+		if err != nil {
+			return nil, err
+		}
+		w.string([]byte(keyTypeED25519))
+		w.string(sig)
+	case *rsa.PublicKey:
+		digest := sha512.Sum512(data)
+		sig, err := ca.Sign(rand.Reader, digest[:], crypto.SHA512)
+		if err != nil {
+			return nil, err
+		}
+		w.string([]byte("rsa-sha2-512"))
+		w.string(sig)
+	default:
+		return nil, fmt.Errorf("unsupported ssh CA key type %T", ca.Public())
+	}
+	return w.buf, nil
+}
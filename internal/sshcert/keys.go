@@ -0,0 +1,207 @@
+/*
+Copyright 2025.
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+*/
+
+package sshcert
+
+import (
+	"bytes"
+	"crypto"
+	"crypto/ed25519"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/x509"
+	"encoding/base64"
+	"encoding/pem"
+	"errors"
+	"fmt"
+	"math/big"
+	"strings"
+)
+
+const (
+	keyTypeED25519 = "ssh-ed25519"
+	keyTypeRSA     = "ssh-rsa"
+
+	opensshMagic      = "openssh-key-v1\x00"
+	opensshPEMType    = "OPENSSH PRIVATE KEY"
+	opensshCipherNone = "none"
+)
+
+// PublicKey is an SSH public key in wire format together with its key type
+type PublicKey struct {
+	Type string
+	Blob []byte
+}
+
+// MarshalAuthorizedKey renders the key as an authorized_keys line
+func (k PublicKey) MarshalAuthorizedKey(comment string) []byte {
+	line := k.Type + " " + base64.StdEncoding.EncodeToString(k.Blob)
+	if comment != "" {
+		line += " " + comment
+	}
+	return []byte(line + "\n")
+}
+
+// ParseAuthorizedKey parses a single authorized_keys line ("type base64 [comment]")
+func ParseAuthorizedKey(line string) (PublicKey, error) {
+	fields := strings.Fields(line)
+	if len(fields) < 2 {
+		return PublicKey{}, errors.New("ssh public key must have the form '<type> <base64> [comment]'")
+	}
+	blob, err := base64.StdEncoding.DecodeString(fields[1])
+	if err != nil {
+		return PublicKey{}, fmt.Errorf("ssh public key is not valid base64: %w", err)
+	}
+	r := reader{buf: blob}
+	keyType := string(r.string())
+	if r.err != nil || keyType != fields[0] {
+		return PublicKey{}, fmt.Errorf("ssh public key type %q does not match its encoded type %q", fields[0], keyType)
+	}
+	if _, ok := certTypes[keyType]; !ok {
+		return PublicKey{}, fmt.Errorf("unsupported ssh public key type %q", keyType)
+	}
+	return PublicKey{Type: keyType, Blob: blob}, nil
+}
+
+// publicKeyOf returns the wire format of a CA signer's public key
+func publicKeyOf(signer crypto.Signer) (PublicKey, error) {
+	var w writer
+	switch pub := signer.Public().(type) {
+	case ed25519.PublicKey:
+		w.string([]byte(keyTypeED25519))
+		w.string(pub)
+		return PublicKey{Type: keyTypeED25519, Blob: w.buf}, nil
+	case *rsa.PublicKey:
+		w.string([]byte(keyTypeRSA))
+		w.mpint(big.NewInt(int64(pub.E)))
+		w.mpint(pub.N)
+		return PublicKey{Type: keyTypeRSA, Blob: w.buf}, nil
+	default:
+		return PublicKey{}, fmt.Errorf("unsupported ssh CA key type %T", pub)
+	}
+}
+
+// ParseCAKey parses an unencrypted ed25519 or RSA private key, either in OpenSSH format
+// (as written by ssh-keygen) or as PKCS#8 or PKCS#1 PEM
+func ParseCAKey(data []byte) (crypto.Signer, error) {
+	block, _ := pem.Decode(data)
+	if block == nil {
+		return nil, errors.New("ssh CA key is not PEM encoded")
+	}
+	switch block.Type {
+	case opensshPEMType:
+		return parseOpenSSHPrivateKey(block.Bytes)
+	case "RSA PRIVATE KEY":
+		return x509.ParsePKCS1PrivateKey(block.Bytes)
+	case "PRIVATE KEY":
+		key, err := x509.ParsePKCS8PrivateKey(block.Bytes)
+		if err != nil {
+			return nil, err
+		}
+		switch key := key.(type) {
+		case ed25519.PrivateKey:
+			return key, nil
+		case *rsa.PrivateKey:
+			return key, nil
+		}
+		return nil, fmt.Errorf("unsupported ssh CA key type %T", key)
+	}
+	return nil, fmt.Errorf("unsupported ssh CA key PEM type %q", block.Type)
+}
+
+func parseOpenSSHPrivateKey(data []byte) (crypto.Signer, error) {
+	if !bytes.HasPrefix(data, []byte(opensshMagic)) {
+		return nil, errors.New("invalid OpenSSH private key")
+	}
+	r := reader{buf: data[len(opensshMagic):]}
+	cipher, kdf := string(r.string()), string(r.string())
+	r.string() // kdf options
+	if r.err == nil && (cipher != opensshCipherNone || kdf != opensshCipherNone) {
+		return nil, errors.New("encrypted OpenSSH private keys are not supported")
+	}
+	if n := r.uint32(); r.err == nil && n != 1 {
+		return nil, fmt.Errorf("OpenSSH private key holds %d keys, expected 1", n)
+	}
+	r.string() // public key
+	priv := reader{buf: r.string()}
+	if r.err != nil {
+		return nil, r.err
+	}
+
+	if priv.uint32() != priv.uint32() {
+		return nil, errors.New("OpenSSH private key check bytes do not match")
+	}
+	switch keyType := string(priv.string()); keyType {
+	case keyTypeED25519:
+		priv.string() // public part, repeated in the private key
+		key := priv.string()
+		if priv.err != nil {
+			return nil, priv.err
+		}
+		if len(key) != ed25519.PrivateKeySize {
+			return nil, errors.New("invalid ed25519 private key length")
+		}
+		return ed25519.PrivateKey(bytes.Clone(key)), nil
+	case keyTypeRSA:
+		n, e, d := priv.mpint(), priv.mpint(), priv.mpint()
+		priv.mpint() // iqmp, recomputed by Precompute
+		p, q := priv.mpint(), priv.mpint()
+		if priv.err != nil {
+			return nil, priv.err
+		}
+		key := &rsa.PrivateKey{
+			PublicKey: rsa.PublicKey{N: n, E: int(e.Int64())},
+			D:         d,
+			Primes:    []*big.Int{p, q},
+		}
+		if err := key.Validate(); err != nil {
+			return nil, err
+		}
+		key.Precompute()
+		return key, nil
+	default:
+		return nil, fmt.Errorf("unsupported OpenSSH private key type %q", keyType)
+	}
+}
+
+// GenerateKey creates an ed25519 key pair and returns the private key in OpenSSH format
+// together with its public key
+func GenerateKey(comment string) ([]byte, PublicKey, error) {
+	pub, priv, err := ed25519.GenerateKey(rand.Reader)
+	if err != nil {
+		return nil, PublicKey{}, err
+	}
+	var pubWire writer
+	pubWire.string([]byte(keyTypeED25519))
+	pubWire.string(pub)
+
+	var check [4]byte
+	if _, err := rand.Read(check[:]); err != nil {
+		return nil, PublicKey{}, err
+	}
+	var privWire writer
+	privWire.raw(check[:])
+	privWire.raw(check[:])
+	privWire.string([]byte(keyTypeED25519))
+	privWire.string(pub)
+	privWire.string(priv)
+	privWire.string([]byte(comment))
+	for i := byte(1); len(privWire.buf)%8 != 0; i++ {
+		privWire.raw([]byte{i})
+	}
+
+	var out writer
+	out.raw([]byte(opensshMagic))
+	out.string([]byte(opensshCipherNone))
+	out.string([]byte(opensshCipherNone))
+	out.string(nil)
+	out.uint32(1)
+	out.string(pubWire.buf)
+	out.string(privWire.buf)
+
+	keyPEM := pem.EncodeToMemory(&pem.Block{Type: opensshPEMType, Bytes: out.buf})
+	return keyPEM, PublicKey{Type: keyTypeED25519, Blob: pubWire.buf}, nil
+}
@@ -0,0 +1,152 @@
+/*
+Copyright 2025.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package sshcert
+
+import (
+	"crypto"
+	"crypto/ed25519"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/sha512"
+	"crypto/x509"
+	"encoding/base64"
+	"encoding/pem"
+	"strings"
+	"time"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+)
+
+// parsedCert holds the certificate fields the tests check
+type parsedCert struct {
+	certType    string
+	keyID       string
+	principals  []string
+	validAfter  uint64
+	validBefore uint64
+	signed      []byte
+	sigFormat   string
+	signature   []byte
+}
+
+func parseCert(line []byte) parsedCert {
+	fields := strings.Fields(string(line))
+	ExpectWithOffset(1, fields).To(HaveLen(3))
+	blob, err := base64.StdEncoding.DecodeString(fields[1])
+	ExpectWithOffset(1, err).NotTo(HaveOccurred())
+	r := reader{buf: blob}
+	var c parsedCert
+	c.certType = string(r.string())
+	r.string() // nonce
+	r.string() // ed25519 public key
+	r.uint64() // serial
+	ExpectWithOffset(1, r.uint32()).To(Equal(uint32(userCert)))
+	c.keyID = string(r.string())
+	principals := reader{buf: r.string()}
+	for len(principals.buf) > 0 {
+		c.principals = append(c.principals, string(principals.string()))
+	}
+	c.validAfter, c.validBefore = r.uint64(), r.uint64()
+	r.string() // critical options
+	r.string() // extensions
+	r.string() // reserved
+	r.string() // CA key
+	c.signed = blob[:len(blob)-len(r.buf)]
+	sig := reader{buf: r.string()}
+	c.sigFormat, c.signature = string(sig.string()), sig.string()
+	ExpectWithOffset(1, r.err).NotTo(HaveOccurred())
+	ExpectWithOffset(1, r.buf).To(BeEmpty())
+	return c
+}
+
+var _ = Describe("SSH certificates", func() {
+	now := time.Unix(1700000000, 0)
+	request := func(key PublicKey) Request {
+		return Request{
+			Key:         key,
+			KeyID:       "kubeuser:jane",
+			Principals:  []string{"jane", "ops"},
+			ValidAfter:  now,
+			ValidBefore: now.Add(time.Hour),
+		}
+	}
+
+	It("signs generated keys with an OpenSSH ed25519 CA", func() {
+		caPEM, _, err := GenerateKey("ca")
+		Expect(err).NotTo(HaveOccurred())
+		ca, err := ParseCAKey(caPEM)
+		Expect(err).NotTo(HaveOccurred())
+
+		_, userKey, err := GenerateKey("jane")
+		Expect(err).NotTo(HaveOccurred())
+		line, err := Sign(ca, request(userKey))
+		Expect(err).NotTo(HaveOccurred())
+		Expect(string(line)).To(HavePrefix("ssh-ed25519-cert-v01@openssh.com "))
+
+		cert := parseCert(line)
+		Expect(cert.keyID).To(Equal("kubeuser:jane"))
+		Expect(cert.principals).To(Equal([]string{"jane", "ops"}))
+		Expect(cert.validAfter).To(Equal(uint64(now.Unix())))
+		Expect(cert.validBefore).To(Equal(uint64(now.Add(time.Hour).Unix())))
+		Expect(cert.sigFormat).To(Equal("ssh-ed25519"))
+		Expect(ed25519.Verify(ca.Public().(ed25519.PublicKey), cert.signed, cert.signature)).To(BeTrue())
+	})
+
+	It("signs with a PKCS#1 RSA CA using rsa-sha2-512", func() {
+		rsaKey, err := rsa.GenerateKey(rand.Reader, 2048)
+		Expect(err).NotTo(HaveOccurred())
+		ca, err := ParseCAKey(pem.EncodeToMemory(&pem.Block{Type: "RSA PRIVATE KEY", Bytes: x509.MarshalPKCS1PrivateKey(rsaKey)}))
+		Expect(err).NotTo(HaveOccurred())
+
+		_, userKey, err := GenerateKey("jane")
+		Expect(err).NotTo(HaveOccurred())
+		line, err := Sign(ca, request(userKey))
+		Expect(err).NotTo(HaveOccurred())
+
+		cert := parseCert(line)
+		Expect(cert.sigFormat).To(Equal("rsa-sha2-512"))
+		digest := sha512.Sum512(cert.signed)
+		Expect(rsa.VerifyPKCS1v15(&rsaKey.PublicKey, crypto.SHA512, digest[:], cert.signature)).To(Succeed())
+	})
+
+	It("round-trips public keys through authorized_keys lines", func() {
+		_, key, err := GenerateKey("jane")
+		Expect(err).NotTo(HaveOccurred())
+		parsed, err := ParseAuthorizedKey(strings.TrimSpace(string(key.MarshalAuthorizedKey("jane@laptop"))))
+		Expect(err).NotTo(HaveOccurred())
+		Expect(parsed).To(Equal(key))
+	})
+
+	It("rejects malformed public keys and requests", func() {
+		_, err := ParseAuthorizedKey("ssh-ed25519")
+		Expect(err).To(HaveOccurred())
+		_, err = ParseAuthorizedKey("ssh-rsa AAAAC3NzaC1lZDI1NTE5AAAAIKt5WuJdm0W4s3DfYx5GwKf/rWbRKfoz5h6I9mKE/Rxv")
+		Expect(err).To(MatchError(ContainSubstring("does not match")))
+		_, err = ParseAuthorizedKey("ssh-dss AAAAB3NzaC1kc3M=")
+		Expect(err).To(MatchError(ContainSubstring("unsupported")))
+
+		caPEM, userKey, err := GenerateKey("ca")
+		Expect(err).NotTo(HaveOccurred())
+		ca, err := ParseCAKey(caPEM)
+		Expect(err).NotTo(HaveOccurred())
+		req := request(userKey)
+		req.Principals = nil
+		_, err = Sign(ca, req)
+		Expect(err).To(MatchError(ContainSubstring("principal")))
+	})
+})
@@ -0,0 +1,88 @@
+/*
+Copyright 2025.
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+*/
+
+package sshcert
+
+import (
+	"encoding/binary"
+	"errors"
+	"math/big"
+)
+
+// The SSH wire format (RFC 4251, section 5) encodes strings and multiple precision integers
+// with a uint32 length prefix. Only what certificates and OpenSSH private keys need is here.
+
+var errShortBuffer = errors.New("ssh: short buffer")
+
+type writer struct {
+	buf []byte
+}
+
+func (w *writer) uint32(v uint32) {
+	w.buf = binary.BigEndian.AppendUint32(w.buf, v)
+}
+
+func (w *writer) uint64(v uint64) {
+	w.buf = binary.BigEndian.AppendUint64(w.buf, v)
+}
+
+func (w *writer) string(s []byte) {
+	w.uint32(uint32(len(s)))
+	w.buf = append(w.buf, s...)
+}
+
+func (w *writer) mpint(n *big.Int) {
+	b := n.Bytes()
+	// Positive numbers with the high bit set need a leading zero byte
+	if len(b) > 0 && b[0]&0x80 != 0 {
+		b = append([]byte{0}, b...)
+	}
+	w.string(b)
+}
+
+func (w *writer) raw(b []byte) {
+	w.buf = append(w.buf, b...)
+}
+
+type reader struct {
+	buf []byte
+	err error
+}
+
+func (r *reader) uint32() uint32 {
+	if r.err != nil || len(r.buf) < 4 {
+		r.err = errShortBuffer
+		return 0
+	}
+	v := binary.BigEndian.Uint32(r.buf)
+	r.buf = r.buf[4:]
+	return v
+}
+
+func (r *reader) uint64() uint64 {
+	if r.err != nil || len(r.buf) < 8 {
+		r.err = errShortBuffer
+		return 0
+	}
+	v := binary.BigEndian.Uint64(r.buf)
+	r.buf = r.buf[8:]
+	return v
+}
+
+func (r *reader) string() []byte {
+	n := r.uint32()
+	if r.err != nil || uint32(len(r.buf)) < n {
+		r.err = errShortBuffer
+		return nil
+	}
+	s := r.buf[:n]
+	r.buf = r.buf[n:]
+	return s
+}
+
+func (r *reader) mpint() *big.Int {
+	return new(big.Int).SetBytes(r.string())
+}
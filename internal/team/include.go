@@ -0,0 +1,79 @@
+/*
+Copyright 2025.
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+*/
+
+package team
+
+import (
+	"context"
+	"fmt"
+	"slices"
+
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/types"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+
+	authv1alpha1 "github.com/openkube-hub/KubeUser/api/v1alpha1"
+)
+
+// Include returns teams followed by the Teams they include, directly or through other included
+// Teams. Included Teams come breadth first in the order they are listed, so a team's roles take
+// precedence over those of the Teams it includes in Apply. Each Team is returned once, which
+// also ends cycles; included Teams that do not exist are skipped.
+func Include(ctx context.Context, reader client.Reader, teams []authv1alpha1.Team) ([]authv1alpha1.Team, error) {
+	flattened := slices.Clone(teams)
+	seen := make(map[string]bool, len(teams))
+	for _, t := range teams {
+		seen[t.Name] = true
+	}
+	for i := 0; i < len(flattened); i++ {
+		for _, name := range flattened[i].Spec.IncludeTeams {
+			if seen[name] {
+				continue
+			}
+			seen[name] = true
+			var included authv1alpha1.Team
+			if err := reader.Get(ctx, types.NamespacedName{Name: name}, &included); err != nil {
+				if apierrors.IsNotFound(err) {
+					continue
+				}
+				return nil, fmt.Errorf("failed to get included team %s: %w", name, err)
+			}
+			flattened = append(flattened, included)
+		}
+	}
+	return flattened, nil
+}
+
+// IncludeCycle returns the path of Teams by which team includes itself, starting and ending
+// with team, or nil if it does not. team's own includes are taken from team rather than read,
+// so a new version can be checked before it is stored.
+func IncludeCycle(ctx context.Context, reader client.Reader, team *authv1alpha1.Team) ([]string, error) {
+	visited := map[string]bool{}
+	var visit func(path, includes []string) ([]string, error)
+	visit = func(path, includes []string) ([]string, error) {
+		for _, name := range includes {
+			if name == team.Name {
+				return append(slices.Clone(path), name), nil
+			}
+			if visited[name] {
+				continue
+			}
+			visited[name] = true
+			var included authv1alpha1.Team
+			if err := reader.Get(ctx, types.NamespacedName{Name: name}, &included); err != nil {
+				if apierrors.IsNotFound(err) {
+					continue
+				}
+				return nil, fmt.Errorf("failed to get included team %s: %w", name, err)
+			}
+			if cycle, err := visit(append(path, name), included.Spec.IncludeTeams); cycle != nil || err != nil {
+				return cycle, err
+			}
+		}
+		return nil, nil
+	}
+	return visit([]string{team.Name}, team.Spec.IncludeTeams)
+}
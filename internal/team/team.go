@@ -0,0 +1,244 @@
+/*
+Copyright 2025.
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+*/
+
+// Package team merges the roles of Teams into their members. The controller binds them with
+// the member's own roles, and the admission webhook checks them when the Team changes.
+package team
+
+import (
+	"context"
+	"fmt"
+	"slices"
+	"strings"
+	"time"
+
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/labels"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+
+	authv1alpha1 "github.com/openkube-hub/KubeUser/api/v1alpha1"
+)
+
+// MemberLabel marks the Users a Team created for its members; the value is the Team's name
+const MemberLabel = "auth.openkube.io/team"
+
+// ForUser returns the Teams listing the User name as a member, sorted by name so their roles
+// are merged in a stable order. opts may narrow the Teams listed, e.g. to an index of members.
+func ForUser(ctx context.Context, reader client.Reader, name string, opts ...client.ListOption) ([]authv1alpha1.Team, error) {
+	var teams authv1alpha1.TeamList
+	if err := reader.List(ctx, &teams, opts...); err != nil {
+		return nil, fmt.Errorf("failed to list teams: %w", err)
+	}
+	var member []authv1alpha1.Team
+	for _, t := range teams.Items {
+		if IsMember(&t, name) {
+			member = append(member, t)
+		}
+	}
+	slices.SortFunc(member, func(a, b authv1alpha1.Team) int { return strings.Compare(a.Name, b.Name) })
+	return member, nil
+}
+
+// IsMember reports whether the User name is a member receiving team's roles: listed in the
+// spec, selected by its labels, or in the team's directory group with an account that is not
+// disabled
+func IsMember(team *authv1alpha1.Team, name string) bool {
+	if isListed(team, name) || isSelected(team, name) {
+		return true
+	}
+	member, ok := directoryMember(team, name)
+	return ok && !member.Disabled
+}
+
+// Lists reports whether team has the User name as a member, including members whose directory
+// account is disabled. The team keeps the Users it created for them.
+func Lists(team *authv1alpha1.Team, name string) bool {
+	_, ok := directoryMember(team, name)
+	return ok || isListed(team, name) || isSelected(team, name)
+}
+
+// IsDisabled reports whether the User name is a member only through the team's directory group
+// and its account there is disabled
+func IsDisabled(team *authv1alpha1.Team, name string) bool {
+	member, ok := directoryMember(team, name)
+	return ok && member.Disabled && !isListed(team, name) && !isSelected(team, name)
+}
+
+// MemberNames returns the members listed in team's spec whose membership has not expired,
+// followed by those of its directory group that are not listed, disabled or not, and then those
+// selected by their labels that are in neither
+func MemberNames(team *authv1alpha1.Team) []string {
+	names := make([]string, 0, len(team.Spec.Members)+len(team.Status.DirectoryMembers)+len(team.Status.SelectedMembers))
+	for _, member := range team.Spec.Members {
+		if !isExpired(team, member) {
+			names = append(names, member.Name)
+		}
+	}
+	for _, member := range team.Status.DirectoryMembers {
+		if team.Spec.DirectorySync != nil && !isListed(team, member.Name) {
+			names = append(names, member.Name)
+		}
+	}
+	for _, name := range team.Status.SelectedMembers {
+		if _, ok := directoryMember(team, name); isSelected(team, name) && !ok && !isListed(team, name) {
+			names = append(names, name)
+		}
+	}
+	return names
+}
+
+// Selects reports whether team's spec.memberSelector matches a User with the labels set. Teams
+// without a selector, or with an invalid one, select no Users.
+func Selects(team *authv1alpha1.Team, set labels.Set) bool {
+	if team.Spec.MemberSelector == nil {
+		return false
+	}
+	selector, err := metav1.LabelSelectorAsSelector(team.Spec.MemberSelector)
+	return err == nil && !selector.Empty() && selector.Matches(set)
+}
+
+// Selecting returns the Teams whose spec.memberSelector matches a User with the labels set,
+// sorted by name like ForUser
+func Selecting(ctx context.Context, reader client.Reader, set labels.Set) ([]authv1alpha1.Team, error) {
+	var teams authv1alpha1.TeamList
+	if err := reader.List(ctx, &teams); err != nil {
+		return nil, fmt.Errorf("failed to list teams: %w", err)
+	}
+	var selecting []authv1alpha1.Team
+	for _, t := range teams.Items {
+		if Selects(&t, set) {
+			selecting = append(selecting, t)
+		}
+	}
+	slices.SortFunc(selecting, func(a, b authv1alpha1.Team) int { return strings.Compare(a.Name, b.Name) })
+	return selecting, nil
+}
+
+// Expirations returns the members of team's spec whose expiresAt is not after now, for
+// status.expiredMembers
+func Expirations(team *authv1alpha1.Team, now time.Time) []authv1alpha1.ExpiredMember {
+	var expired []authv1alpha1.ExpiredMember
+	for _, member := range team.Spec.Members {
+		if member.ExpiresAt != nil && !member.ExpiresAt.After(now) {
+			expired = append(expired, authv1alpha1.ExpiredMember{Name: member.Name, ExpiresAt: *member.ExpiresAt})
+		}
+	}
+	return expired
+}
+
+// isListed reports whether team's spec lists the User name with a membership that has not expired
+func isListed(team *authv1alpha1.Team, name string) bool {
+	i := slices.IndexFunc(team.Spec.Members, func(m authv1alpha1.TeamMember) bool { return m.Name == name })
+	return i >= 0 && !isExpired(team, team.Spec.Members[i])
+}
+
+// isExpired reports whether member's expiresAt has passed as of the last reconcile of team. A
+// changed expiresAt no longer matches the recorded one, so it applies right away.
+func isExpired(team *authv1alpha1.Team, member authv1alpha1.TeamMember) bool {
+	if member.ExpiresAt == nil {
+		return false
+	}
+	return slices.ContainsFunc(team.Status.ExpiredMembers, func(e authv1alpha1.ExpiredMember) bool {
+		return e.Name == member.Name && e.ExpiresAt.Equal(member.ExpiresAt)
+	})
+}
+
+// isSelected reports whether the User name matched team's member selector at the last reconcile
+func isSelected(team *authv1alpha1.Team, name string) bool {
+	return team.Spec.MemberSelector != nil && slices.Contains(team.Status.SelectedMembers, name)
+}
+
+// directoryMember returns the member name of team's directory group as of the last sync
+func directoryMember(team *authv1alpha1.Team, name string) (authv1alpha1.DirectoryMember, bool) {
+	if team.Spec.DirectorySync == nil {
+		return authv1alpha1.DirectoryMember{}, false
+	}
+	i := slices.IndexFunc(team.Status.DirectoryMembers, func(m authv1alpha1.DirectoryMember) bool { return m.Name == name })
+	if i < 0 {
+		return authv1alpha1.DirectoryMember{}, false
+	}
+	return team.Status.DirectoryMembers[i], true
+}
+
+// Grants returns the roles team grants its members. Roles in the team's namespaces create
+// them if they do not exist yet, as the team's own reconcile does.
+func Grants(team *authv1alpha1.Team) ([]authv1alpha1.RoleSpec, []authv1alpha1.ClusterRoleSpec) {
+	roles := make([]authv1alpha1.RoleSpec, 0, len(team.Spec.Roles))
+	for _, role := range team.Spec.Roles {
+		role := *role.DeepCopy()
+		if slices.Contains(team.Spec.Namespaces, role.Namespace) {
+			role.CreateNamespace = true
+		}
+		roles = append(roles, role)
+	}
+	clusterRoles := make([]authv1alpha1.ClusterRoleSpec, 0, len(team.Spec.ClusterRoles))
+	for _, clusterRole := range team.Spec.ClusterRoles {
+		clusterRoles = append(clusterRoles, *clusterRole.DeepCopy())
+	}
+	return roles, clusterRoles
+}
+
+// Apply adds the roles of teams after user's own. An entry for a Role in a namespace, or for a
+// ClusterRole, that the user or an earlier team already has is skipped. The teams' EKS access
+// policies are added when the user has an access entry, and their GCP roles when the user has a
+// Google account; see MergeEKSAccessPolicy.
+func Apply(user *authv1alpha1.User, teams []authv1alpha1.Team) {
+	bound := map[[2]string]bool{}
+	for _, role := range user.Spec.Roles {
+		bound[[2]string{role.Namespace, role.ExistingRole}] = true
+	}
+	clusterBound := map[string]bool{}
+	for _, clusterRole := range user.Spec.ClusterRoles {
+		clusterBound[clusterRole.ExistingClusterRole] = true
+	}
+	for i := range teams {
+		roles, clusterRoles := Grants(&teams[i])
+		for _, role := range roles {
+			if key := [2]string{role.Namespace, role.ExistingRole}; !bound[key] {
+				bound[key] = true
+				user.Spec.Roles = append(user.Spec.Roles, role)
+			}
+		}
+		for _, clusterRole := range clusterRoles {
+			if !clusterBound[clusterRole.ExistingClusterRole] {
+				clusterBound[clusterRole.ExistingClusterRole] = true
+				user.Spec.ClusterRoles = append(user.Spec.ClusterRoles, clusterRole)
+			}
+		}
+		if user.Spec.EKS != nil {
+			for _, policy := range teams[i].Spec.EKSAccessPolicies {
+				user.Spec.EKS.AccessPolicies = MergeEKSAccessPolicy(user.Spec.EKS.AccessPolicies, policy)
+			}
+		}
+		if user.Spec.GCP != nil {
+			for _, role := range teams[i].Spec.GCPRoles {
+				if !slices.Contains(user.Spec.GCP.Roles, role) {
+					user.Spec.GCP.Roles = append(user.Spec.GCP.Roles, role)
+				}
+			}
+		}
+	}
+}
+
+// MergeEKSAccessPolicy adds policy to policies. An access entry holds each policy once, so a
+// policy already there gets the namespaces of both, or the whole cluster if either has it.
+func MergeEKSAccessPolicy(policies []authv1alpha1.EKSAccessPolicy,
+	policy authv1alpha1.EKSAccessPolicy) []authv1alpha1.EKSAccessPolicy {
+	i := slices.IndexFunc(policies, func(p authv1alpha1.EKSAccessPolicy) bool { return p.PolicyARN == policy.PolicyARN })
+	if i < 0 {
+		return append(policies, *policy.DeepCopy())
+	}
+	if len(policies[i].Namespaces) == 0 || len(policy.Namespaces) == 0 {
+		policies[i].Namespaces = nil
+		return policies
+	}
+	for _, namespace := range policy.Namespaces {
+		if !slices.Contains(policies[i].Namespaces, namespace) {
+			policies[i].Namespaces = append(policies[i].Namespaces, namespace)
+		}
+	}
+	return policies
+}
@@ -0,0 +1,291 @@
+/*
+Copyright 2025.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package team
+
+import (
+	"context"
+	"time"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/labels"
+	"k8s.io/apimachinery/pkg/runtime"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+
+	authv1alpha1 "github.com/openkube-hub/KubeUser/api/v1alpha1"
+)
+
+var _ = Describe("Team", func() {
+	payments := authv1alpha1.Team{
+		ObjectMeta: metav1.ObjectMeta{Name: "payments"},
+		Spec: authv1alpha1.TeamSpec{
+			Namespaces: []string{"payments"},
+			Roles: []authv1alpha1.RoleSpec{
+				{Namespace: "payments", ExistingRole: "developer"},
+				{Namespace: "shared", ExistingRole: "view"},
+			},
+			ClusterRoles: []authv1alpha1.ClusterRoleSpec{{ExistingClusterRole: "view"}},
+			Members:      []authv1alpha1.TeamMember{{Name: "alice"}, {Name: "bob"}},
+		},
+	}
+	oncall := authv1alpha1.Team{
+		ObjectMeta: metav1.ObjectMeta{Name: "oncall"},
+		Spec: authv1alpha1.TeamSpec{
+			Roles:        []authv1alpha1.RoleSpec{{Namespace: "shared", ExistingRole: "view"}},
+			ClusterRoles: []authv1alpha1.ClusterRoleSpec{{ExistingClusterRole: "view"}, {ExistingClusterRole: "node-reader"}},
+			Members:      []authv1alpha1.TeamMember{{Name: "alice"}},
+		},
+	}
+
+	It("creates the team's namespaces for its roles", func() {
+		roles, clusterRoles := Grants(&payments)
+		Expect(roles).To(Equal([]authv1alpha1.RoleSpec{
+			{Namespace: "payments", ExistingRole: "developer", CreateNamespace: true},
+			{Namespace: "shared", ExistingRole: "view"},
+		}))
+		Expect(clusterRoles).To(Equal([]authv1alpha1.ClusterRoleSpec{{ExistingClusterRole: "view"}}))
+		Expect(payments.Spec.Roles[0].CreateNamespace).To(BeFalse())
+	})
+
+	It("adds each role once after the user's own", func() {
+		until := metav1.Now()
+		user := &authv1alpha1.User{Spec: authv1alpha1.UserSpec{
+			Roles: []authv1alpha1.RoleSpec{{Namespace: "payments", ExistingRole: "developer", ExpiresAt: &until}},
+		}}
+		Apply(user, []authv1alpha1.Team{payments, oncall})
+		Expect(user.Spec.Roles).To(Equal([]authv1alpha1.RoleSpec{
+			{Namespace: "payments", ExistingRole: "developer", ExpiresAt: &until},
+			{Namespace: "shared", ExistingRole: "view"},
+		}))
+		Expect(user.Spec.ClusterRoles).To(Equal([]authv1alpha1.ClusterRoleSpec{
+			{ExistingClusterRole: "view"},
+			{ExistingClusterRole: "node-reader"},
+		}))
+	})
+
+	It("merges EKS access policies into the user's access entry", func() {
+		view := "arn:aws:eks::aws:cluster-access-policy/AmazonEKSViewPolicy"
+		edit := "arn:aws:eks::aws:cluster-access-policy/AmazonEKSEditPolicy"
+		withPolicies := func(t authv1alpha1.Team, policies ...authv1alpha1.EKSAccessPolicy) authv1alpha1.Team {
+			t.Spec.EKSAccessPolicies = policies
+			return t
+		}
+		teams := []authv1alpha1.Team{
+			withPolicies(payments,
+				authv1alpha1.EKSAccessPolicy{PolicyARN: view, Namespaces: []string{"payments"}},
+				authv1alpha1.EKSAccessPolicy{PolicyARN: edit, Namespaces: []string{"payments"}}),
+			withPolicies(oncall, authv1alpha1.EKSAccessPolicy{PolicyARN: edit}),
+		}
+
+		user := &authv1alpha1.User{Spec: authv1alpha1.UserSpec{EKS: &authv1alpha1.EKSAccess{
+			PrincipalARN:   "arn:aws:iam::111122223333:role/alice",
+			AccessPolicies: []authv1alpha1.EKSAccessPolicy{{PolicyARN: view, Namespaces: []string{"shared"}}},
+		}}}
+		Apply(user, teams)
+		Expect(user.Spec.EKS.AccessPolicies).To(Equal([]authv1alpha1.EKSAccessPolicy{
+			{PolicyARN: view, Namespaces: []string{"shared", "payments"}},
+			{PolicyARN: edit},
+		}))
+		Expect(teams[0].Spec.EKSAccessPolicies[1].Namespaces).To(Equal([]string{"payments"}))
+
+		withoutEntry := &authv1alpha1.User{}
+		Apply(withoutEntry, teams)
+		Expect(withoutEntry.Spec.EKS).To(BeNil())
+	})
+
+	It("adds GCP roles for members with a Google account", func() {
+		withRoles := func(t authv1alpha1.Team, roles ...string) authv1alpha1.Team {
+			t.Spec.GCPRoles = roles
+			return t
+		}
+		teams := []authv1alpha1.Team{
+			withRoles(payments, "roles/container.developer"),
+			withRoles(oncall, "roles/container.viewer", "roles/container.developer"),
+		}
+
+		user := &authv1alpha1.User{Spec: authv1alpha1.UserSpec{GCP: &authv1alpha1.GCPAccess{
+			Account: "alice@example.com",
+			Roles:   []string{"roles/container.viewer"},
+		}}}
+		Apply(user, teams)
+		Expect(user.Spec.GCP.Roles).To(Equal([]string{"roles/container.viewer", "roles/container.developer"}))
+
+		withoutAccount := &authv1alpha1.User{}
+		Apply(withoutAccount, teams)
+		Expect(withoutAccount.Spec.GCP).To(BeNil())
+	})
+
+	It("counts directory members with an enabled account", func() {
+		platform := authv1alpha1.Team{
+			ObjectMeta: metav1.ObjectMeta{Name: "platform"},
+			Spec: authv1alpha1.TeamSpec{
+				Members:       []authv1alpha1.TeamMember{{Name: "alice"}},
+				DirectorySync: &authv1alpha1.DirectorySync{Provider: authv1alpha1.DirectoryProviderEntra, Group: "platform"},
+			},
+			Status: authv1alpha1.TeamStatus{DirectoryMembers: []authv1alpha1.DirectoryMember{
+				{Name: "alice", Disabled: true},
+				{Name: "bob"},
+				{Name: "carol", Disabled: true},
+			}},
+		}
+		Expect(MemberNames(&platform)).To(Equal([]string{"alice", "bob", "carol"}))
+		Expect(IsMember(&platform, "alice")).To(BeTrue())
+		Expect(IsMember(&platform, "bob")).To(BeTrue())
+		Expect(IsMember(&platform, "carol")).To(BeFalse())
+		Expect(Lists(&platform, "carol")).To(BeTrue())
+		Expect(IsDisabled(&platform, "alice")).To(BeFalse())
+		Expect(IsDisabled(&platform, "carol")).To(BeTrue())
+
+		platform.Spec.DirectorySync = nil
+		Expect(MemberNames(&platform)).To(Equal([]string{"alice"}))
+		Expect(IsMember(&platform, "bob")).To(BeFalse())
+	})
+
+	It("counts members selected by their labels", func() {
+		platform := authv1alpha1.Team{
+			ObjectMeta: metav1.ObjectMeta{Name: "platform"},
+			Spec: authv1alpha1.TeamSpec{
+				Members:        []authv1alpha1.TeamMember{{Name: "alice"}},
+				MemberSelector: &metav1.LabelSelector{MatchLabels: map[string]string{"team": "platform"}},
+				DirectorySync:  &authv1alpha1.DirectorySync{Provider: authv1alpha1.DirectoryProviderEntra, Group: "platform"},
+			},
+			Status: authv1alpha1.TeamStatus{
+				DirectoryMembers: []authv1alpha1.DirectoryMember{{Name: "bob", Disabled: true}},
+				SelectedMembers:  []string{"alice", "bob", "dave"},
+			},
+		}
+		Expect(MemberNames(&platform)).To(Equal([]string{"alice", "bob", "dave"}))
+		Expect(IsMember(&platform, "dave")).To(BeTrue())
+		Expect(Lists(&platform, "dave")).To(BeTrue())
+		// A selected member gets the team's roles despite its disabled directory account
+		Expect(IsMember(&platform, "bob")).To(BeTrue())
+		Expect(IsDisabled(&platform, "bob")).To(BeFalse())
+
+		Expect(Selects(&platform, labels.Set{"team": "platform"})).To(BeTrue())
+		Expect(Selects(&platform, labels.Set{"team": "payments"})).To(BeFalse())
+
+		platform.Spec.MemberSelector = &metav1.LabelSelector{}
+		Expect(Selects(&platform, labels.Set{"team": "platform"})).To(BeFalse())
+		platform.Spec.MemberSelector = nil
+		Expect(MemberNames(&platform)).To(Equal([]string{"alice", "bob"}))
+		Expect(IsMember(&platform, "dave")).To(BeFalse())
+	})
+
+	It("finds the teams selecting a user", func() {
+		scheme := runtime.NewScheme()
+		Expect(authv1alpha1.AddToScheme(scheme)).To(Succeed())
+		selecting := func(name string) *authv1alpha1.Team {
+			t := payments.DeepCopy()
+			t.Name = name
+			t.Spec.MemberSelector = &metav1.LabelSelector{MatchLabels: map[string]string{"team": "payments"}}
+			return t
+		}
+		c := fake.NewClientBuilder().WithScheme(scheme).
+			WithObjects(selecting("payments-eu"), selecting("payments-ap"), oncall.DeepCopy()).Build()
+
+		teams, err := Selecting(context.Background(), c, labels.Set{"team": "payments"})
+		Expect(err).NotTo(HaveOccurred())
+		Expect(teams).To(HaveLen(2))
+		Expect(teams[0].Name).To(Equal("payments-ap"))
+		Expect(teams[1].Name).To(Equal("payments-eu"))
+
+		teams, err = Selecting(context.Background(), c, nil)
+		Expect(err).NotTo(HaveOccurred())
+		Expect(teams).To(BeEmpty())
+	})
+
+	It("flattens included teams breadth first, each once", func() {
+		scheme := runtime.NewScheme()
+		Expect(authv1alpha1.AddToScheme(scheme)).To(Succeed())
+		team := func(name string, includes ...string) *authv1alpha1.Team {
+			return &authv1alpha1.Team{
+				ObjectMeta: metav1.ObjectMeta{Name: name},
+				Spec:       authv1alpha1.TeamSpec{IncludeTeams: includes},
+			}
+		}
+		c := fake.NewClientBuilder().WithScheme(scheme).WithObjects(
+			team("backend", "baseline", "oncall"),
+			team("oncall", "baseline", "payments"),
+			team("baseline", "backend"),
+			team("payments"),
+		).Build()
+		ctx := context.Background()
+
+		teams, err := Include(ctx, c, []authv1alpha1.Team{*team("frontend", "missing", "oncall", "backend")})
+		Expect(err).NotTo(HaveOccurred())
+		names := make([]string, 0, len(teams))
+		for _, t := range teams {
+			names = append(names, t.Name)
+		}
+		Expect(names).To(Equal([]string{"frontend", "oncall", "backend", "baseline", "payments"}))
+
+		cycle, err := IncludeCycle(ctx, c, team("backend", "oncall", "baseline"))
+		Expect(err).NotTo(HaveOccurred())
+		Expect(cycle).To(Equal([]string{"backend", "oncall", "baseline", "backend"}))
+
+		cycle, err = IncludeCycle(ctx, c, team("frontend", "oncall", "missing"))
+		Expect(err).NotTo(HaveOccurred())
+		Expect(cycle).To(BeNil())
+	})
+
+	It("ends memberships once recorded as expired", func() {
+		now := time.Now()
+		past, future := metav1.NewTime(now.Add(-time.Hour)), metav1.NewTime(now.Add(time.Hour))
+		rotation := authv1alpha1.Team{
+			ObjectMeta: metav1.ObjectMeta{Name: "rotation"},
+			Spec: authv1alpha1.TeamSpec{Members: []authv1alpha1.TeamMember{
+				{Name: "alice", ExpiresAt: &past},
+				{Name: "bob", ExpiresAt: &future},
+				{Name: "carol"},
+			}},
+		}
+		expired := Expirations(&rotation, now)
+		Expect(expired).To(Equal([]authv1alpha1.ExpiredMember{{Name: "alice", ExpiresAt: past}}))
+		Expect(IsMember(&rotation, "alice")).To(BeTrue(), "not recorded yet")
+
+		rotation.Status.ExpiredMembers = expired
+		Expect(MemberNames(&rotation)).To(Equal([]string{"bob", "carol"}))
+		Expect(IsMember(&rotation, "alice")).To(BeFalse())
+		Expect(Lists(&rotation, "alice")).To(BeFalse())
+
+		// Extending the membership applies before the next reconcile
+		rotation.Spec.Members[0].ExpiresAt = &future
+		Expect(IsMember(&rotation, "alice")).To(BeTrue())
+	})
+
+	It("finds the teams of a user", func() {
+		scheme := runtime.NewScheme()
+		Expect(authv1alpha1.AddToScheme(scheme)).To(Succeed())
+		c := fake.NewClientBuilder().WithScheme(scheme).WithObjects(oncall.DeepCopy(), payments.DeepCopy()).Build()
+		ctx := context.Background()
+
+		teams, err := ForUser(ctx, c, "alice")
+		Expect(err).NotTo(HaveOccurred())
+		Expect(teams).To(HaveLen(2))
+		Expect(teams[0].Name).To(Equal("oncall"))
+		Expect(teams[1].Name).To(Equal("payments"))
+
+		teams, err = ForUser(ctx, c, "bob")
+		Expect(err).NotTo(HaveOccurred())
+		Expect(teams).To(HaveLen(1))
+
+		teams, err = ForUser(ctx, c, "carol")
+		Expect(err).NotTo(HaveOccurred())
+		Expect(teams).To(BeEmpty())
+	})
+})
@@ -0,0 +1,97 @@
+/*
+Copyright 2025.
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+*/
+
+package usage
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/types"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	logf "sigs.k8s.io/controller-runtime/pkg/log"
+
+	authv1alpha1 "github.com/openkube-hub/KubeUser/api/v1alpha1"
+)
+
+// DefaultActivityInterval is how often observed activity is written to User status
+const DefaultActivityInterval = 5 * time.Minute
+
+// WriteActivity moves status.lastActivity of every User seen in the store forward to the last
+// request observed. Users whose status is already as recent are not written. It returns how
+// many Users were updated.
+func WriteActivity(ctx context.Context, c client.Client, store *Store) (int, error) {
+	updated := 0
+	var errs []error
+	for name, at := range store.Activity() {
+		var user authv1alpha1.User
+		if err := c.Get(ctx, types.NamespacedName{Name: name}, &user); err != nil {
+			if apierrors.IsNotFound(err) {
+				store.Forget(name)
+				continue
+			}
+			errs = append(errs, err)
+			continue
+		}
+		// Status only moves forward, also when several replicas receive audit events
+		if last := user.Status.LastActivity; last != nil && !at.Truncate(time.Second).After(last.Time) {
+			continue
+		}
+		patch := client.MergeFromWithOptions(user.DeepCopy(), client.MergeFromWithOptimisticLock{})
+		user.Status.LastActivity = &metav1.Time{Time: at}
+		if err := c.Status().Patch(ctx, &user, patch); err != nil {
+			// A conflicting write is retried with the next interval
+			if !apierrors.IsConflict(err) && !apierrors.IsNotFound(err) {
+				errs = append(errs, err)
+			}
+			continue
+		}
+		updated++
+	}
+	return updated, errors.Join(errs...)
+}
+
+// ActivityWriter writes observed activity to User status every Interval. It runs on every
+// replica, since each one only sees the audit events sent to it.
+type ActivityWriter struct {
+	Client client.Client
+	Store  *Store
+	// Interval defaults to DefaultActivityInterval
+	Interval time.Duration
+}
+
+// NeedLeaderElection implements manager.LeaderElectionRunnable
+func (w *ActivityWriter) NeedLeaderElection() bool {
+	return false
+}
+
+// Start implements manager.Runnable; it writes activity until ctx is done
+func (w *ActivityWriter) Start(ctx context.Context) error {
+	logger := logf.FromContext(ctx).WithName("activity")
+	interval := w.Interval
+	if interval <= 0 {
+		interval = DefaultActivityInterval
+	}
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			return nil
+		case <-ticker.C:
+		}
+		updated, err := WriteActivity(ctx, w.Client, w.Store)
+		if err != nil {
+			logger.Error(err, "Failed to write last activity")
+		}
+		if updated > 0 {
+			logger.V(1).Info("Wrote last activity", "users", updated)
+		}
+	}
+}
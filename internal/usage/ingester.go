@@ -0,0 +1,188 @@
+/*
+Copyright 2025.
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+*/
+
+package usage
+
+import (
+	"context"
+	"crypto/subtle"
+	"crypto/x509"
+	"encoding/json"
+	"net/http"
+	"strings"
+	"time"
+
+	"k8s.io/apimachinery/pkg/types"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	logf "sigs.k8s.io/controller-runtime/pkg/log"
+
+	authv1alpha1 "github.com/openkube-hub/KubeUser/api/v1alpha1"
+)
+
+// auditStageResponseComplete is the only audit stage that is recorded; earlier stages
+// would count the same request several times.
+const auditStageResponseComplete = "ResponseComplete"
+
+// maxAuditBatchBytes bounds the size of a single audit webhook request body
+const maxAuditBatchBytes = 16 << 20
+
+// auditEventList is the subset of audit.k8s.io/v1 EventList the ingester needs
+type auditEventList struct {
+	Items []auditEvent `json:"items"`
+}
+
+type auditEvent struct {
+	Stage string `json:"stage"`
+	Verb  string `json:"verb"`
+	User  struct {
+		Username string `json:"username"`
+	} `json:"user"`
+	// ImpersonatedUser is set for requests made through the impersonation proxy
+	ImpersonatedUser *struct {
+		Username string `json:"username"`
+	} `json:"impersonatedUser"`
+	ObjectRef *struct {
+		Resource    string `json:"resource"`
+		Namespace   string `json:"namespace"`
+		APIGroup    string `json:"apiGroup"`
+		Subresource string `json:"subresource"`
+	} `json:"objectRef"`
+	ResponseStatus *struct {
+		Code int32 `json:"code"`
+	} `json:"responseStatus"`
+	StageTimestamp time.Time `json:"stageTimestamp"`
+}
+
+// Ingester is an audit webhook backend (see the kube-apiserver --audit-webhook-config-file flag)
+// that records the activity and resource requests of managed users in a Store. Only the API
+// server may send events: requests must present a client certificate signed by ClientCAs or
+// the bearer token Token, and are rejected when neither is configured.
+type Ingester struct {
+	Store *Store
+	// ClientCAs verifies the client certificate of the audit webhook. The server must request
+	// client certificates without verifying them, as they are checked here.
+	ClientCAs *x509.CertPool
+	// Token is the bearer token of the audit webhook
+	Token string
+	// Resolve returns the User a request's username belongs to, or "" for requests of
+	// anyone else; nil records every username as is
+	Resolve func(username string) string
+}
+
+// UserResolver returns an Ingester.Resolve that maps usernames to existing Users. Users
+// authenticate with their certificate as the name userName maps back to the User's, and with
+// tokens of their ServiceAccount anchor in namespace() as system:serviceaccount:<namespace>:<name>.
+func UserResolver(reader client.Reader, namespace func() string,
+	userName func(username string) (string, bool)) func(username string) string {
+	return func(username string) string {
+		name := username
+		if rest, ok := strings.CutPrefix(username, "system:serviceaccount:"); ok {
+			ns, sa, _ := strings.Cut(rest, ":")
+			if ns != namespace() {
+				return ""
+			}
+			name = sa
+		} else if name, ok = userName(username); !ok {
+			return ""
+		}
+		var user authv1alpha1.User
+		if name == "" || reader.Get(context.Background(), types.NamespacedName{Name: name}, &user) != nil {
+			return ""
+		}
+		return name
+	}
+}
+
+// ServeHTTP implements http.Handler
+func (i *Ingester) ServeHTTP(w http.ResponseWriter, req *http.Request) {
+	if req.Method != http.MethodPost {
+		http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+	if !i.authenticated(req) {
+		logf.FromContext(req.Context()).V(1).Info("Rejected unauthenticated audit events", "remote", req.RemoteAddr)
+		http.Error(w, "unauthorized", http.StatusUnauthorized)
+		return
+	}
+	var events auditEventList
+	if err := json.NewDecoder(http.MaxBytesReader(w, req.Body, maxAuditBatchBytes)).Decode(&events); err != nil {
+		http.Error(w, "invalid audit event list: "+err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	recorded := 0
+	for _, ev := range events.Items {
+		if i.record(ev) {
+			recorded++
+		}
+	}
+	logf.FromContext(req.Context()).V(1).Info("Ingested audit events", "received", len(events.Items), "recorded", recorded)
+	w.WriteHeader(http.StatusOK)
+}
+
+// authenticated reports whether req comes from the API server's audit webhook
+func (i *Ingester) authenticated(req *http.Request) bool {
+	if i.Token != "" {
+		if token, ok := strings.CutPrefix(req.Header.Get("Authorization"), "Bearer "); ok &&
+			subtle.ConstantTimeCompare([]byte(token), []byte(i.Token)) == 1 {
+			return true
+		}
+	}
+	if i.ClientCAs == nil || req.TLS == nil || len(req.TLS.PeerCertificates) == 0 {
+		return false
+	}
+	intermediates := x509.NewCertPool()
+	for _, cert := range req.TLS.PeerCertificates[1:] {
+		intermediates.AddCert(cert)
+	}
+	_, err := req.TLS.PeerCertificates[0].Verify(x509.VerifyOptions{
+		Roots:         i.ClientCAs,
+		Intermediates: intermediates,
+		KeyUsages:     []x509.ExtKeyUsage{x509.ExtKeyUsageClientAuth},
+	})
+	return err == nil
+}
+
+func (i *Ingester) record(ev auditEvent) bool {
+	if ev.Stage != auditStageResponseComplete {
+		return false
+	}
+	username := ev.User.Username
+	if ev.ImpersonatedUser != nil && ev.ImpersonatedUser.Username != "" {
+		username = ev.ImpersonatedUser.Username
+	}
+	if username != "" && i.Resolve != nil {
+		username = i.Resolve(username)
+	}
+	if username == "" {
+		return false
+	}
+	at := ev.StageTimestamp
+	if at.IsZero() {
+		at = time.Now()
+	}
+	// Any request, even a denied one, shows the credentials are in use
+	i.Store.Seen(username, at)
+
+	// Denied requests say nothing about which granted permissions are used
+	if ev.ObjectRef == nil {
+		return true
+	}
+	if ev.ResponseStatus != nil && (ev.ResponseStatus.Code == http.StatusForbidden || ev.ResponseStatus.Code == http.StatusUnauthorized) {
+		return true
+	}
+	resource := ev.ObjectRef.Resource
+	if ev.ObjectRef.Subresource != "" {
+		resource += "/" + ev.ObjectRef.Subresource
+	}
+	i.Store.Record(username, Access{
+		Namespace: ev.ObjectRef.Namespace,
+		APIGroup:  ev.ObjectRef.APIGroup,
+		Resource:  resource,
+		Verb:      ev.Verb,
+	}, at)
+	return true
+}
@@ -0,0 +1,163 @@
+/*
+Copyright 2025.
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+*/
+
+package usage
+
+import (
+	"fmt"
+	"slices"
+	"sort"
+	"strings"
+	"time"
+
+	rbacv1 "k8s.io/api/rbac/v1"
+
+	authv1alpha1 "github.com/openkube-hub/KubeUser/api/v1alpha1"
+)
+
+// wildcardWeight is how many concrete entries a "*" counts as when comparing role sizes
+const wildcardWeight = 100
+
+// Grant is a role bound to a user. Namespace is the binding namespace; it is empty for
+// ClusterRoleBindings, whose rules apply to every namespace.
+type Grant struct {
+	Kind      string
+	Namespace string
+	Name      string
+	Rules     []rbacv1.PolicyRule
+}
+
+// Candidate is a ClusterRole that may be recommended as a narrower replacement
+type Candidate struct {
+	Name  string
+	Rules []rbacv1.PolicyRule
+}
+
+// Recommend compares the user's grants with the accesses observed during window and suggests
+// removing grants that were never used and replacing grants with the smallest candidate that
+// still covers everything the user did with them.
+func Recommend(grants []Grant, observed []Access, candidates []Candidate, window time.Duration) []authv1alpha1.RoleRecommendation {
+	var out []authv1alpha1.RoleRecommendation
+	for _, grant := range grants {
+		used := UsedBy(grant, observed)
+		rec := authv1alpha1.RoleRecommendation{Kind: grant.Kind, Namespace: grant.Namespace, Name: grant.Name}
+		if len(used) == 0 {
+			rec.Action = authv1alpha1.RecommendationRemove
+			rec.Reason = fmt.Sprintf("no requests covered by this role were observed in the last %s", window)
+			out = append(out, rec)
+			continue
+		}
+		if replacement, ok := narrowest(grant, used, candidates); ok {
+			rec.Action = authv1alpha1.RecommendationReplace
+			rec.Replacement = replacement
+			rec.Reason = fmt.Sprintf("ClusterRole %s covers all %d observed access patterns with fewer permissions", replacement, len(used))
+			out = append(out, rec)
+		}
+	}
+	return out
+}
+
+// UsedBy returns the observed accesses that fall in the grant's scope and are allowed by its rules
+func UsedBy(grant Grant, observed []Access) []Access {
+	var used []Access
+	for _, a := range observed {
+		if grant.Namespace != "" && grant.Namespace != a.Namespace {
+			continue
+		}
+		if rulesAllow(grant.Rules, a, false) {
+			used = append(used, a)
+		}
+	}
+	return used
+}
+
+// narrowest picks the smallest candidate that covers every used access and is smaller than the grant
+func narrowest(grant Grant, used []Access, candidates []Candidate) (string, bool) {
+	best, bestSize := "", ruleSize(grant.Rules)
+	sorted := slices.Clone(candidates)
+	sort.Slice(sorted, func(i, j int) bool { return sorted[i].Name < sorted[j].Name })
+	for _, c := range sorted {
+		if c.Name == grant.Name {
+			continue
+		}
+		size := ruleSize(c.Rules)
+		if size >= bestSize {
+			continue
+		}
+		covers := true
+		for _, a := range used {
+			if !rulesAllow(c.Rules, a, true) {
+				covers = false
+				break
+			}
+		}
+		if covers {
+			best, bestSize = c.Name, size
+		}
+	}
+	return best, best != ""
+}
+
+// rulesAllow reports whether any rule allows the access. Rules restricted to resource names
+// cannot be matched against audit data reliably: they count as allowing when checking what a
+// grant was used for, and as not allowing when checking whether a replacement suffices (strict).
+func rulesAllow(rules []rbacv1.PolicyRule, a Access, strict bool) bool {
+	for _, rule := range rules {
+		if len(rule.ResourceNames) > 0 && strict {
+			continue
+		}
+		if matches(rule.Verbs, a.Verb) && matches(rule.APIGroups, a.APIGroup) && resourceMatches(rule.Resources, a.Resource) {
+			return true
+		}
+	}
+	return false
+}
+
+func matches(values []string, want string) bool {
+	return slices.Contains(values, rbacv1.VerbAll) || slices.Contains(values, want)
+}
+
+// resourceMatches follows RBAC semantics: "*" matches everything, "*/sub" any resource's
+// subresource and "res/*" every subresource of res
+func resourceMatches(resources []string, want string) bool {
+	if matches(resources, want) {
+		return true
+	}
+	resource, sub, found := strings.Cut(want, "/")
+	if !found {
+		return false
+	}
+	return slices.Contains(resources, "*/"+sub) || slices.Contains(resources, resource+"/*")
+}
+
+// ruleSize approximates how much a set of rules grants
+func ruleSize(rules []rbacv1.PolicyRule) int {
+	total := 0
+	for _, rule := range rules {
+		if len(rule.NonResourceURLs) > 0 {
+			total += weight(rule.NonResourceURLs) * weight(rule.Verbs)
+			continue
+		}
+		size := weight(rule.Verbs) * weight(rule.APIGroups) * weight(rule.Resources)
+		if len(rule.ResourceNames) > 0 {
+			size = (size + 1) / 2
+		}
+		total += size
+	}
+	return total
+}
+
+func weight(values []string) int {
+	n := 0
+	for _, v := range values {
+		if strings.Contains(v, "*") {
+			n += wildcardWeight
+		} else {
+			n++
+		}
+	}
+	return n
+}
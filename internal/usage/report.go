@@ -0,0 +1,59 @@
+/*
+Copyright 2025.
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+*/
+
+package usage
+
+import (
+	"encoding/json"
+	"net/http"
+
+	"sigs.k8s.io/controller-runtime/pkg/client"
+
+	authv1alpha1 "github.com/openkube-hub/KubeUser/api/v1alpha1"
+)
+
+// UserReport is the report entry for a single user
+type UserReport struct {
+	User              string                            `json:"user"`
+	Recommendations   []authv1alpha1.RoleRecommendation `json:"recommendations,omitempty"`
+	UnusedPermissions []authv1alpha1.UnusedPermission   `json:"unusedPermissions,omitempty"`
+}
+
+// Summary collects the usage findings of all users that have any
+type Summary struct {
+	Users []UserReport `json:"users"`
+}
+
+// ReportHandler serves the current usage findings of all users as JSON
+type ReportHandler struct {
+	Reader client.Reader
+}
+
+// ServeHTTP implements http.Handler
+func (h *ReportHandler) ServeHTTP(w http.ResponseWriter, req *http.Request) {
+	if req.Method != http.MethodGet {
+		http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+	var users authv1alpha1.UserList
+	if err := h.Reader.List(req.Context(), &users); err != nil {
+		http.Error(w, "failed to list users: "+err.Error(), http.StatusInternalServerError)
+		return
+	}
+	report := Summary{Users: []UserReport{}}
+	for _, user := range users.Items {
+		if len(user.Status.Recommendations) == 0 && len(user.Status.UnusedPermissions) == 0 {
+			continue
+		}
+		report.Users = append(report.Users, UserReport{
+			User:              user.Name,
+			Recommendations:   user.Status.Recommendations,
+			UnusedPermissions: user.Status.UnusedPermissions,
+		})
+	}
+	w.Header().Set("Content-Type", "application/json")
+	_ = json.NewEncoder(w).Encode(report)
+}
@@ -0,0 +1,119 @@
+/*
+Copyright 2025.
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+*/
+
+// Package usage records which API requests KubeUser-managed users actually make and
+// derives least-privilege recommendations from that data.
+package usage
+
+import (
+	"sort"
+	"sync"
+	"time"
+)
+
+// Access is the authorization-relevant part of a single API request
+type Access struct {
+	// Namespace is empty for cluster-scoped requests
+	Namespace string
+	APIGroup  string
+	// Resource includes the subresource, e.g. "pods/log"
+	Resource string
+	Verb     string
+}
+
+// Store keeps the last time each user performed each distinct access, and the last time it
+// made any request. Data is held in memory and starts over when the controller restarts.
+type Store struct {
+	mu       sync.RWMutex
+	started  time.Time
+	users    map[string]map[Access]time.Time
+	lastSeen map[string]time.Time
+}
+
+// NewStore returns an empty store that starts tracking now
+func NewStore() *Store {
+	return &Store{started: time.Now(), users: map[string]map[Access]time.Time{}, lastSeen: map[string]time.Time{}}
+}
+
+// Record notes that user performed access at the given time
+func (s *Store) Record(user string, access Access, at time.Time) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	accesses, ok := s.users[user]
+	if !ok {
+		accesses = map[Access]time.Time{}
+		s.users[user] = accesses
+	}
+	if last, ok := accesses[access]; !ok || at.After(last) {
+		accesses[access] = at
+	}
+}
+
+// Seen notes that user made a request at the given time
+func (s *Store) Seen(user string, at time.Time) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if last, ok := s.lastSeen[user]; !ok || at.After(last) {
+		s.lastSeen[user] = at
+	}
+}
+
+// LastActivity returns when user last made a request; zero if it was not seen
+func (s *Store) LastActivity(user string) time.Time {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	return s.lastSeen[user]
+}
+
+// Activity returns a copy of the last request time of every user seen
+func (s *Store) Activity() map[string]time.Time {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	out := make(map[string]time.Time, len(s.lastSeen))
+	for user, at := range s.lastSeen {
+		out[user] = at
+	}
+	return out
+}
+
+// TrackingSince returns when the store started collecting data
+func (s *Store) TrackingSince() time.Time {
+	return s.started
+}
+
+// Accesses returns every distinct access the user performed at or after since, in a stable order
+func (s *Store) Accesses(user string, since time.Time) []Access {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	var out []Access
+	for access, last := range s.users[user] {
+		if !last.Before(since) {
+			out = append(out, access)
+		}
+	}
+	sort.Slice(out, func(i, j int) bool {
+		a, b := out[i], out[j]
+		if a.Namespace != b.Namespace {
+			return a.Namespace < b.Namespace
+		}
+		if a.APIGroup != b.APIGroup {
+			return a.APIGroup < b.APIGroup
+		}
+		if a.Resource != b.Resource {
+			return a.Resource < b.Resource
+		}
+		return a.Verb < b.Verb
+	})
+	return out
+}
+
+// Forget drops all data recorded for the user
+func (s *Store) Forget(user string) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	delete(s.users, user)
+	delete(s.lastSeen, user)
+}
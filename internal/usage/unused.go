@@ -0,0 +1,65 @@
+/*
+Copyright 2025.
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+*/
+
+package usage
+
+import (
+	"sort"
+)
+
+// UnusedPermissions lists the permissions of a grant that no observed access exercised. Each
+// rule is expanded into "verb resource[.group]" entries; wildcards stay unexpanded, so a "*"
+// entry is only reported when nothing it covers was used at all.
+func UnusedPermissions(grant Grant, observed []Access) []string {
+	scoped := make([]Access, 0, len(observed))
+	for _, a := range observed {
+		if grant.Namespace == "" || grant.Namespace == a.Namespace {
+			scoped = append(scoped, a)
+		}
+	}
+
+	unused := map[string]struct{}{}
+	for _, rule := range grant.Rules {
+		if len(rule.NonResourceURLs) > 0 {
+			continue
+		}
+		for _, group := range rule.APIGroups {
+			for _, resource := range rule.Resources {
+				for _, verb := range rule.Verbs {
+					if !exercised(scoped, group, resource, verb) {
+						unused[permissionString(group, resource, verb)] = struct{}{}
+					}
+				}
+			}
+		}
+	}
+
+	out := make([]string, 0, len(unused))
+	for p := range unused {
+		out = append(out, p)
+	}
+	sort.Strings(out)
+	return out
+}
+
+// exercised reports whether any access is covered by the single group/resource/verb entry
+func exercised(observed []Access, group, resource, verb string) bool {
+	for _, a := range observed {
+		if matches([]string{verb}, a.Verb) && matches([]string{group}, a.APIGroup) &&
+			resourceMatches([]string{resource}, a.Resource) {
+			return true
+		}
+	}
+	return false
+}
+
+// permissionString renders an entry the way kubectl auth can-i takes it, e.g. "delete deployments.apps"
+func permissionString(group, resource, verb string) string {
+	if group != "" {
+		resource += "." + group
+	}
+	return verb + " " + resource
+}
@@ -0,0 +1,307 @@
+/*
+Copyright 2025.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package usage
+
+import (
+	"context"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/tls"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"math/big"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"time"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+	rbacv1 "k8s.io/api/rbac/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+
+	authv1alpha1 "github.com/openkube-hub/KubeUser/api/v1alpha1"
+)
+
+// auditToken is the bearer token of the audit webhook in these tests
+const auditToken = "audit-token"
+
+// auditRequest returns an audit webhook request carrying body and auditToken
+func auditRequest(body string) *http.Request {
+	req := httptest.NewRequest(http.MethodPost, "/audit", strings.NewReader(body))
+	req.Header.Set("Authorization", "Bearer "+auditToken)
+	return req
+}
+
+// newCertificate returns a certificate for commonName signed by parent, or self-signed when
+// parent is nil, and its key
+func newCertificate(commonName string, parent *x509.Certificate, parentKey *ecdsa.PrivateKey) (*x509.Certificate, *ecdsa.PrivateKey) {
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	Expect(err).NotTo(HaveOccurred())
+	template := &x509.Certificate{
+		SerialNumber: big.NewInt(time.Now().UnixNano()),
+		Subject:      pkix.Name{CommonName: commonName},
+		NotBefore:    time.Now().Add(-time.Minute),
+		NotAfter:     time.Now().Add(time.Hour),
+		ExtKeyUsage:  []x509.ExtKeyUsage{x509.ExtKeyUsageClientAuth},
+	}
+	if parent == nil {
+		template.IsCA, template.BasicConstraintsValid = true, true
+		template.KeyUsage = x509.KeyUsageCertSign
+		parent, parentKey = template, key
+	}
+	der, err := x509.CreateCertificate(rand.Reader, template, parent, &key.PublicKey, parentKey)
+	Expect(err).NotTo(HaveOccurred())
+	cert, err := x509.ParseCertificate(der)
+	Expect(err).NotTo(HaveOccurred())
+	return cert, key
+}
+
+var _ = Describe("Ingester", func() {
+	It("records completed, allowed resource requests of managed users", func() {
+		store := NewStore()
+		ingester := &Ingester{Store: store, Token: auditToken, Resolve: func(u string) string {
+			if u == "alice" {
+				return u
+			}
+			return ""
+		}}
+		body := `{"kind":"EventList","apiVersion":"audit.k8s.io/v1","items":[
+			{"stage":"ResponseComplete","verb":"get","user":{"username":"alice"},
+			 "objectRef":{"resource":"pods","namespace":"dev","subresource":"log"},
+			 "responseStatus":{"code":200},"stageTimestamp":"2025-01-01T00:00:00.000000Z"},
+			{"stage":"RequestReceived","verb":"list","user":{"username":"alice"},"objectRef":{"resource":"pods","namespace":"dev"}},
+			{"stage":"ResponseComplete","verb":"delete","user":{"username":"alice"},
+			 "objectRef":{"resource":"pods","namespace":"dev"},"responseStatus":{"code":403}},
+			{"stage":"ResponseComplete","verb":"get","user":{"username":"bob"},"objectRef":{"resource":"pods","namespace":"dev"}},
+			{"stage":"ResponseComplete","verb":"get","user":{"username":"alice"},"requestURI":"/healthz"}
+		]}`
+		rec := httptest.NewRecorder()
+		ingester.ServeHTTP(rec, auditRequest(body))
+
+		Expect(rec.Code).To(Equal(http.StatusOK))
+		Expect(store.Accesses("alice", time.Time{})).To(Equal([]Access{
+			{Namespace: "dev", Resource: "pods/log", Verb: "get"},
+		}))
+		Expect(store.Accesses("bob", time.Time{})).To(BeEmpty())
+		Expect(store.LastActivity("bob").IsZero()).To(BeTrue())
+		// The denied and the non-resource request, stamped now, count as activity too
+		Expect(store.LastActivity("alice")).To(BeTemporally(">", time.Date(2025, 1, 1, 0, 0, 0, 0, time.UTC)))
+	})
+
+	It("attributes impersonated and ServiceAccount requests to their User", func() {
+		scheme := runtime.NewScheme()
+		Expect(authv1alpha1.AddToScheme(scheme)).To(Succeed())
+		reader := fake.NewClientBuilder().WithScheme(scheme).WithObjects(
+			&authv1alpha1.User{ObjectMeta: metav1.ObjectMeta{Name: "alice"}},
+			&authv1alpha1.User{ObjectMeta: metav1.ObjectMeta{Name: "ci"}},
+		).Build()
+		store := NewStore()
+		ingester := &Ingester{Store: store, Token: auditToken, Resolve: UserResolver(reader, func() string { return "kubeuser" },
+			func(username string) (string, bool) { return username, true })}
+		body := `{"items":[
+			{"stage":"ResponseComplete","verb":"get","user":{"username":"system:serviceaccount:kubeuser:kubeuser-proxy"},
+			 "impersonatedUser":{"username":"alice"},"stageTimestamp":"2025-01-02T00:00:00Z"},
+			{"stage":"ResponseComplete","verb":"list","user":{"username":"system:serviceaccount:kubeuser:ci"},
+			 "objectRef":{"resource":"deployments","namespace":"prod","apiGroup":"apps"},"stageTimestamp":"2025-01-03T00:00:00Z"},
+			{"stage":"ResponseComplete","verb":"get","user":{"username":"system:serviceaccount:other:ci"},
+			 "stageTimestamp":"2025-01-04T00:00:00Z"}
+		]}`
+		rec := httptest.NewRecorder()
+		ingester.ServeHTTP(rec, auditRequest(body))
+
+		Expect(rec.Code).To(Equal(http.StatusOK))
+		Expect(store.LastActivity("alice")).To(BeTemporally("==", time.Date(2025, 1, 2, 0, 0, 0, 0, time.UTC)))
+		Expect(store.LastActivity("ci")).To(BeTemporally("==", time.Date(2025, 1, 3, 0, 0, 0, 0, time.UTC)))
+		Expect(store.Accesses("ci", time.Time{})).To(Equal([]Access{
+			{Namespace: "prod", APIGroup: "apps", Resource: "deployments", Verb: "list"},
+		}))
+		Expect(store.Activity()).To(HaveLen(2))
+	})
+
+	It("rejects malformed bodies", func() {
+		rec := httptest.NewRecorder()
+		(&Ingester{Store: NewStore(), Token: auditToken}).ServeHTTP(rec, auditRequest("{"))
+		Expect(rec.Code).To(Equal(http.StatusBadRequest))
+	})
+
+	Context("authentication", func() {
+		const body = `{"items":[{"stage":"ResponseComplete","verb":"get","user":{"username":"alice"},
+			"objectRef":{"resource":"pods","namespace":"dev"},"stageTimestamp":"2025-01-01T00:00:00Z"}]}`
+
+		var (
+			store     *Store
+			ca        *x509.Certificate
+			caKey     *ecdsa.PrivateKey
+			clientCAs *x509.CertPool
+		)
+
+		BeforeEach(func() {
+			store = NewStore()
+			ca, caKey = newCertificate("audit-ca", nil, nil)
+			clientCAs = x509.NewCertPool()
+			clientCAs.AddCert(ca)
+		})
+
+		serve := func(ingester *Ingester, req *http.Request) int {
+			ingester.Store = store
+			rec := httptest.NewRecorder()
+			ingester.ServeHTTP(rec, req)
+			return rec.Code
+		}
+		withCertificate := func(cert *x509.Certificate) *http.Request {
+			req := httptest.NewRequest(http.MethodPost, "/audit", strings.NewReader(body))
+			req.TLS = &tls.ConnectionState{PeerCertificates: []*x509.Certificate{cert}}
+			return req
+		}
+
+		It("accepts the configured bearer token only", func() {
+			Expect(serve(&Ingester{Token: auditToken}, auditRequest(body))).To(Equal(http.StatusOK))
+
+			forged := auditRequest(body)
+			forged.Header.Set("Authorization", "Bearer forged")
+			Expect(serve(&Ingester{Token: auditToken}, forged)).To(Equal(http.StatusUnauthorized))
+			Expect(serve(&Ingester{Token: auditToken},
+				httptest.NewRequest(http.MethodPost, "/audit", strings.NewReader(body)))).To(Equal(http.StatusUnauthorized))
+			Expect(store.Activity()).To(HaveLen(1))
+		})
+
+		It("accepts client certificates signed by the client CA only", func() {
+			apiserver, _ := newCertificate("kube-apiserver", ca, caKey)
+			Expect(serve(&Ingester{ClientCAs: clientCAs}, withCertificate(apiserver))).To(Equal(http.StatusOK))
+
+			other, otherKey := newCertificate("other-ca", nil, nil)
+			forged, _ := newCertificate("kube-apiserver", other, otherKey)
+			Expect(serve(&Ingester{ClientCAs: clientCAs}, withCertificate(forged))).To(Equal(http.StatusUnauthorized))
+			Expect(serve(&Ingester{ClientCAs: clientCAs}, auditRequest(body))).To(Equal(http.StatusUnauthorized))
+			Expect(store.Activity()).To(HaveLen(1))
+		})
+
+		It("rejects every request when no credential is configured", func() {
+			apiserver, _ := newCertificate("kube-apiserver", ca, caKey)
+			Expect(serve(&Ingester{}, auditRequest(body))).To(Equal(http.StatusUnauthorized))
+			Expect(serve(&Ingester{}, withCertificate(apiserver))).To(Equal(http.StatusUnauthorized))
+			Expect(store.Activity()).To(BeEmpty())
+		})
+	})
+})
+
+var _ = Describe("Recommend", func() {
+	podReader := []rbacv1.PolicyRule{{APIGroups: []string{""}, Resources: []string{"pods", "pods/log"}, Verbs: []string{"get", "list"}}}
+	admin := []rbacv1.PolicyRule{{APIGroups: []string{"*"}, Resources: []string{"*"}, Verbs: []string{"*"}}}
+	candidates := []Candidate{{Name: "pod-reader", Rules: podReader}, {Name: "admin", Rules: admin}}
+
+	It("suggests removing roles that were not used", func() {
+		grants := []Grant{{Kind: "Role", Namespace: "dev", Name: "editor", Rules: admin}}
+		observed := []Access{{Namespace: "prod", Resource: "pods", Verb: "get"}}
+
+		recs := Recommend(grants, observed, candidates, time.Hour)
+		Expect(recs).To(HaveLen(1))
+		Expect(recs[0].Action).To(Equal(authv1alpha1.RecommendationRemove))
+		Expect(recs[0].Namespace).To(Equal("dev"))
+	})
+
+	It("suggests the smallest candidate covering all observed usage", func() {
+		grants := []Grant{{Kind: "ClusterRole", Name: "cluster-admin", Rules: admin}}
+		observed := []Access{
+			{Namespace: "dev", Resource: "pods", Verb: "list"},
+			{Namespace: "prod", Resource: "pods/log", Verb: "get"},
+		}
+
+		recs := Recommend(grants, observed, candidates, time.Hour)
+		Expect(recs).To(HaveLen(1))
+		Expect(recs[0].Action).To(Equal(authv1alpha1.RecommendationReplace))
+		Expect(recs[0].Replacement).To(Equal("pod-reader"))
+	})
+
+	It("keeps roles when no narrower candidate suffices", func() {
+		grants := []Grant{{Kind: "ClusterRole", Name: "cluster-admin", Rules: admin}}
+		observed := []Access{{Namespace: "dev", Resource: "pods", Verb: "delete"}}
+
+		Expect(Recommend(grants, observed, candidates, time.Hour)).To(BeEmpty())
+	})
+
+	It("matches subresource wildcards", func() {
+		rules := []rbacv1.PolicyRule{{APIGroups: []string{""}, Resources: []string{"*/status"}, Verbs: []string{"get"}}}
+		Expect(rulesAllow(rules, Access{Resource: "pods/status", Verb: "get"}, true)).To(BeTrue())
+		Expect(rulesAllow(rules, Access{Resource: "pods", Verb: "get"}, true)).To(BeFalse())
+	})
+})
+
+var _ = Describe("UnusedPermissions", func() {
+	grant := Grant{Kind: "Role", Namespace: "dev", Name: "deployer", Rules: []rbacv1.PolicyRule{
+		{APIGroups: []string{"apps"}, Resources: []string{"deployments"}, Verbs: []string{"get", "update", "delete"}},
+		{APIGroups: []string{""}, Resources: []string{"*"}, Verbs: []string{"list"}},
+		{APIGroups: []string{""}, Resources: []string{"secrets"}, Verbs: []string{"get"}},
+	}}
+
+	It("lists entries never exercised within the grant's scope", func() {
+		observed := []Access{
+			{Namespace: "dev", APIGroup: "apps", Resource: "deployments", Verb: "get"},
+			{Namespace: "dev", Resource: "configmaps", Verb: "list"},
+			{Namespace: "prod", APIGroup: "apps", Resource: "deployments", Verb: "delete"},
+		}
+		Expect(UnusedPermissions(grant, observed)).To(Equal([]string{
+			"delete deployments.apps",
+			"get secrets",
+			"update deployments.apps",
+		}))
+	})
+
+	It("reports everything when nothing was used", func() {
+		Expect(UnusedPermissions(grant, nil)).To(HaveLen(5))
+	})
+})
+
+var _ = Describe("WriteActivity", func() {
+	It("moves status.lastActivity forward and forgets deleted users", func() {
+		ctx := context.Background()
+		scheme := runtime.NewScheme()
+		Expect(authv1alpha1.AddToScheme(scheme)).To(Succeed())
+		earlier := metav1.NewTime(time.Date(2025, 1, 5, 0, 0, 0, 0, time.UTC))
+		alice := &authv1alpha1.User{ObjectMeta: metav1.ObjectMeta{Name: "alice"}}
+		bob := &authv1alpha1.User{
+			ObjectMeta: metav1.ObjectMeta{Name: "bob"},
+			Status:     authv1alpha1.UserStatus{LastActivity: &earlier},
+		}
+		c := fake.NewClientBuilder().WithScheme(scheme).WithObjects(alice, bob).WithStatusSubresource(alice, bob).Build()
+
+		store := NewStore()
+		store.Seen("alice", time.Date(2025, 1, 3, 0, 0, 0, 0, time.UTC))
+		store.Seen("bob", time.Date(2025, 1, 4, 0, 0, 0, 0, time.UTC))
+		store.Seen("carol", time.Date(2025, 1, 4, 0, 0, 0, 0, time.UTC))
+
+		updated, err := WriteActivity(ctx, c, store)
+		Expect(err).NotTo(HaveOccurred())
+		Expect(updated).To(Equal(1))
+
+		var stored authv1alpha1.User
+		Expect(c.Get(ctx, client.ObjectKey{Name: "alice"}, &stored)).To(Succeed())
+		Expect(stored.Status.LastActivity.Time).To(BeTemporally("==", time.Date(2025, 1, 3, 0, 0, 0, 0, time.UTC)))
+		Expect(c.Get(ctx, client.ObjectKey{Name: "bob"}, &stored)).To(Succeed())
+		Expect(stored.Status.LastActivity.Time).To(BeTemporally("==", earlier.Time))
+		Expect(store.Activity()).NotTo(HaveKey("carol"))
+
+		updated, err = WriteActivity(ctx, c, store)
+		Expect(err).NotTo(HaveOccurred())
+		Expect(updated).To(BeZero())
+	})
+})
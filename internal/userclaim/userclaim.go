@@ -0,0 +1,92 @@
+/*
+Copyright 2025.
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+*/
+
+// Package userclaim resolves UserClaims into the Users created for them. The controller and
+// the admission webhook limit the claimed Roles to the same tenant namespaces.
+package userclaim
+
+import (
+	"context"
+	"fmt"
+	"slices"
+
+	corev1 "k8s.io/api/core/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/types"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+
+	authv1alpha1 "github.com/openkube-hub/KubeUser/api/v1alpha1"
+)
+
+const (
+	// TenantLabel groups namespaces into a tenant. Claims may grant Roles in their own
+	// namespace and in every namespace with the same tenant label value.
+	TenantLabel = "auth.openkube.io/tenant"
+
+	// NamespaceLabel and NameLabel name the claim a User was created for
+	NamespaceLabel = "auth.openkube.io/claim-namespace"
+	NameLabel      = "auth.openkube.io/claim-name"
+
+	// Finalizer keeps a claim until its User is deleted; a namespaced claim cannot own the
+	// cluster-scoped User for the garbage collector
+	Finalizer = "auth.openkube.io/userclaim"
+)
+
+// TenantNamespaces returns the namespaces claims in namespace may grant Roles in
+func TenantNamespaces(ctx context.Context, reader client.Reader, namespace string) ([]string, error) {
+	var ns corev1.Namespace
+	if err := reader.Get(ctx, types.NamespacedName{Name: namespace}, &ns); err != nil {
+		if apierrors.IsNotFound(err) {
+			return []string{namespace}, nil
+		}
+		return nil, fmt.Errorf("failed to read namespace %s: %w", namespace, err)
+	}
+	tenant, ok := ns.Labels[TenantLabel]
+	if !ok || tenant == "" {
+		return []string{namespace}, nil
+	}
+
+	var tenantNamespaces corev1.NamespaceList
+	if err := reader.List(ctx, &tenantNamespaces, client.MatchingLabels{TenantLabel: tenant}); err != nil {
+		return nil, fmt.Errorf("failed to list namespaces of tenant %s: %w", tenant, err)
+	}
+	namespaces := []string{namespace}
+	for _, item := range tenantNamespaces.Items {
+		if item.Name != namespace {
+			namespaces = append(namespaces, item.Name)
+		}
+	}
+	slices.Sort(namespaces[1:])
+	return namespaces, nil
+}
+
+// Roles returns the roles of the User created for claim. Roles without a namespace are in the
+// claim's; roles in a namespace outside tenant are an error.
+func Roles(claim *authv1alpha1.UserClaim, tenant []string) ([]authv1alpha1.RoleSpec, error) {
+	roles := make([]authv1alpha1.RoleSpec, 0, len(claim.Spec.Roles))
+	for _, role := range claim.Spec.Roles {
+		namespace := role.Namespace
+		if namespace == "" {
+			namespace = claim.Namespace
+		}
+		if !slices.Contains(tenant, namespace) {
+			return nil, fmt.Errorf("role '%s' in namespace '%s' is outside the tenant of namespace '%s'",
+				role.ExistingRole, namespace, claim.Namespace)
+		}
+		roles = append(roles, authv1alpha1.RoleSpec{Namespace: namespace, ExistingRole: role.ExistingRole})
+	}
+	return roles, nil
+}
+
+// UserLabels returns the labels naming claim on its User
+func UserLabels(claim *authv1alpha1.UserClaim) map[string]string {
+	return map[string]string{NamespaceLabel: claim.Namespace, NameLabel: claim.Name}
+}
+
+// Owns reports whether user was created for claim
+func Owns(claim *authv1alpha1.UserClaim, user *authv1alpha1.User) bool {
+	return user.Labels[NamespaceLabel] == claim.Namespace && user.Labels[NameLabel] == claim.Name
+}
@@ -0,0 +1,83 @@
+/*
+Copyright 2025.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package userclaim
+
+import (
+	"context"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+
+	authv1alpha1 "github.com/openkube-hub/KubeUser/api/v1alpha1"
+)
+
+var _ = Describe("UserClaim", func() {
+	namespace := func(name, tenant string) *corev1.Namespace {
+		ns := &corev1.Namespace{ObjectMeta: metav1.ObjectMeta{Name: name}}
+		if tenant != "" {
+			ns.Labels = map[string]string{TenantLabel: tenant}
+		}
+		return ns
+	}
+
+	It("finds the namespaces of the claim's tenant", func() {
+		scheme := runtime.NewScheme()
+		Expect(corev1.AddToScheme(scheme)).To(Succeed())
+		c := fake.NewClientBuilder().WithScheme(scheme).WithObjects(
+			namespace("tenant-a", "a"), namespace("tenant-a-staging", "a"), namespace("tenant-a-dev", "a"),
+			namespace("tenant-b", "b"), namespace("shared", ""),
+		).Build()
+		ctx := context.Background()
+
+		Expect(TenantNamespaces(ctx, c, "tenant-a")).To(Equal([]string{"tenant-a", "tenant-a-dev", "tenant-a-staging"}))
+		Expect(TenantNamespaces(ctx, c, "tenant-b")).To(Equal([]string{"tenant-b"}))
+		Expect(TenantNamespaces(ctx, c, "shared")).To(Equal([]string{"shared"}))
+	})
+
+	It("limits roles to the tenant", func() {
+		claim := &authv1alpha1.UserClaim{
+			ObjectMeta: metav1.ObjectMeta{Name: "carol", Namespace: "tenant-a"},
+			Spec: authv1alpha1.UserClaimSpec{Roles: []authv1alpha1.ClaimedRole{
+				{ExistingRole: "developer"},
+				{Namespace: "tenant-a-staging", ExistingRole: "view"},
+			}},
+		}
+		roles, err := Roles(claim, []string{"tenant-a", "tenant-a-staging"})
+		Expect(err).NotTo(HaveOccurred())
+		Expect(roles).To(Equal([]authv1alpha1.RoleSpec{
+			{Namespace: "tenant-a", ExistingRole: "developer"},
+			{Namespace: "tenant-a-staging", ExistingRole: "view"},
+		}))
+
+		_, err = Roles(claim, []string{"tenant-a"})
+		Expect(err).To(MatchError("role 'view' in namespace 'tenant-a-staging' is outside the tenant of namespace 'tenant-a'"))
+	})
+
+	It("recognises the claim's User", func() {
+		claim := &authv1alpha1.UserClaim{ObjectMeta: metav1.ObjectMeta{Name: "carol", Namespace: "tenant-a"}}
+		user := &authv1alpha1.User{ObjectMeta: metav1.ObjectMeta{Name: "carol", Labels: UserLabels(claim)}}
+		Expect(Owns(claim, user)).To(BeTrue())
+
+		other := &authv1alpha1.UserClaim{ObjectMeta: metav1.ObjectMeta{Name: "carol", Namespace: "tenant-b"}}
+		Expect(Owns(other, user)).To(BeFalse())
+		Expect(Owns(claim, &authv1alpha1.User{ObjectMeta: metav1.ObjectMeta{Name: "carol"}})).To(BeFalse())
+	})
+})
@@ -0,0 +1,104 @@
+/*
+Copyright 2025.
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+*/
+
+// Package usertemplate merges UserTemplates into the Users referencing them. The controller
+// binds the merged roles, and the admission webhook checks them like the User's own.
+package usertemplate
+
+import (
+	"context"
+	"fmt"
+
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/types"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+
+	authv1alpha1 "github.com/openkube-hub/KubeUser/api/v1alpha1"
+	"github.com/openkube-hub/KubeUser/internal/notify"
+)
+
+// Get returns the UserTemplate user references, or nil when it references none
+func Get(ctx context.Context, reader client.Reader, user *authv1alpha1.User) (*authv1alpha1.UserTemplate, error) {
+	if user.Spec.TemplateRef == nil {
+		return nil, nil
+	}
+	name := user.Spec.TemplateRef.Name
+	var template authv1alpha1.UserTemplate
+	if err := reader.Get(ctx, types.NamespacedName{Name: name}, &template); err != nil {
+		if apierrors.IsNotFound(err) {
+			return nil, fmt.Errorf("usertemplate %s not found", name)
+		}
+		return nil, fmt.Errorf("failed to read usertemplate %s: %w", name, err)
+	}
+	return &template, nil
+}
+
+// Apply merges template into user. The template's roles come first, followed by the user's
+// own; a user entry for the same Role in the same namespace, or for the same ClusterRole,
+// replaces the template's. Template labels and the Slack channel only fill in what the user
+// does not set.
+func Apply(user *authv1alpha1.User, template *authv1alpha1.UserTemplate) {
+	user.Spec.Roles = mergeRoles(template.Spec.Roles, user.Spec.Roles)
+	user.Spec.ClusterRoles = mergeClusterRoles(template.Spec.ClusterRoles, user.Spec.ClusterRoles)
+
+	for key, value := range MissingLabels(user, template) {
+		if user.Labels == nil {
+			user.Labels = map[string]string{}
+		}
+		user.Labels[key] = value
+	}
+	if template.Spec.SlackChannel != "" && user.Annotations[notify.SlackChannelAnnotation] == "" {
+		if user.Annotations == nil {
+			user.Annotations = map[string]string{}
+		}
+		user.Annotations[notify.SlackChannelAnnotation] = template.Spec.SlackChannel
+	}
+}
+
+// MissingLabels returns the template labels user does not set
+func MissingLabels(user *authv1alpha1.User, template *authv1alpha1.UserTemplate) map[string]string {
+	missing := map[string]string{}
+	for key, value := range template.Spec.Labels {
+		if _, ok := user.Labels[key]; !ok {
+			missing[key] = value
+		}
+	}
+	return missing
+}
+
+func mergeRoles(template, own []authv1alpha1.RoleSpec) []authv1alpha1.RoleSpec {
+	if len(template) == 0 {
+		return own
+	}
+	overridden := make(map[[2]string]bool, len(own))
+	for _, role := range own {
+		overridden[[2]string{role.Namespace, role.ExistingRole}] = true
+	}
+	merged := make([]authv1alpha1.RoleSpec, 0, len(template)+len(own))
+	for _, role := range template {
+		if !overridden[[2]string{role.Namespace, role.ExistingRole}] {
+			merged = append(merged, *role.DeepCopy())
+		}
+	}
+	return append(merged, own...)
+}
+
+func mergeClusterRoles(template, own []authv1alpha1.ClusterRoleSpec) []authv1alpha1.ClusterRoleSpec {
+	if len(template) == 0 {
+		return own
+	}
+	overridden := make(map[string]bool, len(own))
+	for _, clusterRole := range own {
+		overridden[clusterRole.ExistingClusterRole] = true
+	}
+	merged := make([]authv1alpha1.ClusterRoleSpec, 0, len(template)+len(own))
+	for _, clusterRole := range template {
+		if !overridden[clusterRole.ExistingClusterRole] {
+			merged = append(merged, *clusterRole.DeepCopy())
+		}
+	}
+	return append(merged, own...)
+}
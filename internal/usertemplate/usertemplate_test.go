@@ -0,0 +1,111 @@
+/*
+Copyright 2025.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package usertemplate
+
+import (
+	"context"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+
+	authv1alpha1 "github.com/openkube-hub/KubeUser/api/v1alpha1"
+	"github.com/openkube-hub/KubeUser/internal/notify"
+)
+
+var _ = Describe("UserTemplate", func() {
+	developer := &authv1alpha1.UserTemplate{
+		ObjectMeta: metav1.ObjectMeta{Name: "developer"},
+		Spec: authv1alpha1.UserTemplateSpec{
+			Roles: []authv1alpha1.RoleSpec{
+				{Namespace: "dev", ExistingRole: "developer"},
+				{Namespace: "prod", ExistingRole: "view"},
+			},
+			ClusterRoles: []authv1alpha1.ClusterRoleSpec{{ExistingClusterRole: "view"}},
+			Labels:       map[string]string{"team": "platform", "tier": "developer"},
+			SlackChannel: "#platform",
+		},
+	}
+
+	It("adds the template's roles before the user's own", func() {
+		user := &authv1alpha1.User{Spec: authv1alpha1.UserSpec{
+			Roles:        []authv1alpha1.RoleSpec{{Namespace: "team-a", ExistingRole: "admin"}},
+			ClusterRoles: []authv1alpha1.ClusterRoleSpec{{ExistingClusterRole: "node-reader"}},
+		}}
+		Apply(user, developer)
+		Expect(user.Spec.Roles).To(Equal([]authv1alpha1.RoleSpec{
+			{Namespace: "dev", ExistingRole: "developer"},
+			{Namespace: "prod", ExistingRole: "view"},
+			{Namespace: "team-a", ExistingRole: "admin"},
+		}))
+		Expect(user.Spec.ClusterRoles).To(Equal([]authv1alpha1.ClusterRoleSpec{
+			{ExistingClusterRole: "view"},
+			{ExistingClusterRole: "node-reader"},
+		}))
+	})
+
+	It("lets the user's entries override the template's", func() {
+		until := metav1.Now()
+		user := &authv1alpha1.User{
+			ObjectMeta: metav1.ObjectMeta{
+				Labels:      map[string]string{"team": "payments"},
+				Annotations: map[string]string{notify.SlackChannelAnnotation: "#payments"},
+			},
+			Spec: authv1alpha1.UserSpec{
+				Roles: []authv1alpha1.RoleSpec{{Namespace: "prod", ExistingRole: "view", ExpiresAt: &until}},
+			},
+		}
+		Apply(user, developer)
+		Expect(user.Spec.Roles).To(Equal([]authv1alpha1.RoleSpec{
+			{Namespace: "dev", ExistingRole: "developer"},
+			{Namespace: "prod", ExistingRole: "view", ExpiresAt: &until},
+		}))
+		Expect(user.Labels).To(Equal(map[string]string{"team": "payments", "tier": "developer"}))
+		Expect(user.Annotations).To(HaveKeyWithValue(notify.SlackChannelAnnotation, "#payments"))
+	})
+
+	It("fills in labels and the Slack channel", func() {
+		user := &authv1alpha1.User{}
+		Expect(MissingLabels(user, developer)).To(HaveLen(2))
+		Apply(user, developer)
+		Expect(user.Labels).To(HaveKeyWithValue("team", "platform"))
+		Expect(user.Annotations).To(HaveKeyWithValue(notify.SlackChannelAnnotation, "#platform"))
+		Expect(MissingLabels(user, developer)).To(BeEmpty())
+	})
+
+	It("reads the referenced template", func() {
+		scheme := runtime.NewScheme()
+		Expect(authv1alpha1.AddToScheme(scheme)).To(Succeed())
+		c := fake.NewClientBuilder().WithScheme(scheme).WithObjects(developer.DeepCopy()).Build()
+		ctx := context.Background()
+
+		template, err := Get(ctx, c, &authv1alpha1.User{})
+		Expect(err).NotTo(HaveOccurred())
+		Expect(template).To(BeNil())
+
+		user := &authv1alpha1.User{Spec: authv1alpha1.UserSpec{TemplateRef: &authv1alpha1.UserTemplateReference{Name: "developer"}}}
+		template, err = Get(ctx, c, user)
+		Expect(err).NotTo(HaveOccurred())
+		Expect(template.Spec.SlackChannel).To(Equal("#platform"))
+
+		user.Spec.TemplateRef.Name = "missing"
+		_, err = Get(ctx, c, user)
+		Expect(err).To(MatchError("usertemplate missing not found"))
+	})
+})
@@ -0,0 +1,218 @@
+/*
+Copyright 2025.
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+*/
+
+// Package vault is a minimal client for the Vault HTTP API. It logs in with the controller's
+// ServiceAccount token through the Kubernetes auth method and is shared by the Vault certificate
+// issuer and the Vault transit key protector.
+package vault
+
+import (
+	"bytes"
+	"context"
+	"crypto/tls"
+	"crypto/x509"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"strings"
+	"sync"
+	"time"
+)
+
+// DefaultServiceAccountTokenPath is the projected token used to log in to Vault
+const DefaultServiceAccountTokenPath = "/var/run/secrets/kubernetes.io/serviceaccount/token"
+
+// ErrUnavailable marks failures of Vault itself rather than of the request: it is unreachable,
+// sealed or returned a server error
+var ErrUnavailable = errors.New("vault is unavailable")
+
+// Config configures the connection to Vault
+type Config struct {
+	// Address of the Vault server, e.g. https://vault.vault.svc:8200
+	Address string
+	// AuthMount is the mount path of the Kubernetes auth method
+	AuthMount string
+	// AuthRole is the Kubernetes auth role the controller logs in as
+	AuthRole string
+	// TokenPath is the ServiceAccount token presented to the Kubernetes auth method
+	TokenPath string
+	// CACert is a PEM file with the CA of Vault's TLS certificate; empty uses the system roots
+	CACert string
+}
+
+// Client calls the Vault API with a token from the Kubernetes auth method, which it caches and
+// renews before its lease ends
+type Client struct {
+	config Config
+	http   *http.Client
+	// Now is replaced in tests
+	Now func() time.Time
+
+	mu          sync.Mutex
+	token       string
+	tokenExpiry time.Time
+}
+
+// NewClient validates config and returns a client for it
+func NewClient(config Config) (*Client, error) {
+	if config.Address == "" || config.AuthRole == "" {
+		return nil, errors.New("vault address and Kubernetes auth role are required")
+	}
+	if config.AuthMount == "" {
+		config.AuthMount = "kubernetes"
+	}
+	if config.TokenPath == "" {
+		config.TokenPath = DefaultServiceAccountTokenPath
+	}
+	config.Address = strings.TrimSuffix(config.Address, "/")
+
+	transport := http.DefaultTransport.(*http.Transport).Clone()
+	if config.CACert != "" {
+		data, err := os.ReadFile(config.CACert)
+		if err != nil {
+			return nil, fmt.Errorf("failed to read vault CA: %w", err)
+		}
+		pool := x509.NewCertPool()
+		if !pool.AppendCertsFromPEM(data) {
+			return nil, fmt.Errorf("no certificates in vault CA %s", config.CACert)
+		}
+		transport.TLSClientConfig = &tls.Config{RootCAs: pool, MinVersion: tls.VersionTLS12}
+	}
+	return &Client{
+		config: config,
+		http:   &http.Client{Transport: transport, Timeout: 30 * time.Second},
+		Now:    time.Now,
+	}, nil
+}
+
+// Write posts body to the Vault API path and decodes the response into out, logging in again
+// once if the token was rejected
+func (c *Client) Write(ctx context.Context, path string, body, out any) error {
+	return c.call(ctx, http.MethodPost, path, body, out)
+}
+
+// Delete deletes the Vault API path, logging in again once if the token was rejected
+func (c *Client) Delete(ctx context.Context, path string) error {
+	return c.call(ctx, http.MethodDelete, path, nil, nil)
+}
+
+func (c *Client) call(ctx context.Context, method, path string, body, out any) error {
+	token, err := c.loginToken(ctx, false)
+	if err != nil {
+		return err
+	}
+	err = c.do(ctx, method, path, token, body, out)
+	var vaultErr *Error
+	if errors.As(err, &vaultErr) && vaultErr.Status == http.StatusForbidden {
+		// Revoked or expired early; retry once with a fresh token
+		if token, err = c.loginToken(ctx, true); err != nil {
+			return err
+		}
+		err = c.do(ctx, method, path, token, body, out)
+	}
+	return err
+}
+
+// loginToken returns the cached token, logging in through the Kubernetes auth method when it
+// is missing, about to expire or force is set
+func (c *Client) loginToken(ctx context.Context, force bool) (string, error) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if !force && c.token != "" && c.Now().Before(c.tokenExpiry) {
+		return c.token, nil
+	}
+
+	jwt, err := os.ReadFile(c.config.TokenPath)
+	if err != nil {
+		return "", fmt.Errorf("failed to read ServiceAccount token for vault login: %w", err)
+	}
+	var response struct {
+		Auth struct {
+			ClientToken   string `json:"client_token"`
+			LeaseDuration int    `json:"lease_duration"`
+		} `json:"auth"`
+	}
+	body := map[string]any{"role": c.config.AuthRole, "jwt": strings.TrimSpace(string(jwt))}
+	if err := c.do(ctx, http.MethodPost, fmt.Sprintf("auth/%s/login", c.config.AuthMount), "", body, &response); err != nil {
+		return "", fmt.Errorf("vault login: %w", err)
+	}
+	if response.Auth.ClientToken == "" {
+		return "", fmt.Errorf("%w: vault login returned no token", ErrUnavailable)
+	}
+
+	c.token = response.Auth.ClientToken
+	// Renew well before the lease ends; a zero lease means the token does not expire
+	lease := time.Duration(response.Auth.LeaseDuration) * time.Second
+	if lease == 0 {
+		lease = 24 * time.Hour
+	}
+	c.tokenExpiry = c.Now().Add(lease * 3 / 4)
+	return c.token, nil
+}
+
+// Error is an error response from the Vault API
+type Error struct {
+	Status int
+	Errors []string
+}
+
+func (e *Error) Error() string {
+	return fmt.Sprintf("vault returned %d: %s", e.Status, strings.Join(e.Errors, "; "))
+}
+
+// do sends body to the Vault API path and decodes the response into out, if any. Connection
+// failures, server errors and a sealed Vault wrap ErrUnavailable.
+func (c *Client) do(ctx context.Context, method, path, token string, body, out any) error {
+	var reader io.Reader
+	if body != nil {
+		data, err := json.Marshal(body)
+		if err != nil {
+			return err
+		}
+		reader = bytes.NewReader(data)
+	}
+	httpReq, err := http.NewRequestWithContext(ctx, method, c.config.Address+"/v1/"+path, reader)
+	if err != nil {
+		return err
+	}
+	if body != nil {
+		httpReq.Header.Set("Content-Type", "application/json")
+	}
+	if token != "" {
+		httpReq.Header.Set("X-Vault-Token", token)
+	}
+
+	resp, err := c.http.Do(httpReq)
+	if err != nil {
+		return fmt.Errorf("%w: %w", ErrUnavailable, err)
+	}
+	defer func() { _ = resp.Body.Close() }()
+	respBody, err := io.ReadAll(io.LimitReader(resp.Body, 1<<20))
+	if err != nil {
+		return fmt.Errorf("%w: failed to read response: %w", ErrUnavailable, err)
+	}
+
+	if resp.StatusCode >= http.StatusBadRequest {
+		vaultErr := &Error{Status: resp.StatusCode}
+		var errorBody struct {
+			Errors []string `json:"errors"`
+		}
+		if json.Unmarshal(respBody, &errorBody) == nil {
+			vaultErr.Errors = errorBody.Errors
+		}
+		if resp.StatusCode >= http.StatusInternalServerError {
+			return fmt.Errorf("%w: %w", ErrUnavailable, vaultErr)
+		}
+		return vaultErr
+	}
+	if out == nil || len(respBody) == 0 {
+		return nil
+	}
+	return json.Unmarshal(respBody, out)
+}
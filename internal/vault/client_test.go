@@ -0,0 +1,308 @@
+/*
+Copyright 2025.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package vault
+
+import (
+	"context"
+	"encoding/json"
+	"encoding/pem"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strconv"
+	"time"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("Client", func() {
+	var (
+		ctx    context.Context
+		server *httptest.Server
+		client *Client
+		now    time.Time
+		// logins holds the bodies of the login requests
+		logins []map[string]any
+		// lease is the lease duration of the tokens returned by login
+		lease int
+		// tokens counts the tokens issued; token-N is the Nth
+		tokens int
+		// revoked is the token the API rejects with 403
+		revoked string
+		// requests holds the requests to paths other than login, with their token and body
+		requests []recordedRequest
+		// respond answers requests to paths other than login when set
+		respond func(w http.ResponseWriter, r *http.Request)
+	)
+
+	BeforeEach(func() {
+		ctx = context.Background()
+		now = time.Date(2026, 1, 1, 0, 0, 0, 0, time.UTC)
+		logins, lease, tokens, revoked, requests, respond = nil, 3600, 0, "", nil, nil
+
+		mux := http.NewServeMux()
+		mux.HandleFunc("POST /v1/auth/kubernetes/login", func(w http.ResponseWriter, r *http.Request) {
+			var body map[string]any
+			Expect(json.NewDecoder(r.Body).Decode(&body)).To(Succeed())
+			logins = append(logins, body)
+			tokens++
+			_ = json.NewEncoder(w).Encode(map[string]any{
+				"auth": map[string]any{"client_token": tokenName(tokens), "lease_duration": lease},
+			})
+		})
+		mux.HandleFunc("/v1/", func(w http.ResponseWriter, r *http.Request) {
+			request := recordedRequest{method: r.Method, path: r.URL.Path, token: r.Header.Get("X-Vault-Token")}
+			if r.ContentLength != 0 {
+				Expect(r.Header.Get("Content-Type")).To(Equal("application/json"))
+				Expect(json.NewDecoder(r.Body).Decode(&request.body)).To(Succeed())
+			}
+			requests = append(requests, request)
+			if request.token == revoked {
+				w.WriteHeader(http.StatusForbidden)
+				_ = json.NewEncoder(w).Encode(map[string]any{"errors": []string{"permission denied"}})
+				return
+			}
+			if respond != nil {
+				respond(w, r)
+			}
+		})
+		server = httptest.NewServer(mux)
+		DeferCleanup(server.Close)
+
+		tokenPath := filepath.Join(GinkgoT().TempDir(), "token")
+		Expect(os.WriteFile(tokenPath, []byte("sa-token\n"), 0o600)).To(Succeed())
+		var err error
+		client, err = NewClient(Config{Address: server.URL + "/", AuthRole: "kubeuser", TokenPath: tokenPath})
+		Expect(err).NotTo(HaveOccurred())
+		client.Now = func() time.Time { return now }
+	})
+
+	Describe("NewClient", func() {
+		It("requires an address and auth role", func() {
+			_, err := NewClient(Config{AuthRole: "kubeuser"})
+			Expect(err).To(HaveOccurred())
+			_, err = NewClient(Config{Address: "https://vault:8200"})
+			Expect(err).To(HaveOccurred())
+		})
+
+		It("defaults the auth mount and token path", func() {
+			c, err := NewClient(Config{Address: "https://vault:8200/", AuthRole: "kubeuser"})
+			Expect(err).NotTo(HaveOccurred())
+			Expect(c.config.Address).To(Equal("https://vault:8200"))
+			Expect(c.config.AuthMount).To(Equal("kubernetes"))
+			Expect(c.config.TokenPath).To(Equal(DefaultServiceAccountTokenPath))
+		})
+
+		It("rejects CA files without certificates", func() {
+			caPath := filepath.Join(GinkgoT().TempDir(), "ca.crt")
+			Expect(os.WriteFile(caPath, []byte("not a certificate"), 0o600)).To(Succeed())
+			_, err := NewClient(Config{Address: "https://vault:8200", AuthRole: "kubeuser", CACert: caPath})
+			Expect(err).To(MatchError(ContainSubstring("no certificates")))
+			_, err = NewClient(Config{Address: "https://vault:8200", AuthRole: "kubeuser", CACert: caPath + ".missing"})
+			Expect(err).To(HaveOccurred())
+		})
+
+		It("trusts the CA of Vault's certificate", func() {
+			tlsServer := httptest.NewTLSServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				w.WriteHeader(http.StatusNoContent)
+			}))
+			DeferCleanup(tlsServer.Close)
+			caPath := filepath.Join(GinkgoT().TempDir(), "ca.crt")
+			Expect(os.WriteFile(caPath, pem.EncodeToMemory(&pem.Block{
+				Type: "CERTIFICATE", Bytes: tlsServer.Certificate().Raw,
+			}), 0o600)).To(Succeed())
+			c, err := NewClient(Config{Address: tlsServer.URL, AuthRole: "kubeuser", CACert: caPath})
+			Expect(err).NotTo(HaveOccurred())
+			Expect(c.do(ctx, http.MethodDelete, "secret/metadata/jane", "token", nil, nil)).To(Succeed())
+		})
+	})
+
+	It("encrypts with transit and decodes the response", func() {
+		respond = func(w http.ResponseWriter, r *http.Request) {
+			_ = json.NewEncoder(w).Encode(map[string]any{"data": map[string]any{"ciphertext": "vault:v1:abc"}})
+		}
+		var response struct {
+			Data struct {
+				Ciphertext string `json:"ciphertext"`
+			} `json:"data"`
+		}
+		Expect(client.Write(ctx, "transit/encrypt/users",
+			map[string]string{"plaintext": "a2V5", "associated_data": "amFuZQ=="}, &response)).To(Succeed())
+		Expect(response.Data.Ciphertext).To(Equal("vault:v1:abc"))
+		Expect(requests).To(HaveExactElements(recordedRequest{
+			method: http.MethodPost,
+			path:   "/v1/transit/encrypt/users",
+			token:  "token-1",
+			body:   map[string]any{"plaintext": "a2V5", "associated_data": "amFuZQ=="},
+		}))
+		Expect(logins).To(HaveExactElements(map[string]any{"role": "kubeuser", "jwt": "sa-token"}))
+	})
+
+	It("decrypts with transit", func() {
+		respond = func(w http.ResponseWriter, r *http.Request) {
+			_ = json.NewEncoder(w).Encode(map[string]any{"data": map[string]any{"plaintext": "a2V5"}})
+		}
+		var response struct {
+			Data struct {
+				Plaintext string `json:"plaintext"`
+			} `json:"data"`
+		}
+		Expect(client.Write(ctx, "transit/decrypt/users",
+			map[string]string{"ciphertext": "vault:v1:abc"}, &response)).To(Succeed())
+		Expect(response.Data.Plaintext).To(Equal("a2V5"))
+	})
+
+	It("writes and deletes KV secrets", func() {
+		respond = func(w http.ResponseWriter, r *http.Request) {
+			w.WriteHeader(http.StatusNoContent)
+		}
+		var response struct{}
+		Expect(client.Write(ctx, "secret/data/kubeuser/jane",
+			map[string]any{"data": map[string]string{"config": "kubeconfig"}}, &response)).To(Succeed())
+		Expect(client.Delete(ctx, "secret/metadata/kubeuser/jane")).To(Succeed())
+		Expect(requests).To(HaveExactElements(
+			recordedRequest{
+				method: http.MethodPost,
+				path:   "/v1/secret/data/kubeuser/jane",
+				token:  "token-1",
+				body:   map[string]any{"data": map[string]any{"config": "kubeconfig"}},
+			},
+			recordedRequest{method: http.MethodDelete, path: "/v1/secret/metadata/kubeuser/jane", token: "token-1"},
+		))
+	})
+
+	Describe("login", func() {
+		It("reuses the token until three quarters of its lease have passed", func() {
+			Expect(client.Delete(ctx, "a")).To(Succeed())
+			now = now.Add(44 * time.Minute)
+			Expect(client.Delete(ctx, "b")).To(Succeed())
+			Expect(logins).To(HaveLen(1))
+			now = now.Add(time.Minute)
+			Expect(client.Delete(ctx, "c")).To(Succeed())
+			Expect(logins).To(HaveLen(2))
+			Expect(requests[2].token).To(Equal("token-2"))
+		})
+
+		It("renews tokens without a lease daily", func() {
+			lease = 0
+			Expect(client.Delete(ctx, "a")).To(Succeed())
+			now = now.Add(17 * time.Hour)
+			Expect(client.Delete(ctx, "b")).To(Succeed())
+			Expect(logins).To(HaveLen(1))
+			now = now.Add(time.Hour)
+			Expect(client.Delete(ctx, "c")).To(Succeed())
+			Expect(logins).To(HaveLen(2))
+		})
+
+		It("logs in again once when the token is rejected", func() {
+			Expect(client.Delete(ctx, "a")).To(Succeed())
+			revoked = "token-1"
+			Expect(client.Delete(ctx, "b")).To(Succeed())
+			Expect(logins).To(HaveLen(2))
+			Expect(requests).To(HaveLen(3))
+			Expect(requests[1].token).To(Equal("token-1"))
+			Expect(requests[2].token).To(Equal("token-2"))
+		})
+
+		It("returns the error when the fresh token is rejected too", func() {
+			respond = func(w http.ResponseWriter, r *http.Request) {
+				w.WriteHeader(http.StatusForbidden)
+				_ = json.NewEncoder(w).Encode(map[string]any{"errors": []string{"permission denied"}})
+			}
+			var vaultErr *Error
+			Expect(errors.As(client.Delete(ctx, "a"), &vaultErr)).To(BeTrue())
+			Expect(vaultErr.Status).To(Equal(http.StatusForbidden))
+			Expect(logins).To(HaveLen(2))
+			Expect(requests).To(HaveLen(2))
+		})
+
+		It("fails without a ServiceAccount token", func() {
+			client.config.TokenPath = filepath.Join(GinkgoT().TempDir(), "missing")
+			Expect(client.Delete(ctx, "a")).To(MatchError(ContainSubstring("ServiceAccount token")))
+			Expect(requests).To(BeEmpty())
+		})
+
+		It("reports a login without a token as unavailable", func() {
+			client.config.AuthMount = "other"
+			server.Config.Handler.(*http.ServeMux).HandleFunc("POST /v1/auth/other/login",
+				func(w http.ResponseWriter, r *http.Request) {
+					_ = json.NewEncoder(w).Encode(map[string]any{"auth": map[string]any{}})
+				})
+			Expect(client.Delete(ctx, "a")).To(MatchError(ErrUnavailable))
+		})
+	})
+
+	DescribeTable("maps error responses",
+		func(status int, body string, unavailable bool, message string) {
+			respond = func(w http.ResponseWriter, r *http.Request) {
+				w.WriteHeader(status)
+				_, _ = w.Write([]byte(body))
+			}
+			err := client.Write(ctx, "transit/decrypt/users", map[string]string{}, nil)
+			Expect(err).To(HaveOccurred())
+			var vaultErr *Error
+			Expect(errors.As(err, &vaultErr)).To(BeTrue())
+			Expect(vaultErr.Status).To(Equal(status))
+			Expect(err).To(MatchError(ContainSubstring(message)))
+			if unavailable {
+				Expect(err).To(MatchError(ErrUnavailable))
+			} else {
+				Expect(err).NotTo(MatchError(ErrUnavailable))
+			}
+		},
+		Entry("a rejected request", http.StatusBadRequest,
+			`{"errors":["cipher: message authentication failed"]}`, false, "vault returned 400: cipher: message authentication failed"),
+		Entry("several errors", http.StatusBadRequest,
+			`{"errors":["one","two"]}`, false, "one; two"),
+		Entry("a missing path", http.StatusNotFound, `{"errors":[]}`, false, "vault returned 404"),
+		Entry("a body that is not JSON", http.StatusBadRequest, "bad request", false, "vault returned 400"),
+		Entry("a sealed vault", http.StatusServiceUnavailable,
+			`{"errors":["Vault is sealed"]}`, true, "Vault is sealed"),
+		Entry("a server error", http.StatusInternalServerError, "", true, "vault returned 500"),
+	)
+
+	It("reports an unreachable vault as unavailable", func() {
+		server.Close()
+		Expect(client.Delete(ctx, "a")).To(MatchError(ErrUnavailable))
+	})
+
+	It("fails on responses that are not JSON", func() {
+		respond = func(w http.ResponseWriter, r *http.Request) {
+			_, _ = w.Write([]byte("<html>"))
+		}
+		var response map[string]any
+		err := client.Write(ctx, "transit/encrypt/users", map[string]string{}, &response)
+		Expect(err).To(HaveOccurred())
+		Expect(err).NotTo(MatchError(ErrUnavailable))
+	})
+})
+
+// recordedRequest is a request the fake Vault received
+type recordedRequest struct {
+	method string
+	path   string
+	token  string
+	body   map[string]any
+}
+
+// tokenName returns the name of the nth token the fake Vault issues
+func tokenName(n int) string {
+	return "token-" + strconv.Itoa(n)
+}
@@ -0,0 +1,59 @@
+/*
+Copyright 2025.
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+*/
+
+package webhook
+
+import (
+	"context"
+	"fmt"
+
+	authv1alpha1 "github.com/openkube-hub/KubeUser/api/v1alpha1"
+	authenticationv1 "k8s.io/api/authentication/v1"
+	authorizationv1 "k8s.io/api/authorization/v1"
+	"sigs.k8s.io/controller-runtime/pkg/webhook/admission"
+)
+
+// BreakGlassVerb is the custom verb on users.auth.openkube.io required to create break-glass Users
+const BreakGlassVerb = "breakglass"
+
+// validateBreakGlass only lets requesters holding the breakglass verb create break-glass Users,
+// and keeps spec.breakGlass from changing afterwards: the access window starts at creation,
+// and ClusterPolicies would otherwise be bypassed by switching an existing User over.
+func (w *UserWebhook) validateBreakGlass(ctx context.Context, requester authenticationv1.UserInfo,
+	user, previous *authv1alpha1.User) error {
+	if previous != nil {
+		if user.Spec.BreakGlass != previous.Spec.BreakGlass {
+			return fmt.Errorf("spec.breakGlass cannot be changed after creation")
+		}
+		return nil
+	}
+	if !user.Spec.BreakGlass {
+		return nil
+	}
+	allowed, err := w.allowed(ctx, requester, &authorizationv1.ResourceAttributes{
+		Verb:     BreakGlassVerb,
+		Group:    authv1alpha1.GroupVersion.Group,
+		Resource: "users",
+		Name:     user.Name,
+	}, nil)
+	if err != nil {
+		return err
+	}
+	if !allowed {
+		return fmt.Errorf("user '%s' may not create break-glass users: requires the '%s' verb on users.%s",
+			requester.Username, BreakGlassVerb, authv1alpha1.GroupVersion.Group)
+	}
+	return nil
+}
+
+// validateRequesterBreakGlass runs validateBreakGlass for the requester of the admission request in ctx
+func (w *UserWebhook) validateRequesterBreakGlass(ctx context.Context, user, previous *authv1alpha1.User) error {
+	req, err := admission.RequestFromContext(ctx)
+	if err != nil {
+		return fmt.Errorf("cannot verify the requester may create break-glass users: %w", err)
+	}
+	return w.validateBreakGlass(ctx, req.UserInfo, user, previous)
+}
@@ -0,0 +1,119 @@
+/*
+Copyright 2025.
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+*/
+
+package webhook
+
+import (
+	"context"
+	"fmt"
+	"net/mail"
+	"net/url"
+	"slices"
+	"strings"
+
+	authv1alpha1 "github.com/openkube-hub/KubeUser/api/v1alpha1"
+	"github.com/openkube-hub/KubeUser/internal/policy"
+	authenticationv1 "k8s.io/api/authentication/v1"
+	authorizationv1 "k8s.io/api/authorization/v1"
+	"k8s.io/apimachinery/pkg/util/validation"
+	"sigs.k8s.io/controller-runtime/pkg/webhook/admission"
+)
+
+// validateCertificate checks the values of spec.certificate are usable in a certificate. The
+// system: groups of the API server and its components cannot be requested as organizations.
+func validateCertificate(user *authv1alpha1.User) error {
+	subject := user.Spec.Certificate
+	if subject == nil {
+		return nil
+	}
+	if user.Spec.CSR != "" {
+		return fmt.Errorf("spec.certificate cannot be combined with spec.csr, the CSR carries its own subject")
+	}
+	for _, organization := range subject.Organizations {
+		if organization == "" || strings.HasPrefix(organization, "system:") {
+			return fmt.Errorf("invalid spec.certificate.organizations entry %q", organization)
+		}
+	}
+	for _, unit := range subject.OrganizationalUnits {
+		if unit == "" {
+			return fmt.Errorf("spec.certificate.organizationalUnits must not contain empty entries")
+		}
+	}
+	for _, address := range subject.EmailAddresses {
+		if parsed, err := mail.ParseAddress(address); err != nil || parsed.Address != address {
+			return fmt.Errorf("invalid spec.certificate.emailAddresses entry %q", address)
+		}
+	}
+	for _, name := range subject.DNSNames {
+		if errs := validation.IsDNS1123Subdomain(name); len(errs) > 0 {
+			return fmt.Errorf("invalid spec.certificate.dnsNames entry %q: %s", name, strings.Join(errs, ", "))
+		}
+	}
+	for _, uri := range subject.URIs {
+		if parsed, err := url.Parse(uri); err != nil || parsed.Scheme == "" {
+			return fmt.Errorf("invalid spec.certificate.uris entry %q: must be an absolute URI", uri)
+		}
+	}
+	return nil
+}
+
+// validateCertificateSubject checks spec.certificate against the ClusterPolicies, unless the
+// User is break-glass, and lets the requester add organizations only when it may impersonate
+// them as groups: the API server takes them as the user's groups, so they would otherwise let
+// anyone who can create Users join any group. Values carried over from previous are not
+// checked again.
+func (w *UserWebhook) validateCertificateSubject(ctx context.Context, requester authenticationv1.UserInfo,
+	user, previous *authv1alpha1.User) error {
+	subject := user.Spec.Certificate
+	if subject == nil {
+		return nil
+	}
+	var previousSubject *authv1alpha1.CertificateSubject
+	if previous != nil {
+		previousSubject = previous.Spec.Certificate
+	}
+
+	for _, organization := range subject.Organizations {
+		if previousSubject != nil && slices.Contains(previousSubject.Organizations, organization) {
+			continue
+		}
+		allowed, err := w.allowed(ctx, requester, &authorizationv1.ResourceAttributes{
+			Verb:     "impersonate",
+			Resource: "groups",
+			Name:     organization,
+		}, nil)
+		if err != nil {
+			return err
+		}
+		if !allowed {
+			return fmt.Errorf("user '%s' may not add organization '%s' to the certificate: the API server takes "+
+				"it as a group, which requires the impersonate verb on it", requester.Username, organization)
+		}
+	}
+
+	if user.Spec.BreakGlass {
+		return nil
+	}
+	policies, err := policy.List(ctx, w)
+	if err != nil {
+		return err
+	}
+	creator := &policy.Creator{Username: requester.Username, Groups: requester.Groups}
+	return policy.CheckCertificate(policies, subject, previousSubject, creator)
+}
+
+// validateRequesterCertificateSubject runs validateCertificateSubject for the requester of the
+// admission request in ctx
+func (w *UserWebhook) validateRequesterCertificateSubject(ctx context.Context, user, previous *authv1alpha1.User) error {
+	if user.Spec.Certificate == nil {
+		return nil
+	}
+	req, err := admission.RequestFromContext(ctx)
+	if err != nil {
+		return fmt.Errorf("cannot check the user's certificate subject: %w", err)
+	}
+	return w.validateCertificateSubject(ctx, req.UserInfo, user, previous)
+}
@@ -0,0 +1,318 @@
+/*
+Copyright 2025.
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+*/
+
+package webhook
+
+import (
+	"context"
+	"fmt"
+	"slices"
+	"strings"
+
+	authv1alpha1 "github.com/openkube-hub/KubeUser/api/v1alpha1"
+	authenticationv1 "k8s.io/api/authentication/v1"
+	authorizationv1 "k8s.io/api/authorization/v1"
+	corev1 "k8s.io/api/core/v1"
+	rbacv1 "k8s.io/api/rbac/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/types"
+	"sigs.k8s.io/controller-runtime/pkg/webhook/admission"
+)
+
+// grant is a role a User receives, in the scope it is bound in
+type grant struct {
+	kind      string // Role or ClusterRole
+	name      string
+	namespace string // empty for cluster-wide grants
+	// extended is set on grants the User already had whose time limit is lengthened or removed
+	extended bool
+}
+
+func (g grant) String() string {
+	s := fmt.Sprintf("role '%s' in namespace '%s'", g.name, g.namespace)
+	if g.namespace == "" {
+		s = fmt.Sprintf("clusterrole '%s'", g.name)
+	}
+	if g.extended {
+		s += " for longer"
+	}
+	return s
+}
+
+// grantLimit is when a grant ends: the end of its elevation, its expiresAt or its duration
+type grantLimit struct {
+	until     *metav1.Time
+	expiresAt *metav1.Time
+	duration  *metav1.Duration
+}
+
+// extends reports whether l ends later than previous, or is missing a limit previous had.
+// Limits of different kinds cannot be compared, so trading one for another extends too.
+func (l grantLimit) extends(previous grantLimit) bool {
+	if previous.until != nil && (l.until == nil || l.until.After(previous.until.Time)) {
+		return true
+	}
+	if previous.expiresAt != nil && (l.expiresAt == nil || l.expiresAt.After(previous.expiresAt.Time)) {
+		return true
+	}
+	return previous.duration != nil && (l.duration == nil || l.duration.Duration > previous.duration.Duration)
+}
+
+// limitedGrant is a grant with its time limit
+type limitedGrant struct {
+	grant
+	limit grantLimit
+}
+
+// newGrants returns the grants of user that previous did not already have, including grants
+// whose time limit user lengthens or removes. Those are checked like new ones, or a temporary
+// grant could be made permanent by anyone allowed to edit the User.
+func newGrants(user, previous *authv1alpha1.User) []grant {
+	existing := map[grant][]grantLimit{}
+	if previous != nil {
+		for _, g := range userGrants(previous) {
+			existing[g.grant] = append(existing[g.grant], g.limit)
+		}
+	}
+	var grants []grant
+	for _, g := range userGrants(user) {
+		limits, ok := existing[g.grant]
+		if !ok {
+			grants = append(grants, g.grant)
+			continue
+		}
+		if !slices.ContainsFunc(limits, func(limit grantLimit) bool { return !g.limit.extends(limit) }) {
+			g.extended = true
+			grants = append(grants, g.grant)
+		}
+	}
+	return grants
+}
+
+func userGrants(user *authv1alpha1.User) []limitedGrant {
+	grants := make([]limitedGrant, 0, len(user.Spec.Roles)+len(user.Spec.ClusterRoles))
+	for _, role := range user.Spec.Roles {
+		grants = append(grants, limitedGrant{
+			grant: grant{kind: "Role", name: role.ExistingRole, namespace: role.Namespace},
+			limit: grantLimit{expiresAt: role.ExpiresAt, duration: role.Duration},
+		})
+	}
+	for _, clusterRole := range user.Spec.ClusterRoles {
+		limit := grantLimit{expiresAt: clusterRole.ExpiresAt, duration: clusterRole.Duration}
+		if clusterRole.Elevation != nil {
+			limit.until = &clusterRole.Elevation.Until
+		}
+		grants = append(grants, limitedGrant{grant: grant{kind: "ClusterRole", name: clusterRole.ExistingClusterRole}, limit: limit})
+	}
+	return grants
+}
+
+// validateEscalation applies the RBAC API's escalation rule for bindings to the grants a User
+// receives: the requester must either hold every permission of a role in the scope it is
+// granted in, or be allowed to bind the role. Otherwise anyone who can create Users could
+// mint credentials with more privileges than their own. Grants carried over from previous
+// are not checked again, unless their time limit is lengthened or removed.
+func (w *UserWebhook) validateEscalation(ctx context.Context, requester authenticationv1.UserInfo,
+	user, previous *authv1alpha1.User) error {
+	for _, g := range newGrants(user, previous) {
+		pending, err := w.pendingNamespace(ctx, user, g)
+		if err != nil {
+			return err
+		}
+		if pending {
+			// The controller creates the namespace on the User's behalf
+			creatable, err := w.allowed(ctx, requester, &authorizationv1.ResourceAttributes{
+				Verb:     "create",
+				Resource: "namespaces",
+			}, nil)
+			if err != nil {
+				return err
+			}
+			if !creatable {
+				return fmt.Errorf("user '%s' may not grant %s with createNamespace: the namespace does not exist "+
+					"and the user may not create namespaces", requester.Username, g)
+			}
+		}
+
+		bindable, err := w.allowed(ctx, requester, &authorizationv1.ResourceAttributes{
+			Namespace: g.namespace,
+			Verb:      "bind",
+			Group:     rbacv1.GroupName,
+			Resource:  strings.ToLower(g.kind) + "s",
+			Name:      g.name,
+		}, nil)
+		if err != nil {
+			return err
+		}
+		if bindable {
+			continue
+		}
+		if pending {
+			// There are no rules to compare yet
+			return fmt.Errorf("user '%s' may not grant %s before its namespace exists "+
+				"(granting it requires the bind verb on it)", requester.Username, g)
+		}
+
+		rules, err := w.grantRules(ctx, g)
+		if err != nil {
+			return err
+		}
+		for _, rule := range rules {
+			if err := w.coversRule(ctx, requester, g, rule); err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
+
+// validateRequesterEscalation runs validateEscalation for the requester of the admission
+// request in ctx. Without one the grants cannot be attributed, so they are rejected.
+func (w *UserWebhook) validateRequesterEscalation(ctx context.Context, user, previous *authv1alpha1.User) error {
+	req, err := admission.RequestFromContext(ctx)
+	if err != nil {
+		return fmt.Errorf("cannot verify the requester may grant the user's roles: %w", err)
+	}
+	return w.validateEscalation(ctx, req.UserInfo, user, previous)
+}
+
+// pendingNamespace reports whether g is a Role whose namespace does not exist yet and is
+// created by the controller because the user's roles ask for it with createNamespace
+func (w *UserWebhook) pendingNamespace(ctx context.Context, user *authv1alpha1.User, g grant) (bool, error) {
+	if g.kind != "Role" || !createsNamespace(user.Spec.Roles, g.namespace) {
+		return false, nil
+	}
+	var ns corev1.Namespace
+	if err := w.Get(ctx, types.NamespacedName{Name: g.namespace}, &ns); err != nil {
+		if apierrors.IsNotFound(err) {
+			return true, nil
+		}
+		return false, fmt.Errorf("failed to read namespace '%s': %w", g.namespace, err)
+	}
+	return false, nil
+}
+
+// createsNamespace reports whether any role in namespace asks for the namespace to be created
+func createsNamespace(roles []authv1alpha1.RoleSpec, namespace string) bool {
+	for _, role := range roles {
+		if role.CreateNamespace && role.Namespace == namespace {
+			return true
+		}
+	}
+	return false
+}
+
+// grantRules returns the rules of the granted Role or ClusterRole
+func (w *UserWebhook) grantRules(ctx context.Context, g grant) ([]rbacv1.PolicyRule, error) {
+	if g.kind == "Role" {
+		var role rbacv1.Role
+		if err := w.Get(ctx, types.NamespacedName{Name: g.name, Namespace: g.namespace}, &role); err != nil {
+			return nil, fmt.Errorf("failed to read %s: %w", g, err)
+		}
+		return role.Rules, nil
+	}
+	var clusterRole rbacv1.ClusterRole
+	if err := w.Get(ctx, types.NamespacedName{Name: g.name}, &clusterRole); err != nil {
+		return nil, fmt.Errorf("failed to read %s: %w", g, err)
+	}
+	return clusterRole.Rules, nil
+}
+
+// coversRule checks the requester holds every permission of rule in the grant's scope
+func (w *UserWebhook) coversRule(ctx context.Context, requester authenticationv1.UserInfo, g grant,
+	rule rbacv1.PolicyRule) error {
+	denied := func(permission string) error {
+		return fmt.Errorf("user '%s' may not grant %s: it allows %s, which the user does not hold "+
+			"(granting requires every permission of the role or the bind verb on it)", requester.Username, g, permission)
+	}
+
+	if len(rule.NonResourceURLs) > 0 {
+		// Non-resource rules only take effect when bound cluster-wide
+		if g.namespace != "" {
+			return nil
+		}
+		for _, url := range rule.NonResourceURLs {
+			for _, verb := range rule.Verbs {
+				ok, err := w.allowed(ctx, requester, nil, &authorizationv1.NonResourceAttributes{Path: url, Verb: verb})
+				if err != nil {
+					return err
+				}
+				if !ok {
+					return denied(fmt.Sprintf("%s on %s", verb, url))
+				}
+			}
+		}
+		return nil
+	}
+
+	names := rule.ResourceNames
+	if len(names) == 0 {
+		names = []string{""}
+	}
+	for _, group := range rule.APIGroups {
+		for _, resource := range rule.Resources {
+			resource, subresource, _ := strings.Cut(resource, "/")
+			for _, verb := range rule.Verbs {
+				for _, name := range names {
+					attributes := &authorizationv1.ResourceAttributes{
+						Namespace:   g.namespace,
+						Verb:        verb,
+						Group:       group,
+						Resource:    resource,
+						Subresource: subresource,
+						Name:        name,
+					}
+					ok, err := w.allowed(ctx, requester, attributes, nil)
+					if err != nil {
+						return err
+					}
+					if !ok {
+						return denied(describeAttributes(attributes))
+					}
+				}
+			}
+		}
+	}
+	return nil
+}
+
+// allowed asks the API server's authorizers whether requester may perform the request
+func (w *UserWebhook) allowed(ctx context.Context, requester authenticationv1.UserInfo,
+	resource *authorizationv1.ResourceAttributes, nonResource *authorizationv1.NonResourceAttributes) (bool, error) {
+	extra := make(map[string]authorizationv1.ExtraValue, len(requester.Extra))
+	for key, value := range requester.Extra {
+		extra[key] = authorizationv1.ExtraValue(value)
+	}
+	review := &authorizationv1.SubjectAccessReview{
+		Spec: authorizationv1.SubjectAccessReviewSpec{
+			User:                  requester.Username,
+			Groups:                requester.Groups,
+			UID:                   requester.UID,
+			Extra:                 extra,
+			ResourceAttributes:    resource,
+			NonResourceAttributes: nonResource,
+		},
+	}
+	if err := w.Create(ctx, review); err != nil {
+		return false, fmt.Errorf("failed to review access of user '%s': %w", requester.Username, err)
+	}
+	return review.Status.Allowed, nil
+}
+
+func describeAttributes(a *authorizationv1.ResourceAttributes) string {
+	resource := a.Resource
+	if a.Subresource != "" {
+		resource += "/" + a.Subresource
+	}
+	if a.Group != "" {
+		resource += "." + a.Group
+	}
+	if a.Name != "" {
+		resource += " named " + a.Name
+	}
+	return fmt.Sprintf("%s on %s", a.Verb, resource)
+}
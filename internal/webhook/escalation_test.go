@@ -0,0 +1,318 @@
+/*
+Copyright 2025.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package webhook
+
+import (
+	"context"
+	"time"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+	admissionv1 "k8s.io/api/admission/v1"
+	authorizationv1 "k8s.io/api/authorization/v1"
+	corev1 "k8s.io/api/core/v1"
+	rbacv1 "k8s.io/api/rbac/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+
+	authv1alpha1 "github.com/openkube-hub/KubeUser/api/v1alpha1"
+	"github.com/openkube-hub/KubeUser/internal/operatorconfig"
+)
+
+// allowAttributes allows the resource requests equal to one of attributes
+func allowAttributes(attributes ...authorizationv1.ResourceAttributes) func(authorizationv1.SubjectAccessReviewSpec) bool {
+	return func(spec authorizationv1.SubjectAccessReviewSpec) bool {
+		if spec.ResourceAttributes == nil {
+			return false
+		}
+		for _, a := range attributes {
+			if *spec.ResourceAttributes == a {
+				return true
+			}
+		}
+		return false
+	}
+}
+
+var _ = Describe("newGrants", func() {
+	user := func(roles []authv1alpha1.RoleSpec, clusterRoles ...string) *authv1alpha1.User {
+		u := &authv1alpha1.User{Spec: authv1alpha1.UserSpec{Roles: roles}}
+		for _, name := range clusterRoles {
+			u.Spec.ClusterRoles = append(u.Spec.ClusterRoles, authv1alpha1.ClusterRoleSpec{ExistingClusterRole: name})
+		}
+		return u
+	}
+	dev := []authv1alpha1.RoleSpec{{Namespace: "dev", ExistingRole: "developer"}}
+
+	DescribeTable("returns the grants previous did not have",
+		func(current, previous *authv1alpha1.User, expected []grant) {
+			Expect(newGrants(current, previous)).To(Equal(expected))
+		},
+		Entry("all grants of a new user", user(dev, "view"), nil, []grant{
+			{kind: "Role", name: "developer", namespace: "dev"},
+			{kind: "ClusterRole", name: "view"},
+		}),
+		Entry("none already held", user(dev, "view"), user(dev, "view"), nil),
+		Entry("an added ClusterRole", user(dev, "view", "edit"), user(dev, "view"), []grant{
+			{kind: "ClusterRole", name: "edit"},
+		}),
+		Entry("the same Role in another namespace",
+			user([]authv1alpha1.RoleSpec{{Namespace: "prod", ExistingRole: "developer"}}), user(dev), []grant{
+				{kind: "Role", name: "developer", namespace: "prod"},
+			}),
+	)
+	hour := func(hours int) *metav1.Time {
+		t := metav1.NewTime(time.Date(2026, 1, 1, hours, 0, 0, 0, time.UTC))
+		return &t
+	}
+	duration := func(hours int) *metav1.Duration {
+		return &metav1.Duration{Duration: time.Duration(hours) * time.Hour}
+	}
+	admin := func(spec authv1alpha1.ClusterRoleSpec) *authv1alpha1.User {
+		spec.ExistingClusterRole = "cluster-admin"
+		return &authv1alpha1.User{Spec: authv1alpha1.UserSpec{ClusterRoles: []authv1alpha1.ClusterRoleSpec{spec}}}
+	}
+	elevated := func(hours int) authv1alpha1.ClusterRoleSpec {
+		return authv1alpha1.ClusterRoleSpec{Elevation: &authv1alpha1.Elevation{Until: *hour(hours)}}
+	}
+	extended := []grant{{kind: "ClusterRole", name: "cluster-admin", extended: true}}
+
+	DescribeTable("returns grants whose time limit is lengthened or removed",
+		func(current, previous authv1alpha1.ClusterRoleSpec, expected []grant) {
+			Expect(newGrants(admin(current), admin(previous))).To(Equal(expected))
+		},
+		Entry("an unchanged elevation", elevated(10), elevated(10), nil),
+		Entry("a shortened elevation", elevated(9), elevated(10), nil),
+		Entry("a lengthened elevation", elevated(11), elevated(10), extended),
+		Entry("a removed elevation", authv1alpha1.ClusterRoleSpec{}, elevated(10), extended),
+		Entry("an elevation of a permanent grant", elevated(10), authv1alpha1.ClusterRoleSpec{}, nil),
+		Entry("a shortened expiresAt",
+			authv1alpha1.ClusterRoleSpec{ExpiresAt: hour(9)}, authv1alpha1.ClusterRoleSpec{ExpiresAt: hour(10)}, nil),
+		Entry("a lengthened expiresAt",
+			authv1alpha1.ClusterRoleSpec{ExpiresAt: hour(11)}, authv1alpha1.ClusterRoleSpec{ExpiresAt: hour(10)}, extended),
+		Entry("a removed expiresAt",
+			authv1alpha1.ClusterRoleSpec{}, authv1alpha1.ClusterRoleSpec{ExpiresAt: hour(10)}, extended),
+		Entry("a shortened duration",
+			authv1alpha1.ClusterRoleSpec{Duration: duration(1)}, authv1alpha1.ClusterRoleSpec{Duration: duration(8)}, nil),
+		Entry("a lengthened duration",
+			authv1alpha1.ClusterRoleSpec{Duration: duration(9)}, authv1alpha1.ClusterRoleSpec{Duration: duration(8)}, extended),
+		Entry("a removed duration",
+			authv1alpha1.ClusterRoleSpec{}, authv1alpha1.ClusterRoleSpec{Duration: duration(8)}, extended),
+		Entry("an expiresAt traded for a duration",
+			authv1alpha1.ClusterRoleSpec{Duration: duration(1)}, authv1alpha1.ClusterRoleSpec{ExpiresAt: hour(10)}, extended),
+	)
+
+	It("returns Roles whose time limit is lengthened", func() {
+		role := func(expiresAt *metav1.Time) *authv1alpha1.User {
+			return user([]authv1alpha1.RoleSpec{{Namespace: "dev", ExistingRole: "developer", ExpiresAt: expiresAt}})
+		}
+		Expect(newGrants(role(hour(11)), role(hour(10)))).To(Equal([]grant{
+			{kind: "Role", name: "developer", namespace: "dev", extended: true},
+		}))
+		Expect(newGrants(role(hour(10)), role(hour(10)))).To(BeEmpty())
+	})
+
+	It("keeps a grant listed twice if either limit still covers it", func() {
+		previous := admin(elevated(10))
+		previous.Spec.ClusterRoles = append(previous.Spec.ClusterRoles, authv1alpha1.ClusterRoleSpec{
+			ExistingClusterRole: "cluster-admin", Elevation: &authv1alpha1.Elevation{Until: *hour(12)},
+		})
+		Expect(newGrants(admin(elevated(12)), previous)).To(BeEmpty())
+		Expect(newGrants(admin(elevated(13)), previous)).To(Equal(extended))
+	})
+})
+
+var _ = Describe("validateEscalation", func() {
+	pods := rbacv1.PolicyRule{APIGroups: []string{""}, Resources: []string{"pods", "pods/log"}, Verbs: []string{"get"}}
+	wildcard := rbacv1.PolicyRule{APIGroups: []string{"*"}, Resources: []string{"*"}, Verbs: []string{"*"}}
+	metrics := rbacv1.PolicyRule{NonResourceURLs: []string{"/metrics"}, Verbs: []string{"get"}}
+	objects := func() []client.Object {
+		return []client.Object{
+			&rbacv1.ClusterRole{ObjectMeta: metav1.ObjectMeta{Name: "pod-reader"}, Rules: []rbacv1.PolicyRule{pods}},
+			&rbacv1.ClusterRole{ObjectMeta: metav1.ObjectMeta{Name: "cluster-admin"}, Rules: []rbacv1.PolicyRule{wildcard}},
+			&rbacv1.ClusterRole{ObjectMeta: metav1.ObjectMeta{Name: "monitoring"}, Rules: []rbacv1.PolicyRule{metrics}},
+			&rbacv1.Role{ObjectMeta: metav1.ObjectMeta{Name: "developer", Namespace: "dev"}, Rules: []rbacv1.PolicyRule{pods, metrics}},
+		}
+	}
+	podAttributes := func(namespace string) []authorizationv1.ResourceAttributes {
+		return []authorizationv1.ResourceAttributes{
+			{Namespace: namespace, Verb: "get", Resource: "pods"},
+			{Namespace: namespace, Verb: "get", Resource: "pods", Subresource: "log"},
+		}
+	}
+
+	DescribeTable("checks the requester may grant every new role",
+		func(spec authv1alpha1.UserSpec, previous *authv1alpha1.UserSpec,
+			allow func(authorizationv1.SubjectAccessReviewSpec) bool, denied string) {
+			w := newUserWebhook(allow, nil, objects()...)
+			user := &authv1alpha1.User{ObjectMeta: metav1.ObjectMeta{Name: "jane"}, Spec: spec}
+			var previousUser *authv1alpha1.User
+			if previous != nil {
+				previousUser = &authv1alpha1.User{ObjectMeta: metav1.ObjectMeta{Name: "jane"}, Spec: *previous}
+			}
+			err := w.validateEscalation(context.Background(), requester, user, previousUser)
+			if denied == "" {
+				Expect(err).NotTo(HaveOccurred())
+			} else {
+				Expect(err).To(MatchError(ContainSubstring(denied)))
+			}
+		},
+		Entry("allowed with bind on the role",
+			authv1alpha1.UserSpec{ClusterRoles: []authv1alpha1.ClusterRoleSpec{{ExistingClusterRole: "cluster-admin"}}}, nil,
+			allowAttributes(authorizationv1.ResourceAttributes{
+				Verb: "bind", Group: "rbac.authorization.k8s.io", Resource: "clusterroles", Name: "cluster-admin",
+			}), ""),
+		Entry("allowed holding every permission",
+			authv1alpha1.UserSpec{ClusterRoles: []authv1alpha1.ClusterRoleSpec{{ExistingClusterRole: "pod-reader"}}}, nil,
+			allowAttributes(podAttributes("")...), ""),
+		Entry("denied missing a subresource",
+			authv1alpha1.UserSpec{ClusterRoles: []authv1alpha1.ClusterRoleSpec{{ExistingClusterRole: "pod-reader"}}}, nil,
+			allowAttributes(podAttributes("")[0]), "it allows get on pods/log"),
+		Entry("allowed holding the role's permissions only in its namespace",
+			authv1alpha1.UserSpec{Roles: []authv1alpha1.RoleSpec{{Namespace: "dev", ExistingRole: "developer"}}}, nil,
+			allowAttributes(podAttributes("dev")...), ""),
+		Entry("denied holding them in another namespace",
+			authv1alpha1.UserSpec{Roles: []authv1alpha1.RoleSpec{{Namespace: "dev", ExistingRole: "developer"}}}, nil,
+			allowAttributes(podAttributes("prod")...), "may not grant role 'developer' in namespace 'dev'"),
+		Entry("denied wildcard rules without the wildcard permission",
+			authv1alpha1.UserSpec{ClusterRoles: []authv1alpha1.ClusterRoleSpec{{ExistingClusterRole: "cluster-admin"}}}, nil,
+			allowAttributes(podAttributes("")...), "it allows * on *.*"),
+		Entry("allowed wildcard rules with the wildcard permission",
+			authv1alpha1.UserSpec{ClusterRoles: []authv1alpha1.ClusterRoleSpec{{ExistingClusterRole: "cluster-admin"}}}, nil,
+			allowAttributes(authorizationv1.ResourceAttributes{Verb: "*", Group: "*", Resource: "*"}), ""),
+		Entry("denied non-resource URLs bound cluster-wide",
+			authv1alpha1.UserSpec{ClusterRoles: []authv1alpha1.ClusterRoleSpec{{ExistingClusterRole: "monitoring"}}}, nil,
+			allowNone, "it allows get on /metrics"),
+		Entry("grants already held are not checked again",
+			authv1alpha1.UserSpec{ClusterRoles: []authv1alpha1.ClusterRoleSpec{{ExistingClusterRole: "cluster-admin"}}},
+			&authv1alpha1.UserSpec{ClusterRoles: []authv1alpha1.ClusterRoleSpec{{ExistingClusterRole: "cluster-admin"}}},
+			allowNone, ""),
+		Entry("denied removing the elevation of a grant without bind",
+			authv1alpha1.UserSpec{ClusterRoles: []authv1alpha1.ClusterRoleSpec{{ExistingClusterRole: "cluster-admin"}}},
+			&authv1alpha1.UserSpec{ClusterRoles: []authv1alpha1.ClusterRoleSpec{{ExistingClusterRole: "cluster-admin",
+				Elevation: &authv1alpha1.Elevation{Until: metav1.NewTime(time.Now().Add(time.Hour))}}}},
+			allowNone, "may not grant clusterrole 'cluster-admin' for longer"),
+		Entry("denied lengthening the expiresAt of a grant without bind",
+			authv1alpha1.UserSpec{Roles: []authv1alpha1.RoleSpec{{Namespace: "dev", ExistingRole: "developer",
+				ExpiresAt: &metav1.Time{Time: time.Now().Add(48 * time.Hour)}}}},
+			&authv1alpha1.UserSpec{Roles: []authv1alpha1.RoleSpec{{Namespace: "dev", ExistingRole: "developer",
+				ExpiresAt: &metav1.Time{Time: time.Now().Add(-time.Hour)}}}},
+			allowNone, "may not grant role 'developer' in namespace 'dev' for longer"),
+		Entry("allowed lengthening the duration of a grant with bind",
+			authv1alpha1.UserSpec{ClusterRoles: []authv1alpha1.ClusterRoleSpec{{ExistingClusterRole: "cluster-admin",
+				Duration: &metav1.Duration{Duration: 24 * time.Hour}}}},
+			&authv1alpha1.UserSpec{ClusterRoles: []authv1alpha1.ClusterRoleSpec{{ExistingClusterRole: "cluster-admin",
+				Duration: &metav1.Duration{Duration: time.Hour}}}},
+			allowAttributes(authorizationv1.ResourceAttributes{
+				Verb: "bind", Group: "rbac.authorization.k8s.io", Resource: "clusterroles", Name: "cluster-admin",
+			}), ""),
+		Entry("denied roles of a namespace to be created without create on namespaces",
+			authv1alpha1.UserSpec{Roles: []authv1alpha1.RoleSpec{{Namespace: "new", ExistingRole: "developer", CreateNamespace: true}}}, nil,
+			allowAttributes(authorizationv1.ResourceAttributes{
+				Namespace: "new", Verb: "bind", Group: "rbac.authorization.k8s.io", Resource: "roles", Name: "developer",
+			}), "the user may not create namespaces"),
+	)
+
+	It("checks the grants of the User's template", func() {
+		template := &authv1alpha1.UserTemplate{
+			ObjectMeta: metav1.ObjectMeta{Name: "admins"},
+			Spec:       authv1alpha1.UserTemplateSpec{ClusterRoles: []authv1alpha1.ClusterRoleSpec{{ExistingClusterRole: "cluster-admin"}}},
+		}
+		user := &authv1alpha1.User{
+			ObjectMeta: metav1.ObjectMeta{Name: "jane"},
+			Spec:       authv1alpha1.UserSpec{TemplateRef: &authv1alpha1.UserTemplateReference{Name: "admins"}},
+		}
+		var reviews []authorizationv1.SubjectAccessReviewSpec
+		w := newUserWebhook(allowNone, &reviews, append(objects(), template)...)
+		_, err := w.ValidateCreate(admissionContext(admissionv1.Create), user)
+		Expect(err).To(MatchError(ContainSubstring("may not grant clusterrole 'cluster-admin'")))
+		Expect(reviews).NotTo(BeEmpty())
+	})
+
+	It("checks the grants of the Teams selecting the User by its labels", func() {
+		admins := &authv1alpha1.Team{
+			ObjectMeta: metav1.ObjectMeta{Name: "admins"},
+			Spec: authv1alpha1.TeamSpec{
+				ClusterRoles:   []authv1alpha1.ClusterRoleSpec{{ExistingClusterRole: "cluster-admin"}},
+				MemberSelector: &metav1.LabelSelector{MatchLabels: map[string]string{"team": "admins"}},
+			},
+		}
+		w := newUserWebhook(allowNone, nil, append(objects(), admins)...)
+		user := &authv1alpha1.User{ObjectMeta: metav1.ObjectMeta{Name: "jane"}}
+		labelled := user.DeepCopy()
+		labelled.Labels = map[string]string{"team": "admins"}
+
+		_, err := w.ValidateCreate(admissionContext(admissionv1.Create), labelled)
+		Expect(err).To(MatchError(ContainSubstring("may not grant clusterrole 'cluster-admin'")))
+		_, err = w.ValidateUpdate(admissionContext(admissionv1.Update), user, labelled)
+		Expect(err).To(MatchError(ContainSubstring("may not grant clusterrole 'cluster-admin'")))
+
+		// Members already selected keep the team's grants without bind
+		_, err = w.ValidateUpdate(admissionContext(admissionv1.Update), labelled, labelled.DeepCopy())
+		Expect(err).NotTo(HaveOccurred())
+		_, err = w.ValidateCreate(admissionContext(admissionv1.Create), user)
+		Expect(err).NotTo(HaveOccurred())
+	})
+
+	It("checks the grants of the Teams a Team includes and rejects include cycles", func() {
+		baseline := &authv1alpha1.Team{
+			ObjectMeta: metav1.ObjectMeta{Name: "baseline"},
+			Spec:       authv1alpha1.TeamSpec{ClusterRoles: []authv1alpha1.ClusterRoleSpec{{ExistingClusterRole: "cluster-admin"}}},
+		}
+		platform := &authv1alpha1.Team{
+			ObjectMeta: metav1.ObjectMeta{Name: "platform"},
+			Spec:       authv1alpha1.TeamSpec{IncludeTeams: []string{"payments"}},
+		}
+		w := &TeamWebhook{users: newUserWebhook(allowNone, nil, append(objects(), baseline, platform)...)}
+		payments := &authv1alpha1.Team{
+			ObjectMeta: metav1.ObjectMeta{Name: "payments"},
+			Spec:       authv1alpha1.TeamSpec{IncludeTeams: []string{"baseline"}},
+		}
+		_, err := w.ValidateCreate(admissionContext(admissionv1.Create), payments)
+		Expect(err).To(MatchError(ContainSubstring("may not grant clusterrole 'cluster-admin'")))
+
+		payments.Spec.IncludeTeams = []string{"platform"}
+		_, err = w.ValidateCreate(admissionContext(admissionv1.Create), payments)
+		Expect(err).To(MatchError("spec.includeTeams forms a cycle: payments -> platform -> payments"))
+	})
+})
+
+var _ = Describe("validateUsername", func() {
+	namespace := operatorconfig.Namespace()
+
+	DescribeTable("rejects names unusable for a User",
+		func(name string, objects []client.Object, rejected string) {
+			err := newUserWebhook(allowNone, nil, objects...).validateUsername(context.Background(), name)
+			if rejected == "" {
+				Expect(err).NotTo(HaveOccurred())
+			} else {
+				Expect(err).To(MatchError(ContainSubstring(rejected)))
+			}
+		},
+		Entry("a plain name", "jane", nil, ""),
+		Entry("the system: prefix", "system:admin", nil, `reserved prefix "system:"`),
+		Entry("the kube- prefix", "kube-scheduler", nil, `reserved prefix "kube-"`),
+		Entry("an invalid DNS label", "Jane.Doe", nil, "invalid user name"),
+		Entry("a name taken by another ServiceAccount", "jane", []client.Object{
+			&corev1.ServiceAccount{ObjectMeta: metav1.ObjectMeta{Name: "jane", Namespace: namespace}},
+		}, "collides with ServiceAccount"),
+		Entry("the user's own anchor", "jane", []client.Object{
+			&corev1.ServiceAccount{ObjectMeta: metav1.ObjectMeta{Name: "jane", Namespace: namespace,
+				Labels: map[string]string{"auth.openkube.io/user": "jane"}}},
+		}, ""),
+	)
+})
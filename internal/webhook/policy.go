@@ -0,0 +1,112 @@
+/*
+Copyright 2025.
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+*/
+
+package webhook
+
+import (
+	"context"
+	"fmt"
+
+	authv1alpha1 "github.com/openkube-hub/KubeUser/api/v1alpha1"
+	"github.com/openkube-hub/KubeUser/internal/policy"
+	authenticationv1 "k8s.io/api/authentication/v1"
+	corev1 "k8s.io/api/core/v1"
+	rbacv1 "k8s.io/api/rbac/v1"
+	"k8s.io/apimachinery/pkg/types"
+	"sigs.k8s.io/controller-runtime/pkg/webhook/admission"
+)
+
+// validatePolicies checks the grants a User receives against the ClusterPolicies, including
+// policies scoped to the requester. Grants carried over from previous, with a time limit no
+// longer than before, are left to the controller, which removes the bindings of grants a
+// policy no longer allows. Break-glass Users are exempt.
+func (w *UserWebhook) validatePolicies(ctx context.Context, requester authenticationv1.UserInfo,
+	user, previous *authv1alpha1.User) error {
+	if user.Spec.BreakGlass {
+		return nil
+	}
+	grants := newGrants(user, previous)
+	if len(grants) == 0 {
+		return nil
+	}
+	policies, err := policy.List(ctx, w)
+	if err != nil {
+		return err
+	}
+	if len(policies) == 0 {
+		return nil
+	}
+
+	creator := &policy.Creator{Username: requester.Username, Groups: requester.Groups}
+	for _, g := range grants {
+		// Roles in namespaces that do not exist yet have no labels to match; the controller
+		// checks them before it binds them. It does not know the creator though, so the
+		// namespace rules are checked here, by name.
+		if pending, err := w.pendingNamespace(ctx, user, g); err != nil {
+			return err
+		} else if pending {
+			if err := policy.CheckNamespace(policies, policy.Grant{Name: g.name, Namespace: g.namespace}, creator); err != nil {
+				return err
+			}
+			continue
+		}
+		labels, err := w.grantLabels(ctx, g)
+		if err != nil {
+			return err
+		}
+		var namespaceLabels map[string]string
+		if g.kind == "Role" {
+			if namespaceLabels, err = w.namespaceLabels(ctx, g.namespace); err != nil {
+				return err
+			}
+		}
+		err = policy.Check(policies, policy.Grant{
+			ClusterRole:     g.kind == "ClusterRole",
+			Name:            g.name,
+			Namespace:       g.namespace,
+			Labels:          labels,
+			NamespaceLabels: namespaceLabels,
+		}, creator)
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// validateRequesterPolicies runs validatePolicies for the requester of the admission request in ctx
+func (w *UserWebhook) validateRequesterPolicies(ctx context.Context, user, previous *authv1alpha1.User) error {
+	req, err := admission.RequestFromContext(ctx)
+	if err != nil {
+		return fmt.Errorf("cannot check the user's roles against ClusterPolicies: %w", err)
+	}
+	return w.validatePolicies(ctx, req.UserInfo, user, previous)
+}
+
+// grantLabels returns the labels of the granted Role or ClusterRole
+func (w *UserWebhook) grantLabels(ctx context.Context, g grant) (map[string]string, error) {
+	if g.kind == "Role" {
+		var role rbacv1.Role
+		if err := w.Get(ctx, types.NamespacedName{Name: g.name, Namespace: g.namespace}, &role); err != nil {
+			return nil, fmt.Errorf("failed to read %s: %w", g, err)
+		}
+		return role.Labels, nil
+	}
+	var clusterRole rbacv1.ClusterRole
+	if err := w.Get(ctx, types.NamespacedName{Name: g.name}, &clusterRole); err != nil {
+		return nil, fmt.Errorf("failed to read %s: %w", g, err)
+	}
+	return clusterRole.Labels, nil
+}
+
+// namespaceLabels returns the labels of the namespace of a Role grant
+func (w *UserWebhook) namespaceLabels(ctx context.Context, name string) (map[string]string, error) {
+	var ns corev1.Namespace
+	if err := w.Get(ctx, types.NamespacedName{Name: name}, &ns); err != nil {
+		return nil, fmt.Errorf("failed to read namespace '%s': %w", name, err)
+	}
+	return ns.Labels, nil
+}
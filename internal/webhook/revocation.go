@@ -0,0 +1,85 @@
+/*
+Copyright 2025.
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+*/
+
+package webhook
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	authv1alpha1 "github.com/openkube-hub/KubeUser/api/v1alpha1"
+	"k8s.io/apimachinery/pkg/api/equality"
+	"k8s.io/apimachinery/pkg/runtime"
+	"sigs.k8s.io/controller-runtime/pkg/webhook"
+	"sigs.k8s.io/controller-runtime/pkg/webhook/admission"
+)
+
+// +kubebuilder:webhook:path=/mutate-auth-openkube-io-v1alpha1-user,mutating=true,failurePolicy=fail,sideEffects=None,groups=auth.openkube.io,resources=users,verbs=create;update,versions=v1alpha1,name=muser.auth.openkube.io,admissionReviewVersions=v1
+
+// Compile-time check to ensure UserWebhook implements admission.CustomDefaulter
+var _ webhook.CustomDefaulter = &UserWebhook{}
+
+// Default implements admission.CustomDefaulter. It records who revoked a User and when in
+// annotations the controller copies into status.revocation, and who last changed its spec.
+func (w *UserWebhook) Default(ctx context.Context, obj runtime.Object) error {
+	user, ok := obj.(*authv1alpha1.User)
+	if !ok {
+		return fmt.Errorf("expected User object, got %T", obj)
+	}
+	req, err := admission.RequestFromContext(ctx)
+	if err != nil {
+		return fmt.Errorf("cannot record who revoked the user: %w", err)
+	}
+	var previous *authv1alpha1.User
+	if len(req.OldObject.Raw) > 0 {
+		previous = &authv1alpha1.User{}
+		if err := w.decoder.DecodeRaw(req.OldObject, previous); err != nil {
+			return fmt.Errorf("failed to decode previous User: %w", err)
+		}
+	}
+	stampRevocation(user, previous, req.UserInfo.Username, time.Now())
+	stampChangedBy(user, previous, req.UserInfo.Username)
+	return nil
+}
+
+// stampChangedBy records the requester as the one who changed the User when it is created or
+// its spec changes, and otherwise keeps the recorded identity
+func stampChangedBy(user, previous *authv1alpha1.User, requester string) {
+	by := requester
+	if previous != nil && equality.Semantic.DeepEqual(previous.Spec, user.Spec) {
+		by = previous.Annotations[authv1alpha1.ChangedByAnnotation]
+	}
+	if by == "" {
+		delete(user.Annotations, authv1alpha1.ChangedByAnnotation)
+		return
+	}
+	if user.Annotations == nil {
+		user.Annotations = map[string]string{}
+	}
+	user.Annotations[authv1alpha1.ChangedByAnnotation] = by
+}
+
+// stampRevocation sets the revocation annotations when spec.revoked is set, keeps them while
+// it stays set and removes them once it is cleared. Annotations supplied by the requester
+// are never trusted.
+func stampRevocation(user, previous *authv1alpha1.User, requester string, now time.Time) {
+	if !user.Spec.Revoked {
+		delete(user.Annotations, authv1alpha1.RevokedByAnnotation)
+		delete(user.Annotations, authv1alpha1.RevokedAtAnnotation)
+		return
+	}
+	by, at := requester, now.UTC().Format(time.RFC3339)
+	if previous != nil && previous.Spec.Revoked && previous.Annotations[authv1alpha1.RevokedByAnnotation] != "" {
+		by = previous.Annotations[authv1alpha1.RevokedByAnnotation]
+		at = previous.Annotations[authv1alpha1.RevokedAtAnnotation]
+	}
+	if user.Annotations == nil {
+		user.Annotations = map[string]string{}
+	}
+	user.Annotations[authv1alpha1.RevokedByAnnotation] = by
+	user.Annotations[authv1alpha1.RevokedAtAnnotation] = at
+}
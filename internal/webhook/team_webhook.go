@@ -0,0 +1,225 @@
+/*
+Copyright 2025.
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+*/
+
+package webhook
+
+import (
+	"context"
+	"fmt"
+	"slices"
+	"strings"
+	"time"
+
+	authv1alpha1 "github.com/openkube-hub/KubeUser/api/v1alpha1"
+	"github.com/openkube-hub/KubeUser/internal/team"
+	"github.com/openkube-hub/KubeUser/internal/tracing"
+	"go.opentelemetry.io/otel/attribute"
+	authorizationv1 "k8s.io/api/authorization/v1"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/equality"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/types"
+	ctrl "sigs.k8s.io/controller-runtime"
+	logf "sigs.k8s.io/controller-runtime/pkg/log"
+	"sigs.k8s.io/controller-runtime/pkg/webhook"
+	"sigs.k8s.io/controller-runtime/pkg/webhook/admission"
+)
+
+// TeamWebhook validates Team resources. A Team's roles are bound for its members without an
+// admission request for their Users, so they are checked here like a User's own roles.
+type TeamWebhook struct {
+	users *UserWebhook
+}
+
+// +kubebuilder:webhook:path=/validate-auth-openkube-io-v1alpha1-team,mutating=false,failurePolicy=fail,sideEffects=None,groups=auth.openkube.io,resources=teams,verbs=create;update,versions=v1alpha1,name=team.auth.openkube.io,admissionReviewVersions=v1
+
+// SetupWithManager registers the webhook with the manager
+func (w *TeamWebhook) SetupWithManager(mgr ctrl.Manager) error {
+	w.users = &UserWebhook{Client: mgr.GetClient()}
+
+	return ctrl.NewWebhookManagedBy(mgr).
+		For(&authv1alpha1.Team{}).
+		WithValidator(w).
+		Complete()
+}
+
+// Compile-time check to ensure TeamWebhook implements admission.CustomValidator
+var _ webhook.CustomValidator = &TeamWebhook{}
+
+// ValidateCreate implements admission.CustomValidator
+func (w *TeamWebhook) ValidateCreate(ctx context.Context, obj runtime.Object) (admission.Warnings, error) {
+	t, ok := obj.(*authv1alpha1.Team)
+	if !ok {
+		return nil, fmt.Errorf("expected a Team object but got %T", obj)
+	}
+	return w.validate(ctx, t, nil)
+}
+
+// ValidateUpdate implements admission.CustomValidator
+func (w *TeamWebhook) ValidateUpdate(ctx context.Context, oldObj, newObj runtime.Object) (admission.Warnings, error) {
+	t, ok := newObj.(*authv1alpha1.Team)
+	if !ok {
+		return nil, fmt.Errorf("expected a Team object but got %T", newObj)
+	}
+	if t.DeletionTimestamp != nil {
+		return nil, nil
+	}
+	previous, _ := oldObj.(*authv1alpha1.Team)
+	return w.validate(ctx, t, previous)
+}
+
+// ValidateDelete implements admission.CustomValidator
+func (w *TeamWebhook) ValidateDelete(ctx context.Context, obj runtime.Object) (admission.Warnings, error) {
+	return nil, nil
+}
+
+// validate checks the Team's member names, the roles it grants and the namespaces it creates.
+// Grants and namespaces carried over from previous are not checked again, unless a member was
+// added: the new member receives all of the team's grants.
+func (w *TeamWebhook) validate(ctx context.Context, t, previous *authv1alpha1.Team) (admission.Warnings, error) {
+	ctx, span := tracing.Start(ctx, "TeamWebhook.validate", attribute.String("team", t.Name))
+	defer span.End()
+	logger := logf.FromContext(ctx).WithName("team-webhook")
+	logger.Info("Validating Team", "team", t.Name)
+
+	for _, member := range t.Spec.Members {
+		if err := w.users.validateUsername(ctx, member.Name); err != nil {
+			return nil, fmt.Errorf("member %s: %w", member.Name, err)
+		}
+	}
+	if t.Spec.MemberSelector != nil {
+		if _, err := metav1.LabelSelectorAsSelector(t.Spec.MemberSelector); err != nil {
+			return nil, fmt.Errorf("invalid memberSelector: %w", err)
+		}
+	}
+
+	cycle, err := team.IncludeCycle(ctx, w.users, t)
+	if err != nil {
+		return nil, err
+	}
+	if cycle != nil {
+		return nil, fmt.Errorf("spec.includeTeams forms a cycle: %s", strings.Join(cycle, " -> "))
+	}
+
+	grantee, err := w.asGrantee(ctx, t)
+	if err != nil {
+		return nil, err
+	}
+	var previousGrantee *authv1alpha1.User
+	if previous != nil && !addsMembers(t, previous) {
+		if previousGrantee, err = w.asGrantee(ctx, previous); err != nil {
+			return nil, err
+		}
+	}
+	if err := w.users.validateRoles(ctx, grantee.Spec.Roles); err != nil {
+		return nil, err
+	}
+	if err := w.users.validateClusterRoles(ctx, grantee.Spec.ClusterRoles); err != nil {
+		return nil, err
+	}
+	var previousClusterRoles []authv1alpha1.ClusterRoleSpec
+	if previous != nil {
+		previousClusterRoles = previous.Spec.ClusterRoles
+	}
+	warnings, err := validateElevations(grantee.Spec.ClusterRoles, previousClusterRoles, time.Now())
+	if err != nil {
+		return nil, err
+	}
+	if err := validateGrantDurations(grantee.Spec); err != nil {
+		return nil, err
+	}
+	if err := w.validateNamespaces(ctx, t, previous); err != nil {
+		return nil, err
+	}
+	if err := w.users.validateRequesterEscalation(ctx, grantee, previousGrantee); err != nil {
+		return nil, err
+	}
+	if err := w.users.validateRequesterPolicies(ctx, grantee, previousGrantee); err != nil {
+		return nil, err
+	}
+	return warnings, nil
+}
+
+// validateNamespaces requires the requester to be allowed to create the team's namespaces
+// that do not exist yet, since the controller creates them on the team's behalf
+func (w *TeamWebhook) validateNamespaces(ctx context.Context, t, previous *authv1alpha1.Team) error {
+	req, err := admission.RequestFromContext(ctx)
+	if err != nil {
+		return fmt.Errorf("cannot verify the requester may create the team's namespaces: %w", err)
+	}
+	for _, name := range t.Spec.Namespaces {
+		if previous != nil && slices.Contains(previous.Spec.Namespaces, name) {
+			continue
+		}
+		var ns corev1.Namespace
+		err := w.users.Get(ctx, types.NamespacedName{Name: name}, &ns)
+		if err == nil {
+			continue
+		}
+		if !apierrors.IsNotFound(err) {
+			return fmt.Errorf("failed to read namespace '%s': %w", name, err)
+		}
+		creatable, err := w.users.allowed(ctx, req.UserInfo, &authorizationv1.ResourceAttributes{
+			Verb:     "create",
+			Resource: "namespaces",
+		}, nil)
+		if err != nil {
+			return err
+		}
+		if !creatable {
+			return fmt.Errorf("user '%s' may not create namespace '%s' for the team", req.UserInfo.Username, name)
+		}
+	}
+	return nil
+}
+
+// asGrantee returns a User holding the roles t and the Teams it includes grant its members, for
+// the checks of the User webhook
+func (w *TeamWebhook) asGrantee(ctx context.Context, t *authv1alpha1.Team) (*authv1alpha1.User, error) {
+	teams, err := team.Include(ctx, w.users, []authv1alpha1.Team{*t})
+	if err != nil {
+		return nil, err
+	}
+	user := &authv1alpha1.User{}
+	team.Apply(user, teams)
+	return user, nil
+}
+
+// addsMembers reports whether t lists a member previous did not. A changed directory group or
+// member selector may add any number of members.
+func addsMembers(t, previous *authv1alpha1.Team) bool {
+	if !equality.Semantic.DeepEqual(t.Spec.DirectorySync, previous.Spec.DirectorySync) ||
+		!equality.Semantic.DeepEqual(t.Spec.MemberSelector, previous.Spec.MemberSelector) {
+		return true
+	}
+	for _, member := range t.Spec.Members {
+		if !team.IsMember(previous, member.Name) {
+			return true
+		}
+	}
+	return false
+}
+
+// withSelectingTeams merges the roles of the Teams whose member selector matches user's labels,
+// and of the Teams they include, into a copy of user. Labelling a User joins those Teams without an update of the Team, so
+// their grants are checked against the identity changing the User.
+func (w *UserWebhook) withSelectingTeams(ctx context.Context, user *authv1alpha1.User) (*authv1alpha1.User, error) {
+	if user == nil {
+		return nil, nil
+	}
+	teams, err := team.Selecting(ctx, w, user.Labels)
+	if err != nil || len(teams) == 0 {
+		return user, err
+	}
+	if teams, err = team.Include(ctx, w, teams); err != nil {
+		return nil, err
+	}
+	merged := user.DeepCopy()
+	team.Apply(merged, teams)
+	return merged, nil
+}
@@ -0,0 +1,40 @@
+/*
+Copyright 2025.
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+*/
+
+package webhook
+
+import (
+	"context"
+
+	authv1alpha1 "github.com/openkube-hub/KubeUser/api/v1alpha1"
+	"github.com/openkube-hub/KubeUser/internal/usertemplate"
+)
+
+// withTemplate returns user merged with its UserTemplate, as the controller binds it, so the
+// template's grants are checked like the User's own. Users referencing a template that does
+// not exist are rejected.
+func (w *UserWebhook) withTemplate(ctx context.Context, user *authv1alpha1.User) (*authv1alpha1.User, error) {
+	template, err := usertemplate.Get(ctx, w, user)
+	if err != nil || template == nil {
+		return user, err
+	}
+	merged := user.DeepCopy()
+	usertemplate.Apply(merged, template)
+	return merged, nil
+}
+
+// previousWithTemplate merges the template into the previous version of a User. Its grants are
+// only used to skip checks for grants the User already had, so a missing template merges nothing.
+func (w *UserWebhook) previousWithTemplate(ctx context.Context, previous *authv1alpha1.User) *authv1alpha1.User {
+	if previous == nil {
+		return nil
+	}
+	merged, err := w.withTemplate(ctx, previous)
+	if err != nil {
+		return previous
+	}
+	return merged
+}
@@ -8,14 +8,31 @@ package webhook
 
 import (
 	"context"
+	"crypto/x509"
 	"fmt"
-	"net/http"
+	"net/url"
+	"strings"
+	"time"
 
 	authv1alpha1 "github.com/openkube-hub/KubeUser/api/v1alpha1"
+	"github.com/openkube-hub/KubeUser/internal/credentials"
+	"github.com/openkube-hub/KubeUser/internal/issuer"
+	"github.com/openkube-hub/KubeUser/internal/operatorconfig"
+	"github.com/openkube-hub/KubeUser/internal/pgp"
+	"github.com/openkube-hub/KubeUser/internal/schedule"
+	"github.com/openkube-hub/KubeUser/internal/sshcert"
+	"github.com/openkube-hub/KubeUser/internal/tracing"
+	"go.opentelemetry.io/otel/attribute"
+	authenticationv1 "k8s.io/api/authentication/v1"
+	authorizationv1 "k8s.io/api/authorization/v1"
+	corev1 "k8s.io/api/core/v1"
 	rbacv1 "k8s.io/api/rbac/v1"
+	"k8s.io/apimachinery/pkg/api/equality"
 	apierrors "k8s.io/apimachinery/pkg/api/errors"
 	"k8s.io/apimachinery/pkg/runtime"
 	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/apimachinery/pkg/util/validation"
+	"k8s.io/client-go/tools/record"
 	ctrl "sigs.k8s.io/controller-runtime"
 	"sigs.k8s.io/controller-runtime/pkg/client"
 	logf "sigs.k8s.io/controller-runtime/pkg/log"
@@ -27,37 +44,14 @@ import (
 type UserWebhook struct {
 	client.Client
 	decoder admission.Decoder
+	// recorder records rejected updates on the existing User
+	recorder record.EventRecorder
+	// MemberClusters allows spec.clusters, with the MultiCluster feature gate
+	MemberClusters bool
 }
 
-// +kubebuilder:webhook:path=/validate-auth-openkube-io-v1alpha1-user,mutating=false,failurePolicy=fail,sideEffects=None,groups=auth.openkube.io,resources=users,verbs=create;update,versions=v1alpha1,name=user.auth.openkube.io,admissionReviewVersions=v1
-
-func (w *UserWebhook) Handle(ctx context.Context, req admission.Request) admission.Response {
-	logger := logf.FromContext(ctx).WithName("user-webhook")
-	logger.Info("Validating User resource", "name", req.Name, "namespace", req.Namespace, "operation", req.Operation)
-
-	user := &authv1alpha1.User{}
-	if err := w.decoder.Decode(req, user); err != nil {
-		logger.Error(err, "Failed to decode User resource")
-		return admission.Errored(http.StatusBadRequest, fmt.Errorf("failed to decode User: %w", err))
-	}
-
-	// Validate Role references
-	if err := w.validateRoles(ctx, user.Spec.Roles); err != nil {
-		logger.Error(err, "Role validation failed", "user", user.Name)
-		return admission.Denied(err.Error())
-	}
-
-	// Validate ClusterRole references
-	if err := w.validateClusterRoles(ctx, user.Spec.ClusterRoles); err != nil {
-		logger.Error(err, "ClusterRole validation failed", "user", user.Name)
-		return admission.Denied(err.Error())
-	}
-
-	logger.Info("User resource validation successful", "user", user.Name)
-	return admission.Allowed("User resource validation successful")
-}
-
-// validateRoles checks that all referenced Roles exist in their respective namespaces
+// validateRoles checks that all referenced Roles exist in their respective namespaces. Roles
+// with createNamespace are skipped while their namespace does not exist yet.
 func (w *UserWebhook) validateRoles(ctx context.Context, roles []authv1alpha1.RoleSpec) error {
 	for _, roleSpec := range roles {
 		var role rbacv1.Role
@@ -68,6 +62,14 @@ func (w *UserWebhook) validateRoles(ctx context.Context, roles []authv1alpha1.Ro
 
 		if err != nil {
 			if apierrors.IsNotFound(err) {
+				// The Role cannot exist before the namespace the controller creates for it
+				if roleSpec.CreateNamespace {
+					var ns corev1.Namespace
+					nsErr := w.Get(ctx, types.NamespacedName{Name: roleSpec.Namespace}, &ns)
+					if apierrors.IsNotFound(nsErr) {
+						continue
+					}
+				}
 				return fmt.Errorf("role '%s' not found in namespace '%s'",
 					roleSpec.ExistingRole, roleSpec.Namespace)
 			}
@@ -98,13 +100,348 @@ func (w *UserWebhook) validateClusterRoles(ctx context.Context, clusterRoles []a
 	return nil
 }
 
+// validateElevations requires new or changed elevations to end in the future. Elevations carried
+// over unchanged from previous may already have ended; they only produce a warning. Whether the
+// requester may lengthen or drop an elevation is checked with the other grants, see newGrants.
+func validateElevations(clusterRoles, previous []authv1alpha1.ClusterRoleSpec, now time.Time) (admission.Warnings, error) {
+	previousUntil := make(map[string]time.Time)
+	for _, spec := range previous {
+		if spec.Elevation != nil {
+			previousUntil[spec.ExistingClusterRole] = spec.Elevation.Until.Time
+		}
+	}
+
+	var warnings admission.Warnings
+	for _, spec := range clusterRoles {
+		if spec.Elevation == nil {
+			continue
+		}
+		until := spec.Elevation.Until.Time
+		if until.IsZero() {
+			return nil, fmt.Errorf("elevation of clusterrole '%s' requires an end time", spec.ExistingClusterRole)
+		}
+		if until.After(now) {
+			continue
+		}
+		if prev, ok := previousUntil[spec.ExistingClusterRole]; ok && prev.Equal(until) {
+			warnings = append(warnings, fmt.Sprintf("elevation of clusterrole '%s' ended at %s and is no longer bound",
+				spec.ExistingClusterRole, until.UTC().Format(time.RFC3339)))
+			continue
+		}
+		return nil, fmt.Errorf("elevation of clusterrole '%s' must end in the future, got %s",
+			spec.ExistingClusterRole, until.UTC().Format(time.RFC3339))
+	}
+	return warnings, nil
+}
+
+// validateGrantDurations requires the durations of timed grants to be positive
+func validateGrantDurations(spec authv1alpha1.UserSpec) error {
+	for _, role := range spec.Roles {
+		if role.Duration != nil && role.Duration.Duration <= 0 {
+			return fmt.Errorf("duration of role '%s' in namespace '%s' must be positive", role.ExistingRole, role.Namespace)
+		}
+	}
+	for _, clusterRole := range spec.ClusterRoles {
+		if clusterRole.Duration != nil && clusterRole.Duration.Duration <= 0 {
+			return fmt.Errorf("duration of clusterrole '%s' must be positive", clusterRole.ExistingClusterRole)
+		}
+	}
+	return nil
+}
+
+// validateAccessSchedule checks the time zone, cron expressions and durations of an access schedule
+func validateAccessSchedule(accessSchedule *authv1alpha1.AccessSchedule) error {
+	if accessSchedule == nil {
+		return nil
+	}
+	if _, err := schedule.New(accessSchedule); err != nil {
+		return fmt.Errorf("invalid spec.accessSchedule: %w", err)
+	}
+	return nil
+}
+
+// validateOutput checks the requested credential Secret layout and location
+func validateOutput(output *authv1alpha1.OutputSpec) error {
+	if output == nil {
+		return nil
+	}
+	if ref := output.SecretRef; ref != nil {
+		if errs := validation.IsDNS1123Subdomain(ref.Name); len(errs) > 0 {
+			return fmt.Errorf("invalid spec.output.secretRef.name %q: %s", ref.Name, strings.Join(errs, ", "))
+		}
+		if ref.Namespace != "" {
+			if errs := validation.IsDNS1123Label(ref.Namespace); len(errs) > 0 {
+				return fmt.Errorf("invalid spec.output.secretRef.namespace %q: %s", ref.Namespace, strings.Join(errs, ", "))
+			}
+		}
+		if ref.Key != "" && len(output.Keys) > 0 {
+			return fmt.Errorf("spec.output.secretRef.key and spec.output.keys are mutually exclusive")
+		}
+	}
+	if output.Server != "" {
+		if u, err := url.Parse(output.Server); err != nil || u.Scheme != "https" || u.Host == "" {
+			return fmt.Errorf("invalid spec.output.server %q: must be an https:// URL", output.Server)
+		}
+	}
+	if output.CABundle != "" && !x509.NewCertPool().AppendCertsFromPEM([]byte(output.CABundle)) {
+		return fmt.Errorf("invalid spec.output.caBundle: no PEM-encoded certificates found")
+	}
+	if err := operatorconfig.ValidateProxyURL(output.ProxyURL); err != nil {
+		return fmt.Errorf("invalid spec.output.proxyURL: %w", err)
+	}
+	if output.TLSServerName != "" {
+		if errs := validation.IsDNS1123Subdomain(output.TLSServerName); len(errs) > 0 {
+			return fmt.Errorf("invalid spec.output.tlsServerName %q: %s", output.TLSServerName, strings.Join(errs, ", "))
+		}
+	}
+	if output.InsecureSkipTLSVerify != nil && *output.InsecureSkipTLSVerify && output.CABundle != "" {
+		return fmt.Errorf("spec.output.caBundle cannot be combined with spec.output.insecureSkipTLSVerify")
+	}
+	if err := validateOutputEncryption(output); err != nil {
+		return err
+	}
+	if len(output.Keys) == 0 {
+		return nil
+	}
+	if err := credentials.FromSpec(output, nil).Validate(); err != nil {
+		return fmt.Errorf("invalid spec.output.keys: %w", err)
+	}
+	return nil
+}
+
+// validateSSH checks the requested SSH certificate's principals and public key
+func validateSSH(ssh *authv1alpha1.SSHSpec) error {
+	if ssh == nil {
+		return nil
+	}
+	for _, principal := range ssh.Principals {
+		if principal == "" || strings.ContainsAny(principal, ", \t\n") {
+			return fmt.Errorf("invalid spec.ssh.principals entry %q", principal)
+		}
+	}
+	if ssh.PublicKey != "" {
+		if _, err := sshcert.ParseAuthorizedKey(ssh.PublicKey); err != nil {
+			return fmt.Errorf("invalid spec.ssh.publicKey: %w", err)
+		}
+	}
+	return nil
+}
+
+// reservedUsernamePrefixes belong to identities of the API server and Kubernetes components; a
+// certificate for such a name would carry their implicit privileges
+var reservedUsernamePrefixes = []string{"system:", "kube-"}
+
+// validateUsername checks the user name is usable as certificate CN and object name, and is
+// neither reserved nor taken by a ServiceAccount the controller would adopt as the user's anchor
+func (w *UserWebhook) validateUsername(ctx context.Context, name string) error {
+	for _, prefix := range reservedUsernamePrefixes {
+		if strings.HasPrefix(name, prefix) {
+			return fmt.Errorf("user name %q uses the reserved prefix %q", name, prefix)
+		}
+	}
+	if errs := validation.IsDNS1123Label(name); len(errs) > 0 {
+		return fmt.Errorf("invalid user name %q: %s", name, strings.Join(errs, ", "))
+	}
+
+	var sa corev1.ServiceAccount
+	key := types.NamespacedName{Name: name, Namespace: operatorconfig.Namespace()}
+	err := w.Get(ctx, key, &sa)
+	if err == nil && sa.Labels["auth.openkube.io/user"] != name {
+		return fmt.Errorf("user name %q collides with ServiceAccount %s", name, key)
+	} else if err != nil && !apierrors.IsNotFound(err) {
+		return fmt.Errorf("failed to check for ServiceAccount %s: %w", key, err)
+	}
+	return nil
+}
+
+// validateCSR checks a user-supplied CSR requests the user's identity, as named by the
+// username template
+func validateCSR(user *authv1alpha1.User) error {
+	if user.Spec.CSR == "" {
+		return nil
+	}
+	if _, err := issuer.ParseCSR([]byte(user.Spec.CSR), operatorconfig.Current().Username(user.Name)); err != nil {
+		return fmt.Errorf("invalid spec.csr: %w", err)
+	}
+	if user.Spec.Output != nil && user.Spec.Output.Format == authv1alpha1.KubeconfigFormatExecCredential {
+		return fmt.Errorf("spec.output.format %s needs a key held by the controller and cannot be used with spec.csr",
+			authv1alpha1.KubeconfigFormatExecCredential)
+	}
+	return nil
+}
+
+// validateOutputEncryption checks that every recipient is an OpenPGP public key that can encrypt
+func validateOutputEncryption(output *authv1alpha1.OutputSpec) error {
+	if output.Encryption == nil {
+		return nil
+	}
+	if output.Format == authv1alpha1.KubeconfigFormatExecCredential {
+		return fmt.Errorf("spec.output.encryption cannot be combined with spec.output.format %s, "+
+			"the credential helper reads the key from the Secret", authv1alpha1.KubeconfigFormatExecCredential)
+	}
+	for i, recipient := range output.Encryption.Recipients {
+		if strings.HasPrefix(strings.TrimSpace(recipient), "age1") {
+			return fmt.Errorf("invalid spec.output.encryption.recipients[%d]: age recipients are not supported, "+
+				"use an ASCII-armored OpenPGP public key", i)
+		}
+		if _, err := pgp.ParseRecipient(recipient); err != nil {
+			return fmt.Errorf("invalid spec.output.encryption.recipients[%d]: %w", i, err)
+		}
+	}
+	return nil
+}
+
+// minTokenDuration is the shortest expiration the TokenRequest API accepts
+const minTokenDuration = 10 * time.Minute
+
+// validateMachine checks that a machine user asks for nothing that needs a client certificate
+func validateMachine(user *authv1alpha1.User) error {
+	if user.Spec.Type != authv1alpha1.UserTypeMachine {
+		if user.Spec.TokenDuration != nil {
+			return fmt.Errorf("spec.tokenDuration is only supported for machine users")
+		}
+		return nil
+	}
+	switch {
+	case user.Spec.CSR != "":
+		return fmt.Errorf("spec.csr is not supported for machine users, they authenticate with tokens")
+	case user.Spec.SSH != nil:
+		return fmt.Errorf("spec.ssh is not supported for machine users")
+	case user.Spec.Certificate != nil:
+		return fmt.Errorf("spec.certificate is not supported for machine users, they authenticate with tokens")
+	case user.Spec.BreakGlass:
+		return fmt.Errorf("spec.breakGlass is not supported for machine users")
+	case user.Spec.ServiceAccountAnchor != nil && !*user.Spec.ServiceAccountAnchor:
+		return fmt.Errorf("machine users authenticate as their ServiceAccount anchor, it cannot be disabled")
+	case len(user.Spec.Clusters) > 0:
+		return fmt.Errorf("spec.clusters is not supported for machine users")
+	case user.Spec.EKS != nil:
+		return fmt.Errorf("spec.eks is not supported for machine users")
+	case user.Spec.GCP != nil:
+		return fmt.Errorf("spec.gcp is not supported for machine users, they authenticate as their ServiceAccount anchor")
+	case user.Spec.TokenDuration != nil && user.Spec.TokenDuration.Duration < minTokenDuration:
+		return fmt.Errorf("spec.tokenDuration must be at least %s", minTokenDuration)
+	}
+	if output := user.Spec.Output; output != nil {
+		if output.Format == authv1alpha1.KubeconfigFormatExecCredential {
+			return fmt.Errorf("spec.output.format %s is not supported for machine users", output.Format)
+		}
+		for _, key := range output.Keys {
+			if key.Format == authv1alpha1.CredentialFormatClientCert || key.Format == authv1alpha1.CredentialFormatClientKey {
+				return fmt.Errorf("spec.output.keys: format %s is not supported for machine users", key.Format)
+			}
+		}
+	}
+	return nil
+}
+
+// validateEKS checks that a user signing in through its IAM principal only asks for nothing
+// that needs a client certificate
+func validateEKS(user *authv1alpha1.User) error {
+	if user.Spec.EKS == nil || !user.Spec.EKS.IAMOnly {
+		return nil
+	}
+	switch {
+	case user.Spec.CSR != "":
+		return fmt.Errorf("spec.csr cannot be combined with spec.eks.iamOnly, no certificate is issued")
+	case user.Spec.SSH != nil:
+		return fmt.Errorf("spec.ssh cannot be combined with spec.eks.iamOnly, SSH certificates follow the client certificate")
+	case user.Spec.Certificate != nil:
+		return fmt.Errorf("spec.certificate cannot be combined with spec.eks.iamOnly, no certificate is issued")
+	}
+	return nil
+}
+
+// validateClusterTargets checks that the user can be provisioned on its member clusters
+func (w *UserWebhook) validateClusterTargets(user *authv1alpha1.User) error {
+	if len(user.Spec.Clusters) == 0 {
+		return nil
+	}
+	if !w.MemberClusters {
+		return fmt.Errorf("spec.clusters requires the MultiCluster feature gate")
+	}
+	if output := user.Spec.Output; output != nil && output.Format == authv1alpha1.KubeconfigFormatExecCredential {
+		return fmt.Errorf("spec.output.format %s cannot be combined with spec.clusters, the credential helper "+
+			"only reads the credentials for this cluster", authv1alpha1.KubeconfigFormatExecCredential)
+	}
+	for i, cluster := range user.Spec.Clusters {
+		if cluster.Name == "cluster" {
+			return fmt.Errorf("spec.clusters[%d].name cluster is reserved for this cluster in the kubeconfig", i)
+		}
+		ref := cluster.KubeconfigSecretRef
+		if errs := validation.IsDNS1123Subdomain(ref.Name); len(errs) > 0 {
+			return fmt.Errorf("invalid spec.clusters[%d].kubeconfigSecretRef.name %q: %s", i,
+				ref.Name, strings.Join(errs, ", "))
+		}
+		if ref.Namespace == "" {
+			continue
+		}
+		if errs := validation.IsDNS1123Label(ref.Namespace); len(errs) > 0 {
+			return fmt.Errorf("invalid spec.clusters[%d].kubeconfigSecretRef.namespace %q: %s", i,
+				ref.Namespace, strings.Join(errs, ", "))
+		}
+	}
+	return nil
+}
+
+// validateClusters checks the requester may use the user's kubeconfig Secrets. A Secret outside
+// the KubeUser namespace may only be referenced by requesters who can get it, or the User would
+// hand them the controller's access to a cluster they were not given.
+func (w *UserWebhook) validateClusters(ctx context.Context, requester authenticationv1.UserInfo,
+	user, previous *authv1alpha1.User) error {
+	existing := map[authv1alpha1.ClusterKubeconfigReference]bool{}
+	if previous != nil {
+		for _, cluster := range previous.Spec.Clusters {
+			existing[cluster.KubeconfigSecretRef] = true
+		}
+	}
+	for _, cluster := range user.Spec.Clusters {
+		ref := cluster.KubeconfigSecretRef
+		if ref.Namespace == "" || ref.Namespace == operatorconfig.Namespace() || existing[ref] {
+			continue
+		}
+		allowed, err := w.allowed(ctx, requester, &authorizationv1.ResourceAttributes{
+			Verb:      "get",
+			Resource:  "secrets",
+			Namespace: ref.Namespace,
+			Name:      ref.Name,
+		}, nil)
+		if err != nil {
+			return err
+		}
+		if !allowed {
+			return fmt.Errorf("user '%s' may not reference kubeconfig secret '%s' in namespace '%s': "+
+				"requires get on the secret", requester.Username, ref.Name, ref.Namespace)
+		}
+	}
+	return nil
+}
+
+// +kubebuilder:webhook:path=/validate-auth-openkube-io-v1alpha1-user,mutating=false,failurePolicy=fail,sideEffects=None,groups=auth.openkube.io,resources=users,verbs=create;update,versions=v1alpha1,name=user.auth.openkube.io,admissionReviewVersions=v1
+
+// validateRequesterClusters runs validateClusters for the requester of the admission request in
+// ctx. Without one, Secrets outside the KubeUser namespace cannot be checked, so the User is
+// rejected.
+func (w *UserWebhook) validateRequesterClusters(ctx context.Context, user, previous *authv1alpha1.User) error {
+	if len(user.Spec.Clusters) == 0 {
+		return nil
+	}
+	req, err := admission.RequestFromContext(ctx)
+	if err != nil {
+		return fmt.Errorf("cannot verify the requester may reference the user's kubeconfig Secrets: %w", err)
+	}
+	return w.validateClusters(ctx, req.UserInfo, user, previous)
+}
+
 // SetupWithManager registers the webhook with the manager
 func (w *UserWebhook) SetupWithManager(mgr ctrl.Manager) error {
 	w.Client = mgr.GetClient()
 	w.decoder = admission.NewDecoder(mgr.GetScheme())
+	w.recorder = mgr.GetEventRecorderFor("kubeuser-webhook")
 
 	return ctrl.NewWebhookManagedBy(mgr).
 		For(&authv1alpha1.User{}).
+		WithDefaulter(w).
 		WithValidator(w).
 		Complete()
 }
@@ -118,31 +455,38 @@ func (w *UserWebhook) ValidateCreate(ctx context.Context, obj runtime.Object) (a
 	if !ok {
 		return nil, fmt.Errorf("expected User object, got %T", obj)
 	}
+	logf.FromContext(ctx).WithName("user-webhook").Info("Validating User creation", "user", user.Name)
 
-	logger := logf.FromContext(ctx).WithName("user-webhook-create")
-	logger.Info("Validating User creation", "user", user.Name)
-
-	// Validate Role references
-	if err := w.validateRoles(ctx, user.Spec.Roles); err != nil {
+	user, err := w.withTemplate(ctx, user)
+	if err != nil {
 		return nil, err
 	}
-
-	// Validate ClusterRole references
-	if err := w.validateClusterRoles(ctx, user.Spec.ClusterRoles); err != nil {
+	if user, err = w.withSelectingTeams(ctx, user); err != nil {
 		return nil, err
 	}
-
-	return nil, nil
+	// The name becomes the certificate CN; it cannot change after creation
+	if err := w.validateUsername(ctx, user.Name); err != nil {
+		return nil, err
+	}
+	return w.validate(ctx, user, nil)
 }
 
-// ValidateUpdate implements admission.CustomValidator
+// ValidateUpdate implements admission.CustomValidator. Rejected updates are recorded as an
+// Event on the stored User, so they show up in kubectl describe user.
 func (w *UserWebhook) ValidateUpdate(ctx context.Context, oldObj, newObj runtime.Object) (admission.Warnings, error) {
+	warnings, err := w.validateUpdate(ctx, oldObj, newObj)
+	if oldUser, ok := oldObj.(*authv1alpha1.User); ok && err != nil && w.recorder != nil {
+		w.recorder.Eventf(oldUser, corev1.EventTypeWarning, "UpdateRejected", "Update rejected: %v", err)
+	}
+	return warnings, err
+}
+
+func (w *UserWebhook) validateUpdate(ctx context.Context, oldObj, newObj runtime.Object) (admission.Warnings, error) {
 	newUser, ok := newObj.(*authv1alpha1.User)
 	if !ok {
 		return nil, fmt.Errorf("expected User object, got %T", newObj)
 	}
-
-	logger := logf.FromContext(ctx).WithName("user-webhook-update")
+	logger := logf.FromContext(ctx).WithName("user-webhook")
 	logger.Info("Validating User update", "user", newUser.Name)
 
 	// Skip validation if the user is being deleted
@@ -151,17 +495,94 @@ func (w *UserWebhook) ValidateUpdate(ctx context.Context, oldObj, newObj runtime
 		return nil, nil
 	}
 
-	// Validate Role references in the updated spec
-	if err := w.validateRoles(ctx, newUser.Spec.Roles); err != nil {
+	oldUser, _ := oldObj.(*authv1alpha1.User)
+	merged, err := w.withTemplate(ctx, newUser)
+	if err != nil {
+		// Users whose template was deleted keep working until their templateRef changes
+		if oldUser == nil || !equality.Semantic.DeepEqual(newUser.Spec.TemplateRef, oldUser.Spec.TemplateRef) {
+			return nil, err
+		}
+		merged = newUser
+	}
+	if merged, err = w.withSelectingTeams(ctx, merged); err != nil {
 		return nil, err
 	}
+	previous, err := w.withSelectingTeams(ctx, w.previousWithTemplate(ctx, oldUser))
+	if err != nil {
+		return nil, err
+	}
+	return w.validate(ctx, merged, previous)
+}
 
-	// Validate ClusterRole references in the updated spec
-	if err := w.validateClusterRoles(ctx, newUser.Spec.ClusterRoles); err != nil {
+// validate checks user on creation and update. Grants, organizations and break-glass access
+// previous already had are not checked again.
+func (w *UserWebhook) validate(ctx context.Context, user, previous *authv1alpha1.User) (warnings admission.Warnings, err error) {
+	ctx, span := tracing.Start(ctx, "UserWebhook.validate", attribute.String("user", user.Name),
+		attribute.Bool("update", previous != nil))
+	defer func() {
+		// Denials are answers, not failures of the webhook
+		span.SetAttributes(attribute.Bool("allowed", err == nil))
+		span.End()
+	}()
+
+	if err := w.validateRoles(ctx, user.Spec.Roles); err != nil {
+		return nil, err
+	}
+	if err := w.validateClusterRoles(ctx, user.Spec.ClusterRoles); err != nil {
 		return nil, err
 	}
 
-	return nil, nil
+	if err := w.validateRequesterBreakGlass(ctx, user, previous); err != nil {
+		return nil, err
+	}
+	// The requester may only grant roles they could bind themselves
+	if err := w.validateRequesterEscalation(ctx, user, previous); err != nil {
+		return nil, err
+	}
+	if err := w.validateRequesterPolicies(ctx, user, previous); err != nil {
+		return nil, err
+	}
+	if err := w.validateRequesterCertificateSubject(ctx, user, previous); err != nil {
+		return nil, err
+	}
+
+	if err := validateOutput(user.Spec.Output); err != nil {
+		return nil, err
+	}
+	if err := validateSSH(user.Spec.SSH); err != nil {
+		return nil, err
+	}
+	if err := validateCSR(user); err != nil {
+		return nil, err
+	}
+	if err := validateCertificate(user); err != nil {
+		return nil, err
+	}
+	if err := validateMachine(user); err != nil {
+		return nil, err
+	}
+	if err := validateEKS(user); err != nil {
+		return nil, err
+	}
+	if err := w.validateClusterTargets(user); err != nil {
+		return nil, err
+	}
+	if err := w.validateRequesterClusters(ctx, user, previous); err != nil {
+		return nil, err
+	}
+	if err := validateGrantDurations(user.Spec); err != nil {
+		return nil, err
+	}
+	if err := validateAccessSchedule(user.Spec.AccessSchedule); err != nil {
+		return nil, err
+	}
+
+	// Validate elevation end times; unchanged elevations may already have ended
+	var previousClusterRoles []authv1alpha1.ClusterRoleSpec
+	if previous != nil {
+		previousClusterRoles = previous.Spec.ClusterRoles
+	}
+	return validateElevations(user.Spec.ClusterRoles, previousClusterRoles, time.Now())
 }
 
 // ValidateDelete implements admission.CustomValidator
@@ -0,0 +1,256 @@
+/*
+Copyright 2025.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package webhook
+
+import (
+	"context"
+	"time"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+	admissionv1 "k8s.io/api/admission/v1"
+	authenticationv1 "k8s.io/api/authentication/v1"
+	authorizationv1 "k8s.io/api/authorization/v1"
+	rbacv1 "k8s.io/api/rbac/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	clientgoscheme "k8s.io/client-go/kubernetes/scheme"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+	"sigs.k8s.io/controller-runtime/pkg/client/interceptor"
+	"sigs.k8s.io/controller-runtime/pkg/webhook/admission"
+
+	authv1alpha1 "github.com/openkube-hub/KubeUser/api/v1alpha1"
+)
+
+// requester is the user every admission request in these tests comes from
+var requester = authenticationv1.UserInfo{Username: "alice", Groups: []string{"system:authenticated"}}
+
+// newUserWebhook returns a webhook reading objects, whose SubjectAccessReviews are answered by
+// allow and recorded in reviews
+func newUserWebhook(allow func(authorizationv1.SubjectAccessReviewSpec) bool,
+	reviews *[]authorizationv1.SubjectAccessReviewSpec, objects ...client.Object) *UserWebhook {
+	scheme := runtime.NewScheme()
+	Expect(clientgoscheme.AddToScheme(scheme)).To(Succeed())
+	Expect(authv1alpha1.AddToScheme(scheme)).To(Succeed())
+	c := fake.NewClientBuilder().WithScheme(scheme).WithObjects(objects...).WithInterceptorFuncs(interceptor.Funcs{
+		Create: func(ctx context.Context, c client.WithWatch, obj client.Object, opts ...client.CreateOption) error {
+			if review, ok := obj.(*authorizationv1.SubjectAccessReview); ok {
+				Expect(review.Spec.User).To(Equal(requester.Username))
+				if reviews != nil {
+					*reviews = append(*reviews, review.Spec)
+				}
+				review.Status.Allowed = allow(review.Spec)
+				return nil
+			}
+			return c.Create(ctx, obj, opts...)
+		},
+	}).Build()
+	return &UserWebhook{Client: c}
+}
+
+// admissionContext returns a context carrying an admission request of requester
+func admissionContext(operation admissionv1.Operation) context.Context {
+	return admission.NewContextWithRequest(context.Background(), admission.Request{
+		AdmissionRequest: admissionv1.AdmissionRequest{Operation: operation, UserInfo: requester},
+	})
+}
+
+func allowAll(authorizationv1.SubjectAccessReviewSpec) bool  { return true }
+func allowNone(authorizationv1.SubjectAccessReviewSpec) bool { return false }
+
+var _ = Describe("UserWebhook member clusters", func() {
+	var user *authv1alpha1.User
+
+	BeforeEach(func() {
+		user = &authv1alpha1.User{
+			ObjectMeta: metav1.ObjectMeta{Name: "jane"},
+			Spec: authv1alpha1.UserSpec{Clusters: []authv1alpha1.ClusterTarget{{
+				Name:                "prod",
+				KubeconfigSecretRef: authv1alpha1.ClusterKubeconfigReference{Name: "prod-kubeconfig", Namespace: "platform"},
+			}}},
+		}
+	})
+
+	It("denies kubeconfig Secrets in other namespaces the requester may not get", func() {
+		var reviews []authorizationv1.SubjectAccessReviewSpec
+		w := newUserWebhook(allowNone, &reviews)
+		w.MemberClusters = true
+		_, err := w.ValidateCreate(admissionContext(admissionv1.Create), user)
+		Expect(err).To(MatchError(ContainSubstring("may not reference kubeconfig secret 'prod-kubeconfig' in namespace 'platform'")))
+		Expect(reviews).To(ContainElement(HaveField("ResourceAttributes", &authorizationv1.ResourceAttributes{
+			Verb: "get", Resource: "secrets", Namespace: "platform", Name: "prod-kubeconfig",
+		})))
+
+		previous := user.DeepCopy()
+		previous.Spec.Clusters = nil
+		_, err = w.ValidateUpdate(admissionContext(admissionv1.Update), previous, user)
+		Expect(err).To(MatchError(ContainSubstring("requires get on the secret")))
+	})
+
+	It("allows kubeconfig Secrets the requester may get, or the User already referenced", func() {
+		w := newUserWebhook(allowAll, nil)
+		w.MemberClusters = true
+		_, err := w.ValidateCreate(admissionContext(admissionv1.Create), user)
+		Expect(err).NotTo(HaveOccurred())
+
+		w = newUserWebhook(allowNone, nil)
+		w.MemberClusters = true
+		updated := user.DeepCopy()
+		updated.Spec.Clusters[0].Name = "production"
+		_, err = w.ValidateUpdate(admissionContext(admissionv1.Update), user, updated)
+		Expect(err).NotTo(HaveOccurred())
+	})
+
+	It("requires the MultiCluster feature gate", func() {
+		w := newUserWebhook(allowAll, nil)
+		_, err := w.ValidateCreate(admissionContext(admissionv1.Create), user)
+		Expect(err).To(MatchError(ContainSubstring("requires the MultiCluster feature gate")))
+
+		_, err = w.ValidateUpdate(admissionContext(admissionv1.Update), &authv1alpha1.User{
+			ObjectMeta: metav1.ObjectMeta{Name: "jane"},
+		}, user)
+		Expect(err).To(MatchError(ContainSubstring("requires the MultiCluster feature gate")))
+	})
+
+	It("rejects exec credential kubeconfigs and the reserved cluster name", func() {
+		w := newUserWebhook(allowAll, nil)
+		w.MemberClusters = true
+		exec := user.DeepCopy()
+		exec.Spec.Output = &authv1alpha1.OutputSpec{Format: authv1alpha1.KubeconfigFormatExecCredential}
+		_, err := w.ValidateCreate(admissionContext(admissionv1.Create), exec)
+		Expect(err).To(MatchError(ContainSubstring("cannot be combined with spec.clusters")))
+
+		reserved := user.DeepCopy()
+		reserved.Spec.Clusters[0].Name = "cluster"
+		_, err = w.ValidateCreate(admissionContext(admissionv1.Create), reserved)
+		Expect(err).To(MatchError(ContainSubstring("spec.clusters[0].name cluster is reserved")))
+
+		invalid := user.DeepCopy()
+		invalid.Spec.Clusters[0].KubeconfigSecretRef.Namespace = "Not_A_Namespace"
+		_, err = w.ValidateCreate(admissionContext(admissionv1.Create), invalid)
+		Expect(err).To(MatchError(ContainSubstring("invalid spec.clusters[0].kubeconfigSecretRef.namespace")))
+	})
+})
+
+var _ = Describe("UserWebhook elevations", func() {
+	var (
+		clusterAdmin *rbacv1.ClusterRole
+		user         *authv1alpha1.User
+	)
+
+	elevateUntil := func(until time.Time) *authv1alpha1.User {
+		updated := user.DeepCopy()
+		updated.Spec.ClusterRoles[0].Elevation.Until = metav1.NewTime(until)
+		return updated
+	}
+
+	BeforeEach(func() {
+		clusterAdmin = &rbacv1.ClusterRole{
+			ObjectMeta: metav1.ObjectMeta{Name: "cluster-admin"},
+			Rules:      []rbacv1.PolicyRule{{APIGroups: []string{"*"}, Resources: []string{"*"}, Verbs: []string{"*"}}},
+		}
+		user = &authv1alpha1.User{
+			ObjectMeta: metav1.ObjectMeta{Name: "jane"},
+			Spec: authv1alpha1.UserSpec{ClusterRoles: []authv1alpha1.ClusterRoleSpec{{
+				ExistingClusterRole: "cluster-admin",
+				Elevation:           &authv1alpha1.Elevation{Until: metav1.NewTime(time.Now().Add(time.Hour)), Reason: "incident"},
+			}}},
+		}
+	})
+
+	It("denies lengthening an elevation to a requester who may not bind the role", func() {
+		w := newUserWebhook(allowNone, nil, clusterAdmin)
+		_, err := w.ValidateUpdate(admissionContext(admissionv1.Update), user, elevateUntil(time.Now().Add(30*24*time.Hour)))
+		Expect(err).To(MatchError(ContainSubstring("may not grant clusterrole 'cluster-admin' for longer")))
+	})
+
+	It("denies dropping an elevation to a requester who may not bind the role", func() {
+		w := newUserWebhook(allowNone, nil, clusterAdmin)
+		permanent := user.DeepCopy()
+		permanent.Spec.ClusterRoles[0].Elevation = nil
+		_, err := w.ValidateUpdate(admissionContext(admissionv1.Update), user, permanent)
+		Expect(err).To(MatchError(ContainSubstring("may not grant clusterrole 'cluster-admin' for longer")))
+	})
+
+	It("allows shortening an elevation without bind", func() {
+		w := newUserWebhook(allowNone, nil, clusterAdmin)
+		_, err := w.ValidateUpdate(admissionContext(admissionv1.Update), user, elevateUntil(time.Now().Add(time.Minute)))
+		Expect(err).NotTo(HaveOccurred())
+	})
+
+	It("allows lengthening an elevation to a requester who may bind the role", func() {
+		w := newUserWebhook(allowAll, nil, clusterAdmin)
+		_, err := w.ValidateUpdate(admissionContext(admissionv1.Update), user, elevateUntil(time.Now().Add(30*24*time.Hour)))
+		Expect(err).NotTo(HaveOccurred())
+	})
+})
+
+var _ = Describe("UserWebhook timed grants", func() {
+	var (
+		developer *rbacv1.Role
+		user      *authv1alpha1.User
+	)
+
+	BeforeEach(func() {
+		developer = &rbacv1.Role{
+			ObjectMeta: metav1.ObjectMeta{Name: "developer", Namespace: "dev"},
+			Rules:      []rbacv1.PolicyRule{{APIGroups: []string{""}, Resources: []string{"pods"}, Verbs: []string{"get"}}},
+		}
+		expiresAt := metav1.NewTime(time.Now().Add(time.Hour))
+		user = &authv1alpha1.User{
+			ObjectMeta: metav1.ObjectMeta{Name: "jane"},
+			Spec: authv1alpha1.UserSpec{Roles: []authv1alpha1.RoleSpec{{
+				Namespace: "dev", ExistingRole: "developer", ExpiresAt: &expiresAt,
+				Duration: &metav1.Duration{Duration: 8 * time.Hour},
+			}}},
+		}
+	})
+
+	It("denies lengthening expiresAt to a requester who may not bind the role", func() {
+		w := newUserWebhook(allowNone, nil, developer)
+		updated := user.DeepCopy()
+		updated.Spec.Roles[0].ExpiresAt = &metav1.Time{Time: time.Now().Add(30 * 24 * time.Hour)}
+		_, err := w.ValidateUpdate(admissionContext(admissionv1.Update), user, updated)
+		Expect(err).To(MatchError(ContainSubstring("may not grant role 'developer' in namespace 'dev' for longer")))
+
+		updated.Spec.Roles[0].ExpiresAt = nil
+		_, err = w.ValidateUpdate(admissionContext(admissionv1.Update), user, updated)
+		Expect(err).To(MatchError(ContainSubstring("may not grant role 'developer' in namespace 'dev' for longer")))
+	})
+
+	It("denies lengthening the duration to a requester who may not bind the role", func() {
+		w := newUserWebhook(allowNone, nil, developer)
+		updated := user.DeepCopy()
+		updated.Spec.Roles[0].Duration = &metav1.Duration{Duration: 24 * time.Hour}
+		_, err := w.ValidateUpdate(admissionContext(admissionv1.Update), user, updated)
+		Expect(err).To(MatchError(ContainSubstring("may not grant role 'developer' in namespace 'dev' for longer")))
+
+		updated.Spec.Roles[0].Duration = nil
+		_, err = w.ValidateUpdate(admissionContext(admissionv1.Update), user, updated)
+		Expect(err).To(MatchError(ContainSubstring("may not grant role 'developer' in namespace 'dev' for longer")))
+	})
+
+	It("allows shortening a timed grant without bind", func() {
+		w := newUserWebhook(allowNone, nil, developer)
+		updated := user.DeepCopy()
+		updated.Spec.Roles[0].ExpiresAt = &metav1.Time{Time: time.Now().Add(time.Minute)}
+		updated.Spec.Roles[0].Duration = &metav1.Duration{Duration: time.Hour}
+		_, err := w.ValidateUpdate(admissionContext(admissionv1.Update), user, updated)
+		Expect(err).NotTo(HaveOccurred())
+	})
+})
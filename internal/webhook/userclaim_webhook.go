@@ -0,0 +1,117 @@
+/*
+Copyright 2025.
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+*/
+
+package webhook
+
+import (
+	"context"
+	"fmt"
+
+	authv1alpha1 "github.com/openkube-hub/KubeUser/api/v1alpha1"
+	"github.com/openkube-hub/KubeUser/internal/tracing"
+	"github.com/openkube-hub/KubeUser/internal/userclaim"
+	"go.opentelemetry.io/otel/attribute"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/types"
+	ctrl "sigs.k8s.io/controller-runtime"
+	logf "sigs.k8s.io/controller-runtime/pkg/log"
+	"sigs.k8s.io/controller-runtime/pkg/webhook"
+	"sigs.k8s.io/controller-runtime/pkg/webhook/admission"
+)
+
+// UserClaimWebhook validates UserClaim resources. The controller creates the claimed User with
+// its own identity, so the claimed Roles are checked here against the tenant admin's.
+type UserClaimWebhook struct {
+	users *UserWebhook
+}
+
+// +kubebuilder:webhook:path=/validate-auth-openkube-io-v1alpha1-userclaim,mutating=false,failurePolicy=fail,sideEffects=None,groups=auth.openkube.io,resources=userclaims,verbs=create;update,versions=v1alpha1,name=userclaim.auth.openkube.io,admissionReviewVersions=v1
+
+// SetupWithManager registers the webhook with the manager
+func (w *UserClaimWebhook) SetupWithManager(mgr ctrl.Manager) error {
+	w.users = &UserWebhook{Client: mgr.GetClient()}
+
+	return ctrl.NewWebhookManagedBy(mgr).
+		For(&authv1alpha1.UserClaim{}).
+		WithValidator(w).
+		Complete()
+}
+
+// Compile-time check to ensure UserClaimWebhook implements admission.CustomValidator
+var _ webhook.CustomValidator = &UserClaimWebhook{}
+
+// ValidateCreate implements admission.CustomValidator
+func (w *UserClaimWebhook) ValidateCreate(ctx context.Context, obj runtime.Object) (admission.Warnings, error) {
+	claim, ok := obj.(*authv1alpha1.UserClaim)
+	if !ok {
+		return nil, fmt.Errorf("expected a UserClaim object but got %T", obj)
+	}
+	if err := w.users.validateUsername(ctx, claim.Name); err != nil {
+		return nil, err
+	}
+	var user authv1alpha1.User
+	err := w.users.Get(ctx, types.NamespacedName{Name: claim.Name}, &user)
+	if err == nil && !userclaim.Owns(claim, &user) {
+		return nil, fmt.Errorf("user '%s' already exists", claim.Name)
+	} else if err != nil && !apierrors.IsNotFound(err) {
+		return nil, fmt.Errorf("failed to check for User '%s': %w", claim.Name, err)
+	}
+	return nil, w.validate(ctx, claim, nil)
+}
+
+// ValidateUpdate implements admission.CustomValidator
+func (w *UserClaimWebhook) ValidateUpdate(ctx context.Context, oldObj, newObj runtime.Object) (admission.Warnings, error) {
+	claim, ok := newObj.(*authv1alpha1.UserClaim)
+	if !ok {
+		return nil, fmt.Errorf("expected a UserClaim object but got %T", newObj)
+	}
+	if claim.DeletionTimestamp != nil {
+		return nil, nil
+	}
+	previous, _ := oldObj.(*authv1alpha1.UserClaim)
+	return nil, w.validate(ctx, claim, previous)
+}
+
+// ValidateDelete implements admission.CustomValidator
+func (w *UserClaimWebhook) ValidateDelete(ctx context.Context, obj runtime.Object) (admission.Warnings, error) {
+	return nil, nil
+}
+
+// validate limits the claimed Roles to the claim's tenant and checks them like a User's own.
+// Roles carried over from previous are not checked again.
+func (w *UserClaimWebhook) validate(ctx context.Context, claim, previous *authv1alpha1.UserClaim) error {
+	ctx, span := tracing.Start(ctx, "UserClaimWebhook.validate",
+		attribute.String("claim", claim.Namespace+"/"+claim.Name))
+	defer span.End()
+	logger := logf.FromContext(ctx).WithName("userclaim-webhook")
+	logger.Info("Validating UserClaim", "claim", claim.Namespace+"/"+claim.Name)
+
+	tenant, err := userclaim.TenantNamespaces(ctx, w.users, claim.Namespace)
+	if err != nil {
+		return err
+	}
+	roles, err := userclaim.Roles(claim, tenant)
+	if err != nil {
+		return err
+	}
+	if err := w.users.validateRoles(ctx, roles); err != nil {
+		return err
+	}
+
+	grantee := &authv1alpha1.User{Spec: authv1alpha1.UserSpec{Roles: roles}}
+	var previousGrantee *authv1alpha1.User
+	if previous != nil {
+		// Roles of the previous version may have left the tenant since; they count as new
+		if previousRoles, err := userclaim.Roles(previous, tenant); err == nil {
+			previousGrantee = &authv1alpha1.User{Spec: authv1alpha1.UserSpec{Roles: previousRoles}}
+		}
+	}
+	if err := w.users.validateRequesterEscalation(ctx, grantee, previousGrantee); err != nil {
+		return err
+	}
+	return w.users.validateRequesterPolicies(ctx, grantee, previousGrantee)
+}
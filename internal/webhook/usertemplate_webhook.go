@@ -0,0 +1,116 @@
+/*
+Copyright 2025.
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+*/
+
+package webhook
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	authv1alpha1 "github.com/openkube-hub/KubeUser/api/v1alpha1"
+	"github.com/openkube-hub/KubeUser/internal/tracing"
+	"go.opentelemetry.io/otel/attribute"
+	"k8s.io/apimachinery/pkg/runtime"
+	ctrl "sigs.k8s.io/controller-runtime"
+	logf "sigs.k8s.io/controller-runtime/pkg/log"
+	"sigs.k8s.io/controller-runtime/pkg/webhook"
+	"sigs.k8s.io/controller-runtime/pkg/webhook/admission"
+)
+
+// UserTemplateWebhook validates UserTemplate resources. A template's roles are bound for every
+// User referencing it without an admission request for those Users, so they are checked here
+// like a User's own roles.
+type UserTemplateWebhook struct {
+	users *UserWebhook
+}
+
+// +kubebuilder:webhook:path=/validate-auth-openkube-io-v1alpha1-usertemplate,mutating=false,failurePolicy=fail,sideEffects=None,groups=auth.openkube.io,resources=usertemplates,verbs=create;update,versions=v1alpha1,name=usertemplate.auth.openkube.io,admissionReviewVersions=v1
+
+// SetupWithManager registers the webhook with the manager
+func (w *UserTemplateWebhook) SetupWithManager(mgr ctrl.Manager) error {
+	w.users = &UserWebhook{Client: mgr.GetClient()}
+
+	return ctrl.NewWebhookManagedBy(mgr).
+		For(&authv1alpha1.UserTemplate{}).
+		WithValidator(w).
+		Complete()
+}
+
+// Compile-time check to ensure UserTemplateWebhook implements admission.CustomValidator
+var _ webhook.CustomValidator = &UserTemplateWebhook{}
+
+// ValidateCreate implements admission.CustomValidator
+func (w *UserTemplateWebhook) ValidateCreate(ctx context.Context, obj runtime.Object) (admission.Warnings, error) {
+	template, ok := obj.(*authv1alpha1.UserTemplate)
+	if !ok {
+		return nil, fmt.Errorf("expected a UserTemplate object but got %T", obj)
+	}
+	return w.validate(ctx, template, nil)
+}
+
+// ValidateUpdate implements admission.CustomValidator
+func (w *UserTemplateWebhook) ValidateUpdate(ctx context.Context, oldObj, newObj runtime.Object) (admission.Warnings, error) {
+	template, ok := newObj.(*authv1alpha1.UserTemplate)
+	if !ok {
+		return nil, fmt.Errorf("expected a UserTemplate object but got %T", newObj)
+	}
+	if template.DeletionTimestamp != nil {
+		return nil, nil
+	}
+	previous, _ := oldObj.(*authv1alpha1.UserTemplate)
+	return w.validate(ctx, template, previous)
+}
+
+// ValidateDelete implements admission.CustomValidator
+func (w *UserTemplateWebhook) ValidateDelete(ctx context.Context, obj runtime.Object) (admission.Warnings, error) {
+	return nil, nil
+}
+
+// validate checks the roles the template grants, which the requester must be allowed to grant
+// as for a User. Grants carried over from previous are not checked again.
+func (w *UserTemplateWebhook) validate(ctx context.Context, template, previous *authv1alpha1.UserTemplate) (admission.Warnings, error) {
+	ctx, span := tracing.Start(ctx, "UserTemplateWebhook.validate", attribute.String("template", template.Name))
+	defer span.End()
+	logf.FromContext(ctx).WithName("usertemplate-webhook").Info("Validating UserTemplate", "template", template.Name)
+
+	grantee := templateGrantee(template)
+	var previousGrantee *authv1alpha1.User
+	if previous != nil {
+		previousGrantee = templateGrantee(previous)
+	}
+	if err := w.users.validateRoles(ctx, grantee.Spec.Roles); err != nil {
+		return nil, err
+	}
+	if err := w.users.validateClusterRoles(ctx, grantee.Spec.ClusterRoles); err != nil {
+		return nil, err
+	}
+	var previousClusterRoles []authv1alpha1.ClusterRoleSpec
+	if previousGrantee != nil {
+		previousClusterRoles = previousGrantee.Spec.ClusterRoles
+	}
+	warnings, err := validateElevations(grantee.Spec.ClusterRoles, previousClusterRoles, time.Now())
+	if err != nil {
+		return nil, err
+	}
+	if err := validateGrantDurations(grantee.Spec); err != nil {
+		return nil, err
+	}
+	if err := w.users.validateRequesterEscalation(ctx, grantee, previousGrantee); err != nil {
+		return nil, err
+	}
+	if err := w.users.validateRequesterPolicies(ctx, grantee, previousGrantee); err != nil {
+		return nil, err
+	}
+	return warnings, nil
+}
+
+// templateGrantee returns a User holding the roles template grants, for the checks of the User webhook
+func templateGrantee(template *authv1alpha1.UserTemplate) *authv1alpha1.User {
+	user := &authv1alpha1.User{}
+	user.Spec.Roles, user.Spec.ClusterRoles = template.Spec.Roles, template.Spec.ClusterRoles
+	return user
+}
@@ -0,0 +1,94 @@
+/*
+Copyright 2025.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package webhook
+
+import (
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+	admissionv1 "k8s.io/api/admission/v1"
+	authorizationv1 "k8s.io/api/authorization/v1"
+	rbacv1 "k8s.io/api/rbac/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+
+	authv1alpha1 "github.com/openkube-hub/KubeUser/api/v1alpha1"
+)
+
+var _ = Describe("UserTemplates", func() {
+	var (
+		admin    *rbacv1.ClusterRole
+		template *authv1alpha1.UserTemplate
+	)
+
+	BeforeEach(func() {
+		admin = &rbacv1.ClusterRole{
+			ObjectMeta: metav1.ObjectMeta{Name: "admin"},
+			Rules:      []rbacv1.PolicyRule{{APIGroups: []string{""}, Resources: []string{"secrets"}, Verbs: []string{"get"}}},
+		}
+		template = &authv1alpha1.UserTemplate{
+			ObjectMeta: metav1.ObjectMeta{Name: "operators"},
+			Spec: authv1alpha1.UserTemplateSpec{
+				ClusterRoles: []authv1alpha1.ClusterRoleSpec{{ExistingClusterRole: "admin"}},
+			},
+		}
+	})
+
+	It("checks the roles of a template referenced on update", func() {
+		w := newUserWebhook(allowNone, nil, admin, template)
+		user := &authv1alpha1.User{ObjectMeta: metav1.ObjectMeta{Name: "jane"}}
+		updated := user.DeepCopy()
+		updated.Spec.TemplateRef = &authv1alpha1.UserTemplateReference{Name: "operators"}
+		_, err := w.ValidateUpdate(admissionContext(admissionv1.Update), user, updated)
+		Expect(err).To(MatchError(ContainSubstring("may not grant clusterrole 'admin'")))
+
+		updated.Spec.TemplateRef.Name = "missing"
+		_, err = w.ValidateUpdate(admissionContext(admissionv1.Update), user, updated)
+		Expect(err).To(HaveOccurred())
+	})
+
+	It("keeps Users whose template was deleted updatable", func() {
+		w := newUserWebhook(allowNone, nil)
+		user := &authv1alpha1.User{
+			ObjectMeta: metav1.ObjectMeta{Name: "jane"},
+			Spec:       authv1alpha1.UserSpec{TemplateRef: &authv1alpha1.UserTemplateReference{Name: "operators"}},
+		}
+		updated := user.DeepCopy()
+		updated.Labels = map[string]string{"team": "platform"}
+		_, err := w.ValidateUpdate(admissionContext(admissionv1.Update), user, updated)
+		Expect(err).NotTo(HaveOccurred())
+	})
+
+	It("requires the requester to be allowed to grant the roles a template adds", func() {
+		w := &UserTemplateWebhook{users: newUserWebhook(allowNone, nil, admin)}
+		_, err := w.ValidateCreate(admissionContext(admissionv1.Create), template)
+		Expect(err).To(MatchError(ContainSubstring("may not grant clusterrole 'admin'")))
+
+		previous := template.DeepCopy()
+		template.Spec.ClusterRoles = append(template.Spec.ClusterRoles, authv1alpha1.ClusterRoleSpec{ExistingClusterRole: "view"})
+		w = &UserTemplateWebhook{users: newUserWebhook(allowNone, nil, admin,
+			&rbacv1.ClusterRole{ObjectMeta: metav1.ObjectMeta{Name: "view"}})}
+		_, err = w.ValidateUpdate(admissionContext(admissionv1.Update), previous, template)
+		// admin is carried over; view has no rules to hold
+		Expect(err).NotTo(HaveOccurred())
+
+		allowBind := func(spec authorizationv1.SubjectAccessReviewSpec) bool {
+			return spec.ResourceAttributes != nil && spec.ResourceAttributes.Verb == "bind"
+		}
+		w = &UserTemplateWebhook{users: newUserWebhook(allowBind, nil, admin)}
+		_, err = w.ValidateCreate(admissionContext(admissionv1.Create), previous)
+		Expect(err).NotTo(HaveOccurred())
+	})
+})
@@ -0,0 +1,161 @@
+/*
+Copyright 2025.
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+*/
+
+package adminclient
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"strings"
+)
+
+// Client calls the admin API
+type Client struct {
+	// BaseURL is the URL of the KubeUser portal, e.g. https://kubeuser.example.com:8445
+	BaseURL string
+	// Token is a Kubernetes bearer token, e.g. of a ServiceAccount; the API authorizes each
+	// call for its identity
+	Token string
+	// HTTP sends the requests; defaults to http.DefaultClient
+	HTTP *http.Client
+}
+
+// New returns a client of the admin API at baseURL authenticating with token
+func New(baseURL, token string) *Client {
+	return &Client{BaseURL: strings.TrimSuffix(baseURL, "/"), Token: token}
+}
+
+// ListUsers lists all users
+func (c *Client) ListUsers(ctx context.Context) ([]User, error) {
+	var list UserList
+	if err := c.do(ctx, http.MethodGet, "/api/v1/users", nil, &list); err != nil {
+		return nil, err
+	}
+	return list.Items, nil
+}
+
+// GetUser returns the user name
+func (c *Client) GetUser(ctx context.Context, name string) (*User, error) {
+	var user User
+	if err := c.do(ctx, http.MethodGet, userPath(name, ""), nil, &user); err != nil {
+		return nil, err
+	}
+	return &user, nil
+}
+
+// CreateUser creates user; its status is ignored
+func (c *Client) CreateUser(ctx context.Context, user *User) (*User, error) {
+	var created User
+	if err := c.do(ctx, http.MethodPost, "/api/v1/users", user, &created); err != nil {
+		return nil, err
+	}
+	return &created, nil
+}
+
+// Kubeconfig returns the kubeconfig of the user name. Kubeconfigs encrypted to the user's
+// OpenPGP keys are returned encrypted.
+func (c *Client) Kubeconfig(ctx context.Context, name string) ([]byte, error) {
+	var kubeconfig []byte
+	if err := c.do(ctx, http.MethodGet, userPath(name, "kubeconfig"), nil, &kubeconfig); err != nil {
+		return nil, err
+	}
+	return kubeconfig, nil
+}
+
+// Renew asks for new credentials for the user name right away
+func (c *Client) Renew(ctx context.Context, name string, request RenewRequest) error {
+	return c.do(ctx, http.MethodPost, userPath(name, "renew"), request, nil)
+}
+
+// Revoke revokes the user name: all bindings and credentials are removed
+func (c *Client) Revoke(ctx context.Context, name string) error {
+	return c.do(ctx, http.MethodPost, userPath(name, "revoke"), nil, nil)
+}
+
+// Suspend suspends the user name: its bindings are removed until it is resumed
+func (c *Client) Suspend(ctx context.Context, name string) error {
+	return c.do(ctx, http.MethodPost, userPath(name, "suspend"), nil, nil)
+}
+
+// Resume lifts the suspension of the user name
+func (c *Client) Resume(ctx context.Context, name string) error {
+	return c.do(ctx, http.MethodPost, userPath(name, "resume"), nil, nil)
+}
+
+// IsNotFound reports whether err is an API error for a missing user
+func IsNotFound(err error) bool {
+	var apiErr *Error
+	return errors.As(err, &apiErr) && apiErr.Code == http.StatusNotFound
+}
+
+// IsForbidden reports whether err is an API error for a call the caller may not make
+func IsForbidden(err error) bool {
+	var apiErr *Error
+	return errors.As(err, &apiErr) && apiErr.Code == http.StatusForbidden
+}
+
+func userPath(name, action string) string {
+	path := "/api/v1/users/" + url.PathEscape(name)
+	if action != "" {
+		path += "/" + action
+	}
+	return path
+}
+
+// do sends body as JSON and decodes the response into out. A *[]byte out receives the raw body.
+func (c *Client) do(ctx context.Context, method, path string, body, out any) error {
+	var reader io.Reader
+	if body != nil {
+		data, err := json.Marshal(body)
+		if err != nil {
+			return err
+		}
+		reader = bytes.NewReader(data)
+	}
+	req, err := http.NewRequestWithContext(ctx, method, c.BaseURL+path, reader)
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Authorization", "Bearer "+c.Token)
+	if body != nil {
+		req.Header.Set("Content-Type", "application/json")
+	}
+	httpClient := c.HTTP
+	if httpClient == nil {
+		httpClient = http.DefaultClient
+	}
+	resp, err := httpClient.Do(req)
+	if err != nil {
+		return err
+	}
+	defer func() { _ = resp.Body.Close() }()
+	data, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return err
+	}
+	if resp.StatusCode >= http.StatusBadRequest {
+		apiErr := &Error{Code: resp.StatusCode}
+		if json.Unmarshal(data, apiErr) != nil || apiErr.Message == "" {
+			apiErr.Message = fmt.Sprintf("%s %s: %s", method, path, resp.Status)
+		}
+		return apiErr
+	}
+	switch out := out.(type) {
+	case nil:
+		return nil
+	case *[]byte:
+		*out = data
+		return nil
+	default:
+		return json.Unmarshal(data, out)
+	}
+}
@@ -0,0 +1,73 @@
+/*
+Copyright 2025.
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+*/
+
+// Package adminclient is the Go client of the KubeUser admin API, which the manager serves
+// with the SelfServiceAPI feature gate. It lets services manage users over HTTPS with their
+// Kubernetes token instead of working with the User custom resource. The API is described by
+// the OpenAPI document in OpenAPI.
+package adminclient
+
+import (
+	_ "embed"
+
+	authv1alpha1 "github.com/openkube-hub/KubeUser/api/v1alpha1"
+)
+
+// OpenAPI is the OpenAPI 3 description of the admin API
+//
+//go:embed openapi.yaml
+var OpenAPI []byte
+
+// User is a KubeUser user
+type User struct {
+	// Name is the user name, which becomes the certificate's common name
+	Name string `json:"name"`
+	// Labels of the User resource
+	Labels map[string]string `json:"labels,omitempty"`
+	// Spec is the spec of the User resource
+	Spec authv1alpha1.UserSpec `json:"spec"`
+	// Status is read-only
+	Status UserStatus `json:"status,omitempty"`
+}
+
+// UserStatus is the state of a user
+type UserStatus struct {
+	// Phase is Pending, Active, ExpiringSoon, Suspended, Revoked, Expired or Error
+	Phase   string `json:"phase,omitempty"`
+	Message string `json:"message,omitempty"`
+	// ExpiryTime is when the user's credentials expire, in RFC 3339
+	ExpiryTime string `json:"expiryTime,omitempty"`
+	// CertificateExpiry is when the user's client certificate expires, in RFC 3339
+	CertificateExpiry string `json:"certificateExpiry,omitempty"`
+	// Suspended and Revoked repeat the spec fields of the same names
+	Suspended bool `json:"suspended,omitempty"`
+	Revoked   bool `json:"revoked,omitempty"`
+	// Teams are the Teams listing the user as a member
+	Teams []string `json:"teams,omitempty"`
+	// TimedGrants are the roles bound until a point in time
+	TimedGrants []authv1alpha1.TimedGrant `json:"timedGrants,omitempty"`
+}
+
+// UserList is the response of listing users
+type UserList struct {
+	Items []User `json:"items"`
+}
+
+// RenewRequest asks for new credentials
+type RenewRequest struct {
+	// NewKey also replaces the private key the controller holds for the user
+	NewKey bool `json:"newKey,omitempty"`
+}
+
+// Error is the body of error responses
+type Error struct {
+	Code    int    `json:"code"`
+	Message string `json:"message"`
+}
+
+func (e *Error) Error() string {
+	return e.Message
+}